@@ -0,0 +1,377 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/memodb-io/Acontext/acontext-cli/internal/admin"
+	"github.com/spf13/cobra"
+)
+
+const defaultAPIURL = "http://localhost:8029"
+
+var ProjectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage projects of an Acontext server",
+	Long: `Manage the projects of an Acontext server through its admin API.
+
+This command helps you:
+  - Create a project and its first API key
+  - List projects with their storage and monthly usage
+  - Replace or patch the configs of a project, such as its usage and rate limits
+  - Delete a project and everything it stores
+
+The admin API is authenticated by the root API token of the server, read from
+--root-token, ACONTEXT_ROOT_API_BEARER_TOKEN or ROOT_API_BEARER_TOKEN.
+`,
+}
+
+var (
+	apiURL    string
+	rootToken string
+
+	projectConfigs     string
+	projectConfigsFile string
+	projectKeyName     string
+	projectKeyScopes   []string
+	projectKeyExpires  time.Duration
+	projectListLimit   int
+	projectListCursor  string
+	projectListDesc    bool
+	projectListAll     bool
+	projectDeleteYes   bool
+	projectMerge       bool
+)
+
+var projectCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a project",
+	Long:  "Create a project and its first API key. The key is only shown once.",
+	Args:  cobra.NoArgs,
+	RunE:  runProjectCreate,
+}
+
+var projectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List projects",
+	Long:  "List projects with their storage and usage this month",
+	Args:  cobra.NoArgs,
+	RunE:  runProjectList,
+}
+
+var projectGetCmd = &cobra.Command{
+	Use:   "get <project-id>",
+	Short: "Show a project",
+	Long:  "Show a project, its configs and its usage against its limits as JSON",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProjectGet,
+}
+
+var projectConfigureCmd = &cobra.Command{
+	Use:   "configure <project-id>",
+	Short: "Replace or patch the configs of a project",
+	Long: `Replace the configs of a project with a JSON object, given inline or in a file, e.g.
+
+  acontext project configure <project-id> --configs '{"usage_limits": {"storage_bytes": {"hard": 1073741824}}}'
+
+With --merge the object is a JSON merge patch of the current configs instead: objects are
+merged, null removes a key and other values replace the current ones, e.g.
+
+  acontext project configure <project-id> --merge --configs '{"rate_limits": {"concurrency": 4}, "usage_limits": null}'
+
+Configs are checked against the registered keys; unknown keys are reported, or refused if
+the server is set to reject them.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: runProjectConfigure,
+}
+
+var projectDeleteCmd = &cobra.Command{
+	Use:   "delete <project-id>",
+	Short: "Delete a project",
+	Long:  "Delete a project. Its keys stop working at once; the server removes its data and stored files in the background.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runProjectDelete,
+}
+
+func init() {
+	ProjectCmd.PersistentFlags().StringVar(&apiURL, "api-url", "", "Base URL of the Acontext API (default $ACONTEXT_API_URL or "+defaultAPIURL+")")
+	ProjectCmd.PersistentFlags().StringVar(&rootToken, "root-token", "", "Root API bearer token (default $ACONTEXT_ROOT_API_BEARER_TOKEN or $ROOT_API_BEARER_TOKEN)")
+
+	projectCreateCmd.Flags().StringVar(&projectConfigs, "configs", "", "Configs of the project as a JSON object")
+	projectCreateCmd.Flags().StringVar(&projectConfigsFile, "configs-file", "", "File holding the configs of the project as a JSON object")
+	projectCreateCmd.Flags().StringVar(&projectKeyName, "key-name", "default", "Name of the first API key")
+	projectCreateCmd.Flags().StringSliceVar(&projectKeyScopes, "key-scopes", nil, "Scopes of the first API key: read_only, sessions, disks (default full access)")
+	projectCreateCmd.Flags().DurationVar(&projectKeyExpires, "key-expires-in", 0, "Lifetime of the first API key, e.g. 720h (default never expires)")
+
+	projectListCmd.Flags().IntVar(&projectListLimit, "limit", 20, "Projects per page, at most 200")
+	projectListCmd.Flags().StringVar(&projectListCursor, "cursor", "", "Cursor of the page to list, from a previous listing")
+	projectListCmd.Flags().BoolVar(&projectListDesc, "desc", false, "List the newest projects first")
+	projectListCmd.Flags().BoolVar(&projectListAll, "all", false, "List every page")
+
+	projectConfigureCmd.Flags().StringVar(&projectConfigs, "configs", "", "Configs of the project as a JSON object")
+	projectConfigureCmd.Flags().StringVar(&projectConfigsFile, "configs-file", "", "File holding the configs of the project as a JSON object")
+	projectConfigureCmd.Flags().BoolVar(&projectMerge, "merge", false, "Merge the configs into the current ones as a JSON merge patch")
+
+	projectDeleteCmd.Flags().BoolVarP(&projectDeleteYes, "yes", "y", false, "Delete without asking for confirmation")
+
+	ProjectCmd.AddCommand(projectCreateCmd)
+	ProjectCmd.AddCommand(projectListCmd)
+	ProjectCmd.AddCommand(projectGetCmd)
+	ProjectCmd.AddCommand(projectConfigureCmd)
+	ProjectCmd.AddCommand(projectDeleteCmd)
+}
+
+// newAdminClient builds a client from the flags, falling back to the environment
+func newAdminClient() (*admin.Client, error) {
+	url := firstNonEmpty(apiURL, os.Getenv("ACONTEXT_API_URL"), defaultAPIURL)
+	token := firstNonEmpty(rootToken, os.Getenv("ACONTEXT_ROOT_API_BEARER_TOKEN"), os.Getenv("ROOT_API_BEARER_TOKEN"))
+	if token == "" {
+		return nil, fmt.Errorf("root API token is required: pass --root-token or set ACONTEXT_ROOT_API_BEARER_TOKEN")
+	}
+	return admin.NewClient(url, token), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseConfigs reads a JSON object given inline or in a file; it returns nil when neither is given
+func parseConfigs(inline, file string) (map[string]interface{}, error) {
+	if inline != "" && file != "" {
+		return nil, fmt.Errorf("--configs and --configs-file cannot be used together")
+	}
+	raw := []byte(inline)
+	if file != "" {
+		var err error
+		if raw, err = os.ReadFile(file); err != nil {
+			return nil, fmt.Errorf("failed to read configs file: %w", err)
+		}
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var configs map[string]interface{}
+	if err := json.Unmarshal(raw, &configs); err != nil {
+		return nil, fmt.Errorf("configs must be a JSON object: %w", err)
+	}
+	if configs == nil {
+		return nil, fmt.Errorf("configs must be a JSON object")
+	}
+	return configs, nil
+}
+
+func runProjectCreate(cmd *cobra.Command, args []string) error {
+	configs, err := parseConfigs(projectConfigs, projectConfigsFile)
+	if err != nil {
+		return err
+	}
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	out, err := client.CreateProject(cmd.Context(), admin.CreateProjectRequest{
+		Configs: configs,
+		Key: &admin.CreateKeyRequest{
+			Name:      projectKeyName,
+			Scopes:    projectKeyScopes,
+			ExpiresIn: int64(projectKeyExpires.Seconds()),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create project: %w", err)
+	}
+
+	fmt.Println("✅ Project created")
+	fmt.Printf("   Project ID: %s\n", out.Project.ID)
+	if out.Key.Key != nil {
+		fmt.Printf("   API key:    %s (%s)\n", out.Key.Token, out.Key.Key.Name)
+	}
+	fmt.Println()
+	fmt.Println("⚠️  Store the API key now, it is not shown again")
+	return nil
+}
+
+func runProjectList(cmd *cobra.Command, args []string) error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tSTORAGE\tMESSAGES (MONTH)\tTOKENS (MONTH)\tSTATUS")
+
+	cursor := projectListCursor
+	for {
+		page, err := client.ListProjects(cmd.Context(), admin.ListProjectsOptions{
+			Limit:    projectListLimit,
+			Cursor:   cursor,
+			TimeDesc: projectListDesc,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list projects: %w", err)
+		}
+		for _, p := range page.Items {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				p.ID,
+				p.CreatedAt.Local().Format("2006-01-02 15:04"),
+				formatStorage(p.Usage),
+				formatLimit(p.Usage, "monthly_messages"),
+				formatLimit(p.Usage, "monthly_tokens"),
+				projectStatus(p),
+			)
+		}
+		if !page.HasMore {
+			break
+		}
+		if !projectListAll {
+			_ = w.Flush()
+			fmt.Printf("\nMore projects: acontext project list --cursor %s\n", page.NextCursor)
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+	return w.Flush()
+}
+
+func runProjectGet(cmd *cobra.Command, args []string) error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+	project, err := client.GetProject(cmd.Context(), args[0])
+	if err != nil {
+		return fmt.Errorf("failed to get project: %w", err)
+	}
+	return printJSON(project)
+}
+
+func runProjectConfigure(cmd *cobra.Command, args []string) error {
+	configs, err := parseConfigs(projectConfigs, projectConfigsFile)
+	if err != nil {
+		return err
+	}
+	if configs == nil {
+		return fmt.Errorf("--configs or --configs-file is required")
+	}
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	if projectMerge {
+		out, err := client.PatchConfigs(cmd.Context(), args[0], configs)
+		if err != nil {
+			return fmt.Errorf("failed to update configs: %w", err)
+		}
+		fmt.Println("✅ Configs updated")
+		for _, w := range out.Warnings {
+			fmt.Printf("⚠️  %s: %s\n", w.Key, w.Message)
+		}
+		return printJSON(out.Configs)
+	}
+
+	project, err := client.UpdateConfigs(cmd.Context(), args[0], configs)
+	if err != nil {
+		return fmt.Errorf("failed to update configs: %w", err)
+	}
+	fmt.Println("✅ Configs updated")
+	return printJSON(project.Configs)
+}
+
+func runProjectDelete(cmd *cobra.Command, args []string) error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+
+	if !projectDeleteYes {
+		confirmed := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Delete project %s and everything it stores? This cannot be undone.", args[0]),
+			Default: false,
+		}
+		if err := survey.AskOne(prompt, &confirmed); err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	if _, err := client.DeleteProject(cmd.Context(), args[0]); err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	fmt.Println("✅ Project deleted; its data is removed in the background")
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func projectStatus(p *admin.Project) string {
+	if p.DeleteRequestedAt != nil {
+		return "deleting"
+	}
+	if p.Configs["__default_init_project__"] == true {
+		return "active (default)"
+	}
+	return "active"
+}
+
+func formatStorage(u *admin.UsageSummary) string {
+	if u == nil {
+		return "-"
+	}
+	s := formatBytes(u.Storage.Bytes)
+	if l, ok := u.Limit("storage_bytes"); ok && l.Hard > 0 {
+		s += " / " + formatBytes(l.Hard)
+	}
+	return s
+}
+
+func formatLimit(u *admin.UsageSummary, name string) string {
+	if u == nil {
+		return "-"
+	}
+	l, ok := u.Limit(name)
+	if !ok {
+		return "-"
+	}
+	if l.Hard > 0 {
+		return fmt.Sprintf("%d / %d", l.Used, l.Hard)
+	}
+	return fmt.Sprintf("%d", l.Used)
+}
+
+// formatBytes renders a size in binary units, e.g. 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "configs.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"rate_limits": {"project": {"rate": 10}}}`), 0o600))
+
+	tests := []struct {
+		name    string
+		inline  string
+		file    string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{name: "neither", want: nil},
+		{name: "inline", inline: `{"a": 1}`, want: map[string]interface{}{"a": float64(1)}},
+		{name: "file", file: file, want: map[string]interface{}{"rate_limits": map[string]interface{}{"project": map[string]interface{}{"rate": float64(10)}}}},
+		{name: "both", inline: `{}`, file: file, wantErr: true},
+		{name: "not an object", inline: `[1]`, wantErr: true},
+		{name: "null", inline: `null`, wantErr: true},
+		{name: "missing file", file: filepath.Join(t.TempDir(), "missing.json"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseConfigs(tt.inline, tt.file)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "1.0 GiB", formatBytes(1<<30))
+}
@@ -0,0 +1,299 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const timeout = 30 * time.Second
+
+// Client calls the admin API of an Acontext server, which is authenticated by the root API token
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the server at baseURL, e.g. http://localhost:8029
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// APIError is an error answered by the server. Code is the machine-readable code of the
+// error, e.g. project_not_found; servers before error codes leave it empty.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	TraceID string
+	// Problems lists what is wrong with refused configs
+	Problems []ConfigProblem
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server answered %d %s", e.Status, http.StatusText(e.Status))
+	}
+	msg := e.Message
+	for _, p := range e.Problems {
+		msg += fmt.Sprintf("; %s: %s", p.Key, p.Message)
+	}
+	if e.TraceID != "" {
+		msg += " (trace " + e.TraceID + ")"
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("server answered %d %s: %s", e.Status, e.Code, msg)
+	}
+	return fmt.Sprintf("server answered %d: %s", e.Status, msg)
+}
+
+// StorageUsage is what the assets of a project take up
+type StorageUsage struct {
+	Bytes  int64 `json:"bytes"`
+	Assets int64 `json:"assets"`
+}
+
+// UsageLimitStatus is the usage of a project against one of its limits
+type UsageLimitStatus struct {
+	Name         string `json:"name"`
+	Used         int64  `json:"used"`
+	Soft         int64  `json:"soft"`
+	Hard         int64  `json:"hard"`
+	SoftExceeded bool   `json:"soft_exceeded"`
+	HardExceeded bool   `json:"hard_exceeded"`
+}
+
+type UsageSummary struct {
+	Storage StorageUsage       `json:"storage"`
+	Limits  []UsageLimitStatus `json:"limits"`
+}
+
+// Limit returns the status of the named limit, e.g. "monthly_messages"
+func (u *UsageSummary) Limit(name string) (UsageLimitStatus, bool) {
+	for _, l := range u.Limits {
+		if l.Name == name {
+			return l, true
+		}
+	}
+	return UsageLimitStatus{}, false
+}
+
+type Project struct {
+	ID                string                 `json:"id"`
+	Configs           map[string]interface{} `json:"configs"`
+	DeleteRequestedAt *time.Time             `json:"delete_requested_at,omitempty"`
+	CreatedAt         time.Time              `json:"created_at"`
+	UpdatedAt         time.Time              `json:"updated_at"`
+	// Usage is only returned when listing or getting projects
+	Usage *UsageSummary `json:"usage,omitempty"`
+}
+
+type APIKey struct {
+	ID        string     `json:"id"`
+	ProjectID string     `json:"project_id"`
+	Name      string     `json:"name"`
+	Hint      string     `json:"hint"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type CreateKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes,omitempty"`
+	ExpiresIn int64    `json:"expires_in,omitempty"`
+}
+
+type CreateProjectRequest struct {
+	Configs map[string]interface{} `json:"configs,omitempty"`
+	Key     *CreateKeyRequest      `json:"key,omitempty"`
+}
+
+type CreateProjectResult struct {
+	Project *Project `json:"project"`
+	Key     struct {
+		Key   *APIKey `json:"key"`
+		Token string  `json:"token"`
+	} `json:"key"`
+}
+
+type ListProjectsOptions struct {
+	Limit    int
+	Cursor   string
+	TimeDesc bool
+}
+
+type ProjectList struct {
+	Items      []*Project `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	HasMore    bool       `json:"has_more"`
+}
+
+// CreateProject creates a project and its first API key, whose token is only returned here
+func (c *Client) CreateProject(ctx context.Context, req CreateProjectRequest) (*CreateProjectResult, error) {
+	var out CreateProjectResult
+	if err := c.do(ctx, http.MethodPost, "/api/v1/admin/project", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) ListProjects(ctx context.Context, opts ListProjectsOptions) (*ProjectList, error) {
+	q := url.Values{}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		q.Set("cursor", opts.Cursor)
+	}
+	if opts.TimeDesc {
+		q.Set("time_desc", "true")
+	}
+	path := "/api/v1/admin/project"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var out ProjectList
+	if err := c.do(ctx, http.MethodGet, path, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) GetProject(ctx context.Context, projectID string) (*Project, error) {
+	var out Project
+	if err := c.do(ctx, http.MethodGet, "/api/v1/admin/project/"+url.PathEscape(projectID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateConfigs replaces the configs of a project
+func (c *Client) UpdateConfigs(ctx context.Context, projectID string, configs map[string]interface{}) (*Project, error) {
+	body := map[string]interface{}{"configs": configs}
+	var out Project
+	if err := c.do(ctx, http.MethodPut, "/api/v1/admin/project/"+url.PathEscape(projectID)+"/configs", body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfigProblem is what is wrong with, or unknown about, one key of a configs object
+type ConfigProblem struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// ConfigsUpdate is the configs a project was left with by a merge patch
+type ConfigsUpdate struct {
+	ID       string                 `json:"id"`
+	Configs  map[string]interface{} `json:"configs"`
+	Warnings []ConfigProblem        `json:"warnings,omitempty"`
+}
+
+// PatchConfigs applies a JSON merge patch to the configs of a project: keys set to nil are
+// removed, objects are merged and other values replace the current ones
+func (c *Client) PatchConfigs(ctx context.Context, projectID string, patch map[string]interface{}) (*ConfigsUpdate, error) {
+	var out ConfigsUpdate
+	if err := c.do(ctx, http.MethodPatch, "/api/v1/admin/project/"+url.PathEscape(projectID)+"/configs", patch, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteProject deletes a project. The server removes its data in the background.
+func (c *Client) DeleteProject(ctx context.Context, projectID string) (*Project, error) {
+	var out Project
+	if err := c.do(ctx, http.MethodDelete, "/api/v1/admin/project/"+url.PathEscape(projectID), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// do sends a request and decodes the data of the response into out
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", c.baseURL, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var envelope struct {
+		Data  json.RawMessage `json:"data"`
+		Msg   string          `json:"msg"`
+		Error string          `json:"error"`
+	}
+	decodeErr := json.Unmarshal(raw, &envelope)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &APIError{Status: resp.StatusCode}
+		// Errors are problem details (RFC 7807); older servers answer the envelope
+		var problem struct {
+			Code     any             `json:"code"`
+			Detail   string          `json:"detail"`
+			TraceID  string          `json:"trace_id"`
+			Problems []ConfigProblem `json:"problems"`
+		}
+		if decodeErr == nil && json.Unmarshal(raw, &problem) == nil {
+			apiErr.Code, _ = problem.Code.(string)
+			apiErr.Message = envelope.Msg
+			if problem.Detail != "" {
+				apiErr.Message = problem.Detail
+			}
+			if envelope.Error != "" {
+				apiErr.Message += " (" + envelope.Error + ")"
+			}
+			apiErr.TraceID = problem.TraceID
+			apiErr.Problems = problem.Problems
+		}
+		return apiErr
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("failed to decode response: %w", decodeErr)
+	}
+	if out == nil || len(envelope.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientListProjects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer root-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "/api/v1/admin/project", r.URL.Path)
+		assert.Equal(t, "5", r.URL.Query().Get("limit"))
+		assert.Equal(t, "true", r.URL.Query().Get("time_desc"))
+		_, _ = w.Write([]byte(`{"code":0,"msg":"","data":{"items":[{"id":"p1","configs":{},"usage":{"storage":{"bytes":2048,"assets":1},"limits":[{"name":"monthly_messages","used":3,"hard":10}]}}],"next_cursor":"c","has_more":true}}`))
+	}))
+	defer server.Close()
+
+	list, err := NewClient(server.URL+"/", "root-token").ListProjects(context.Background(), ListProjectsOptions{Limit: 5, TimeDesc: true})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.True(t, list.HasMore)
+	assert.Equal(t, "c", list.NextCursor)
+	assert.Equal(t, int64(2048), list.Items[0].Usage.Storage.Bytes)
+
+	limit, ok := list.Items[0].Usage.Limit("monthly_messages")
+	require.True(t, ok)
+	assert.Equal(t, int64(3), limit.Used)
+	_, ok = list.Items[0].Usage.Limit("monthly_tokens")
+	assert.False(t, ok)
+}
+
+func TestClientUpdateConfigs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/api/v1/admin/project/p1/configs", r.URL.Path)
+		var body map[string]map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{"a": float64(1)}, body["configs"])
+		_, _ = w.Write([]byte(`{"code":0,"msg":"","data":{"id":"p1","configs":{"a":1}}}`))
+	}))
+	defer server.Close()
+
+	project, err := NewClient(server.URL, "root-token").UpdateConfigs(context.Background(), "p1", map[string]interface{}{"a": 1})
+	require.NoError(t, err)
+	assert.Equal(t, "p1", project.ID)
+	assert.Equal(t, float64(1), project.Configs["a"])
+}
+
+func TestClientPatchConfigs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "/api/v1/admin/project/p1/configs", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{"a": nil, "mode": "fast"}, body)
+		_, _ = w.Write([]byte(`{"code":0,"msg":"","data":{"scope":"project","id":"p1","configs":{"mode":"fast"},"warnings":[{"key":"mode","message":"unknown config key"}]}}`))
+	}))
+	defer server.Close()
+
+	out, err := NewClient(server.URL, "root-token").PatchConfigs(context.Background(), "p1", map[string]interface{}{"a": nil, "mode": "fast"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"mode": "fast"}, out.Configs)
+	require.Len(t, out.Warnings, 1)
+	assert.Equal(t, "mode", out.Warnings[0].Key)
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantMsg string
+	}{
+		{
+			name:    "error envelope",
+			status:  http.StatusConflict,
+			body:    `{"code":409,"msg":"the default project cannot be deleted"}`,
+			wantMsg: "server answered 409: the default project cannot be deleted",
+		},
+		{
+			name:    "problem",
+			status:  http.StatusConflict,
+			body:    `{"type":"urn:acontext:error:default_project","title":"The default project cannot be changed this way","status":409,"detail":"the default project cannot be deleted","code":"default_project","trace_id":"4bf92f35"}`,
+			wantMsg: "server answered 409 default_project: the default project cannot be deleted (trace 4bf92f35)",
+		},
+		{
+			name:    "problem with config problems",
+			status:  http.StatusUnprocessableEntity,
+			body:    `{"title":"Invalid configs","status":422,"detail":"invalid configs","code":"invalid_configs","problems":[{"key":"mdoe","message":"unknown config key"}]}`,
+			wantMsg: "server answered 422 invalid_configs: invalid configs; mdoe: unknown config key",
+		},
+		{
+			name:    "not json",
+			status:  http.StatusBadGateway,
+			body:    `<html>bad gateway</html>`,
+			wantMsg: "server answered 502 Bad Gateway",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewClient(server.URL, "root-token").DeleteProject(context.Background(), "p1")
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.status, apiErr.Status)
+			assert.Equal(t, tt.wantMsg, err.Error())
+		})
+	}
+}
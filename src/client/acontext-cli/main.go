@@ -144,6 +144,7 @@ Get started by running: acontext create
 		fmt.Println("Quick Commands:")
 		fmt.Println("  acontext create     Create a new project")
 		fmt.Println("  acontext docker     Manage Docker services (up/down/status/logs/env)")
+		fmt.Println("  acontext project    Manage projects of a server (create/list/configure/delete)")
 		fmt.Println("  acontext version    Show version information")
 		fmt.Println("  acontext upgrade    Upgrade to the latest version")
 		fmt.Println("  acontext help       Show help information")
@@ -156,6 +157,7 @@ func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(cmd.CreateCmd)
 	rootCmd.AddCommand(cmd.DockerCmd)
+	rootCmd.AddCommand(cmd.ProjectCmd)
 	rootCmd.AddCommand(cmd.UpgradeCmd)
 }
 
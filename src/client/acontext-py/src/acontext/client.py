@@ -9,6 +9,7 @@ from typing import Any, BinaryIO
 import httpx
 
 from ._constants import DEFAULT_BASE_URL, DEFAULT_USER_AGENT
+from .error_codes import api_error_class
 from .errors import APIError, TransportError
 from .messages import MessagePart as MessagePart
 from .uploads import FileUpload as FileUpload
@@ -174,7 +175,8 @@ class AcontextClient:
         content_type = response.headers.get("content-type", "")
 
         parsed: Mapping[str, Any] | None
-        if "application/json" in content_type:
+        # Errors are application/problem+json
+        if "json" in content_type:
             try:
                 parsed = response.json()  # dict
             except ValueError:
@@ -186,21 +188,31 @@ class AcontextClient:
             message = response.reason_phrase
             payload: Mapping[str, Any] | None = parsed
             code: int | None = None
+            error_code: str | None = None
             error: str | None = None
+            trace_id: str | None = response.headers.get("x-trace-id")
             if payload and isinstance(payload, Mapping):
-                message = str(payload.get("msg") or payload.get("message") or message)
+                message = str(
+                    payload.get("detail")
+                    or payload.get("title")
+                    or payload.get("msg")
+                    or payload.get("message")
+                    or message
+                )
                 error = payload.get("error")
-                try:
-                    code_val = payload.get("code")
-                    if isinstance(code_val, int):
-                        code = code_val
-                except Exception:  # pragma: no cover - defensive
-                    code = None
-            raise APIError(
+                trace_id = payload.get("trace_id") or trace_id
+                code_val = payload.get("code")
+                if isinstance(code_val, str):
+                    error_code = code_val
+                elif isinstance(code_val, int):
+                    code = code_val
+            raise api_error_class(error_code)(
                 status_code=response.status_code,
                 code=code,
+                error_code=error_code,
                 message=message,
                 error=error,
+                trace_id=trace_id,
                 payload=payload,
             )
 
@@ -14,27 +14,40 @@ class APIError(AcontextError):
     """
     Raised when the server returns an error response.
 
+    Error responses are problem details (RFC 7807) that name a stable ``error_code``; the
+    exception is an instance of the class generated for that code in ``acontext.error_codes``,
+    e.g. ``SessionNotFoundError``.
+
     Attributes:
         status_code: HTTP status code returned by the server.
-        code: Optional application-level error code from the payload.
+        code: Optional numeric application-level code from the payload.
+        error_code: Machine-readable code of the error, e.g. ``"session_not_found"``.
         message: Human readable message if provided by the server.
         error: Raw error field from the payload in non-release environments.
+        trace_id: ID of the server trace of the request, to quote when reporting an issue.
         payload: The full parsed JSON payload.
     """
 
+    error_code: str | None = None
+
     def __init__(
         self,
         *,
         status_code: int,
         code: int | None = None,
+        error_code: str | None = None,
         message: str | None = None,
         error: str | None = None,
+        trace_id: str | None = None,
         payload: Mapping[str, Any] | None = None,
     ) -> None:
         self.status_code = status_code
         self.code = code
+        if error_code is not None:
+            self.error_code = error_code
         self.message = message
         self.error = error
+        self.trace_id = trace_id
         self.payload = payload
         details = message or error or "API request failed"
         super().__init__(f"{status_code}: {details}")
@@ -55,13 +55,47 @@ class AsyncDisksAPI:
         return Disk.model_validate(data)
 
     async def delete(self, disk_id: str) -> None:
-        """Delete a disk by its ID.
+        """Move a disk to the trash.
+        
+        It can be restored with ``restore`` until the server purges it, by default
+        30 days later.
         
         Args:
             disk_id: The UUID of the disk to delete.
         """
         await self._requester.request("DELETE", f"/disk/{disk_id}")
 
+    async def list_trash(
+        self,
+        *,
+        limit: int | None = None,
+        cursor: str | None = None,
+    ) -> ListDisksOutput:
+        """List the disks in the trash, the most recently deleted first.
+        
+        Args:
+            limit: Maximum number of disks to return. Defaults to None.
+            cursor: Cursor for pagination. Defaults to None.
+        
+        Returns:
+            ListDisksOutput containing the deleted disks and pagination information.
+        """
+        params = build_params(limit=limit, cursor=cursor)
+        data = await self._requester.request("GET", "/disk/trash", params=params or None)
+        return ListDisksOutput.model_validate(data)
+
+    async def restore(self, disk_id: str) -> Disk:
+        """Take a deleted disk out of the trash, with its artifacts.
+        
+        Args:
+            disk_id: The UUID of the disk to restore.
+        
+        Returns:
+            The restored Disk object.
+        """
+        data = await self._requester.request("POST", f"/disk/{disk_id}/restore")
+        return Disk.model_validate(data)
+
 
 class AsyncDiskArtifactsAPI:
     def __init__(self, requester: AsyncRequesterProtocol) -> None:
@@ -96,13 +96,47 @@ class SessionsAPI:
         return Session.model_validate(data)
 
     def delete(self, session_id: str) -> None:
-        """Delete a session by its ID.
+        """Move a session to the trash.
+
+        It can be restored with ``restore`` until the server purges it, by default
+        30 days later.
 
         Args:
             session_id: The UUID of the session to delete.
         """
         self._requester.request("DELETE", f"/session/{session_id}")
 
+    def list_trash(
+        self,
+        *,
+        limit: int | None = None,
+        cursor: str | None = None,
+    ) -> ListSessionsOutput:
+        """List the sessions in the trash, the most recently deleted first.
+
+        Args:
+            limit: Maximum number of sessions to return. Defaults to None.
+            cursor: Cursor for pagination. Defaults to None.
+
+        Returns:
+            ListSessionsOutput containing the deleted sessions and pagination information.
+        """
+        params = build_params(limit=limit, cursor=cursor)
+        data = self._requester.request("GET", "/session/trash", params=params or None)
+        return ListSessionsOutput.model_validate(data)
+
+    def restore(self, session_id: str) -> Session:
+        """Take a deleted session out of the trash, with its messages and tasks.
+
+        Args:
+            session_id: The UUID of the session to restore.
+
+        Returns:
+            The restored Session object.
+        """
+        data = self._requester.request("POST", f"/session/{session_id}/restore")
+        return Session.model_validate(data)
+
     def update_configs(
         self,
         session_id: str,
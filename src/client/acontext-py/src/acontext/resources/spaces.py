@@ -57,13 +57,47 @@ class SpacesAPI:
         return Space.model_validate(data)
 
     def delete(self, space_id: str) -> None:
-        """Delete a space by its ID.
+        """Move a space to the trash.
+
+        It can be restored with ``restore`` until the server purges it, by default
+        30 days later.
 
         Args:
             space_id: The UUID of the space to delete.
         """
         self._requester.request("DELETE", f"/space/{space_id}")
 
+    def list_trash(
+        self,
+        *,
+        limit: int | None = None,
+        cursor: str | None = None,
+    ) -> ListSpacesOutput:
+        """List the spaces in the trash, the most recently deleted first.
+
+        Args:
+            limit: Maximum number of spaces to return. Defaults to None.
+            cursor: Cursor for pagination. Defaults to None.
+
+        Returns:
+            ListSpacesOutput containing the deleted spaces and pagination information.
+        """
+        params = build_params(limit=limit, cursor=cursor)
+        data = self._requester.request("GET", "/space/trash", params=params or None)
+        return ListSpacesOutput.model_validate(data)
+
+    def restore(self, space_id: str) -> Space:
+        """Take a deleted space out of the trash, with its blocks and experience confirmations.
+
+        Args:
+            space_id: The UUID of the space to restore.
+
+        Returns:
+            The restored Space object.
+        """
+        data = self._requester.request("POST", f"/space/{space_id}/restore")
+        return Space.model_validate(data)
+
     def update_configs(
         self,
         space_id: str,
@@ -12,6 +12,9 @@ class Disk(BaseModel):
     project_id: str = Field(..., description="Project UUID")
     created_at: str = Field(..., description="ISO 8601 formatted creation timestamp")
     updated_at: str = Field(..., description="ISO 8601 formatted update timestamp")
+    deleted_at: str | None = Field(
+        None, description="ISO 8601 formatted deletion timestamp, set while in the trash"
+    )
 
 
 class ListDisksOutput(BaseModel):
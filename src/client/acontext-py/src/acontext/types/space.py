@@ -15,6 +15,9 @@ class Space(BaseModel):
     )
     created_at: str = Field(..., description="ISO 8601 formatted creation timestamp")
     updated_at: str = Field(..., description="ISO 8601 formatted update timestamp")
+    deleted_at: str | None = Field(
+        None, description="ISO 8601 formatted deletion timestamp, set while in the trash"
+    )
 
 
 class ListSpacesOutput(BaseModel):
@@ -8,6 +8,7 @@ import pytest
 
 from acontext.client import AcontextClient, FileUpload  # noqa: E402
 from acontext.messages import build_acontext_message  # noqa: E402
+from acontext.error_codes import SessionNotFoundError  # noqa: E402
 from acontext.errors import APIError, TransportError  # noqa: E402
 
 
@@ -58,6 +59,32 @@ def test_handle_response_app_code_error() -> None:
     assert ctx.value.status_code == 200
 
 
+def test_handle_response_problem() -> None:
+    request = httpx.Request("GET", "https://api.acontext.test/session/1")
+    resp = httpx.Response(
+        404,
+        content=b'{"type":"urn:acontext:error:session_not_found","title":"Session not found",'
+        b'"status":404,"detail":"session not found","code":"session_not_found","trace_id":"abc"}',
+        headers={"content-type": "application/problem+json"},
+        request=request,
+    )
+    with pytest.raises(SessionNotFoundError) as ctx:
+        AcontextClient._handle_response(resp, unwrap=True)
+    assert isinstance(ctx.value, APIError)
+    assert ctx.value.error_code == "session_not_found"
+    assert ctx.value.message == "session not found"
+    assert ctx.value.trace_id == "abc"
+    assert ctx.value.status_code == 404
+
+
+def test_handle_response_unknown_error_code() -> None:
+    resp = make_response(409, {"code": "something_new", "detail": "nope"})
+    with pytest.raises(APIError) as ctx:
+        AcontextClient._handle_response(resp, unwrap=True)
+    assert type(ctx.value) is APIError
+    assert ctx.value.error_code == "something_new"
+
+
 @patch("acontext.client.httpx.Client.request")
 def test_request_transport_error(mock_request) -> None:
     exc = httpx.ConnectError(
@@ -763,6 +790,28 @@ def test_disks_create_hits_disk_endpoint(mock_request, client: AcontextClient) -
     assert result.id == "disk"
 
 
+@patch("acontext.client.AcontextClient.request")
+def test_disks_restore_hits_restore_endpoint(
+    mock_request, client: AcontextClient
+) -> None:
+    mock_request.return_value = {
+        "id": "disk-id",
+        "project_id": "project-id",
+        "created_at": "2024-01-01T00:00:00Z",
+        "updated_at": "2024-01-01T00:00:00Z",
+        "deleted_at": None,
+    }
+
+    result = client.disks.restore("disk-id")
+
+    mock_request.assert_called_once()
+    args, _ = mock_request.call_args
+    method, path = args
+    assert method == "POST"
+    assert path == "/disk/disk-id/restore"
+    assert result.deleted_at is None
+
+
 def test_artifacts_aliases_disk_artifacts(client: AcontextClient) -> None:
     assert client.artifacts is client.disks.artifacts
 
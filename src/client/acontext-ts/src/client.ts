@@ -3,6 +3,7 @@
  */
 
 import { APIError, TransportError } from './errors';
+import { createAPIError } from './error-codes';
 import { BlocksAPI } from './resources/blocks';
 import { DisksAPI } from './resources/disks';
 import { SessionsAPI } from './resources/sessions';
@@ -225,7 +226,8 @@ export class AcontextClient implements RequesterProtocol {
     const contentType = response.headers.get('content-type') || '';
 
     let parsed: unknown = null;
-    if (contentType.includes('application/json')) {
+    // Errors are application/problem+json
+    if (contentType.includes('json')) {
       try {
         parsed = await response.json();
       } catch {
@@ -237,22 +239,31 @@ export class AcontextClient implements RequesterProtocol {
       const payload = parsed as Record<string, unknown> | null;
       let message = response.statusText;
       let code: number | undefined;
+      let errorCode: string | undefined;
       let error: string | undefined;
+      let traceId = response.headers.get('x-trace-id') || undefined;
 
       if (payload && typeof payload === 'object') {
-        message = String(payload.msg || payload.message || message);
+        message = String(
+          payload.detail || payload.title || payload.msg || payload.message || message
+        );
         error = payload.error as string | undefined;
+        traceId = (payload.trace_id as string | undefined) || traceId;
         const codeVal = payload.code;
-        if (typeof codeVal === 'number') {
+        if (typeof codeVal === 'string') {
+          errorCode = codeVal;
+        } else if (typeof codeVal === 'number') {
           code = codeVal;
         }
       }
 
-      throw new APIError({
+      throw createAPIError({
         statusCode: response.status,
         code,
+        errorCode,
         message,
         error,
+        traceId,
         payload,
       });
     }
@@ -13,30 +13,45 @@ export class AcontextError extends Error {
   }
 }
 
+export interface APIErrorOptions {
+  statusCode: number;
+  /** Numeric application-level code of the payload */
+  code?: number;
+  /** Machine-readable code of the error, e.g. `session_not_found` */
+  errorCode?: string;
+  message?: string;
+  error?: string;
+  /** ID of the server trace of the request, to quote when reporting an issue */
+  traceId?: string;
+  payload?: unknown;
+}
+
 /**
  * Raised when the server returns an error response.
+ *
+ * Error responses are problem details (RFC 7807) that name a stable `errorCode`; the error is
+ * an instance of the class generated for that code in `error-codes.ts`, e.g.
+ * `SessionNotFoundError`.
  */
 export class APIError extends AcontextError {
   statusCode: number;
   code?: number;
+  errorCode?: string;
   message: string;
   error?: string;
+  traceId?: string;
   payload?: unknown;
 
-  constructor(options: {
-    statusCode: number;
-    code?: number;
-    message?: string;
-    error?: string;
-    payload?: unknown;
-  }) {
+  constructor(options: APIErrorOptions) {
     const details = options.message || options.error || 'API request failed';
     super(`${options.statusCode}: ${details}`);
     this.name = 'APIError';
     this.statusCode = options.statusCode;
     this.code = options.code;
+    this.errorCode = options.errorCode;
     this.message = details;
     this.error = options.error;
+    this.traceId = options.traceId;
     this.payload = options.payload;
     Object.setPrototypeOf(this, APIError.prototype);
   }
@@ -9,6 +9,8 @@ export { FileUpload } from './uploads';
 export { MessagePart, AcontextMessage, buildAcontextMessage } from './messages';
 
 export { APIError, TransportError, AcontextError } from './errors';
+export type { APIErrorOptions } from './errors';
+export * from './error-codes';
 
 export * from './types';
 export * from './resources';
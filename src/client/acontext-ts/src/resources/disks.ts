@@ -50,9 +50,38 @@ export class DisksAPI {
     return DiskSchema.parse(data);
   }
 
+  /**
+   * Move a disk to the trash. It can be restored with `restore` until the server
+   * purges it, by default 30 days later.
+   */
   async delete(diskId: string): Promise<void> {
     await this.requester.request('DELETE', `/disk/${diskId}`);
   }
+
+  /**
+   * List the disks in the trash, the most recently deleted first.
+   */
+  async listTrash(options?: {
+    limit?: number | null;
+    cursor?: string | null;
+  }): Promise<ListDisksOutput> {
+    const params = buildParams({
+      limit: options?.limit ?? null,
+      cursor: options?.cursor ?? null,
+    });
+    const data = await this.requester.request('GET', '/disk/trash', {
+      params: Object.keys(params).length > 0 ? params : undefined,
+    });
+    return ListDisksOutputSchema.parse(data);
+  }
+
+  /**
+   * Take a deleted disk out of the trash, with its artifacts.
+   */
+  async restore(diskId: string): Promise<Disk> {
+    const data = await this.requester.request('POST', `/disk/${diskId}/restore`);
+    return DiskSchema.parse(data);
+  }
 }
 
 export class DiskArtifactsAPI {
@@ -76,10 +76,39 @@ export class SessionsAPI {
     return SessionSchema.parse(data);
   }
 
+  /**
+   * Move a session to the trash. It can be restored with `restore` until the server
+   * purges it, by default 30 days later.
+   */
   async delete(sessionId: string): Promise<void> {
     await this.requester.request('DELETE', `/session/${sessionId}`);
   }
 
+  /**
+   * List the sessions in the trash, the most recently deleted first.
+   */
+  async listTrash(options?: {
+    limit?: number | null;
+    cursor?: string | null;
+  }): Promise<ListSessionsOutput> {
+    const params = buildParams({
+      limit: options?.limit ?? null,
+      cursor: options?.cursor ?? null,
+    });
+    const data = await this.requester.request('GET', '/session/trash', {
+      params: Object.keys(params).length > 0 ? params : undefined,
+    });
+    return ListSessionsOutputSchema.parse(data);
+  }
+
+  /**
+   * Take a deleted session out of the trash, with its messages and tasks.
+   */
+  async restore(sessionId: string): Promise<Session> {
+    const data = await this.requester.request('POST', `/session/${sessionId}/restore`);
+    return SessionSchema.parse(data);
+  }
+
   async updateConfigs(
     sessionId: string,
     options: {
@@ -49,10 +49,39 @@ export class SpacesAPI {
     return SpaceSchema.parse(data);
   }
 
+  /**
+   * Move a space to the trash. It can be restored with `restore` until the server
+   * purges it, by default 30 days later.
+   */
   async delete(spaceId: string): Promise<void> {
     await this.requester.request('DELETE', `/space/${spaceId}`);
   }
 
+  /**
+   * List the spaces in the trash, the most recently deleted first.
+   */
+  async listTrash(options?: {
+    limit?: number | null;
+    cursor?: string | null;
+  }): Promise<ListSpacesOutput> {
+    const params = buildParams({
+      limit: options?.limit ?? null,
+      cursor: options?.cursor ?? null,
+    });
+    const data = await this.requester.request('GET', '/space/trash', {
+      params: Object.keys(params).length > 0 ? params : undefined,
+    });
+    return ListSpacesOutputSchema.parse(data);
+  }
+
+  /**
+   * Take a deleted space out of the trash, with its blocks and experience confirmations.
+   */
+  async restore(spaceId: string): Promise<Space> {
+    const data = await this.requester.request('POST', `/space/${spaceId}/restore`);
+    return SpaceSchema.parse(data);
+  }
+
   async updateConfigs(
     spaceId: string,
     options: {
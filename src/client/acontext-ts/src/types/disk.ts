@@ -9,6 +9,7 @@ export const DiskSchema = z.object({
   project_id: z.string(),
   created_at: z.string(),
   updated_at: z.string(),
+  deleted_at: z.string().nullable().optional(),
 });
 
 export type Disk = z.infer<typeof DiskSchema>;
@@ -48,6 +48,7 @@ export const SessionSchema = z.object({
   configs: z.record(z.string(), z.unknown()).nullable(),
   created_at: z.string(),
   updated_at: z.string(),
+  deleted_at: z.string().nullable().optional(),
 });
 
 export type Session = z.infer<typeof SessionSchema>;
@@ -10,6 +10,7 @@ export const SpaceSchema = z.object({
   configs: z.record(z.string(), z.unknown()).nullable(),
   created_at: z.string(),
   updated_at: z.string(),
+  deleted_at: z.string().nullable().optional(),
 });
 
 export type Space = z.infer<typeof SpaceSchema>;
@@ -0,0 +1,153 @@
+// Command errcodes writes the error types of the Python and TypeScript SDKs from the API
+// error catalog, so clients can catch errors by code instead of matching messages.
+//
+//	go run ./cmd/errcodes -py <error_codes.py> -ts <error-codes.ts>
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+)
+
+const header = "Code generated by src/server/api/go/cmd/errcodes from the API error catalog. DO NOT EDIT."
+
+// initialisms are written in capitals in class names
+var initialisms = map[string]string{"api": "API", "id": "ID", "url": "URL"}
+
+// classNames name the exceptions of codes whose names would shadow Python builtins
+var classNames = map[string]string{"not_implemented": "APINotImplementedError"}
+
+// className names the exception of a code, e.g. SessionNotFoundError for session_not_found
+func className(id string) string {
+	if name, ok := classNames[id]; ok {
+		return name
+	}
+	var b strings.Builder
+	for _, word := range strings.Split(id, "_") {
+		if s, ok := initialisms[word]; ok {
+			b.WriteString(s)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	if name := b.String(); !strings.HasSuffix(name, "Error") {
+		b.WriteString("Error")
+	}
+	return b.String()
+}
+
+var funcs = template.FuncMap{"class": className}
+
+var pyTemplate = template.Must(template.New("py").Funcs(funcs).Parse(`# {{.Header}}
+"""
+Error codes of the Acontext API and the exceptions raised for them.
+
+Every error response carries one of these codes. Catch the exception of a code, or compare
+` + "``APIError.error_code``" + ` with it, rather than matching messages, which may change.
+"""
+
+from typing import Literal
+
+from .errors import APIError
+
+ErrorCode = Literal[
+{{- range .Codes}}
+    "{{.ID}}",
+{{- end}}
+]
+{{range .Codes}}
+
+class {{class .ID}}(APIError):
+    """{{.Status}} {{.Title}}."""
+
+    error_code = "{{.ID}}"
+{{end}}
+
+ERROR_CLASSES: dict[str, type[APIError]] = {
+{{- range .Codes}}
+    "{{.ID}}": {{class .ID}},
+{{- end}}
+}
+
+
+def api_error_class(error_code: str | None) -> type[APIError]:
+    """Return the exception raised for an error code, APIError for unknown codes."""
+    if error_code is None:
+        return APIError
+    return ERROR_CLASSES.get(error_code, APIError)
+`))
+
+var tsTemplate = template.Must(template.New("ts").Funcs(funcs).Parse(`// {{.Header}}
+
+/**
+ * Error codes of the Acontext API and the errors thrown for them.
+ *
+ * Every error response carries one of these codes. Catch the error class of a code, or compare
+ * ` + "`APIError.errorCode`" + ` with it, rather than matching messages, which may change.
+ */
+
+import { APIError, APIErrorOptions } from './errors';
+
+export type ErrorCode =
+{{- range .Codes}}
+  | '{{.ID}}'
+{{- end}};
+{{range .Codes}}
+/** {{.Status}} {{.Title}}. */
+export class {{class .ID}} extends APIError {
+  static readonly errorCode = '{{.ID}}';
+
+  constructor(options: APIErrorOptions) {
+    super(options);
+    this.name = '{{class .ID}}';
+    Object.setPrototypeOf(this, {{class .ID}}.prototype);
+  }
+}
+{{end}}
+export const errorClasses: Record<ErrorCode, new (options: APIErrorOptions) => APIError> = {
+{{- range .Codes}}
+  {{.ID}}: {{class .ID}},
+{{- end}}
+};
+
+/**
+ * Create the error thrown for a response, an instance of the class of its code or APIError
+ * for unknown codes.
+ */
+export function createAPIError(options: APIErrorOptions): APIError {
+  const cls = options.errorCode
+    ? (errorClasses as Record<string, new (options: APIErrorOptions) => APIError>)[options.errorCode]
+    : undefined;
+  return cls ? new cls(options) : new APIError(options);
+}
+`))
+
+func write(path string, t *template.Template) error {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, map[string]any{"Header": header, "Codes": apierr.All()}); err != nil {
+		return fmt.Errorf("render %s: %w", path, err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func main() {
+	py := flag.String("py", "", "path of the Python module to write")
+	ts := flag.String("ts", "", "path of the TypeScript module to write")
+	flag.Parse()
+
+	for path, t := range map[string]*template.Template{*py: pyTemplate, *ts: tsTemplate} {
+		if path == "" {
+			continue
+		}
+		if err := write(path, t); err != nil {
+			fmt.Fprintln(os.Stderr, "errcodes:", err)
+			os.Exit(1)
+		}
+	}
+}
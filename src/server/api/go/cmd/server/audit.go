@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// auditPruneLockKey keeps replicas from pruning at the same time
+const auditPruneLockKey = "audit_prune:lock"
+
+// runAuditPruneWorker removes expired audit events every audit.pruneIntervalSec until ctx is done
+func runAuditPruneWorker(ctx context.Context, svc service.AuditService, rdb *redis.Client, cfg *config.Config, log *zap.Logger) {
+	interval := time.Duration(cfg.Audit.PruneIntervalSec) * time.Second
+	retention := time.Duration(cfg.Audit.RetentionDays) * 24 * time.Hour
+	runLocked(ctx, rdb, auditPruneLockKey, interval, log, func(ctx context.Context) {
+		removed, err := svc.Prune(ctx, retention)
+		if err != nil {
+			log.Sugar().Errorw("audit prune failed", "removed", removed, "err", err)
+			return
+		}
+		if removed > 0 {
+			log.Sugar().Infow("audit prune finished", "removed", removed)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/redis/go-redis/v9"
+	"github.com/samber/do"
+	"go.uber.org/zap"
+)
+
+// assetGCLockKey keeps replicas from collecting at the same time
+const assetGCLockKey = "asset_gc:lock"
+
+func runCommand(inj *do.Injector, args []string) int {
+	switch args[0] {
+	case "gc-assets":
+		return runAssetGC(inj, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\nusage: server [gc-assets [-dry-run] [-grace duration]]\n", args[0])
+		return 2
+	}
+}
+
+// runAssetGC reconciles asset references once and prints the report
+func runAssetGC(inj *do.Injector, args []string) int {
+	cfg := do.MustInvoke[*config.Config](inj)
+
+	fs := flag.NewFlagSet("gc-assets", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would be fixed and deleted without changing anything")
+	grace := fs.Duration("grace", time.Duration(cfg.AssetGC.GraceSec)*time.Second, "leave references and objects touched within this window alone")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := do.MustInvoke[service.AssetGCService](inj).Reconcile(ctx, service.AssetGCOptions{
+		DryRun:      *dryRun,
+		GracePeriod: *grace,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "asset gc failed: %v\n", err)
+		return 1
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+	if report.FailedProjects > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runAssetGCWorker reconciles asset references every assetGC.intervalSec until ctx is done.
+// Only one replica runs each round, see runLocked.
+func runAssetGCWorker(ctx context.Context, svc service.AssetGCService, rdb *redis.Client, cfg *config.Config, log *zap.Logger) {
+	interval := time.Duration(cfg.AssetGC.IntervalSec) * time.Second
+	runLocked(ctx, rdb, assetGCLockKey, interval, log, func(ctx context.Context) {
+		report, err := svc.Reconcile(ctx, service.AssetGCOptions{
+			GracePeriod: time.Duration(cfg.AssetGC.GraceSec) * time.Second,
+		})
+		if err != nil {
+			log.Sugar().Errorw("asset gc failed", "err", err)
+			return
+		}
+		log.Sugar().Infow("asset gc finished", "report", report)
+	})
+}
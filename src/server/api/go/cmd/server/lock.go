@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// extendLockScript sets the TTL of a lock, but only while the caller still holds it
+var extendLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLockScript deletes a lock, but only while the caller still holds it
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// runLocked calls fn every interval until ctx is done, on one replica at a time: each round
+// goes to the replica that takes the Redis lock at key.
+func runLocked(ctx context.Context, rdb *redis.Client, key string, interval time.Duration, log *zap.Logger, fn func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := runOnceLocked(ctx, rdb, key, interval, log, fn); err != nil {
+			log.Sugar().Warnw("acquire lock", "key", key, "err", err)
+		}
+	}
+}
+
+// runOnceLocked calls fn if it can take the lock at key, and only fails when the lock cannot be
+// read. The lock is renewed while fn runs, so
+// a run that outlasts the interval does not overlap the next one, and fn is cancelled if the
+// lock is lost all the same. Afterwards the lock is kept until the interval is up, so the
+// other replicas do not start another round right away.
+func runOnceLocked(ctx context.Context, rdb *redis.Client, key string, interval time.Duration, log *zap.Logger, fn func(context.Context)) error {
+	token := uuid.NewString()
+	acquired, err := rdb.SetNX(ctx, key, token, interval).Result()
+	if err != nil || !acquired {
+		return err
+	}
+	start := time.Now()
+
+	runCtx, cancel := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		ticker := time.NewTicker(interval / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			held, err := extendLockScript.Run(runCtx, rdb, []string{key}, token, interval.Milliseconds()).Int()
+			if err != nil {
+				// The lock outlives a few failed renewals; try again on the next tick
+				log.Sugar().Warnw("renew lock", "key", key, "err", err)
+				continue
+			}
+			if held == 0 {
+				log.Sugar().Warnw("lock lost, cancelling the run", "key", key)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	fn(runCtx)
+	cancel()
+	<-renewed
+
+	// Finishing up must not be skipped because ctx is done, or the lock stays held until it expires
+	ctx = context.WithoutCancel(ctx)
+	if rest := interval - time.Since(start); rest > 0 {
+		err = extendLockScript.Run(ctx, rdb, []string{key}, token, rest.Milliseconds()).Err()
+	} else {
+		err = releaseLockScript.Run(ctx, rdb, []string{key}, token).Err()
+	}
+	if err != nil {
+		log.Sugar().Warnw("release lock", "key", key, "err", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// setupTestRedis connects to the Redis of the development environment
+// Note: This requires a running Redis instance for integration tests
+func setupTestRedis(t *testing.T) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:16379", Password: "helloworld"})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skip("Test Redis not available, skipping integration tests")
+	}
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+func TestRunOnceLocked_RenewsTheLockDuringALongRun(t *testing.T) {
+	rdb := setupTestRedis(t)
+	ctx := context.Background()
+	key := "lock:test:" + uuid.NewString()
+	t.Cleanup(func() { rdb.Del(ctx, key) })
+
+	interval := 300 * time.Millisecond
+	var overlapped atomic.Int32
+	err := runOnceLocked(ctx, rdb, key, interval, zap.NewNop(), func(ctx context.Context) {
+		// Outlast the interval twice; another replica must not get the lock meanwhile
+		for i := 0; i < 3; i++ {
+			time.Sleep(interval)
+			require.NoError(t, runOnceLocked(ctx, rdb, key, interval, zap.NewNop(), func(context.Context) {
+				overlapped.Add(1)
+			}))
+		}
+		assert.NoError(t, ctx.Err())
+	})
+	require.NoError(t, err)
+	assert.Zero(t, overlapped.Load())
+
+	// The run outlasted its interval, so the lock is released right away
+	n, err := rdb.Exists(ctx, key).Result()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestRunOnceLocked_HoldsTheLockUntilTheIntervalIsUp(t *testing.T) {
+	rdb := setupTestRedis(t)
+	ctx := context.Background()
+	key := "lock:test:" + uuid.NewString()
+	t.Cleanup(func() { rdb.Del(ctx, key) })
+
+	require.NoError(t, runOnceLocked(ctx, rdb, key, time.Minute, zap.NewNop(), func(context.Context) {}))
+
+	ttl, err := rdb.PTTL(ctx, key).Result()
+	require.NoError(t, err)
+	assert.Greater(t, ttl, 50*time.Second)
+}
+
+func TestRunOnceLocked_CancelsTheRunWhenTheLockIsLost(t *testing.T) {
+	rdb := setupTestRedis(t)
+	ctx := context.Background()
+	key := "lock:test:" + uuid.NewString()
+	t.Cleanup(func() { rdb.Del(ctx, key) })
+
+	interval := 300 * time.Millisecond
+	err := runOnceLocked(ctx, rdb, key, interval, zap.NewNop(), func(ctx context.Context) {
+		require.NoError(t, rdb.Set(ctx, key, "someone else", time.Minute).Err())
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Error("run was not cancelled")
+		}
+	})
+	require.NoError(t, err)
+
+	// The lock of the other holder is left alone
+	v, err := rdb.Get(ctx, key).Result()
+	require.NoError(t, err)
+	assert.Equal(t, "someone else", v)
+}
@@ -14,6 +14,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -26,7 +27,10 @@ import (
 	"github.com/memodb-io/Acontext/internal/config"
 	"github.com/memodb-io/Acontext/internal/infra/cache"
 	dbpkg "github.com/memodb-io/Acontext/internal/infra/db"
+	mq "github.com/memodb-io/Acontext/internal/infra/queue"
+	"github.com/memodb-io/Acontext/internal/middleware"
 	"github.com/memodb-io/Acontext/internal/modules/handler"
+	"github.com/memodb-io/Acontext/internal/modules/service"
 	"github.com/memodb-io/Acontext/internal/pkg/tokenizer"
 	"github.com/memodb-io/Acontext/internal/router"
 	"github.com/memodb-io/Acontext/internal/telemetry"
@@ -40,6 +44,11 @@ func main() {
 	// build dependency injection container
 	inj := bootstrap.BuildContainer()
 
+	// subcommands run once and exit instead of serving
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(inj, os.Args[1:]))
+	}
+
 	cfg := do.MustInvoke[*config.Config](inj)
 	log := do.MustInvoke[*zap.Logger](inj)
 	db := do.MustInvoke[*gorm.DB](inj)
@@ -79,6 +88,53 @@ func main() {
 		}
 	}
 
+	// start background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	artifactSearchSvc := do.MustInvoke[service.ArtifactSearchService](inj)
+	artifactIndexConsumer := do.MustInvokeNamed[*mq.Consumer](inj, bootstrap.ArtifactIndexConsumer)
+	go func() {
+		err := artifactIndexConsumer.Handle(workerCtx, func(body []byte) error {
+			return artifactSearchSvc.HandleIndexMessage(workerCtx, body)
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Sugar().Errorw("artifact index consumer stopped", "err", err)
+		}
+	}()
+
+	if cfg.AssetGC.IntervalSec > 0 {
+		go runAssetGCWorker(workerCtx, do.MustInvoke[service.AssetGCService](inj), rdb, cfg, log)
+	}
+
+	if cfg.Projects.CleanupIntervalSec > 0 {
+		go runProjectCleanupWorker(workerCtx, do.MustInvoke[service.ProjectService](inj), rdb, cfg, log)
+	}
+
+	if cfg.Trash.PurgeIntervalSec > 0 {
+		go runTrashPurgeWorker(workerCtx, do.MustInvoke[service.TrashService](inj), rdb, cfg, log)
+	}
+
+	if cfg.Uploads.SweepIntervalSec > 0 {
+		go runUploadSweepWorker(workerCtx, do.MustInvoke[service.ArtifactUploadService](inj), rdb, cfg, log)
+	}
+
+	if cfg.Audit.RetentionDays > 0 && cfg.Audit.PruneIntervalSec > 0 {
+		go runAuditPruneWorker(workerCtx, do.MustInvoke[service.AuditService](inj), rdb, cfg, log)
+	}
+
+	if cfg.Webhook.PollIntervalMs > 0 {
+		go runWebhookWorker(workerCtx, do.MustInvoke[service.WebhookService](inj), cfg, log)
+	}
+
+	sessionEventSvc := do.MustInvoke[service.SessionEventService](inj)
+	if cfg.Events.RelayIntervalMs > 0 {
+		go runSessionEventRelay(workerCtx, sessionEventSvc, cfg, log)
+	}
+	if cfg.Events.RetentionHours > 0 && cfg.Events.PruneIntervalSec > 0 {
+		go runSessionEventPruneWorker(workerCtx, sessionEventSvc, rdb, cfg, log)
+	}
+
 	// init gin
 	gin.SetMode(cfg.App.Env)
 
@@ -86,10 +142,24 @@ func main() {
 	spaceHandler := do.MustInvoke[*handler.SpaceHandler](inj)
 	blockHandler := do.MustInvoke[*handler.BlockHandler](inj)
 	sessionHandler := do.MustInvoke[*handler.SessionHandler](inj)
+	eventHandler := do.MustInvoke[*handler.SessionEventHandler](inj)
 	diskHandler := do.MustInvoke[*handler.DiskHandler](inj)
 	artifactHandler := do.MustInvoke[*handler.ArtifactHandler](inj)
+	uploadHandler := do.MustInvoke[*handler.ArtifactUploadHandler](inj)
+	shareHandler := do.MustInvoke[*handler.ShareHandler](inj)
+	blobHandler := do.MustInvoke[*handler.BlobHandler](inj)
 	taskHandler := do.MustInvoke[*handler.TaskHandler](inj)
 	toolHandler := do.MustInvoke[*handler.ToolHandler](inj)
+	usageHandler := do.MustInvoke[*handler.UsageHandler](inj)
+	auditHandler := do.MustInvoke[*handler.AuditHandler](inj)
+	webhookHandler := do.MustInvoke[*handler.WebhookHandler](inj)
+	projectHandler := do.MustInvoke[*handler.ProjectHandler](inj)
+	configHandler := do.MustInvoke[*handler.ConfigHandler](inj)
+	trashHandler := do.MustInvoke[*handler.TrashHandler](inj)
+	tenant := do.MustInvoke[*middleware.Tenant](inj)
+	rateLimit := do.MustInvoke[*middleware.RateLimit](inj)
+	idempotency := do.MustInvoke[*middleware.Idempotency](inj)
+	audit := do.MustInvoke[*middleware.Audit](inj)
 
 	engine := router.NewRouter(router.RouterDeps{
 		Config:          cfg,
@@ -98,14 +168,29 @@ func main() {
 		SpaceHandler:    spaceHandler,
 		BlockHandler:    blockHandler,
 		SessionHandler:  sessionHandler,
+		EventHandler:    eventHandler,
 		DiskHandler:     diskHandler,
 		ArtifactHandler: artifactHandler,
+		UploadHandler:   uploadHandler,
+		ShareHandler:    shareHandler,
+		BlobHandler:     blobHandler,
 		TaskHandler:     taskHandler,
 		ToolHandler:     toolHandler,
+		UsageHandler:    usageHandler,
+		AuditHandler:    auditHandler,
+		WebhookHandler:  webhookHandler,
+		ProjectHandler:  projectHandler,
+		ConfigHandler:   configHandler,
+		TrashHandler:    trashHandler,
+		Tenant:          tenant,
+		RateLimit:       rateLimit,
+		Idempotency:     idempotency,
+		Audit:           audit,
 	})
 
 	addr := fmt.Sprintf("%s:%d", cfg.App.Host, cfg.App.Port)
 	srv := &http.Server{Addr: addr, Handler: engine}
+	srv.RegisterOnShutdown(eventHandler.Close)
 
 	go func() {
 		log.Sugar().Infow("starting http server", "addr", addr)
@@ -121,6 +206,8 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	stopWorkers()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
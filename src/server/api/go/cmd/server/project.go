@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// projectCleanupLockKey keeps replicas from removing the same projects at the same time
+const projectCleanupLockKey = "project_cleanup:lock"
+
+// runProjectCleanupWorker removes deleted projects every projects.cleanupIntervalSec until ctx is done
+func runProjectCleanupWorker(ctx context.Context, svc service.ProjectService, rdb *redis.Client, cfg *config.Config, log *zap.Logger) {
+	interval := time.Duration(cfg.Projects.CleanupIntervalSec) * time.Second
+	runLocked(ctx, rdb, projectCleanupLockKey, interval, log, func(ctx context.Context) {
+		purged, err := svc.PurgeDeleted(ctx)
+		if err != nil {
+			log.Sugar().Errorw("project cleanup failed", "err", err)
+			return
+		}
+		if purged > 0 {
+			log.Sugar().Infow("project cleanup finished", "purged", purged)
+		}
+	})
+}
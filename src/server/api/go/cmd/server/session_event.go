@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// sessionEventPruneLockKey keeps replicas from pruning at the same time
+const sessionEventPruneLockKey = "session_event_prune:lock"
+
+// runSessionEventRelay publishes new session events every events.relayIntervalMs until ctx is
+// done. One replica publishes at a time; the others find nothing to publish.
+func runSessionEventRelay(ctx context.Context, svc service.SessionEventService, cfg *config.Config, log *zap.Logger) {
+	ticker := time.NewTicker(time.Duration(cfg.Events.RelayIntervalMs) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// A full batch means there may be more waiting, so look again at once
+		for {
+			n, err := svc.Relay(ctx)
+			if err != nil {
+				log.Sugar().Errorw("session event relay failed", "err", err)
+				break
+			}
+			if n < cfg.Events.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
+
+// runSessionEventPruneWorker removes expired session events every events.pruneIntervalSec until
+// ctx is done
+func runSessionEventPruneWorker(ctx context.Context, svc service.SessionEventService, rdb *redis.Client, cfg *config.Config, log *zap.Logger) {
+	interval := time.Duration(cfg.Events.PruneIntervalSec) * time.Second
+	retention := time.Duration(cfg.Events.RetentionHours) * time.Hour
+	runLocked(ctx, rdb, sessionEventPruneLockKey, interval, log, func(ctx context.Context) {
+		removed, err := svc.Prune(ctx, retention)
+		if err != nil {
+			log.Sugar().Errorw("session event prune failed", "removed", removed, "err", err)
+			return
+		}
+		if removed > 0 {
+			log.Sugar().Infow("session event prune finished", "removed", removed)
+		}
+	})
+}
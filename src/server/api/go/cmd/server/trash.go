@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// trashPurgeLockKey keeps replicas from purging the same resources at the same time
+const trashPurgeLockKey = "trash_purge:lock"
+
+// runTrashPurgeWorker purges the sessions, spaces and disks kept in the trash longer than
+// trash.retentionDays every trash.purgeIntervalSec until ctx is done
+func runTrashPurgeWorker(ctx context.Context, svc service.TrashService, rdb *redis.Client, cfg *config.Config, log *zap.Logger) {
+	interval := time.Duration(cfg.Trash.PurgeIntervalSec) * time.Second
+	runLocked(ctx, rdb, trashPurgeLockKey, interval, log, func(ctx context.Context) {
+		purged, err := svc.Purge(ctx)
+		if err != nil {
+			log.Sugar().Errorw("trash purge failed", "err", err)
+			return
+		}
+		if purged > 0 {
+			log.Sugar().Infow("trash purge finished", "purged", purged)
+		}
+	})
+}
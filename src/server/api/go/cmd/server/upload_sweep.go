@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// uploadSweepLockKey keeps replicas from sweeping the same uploads at the same time
+const uploadSweepLockKey = "upload_sweep:lock"
+
+// runUploadSweepWorker deletes the staging data of direct uploads that expired without being
+// committed every uploads.sweepIntervalSec until ctx is done
+func runUploadSweepWorker(ctx context.Context, svc service.ArtifactUploadService, rdb *redis.Client, cfg *config.Config, log *zap.Logger) {
+	interval := time.Duration(cfg.Uploads.SweepIntervalSec) * time.Second
+	runLocked(ctx, rdb, uploadSweepLockKey, interval, log, func(ctx context.Context) {
+		swept, err := svc.Sweep(ctx)
+		if err != nil {
+			log.Sugar().Errorw("upload sweep failed", "swept", swept, "err", err)
+			return
+		}
+		if swept > 0 {
+			log.Sugar().Infow("upload sweep finished", "swept", swept)
+		}
+	})
+}
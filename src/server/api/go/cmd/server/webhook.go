@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"go.uber.org/zap"
+)
+
+// runWebhookWorker fans out new webhook events and sends the due deliveries every
+// webhook.pollIntervalMs until ctx is done. Replicas take different rows, so every
+// replica can run it.
+func runWebhookWorker(ctx context.Context, svc service.WebhookService, cfg *config.Config, log *zap.Logger) {
+	ticker := time.NewTicker(time.Duration(cfg.Webhook.PollIntervalMs) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// A full batch means there may be more waiting, so look again at once
+		for {
+			n, err := svc.Dispatch(ctx)
+			if err != nil {
+				log.Sugar().Errorw("webhook dispatch failed", "err", err)
+				break
+			}
+			if n < cfg.Webhook.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		for {
+			n, err := svc.Deliver(ctx)
+			if err != nil {
+				log.Sugar().Errorw("webhook delivery failed", "err", err)
+				break
+			}
+			if n < cfg.Webhook.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+}
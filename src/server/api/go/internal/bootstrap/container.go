@@ -10,9 +10,13 @@ import (
 	"github.com/memodb-io/Acontext/internal/infra/blob"
 	"github.com/memodb-io/Acontext/internal/infra/cache"
 	"github.com/memodb-io/Acontext/internal/infra/db"
+	"github.com/memodb-io/Acontext/internal/infra/embedding"
 	"github.com/memodb-io/Acontext/internal/infra/httpclient"
+	"github.com/memodb-io/Acontext/internal/infra/idempotency"
 	"github.com/memodb-io/Acontext/internal/infra/logger"
 	mq "github.com/memodb-io/Acontext/internal/infra/queue"
+	"github.com/memodb-io/Acontext/internal/infra/ratelimit"
+	"github.com/memodb-io/Acontext/internal/middleware"
 	"github.com/memodb-io/Acontext/internal/modules/handler"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/repo"
@@ -24,6 +28,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// ArtifactIndexConsumer is the injector name of the queue consumer for artifact indexing
+const ArtifactIndexConsumer = "artifact-index-consumer"
+
 func BuildContainer() *do.Injector {
 	inj := do.New()
 
@@ -48,6 +55,8 @@ func BuildContainer() *do.Injector {
 		}
 		// [optional] auto migrate
 		if cfg.Database.AutoMigrate {
+			// artifact_chunks stores embeddings in a pgvector column
+			_ = d.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error
 			_ = d.AutoMigrate(
 				&model.Project{},
 				&model.Space{},
@@ -57,12 +66,30 @@ func BuildContainer() *do.Injector {
 				&model.Block{},
 				&model.Disk{},
 				&model.Artifact{},
+				&model.DiskShare{},
+				&model.ArtifactUpload{},
+				&model.ArtifactChunk{},
 				&model.AssetReference{},
 				&model.ToolReference{},
 				&model.ToolSOP{},
 				&model.ExperienceConfirmation{},
 				&model.Metric{},
+				&model.ProjectDataKey{},
+				&model.ProjectAPIKey{},
+				&model.AuditEvent{},
+				&model.WebhookEndpoint{},
+				&model.WebhookOutbox{},
+				&model.WebhookDelivery{},
+				&model.SessionEvent{},
 			)
+			// Disk names are unique among the disks not in the trash, by idx_disk_project_live_name
+			_ = d.Exec("DROP INDEX IF EXISTS idx_disk_project_name").Error
+			if err := repo.InstallWebhookOutboxTriggers(d); err != nil {
+				log.Sugar().Warnw("install webhook outbox triggers", "err", err)
+			}
+			if err := repo.InstallSessionEventTriggers(d); err != nil {
+				log.Sugar().Warnw("install session event triggers", "err", err)
+			}
 		}
 
 		// ensure default project exists
@@ -110,10 +137,34 @@ func BuildContainer() *do.Injector {
 		return mq.NewPublisher(conn, log, cfg)
 	})
 
-	// S3
-	do.Provide(inj, func(i *do.Injector) (*blob.S3Deps, error) {
+	// RabbitMQ Consumers
+	do.ProvideNamed(inj, ArtifactIndexConsumer, func(i *do.Injector) (*mq.Consumer, error) {
+		cfg := do.MustInvoke[*config.Config](i)
+		conn := do.MustInvoke[*amqp.Connection](i)
+		log := do.MustInvoke[*zap.Logger](i)
+		c, err := mq.NewConsumer(conn, cfg.RabbitMQ.QueueName.ArtifactIndex, cfg.RabbitMQ.Prefetch, log, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Bind(cfg.RabbitMQ.ExchangeName.ArtifactIndex, cfg.RabbitMQ.RoutingKey.ArtifactIndexUpsert); err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+
+	// Embedding provider
+	do.Provide(inj, func(i *do.Injector) (embedding.Provider, error) {
+		return embedding.New(do.MustInvoke[*config.Config](i))
+	})
+
+	// Blob store, S3 unless blob.driver selects another driver, encrypted when encryption is enabled
+	do.Provide(inj, func(i *do.Injector) (blob.BlobStore, error) {
 		cfg := do.MustInvoke[*config.Config](i)
-		return blob.NewS3(context.Background(), cfg)
+		store, err := blob.New(context.Background(), cfg)
+		if err != nil || !cfg.Encryption.Enabled {
+			return store, err
+		}
+		return blob.Encrypt(cfg, store, repo.NewDataKeyRepo(do.MustInvoke[*gorm.DB](i)))
 	})
 	// get presign expire duration
 	do.Provide(inj, func(i *do.Injector) (func() time.Duration, error) {
@@ -137,28 +188,20 @@ func BuildContainer() *do.Injector {
 	do.Provide(inj, func(i *do.Injector) (repo.AssetReferenceRepo, error) {
 		return repo.NewAssetReferenceRepo(
 			do.MustInvoke[*gorm.DB](i),
-			do.MustInvoke[*blob.S3Deps](i),
+			do.MustInvoke[blob.BlobStore](i),
 		), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (repo.SpaceRepo, error) {
 		return repo.NewSpaceRepo(do.MustInvoke[*gorm.DB](i)), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (repo.SessionRepo, error) {
-		return repo.NewSessionRepo(
-			do.MustInvoke[*gorm.DB](i),
-			do.MustInvoke[repo.AssetReferenceRepo](i),
-			do.MustInvoke[*blob.S3Deps](i),
-			do.MustInvoke[*zap.Logger](i),
-		), nil
+		return repo.NewSessionRepo(do.MustInvoke[*gorm.DB](i)), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (repo.BlockRepo, error) {
 		return repo.NewBlockRepo(do.MustInvoke[*gorm.DB](i)), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (repo.DiskRepo, error) {
-		return repo.NewDiskRepo(
-			do.MustInvoke[*gorm.DB](i),
-			do.MustInvoke[repo.AssetReferenceRepo](i),
-		), nil
+		return repo.NewDiskRepo(do.MustInvoke[*gorm.DB](i)), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (repo.ArtifactRepo, error) {
 		return repo.NewArtifactRepo(
@@ -166,9 +209,46 @@ func BuildContainer() *do.Injector {
 			do.MustInvoke[repo.AssetReferenceRepo](i),
 		), nil
 	})
+	do.Provide(inj, func(i *do.Injector) (repo.DiskShareRepo, error) {
+		return repo.NewDiskShareRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.ArtifactUploadRepo, error) {
+		return repo.NewArtifactUploadRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.ArtifactChunkRepo, error) {
+		return repo.NewArtifactChunkRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.AssetGCRepo, error) {
+		return repo.NewAssetGCRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.UsageRepo, error) {
+		return repo.NewUsageRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.ProjectRepo, error) {
+		return repo.NewProjectRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.ConfigRepo, error) {
+		return repo.NewConfigRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
 	do.Provide(inj, func(i *do.Injector) (repo.TaskRepo, error) {
 		return repo.NewTaskRepo(do.MustInvoke[*gorm.DB](i)), nil
 	})
+	do.Provide(inj, func(i *do.Injector) (repo.TrashRepo, error) {
+		return repo.NewTrashRepo(
+			do.MustInvoke[*gorm.DB](i),
+			do.MustInvoke[repo.AssetReferenceRepo](i),
+			do.MustInvoke[blob.BlobStore](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.AuditRepo, error) {
+		return repo.NewAuditRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.WebhookRepo, error) {
+		return repo.NewWebhookRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (repo.SessionEventRepo, error) {
+		return repo.NewSessionEventRepo(do.MustInvoke[*gorm.DB](i)), nil
+	})
 
 	// Service
 	do.Provide(inj, func(i *do.Injector) (service.SpaceService, error) {
@@ -179,15 +259,64 @@ func BuildContainer() *do.Injector {
 			do.MustInvoke[*zap.Logger](i),
 		), nil
 	})
+	do.Provide(inj, func(i *do.Injector) (service.ProjectService, error) {
+		return service.NewProjectService(
+			do.MustInvoke[repo.ProjectRepo](i),
+			do.MustInvoke[repo.AssetReferenceRepo](i),
+			do.MustInvoke[service.UsageService](i),
+			do.MustInvoke[*config.Config](i),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.ConfigService, error) {
+		return service.NewConfigService(
+			do.MustInvoke[repo.ConfigRepo](i),
+			do.MustInvoke[*config.Config](i),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.TrashService, error) {
+		return service.NewTrashService(
+			do.MustInvoke[repo.TrashRepo](i),
+			do.MustInvoke[*config.Config](i),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.AuditService, error) {
+		return service.NewAuditService(do.MustInvoke[repo.AuditRepo](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.WebhookService, error) {
+		return service.NewWebhookService(
+			do.MustInvoke[repo.WebhookRepo](i),
+			do.MustInvoke[*config.Config](i),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.SessionEventService, error) {
+		return service.NewSessionEventService(
+			do.MustInvoke[repo.SessionEventRepo](i),
+			do.MustInvoke[*redis.Client](i),
+			do.MustInvoke[*config.Config](i),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.UsageService, error) {
+		return service.NewUsageService(
+			do.MustInvoke[repo.UsageRepo](i),
+			do.MustInvoke[*config.Config](i),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
 	do.Provide(inj, func(i *do.Injector) (service.SessionService, error) {
 		return service.NewSessionService(
 			do.MustInvoke[repo.SessionRepo](i),
 			do.MustInvoke[repo.AssetReferenceRepo](i),
 			do.MustInvoke[*zap.Logger](i),
-			do.MustInvoke[*blob.S3Deps](i),
+			do.MustInvoke[blob.BlobStore](i),
 			do.MustInvoke[*mq.Publisher](i),
 			do.MustInvoke[*config.Config](i),
 			do.MustInvoke[*redis.Client](i),
+			do.MustInvoke[service.UsageService](i),
 		), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (service.BlockService, error) {
@@ -196,10 +325,48 @@ func BuildContainer() *do.Injector {
 	do.Provide(inj, func(i *do.Injector) (service.DiskService, error) {
 		return service.NewDiskService(do.MustInvoke[repo.DiskRepo](i)), nil
 	})
+	do.Provide(inj, func(i *do.Injector) (service.ArtifactSearchService, error) {
+		return service.NewArtifactSearchService(
+			do.MustInvoke[repo.ArtifactRepo](i),
+			do.MustInvoke[repo.ArtifactChunkRepo](i),
+			do.MustInvoke[blob.BlobStore](i),
+			do.MustInvoke[embedding.Provider](i),
+			do.MustInvoke[*mq.Publisher](i),
+			do.MustInvoke[*config.Config](i),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
 	do.Provide(inj, func(i *do.Injector) (service.ArtifactService, error) {
 		return service.NewArtifactService(
 			do.MustInvoke[repo.ArtifactRepo](i),
-			do.MustInvoke[*blob.S3Deps](i),
+			do.MustInvoke[repo.AssetReferenceRepo](i),
+			do.MustInvoke[blob.BlobStore](i),
+			do.MustInvoke[service.ArtifactSearchService](i),
+			do.MustInvoke[service.UsageService](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.ArtifactUploadService, error) {
+		return service.NewArtifactUploadService(
+			do.MustInvoke[repo.ArtifactUploadRepo](i),
+			do.MustInvoke[repo.ArtifactRepo](i),
+			do.MustInvoke[repo.AssetReferenceRepo](i),
+			do.MustInvoke[blob.BlobStore](i),
+			do.MustInvoke[service.ArtifactSearchService](i),
+			do.MustInvoke[service.UsageService](i),
+			do.MustInvoke[func() time.Duration](i)(),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.DiskShareService, error) {
+		return service.NewDiskShareService(
+			do.MustInvoke[repo.DiskShareRepo](i),
+			do.MustInvoke[*config.Config](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (service.AssetGCService, error) {
+		return service.NewAssetGCService(
+			do.MustInvoke[repo.AssetGCRepo](i),
+			do.MustInvoke[blob.BlobStore](i),
+			do.MustInvoke[*zap.Logger](i),
 		), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (service.TaskService, error) {
@@ -222,6 +389,12 @@ func BuildContainer() *do.Injector {
 			do.MustInvoke[*httpclient.CoreClient](i),
 		), nil
 	})
+	do.Provide(inj, func(i *do.Injector) (*handler.SessionEventHandler, error) {
+		return handler.NewSessionEventHandler(
+			do.MustInvoke[service.SessionEventService](i),
+			do.MustInvoke[*config.Config](i),
+		), nil
+	})
 	do.Provide(inj, func(i *do.Injector) (*handler.BlockHandler, error) {
 		return handler.NewBlockHandler(
 			do.MustInvoke[service.BlockService](i),
@@ -231,8 +404,41 @@ func BuildContainer() *do.Injector {
 	do.Provide(inj, func(i *do.Injector) (*handler.DiskHandler, error) {
 		return handler.NewDiskHandler(do.MustInvoke[service.DiskService](i)), nil
 	})
+	do.Provide(inj, func(i *do.Injector) (*handler.ProjectHandler, error) {
+		return handler.NewProjectHandler(do.MustInvoke[service.ProjectService](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.ConfigHandler, error) {
+		return handler.NewConfigHandler(do.MustInvoke[service.ConfigService](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.UsageHandler, error) {
+		return handler.NewUsageHandler(do.MustInvoke[service.UsageService](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.TrashHandler, error) {
+		return handler.NewTrashHandler(do.MustInvoke[service.TrashService](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.AuditHandler, error) {
+		return handler.NewAuditHandler(do.MustInvoke[service.AuditService](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.WebhookHandler, error) {
+		return handler.NewWebhookHandler(do.MustInvoke[service.WebhookService](i)), nil
+	})
 	do.Provide(inj, func(i *do.Injector) (*handler.ArtifactHandler, error) {
-		return handler.NewArtifactHandler(do.MustInvoke[service.ArtifactService](i)), nil
+		return handler.NewArtifactHandler(
+			do.MustInvoke[service.ArtifactService](i),
+			do.MustInvoke[service.ArtifactSearchService](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.ArtifactUploadHandler, error) {
+		return handler.NewArtifactUploadHandler(do.MustInvoke[service.ArtifactUploadService](i)), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.ShareHandler, error) {
+		return handler.NewShareHandler(
+			do.MustInvoke[service.DiskShareService](i),
+			do.MustInvoke[service.ArtifactService](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*handler.BlobHandler, error) {
+		return handler.NewBlobHandler(do.MustInvoke[blob.BlobStore](i)), nil
 	})
 	do.Provide(inj, func(i *do.Injector) (*handler.TaskHandler, error) {
 		return handler.NewTaskHandler(do.MustInvoke[service.TaskService](i)), nil
@@ -241,5 +447,35 @@ func BuildContainer() *do.Injector {
 		return handler.NewToolHandler(do.MustInvoke[*httpclient.CoreClient](i)), nil
 	})
 
+	// Middleware
+	do.Provide(inj, func(i *do.Injector) (*middleware.Tenant, error) {
+		return middleware.NewTenant(
+			do.MustInvoke[repo.SessionRepo](i),
+			do.MustInvoke[repo.SpaceRepo](i),
+			do.MustInvoke[repo.DiskRepo](i),
+			do.MustInvoke[repo.BlockRepo](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*middleware.RateLimit, error) {
+		return middleware.NewRateLimit(
+			do.MustInvoke[*config.Config](i),
+			ratelimit.NewRedisStore(do.MustInvoke[*redis.Client](i)),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*middleware.Audit, error) {
+		return middleware.NewAudit(
+			do.MustInvoke[service.AuditService](i),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
+	do.Provide(inj, func(i *do.Injector) (*middleware.Idempotency, error) {
+		return middleware.NewIdempotency(
+			do.MustInvoke[*config.Config](i),
+			idempotency.NewRedisStore(do.MustInvoke[*redis.Client](i)),
+			do.MustInvoke[*zap.Logger](i),
+		), nil
+	})
+
 	return inj
 }
@@ -26,7 +26,7 @@ func EnsureDefaultProjectExists(ctx context.Context, db *gorm.DB, cfg *config.Co
 	// First, check if a default project exists by looking for the special config field
 	var defaultProject model.Project
 	err := db.WithContext(ctx).
-		Where("configs @> ?", `{"__default_init_project__": true}`).
+		Where("configs @> ?", datatypes.JSONMap{model.DefaultProjectConfigKey: true}).
 		First(&defaultProject).Error
 
 	switch err {
@@ -59,7 +59,7 @@ func EnsureDefaultProjectExists(ctx context.Context, db *gorm.DB, cfg *config.Co
 			SecretKeyHMAC:    lookup,
 			SecretKeyHashPHC: phc,
 			Configs: datatypes.JSONMap{
-				"__default_init_project__": true,
+				model.DefaultProjectConfigKey: true,
 			},
 		}
 		if cErr := db.WithContext(ctx).Create(&newP).Error; cErr != nil {
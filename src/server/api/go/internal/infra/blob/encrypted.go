@@ -0,0 +1,753 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+)
+
+var (
+	// ErrCorruptObject is returned when an encrypted object fails authentication, because it was
+	// truncated, tampered with or encrypted under another key
+	ErrCorruptObject = errors.New("encrypted object is corrupt")
+	// ErrDirectUploadUnsupported is returned for presigned uploads to an encrypted store, which
+	// would hand plaintext straight to the object store
+	ErrDirectUploadUnsupported = errors.New("direct uploads are not supported while encryption is enabled")
+)
+
+// Encrypted objects are a header followed by the plaintext in chunks, each sealed with AES-GCM:
+//
+//	magic (4) | version (1) | nonce prefix (7) | plaintext size (8, big endian) | chunk 0 | chunk 1 | ...
+//
+// The nonce of a chunk is the nonce prefix, the chunk index and a flag marking the final chunk,
+// and the header is the additional data of every chunk. Chunks can therefore be neither
+// reordered, dropped nor cut off, and ranges can be read without decrypting what comes before.
+const (
+	encVersion    = 1
+	encHeaderSize = 20
+	encPrefixSize = 7
+	encChunkSize  = 64 << 10
+	encTagSize    = 16
+)
+
+var encMagic = [4]byte{0x89, 'A', 'C', 'E'}
+
+// DataKeyStore keeps the wrapped data key of each project
+type DataKeyStore interface {
+	// GetDataKey returns the data key of a project, or nil when it has none yet
+	GetDataKey(ctx context.Context, projectID uuid.UUID) (*model.ProjectDataKey, error)
+	// CreateDataKey stores key unless the project already has one, and returns the one stored
+	CreateDataKey(ctx context.Context, key *model.ProjectDataKey) (*model.ProjectDataKey, error)
+}
+
+// Sealer encrypts small values with the data key of the project that owns key, for caches that
+// hold copies of stored objects outside the object store
+type Sealer interface {
+	Seal(ctx context.Context, key string, plaintext []byte) ([]byte, error)
+	Unseal(ctx context.Context, key string, sealed []byte) ([]byte, error)
+}
+
+// SignedURLStore is a BlobStore whose presigned URLs point at the API rather than the object store
+type SignedURLStore interface {
+	BlobStore
+	// Verify checks a presigned URL for method on key
+	Verify(method, key string, query url.Values) error
+	// Open opens an object for a presigned GET and returns its size
+	Open(key string) (io.ReadSeekCloser, int64, error)
+	// Write stores the body of a presigned PUT
+	Write(key string, query url.Values, body io.Reader) error
+}
+
+// projectCipher holds the keys derived from the data key of one project
+type projectCipher struct {
+	aead   cipher.AEAD
+	macKey []byte
+}
+
+// EncryptedStore wraps another BlobStore with envelope encryption. Objects are encrypted with
+// the data key of the project in their key, as in "disks/<project>/...", so the wrapped store
+// only ever sees ciphertext. Objects stored before encryption was enabled are read as they are,
+// and parts, which the core service reads straight from the object store, are stored in plain.
+//
+// Deduplication uses a keyed HMAC of the plaintext in place of its SHA256, so equal content is
+// only recognized within one project. Presigned downloads are served and decrypted by the API,
+// and presigned uploads are refused.
+type EncryptedStore struct {
+	inner  BlobStore
+	kms    KMS
+	keys   DataKeyStore
+	signer *URLSigner
+
+	mu      sync.Mutex
+	ciphers map[uuid.UUID]*projectCipher
+}
+
+var (
+	_ SignedURLStore = (*EncryptedStore)(nil)
+	_ Sealer         = (*EncryptedStore)(nil)
+)
+
+func NewEncryptedStore(inner BlobStore, kms KMS, keys DataKeyStore, signer *URLSigner) *EncryptedStore {
+	return &EncryptedStore{
+		inner:   inner,
+		kms:     kms,
+		keys:    keys,
+		signer:  signer,
+		ciphers: make(map[uuid.UUID]*projectCipher),
+	}
+}
+
+// plaintextPrefixes hold objects the core service downloads itself and cannot decrypt
+var plaintextPrefixes = []string{"parts/"}
+
+// plaintext reports whether an object key or key prefix is stored without encryption
+func plaintext(key string) bool {
+	for _, prefix := range plaintextPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectOf returns the project an object key or key prefix belongs to
+func projectOf(key string) (uuid.UUID, error) {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) >= 2 {
+		if id, err := uuid.Parse(parts[1]); err == nil {
+			return id, nil
+		}
+	}
+	return uuid.Nil, fmt.Errorf("key %q does not belong to a project", key)
+}
+
+// cipherFor returns the keys of the project that owns key, creating its data key on first use
+func (s *EncryptedStore) cipherFor(ctx context.Context, key string) (*projectCipher, error) {
+	projectID, err := projectOf(key)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	pc, ok := s.ciphers[projectID]
+	s.mu.Unlock()
+	if ok {
+		return pc, nil
+	}
+
+	stored, err := s.keys.GetDataKey(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("get data key: %w", err)
+	}
+	if stored == nil {
+		dek := make([]byte, 32)
+		if _, err := rand.Read(dek); err != nil {
+			return nil, err
+		}
+		wrapped, err := s.kms.Wrap(ctx, dek, projectID[:])
+		if err != nil {
+			return nil, fmt.Errorf("wrap data key: %w", err)
+		}
+		stored, err = s.keys.CreateDataKey(ctx, &model.ProjectDataKey{ProjectID: projectID, KMSKeyID: s.kms.KeyID(), WrappedKey: wrapped})
+		if err != nil {
+			return nil, fmt.Errorf("create data key: %w", err)
+		}
+	}
+	dek, err := s.kms.Unwrap(ctx, stored.KMSKeyID, stored.WrappedKey, projectID[:])
+	if err != nil {
+		return nil, err
+	}
+	if pc, err = deriveCipher(dek); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.ciphers[projectID] = pc
+	s.mu.Unlock()
+	return pc, nil
+}
+
+// deriveCipher derives separate keys for encryption and deduplication from a data key
+func deriveCipher(dek []byte) (*projectCipher, error) {
+	encKey, err := hkdf.Key(sha256.New, dek, nil, "acontext blob encryption", 32)
+	if err != nil {
+		return nil, err
+	}
+	macKey, err := hkdf.Key(sha256.New, dek, nil, "acontext blob dedup", 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &projectCipher{aead: aead, macKey: macKey}, nil
+}
+
+func (pc *projectCipher) mac() hash.Hash {
+	return hmac.New(sha256.New, pc.macKey)
+}
+
+// encryptedSize is the size of the object that holds size bytes of plaintext
+func encryptedSize(size int64) int64 {
+	return encHeaderSize + size + chunkCount(size)*encTagSize
+}
+
+func chunkCount(size int64) int64 {
+	return max(1, (size+encChunkSize-1)/encChunkSize)
+}
+
+func chunkNonce(prefix []byte, index int64, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[encPrefixSize:], uint32(index))
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// parseHeader returns the plaintext size of an encrypted object header, or false when header
+// does not start an encrypted object
+func parseHeader(header []byte) (int64, bool) {
+	if len(header) < encHeaderSize || !bytes.Equal(header[:4], encMagic[:]) || header[4] != encVersion {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(header[12:encHeaderSize])), true
+}
+
+// encryptReader streams the encrypted object of size bytes read from src
+type encryptReader struct {
+	src    io.Reader
+	aead   cipher.AEAD
+	header []byte
+	index  int64
+	remain int64
+	plain  []byte
+	sealed []byte
+	out    []byte
+	done   bool
+}
+
+func (pc *projectCipher) encrypt(src io.Reader, size int64) (io.Reader, error) {
+	header := make([]byte, encHeaderSize)
+	copy(header, encMagic[:])
+	header[4] = encVersion
+	if _, err := rand.Read(header[5:12]); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint64(header[12:], uint64(size))
+	return &encryptReader{
+		src:    src,
+		aead:   pc.aead,
+		header: header,
+		remain: size,
+		plain:  make([]byte, min(size, encChunkSize)),
+		sealed: make([]byte, 0, min(size, encChunkSize)+encTagSize),
+		out:    header,
+	}, nil
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		n := min(r.remain, encChunkSize)
+		if _, err := io.ReadFull(r.src, r.plain[:n]); err != nil {
+			return 0, fmt.Errorf("read plaintext: %w", err)
+		}
+		r.remain -= n
+		r.done = r.remain == 0
+		nonce := chunkNonce(r.header[5:12], r.index, r.done)
+		r.out = r.aead.Seal(r.sealed[:0], nonce, r.plain[:n], r.header)
+		r.index++
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// decryptReader decrypts the chunks of an encrypted object read from src, starting at chunk
+// index, and returns remain bytes of plaintext after dropping the first skip
+type decryptReader struct {
+	src    io.Reader
+	closer io.Closer
+	aead   cipher.AEAD
+	header []byte
+	size   int64
+	index  int64
+	skip   int64
+	remain int64
+	sealed []byte
+	out    []byte
+}
+
+func (pc *projectCipher) decrypt(src io.ReadCloser, header []byte, size, index, skip, remain int64) *decryptReader {
+	return &decryptReader{
+		src:    src,
+		closer: src,
+		aead:   pc.aead,
+		header: header,
+		size:   size,
+		index:  index,
+		skip:   skip,
+		remain: remain,
+		sealed: make([]byte, encChunkSize+encTagSize),
+	}
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.remain <= 0 {
+			return 0, io.EOF
+		}
+		last := chunkCount(r.size) - 1
+		n := int64(encChunkSize)
+		if r.index == last {
+			n = r.size - last*encChunkSize
+		}
+		sealed := r.sealed[:n+encTagSize]
+		if _, err := io.ReadFull(r.src, sealed); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return 0, fmt.Errorf("%w: chunk %d is cut off", ErrCorruptObject, r.index)
+			}
+			return 0, err
+		}
+		plain, err := r.aead.Open(sealed[:0], chunkNonce(r.header[5:12], r.index, r.index == last), sealed, r.header)
+		if err != nil {
+			return 0, fmt.Errorf("%w: chunk %d fails authentication", ErrCorruptObject, r.index)
+		}
+		r.index++
+		plain = plain[min(r.skip, int64(len(plain))):]
+		r.skip = 0
+		r.out = plain[:min(r.remain, int64(len(plain)))]
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	r.remain -= int64(n)
+	return n, nil
+}
+
+func (r *decryptReader) Close() error {
+	return r.closer.Close()
+}
+
+// put encrypts the size bytes of src and stores them under the content-addressed key of sumHex
+func (s *EncryptedStore) put(ctx context.Context, pc *projectCipher, keyPrefix, sumHex, contentType, ext string, src io.Reader, size int64) (*model.Asset, error) {
+	body, err := pc.encrypt(src, size)
+	if err != nil {
+		return nil, err
+	}
+	key := contentKey(keyPrefix, sumHex, ext)
+	// The object store learns neither the file name nor the content type
+	etag, err := s.inner.PutObject(ctx, key, "application/octet-stream", body, map[string]string{"sha256": sumHex})
+	if err != nil {
+		return nil, err
+	}
+	asset := &model.Asset{S3Key: key, ETag: etag, SHA256: sumHex, MIME: contentType, SizeB: size}
+	switch inner := s.inner.(type) {
+	case *S3Deps:
+		asset.Bucket = inner.Bucket
+	case *SignedStore:
+		asset.Bucket = inner.bucket
+	}
+	return asset, nil
+}
+
+func (s *EncryptedStore) uploadWithDedup(ctx context.Context, keyPrefix, contentType, ext string, data []byte, lookup AssetLookup) (*model.Asset, error) {
+	pc, err := s.cipherFor(ctx, keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	mac := pc.mac()
+	mac.Write(data)
+	sumHex := hex.EncodeToString(mac.Sum(nil))
+
+	existing, err := findExisting(ctx, lookup, sumHex, contentType)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+	return s.put(ctx, pc, keyPrefix, sumHex, contentType, ext, bytes.NewReader(data), int64(len(data)))
+}
+
+// UploadFormFile reads the file twice, first to find its HMAC and then to encrypt it, so it is
+// never held in memory and content already stored is not uploaded again
+func (s *EncryptedStore) UploadFormFile(ctx context.Context, keyPrefix string, fh *multipart.FileHeader, lookup AssetLookup) (*model.Asset, error) {
+	if plaintext(keyPrefix) {
+		return s.inner.UploadFormFile(ctx, keyPrefix, fh, lookup)
+	}
+	pc, err := s.cipherFor(ctx, keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	file, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	mac := pc.mac()
+	size, err := io.Copy(mac, file)
+	if err != nil {
+		return nil, err
+	}
+	sumHex := hex.EncodeToString(mac.Sum(nil))
+	contentType := fh.Header.Get("Content-Type")
+
+	existing, err := findExisting(ctx, lookup, sumHex, contentType)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	return s.put(ctx, pc, keyPrefix, sumHex, contentType, ext, file, size)
+}
+
+func (s *EncryptedStore) UploadBytes(ctx context.Context, keyPrefix string, filename string, contentType string, data []byte, lookup AssetLookup) (*model.Asset, error) {
+	if plaintext(keyPrefix) {
+		return s.inner.UploadBytes(ctx, keyPrefix, filename, contentType, data, lookup)
+	}
+	return s.uploadWithDedup(ctx, keyPrefix, contentType, strings.ToLower(filepath.Ext(filename)), data, lookup)
+}
+
+func (s *EncryptedStore) UploadJSON(ctx context.Context, keyPrefix string, data interface{}, lookup AssetLookup) (*model.Asset, error) {
+	if plaintext(keyPrefix) {
+		return s.inner.UploadJSON(ctx, keyPrefix, data, lookup)
+	}
+	jsonData, err := sonic.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal json: %w", err)
+	}
+	return s.uploadWithDedup(ctx, keyPrefix, "application/json", ".json", jsonData, lookup)
+}
+
+// PutObject encrypts body, which is read into memory to learn its size
+func (s *EncryptedStore) PutObject(ctx context.Context, key, contentType string, body io.Reader, metadata map[string]string) (string, error) {
+	if plaintext(key) {
+		return s.inner.PutObject(ctx, key, contentType, body, metadata)
+	}
+	pc, err := s.cipherFor(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := pc.encrypt(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	return s.inner.PutObject(ctx, key, "application/octet-stream", encrypted, nil)
+}
+
+func (s *EncryptedStore) DownloadJSON(ctx context.Context, key string, target interface{}) error {
+	data, err := s.DownloadFile(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := sonic.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("unmarshal json: %w", err)
+	}
+	return nil
+}
+
+func (s *EncryptedStore) DownloadFile(ctx context.Context, key string) ([]byte, error) {
+	body, err := s.OpenObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// OpenObject reads the header off the object itself, so whole objects take a single request
+func (s *EncryptedStore) OpenObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	body, err := s.inner.OpenObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, encHeaderSize)
+	n, err := io.ReadFull(body, header)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		body.Close()
+		return nil, err
+	}
+	size, ok := parseHeader(header[:n])
+	if !ok {
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(header[:n]), body), body}, nil
+	}
+
+	pc, err := s.cipherFor(ctx, key)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	return pc.decrypt(body, header, size, 0, 0, size), nil
+}
+
+// header reads the header of an object; ok is false for objects stored without encryption
+func (s *EncryptedStore) header(ctx context.Context, key string) ([]byte, int64, bool, error) {
+	body, err := s.inner.OpenRange(ctx, key, 0, encHeaderSize)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer body.Close()
+	header := make([]byte, encHeaderSize)
+	n, err := io.ReadFull(body, header)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, 0, false, err
+	}
+	size, ok := parseHeader(header[:n])
+	return header, size, ok, nil
+}
+
+// OpenRange reads the header first and then only the chunks that hold the range
+func (s *EncryptedStore) OpenRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if key == "" {
+		return nil, errors.New("key is empty")
+	}
+	if offset == 0 && length < 0 {
+		return s.OpenObject(ctx, key)
+	}
+	header, size, ok, err := s.header(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return s.inner.OpenRange(ctx, key, offset, length)
+	}
+	if offset > 0 && offset >= size {
+		return nil, fmt.Errorf("range start %d is beyond object size %d", offset, size)
+	}
+	if length < 0 || offset+length > size {
+		length = size - offset
+	}
+	pc, err := s.cipherFor(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	first := offset / encChunkSize
+	last := first
+	if length > 0 {
+		last = (offset + length - 1) / encChunkSize
+	}
+	start := encHeaderSize + first*(encChunkSize+encTagSize)
+	end := min(encHeaderSize+(last+1)*(encChunkSize+encTagSize), encryptedSize(size))
+	body, err := s.inner.OpenRange(ctx, key, start, end-start)
+	if err != nil {
+		return nil, err
+	}
+	return pc.decrypt(body, header, size, first, offset-first*encChunkSize, length), nil
+}
+
+// HashObject hashes the plaintext of an object
+func (s *EncryptedStore) HashObject(ctx context.Context, key string) (string, int64, error) {
+	body, err := s.OpenObject(ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	defer body.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, body)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+func (s *EncryptedStore) ListObjects(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	return s.inner.ListObjects(ctx, prefix, fn)
+}
+
+func (s *EncryptedStore) DeleteObject(ctx context.Context, key string) error {
+	return s.inner.DeleteObject(ctx, key)
+}
+
+func (s *EncryptedStore) DeleteObjects(ctx context.Context, keys []string) error {
+	return s.inner.DeleteObjects(ctx, keys)
+}
+
+// PresignGet returns a URL served by the API, which decrypts the object on the way out.
+// Objects stored before encryption was enabled are still downloaded from S3 directly.
+func (s *EncryptedStore) PresignGet(ctx context.Context, key string, expire time.Duration) (string, error) {
+	if key == "" {
+		return "", errors.New("key is empty")
+	}
+	if _, served := s.inner.(SignedURLStore); !served {
+		_, _, ok, err := s.header(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return s.inner.PresignGet(ctx, key, expire)
+		}
+	}
+	return s.signer.Sign(http.MethodGet, key, nil, expire), nil
+}
+
+func (s *EncryptedStore) PresignPut(ctx context.Context, key, contentType string, expire time.Duration) (string, error) {
+	return "", ErrDirectUploadUnsupported
+}
+
+func (s *EncryptedStore) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	return "", ErrDirectUploadUnsupported
+}
+
+func (s *EncryptedStore) PresignUploadPart(ctx context.Context, key, uploadID string, partNumber int32, expire time.Duration) (string, error) {
+	return "", ErrDirectUploadUnsupported
+}
+
+func (s *EncryptedStore) CompleteMultipartUpload(ctx context.Context, key, uploadID string, partCount int32) error {
+	return ErrDirectUploadUnsupported
+}
+
+// AbortMultipartUpload passes through, so uploads started before encryption was enabled can be cleaned up
+func (s *EncryptedStore) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	return s.inner.AbortMultipartUpload(ctx, key, uploadID)
+}
+
+func (s *EncryptedStore) PromoteObject(ctx context.Context, stagingKey, keyPrefix, sumHex, contentType, ext string, size int64, metadata map[string]string, lookup AssetLookup) (*model.Asset, error) {
+	return nil, ErrDirectUploadUnsupported
+}
+
+func (s *EncryptedStore) Verify(method, key string, query url.Values) error {
+	return s.signer.Verify(method, key, query)
+}
+
+// Open opens the plaintext of an object for a presigned GET. Reads are served by range
+// requests to the wrapped store, so seeking does not decrypt the object from the start.
+func (s *EncryptedStore) Open(key string) (io.ReadSeekCloser, int64, error) {
+	ctx := context.Background()
+	_, size, ok, err := s.header(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		if inner, served := s.inner.(SignedURLStore); served {
+			return inner.Open(key)
+		}
+		body, err := s.inner.DownloadFile(ctx, key)
+		if err != nil {
+			return nil, 0, err
+		}
+		return nopSeekCloser{bytes.NewReader(body)}, int64(len(body)), nil
+	}
+	return &rangeReader{ctx: ctx, store: s, key: key, size: size}, size, nil
+}
+
+// Write refuses presigned uploads, which PresignPut never hands out
+func (s *EncryptedStore) Write(key string, query url.Values, body io.Reader) error {
+	return ErrDirectUploadUnsupported
+}
+
+// Seal encrypts a value with the data key of the project that owns key
+func (s *EncryptedStore) Seal(ctx context.Context, key string, plaintext []byte) ([]byte, error) {
+	pc, err := s.cipherFor(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	r, err := pc.encrypt(bytes.NewReader(plaintext), int64(len(plaintext)))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func (s *EncryptedStore) Unseal(ctx context.Context, key string, sealed []byte) ([]byte, error) {
+	header := sealed[:min(len(sealed), encHeaderSize)]
+	size, ok := parseHeader(header)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing header", ErrCorruptObject)
+	}
+	pc, err := s.cipherFor(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	r := pc.decrypt(io.NopCloser(bytes.NewReader(sealed[encHeaderSize:])), header, size, 0, 0, size)
+	return io.ReadAll(r)
+}
+
+// rangeReader reads the plaintext of an encrypted object from wherever it was last sought to
+type rangeReader struct {
+	ctx    context.Context
+	store  *EncryptedStore
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.store.OpenRange(r.ctx, r.key, r.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative seek offset")
+	}
+	if offset != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *rangeReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	return r.body.Close()
+}
@@ -0,0 +1,344 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryDataKeys is a DataKeyStore that keeps data keys in memory
+type memoryDataKeys struct {
+	mu   sync.Mutex
+	keys map[uuid.UUID]*model.ProjectDataKey
+}
+
+func (m *memoryDataKeys) GetDataKey(ctx context.Context, projectID uuid.UUID) (*model.ProjectDataKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.keys[projectID], nil
+}
+
+func (m *memoryDataKeys) CreateDataKey(ctx context.Context, key *model.ProjectDataKey) (*model.ProjectDataKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.keys[key.ProjectID]; ok {
+		return existing, nil
+	}
+	m.keys[key.ProjectID] = key
+	return key, nil
+}
+
+func testKMS(t *testing.T) *LocalKMS {
+	kms, err := NewLocalKMS("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)})
+	require.NoError(t, err)
+	return kms
+}
+
+func testEncryptedStore(t *testing.T) (*EncryptedStore, *SignedStore) {
+	signer := NewURLSigner("http://api.test", []byte("secret"))
+	inner := NewMemoryStore(signer)
+	keys := &memoryDataKeys{keys: map[uuid.UUID]*model.ProjectDataKey{}}
+	return NewEncryptedStore(inner, testKMS(t), keys, signer), inner
+}
+
+// patterned returns n bytes that differ from chunk to chunk, so misplaced chunks are noticed
+func patterned(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i*7 + i/encChunkSize)
+	}
+	return data
+}
+
+func TestEncryptedStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, inner := testEncryptedStore(t)
+	prefix := "disks/" + uuid.NewString()
+
+	for _, size := range []int{0, 1, encChunkSize, 3*encChunkSize + 100} {
+		data := patterned(size)
+		asset, err := store.UploadBytes(ctx, prefix, "data.bin", "application/x-test", data, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(size), asset.SizeB)
+		assert.Equal(t, "application/x-test", asset.MIME)
+		assert.Equal(t, DriverMemory, asset.Bucket)
+		assert.NotEqual(t, sha256Hex(data), asset.SHA256, "the dedup key must not be the plain SHA256")
+
+		raw, err := inner.DownloadFile(ctx, asset.S3Key)
+		require.NoError(t, err)
+		assert.Equal(t, encryptedSize(int64(size)), int64(len(raw)))
+		if size > 16 {
+			assert.False(t, bytes.Contains(raw, data[:16]), "the inner store must only hold ciphertext")
+		}
+
+		got, err := store.DownloadFile(ctx, asset.S3Key)
+		require.NoError(t, err)
+		assert.Equal(t, data, got, "size %d", size)
+
+		sum, n, err := store.HashObject(ctx, asset.S3Key)
+		require.NoError(t, err)
+		assert.Equal(t, sha256Hex(data), sum)
+		assert.Equal(t, int64(size), n)
+	}
+
+	var target map[string]string
+	asset, err := store.UploadJSON(ctx, "disks/"+uuid.NewString(), map[string]string{"text": "secret"}, nil)
+	require.NoError(t, err)
+	raw, err := inner.DownloadFile(ctx, asset.S3Key)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "secret")
+	require.NoError(t, store.DownloadJSON(ctx, asset.S3Key, &target))
+	assert.Equal(t, "secret", target["text"])
+}
+
+func TestEncryptedStore_UploadFormFile(t *testing.T) {
+	ctx := context.Background()
+	store, inner := testEncryptedStore(t)
+	data := []byte("hello, encrypted blob store")
+
+	asset, err := store.UploadFormFile(ctx, "assets/"+uuid.NewString(), formFile(t, "Notes.TXT", "text/plain", data), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", asset.MIME)
+	assert.True(t, strings.HasSuffix(asset.S3Key, asset.SHA256+".txt"), asset.S3Key)
+
+	raw, err := inner.DownloadFile(ctx, asset.S3Key)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "hello")
+	got, err := store.DownloadFile(ctx, asset.S3Key)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestEncryptedStore_Dedup(t *testing.T) {
+	ctx := context.Background()
+	store, _ := testEncryptedStore(t)
+	data := []byte("same content")
+	p1, p2 := "disks/"+uuid.NewString(), "disks/"+uuid.NewString()
+
+	first, err := store.UploadBytes(ctx, p1, "a.txt", "text/plain", data, nil)
+	require.NoError(t, err)
+
+	var looked []string
+	lookup := func(ctx context.Context, sumHex string) (*model.Asset, error) {
+		looked = append(looked, sumHex)
+		if sumHex == first.SHA256 {
+			return first, nil
+		}
+		return nil, nil
+	}
+	again, err := store.UploadFormFile(ctx, p1, formFile(t, "b.txt", "text/markdown", data), lookup)
+	require.NoError(t, err)
+	assert.Equal(t, first.S3Key, again.S3Key)
+	assert.Equal(t, "text/markdown", again.MIME)
+
+	other, err := store.UploadBytes(ctx, p2, "a.txt", "text/plain", data, lookup)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.SHA256, other.SHA256, "equal content must not be recognizable across projects")
+	assert.NotEqual(t, first.S3Key, other.S3Key)
+	assert.Equal(t, []string{first.SHA256, other.SHA256}, looked)
+}
+
+func TestEncryptedStore_OpenRange(t *testing.T) {
+	ctx := context.Background()
+	store, _ := testEncryptedStore(t)
+	data := patterned(3*encChunkSize + 100)
+	asset, err := store.UploadBytes(ctx, "disks/"+uuid.NewString(), "data.bin", "", data, nil)
+	require.NoError(t, err)
+
+	for _, r := range []struct{ offset, length int64 }{
+		{0, 10},
+		{encChunkSize - 5, 10},
+		{encChunkSize, encChunkSize},
+		{100, 2*encChunkSize + 50},
+		{3 * encChunkSize, -1},
+		{3*encChunkSize + 90, 1000},
+	} {
+		body, err := store.OpenRange(ctx, asset.S3Key, r.offset, r.length)
+		require.NoError(t, err)
+		got, err := io.ReadAll(body)
+		require.NoError(t, err)
+		require.NoError(t, body.Close())
+
+		end := int64(len(data))
+		if r.length >= 0 {
+			end = min(end, r.offset+r.length)
+		}
+		assert.Equal(t, data[r.offset:end], got, "range %d+%d", r.offset, r.length)
+	}
+
+	_, err = store.OpenRange(ctx, asset.S3Key, int64(len(data)), 1)
+	assert.Error(t, err)
+}
+
+func TestEncryptedStore_DetectsTampering(t *testing.T) {
+	ctx := context.Background()
+	store, inner := testEncryptedStore(t)
+	data := patterned(2*encChunkSize + 10)
+	asset, err := store.UploadBytes(ctx, "disks/"+uuid.NewString(), "data.bin", "", data, nil)
+	require.NoError(t, err)
+	raw, err := inner.DownloadFile(ctx, asset.S3Key)
+	require.NoError(t, err)
+
+	flipped := bytes.Clone(raw)
+	flipped[encHeaderSize+encChunkSize+encTagSize+3] ^= 1
+	// Dropping the final chunk leaves a complete chunk that is not marked final
+	truncated := raw[:encHeaderSize+2*(encChunkSize+encTagSize)]
+	resized := bytes.Clone(raw)
+	resized[19]--
+
+	for name, stored := range map[string][]byte{"flipped bit": flipped, "truncated": truncated, "resized header": resized} {
+		t.Run(name, func(t *testing.T) {
+			_, err := inner.PutObject(ctx, asset.S3Key, "", bytes.NewReader(stored), nil)
+			require.NoError(t, err)
+			_, err = store.DownloadFile(ctx, asset.S3Key)
+			assert.ErrorIs(t, err, ErrCorruptObject)
+		})
+	}
+}
+
+func TestEncryptedStore_ReadsPlaintextObjects(t *testing.T) {
+	ctx := context.Background()
+	store, inner := testEncryptedStore(t)
+	key := "disks/" + uuid.NewString() + "/legacy.txt"
+	_, err := inner.PutObject(ctx, key, "text/plain", strings.NewReader("stored before encryption"), nil)
+	require.NoError(t, err)
+
+	got, err := store.DownloadFile(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, "stored before encryption", string(got))
+
+	body, err := store.OpenRange(ctx, key, 7, 6)
+	require.NoError(t, err)
+	part, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, "before", string(part))
+}
+
+func TestEncryptedStore_StoresPartsInPlain(t *testing.T) {
+	ctx := context.Background()
+	store, inner := testEncryptedStore(t)
+	prefix := "parts/" + uuid.NewString()
+
+	asset, err := store.UploadJSON(ctx, prefix, map[string]string{"text": "read by the core"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, sha256Hex([]byte(`{"text":"read by the core"}`)), asset.SHA256)
+	raw, err := inner.DownloadFile(ctx, asset.S3Key)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text":"read by the core"}`, string(raw))
+
+	var target map[string]string
+	require.NoError(t, store.DownloadJSON(ctx, asset.S3Key, &target))
+	assert.Equal(t, "read by the core", target["text"])
+
+	key := prefix + "/raw.json"
+	_, err = store.PutObject(ctx, key, "application/json", strings.NewReader(`{}`), nil)
+	require.NoError(t, err)
+	raw, err = inner.DownloadFile(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(raw))
+}
+
+func TestEncryptedStore_PresignedDownload(t *testing.T) {
+	ctx := context.Background()
+	store, _ := testEncryptedStore(t)
+	data := patterned(encChunkSize + 10)
+	asset, err := store.UploadBytes(ctx, "disks/"+uuid.NewString(), "data.bin", "", data, nil)
+	require.NoError(t, err)
+
+	raw, err := store.PresignGet(ctx, asset.S3Key, time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	require.NoError(t, store.Verify(http.MethodGet, asset.S3Key, u.Query()))
+
+	f, size, err := store.Open(asset.S3Key)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, int64(len(data)), size)
+	_, err = f.Seek(encChunkSize-2, io.SeekStart)
+	require.NoError(t, err)
+	part := make([]byte, 4)
+	_, err = io.ReadFull(f, part)
+	require.NoError(t, err)
+	assert.Equal(t, data[encChunkSize-2:encChunkSize+2], part)
+}
+
+func TestEncryptedStore_RefusesDirectUploads(t *testing.T) {
+	ctx := context.Background()
+	store, _ := testEncryptedStore(t)
+	key := "uploads/" + uuid.NewString() + "/staging"
+
+	_, err := store.PresignPut(ctx, key, "text/plain", time.Minute)
+	assert.ErrorIs(t, err, ErrDirectUploadUnsupported)
+	_, err = store.CreateMultipartUpload(ctx, key, "text/plain")
+	assert.ErrorIs(t, err, ErrDirectUploadUnsupported)
+	_, err = store.PromoteObject(ctx, key, "disks/p1", "sum", "text/plain", ".txt", 1, nil, nil)
+	assert.ErrorIs(t, err, ErrDirectUploadUnsupported)
+	assert.ErrorIs(t, store.Write(key, url.Values{}, strings.NewReader("x")), ErrDirectUploadUnsupported)
+}
+
+func TestEncryptedStore_Seal(t *testing.T) {
+	ctx := context.Background()
+	store, _ := testEncryptedStore(t)
+	key := "parts/" + uuid.NewString() + "/a.json"
+
+	sealed, err := store.Seal(ctx, key, []byte(`{"text":"secret"}`))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "secret")
+
+	plain, err := store.Unseal(ctx, key, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, `{"text":"secret"}`, string(plain))
+
+	_, err = store.Unseal(ctx, "parts/"+uuid.NewString()+"/a.json", sealed)
+	assert.ErrorIs(t, err, ErrCorruptObject, "another project's key must not open it")
+	_, err = store.Unseal(ctx, key, []byte(`{"text":"secret"}`))
+	assert.ErrorIs(t, err, ErrCorruptObject)
+}
+
+func TestEncryptedStore_KeysOutsideProjects(t *testing.T) {
+	store, _ := testEncryptedStore(t)
+	_, err := store.UploadBytes(context.Background(), "disks", "a.txt", "text/plain", []byte("x"), nil)
+	assert.Error(t, err)
+}
+
+func TestLocalKMS(t *testing.T) {
+	ctx := context.Background()
+	old, err := NewLocalKMS("old", map[string][]byte{"old": bytes.Repeat([]byte{1}, 32)})
+	require.NoError(t, err)
+	wrapped, err := old.Wrap(ctx, []byte("data key"), []byte("project"))
+	require.NoError(t, err)
+
+	// Rotating the master key keeps data keys wrapped by the old one readable
+	rotated, err := NewLocalKMS("new", map[string][]byte{
+		"old": bytes.Repeat([]byte{1}, 32),
+		"new": bytes.Repeat([]byte{2}, 32),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "new", rotated.KeyID())
+	plain, err := rotated.Unwrap(ctx, "old", wrapped, []byte("project"))
+	require.NoError(t, err)
+	assert.Equal(t, "data key", string(plain))
+
+	_, err = rotated.Unwrap(ctx, "old", wrapped, []byte("another project"))
+	assert.Error(t, err)
+	_, err = rotated.Unwrap(ctx, "gone", wrapped, []byte("project"))
+	assert.ErrorIs(t, err, ErrUnknownMasterKey)
+
+	_, err = NewLocalKMS("short", map[string][]byte{"short": []byte("too short")})
+	assert.Error(t, err)
+	_, err = NewLocalKMS("missing", map[string][]byte{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package blob
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bytedance/sonic"
+	"github.com/memodb-io/Acontext/internal/config"
+)
+
+// ErrUnknownMasterKey is returned when a data key was wrapped by a master key the KMS does not hold
+var ErrUnknownMasterKey = errors.New("unknown master key")
+
+// KMS wraps the data keys of projects with master keys that never leave it.
+// aad binds a wrapped key to its owner, so it cannot be unwrapped on behalf of another.
+type KMS interface {
+	// KeyID names the master key new data keys are wrapped with
+	KeyID() string
+	Wrap(ctx context.Context, plaintext, aad []byte) ([]byte, error)
+	Unwrap(ctx context.Context, keyID string, wrapped, aad []byte) ([]byte, error)
+}
+
+// LocalKMS keeps its master keys in process memory. Keys that are no longer current stay
+// usable for unwrapping, so master keys can be rotated without rewrapping every data key.
+type LocalKMS struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+var _ KMS = (*LocalKMS)(nil)
+
+// NewLocalKMS creates a KMS from 32 byte AES-256 master keys by ID; current must be one of them
+func NewLocalKMS(current string, keys map[string][]byte) (*LocalKMS, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current master key %q is not defined", current)
+	}
+	k := &LocalKMS{current: current, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("master key %q must be 32 bytes, got %d", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		if k.keys[id], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// keyFile is the format of encryption.keyFile:
+// {"current": "2025-01", "keys": {"2024-06": "<base64>", "2025-01": "<base64>"}}
+type keyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// LoadLocalKMS reads the master keys of a LocalKMS from a JSON key file
+func LoadLocalKMS(path string) (*LocalKMS, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	var f keyFile
+	if err := sonic.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("parse key file: %w", err)
+	}
+	keys := make(map[string][]byte, len(f.Keys))
+	for id, encoded := range f.Keys {
+		if keys[id], err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return nil, fmt.Errorf("decode master key %q: %w", id, err)
+		}
+	}
+	return NewLocalKMS(f.Current, keys)
+}
+
+// NewKMS creates the KMS configured under encryption: the key file when one is set, otherwise
+// the single master key from the config
+func NewKMS(cfg *config.Config) (KMS, error) {
+	if cfg.Encryption.KeyFile != "" {
+		return LoadLocalKMS(cfg.Encryption.KeyFile)
+	}
+	if cfg.Encryption.MasterKey == "" {
+		return nil, errors.New("encryption is enabled but neither encryption.masterKey nor encryption.keyFile is set")
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.Encryption.MasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode encryption.masterKey: %w", err)
+	}
+	return NewLocalKMS("config", map[string][]byte{"config": key})
+}
+
+func (k *LocalKMS) KeyID() string {
+	return k.current
+}
+
+// Wrap seals plaintext with the current master key; the nonce is prepended to the result
+func (k *LocalKMS) Wrap(ctx context.Context, plaintext, aad []byte) ([]byte, error) {
+	aead := k.keys[k.current]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func (k *LocalKMS) Unwrap(ctx context.Context, keyID string, wrapped, aad []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownMasterKey, keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return plaintext, nil
+}
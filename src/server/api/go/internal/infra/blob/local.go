@@ -0,0 +1,159 @@
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewLocalStore creates a SignedStore that keeps objects as files under root
+func NewLocalStore(root string, signer *URLSigner) (*SignedStore, error) {
+	if root == "" {
+		return nil, errors.New("blob local root is empty")
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0o750); err != nil {
+		return nil, fmt.Errorf("create blob root %s: %w", abs, err)
+	}
+	return &SignedStore{backend: &fsBackend{root: abs}, signer: signer, bucket: DriverLocal}, nil
+}
+
+// fsBackend stores each object as a file named after its key
+type fsBackend struct {
+	root string
+}
+
+// path maps a key to a file under root, rejecting keys that escape it
+func (b *fsBackend) path(key string) (string, error) {
+	p := filepath.Join(b.root, filepath.FromSlash(key))
+	if key == "" || !strings.HasPrefix(p, b.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return p, nil
+}
+
+func (b *fsBackend) write(key string, r io.Reader) (int64, error) {
+	p, err := b.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return 0, err
+	}
+
+	// Write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), p)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return 0, err
+	}
+	return n, nil
+}
+
+func (b *fsBackend) open(key string) (io.ReadSeekCloser, int64, error) {
+	p, err := b.path(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, 0, ErrObjectNotFound
+		}
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+func (b *fsBackend) remove(key string) error {
+	p, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (b *fsBackend) rename(src, dst string) error {
+	srcPath, err := b.path(src)
+	if err != nil {
+		return err
+	}
+	dstPath, err := b.path(dst)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o750); err != nil {
+		return err
+	}
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrObjectNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (b *fsBackend) list(prefix string) ([]ObjectInfo, error) {
+	// Only the directory holding the prefix can contain matching keys
+	dir := b.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		var err error
+		if dir, err = b.path(prefix[:i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var objects []ObjectInfo
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(b.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	return objects, err
+}
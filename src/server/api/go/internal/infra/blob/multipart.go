@@ -0,0 +1,255 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+)
+
+var (
+	// ErrObjectNotFound is returned when an object or multipart upload does not exist
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrIncompleteUpload is returned when a multipart upload is missing parts
+	ErrIncompleteUpload = errors.New("multipart upload is incomplete")
+)
+
+// maxCopyObjectSize is the largest object S3 can copy in a single CopyObject call
+const maxCopyObjectSize = 5 << 30
+
+// copyPartSize is the part size used to copy objects larger than maxCopyObjectSize
+const copyPartSize = 512 << 20
+
+func isNotFound(err error) bool {
+	var nf *s3types.NotFound
+	var nsk *s3types.NoSuchKey
+	var nsu *s3types.NoSuchUpload
+	return errors.As(err, &nf) || errors.As(err, &nsk) || errors.As(err, &nsu)
+}
+
+// CreateMultipartUpload starts a multipart upload for key and returns its upload ID
+func (s *S3Deps) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	}
+	if s.SSE != nil {
+		input.ServerSideEncryption = *s.SSE
+	}
+	out, err := s.Client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("create multipart upload: %w", err)
+	}
+	return aws.ToString(out.UploadId), nil
+}
+
+// Generate a pre-signed PUT URL for one part of a multipart upload
+func (s *S3Deps) PresignUploadPart(ctx context.Context, key, uploadID string, partNumber int32, expire time.Duration) (string, error) {
+	ps, err := s.Presigner.PresignUploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     aws.String(s.Bucket),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadID),
+		PartNumber: aws.Int32(partNumber),
+	}, func(po *s3.PresignOptions) {
+		po.Expires = expire
+	})
+	if err != nil {
+		return "", err
+	}
+	return ps.URL, nil
+}
+
+// CompleteMultipartUpload assembles the parts S3 received for a multipart upload.
+// It returns ErrIncompleteUpload unless exactly parts 1 to partCount were uploaded.
+func (s *S3Deps) CompleteMultipartUpload(ctx context.Context, key, uploadID string, partCount int32) error {
+	completed := make([]s3types.CompletedPart, 0, partCount)
+	paginator := s3.NewListPartsPaginator(s.Client, &s3.ListPartsInput{
+		Bucket:   aws.String(s.Bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			if isNotFound(err) {
+				return ErrObjectNotFound
+			}
+			return fmt.Errorf("list uploaded parts: %w", err)
+		}
+		for _, part := range page.Parts {
+			completed = append(completed, s3types.CompletedPart{
+				ETag:       part.ETag,
+				PartNumber: part.PartNumber,
+			})
+		}
+	}
+
+	if int32(len(completed)) != partCount {
+		return fmt.Errorf("%w: received %d of %d parts", ErrIncompleteUpload, len(completed), partCount)
+	}
+	for i, part := range completed {
+		if aws.ToInt32(part.PartNumber) != int32(i+1) {
+			return fmt.Errorf("%w: part %d is missing", ErrIncompleteUpload, i+1)
+		}
+	}
+
+	_, err := s.Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.Bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return fmt.Errorf("complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+// AbortMultipartUpload discards a multipart upload and the parts uploaded so far.
+// Aborting an upload that no longer exists is not an error.
+func (s *S3Deps) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.Client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.Bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("abort multipart upload: %w", err)
+	}
+	return nil
+}
+
+// HashObject streams an object and returns its SHA256 and size without buffering it in memory
+func (s *S3Deps) HashObject(ctx context.Context, key string) (string, int64, error) {
+	result, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return "", 0, ErrObjectNotFound
+		}
+		return "", 0, fmt.Errorf("get object from S3: %w", err)
+	}
+	defer result.Body.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, result.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("read object from S3: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// PromoteObject moves a verified staged object to its content-addressed key under keyPrefix.
+// When lookup finds an asset with the same SHA256, the staged copy is dropped and the existing
+// asset is returned instead.
+func (s *S3Deps) PromoteObject(ctx context.Context, stagingKey, keyPrefix, sumHex, contentType, ext string, size int64, metadata map[string]string, lookup AssetLookup) (*model.Asset, error) {
+	existing, err := findExisting(ctx, lookup, sumHex, contentType)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if err := s.DeleteObject(ctx, stagingKey); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	key := contentKey(keyPrefix, sumHex, ext)
+
+	etag, err := s.copyObject(ctx, stagingKey, key, contentType, size, metadata)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DeleteObject(ctx, stagingKey); err != nil {
+		return nil, err
+	}
+
+	return &model.Asset{
+		Bucket: s.Bucket,
+		S3Key:  key,
+		ETag:   etag,
+		SHA256: sumHex,
+		MIME:   contentType,
+		SizeB:  size,
+	}, nil
+}
+
+// copyObject copies an object within the bucket, using a multipart copy for objects over 5GB
+func (s *S3Deps) copyObject(ctx context.Context, srcKey, dstKey, contentType string, size int64, metadata map[string]string) (string, error) {
+	source := (&url.URL{Path: s.Bucket + "/" + srcKey}).EscapedPath()
+
+	if size <= maxCopyObjectSize {
+		input := &s3.CopyObjectInput{
+			Bucket:            aws.String(s.Bucket),
+			Key:               aws.String(dstKey),
+			CopySource:        aws.String(source),
+			ContentType:       aws.String(contentType),
+			Metadata:          metadata,
+			MetadataDirective: s3types.MetadataDirectiveReplace,
+		}
+		if s.SSE != nil {
+			input.ServerSideEncryption = *s.SSE
+		}
+		out, err := s.Client.CopyObject(ctx, input)
+		if err != nil {
+			return "", fmt.Errorf("copy object in S3: %w", err)
+		}
+		return cleanETag(aws.ToString(out.CopyObjectResult.ETag)), nil
+	}
+
+	create := &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(dstKey),
+		ContentType: aws.String(contentType),
+		Metadata:    metadata,
+	}
+	if s.SSE != nil {
+		create.ServerSideEncryption = *s.SSE
+	}
+	mpu, err := s.Client.CreateMultipartUpload(ctx, create)
+	if err != nil {
+		return "", fmt.Errorf("create multipart copy: %w", err)
+	}
+
+	var parts []s3types.CompletedPart
+	for start, partNumber := int64(0), int32(1); start < size; start, partNumber = start+copyPartSize, partNumber+1 {
+		end := min(start+copyPartSize, size) - 1
+		out, err := s.Client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:          aws.String(s.Bucket),
+			Key:             aws.String(dstKey),
+			UploadId:        mpu.UploadId,
+			PartNumber:      aws.Int32(partNumber),
+			CopySource:      aws.String(source),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		})
+		if err != nil {
+			_ = s.AbortMultipartUpload(ctx, dstKey, aws.ToString(mpu.UploadId))
+			return "", fmt.Errorf("copy object part %d in S3: %w", partNumber, err)
+		}
+		parts = append(parts, s3types.CompletedPart{ETag: out.CopyPartResult.ETag, PartNumber: aws.Int32(partNumber)})
+	}
+
+	out, err := s.Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.Bucket),
+		Key:             aws.String(dstKey),
+		UploadId:        mpu.UploadId,
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		_ = s.AbortMultipartUpload(ctx, dstKey, aws.ToString(mpu.UploadId))
+		return "", fmt.Errorf("complete multipart copy: %w", err)
+	}
+	return cleanETag(aws.ToString(out.ETag)), nil
+}
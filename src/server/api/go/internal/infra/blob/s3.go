@@ -21,6 +21,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/config"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
@@ -171,8 +172,29 @@ func cleanETag(etag string) string {
 	return strings.Trim(etag, `"`)
 }
 
+// AssetLookup returns the stored asset with the given SHA256, or nil when there is none.
+// Uploads use it to skip content that is already stored; a nil AssetLookup disables deduplication.
+type AssetLookup func(ctx context.Context, sumHex string) (*model.Asset, error)
+
+// findExisting returns the stored asset for sumHex through lookup, or nil when there is none
+func findExisting(ctx context.Context, lookup AssetLookup, sumHex string, contentType string) (*model.Asset, error) {
+	if lookup == nil {
+		return nil, nil
+	}
+	existing, err := lookup(ctx, sumHex)
+	if err != nil {
+		return nil, fmt.Errorf("look up existing asset: %w", err)
+	}
+	if existing == nil {
+		return nil, nil
+	}
+	asset := *existing
+	asset.MIME = contentType
+	return &asset, nil
+}
+
 // uploadWithDedup performs content-addressed deduplicated upload.
-// It searches for existing objects under keyPrefix that contain the given sumHex in the key.
+// It looks up an existing asset with the given sumHex through lookup.
 // If found, returns its metadata; otherwise uploads the new content using date + sumHex + ext as key.
 func (u *S3Deps) uploadWithDedup(
 	ctx context.Context,
@@ -183,52 +205,35 @@ func (u *S3Deps) uploadWithDedup(
 	size int64,
 	body io.Reader,
 	metadata map[string]string,
+	lookup AssetLookup,
 ) (*model.Asset, error) {
-	// Check for existing object with pagination support
-	listInput := &s3.ListObjectsV2Input{
-		Bucket: &u.Bucket,
-		Prefix: &keyPrefix,
+	existing, err := findExisting(ctx, lookup, sumHex, contentType)
+	if err != nil {
+		return nil, err
 	}
-
-	var continuationToken *string
-	for {
-		listInput.ContinuationToken = continuationToken
-		result, err := u.Client.ListObjectsV2(ctx, listInput)
-		if err != nil {
-			break
-		}
-
-		if result.Contents != nil {
-			for _, obj := range result.Contents {
-				if obj.Key != nil && strings.Contains(*obj.Key, sumHex) {
-					if headResult, herr := u.Client.HeadObject(ctx, &s3.HeadObjectInput{
-						Bucket: &u.Bucket,
-						Key:    obj.Key,
-					}); herr == nil {
-						return &model.Asset{
-							Bucket: u.Bucket,
-							S3Key:  *obj.Key,
-							ETag:   cleanETag(*headResult.ETag),
-							SHA256: sumHex,
-							MIME:   contentType,
-							SizeB:  aws.ToInt64(headResult.ContentLength),
-						}, nil
-					}
-				}
-			}
-		}
-
-		// Check if there are more pages
-		if !aws.ToBool(result.IsTruncated) {
-			break
-		}
-		continuationToken = result.NextContinuationToken
+	if existing != nil {
+		return existing, nil
 	}
 
 	// No existing file found, upload new file with date prefix
-	datePrefix := time.Now().UTC().Format("2006/01/02")
-	key := fmt.Sprintf("%s/%s/%s%s", keyPrefix, datePrefix, sumHex, ext)
+	key := contentKey(keyPrefix, sumHex, ext)
+	etag, err := u.PutObject(ctx, key, contentType, body, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Asset{
+		Bucket: u.Bucket,
+		S3Key:  key,
+		ETag:   etag,
+		SHA256: sumHex,
+		MIME:   contentType,
+		SizeB:  size,
+	}, nil
+}
 
+// PutObject uploads body to key as is and returns its ETag
+func (u *S3Deps) PutObject(ctx context.Context, key, contentType string, body io.Reader, metadata map[string]string) (string, error) {
 	input := &s3.PutObjectInput{
 		Bucket:      aws.String(u.Bucket),
 		Key:         aws.String(key),
@@ -242,43 +247,69 @@ func (u *S3Deps) uploadWithDedup(
 
 	out, err := u.Uploader.Upload(ctx, input)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	return &model.Asset{
-		Bucket: u.Bucket,
-		S3Key:  key,
-		ETag:   cleanETag(*out.ETag),
-		SHA256: sumHex,
-		MIME:   contentType,
-		SizeB:  size,
-	}, nil
+	return cleanETag(*out.ETag), nil
 }
 
-// UploadFormFile uploads a file to S3 with automatic deduplication
-// It checks if a file with the same SHA256 already exists under the keyPrefix
-// If found, returns the existing file metadata; otherwise uploads the new file
-func (u *S3Deps) UploadFormFile(ctx context.Context, keyPrefix string, fh *multipart.FileHeader) (*model.Asset, error) {
+// UploadFormFile uploads a file to S3 with automatic deduplication.
+// The file is streamed to a staging key while it is hashed, so it is never held in memory.
+// If lookup finds an asset with the same SHA256, the staged copy is dropped and the existing
+// asset is returned; otherwise the staged object is copied to its content-addressed key.
+func (u *S3Deps) UploadFormFile(ctx context.Context, keyPrefix string, fh *multipart.FileHeader, lookup AssetLookup) (*model.Asset, error) {
 	file, err := fh.Open()
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	// Read file content into memory
-	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, file); err != nil {
+	contentType := fh.Header.Get("Content-Type")
+	stagingKey := fmt.Sprintf("%s/staging/%s", keyPrefix, uuid.NewString())
+
+	h := sha256.New()
+	counter := &countingReader{r: io.TeeReader(file, h)}
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(u.Bucket),
+		Key:         aws.String(stagingKey),
+		Body:        counter,
+		ContentType: aws.String(contentType),
+	}
+	if u.SSE != nil {
+		input.ServerSideEncryption = *u.SSE
+	}
+	if _, err := u.Uploader.Upload(ctx, input); err != nil {
 		return nil, err
 	}
-	fileContent := buf.Bytes()
 
+	sumHex := hex.EncodeToString(h.Sum(nil))
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	return u.PromoteObject(ctx, stagingKey, keyPrefix, sumHex, contentType, ext, counter.n, map[string]string{
+		"sha256": sumHex,
+		"name":   fh.Filename,
+	}, lookup)
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// UploadBytes uploads in-memory file content to S3 with automatic deduplication
+// The filename is only used to derive the object extension and metadata
+func (u *S3Deps) UploadBytes(ctx context.Context, keyPrefix string, filename string, contentType string, data []byte, lookup AssetLookup) (*model.Asset, error) {
 	// Calculate SHA256 of the file content
 	h := sha256.New()
-	h.Write(fileContent)
+	h.Write(data)
 	sumHex := hex.EncodeToString(h.Sum(nil))
 
-	ext := strings.ToLower(filepath.Ext(fh.Filename))
-	contentType := fh.Header.Get("Content-Type")
+	ext := strings.ToLower(filepath.Ext(filename))
 
 	return u.uploadWithDedup(
 		ctx,
@@ -286,17 +317,18 @@ func (u *S3Deps) UploadFormFile(ctx context.Context, keyPrefix string, fh *multi
 		sumHex,
 		contentType,
 		ext,
-		int64(len(fileContent)),
-		bytes.NewReader(fileContent),
+		int64(len(data)),
+		bytes.NewReader(data),
 		map[string]string{
 			"sha256": sumHex,
-			"name":   fh.Filename,
+			"name":   filename,
 		},
+		lookup,
 	)
 }
 
 // UploadJSON uploads JSON data to S3 and returns metadata
-func (u *S3Deps) UploadJSON(ctx context.Context, keyPrefix string, data interface{}) (*model.Asset, error) {
+func (u *S3Deps) UploadJSON(ctx context.Context, keyPrefix string, data interface{}, lookup AssetLookup) (*model.Asset, error) {
 	// Serialize data to JSON
 	jsonData, err := sonic.Marshal(data)
 	if err != nil {
@@ -319,6 +351,7 @@ func (u *S3Deps) UploadJSON(ctx context.Context, keyPrefix string, data interfac
 		map[string]string{
 			"sha256": sumHex,
 		},
+		lookup,
 	)
 }
 
@@ -373,6 +406,76 @@ func (u *S3Deps) DownloadFile(ctx context.Context, key string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// OpenObject opens an S3 object for streaming reads. The caller must close the returned body.
+func (u *S3Deps) OpenObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	if key == "" {
+		return nil, errors.New("key is empty")
+	}
+
+	result, err := u.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &u.Bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get object from S3: %w", err)
+	}
+
+	return result.Body, nil
+}
+
+// OpenRange opens length bytes of an S3 object starting at offset; a negative length reads to the end.
+// The caller must close the returned body.
+func (u *S3Deps) OpenRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if key == "" {
+		return nil, errors.New("key is empty")
+	}
+
+	rng := fmt.Sprintf("bytes=%d-", offset)
+	if length >= 0 {
+		if length == 0 {
+			return io.NopCloser(bytes.NewReader(nil)), nil
+		}
+		rng = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	result, err := u.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &u.Bucket,
+		Key:    &key,
+		Range:  aws.String(rng),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("get object range from S3: %w", err)
+	}
+
+	return result.Body, nil
+}
+
+// ListObjects calls fn for every object under prefix in key order, stopping at the first error
+func (u *S3Deps) ListObjects(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	paginator := s3.NewListObjectsV2Paginator(u.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(u.Bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("list objects in S3: %w", err)
+		}
+		for _, obj := range page.Contents {
+			if err := fn(ObjectInfo{
+				Key:          aws.ToString(obj.Key),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // DeleteObject deletes an object from S3
 func (u *S3Deps) DeleteObject(ctx context.Context, key string) error {
 	if key == "" {
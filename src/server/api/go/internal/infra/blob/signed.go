@@ -0,0 +1,382 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+)
+
+// multipartPrefix holds the parts of unfinished multipart uploads
+const multipartPrefix = ".multipart/"
+
+// objectBackend is the raw storage behind a SignedStore
+type objectBackend interface {
+	// write stores the content of r under key, replacing any existing object
+	write(key string, r io.Reader) (int64, error)
+	// open opens key for reading and returns its size, or ErrObjectNotFound
+	open(key string) (io.ReadSeekCloser, int64, error)
+	// remove deletes key; a missing key is not an error
+	remove(key string) error
+	// rename moves src to dst, replacing any existing object at dst
+	rename(src, dst string) error
+	// list returns the objects under prefix in any order
+	list(prefix string) ([]ObjectInfo, error)
+}
+
+// SignedStore is a BlobStore for deployments without S3. Its presigned URLs point at the API
+// itself, which checks their signature and reads or writes the object through the store.
+type SignedStore struct {
+	backend objectBackend
+	signer  *URLSigner
+	bucket  string
+}
+
+// Verify checks a presigned URL for method on key
+func (s *SignedStore) Verify(method, key string, query url.Values) error {
+	return s.signer.Verify(method, key, query)
+}
+
+// Open opens an object for a presigned GET and returns its size
+func (s *SignedStore) Open(key string) (io.ReadSeekCloser, int64, error) {
+	return s.backend.open(key)
+}
+
+// Write stores the body of a presigned PUT, which is either a whole object or one multipart upload part
+func (s *SignedStore) Write(key string, query url.Values, body io.Reader) error {
+	uploadID := query.Get("upload_id")
+	if uploadID == "" {
+		_, err := s.backend.write(key, body)
+		return err
+	}
+
+	partNumber, err := strconv.Atoi(query.Get("part_number"))
+	if err != nil || partNumber < 1 {
+		return fmt.Errorf("invalid part number %q", query.Get("part_number"))
+	}
+	if _, _, err := s.backend.open(uploadMarkerKey(uploadID)); err != nil {
+		return err
+	}
+	_, err = s.backend.write(partKey(uploadID, int32(partNumber)), body)
+	return err
+}
+
+func uploadMarkerKey(uploadID string) string {
+	return multipartPrefix + uploadID + "/upload"
+}
+
+func partKey(uploadID string, partNumber int32) string {
+	return fmt.Sprintf("%s%s/part-%05d", multipartPrefix, uploadID, partNumber)
+}
+
+func (s *SignedStore) UploadFormFile(ctx context.Context, keyPrefix string, fh *multipart.FileHeader, lookup AssetLookup) (*model.Asset, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stagingKey := fmt.Sprintf("%s/staging/%s", keyPrefix, uuid.NewString())
+	h := sha256.New()
+	size, err := s.backend.write(stagingKey, io.TeeReader(file, h))
+	if err != nil {
+		return nil, err
+	}
+
+	sumHex := hex.EncodeToString(h.Sum(nil))
+	ext := strings.ToLower(filepath.Ext(fh.Filename))
+	return s.PromoteObject(ctx, stagingKey, keyPrefix, sumHex, fh.Header.Get("Content-Type"), ext, size, nil, lookup)
+}
+
+func (s *SignedStore) UploadBytes(ctx context.Context, keyPrefix string, filename string, contentType string, data []byte, lookup AssetLookup) (*model.Asset, error) {
+	sum := sha256.Sum256(data)
+	ext := strings.ToLower(filepath.Ext(filename))
+	return s.uploadWithDedup(ctx, keyPrefix, hex.EncodeToString(sum[:]), contentType, ext, data, lookup)
+}
+
+func (s *SignedStore) UploadJSON(ctx context.Context, keyPrefix string, data interface{}, lookup AssetLookup) (*model.Asset, error) {
+	jsonData, err := sonic.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshal json: %w", err)
+	}
+	sum := sha256.Sum256(jsonData)
+	return s.uploadWithDedup(ctx, keyPrefix, hex.EncodeToString(sum[:]), "application/json", ".json", jsonData, lookup)
+}
+
+func (s *SignedStore) uploadWithDedup(ctx context.Context, keyPrefix, sumHex, contentType, ext string, data []byte, lookup AssetLookup) (*model.Asset, error) {
+	existing, err := findExisting(ctx, lookup, sumHex, contentType)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	key := contentKey(keyPrefix, sumHex, ext)
+	if _, err := s.backend.write(key, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return s.asset(key, sumHex, contentType, int64(len(data))), nil
+}
+
+// PutObject stores body under key as is and returns its ETag, the SHA256 of the stored bytes
+func (s *SignedStore) PutObject(ctx context.Context, key, contentType string, body io.Reader, metadata map[string]string) (string, error) {
+	h := sha256.New()
+	if _, err := s.backend.write(key, io.TeeReader(body, h)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (s *SignedStore) PromoteObject(ctx context.Context, stagingKey, keyPrefix, sumHex, contentType, ext string, size int64, metadata map[string]string, lookup AssetLookup) (*model.Asset, error) {
+	existing, err := findExisting(ctx, lookup, sumHex, contentType)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if err := s.backend.remove(stagingKey); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	key := contentKey(keyPrefix, sumHex, ext)
+	if err := s.backend.rename(stagingKey, key); err != nil {
+		return nil, err
+	}
+	return s.asset(key, sumHex, contentType, size), nil
+}
+
+// contentKey is the content-addressed key of an object, shared with the S3 store
+func contentKey(keyPrefix, sumHex, ext string) string {
+	datePrefix := time.Now().UTC().Format("2006/01/02")
+	return fmt.Sprintf("%s/%s/%s%s", keyPrefix, datePrefix, sumHex, ext)
+}
+
+func (s *SignedStore) asset(key, sumHex, contentType string, size int64) *model.Asset {
+	return &model.Asset{
+		Bucket: s.bucket,
+		S3Key:  key,
+		ETag:   sumHex,
+		SHA256: sumHex,
+		MIME:   contentType,
+		SizeB:  size,
+	}
+}
+
+func (s *SignedStore) DownloadJSON(ctx context.Context, key string, target interface{}) error {
+	data, err := s.DownloadFile(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := sonic.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("unmarshal json: %w", err)
+	}
+	return nil
+}
+
+func (s *SignedStore) DownloadFile(ctx context.Context, key string) ([]byte, error) {
+	body, err := s.OpenObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func (s *SignedStore) OpenObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.OpenRange(ctx, key, 0, -1)
+}
+
+func (s *SignedStore) OpenRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if key == "" {
+		return nil, errors.New("key is empty")
+	}
+	f, size, err := s.backend.open(key)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		if offset >= size {
+			f.Close()
+			return nil, fmt.Errorf("range start %d is beyond object size %d", offset, size)
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(f, length), f}, nil
+}
+
+func (s *SignedStore) HashObject(ctx context.Context, key string) (string, int64, error) {
+	f, _, err := s.backend.open(key)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+func (s *SignedStore) ListObjects(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	objects, err := s.backend.list(prefix)
+	if err != nil {
+		return err
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	for _, obj := range objects {
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SignedStore) DeleteObject(ctx context.Context, key string) error {
+	if key == "" {
+		return errors.New("key is empty")
+	}
+	return s.backend.remove(key)
+}
+
+func (s *SignedStore) DeleteObjects(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.backend.remove(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SignedStore) PresignGet(ctx context.Context, key string, expire time.Duration) (string, error) {
+	if key == "" {
+		return "", errors.New("key is empty")
+	}
+	return s.signer.Sign(http.MethodGet, key, nil, expire), nil
+}
+
+func (s *SignedStore) PresignPut(ctx context.Context, key, contentType string, expire time.Duration) (string, error) {
+	if key == "" {
+		return "", errors.New("key is empty")
+	}
+	return s.signer.Sign(http.MethodPut, key, nil, expire), nil
+}
+
+func (s *SignedStore) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	uploadID := uuid.NewString()
+	if _, err := s.backend.write(uploadMarkerKey(uploadID), strings.NewReader(key)); err != nil {
+		return "", fmt.Errorf("create multipart upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+func (s *SignedStore) PresignUploadPart(ctx context.Context, key, uploadID string, partNumber int32, expire time.Duration) (string, error) {
+	return s.signer.Sign(http.MethodPut, key, url.Values{
+		"upload_id":   {uploadID},
+		"part_number": {strconv.Itoa(int(partNumber))},
+	}, expire), nil
+}
+
+func (s *SignedStore) CompleteMultipartUpload(ctx context.Context, key, uploadID string, partCount int32) error {
+	if _, _, err := s.backend.open(uploadMarkerKey(uploadID)); err != nil {
+		return err
+	}
+	parts, err := s.backend.list(multipartPrefix + uploadID + "/part-")
+	if err != nil {
+		return fmt.Errorf("list uploaded parts: %w", err)
+	}
+	keys := make([]string, len(parts))
+	for i, part := range parts {
+		keys[i] = part.Key
+	}
+	sort.Strings(keys)
+
+	if int32(len(keys)) != partCount {
+		return fmt.Errorf("%w: received %d of %d parts", ErrIncompleteUpload, len(keys), partCount)
+	}
+	for i, k := range keys {
+		if k != partKey(uploadID, int32(i+1)) {
+			return fmt.Errorf("%w: part %d is missing", ErrIncompleteUpload, i+1)
+		}
+	}
+
+	if _, err := s.backend.write(key, &partsReader{backend: s.backend, keys: keys}); err != nil {
+		return fmt.Errorf("complete multipart upload: %w", err)
+	}
+	return s.AbortMultipartUpload(ctx, key, uploadID)
+}
+
+func (s *SignedStore) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	objects, err := s.backend.list(multipartPrefix + uploadID + "/")
+	if err != nil {
+		return fmt.Errorf("abort multipart upload: %w", err)
+	}
+	for _, obj := range objects {
+		if err := s.backend.remove(obj.Key); err != nil {
+			return fmt.Errorf("abort multipart upload: %w", err)
+		}
+	}
+	return nil
+}
+
+// partsReader reads multipart upload parts one after another, opening each only when it is reached
+type partsReader struct {
+	backend objectBackend
+	keys    []string
+	current io.ReadCloser
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			f, _, err := r.backend.open(r.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.keys = f, r.keys[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
@@ -0,0 +1,231 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStores(t *testing.T) map[string]*SignedStore {
+	signer := NewURLSigner("http://api.test", []byte("secret"))
+	local, err := NewLocalStore(t.TempDir(), signer)
+	require.NoError(t, err)
+	return map[string]*SignedStore{
+		DriverLocal:  local,
+		DriverMemory: NewMemoryStore(signer),
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func formFile(t *testing.T, filename, contentType string, data []byte) *multipart.FileHeader {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreatePart(map[string][]string{
+		"Content-Disposition": {`form-data; name="file"; filename="` + filename + `"`},
+		"Content-Type":        {contentType},
+	})
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(1 << 20)
+	require.NoError(t, err)
+	return form.File["file"][0]
+}
+
+func listKeys(t *testing.T, store BlobStore, prefix string) []string {
+	var keys []string
+	err := store.ListObjects(context.Background(), prefix, func(obj ObjectInfo) error {
+		keys = append(keys, obj.Key)
+		return nil
+	})
+	require.NoError(t, err)
+	return keys
+}
+
+func TestSignedStore_ListObjects(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"parts/p2/b.json", "parts/p1/a.json", "parts/p1/c.json", "disks/p1/a.txt"} {
+				_, err := store.backend.write(key, strings.NewReader(key))
+				require.NoError(t, err)
+			}
+
+			var objects []ObjectInfo
+			require.NoError(t, store.ListObjects(ctx, "parts/p1", func(obj ObjectInfo) error {
+				objects = append(objects, obj)
+				return nil
+			}))
+			require.Len(t, objects, 2)
+			assert.Equal(t, "parts/p1/a.json", objects[0].Key)
+			assert.Equal(t, "parts/p1/c.json", objects[1].Key)
+			assert.Equal(t, int64(len("parts/p1/a.json")), objects[0].Size)
+			assert.WithinDuration(t, time.Now(), objects[0].LastModified, time.Minute)
+
+			assert.Equal(t, []string{"disks/p1/a.txt", "parts/p1/a.json", "parts/p1/c.json", "parts/p2/b.json"}, listKeys(t, store, ""))
+		})
+	}
+}
+
+func TestSignedStore_UploadAndRead(t *testing.T) {
+	ctx := context.Background()
+	data := []byte("hello, blob store")
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			asset, err := store.UploadFormFile(ctx, "disks/p1", formFile(t, "Notes.TXT", "text/plain", data), nil)
+			require.NoError(t, err)
+			assert.Equal(t, name, asset.Bucket)
+			assert.Equal(t, sha256Hex(data), asset.SHA256)
+			assert.Equal(t, int64(len(data)), asset.SizeB)
+			assert.Equal(t, "text/plain", asset.MIME)
+			assert.True(t, strings.HasSuffix(asset.S3Key, sha256Hex(data)+".txt"), asset.S3Key)
+
+			got, err := store.DownloadFile(ctx, asset.S3Key)
+			require.NoError(t, err)
+			assert.Equal(t, data, got)
+
+			body, err := store.OpenRange(ctx, asset.S3Key, 7, 4)
+			require.NoError(t, err)
+			part, err := io.ReadAll(body)
+			require.NoError(t, err)
+			require.NoError(t, body.Close())
+			assert.Equal(t, "blob", string(part))
+
+			sum, size, err := store.HashObject(ctx, asset.S3Key)
+			require.NoError(t, err)
+			assert.Equal(t, sha256Hex(data), sum)
+			assert.Equal(t, int64(len(data)), size)
+
+			// Nothing but the content-addressed object is left behind
+			assert.Equal(t, []string{asset.S3Key}, listKeys(t, store, "disks/"))
+
+			require.NoError(t, store.DeleteObjects(ctx, []string{asset.S3Key, ""}))
+			_, err = store.DownloadFile(ctx, asset.S3Key)
+			assert.ErrorIs(t, err, ErrObjectNotFound)
+		})
+	}
+}
+
+func TestSignedStore_Dedup(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			first, err := store.UploadJSON(ctx, "parts/p1", map[string]string{"a": "b"}, nil)
+			require.NoError(t, err)
+
+			lookup := func(ctx context.Context, sumHex string) (*model.Asset, error) {
+				if sumHex == first.SHA256 {
+					return first, nil
+				}
+				return nil, nil
+			}
+			second, err := store.UploadFormFile(ctx, "parts/p1", formFile(t, "a.json", "text/json", []byte(`{"a":"b"}`)), lookup)
+			require.NoError(t, err)
+			assert.Equal(t, first.S3Key, second.S3Key)
+			assert.Equal(t, "text/json", second.MIME)
+
+			assert.Equal(t, []string{first.S3Key}, listKeys(t, store, "parts/"))
+
+			var out map[string]string
+			require.NoError(t, store.DownloadJSON(ctx, first.S3Key, &out))
+			assert.Equal(t, "b", out["a"])
+		})
+	}
+}
+
+func TestSignedStore_MultipartUpload(t *testing.T) {
+	ctx := context.Background()
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			key := "uploads/p1/u1"
+			uploadID, err := store.CreateMultipartUpload(ctx, key, "application/octet-stream")
+			require.NoError(t, err)
+
+			put := func(partNumber int32, body string) {
+				raw, err := store.PresignUploadPart(ctx, key, uploadID, partNumber, time.Minute)
+				require.NoError(t, err)
+				u, err := url.Parse(raw)
+				require.NoError(t, err)
+				require.NoError(t, store.Verify(http.MethodPut, key, u.Query()))
+				require.NoError(t, store.Write(key, u.Query(), strings.NewReader(body)))
+			}
+
+			put(2, "world")
+			err = store.CompleteMultipartUpload(ctx, key, uploadID, 2)
+			assert.ErrorIs(t, err, ErrIncompleteUpload)
+
+			put(1, "hello ")
+			require.NoError(t, store.CompleteMultipartUpload(ctx, key, uploadID, 2))
+
+			got, err := store.DownloadFile(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, "hello world", string(got))
+
+			assert.Empty(t, listKeys(t, store, multipartPrefix))
+
+			// Parts for an aborted upload are refused
+			require.NoError(t, store.AbortMultipartUpload(ctx, key, uploadID))
+			raw, err := store.PresignUploadPart(ctx, key, uploadID, 1, time.Minute)
+			require.NoError(t, err)
+			u, _ := url.Parse(raw)
+			assert.ErrorIs(t, store.Write(key, u.Query(), strings.NewReader("late")), ErrObjectNotFound)
+		})
+	}
+}
+
+func TestLocalStore_RejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), NewURLSigner("", []byte("secret")))
+	require.NoError(t, err)
+
+	_, err = store.UploadBytes(context.Background(), "../outside", "a.txt", "text/plain", []byte("x"), nil)
+	assert.Error(t, err)
+	_, err = store.DownloadFile(context.Background(), "../../etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestURLSigner(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	signer := NewURLSigner("http://api.test/", []byte("secret"))
+	signer.now = func() time.Time { return now }
+
+	raw := signer.Sign(http.MethodGet, "disks/p1/a b.txt", nil, time.Minute)
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, SignedURLPath+"disks/p1/a b.txt", u.Path)
+	assert.NoError(t, signer.Verify(http.MethodGet, "disks/p1/a b.txt", u.Query()))
+
+	assert.ErrorIs(t, signer.Verify(http.MethodPut, "disks/p1/a b.txt", u.Query()), ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify(http.MethodGet, "disks/p1/other.txt", u.Query()), ErrInvalidSignature)
+
+	tampered := u.Query()
+	tampered.Set("expires", "99999999999")
+	assert.ErrorIs(t, signer.Verify(http.MethodGet, "disks/p1/a b.txt", tampered), ErrInvalidSignature)
+
+	other := NewURLSigner("http://api.test", []byte("other"))
+	assert.ErrorIs(t, other.Verify(http.MethodGet, "disks/p1/a b.txt", u.Query()), ErrInvalidSignature)
+
+	signer.now = func() time.Time { return now.Add(2 * time.Minute) }
+	assert.ErrorIs(t, signer.Verify(http.MethodGet, "disks/p1/a b.txt", u.Query()), ErrURLExpired)
+}
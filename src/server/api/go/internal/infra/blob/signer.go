@@ -0,0 +1,82 @@
+package blob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignedURLPath is the API route that serves the presigned URLs of a SignedStore
+const SignedURLPath = "/api/v1/blob/"
+
+var (
+	// ErrInvalidSignature is returned for a URL that was not signed by this server
+	ErrInvalidSignature = errors.New("invalid blob url signature")
+	// ErrURLExpired is returned for a signed URL whose expiry has passed
+	ErrURLExpired = errors.New("blob url expired")
+)
+
+// URLSigner creates and checks presigned URLs for blob stores that are served by the API
+type URLSigner struct {
+	baseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+func NewURLSigner(baseURL string, secret []byte) *URLSigner {
+	return &URLSigner{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  secret,
+		now:     time.Now,
+	}
+}
+
+// Sign returns a URL that allows method on key until expire has passed.
+// The extra query values, such as the part of a multipart upload, are covered by the signature.
+func (s *URLSigner) Sign(method, key string, extra url.Values, expire time.Duration) string {
+	q := url.Values{}
+	for k, v := range extra {
+		q[k] = v
+	}
+	q.Set("expires", strconv.FormatInt(s.now().Add(expire).Unix(), 10))
+	q.Set("signature", s.signature(method, key, q))
+	return s.baseURL + SignedURLPath + (&url.URL{Path: key}).EscapedPath() + "?" + q.Encode()
+}
+
+// Verify checks the signature and expiry of a URL for method on key
+func (s *URLSigner) Verify(method, key string, query url.Values) error {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	sig, err := hex.DecodeString(q.Get("signature"))
+	if err != nil || len(sig) == 0 {
+		return ErrInvalidSignature
+	}
+	q.Del("signature")
+
+	want, _ := hex.DecodeString(s.signature(method, key, q))
+	if !hmac.Equal(sig, want) {
+		return ErrInvalidSignature
+	}
+
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if s.now().Unix() > expires {
+		return ErrURLExpired
+	}
+	return nil
+}
+
+func (s *URLSigner) signature(method, key string, q url.Values) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(method + "\n" + key + "\n" + q.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,136 @@
+package blob
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"time"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+)
+
+// Blob store drivers selectable through blob.driver
+const (
+	DriverS3     = "s3"
+	DriverLocal  = "local"
+	DriverMemory = "memory"
+)
+
+// BlobStore stores the content-addressed objects behind assets and artifacts.
+// S3Deps keeps them in an S3 bucket; SignedStore keeps them on the local filesystem or in memory
+// and serves its presigned URLs through the API. EncryptedStore encrypts the objects of another.
+type BlobStore interface {
+	// UploadFormFile stores an uploaded file, reusing the asset lookup finds for the same content
+	UploadFormFile(ctx context.Context, keyPrefix string, fh *multipart.FileHeader, lookup AssetLookup) (*model.Asset, error)
+	// UploadBytes stores in-memory content, reusing the asset lookup finds for the same content
+	UploadBytes(ctx context.Context, keyPrefix string, filename string, contentType string, data []byte, lookup AssetLookup) (*model.Asset, error)
+	// UploadJSON stores data encoded as JSON, reusing the asset lookup finds for the same content
+	UploadJSON(ctx context.Context, keyPrefix string, data interface{}, lookup AssetLookup) (*model.Asset, error)
+	// PutObject stores body under key as is, without deduplication, and returns its ETag
+	PutObject(ctx context.Context, key, contentType string, body io.Reader, metadata map[string]string) (string, error)
+
+	// DownloadJSON reads an object and unmarshals it into target
+	DownloadJSON(ctx context.Context, key string, target interface{}) error
+	// DownloadFile reads a whole object into memory
+	DownloadFile(ctx context.Context, key string) ([]byte, error)
+	// OpenObject opens an object for streaming reads. The caller must close the returned body.
+	OpenObject(ctx context.Context, key string) (io.ReadCloser, error)
+	// OpenRange opens length bytes of an object starting at offset; a negative length reads to the end.
+	// The caller must close the returned body.
+	OpenRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// HashObject streams an object and returns its SHA256 and size
+	HashObject(ctx context.Context, key string) (string, int64, error)
+
+	// ListObjects calls fn for every object under prefix in key order, stopping at the first error
+	ListObjects(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+
+	// DeleteObject deletes one object
+	DeleteObject(ctx context.Context, key string) error
+	// DeleteObjects deletes several objects, ignoring empty keys
+	DeleteObjects(ctx context.Context, keys []string) error
+
+	// PresignGet returns a URL that downloads key until expire has passed
+	PresignGet(ctx context.Context, key string, expire time.Duration) (string, error)
+	// PresignPut returns a URL that uploads key until expire has passed
+	PresignPut(ctx context.Context, key, contentType string, expire time.Duration) (string, error)
+
+	// CreateMultipartUpload starts a multipart upload for key and returns its upload ID
+	CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error)
+	// PresignUploadPart returns a URL that uploads one part of a multipart upload
+	PresignUploadPart(ctx context.Context, key, uploadID string, partNumber int32, expire time.Duration) (string, error)
+	// CompleteMultipartUpload assembles parts 1 to partCount into key
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, partCount int32) error
+	// AbortMultipartUpload discards a multipart upload and its parts
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+	// PromoteObject moves a verified staged object to its content-addressed key under keyPrefix
+	PromoteObject(ctx context.Context, stagingKey, keyPrefix, sumHex, contentType, ext string, size int64, metadata map[string]string, lookup AssetLookup) (*model.Asset, error)
+}
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+var (
+	_ BlobStore = (*S3Deps)(nil)
+	_ BlobStore = (*SignedStore)(nil)
+	_ BlobStore = (*EncryptedStore)(nil)
+)
+
+// New creates the blob store selected by blob.driver
+func New(ctx context.Context, cfg *config.Config) (BlobStore, error) {
+	switch cfg.Blob.Driver {
+	case "", DriverS3:
+		s3, err := NewS3(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return s3, nil
+	case DriverLocal:
+		signer, err := newConfigSigner(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewLocalStore(cfg.Blob.LocalRoot, signer)
+	case DriverMemory:
+		signer, err := newConfigSigner(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewMemoryStore(signer), nil
+	default:
+		return nil, fmt.Errorf("unknown blob driver %q", cfg.Blob.Driver)
+	}
+}
+
+// Encrypt wraps inner with the envelope encryption configured under encryption, keeping the
+// data keys of projects in keys
+func Encrypt(cfg *config.Config, inner BlobStore, keys DataKeyStore) (*EncryptedStore, error) {
+	kms, err := NewKMS(cfg)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := newConfigSigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptedStore(inner, kms, keys, signer), nil
+}
+
+// newConfigSigner signs URLs with blob.signingSecret. Without a configured secret a random one is
+// generated, so URLs handed out before a restart stop working.
+func newConfigSigner(cfg *config.Config) (*URLSigner, error) {
+	secret := []byte(cfg.Blob.SigningSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generate blob signing secret: %w", err)
+		}
+	}
+	return NewURLSigner(cfg.Blob.PublicURL, secret), nil
+}
@@ -0,0 +1,28 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/memodb-io/Acontext/internal/config"
+)
+
+// Provider turns texts into embedding vectors of a fixed dimension
+type Provider interface {
+	// Model identifies the embedding space; vectors from different models must not be compared
+	Model() string
+	Dimensions() int
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// New creates the embedding provider selected by configuration
+func New(cfg *config.Config) (Provider, error) {
+	switch cfg.Embedding.Provider {
+	case "", "local":
+		return NewLocal(cfg.Embedding.Model, cfg.Embedding.Dim), nil
+	case "openai":
+		return NewOpenAI(cfg.Embedding.Model, cfg.Embedding.Dim, cfg.Embedding.APIKey, cfg.Embedding.BaseURL)
+	default:
+		return nil, fmt.Errorf("unknown embedding provider: %s", cfg.Embedding.Provider)
+	}
+}
@@ -0,0 +1,85 @@
+package embedding
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const defaultLocalDim = 384
+
+// Local is a deterministic feature-hashing embedder that runs in-process.
+// It captures lexical rather than semantic similarity, which makes it suitable
+// for tests and offline deployments without an embedding API.
+type Local struct {
+	model string
+	dim   int
+}
+
+func NewLocal(model string, dim int) *Local {
+	if model == "" {
+		model = "local-hash"
+	}
+	if dim <= 0 {
+		dim = defaultLocalDim
+	}
+	return &Local{model: model, dim: dim}
+}
+
+func (l *Local) Model() string { return l.model }
+
+func (l *Local) Dimensions() int { return l.dim }
+
+func (l *Local) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		out[i] = l.embed(text)
+	}
+	return out, nil
+}
+
+func (l *Local) embed(text string) []float32 {
+	vec := make([]float32, l.dim)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		l.add(vec, "w:"+w, 1)
+		// Character trigrams let related word forms share features
+		runes := []rune("^" + w + "$")
+		for i := 0; i+3 <= len(runes); i++ {
+			l.add(vec, "t:"+string(runes[i:i+3]), 0.5)
+		}
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return vec
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vec {
+		vec[i] *= scale
+	}
+	return vec
+}
+
+// add hashes a feature into a bucket, using one hash bit as the sign to reduce collision bias
+func (l *Local) add(vec []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	idx := int(sum % uint64(l.dim))
+	if sum&(1<<63) != 0 {
+		weight = -weight
+	}
+	vec[idx] += weight
+}
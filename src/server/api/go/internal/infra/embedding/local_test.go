@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func TestLocal_Deterministic(t *testing.T) {
+	l := NewLocal("", 64)
+	assert.Equal(t, "local-hash", l.Model())
+	assert.Equal(t, 64, l.Dimensions())
+
+	a, err := l.Embed(context.Background(), []string{"hello world"})
+	require.NoError(t, err)
+	b, err := NewLocal("", 64).Embed(context.Background(), []string{"hello world"})
+	require.NoError(t, err)
+
+	assert.Len(t, a[0], 64)
+	assert.Equal(t, a, b)
+	assert.InDelta(t, 1.0, cosine(a[0], a[0]), 1e-6)
+}
+
+func TestLocal_Similarity(t *testing.T) {
+	l := NewLocal("", 256)
+	vecs, err := l.Embed(context.Background(), []string{
+		"how to configure the database connection",
+		"Database connection configuration guide",
+		"chocolate cake recipe with strawberries",
+	})
+	require.NoError(t, err)
+
+	assert.Greater(t, cosine(vecs[0], vecs[1]), cosine(vecs[0], vecs[2]))
+}
+
+func TestLocal_EmptyText(t *testing.T) {
+	vecs, err := NewLocal("", 16).Embed(context.Background(), []string{"  "})
+	require.NoError(t, err)
+	assert.Equal(t, make([]float32, 16), vecs[0])
+}
+
+func TestLocal_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewLocal("", 16).Embed(ctx, []string{"a"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,77 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// maxOpenAIBatch is the maximum number of inputs sent in one embeddings request
+const maxOpenAIBatch = 256
+
+// OpenAI embeds texts with an OpenAI-compatible embeddings API
+type OpenAI struct {
+	client openai.Client
+	model  string
+	dim    int
+}
+
+func NewOpenAI(model string, dim int, apiKey string, baseURL string) (*OpenAI, error) {
+	if apiKey == "" {
+		return nil, errors.New("embedding api key is required for the openai provider")
+	}
+	if model == "" {
+		model = openai.EmbeddingModelTextEmbedding3Small
+	}
+
+	opts := []option.RequestOption{option.WithAPIKey(apiKey)}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+
+	return &OpenAI{client: openai.NewClient(opts...), model: model, dim: dim}, nil
+}
+
+func (o *OpenAI) Model() string { return o.model }
+
+func (o *OpenAI) Dimensions() int { return o.dim }
+
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxOpenAIBatch {
+		end := min(start+maxOpenAIBatch, len(texts))
+
+		params := openai.EmbeddingNewParams{
+			Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts[start:end]},
+			Model: o.model,
+		}
+		if o.dim > 0 {
+			params.Dimensions = openai.Int(int64(o.dim))
+		}
+
+		resp, err := o.client.Embeddings.New(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("create embeddings: %w", err)
+		}
+		if len(resp.Data) != end-start {
+			return nil, fmt.Errorf("create embeddings: expected %d vectors, got %d", end-start, len(resp.Data))
+		}
+
+		batch := make([][]float32, end-start)
+		for _, d := range resp.Data {
+			if d.Index < 0 || int(d.Index) >= len(batch) {
+				return nil, fmt.Errorf("create embeddings: unexpected index %d", d.Index)
+			}
+			vec := make([]float32, len(d.Embedding))
+			for i, v := range d.Embedding {
+				vec[i] = float32(v)
+			}
+			batch[d.Index] = vec
+		}
+		out = append(out, batch...)
+	}
+	return out, nil
+}
@@ -0,0 +1,88 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/redis/go-redis/v9"
+)
+
+// Record is what is kept under an idempotency key: a claim while the first request runs, then
+// the response to replay
+type Record struct {
+	// Fingerprint identifies the request the key was first used with
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Store keeps idempotency records shared by every instance of the API
+type Store interface {
+	// Claim reserves key for a request until lock has passed. It returns nil when the claim
+	// succeeded, or the record already under key.
+	Claim(ctx context.Context, key, fingerprint string, lock time.Duration) (*Record, error)
+	// Save replaces the claim on key with the response to replay until ttl has passed
+	Save(ctx context.Context, key string, rec *Record, ttl time.Duration) error
+	// Release drops the claim on key, so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// claimScript returns the record under KEYS[1], or stores ARGV[1] there for ARGV[2] milliseconds
+var claimScript = redis.NewScript(`
+local rec = redis.call('GET', KEYS[1])
+if rec then
+	return rec
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return false
+`)
+
+type redisStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStore(rdb *redis.Client) Store {
+	return &redisStore{rdb: rdb}
+}
+
+func (s *redisStore) Claim(ctx context.Context, key, fingerprint string, lock time.Duration) (*Record, error) {
+	claim, err := sonic.Marshal(&Record{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, fmt.Errorf("encode claim: %w", err)
+	}
+	raw, err := claimScript.Run(ctx, s.rdb, []string{key}, claim, lock.Milliseconds()).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim key: %w", err)
+	}
+	var rec Record
+	if err := sonic.UnmarshalString(raw, &rec); err != nil {
+		return nil, fmt.Errorf("decode record: %w", err)
+	}
+	return &rec, nil
+}
+
+func (s *redisStore) Save(ctx context.Context, key string, rec *Record, ttl time.Duration) error {
+	raw, err := sonic.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode record: %w", err)
+	}
+	if err := s.rdb.Set(ctx, key, raw, ttl).Err(); err != nil {
+		return fmt.Errorf("save record: %w", err)
+	}
+	return nil
+}
+
+func (s *redisStore) Release(ctx context.Context, key string) error {
+	if err := s.rdb.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("release key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestRedis connects to the Redis of the development environment
+// Note: This requires a running Redis instance for integration tests
+func setupTestRedis(t *testing.T) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:16379", Password: "helloworld"})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skip("Test Redis not available, skipping integration tests")
+	}
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+func TestRedisStore(t *testing.T) {
+	rdb := setupTestRedis(t)
+	store := NewRedisStore(rdb)
+	ctx := context.Background()
+
+	key := "idempotency:test:" + uuid.NewString()
+	t.Cleanup(func() { rdb.Del(ctx, key) })
+
+	rec, err := store.Claim(ctx, key, "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+
+	rec, err = store.Claim(ctx, key, "fp", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	assert.Equal(t, "fp", rec.Fingerprint)
+	assert.False(t, rec.Done)
+
+	saved := &Record{Fingerprint: "fp", Done: true, Status: 201, ContentType: "application/json", Body: []byte(`{"ok":true}`)}
+	require.NoError(t, store.Save(ctx, key, saved, time.Minute))
+	rec, err = store.Claim(ctx, key, "fp", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, saved, rec)
+
+	require.NoError(t, store.Release(ctx, key))
+	rec, err = store.Claim(ctx, key, "other", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+}
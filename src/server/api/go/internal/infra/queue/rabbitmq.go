@@ -128,6 +128,18 @@ func NewConsumer(conn *amqp.Connection, queueName string, prefetch int, log *zap
 
 func (c *Consumer) Close() error { return c.ch.Close() }
 
+// Bind declares a durable direct exchange and binds the consumer queue to it with the routing key.
+// Declaring the exchange here also guarantees it exists before anything is published to it.
+func (c *Consumer) Bind(exchangeName string, routingKey string) error {
+	if err := c.ch.ExchangeDeclare(exchangeName, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare exchange %s: %w", exchangeName, err)
+	}
+	if err := c.ch.QueueBind(c.q.Name, routingKey, exchangeName, false, nil); err != nil {
+		return fmt.Errorf("bind queue %s: %w", c.q.Name, err)
+	}
+	return nil
+}
+
 // Handle is a consumption helper function that will Nack and requeue when the handler returns an error.
 func (c *Consumer) Handle(ctx context.Context, handler func([]byte) error) error {
 	msgs, err := c.ch.Consume(c.q.Name, "", false, false, false, false, nil)
@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Bucket is a token bucket holding up to Burst tokens, refilled with Rate tokens per second
+type Bucket struct {
+	Key   string
+	Rate  float64
+	Burst int
+}
+
+// Result is the outcome of taking from several buckets at once. Limit, Remaining and Reset
+// describe the bucket with the fewest tokens left.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until that bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until every bucket has enough tokens, when the take was refused
+	RetryAfter time.Duration
+}
+
+// Store keeps rate limit state shared by every instance of the API
+type Store interface {
+	// Take takes cost tokens from every bucket, or from none of them when one has too few
+	Take(ctx context.Context, buckets []Bucket, cost int) (Result, error)
+	// Acquire holds one of limit slots under key until it is released or ttl has passed.
+	// It returns the lease to release, or "" when all slots are held.
+	Acquire(ctx context.Context, key string, limit int, ttl time.Duration) (string, error)
+	// Release frees the slot held by lease
+	Release(ctx context.Context, key, lease string) error
+}
+
+// takeScript refills and takes from token buckets stored as hashes. ARGV holds the cost, then
+// the rate and burst of every key. Time comes from Redis so API instances agree on it.
+var takeScript = redis.NewScript(`
+local now = redis.call('TIME')
+now = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+local cost = tonumber(ARGV[1])
+
+local tokens = {}
+local allowed = 1
+local retry = 0
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[2 * i])
+	local burst = tonumber(ARGV[2 * i + 1])
+	local state = redis.call('HMGET', key, 'tokens', 'ts')
+	local t = tonumber(state[1])
+	local ts = tonumber(state[2])
+	if t == nil or ts == nil then
+		t = burst
+	else
+		t = math.min(burst, t + math.max(0, now - ts) * rate / 1000)
+	end
+	if t < cost then
+		allowed = 0
+		retry = math.max(retry, math.ceil((cost - t) * 1000 / rate))
+	end
+	tokens[i] = t
+end
+
+local limit, remaining, reset = 0, -1, 0
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[2 * i])
+	local burst = tonumber(ARGV[2 * i + 1])
+	local t = tokens[i]
+	if allowed == 1 then
+		t = t - cost
+		redis.call('HSET', key, 'tokens', tostring(t), 'ts', now)
+		redis.call('PEXPIRE', key, math.ceil(burst * 1000 / rate) + 1000)
+	end
+	if remaining < 0 or t < remaining then
+		limit = burst
+		remaining = t
+		reset = math.ceil((burst - t) * 1000 / rate)
+	end
+end
+return {allowed, retry, limit, math.floor(remaining), reset}
+`)
+
+// acquireScript drops expired leases from a sorted set scored by expiry, then adds ARGV[3]
+// when fewer than ARGV[1] are left
+var acquireScript = redis.NewScript(`
+local now = redis.call('TIME')
+now = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+local ttl = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], now + ttl, ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ttl)
+return 1
+`)
+
+type redisStore struct {
+	rdb *redis.Client
+}
+
+// NewRedisStore keeps rate limit state in Redis. Every key of one Take must hash to the same
+// cluster slot, e.g. by sharing a {hash tag}.
+func NewRedisStore(rdb *redis.Client) Store {
+	return &redisStore{rdb: rdb}
+}
+
+func (s *redisStore) Take(ctx context.Context, buckets []Bucket, cost int) (Result, error) {
+	if len(buckets) == 0 {
+		return Result{Allowed: true}, nil
+	}
+	keys := make([]string, 0, len(buckets))
+	args := make([]any, 0, 1+2*len(buckets))
+	args = append(args, cost)
+	for _, b := range buckets {
+		if b.Rate <= 0 || b.Burst <= 0 {
+			return Result{}, fmt.Errorf("bucket %s: rate and burst must be positive", b.Key)
+		}
+		keys = append(keys, b.Key)
+		args = append(args, b.Rate, b.Burst)
+	}
+
+	res, err := takeScript.Run(ctx, s.rdb, keys, args...).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("take tokens: %w", err)
+	}
+	if len(res) != 5 {
+		return Result{}, fmt.Errorf("take tokens: unexpected reply %v", res)
+	}
+	return Result{
+		Allowed:    res[0] == 1,
+		RetryAfter: time.Duration(res[1]) * time.Millisecond,
+		Limit:      int(res[2]),
+		Remaining:  int(res[3]),
+		Reset:      time.Duration(res[4]) * time.Millisecond,
+	}, nil
+}
+
+func (s *redisStore) Acquire(ctx context.Context, key string, limit int, ttl time.Duration) (string, error) {
+	lease := uuid.NewString()
+	ok, err := acquireScript.Run(ctx, s.rdb, []string{key}, limit, ttl.Milliseconds(), lease).Int()
+	if err != nil {
+		return "", fmt.Errorf("acquire slot: %w", err)
+	}
+	if ok != 1 {
+		return "", nil
+	}
+	return lease, nil
+}
+
+func (s *redisStore) Release(ctx context.Context, key, lease string) error {
+	if err := s.rdb.ZRem(ctx, key, lease).Err(); err != nil {
+		return fmt.Errorf("release slot: %w", err)
+	}
+	return nil
+}
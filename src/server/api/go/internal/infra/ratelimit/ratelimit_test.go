@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestRedis connects to the Redis of the development environment
+// Note: This requires a running Redis instance for integration tests
+func setupTestRedis(t *testing.T) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:16379", Password: "helloworld"})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skip("Test Redis not available, skipping integration tests")
+	}
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+func TestRedisStore_Take(t *testing.T) {
+	rdb := setupTestRedis(t)
+	store := NewRedisStore(rdb)
+	ctx := context.Background()
+
+	tag := "{" + uuid.NewString() + "}"
+	slow := Bucket{Key: "ratelimit:test:" + tag + ":slow", Rate: 0.1, Burst: 2}
+	fast := Bucket{Key: "ratelimit:test:" + tag + ":fast", Rate: 100, Burst: 5}
+	t.Cleanup(func() { rdb.Del(ctx, slow.Key, fast.Key) })
+
+	res, err := store.Take(ctx, []Bucket{slow, fast}, 1)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Limit)
+	assert.Equal(t, 1, res.Remaining)
+
+	res, err = store.Take(ctx, []Bucket{slow, fast}, 1)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// The slow bucket is empty; the fast one must not be charged for the refused request
+	res, err = store.Take(ctx, []Bucket{slow, fast}, 1)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Greater(t, res.RetryAfter, time.Second)
+
+	res, err = store.Take(ctx, []Bucket{fast}, 1)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.GreaterOrEqual(t, res.Remaining, 2)
+}
+
+func TestRedisStore_Acquire(t *testing.T) {
+	rdb := setupTestRedis(t)
+	store := NewRedisStore(rdb)
+	ctx := context.Background()
+
+	key := "concurrency:test:{" + uuid.NewString() + "}"
+	t.Cleanup(func() { rdb.Del(ctx, key) })
+
+	first, err := store.Acquire(ctx, key, 1, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+
+	second, err := store.Acquire(ctx, key, 1, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, second)
+
+	require.NoError(t, store.Release(ctx, key, first))
+	third, err := store.Acquire(ctx, key, 1, 50*time.Millisecond)
+	require.NoError(t, err)
+	require.NotEmpty(t, third)
+
+	// An expired lease no longer holds its slot
+	time.Sleep(100 * time.Millisecond)
+	fourth, err := store.Acquire(ctx, key, 1, time.Minute)
+	require.NoError(t, err)
+	assert.NotEmpty(t, fourth)
+}
@@ -0,0 +1,168 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// SignatureHeader carries "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">" keyed by the
+	// secret of the endpoint. Receivers should check it and reject old timestamps.
+	SignatureHeader  = "Acontext-Signature"
+	EventIDHeader    = "Acontext-Event-Id"
+	EventTypeHeader  = "Acontext-Event-Type"
+	DeliveryIDHeader = "Acontext-Delivery-Id"
+
+	// maxResponseBody is how much of the answer of an endpoint is kept in the delivery log
+	maxResponseBody = 1024
+)
+
+var (
+	// ErrForbiddenAddress is returned when an endpoint resolves to a loopback, private or
+	// link-local address and those are not allowed
+	ErrForbiddenAddress = errors.New("webhook endpoint resolves to a non-public address")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Sign returns the signature header of body sent at t
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + mac(secret, ts, body)
+}
+
+// Verify checks a signature header made by Sign, and that it was made within tolerance of now
+func Verify(secret string, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return ErrInvalidSignature
+	}
+	if d := now.Sub(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+	if !hmac.Equal([]byte(sig), []byte(mac(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func mac(secret string, ts string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(ts))
+	m.Write([]byte("."))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// Request is one attempt of a delivery
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID string
+	EventID    string
+	EventType  string
+	Body       []byte
+}
+
+// Response is what an endpoint answered
+type Response struct {
+	Status int
+	Body   string // The start of the body
+}
+
+// OK reports whether the endpoint accepted the delivery
+func (r *Response) OK() bool {
+	return r.Status >= 200 && r.Status < 300
+}
+
+// Client sends signed deliveries. Redirects are not followed, and unless private networks are
+// allowed it refuses to connect to addresses that are not public, so endpoints cannot be used
+// to reach internal services.
+type Client struct {
+	http *http.Client
+	now  func() time.Time
+}
+
+func NewClient(timeout time.Duration, allowPrivateNetworks bool) *Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		// Checked on the resolved address of every connection, so DNS cannot point around it
+		dialer.Control = func(_ string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		}
+	}
+	return &Client{
+		http: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConnsPerHost: 4,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// IsPublicIP reports whether ip is a public unicast address
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// Send makes one attempt of a delivery. An error means no answer was received.
+func (c *Client) Send(ctx context.Context, req Request) (*Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "Acontext-Webhook/1")
+	httpReq.Header.Set(SignatureHeader, Sign(req.Secret, c.now(), req.Body))
+	httpReq.Header.Set(EventIDHeader, req.EventID)
+	httpReq.Header.Set(EventTypeHeader, req.EventType)
+	httpReq.Header.Set(DeliveryIDHeader, req.DeliveryID)
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	// Drain a little more so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return &Response{Status: resp.StatusCode, Body: string(body)}, nil
+}
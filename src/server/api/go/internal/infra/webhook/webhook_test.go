@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1735689600, 0)
+	body := []byte(`{"id":"evt"}`)
+	header := Sign("whsec_test", now, body)
+
+	assert.True(t, strings.HasPrefix(header, "t=1735689600,v1="))
+	assert.NoError(t, Verify("whsec_test", header, body, 5*time.Minute, now.Add(time.Minute)))
+
+	assert.ErrorIs(t, Verify("whsec_other", header, body, 5*time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("whsec_test", header, []byte(`{"id":"evt2"}`), 5*time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("whsec_test", header, body, 5*time.Minute, now.Add(10*time.Minute)), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("whsec_test", "v1=abc", body, 5*time.Minute, now), ErrInvalidSignature)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1":    true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+	} {
+		assert.Equal(t, public, IsPublicIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestClient_Send(t *testing.T) {
+	body := []byte(`{"id":"evt","type":"task.created"}`)
+	var received *http.Request
+	var receivedBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(strings.Repeat("x", 2*maxResponseBody)))
+	}))
+	defer srv.Close()
+
+	c := NewClient(5*time.Second, true)
+	resp, err := c.Send(context.Background(), Request{
+		URL:        srv.URL,
+		Secret:     "whsec_test",
+		DeliveryID: "dlv",
+		EventID:    "evt",
+		EventType:  "task.created",
+		Body:       body,
+	})
+
+	require.NoError(t, err)
+	assert.True(t, resp.OK())
+	assert.Equal(t, http.StatusAccepted, resp.Status)
+	assert.Len(t, resp.Body, maxResponseBody)
+	assert.Equal(t, body, receivedBody)
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+	assert.Equal(t, "evt", received.Header.Get(EventIDHeader))
+	assert.Equal(t, "task.created", received.Header.Get(EventTypeHeader))
+	assert.Equal(t, "dlv", received.Header.Get(DeliveryIDHeader))
+	assert.NoError(t, Verify("whsec_test", received.Header.Get(SignatureHeader), receivedBody, time.Minute, time.Now()))
+}
+
+func TestClient_DoesNotFollowRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	resp, err := NewClient(5*time.Second, true).Send(context.Background(), Request{URL: srv.URL, Body: []byte(`{}`)})
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, resp.Status)
+	assert.False(t, resp.OK())
+}
+
+func TestClient_RefusesPrivateAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	_, err := NewClient(5*time.Second, false).Send(context.Background(), Request{URL: srv.URL, Body: []byte(`{}`)})
+
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+	assert.False(t, called)
+}
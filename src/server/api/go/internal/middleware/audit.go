@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/audit"
+)
+
+// auditedResources are the context keys the tenant loaders set, innermost first
+var auditedResources = []string{"block", "session", "disk", "space"}
+
+// Audit writes an audit event for every mutating request that reaches it, whatever its outcome.
+// The event records who made the request, the route and the IDs in its params, a summary of
+// the resource loaded by the tenant loaders as it was before, and a summary of what the
+// services reported with audit.After. It must run after ProjectAuth, RootAuth or ResolveShare.
+// Handlers that authenticate the request themselves, like presigned uploads, set the project
+// and the audit_actor as they run.
+type Audit struct {
+	svc service.AuditService
+	log *zap.Logger
+}
+
+func NewAudit(svc service.AuditService, log *zap.Logger) *Audit {
+	return &Audit{svc: svc, log: log}
+}
+
+// Handle records the mutating requests
+func (a *Audit) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		entry := &audit.Entry{}
+		c.Request = c.Request.WithContext(audit.NewContext(c.Request.Context(), entry))
+		c.Next()
+
+		after := entry.AfterSummary()
+		ids := datatypes.JSONMap{}
+		for _, p := range c.Params {
+			if strings.HasSuffix(p.Key, "_id") {
+				ids[p.Key] = p.Value
+			}
+		}
+		if id, ok := after["id"].(string); ok {
+			ids["id"] = id
+		}
+
+		ev := &model.AuditEvent{
+			Actor:        model.AuditActorProjectSecret,
+			Method:       c.Request.Method,
+			Route:        c.FullPath(),
+			Status:       c.Writer.Status(),
+			ResourceType: resourceType(c),
+			ResourceIDs:  ids,
+			After:        after,
+			TraceID:      TraceIDFromContext(c.Request.Context()),
+			ClientIP:     c.ClientIP(),
+		}
+		for _, key := range auditedResources {
+			if v, ok := c.Get(key); ok {
+				ev.Before = audit.Summary(v)
+				break
+			}
+		}
+
+		if project, ok := c.Get("project"); ok {
+			ev.ProjectID = project.(*model.Project).ID
+			if apiKey, ok := c.Get("api_key"); ok {
+				ev.Actor = model.AuditActorAPIKey
+				ev.APIKeyID = &apiKey.(*model.ProjectAPIKey).ID
+			}
+			if actor, ok := c.Get("audit_actor"); ok {
+				ev.Actor = actor.(string)
+			}
+			if share, ok := c.Get("share"); ok {
+				ids["share_id"] = share.(*model.DiskShare).ID.String()
+			}
+		} else {
+			// Admin requests name their project in the path, or create it
+			ev.Actor = model.AuditActorRoot
+			projectID, err := uuid.Parse(c.Param("project_id"))
+			if id, ok := ids["id"].(string); ok && err != nil {
+				projectID, err = uuid.Parse(id)
+			}
+			if err != nil {
+				a.log.Debug("audit: request names no project", zap.String("route", ev.Route))
+				return
+			}
+			ev.ProjectID = projectID
+		}
+
+		// The request already happened, so a failure to record it must not change its response
+		if err := a.svc.Record(context.WithoutCancel(c.Request.Context()), ev); err != nil {
+			a.log.Error("audit: record event", zap.String("route", ev.Route), zap.Error(err))
+		}
+	}
+}
+
+// resourceType is the route group of a request, or the group under /admin for admin requests
+func resourceType(c *gin.Context) string {
+	group := routeGroup(c)
+	if group == "admin" {
+		rest := strings.TrimPrefix(c.FullPath(), "/api/v1/admin/")
+		group, _, _ = strings.Cut(rest, "/")
+	}
+	return group
+}
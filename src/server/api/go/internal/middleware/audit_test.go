@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/audit"
+)
+
+type fakeAuditService struct {
+	events []*model.AuditEvent
+	err    error
+}
+
+func (s *fakeAuditService) Record(_ context.Context, e *model.AuditEvent) error {
+	s.events = append(s.events, e)
+	return s.err
+}
+
+func (s *fakeAuditService) List(context.Context, service.ListAuditEventsInput) (*service.ListAuditEventsOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeAuditService) Prune(context.Context, time.Duration) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func TestAudit_ProjectRequests(t *testing.T) {
+	project := &model.Project{ID: uuid.New()}
+	apiKey := &model.ProjectAPIKey{ID: uuid.New(), ProjectID: project.ID}
+	session := &model.Session{ID: uuid.New(), ProjectID: project.ID, Configs: datatypes.JSONMap{"mode": "chat"}}
+	created := uuid.New()
+
+	gin.SetMode(gin.TestMode)
+	svc := &fakeAuditService{}
+	r := gin.New()
+	v1 := r.Group("/api/v1")
+	v1.Use(func(c *gin.Context) {
+		c.Set("project", project)
+		if c.GetHeader("X-Key") != "" {
+			c.Set("api_key", apiKey)
+		}
+		c.Next()
+	}, NewAudit(svc, zap.NewNop()).Handle())
+	v1.POST("/session", func(c *gin.Context) {
+		audit.After(c.Request.Context(), &model.Session{ID: created, ProjectID: project.ID})
+		c.Status(http.StatusCreated)
+	})
+	v1.GET("/session/:session_id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	v1.DELETE("/session/:session_id", func(c *gin.Context) {
+		// What the tenant loader sets before the handler runs
+		c.Set("session", session)
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		key      bool
+		expected *model.AuditEvent
+	}{
+		{
+			name:   "create with the project secret",
+			method: http.MethodPost,
+			path:   "/api/v1/session",
+			expected: &model.AuditEvent{
+				ProjectID:    project.ID,
+				Actor:        model.AuditActorProjectSecret,
+				Method:       http.MethodPost,
+				Route:        "/api/v1/session",
+				Status:       http.StatusCreated,
+				ResourceType: "session",
+				ResourceIDs:  datatypes.JSONMap{"id": created.String()},
+			},
+		},
+		{
+			name:   "delete with an api key",
+			method: http.MethodDelete,
+			path:   "/api/v1/session/" + session.ID.String(),
+			key:    true,
+			expected: &model.AuditEvent{
+				ProjectID:    project.ID,
+				Actor:        model.AuditActorAPIKey,
+				APIKeyID:     &apiKey.ID,
+				Method:       http.MethodDelete,
+				Route:        "/api/v1/session/:session_id",
+				Status:       http.StatusOK,
+				ResourceType: "session",
+				ResourceIDs:  datatypes.JSONMap{"session_id": session.ID.String()},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc.events = nil
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.RemoteAddr = "203.0.113.7:1234"
+			if tt.key {
+				req.Header.Set("X-Key", "1")
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Len(t, svc.events, 1)
+			ev := svc.events[0]
+			assert.Equal(t, tt.expected.ProjectID, ev.ProjectID)
+			assert.Equal(t, tt.expected.Actor, ev.Actor)
+			assert.Equal(t, tt.expected.APIKeyID, ev.APIKeyID)
+			assert.Equal(t, tt.expected.Method, ev.Method)
+			assert.Equal(t, tt.expected.Route, ev.Route)
+			assert.Equal(t, tt.expected.Status, ev.Status)
+			assert.Equal(t, tt.expected.ResourceType, ev.ResourceType)
+			assert.Equal(t, tt.expected.ResourceIDs, ev.ResourceIDs)
+			assert.Equal(t, "203.0.113.7", ev.ClientIP)
+		})
+	}
+
+	t.Run("summaries", func(t *testing.T) {
+		svc.events = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/session/"+session.ID.String(), nil))
+		require.Len(t, svc.events, 1)
+		assert.Equal(t, session.ID.String(), svc.events[0].Before["id"])
+		assert.Equal(t, map[string]any{"mode": "chat"}, svc.events[0].Before["configs"])
+		assert.Nil(t, svc.events[0].After)
+
+		svc.events = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/session", nil))
+		require.Len(t, svc.events, 1)
+		assert.Nil(t, svc.events[0].Before)
+		assert.Equal(t, created.String(), svc.events[0].After["id"])
+	})
+
+	t.Run("reads are not audited", func(t *testing.T) {
+		svc.events = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/session/"+session.ID.String(), nil))
+		assert.Empty(t, svc.events)
+	})
+
+	t.Run("failing to record keeps the response", func(t *testing.T) {
+		svc.events = nil
+		svc.err = errors.New("database down")
+		defer func() { svc.err = nil }()
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/session", nil))
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func TestAudit_AdminRequests(t *testing.T) {
+	projectID := uuid.New()
+	keyID := uuid.New()
+
+	gin.SetMode(gin.TestMode)
+	svc := &fakeAuditService{}
+	r := gin.New()
+	admin := r.Group("/api/v1/admin")
+	admin.Use(NewAudit(svc, zap.NewNop()).Handle())
+	admin.POST("/project", func(c *gin.Context) {
+		audit.After(c.Request.Context(), &model.Project{ID: projectID})
+		c.Status(http.StatusCreated)
+	})
+	admin.DELETE("/project/:project_id/key/:key_id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/admin/project", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/admin/project/"+projectID.String()+"/key/"+keyID.String(), nil))
+
+	require.Len(t, svc.events, 2)
+	for _, ev := range svc.events {
+		assert.Equal(t, projectID, ev.ProjectID)
+		assert.Equal(t, model.AuditActorRoot, ev.Actor)
+		assert.Nil(t, ev.APIKeyID)
+		assert.Equal(t, "project", ev.ResourceType)
+	}
+	assert.Equal(t, http.StatusNotFound, svc.events[1].Status)
+	assert.Equal(t, datatypes.JSONMap{"project_id": projectID.String(), "key_id": keyID.String()}, svc.events[1].ResourceIDs)
+}
+
+func TestAudit_PublicRequests(t *testing.T) {
+	project := &model.Project{ID: uuid.New()}
+	share := &model.DiskShare{ID: uuid.New(), ProjectID: project.ID, DiskID: uuid.New(), Path: "/"}
+
+	gin.SetMode(gin.TestMode)
+	svc := &fakeAuditService{}
+	a := NewAudit(svc, zap.NewNop())
+	r := gin.New()
+	shared := r.Group("/api/v1/share/:token")
+	shared.Use(func(c *gin.Context) {
+		// What ResolveShare sets for a valid token
+		c.Set("share", share)
+		c.Set("project", &model.Project{ID: share.ProjectID})
+		c.Set("audit_actor", model.AuditActorShare)
+		c.Next()
+	}, a.Handle())
+	shared.POST("/artifact", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	r.PUT("/api/v1/blob/*key", a.Handle(), func(c *gin.Context) {
+		// What the blob handler sets once the signature checks out
+		if c.Query("signature") != "" {
+			c.Set("project", project)
+			c.Set("audit_actor", model.AuditActorPresignedURL)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("share link", func(t *testing.T) {
+		svc.events = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/share/tok/artifact", nil))
+
+		require.Len(t, svc.events, 1)
+		ev := svc.events[0]
+		assert.Equal(t, project.ID, ev.ProjectID)
+		assert.Equal(t, model.AuditActorShare, ev.Actor)
+		assert.Equal(t, "share", ev.ResourceType)
+		assert.Equal(t, datatypes.JSONMap{"share_id": share.ID.String()}, ev.ResourceIDs)
+	})
+
+	t.Run("presigned upload", func(t *testing.T) {
+		svc.events = nil
+		key := "uploads/" + project.ID.String() + "/" + uuid.NewString()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/api/v1/blob/"+key+"?signature=s", nil))
+
+		require.Len(t, svc.events, 1)
+		ev := svc.events[0]
+		assert.Equal(t, project.ID, ev.ProjectID)
+		assert.Equal(t, model.AuditActorPresignedURL, ev.Actor)
+		assert.Equal(t, "blob", ev.ResourceType)
+	})
+
+	t.Run("unsigned upload names no project", func(t *testing.T) {
+		svc.events = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/api/v1/blob/uploads/x/y", nil))
+		assert.Empty(t, svc.events)
+	})
+}
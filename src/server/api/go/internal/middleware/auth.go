@@ -1,9 +1,10 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"errors"
-	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/attribute"
@@ -13,48 +14,106 @@ import (
 	"github.com/memodb-io/Acontext/internal/config"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/secrets"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/tokens"
 )
 
+// lastUsedResolution is how often the last-used time of an API key is written at most
+const lastUsedResolution = time.Minute
+
+// RootAuth returns a middleware that only lets requests bearing the root API token through
+func RootAuth(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if cfg.Root.ApiBearerToken == "" || token == auth ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Root.ApiBearerToken)) != 1 {
+			serializer.AuthErr(c, "Unauthorized")
+			return
+		}
+		c.Next()
+	}
+}
+
 // ProjectAuth returns a middleware that authenticates requests using project bearer tokens.
-// It validates the token, looks up the project in the database, and sets the project in the context.
+// The token is either one of the API keys of the project, whose scopes limit the routes it
+// reaches, or the built-in project secret, which has full access and is rotated or revoked
+// through the admin API.
+// It validates the token, looks up the project in the database, and sets the project in the
+// context, along with the API key under "api_key" when one was used.
 // It also sets the project_id attribute on the current span for telemetry filtering.
 func ProjectAuth(cfg *config.Config, db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if !strings.HasPrefix(auth, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, serializer.AuthErr("Unauthorized"))
+			serializer.AuthErr(c, "Unauthorized")
 			return
 		}
 		raw := strings.TrimPrefix(auth, "Bearer ")
 
 		secret, ok := tokens.ParseToken(raw, cfg.Root.ProjectBearerTokenPrefix)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, serializer.AuthErr("Unauthorized"))
+			serializer.AuthErr(c, "Unauthorized")
 			return
 		}
 
 		lookup := tokens.HMAC256Hex(cfg.Root.SecretPepper, secret)
+		ctx := c.Request.Context()
 
 		var project model.Project
-		if err := db.WithContext(c.Request.Context()).Where(&model.Project{SecretKeyHMAC: lookup}).First(&project).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, serializer.AuthErr("Unauthorized"))
+		var key model.ProjectAPIKey
+		err := db.WithContext(ctx).Where(&model.ProjectAPIKey{SecretKeyHMAC: lookup}).First(&key).Error
+		switch {
+		case err == nil:
+			now := time.Now()
+			pass, err := secrets.VerifySecret(secret, cfg.Root.SecretPepper, key.SecretKeyHashPHC)
+			if err != nil || !pass || !key.Active(now) {
+				serializer.AuthErr(c, "Unauthorized")
+				return
+			}
+			if !key.Allows(c.Request.Method, strings.TrimPrefix(c.FullPath(), "/api/v1")) {
+				serializer.Err(c, apierr.ScopeForbidden, "the scopes of this api key do not allow this request", nil)
+				return
+			}
+			// Projects being deleted no longer authenticate
+			if err := db.WithContext(ctx).First(&project, "id = ? AND delete_requested_at IS NULL", key.ProjectID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					serializer.AuthErr(c, "Unauthorized")
+					return
+				}
+				serializer.DBErr(c, "", err)
+				return
+			}
+			// Only write the last-used time once in a while, not on every request
+			_ = db.WithContext(ctx).Model(&model.ProjectAPIKey{}).
+				Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", key.ID, now.Add(-lastUsedResolution)).
+				UpdateColumn("last_used_at", now).Error
+			c.Set("api_key", &key)
+
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := db.WithContext(ctx).Where(&model.Project{SecretKeyHMAC: lookup}).Where("delete_requested_at IS NULL").First(&project).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					serializer.AuthErr(c, "Unauthorized")
+					return
+				}
+				serializer.DBErr(c, "", err)
+				return
+			}
+
+			pass, err := secrets.VerifySecret(secret, cfg.Root.SecretPepper, project.SecretKeyHashPHC)
+			if err != nil || !pass {
+				serializer.AuthErr(c, "Unauthorized")
 				return
 			}
-			c.AbortWithStatusJSON(http.StatusInternalServerError, serializer.DBErr("", err))
-			return
-		}
 
-		pass, err := secrets.VerifySecret(secret, cfg.Root.SecretPepper, project.SecretKeyHashPHC)
-		if err != nil || !pass {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, serializer.AuthErr("Unauthorized"))
+		default:
+			serializer.DBErr(c, "", err)
 			return
 		}
 
 		// Set project_id attribute on the current span for telemetry filtering
-		span := trace.SpanFromContext(c.Request.Context())
+		span := trace.SpanFromContext(ctx)
 		if span.SpanContext().IsValid() {
 			span.SetAttributes(attribute.String("project_id", project.ID.String()))
 		}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/idempotency"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+)
+
+const (
+	// IdempotencyKeyHeader carries the key a client picks for a mutating request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLen bounds the keys clients may send
+	maxIdempotencyKeyLen = 255
+	// maxFingerprintBody is the largest body hashed into the fingerprint of a request. Larger
+	// bodies, and multipart ones whose boundary changes between retries, are left out.
+	maxFingerprintBody = 1 << 20
+)
+
+// Idempotency answers mutating requests sent with an Idempotency-Key header once per key and
+// project. A retry with the same key replays the stored response instead of running again; a
+// retry while the first request is still in flight answers 409, and reusing a key for a
+// different request answers 422. It must run after ProjectAuth.
+//
+// Responses are stored unless they are server errors or 429s, which a retry should run again.
+// When the store cannot be reached requests run without the guarantee rather than failing.
+type Idempotency struct {
+	store idempotency.Store
+	ttl   time.Duration
+	lock  time.Duration
+	log   *zap.Logger
+}
+
+func NewIdempotency(cfg *config.Config, store idempotency.Store, log *zap.Logger) *Idempotency {
+	return &Idempotency{
+		store: store,
+		ttl:   time.Duration(cfg.Idempotency.TTLSec) * time.Second,
+		lock:  time.Duration(cfg.Idempotency.LockSec) * time.Second,
+		log:   log,
+	}
+}
+
+// recordingWriter keeps a copy of the response body it writes
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Handle guards the mutating requests that carry an Idempotency-Key header
+func (i *Idempotency) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			serializer.ParamErr(c, "Idempotency-Key is longer than 255 characters", nil)
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c)
+		if err != nil {
+			serializer.ParamErr(c, "failed to read request body", err)
+			return
+		}
+
+		project := c.MustGet("project").(*model.Project)
+		storeKey := "idempotency:{" + project.ID.String() + "}:" + key
+		ctx := c.Request.Context()
+		rec, err := i.store.Claim(ctx, storeKey, fingerprint, i.lock)
+		if err != nil {
+			i.log.Warn("idempotency store unavailable, running the request without it", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if rec != nil {
+			switch {
+			case !rec.Done:
+				c.Header("Retry-After", "1")
+				serializer.Err(c, apierr.IdempotencyInProgress, "a request with this Idempotency-Key is still in progress", nil)
+			case rec.Fingerprint != fingerprint:
+				serializer.Err(c, apierr.IdempotencyKeyReused, "Idempotency-Key was already used for a different request", nil)
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(rec.Status, rec.ContentType, rec.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		// The request ran to the end whether or not the client is still there
+		ctx = context.WithoutCancel(ctx)
+		status := w.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			if err := i.store.Release(ctx, storeKey); err != nil {
+				i.log.Warn("release idempotency key", zap.Error(err))
+			}
+			return
+		}
+		err = i.store.Save(ctx, storeKey, &idempotency.Record{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+		}, i.ttl)
+		if err != nil {
+			i.log.Warn("save idempotent response", zap.Error(err))
+		}
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestFingerprint hashes the method, URI and body of a request, leaving the body readable
+func requestFingerprint(c *gin.Context) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, c.Request.Method+" "+c.Request.URL.RequestURI()+"\n")
+
+	if c.Request.Body != nil && c.Request.ContentLength <= maxFingerprintBody &&
+		!strings.HasPrefix(c.ContentType(), "multipart/") {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxFingerprintBody+1))
+		if err != nil {
+			return "", err
+		}
+		h.Write(body)
+		// A body of unknown length may go on past the limit; hand the rest on unread
+		c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
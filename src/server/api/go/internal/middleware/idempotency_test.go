@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/idempotency"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+)
+
+type fakeIdempotencyStore struct {
+	records map[string]*idempotency.Record
+	err     error
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{records: map[string]*idempotency.Record{}}
+}
+
+func (s *fakeIdempotencyStore) Claim(_ context.Context, key, fingerprint string, _ time.Duration) (*idempotency.Record, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if rec, ok := s.records[key]; ok {
+		return rec, nil
+	}
+	s.records[key] = &idempotency.Record{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (s *fakeIdempotencyStore) Save(_ context.Context, key string, rec *idempotency.Record, _ time.Duration) error {
+	s.records[key] = rec
+	return nil
+}
+
+func (s *fakeIdempotencyStore) Release(_ context.Context, key string) error {
+	delete(s.records, key)
+	return nil
+}
+
+// idempotencyTestRouter counts the messages stored through it. The project of a request is
+// picked by the X-Project header.
+type idempotencyTestRouter struct {
+	*gin.Engine
+	store  *fakeIdempotencyStore
+	stored int
+	status int
+	bodies []string
+}
+
+func newIdempotencyTestRouter() *idempotencyTestRouter {
+	gin.SetMode(gin.TestMode)
+	tr := &idempotencyTestRouter{Engine: gin.New(), store: newFakeIdempotencyStore(), status: http.StatusCreated}
+	i := NewIdempotency(&config.Config{Idempotency: config.IdempotencyCfg{TTLSec: 60, LockSec: 60}}, tr.store, zap.NewNop())
+	tr.Use(func(c *gin.Context) {
+		c.Set("project", &model.Project{ID: uuid.MustParse(c.GetHeader("X-Project"))})
+		c.Next()
+	}, i.Handle())
+	tr.POST("/session/:session_id/messages", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		tr.bodies = append(tr.bodies, string(body))
+		tr.stored++
+		c.JSON(tr.status, gin.H{"stored": tr.stored})
+	})
+	tr.GET("/session/:session_id/messages", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"stored": tr.stored})
+	})
+	return tr
+}
+
+func (tr *idempotencyTestRouter) do(method, project, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/session/s1/messages", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Project", project)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	tr.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	tr := newIdempotencyTestRouter()
+	project := uuid.NewString()
+
+	first := tr.do(http.MethodPost, project, "retry-1", `{"text":"hi"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	replay := tr.do(http.MethodPost, project, "retry-1", `{"text":"hi"}`)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", replay.Header().Get("Content-Type"))
+
+	assert.Equal(t, 1, tr.stored)
+	// The handler still reads the whole body after it was fingerprinted
+	assert.Equal(t, []string{`{"text":"hi"}`}, tr.bodies)
+}
+
+func TestIdempotency_Requests(t *testing.T) {
+	project := uuid.NewString()
+
+	tests := []struct {
+		name           string
+		setup          func(tr *idempotencyTestRouter)
+		method         string
+		project        string
+		key            string
+		body           string
+		expectedStatus int
+		expectedStored int
+	}{
+		{
+			name:           "key used for a different request",
+			method:         http.MethodPost,
+			project:        project,
+			key:            "retry-1",
+			body:           `{"text":"bye"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedStored: 1,
+		},
+		{
+			name:           "same key in another project",
+			method:         http.MethodPost,
+			project:        uuid.NewString(),
+			key:            "retry-1",
+			body:           `{"text":"hi"}`,
+			expectedStatus: http.StatusCreated,
+			expectedStored: 2,
+		},
+		{
+			name:           "request without a key",
+			method:         http.MethodPost,
+			project:        project,
+			body:           `{"text":"hi"}`,
+			expectedStatus: http.StatusCreated,
+			expectedStored: 2,
+		},
+		{
+			name:           "key on a read",
+			method:         http.MethodGet,
+			project:        project,
+			key:            "retry-1",
+			expectedStatus: http.StatusOK,
+			expectedStored: 1,
+		},
+		{
+			name: "first request still in flight",
+			setup: func(tr *idempotencyTestRouter) {
+				_, _ = tr.store.Claim(context.Background(), "idempotency:{"+project+"}:in-flight", "", time.Minute)
+			},
+			method:         http.MethodPost,
+			project:        project,
+			key:            "in-flight",
+			body:           `{"text":"hi"}`,
+			expectedStatus: http.StatusConflict,
+			expectedStored: 1,
+		},
+		{
+			name:           "key too long",
+			method:         http.MethodPost,
+			project:        project,
+			key:            strings.Repeat("k", 256),
+			body:           `{"text":"hi"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedStored: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newIdempotencyTestRouter()
+			require.Equal(t, http.StatusCreated, tr.do(http.MethodPost, project, "retry-1", `{"text":"hi"}`).Code)
+			if tt.setup != nil {
+				tt.setup(tr)
+			}
+
+			w := tr.do(tt.method, tt.project, tt.key, tt.body)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+			assert.Equal(t, tt.expectedStored, tr.stored)
+		})
+	}
+}
+
+func TestIdempotency_ServerErrorsAreRetried(t *testing.T) {
+	tr := newIdempotencyTestRouter()
+	project := uuid.NewString()
+
+	tr.status = http.StatusInternalServerError
+	assert.Equal(t, http.StatusInternalServerError, tr.do(http.MethodPost, project, "retry-1", `{}`).Code)
+
+	tr.status = http.StatusCreated
+	w := tr.do(http.MethodPost, project, "retry-1", `{}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 2, tr.stored)
+}
+
+func TestIdempotency_StoreUnavailable(t *testing.T) {
+	tr := newIdempotencyTestRouter()
+	tr.store.err = errors.New("connection refused")
+	project := uuid.NewString()
+
+	for range 2 {
+		assert.Equal(t, http.StatusCreated, tr.do(http.MethodPost, project, "retry-1", `{}`).Code)
+	}
+	assert.Equal(t, 2, tr.stored)
+}
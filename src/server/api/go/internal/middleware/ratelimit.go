@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"context"
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/ratelimit"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+)
+
+// rateLimitsConfigKey is the key of the project configs that overrides the default rate limits
+const rateLimitsConfigKey = "rate_limits"
+
+// defaultConcurrencyTTL frees the slot of a request that never finished when no TTL is configured
+const defaultConcurrencyTTL = 5 * time.Minute
+
+// rateLimitRule is a token bucket of Burst requests refilled with Rate requests per second
+type rateLimitRule struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+func (r rateLimitRule) unlimited() bool {
+	return r.Rate <= 0 || r.Burst <= 0
+}
+
+// rateLimits are the request limits of a project. In the project configs, a rule left out
+// keeps its default and a field left out of a rule keeps the default of that field.
+type rateLimits struct {
+	Project     rateLimitRule            `json:"project"`
+	Key         rateLimitRule            `json:"key"`
+	Groups      map[string]rateLimitRule `json:"groups"`
+	Concurrency int                      `json:"concurrency"`
+}
+
+// RateLimit limits the requests of every project with token buckets kept in a shared store.
+// A request takes from the bucket of its project, of the API key or share link it was made
+// with, and of its route group. It must run after ProjectAuth or ResolveShare.
+//
+// When the store cannot be reached requests are let through, so an outage of Redis does not
+// take the API down with it.
+type RateLimit struct {
+	store    ratelimit.Store
+	defaults rateLimits
+	ttl      time.Duration
+	log      *zap.Logger
+}
+
+func NewRateLimit(cfg *config.Config, store ratelimit.Store, log *zap.Logger) *RateLimit {
+	rule := func(r config.RateLimitRule) rateLimitRule {
+		return rateLimitRule{Rate: r.Rate, Burst: r.Burst}
+	}
+	ttl := time.Duration(cfg.RateLimit.ConcurrencyTTLSec) * time.Second
+	if ttl <= 0 {
+		ttl = defaultConcurrencyTTL
+	}
+	groups := make(map[string]rateLimitRule, len(cfg.RateLimit.Groups))
+	for name, r := range cfg.RateLimit.Groups {
+		groups[name] = rule(r)
+	}
+	return &RateLimit{
+		store: store,
+		defaults: rateLimits{
+			Project:     rule(cfg.RateLimit.Project),
+			Key:         rule(cfg.RateLimit.Key),
+			Groups:      groups,
+			Concurrency: cfg.RateLimit.Concurrency,
+		},
+		ttl: ttl,
+		log: log,
+	}
+}
+
+// limits returns the default limits overridden by the project configs. Invalid overrides are
+// logged and ignored.
+func (l *RateLimit) limits(project *model.Project) rateLimits {
+	limits := l.defaults
+	override, ok := project.Configs[rateLimitsConfigKey]
+	if !ok || override == nil {
+		return limits
+	}
+	// Decoding into the defaults keeps what the override leaves out, so copy the shared map first
+	limits.Groups = maps.Clone(l.defaults.Groups)
+	raw, err := sonic.Marshal(override)
+	if err == nil {
+		err = sonic.Unmarshal(raw, &limits)
+	}
+	if err != nil {
+		l.log.Warn("invalid rate limits in project configs, using the defaults",
+			zap.String("project_id", project.ID.String()), zap.Error(err))
+		return l.defaults
+	}
+	return limits
+}
+
+// Requests takes a token from each bucket that limits the request, and answers 429 with
+// Retry-After when one of them is empty. Every limited response reports the bucket with the
+// fewest tokens left in RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset.
+func (l *RateLimit) Requests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		project := c.MustGet("project").(*model.Project)
+		limits := l.limits(project)
+
+		// The {hash tag} keeps the buckets of a project in one Redis cluster slot
+		prefix := "ratelimit:{" + project.ID.String() + "}:"
+		var buckets []ratelimit.Bucket
+		add := func(name string, r rateLimitRule) {
+			if !r.unlimited() {
+				buckets = append(buckets, ratelimit.Bucket{Key: prefix + name, Rate: r.Rate, Burst: r.Burst})
+			}
+		}
+		add("project", limits.Project)
+		key := "secret"
+		if apiKey, ok := c.Get("api_key"); ok {
+			key = apiKey.(*model.ProjectAPIKey).ID.String()
+		} else if share, ok := c.Get("share"); ok {
+			key = "share:" + share.(*model.DiskShare).ID.String()
+		}
+		add("key:"+key, limits.Key)
+		if group := routeGroup(c); group != "" {
+			add("group:"+group, limits.Groups[group])
+		}
+		if len(buckets) == 0 {
+			c.Next()
+			return
+		}
+
+		res, err := l.store.Take(c.Request.Context(), buckets, 1)
+		if err != nil {
+			l.log.Warn("rate limit store unavailable, letting the request through", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", seconds(res.Reset))
+		if !res.Allowed {
+			c.Header("Retry-After", seconds(max(res.RetryAfter, time.Second)))
+			serializer.Err(c, apierr.RateLimited, "rate limit exceeded", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Concurrency caps how many requests of a project run at once, for routes that are expensive
+// to serve. when picks the requests that count; nil counts all of them. A request over the
+// cap answers 429 right away instead of waiting for a slot.
+func (l *RateLimit) Concurrency(when func(c *gin.Context) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if when != nil && !when(c) {
+			c.Next()
+			return
+		}
+		project := c.MustGet("project").(*model.Project)
+		limit := l.limits(project).Concurrency
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		key := "concurrency:{" + project.ID.String() + "}"
+		lease, err := l.store.Acquire(ctx, key, limit, l.ttl)
+		if err != nil {
+			l.log.Warn("rate limit store unavailable, letting the request through", zap.Error(err))
+			c.Next()
+			return
+		}
+		if lease == "" {
+			c.Header("Retry-After", "1")
+			serializer.Err(c, apierr.ConcurrencyLimited, "too many concurrent requests", nil)
+			return
+		}
+		defer func() {
+			// Free the slot even when the client went away
+			if err := l.store.Release(context.WithoutCancel(ctx), key, lease); err != nil {
+				l.log.Warn("release concurrency slot", zap.Error(err))
+			}
+		}()
+		c.Next()
+	}
+}
+
+// routeGroup returns the first segment of the route path under /api/v1, e.g. "session"
+func routeGroup(c *gin.Context) string {
+	path := strings.TrimPrefix(strings.TrimPrefix(c.FullPath(), "/api/v1"), "/")
+	group, _, _ := strings.Cut(path, "/")
+	return group
+}
+
+// seconds formats d as whole seconds, rounded up
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,338 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/ratelimit"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+)
+
+// fakeStore counts the tokens left in each bucket and the slots held under each key, without refills
+type fakeStore struct {
+	tokens map[string]int
+	leases map[string]int
+	taken  [][]ratelimit.Bucket
+	err    error
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{tokens: map[string]int{}, leases: map[string]int{}}
+}
+
+func (s *fakeStore) Take(_ context.Context, buckets []ratelimit.Bucket, cost int) (ratelimit.Result, error) {
+	if s.err != nil {
+		return ratelimit.Result{}, s.err
+	}
+	s.taken = append(s.taken, buckets)
+	res := ratelimit.Result{Allowed: true, Remaining: -1}
+	for _, b := range buckets {
+		if _, ok := s.tokens[b.Key]; !ok {
+			s.tokens[b.Key] = b.Burst
+		}
+		if s.tokens[b.Key] < cost {
+			res.Allowed = false
+			res.RetryAfter = time.Duration(float64(time.Second) / b.Rate)
+		}
+	}
+	for _, b := range buckets {
+		if res.Allowed {
+			s.tokens[b.Key] -= cost
+		}
+		if res.Remaining < 0 || s.tokens[b.Key] < res.Remaining {
+			res.Limit = b.Burst
+			res.Remaining = s.tokens[b.Key]
+			res.Reset = time.Duration(float64(b.Burst-s.tokens[b.Key]) / b.Rate * float64(time.Second))
+		}
+	}
+	return res, nil
+}
+
+func (s *fakeStore) Acquire(_ context.Context, key string, limit int, _ time.Duration) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	if s.leases[key] >= limit {
+		return "", nil
+	}
+	s.leases[key]++
+	return uuid.NewString(), nil
+}
+
+func (s *fakeStore) Release(_ context.Context, key, _ string) error {
+	s.leases[key]--
+	return nil
+}
+
+func newRateLimitRouter(cfg config.RateLimitCfg, store ratelimit.Store, project *model.Project, apiKey *model.ProjectAPIKey) (*gin.Engine, *RateLimit) {
+	gin.SetMode(gin.TestMode)
+	l := NewRateLimit(&config.Config{RateLimit: cfg}, store, zap.NewNop())
+	r := gin.New()
+	v1 := r.Group("/api/v1")
+	v1.Use(func(c *gin.Context) {
+		c.Set("project", project)
+		if apiKey != nil {
+			c.Set("api_key", apiKey)
+		}
+		c.Next()
+	}, l.Requests())
+	v1.GET("/session/:session_id/messages", func(c *gin.Context) { c.Status(http.StatusOK) })
+	v1.GET("/disk", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r, l
+}
+
+func get(r http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func bucketKeys(buckets []ratelimit.Bucket) []string {
+	keys := make([]string, 0, len(buckets))
+	for _, b := range buckets {
+		keys = append(keys, b.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestRateLimit_Requests(t *testing.T) {
+	project := &model.Project{ID: uuid.New()}
+	store := newFakeStore()
+	r, _ := newRateLimitRouter(config.RateLimitCfg{
+		Project: config.RateLimitRule{Rate: 1, Burst: 2},
+	}, store, project, nil)
+
+	w := get(r, "/api/v1/disk")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = get(r, "/api/v1/disk")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = get(r, "/api/v1/disk")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimit_Buckets(t *testing.T) {
+	project := &model.Project{ID: uuid.New()}
+	apiKey := &model.ProjectAPIKey{ID: uuid.New(), ProjectID: project.ID}
+	prefix := "ratelimit:{" + project.ID.String() + "}:"
+	cfg := config.RateLimitCfg{
+		Project: config.RateLimitRule{Rate: 10, Burst: 10},
+		Key:     config.RateLimitRule{Rate: 5, Burst: 5},
+		Groups:  map[string]config.RateLimitRule{"session": {Rate: 1, Burst: 1}},
+	}
+
+	tests := []struct {
+		name     string
+		apiKey   *model.ProjectAPIKey
+		path     string
+		expected []string
+	}{
+		{
+			name:     "project secret outside limited groups",
+			path:     "/api/v1/disk",
+			expected: []string{prefix + "key:secret", prefix + "project"},
+		},
+		{
+			name:   "api key in a limited group",
+			apiKey: apiKey,
+			path:   "/api/v1/session/" + uuid.NewString() + "/messages",
+			expected: []string{
+				prefix + "group:session",
+				prefix + "key:" + apiKey.ID.String(),
+				prefix + "project",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			r, _ := newRateLimitRouter(cfg, store, project, tt.apiKey)
+
+			w := get(r, tt.path)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			require.Len(t, store.taken, 1)
+			assert.Equal(t, tt.expected, bucketKeys(store.taken[0]))
+		})
+	}
+}
+
+func TestRateLimit_Share(t *testing.T) {
+	project := &model.Project{ID: uuid.New()}
+	share := &model.DiskShare{ID: uuid.New(), ProjectID: project.ID, Project: project}
+	prefix := "ratelimit:{" + project.ID.String() + "}:"
+	store := newFakeStore()
+	l := NewRateLimit(&config.Config{RateLimit: config.RateLimitCfg{
+		Project: config.RateLimitRule{Rate: 10, Burst: 10},
+		Key:     config.RateLimitRule{Rate: 1, Burst: 1},
+	}}, store, zap.NewNop())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// What ResolveShare sets for a valid token
+	r.Group("/api/v1/share/:token", func(c *gin.Context) {
+		c.Set("share", share)
+		c.Set("project", share.Project)
+		c.Next()
+	}, l.Requests()).GET("/ls", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := get(r, "/api/v1/share/sh-token/ls")
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, store.taken, 1)
+	assert.Equal(t, []string{prefix + "key:share:" + share.ID.String(), prefix + "project"}, bucketKeys(store.taken[0]))
+
+	w = get(r, "/api/v1/share/sh-token/ls")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestRateLimit_ProjectOverride(t *testing.T) {
+	defaults := config.RateLimitCfg{
+		Project:     config.RateLimitRule{Rate: 10, Burst: 20},
+		Groups:      map[string]config.RateLimitRule{"disk": {Rate: 1, Burst: 1}},
+		Concurrency: 4,
+	}
+	l := NewRateLimit(&config.Config{RateLimit: defaults}, newFakeStore(), zap.NewNop())
+
+	tests := []struct {
+		name     string
+		configs  datatypes.JSONMap
+		expected rateLimits
+	}{
+		{
+			name:    "no override",
+			configs: nil,
+			expected: rateLimits{
+				Project:     rateLimitRule{Rate: 10, Burst: 20},
+				Groups:      map[string]rateLimitRule{"disk": {Rate: 1, Burst: 1}},
+				Concurrency: 4,
+			},
+		},
+		{
+			name: "partial override",
+			configs: datatypes.JSONMap{rateLimitsConfigKey: map[string]any{
+				"project":     map[string]any{"burst": 100},
+				"groups":      map[string]any{"session": map[string]any{"rate": 2, "burst": 4}},
+				"concurrency": 1,
+			}},
+			expected: rateLimits{
+				Project: rateLimitRule{Rate: 10, Burst: 100},
+				Groups: map[string]rateLimitRule{
+					"disk":    {Rate: 1, Burst: 1},
+					"session": {Rate: 2, Burst: 4},
+				},
+				Concurrency: 1,
+			},
+		},
+		{
+			name:    "invalid override",
+			configs: datatypes.JSONMap{rateLimitsConfigKey: "fast"},
+			expected: rateLimits{
+				Project:     rateLimitRule{Rate: 10, Burst: 20},
+				Groups:      map[string]rateLimitRule{"disk": {Rate: 1, Burst: 1}},
+				Concurrency: 4,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits := l.limits(&model.Project{ID: uuid.New(), Configs: tt.configs})
+			assert.Equal(t, tt.expected, limits)
+		})
+	}
+
+	// Overrides never leak into the defaults shared by other projects
+	assert.Len(t, l.defaults.Groups, 1)
+}
+
+func TestRateLimit_StoreUnavailable(t *testing.T) {
+	project := &model.Project{ID: uuid.New()}
+	store := newFakeStore()
+	store.err = errors.New("connection refused")
+	r, _ := newRateLimitRouter(config.RateLimitCfg{
+		Project: config.RateLimitRule{Rate: 1, Burst: 1},
+	}, store, project, nil)
+
+	for range 3 {
+		w := get(r, "/api/v1/disk")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRateLimit_Concurrency(t *testing.T) {
+	project := &model.Project{ID: uuid.New()}
+	store := newFakeStore()
+	key := "concurrency:{" + project.ID.String() + "}"
+	l := NewRateLimit(&config.Config{RateLimit: config.RateLimitCfg{Concurrency: 1}}, store, zap.NewNop())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("project", project)
+		c.Next()
+	})
+
+	// The handler issues a nested request while it holds its own slot
+	var nested *httptest.ResponseRecorder
+	r.GET("/search", l.Concurrency(nil), func(c *gin.Context) {
+		if c.Query("nested") != "" {
+			nested = get(r, "/search")
+		}
+		c.Status(http.StatusOK)
+	})
+	r.GET("/messages", l.Concurrency(func(c *gin.Context) bool { return c.Query("edit") != "" }), func(c *gin.Context) {
+		if c.Query("nested") != "" {
+			nested = get(r, "/messages?edit=1")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedNested int
+	}{
+		{name: "second request over the cap", path: "/search?nested=1", expectedNested: http.StatusTooManyRequests},
+		{name: "requests that do not count", path: "/messages?nested=1", expectedNested: http.StatusOK},
+		{name: "counted request over the cap", path: "/messages?edit=1&nested=1", expectedNested: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(r, tt.path)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			require.NotNil(t, nested)
+			assert.Equal(t, tt.expectedNested, nested.Code)
+			if tt.expectedNested == http.StatusTooManyRequests {
+				assert.Equal(t, "1", nested.Header().Get("Retry-After"))
+			}
+			// Every slot is released once the requests finish
+			assert.Zero(t, store.leases[key])
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -29,13 +30,19 @@ func OtelTracing(serviceName string) gin.HandlerFunc {
 // This is useful for correlating logs and traces in distributed systems.
 func TraceID() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get current span from context
-		span := trace.SpanFromContext(c.Request.Context())
-		if span.SpanContext().IsValid() {
-			// Add trace ID to response header
-			traceID := span.SpanContext().TraceID().String()
+		// Add trace ID of the current span to response header
+		if traceID := TraceIDFromContext(c.Request.Context()); traceID != "" {
 			c.Header("X-Trace-Id", traceID)
 		}
 		c.Next()
 	}
 }
+
+// TraceIDFromContext returns the trace ID of the span in ctx, or "" when there is none
+func TraceIDFromContext(ctx context.Context) string {
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return ""
+	}
+	return span.SpanContext().TraceID().String()
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+)
+
+// Tenant resolves the resources named in route params and checks they belong to the
+// authenticated project. It must run after ProjectAuth.
+//
+// A resource of another project answers 404, exactly like one that does not exist, so
+// requests cannot probe which IDs are in use. The loaded resource is set in the context
+// under its name ("session", "space", "disk" or "block").
+type Tenant struct {
+	sessions repo.SessionRepo
+	spaces   repo.SpaceRepo
+	disks    repo.DiskRepo
+	blocks   repo.BlockRepo
+}
+
+func NewTenant(sessions repo.SessionRepo, spaces repo.SpaceRepo, disks repo.DiskRepo, blocks repo.BlockRepo) *Tenant {
+	return &Tenant{sessions: sessions, spaces: spaces, disks: disks, blocks: blocks}
+}
+
+// Session loads :session_id
+func (t *Tenant) Session() gin.HandlerFunc {
+	return load("session_id", "session", apierr.SessionNotFound, func(c *gin.Context, id uuid.UUID) (*model.Session, error) {
+		return t.sessions.Get(c.Request.Context(), projectID(c), id)
+	})
+}
+
+// Space loads :space_id
+func (t *Tenant) Space() gin.HandlerFunc {
+	return load("space_id", "space", apierr.SpaceNotFound, func(c *gin.Context, id uuid.UUID) (*model.Space, error) {
+		return t.spaces.Get(c.Request.Context(), projectID(c), id)
+	})
+}
+
+// Disk loads :disk_id
+func (t *Tenant) Disk() gin.HandlerFunc {
+	return load("disk_id", "disk", apierr.DiskNotFound, func(c *gin.Context, id uuid.UUID) (*model.Disk, error) {
+		return t.disks.Get(c.Request.Context(), projectID(c), id)
+	})
+}
+
+// Block loads :block_id within the space loaded by Space, which must run first
+func (t *Tenant) Block() gin.HandlerFunc {
+	return load("block_id", "block", apierr.BlockNotFound, func(c *gin.Context, id uuid.UUID) (*model.Block, error) {
+		space := c.MustGet("space").(*model.Space)
+		return t.blocks.Get(c.Request.Context(), space.ID, id)
+	})
+}
+
+func projectID(c *gin.Context) uuid.UUID {
+	return c.MustGet("project").(*model.Project).ID
+}
+
+func load[T any](param, key string, notFound apierr.Code, get func(c *gin.Context, id uuid.UUID) (T, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param(param))
+		if err != nil {
+			serializer.ParamErr(c, "", err)
+			return
+		}
+
+		v, err := get(c, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				serializer.Err(c, notFound, key+" not found", nil)
+				return
+			}
+			serializer.DBErr(c, "", err)
+			return
+		}
+
+		c.Set(key, v)
+		c.Next()
+	}
+}
@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/bytedance/sonic"
@@ -12,16 +17,20 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
 	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/archive"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/fileparser"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/path"
+	"gorm.io/gorm"
 )
 
 type ArtifactHandler struct {
-	svc service.ArtifactService
+	svc       service.ArtifactService
+	searchSvc service.ArtifactSearchService
 }
 
-func NewArtifactHandler(s service.ArtifactService) *ArtifactHandler {
-	return &ArtifactHandler{svc: s}
+func NewArtifactHandler(s service.ArtifactService, searchSvc service.ArtifactSearchService) *ArtifactHandler {
+	return &ArtifactHandler{svc: s, searchSvc: searchSvc}
 }
 
 type CreateArtifactReq struct {
@@ -42,30 +51,31 @@ type CreateArtifactReq struct {
 //	@Param			meta		formData	string	false	"Custom metadata as JSON string (optional, system metadata will be stored under '__artifact_info__' key)"
 //	@Security		BearerAuth
 //	@Success		201	{object}	serializer.Response{data=model.Artifact}
+//	@Failure		413	{object}	serializer.Problem	"Disk quota exceeded"
 //	@Router			/disk/{disk_id}/artifact [post]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Upload a file to disk\nwith open('report.pdf', 'rb') as f:\n    artifact = client.disks.upload_artifact(\n        disk_id='disk-uuid',\n        file=f,\n        file_path='/documents/',\n        meta={'category': 'reports', 'year': 2024}\n    )\nprint(f\"Uploaded artifact: {artifact.id}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\nimport fs from 'fs';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Upload a file to disk\nconst fileBuffer = fs.readFileSync('report.pdf');\nconst artifact = await client.disks.uploadArtifact('disk-uuid', {\n  file: fileBuffer,\n  filePath: '/documents/',\n  meta: { category: 'reports', year: 2024 }\n});\nconsole.log(`Uploaded artifact: ${artifact.id}`);\n","label":"JavaScript"}]
 func (h *ArtifactHandler) UpsertArtifact(c *gin.Context) {
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	req := CreateArtifactReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("file is required", err))
+		serializer.ParamErr(c, "file is required", err)
 		return
 	}
 
@@ -77,7 +87,7 @@ func (h *ArtifactHandler) UpsertArtifact(c *gin.Context) {
 
 	// Validate the path parameter
 	if err := path.ValidatePath(filePath); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+		serializer.ParamErr(c, "invalid path", err)
 		return
 	}
 
@@ -85,7 +95,7 @@ func (h *ArtifactHandler) UpsertArtifact(c *gin.Context) {
 	var userMeta map[string]interface{}
 	if req.Meta != "" {
 		if err := sonic.Unmarshal([]byte(req.Meta), &userMeta); err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid meta JSON format", err))
+			serializer.ParamErr(c, "invalid meta JSON format", err)
 			return
 		}
 
@@ -93,7 +103,7 @@ func (h *ArtifactHandler) UpsertArtifact(c *gin.Context) {
 		reservedKeys := model.GetReservedKeys()
 		for _, reservedKey := range reservedKeys {
 			if _, exists := userMeta[reservedKey]; exists {
-				c.JSON(http.StatusBadRequest, serializer.ParamErr("", fmt.Errorf("reserved key '%s' is not allowed in user meta", reservedKey)))
+				serializer.ParamErr(c, "", fmt.Errorf("reserved key '%s' is not allowed in user meta", reservedKey))
 				return
 			}
 		}
@@ -108,13 +118,40 @@ func (h *ArtifactHandler) UpsertArtifact(c *gin.Context) {
 		UserMeta:   userMeta,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		artifactWriteErr(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, serializer.Response{Data: artifactRecord})
 }
 
+// artifactErr answers a failed read or write of the artifact at a path
+func artifactErr(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		serializer.Err(c, apierr.ArtifactNotFound, "artifact not found", err)
+		return
+	}
+	serializer.DBErr(c, "", err)
+}
+
+// artifactWriteErr answers a failed upload. Missing disks, taken paths and exceeded disk quotas
+// and usage limits are client errors.
+func artifactWriteErr(c *gin.Context, err error) {
+	if usageLimitErr(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrDiskQuotaExceeded):
+		serializer.Err(c, apierr.DiskQuotaExceeded, "disk quota exceeded", err)
+	case errors.Is(err, service.ErrDiskNotFound):
+		serializer.Err(c, apierr.DiskNotFound, "disk not found", err)
+	case errors.Is(err, service.ErrArtifactExists):
+		serializer.Err(c, apierr.ArtifactPathConflict, err.Error(), err)
+	default:
+		serializer.DBErr(c, "", err)
+	}
+}
+
 type DeleteArtifactReq struct {
 	FilePath string `form:"file_path" json:"file_path" binding:"required"` // File path including filename
 }
@@ -135,19 +172,19 @@ type DeleteArtifactReq struct {
 func (h *ArtifactHandler) DeleteArtifact(c *gin.Context) {
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	req := DeleteArtifactReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
@@ -156,12 +193,12 @@ func (h *ArtifactHandler) DeleteArtifact(c *gin.Context) {
 
 	// Validate the path parameter
 	if err := path.ValidatePath(filePath); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+		serializer.ParamErr(c, "invalid path", err)
 		return
 	}
 
 	if err := h.svc.DeleteByPath(c.Request.Context(), project.ID, diskID, filePath, filename); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		artifactErr(c, err)
 		return
 	}
 
@@ -173,6 +210,36 @@ type GetArtifactReq struct {
 	WithPublicURL bool   `form:"with_public_url,default=true" json:"with_public_url" example:"true"`
 	WithContent   bool   `form:"with_content,default=true" json:"with_content" example:"true"`
 	Expire        int    `form:"expire,default=3600" json:"expire" example:"3600"` // Expire time in seconds for presigned URL
+
+	// Structured content for JSON and CSV files
+	Structured bool     `form:"structured" json:"structured" example:"false"`
+	Filter     []string `form:"filter" json:"filter" example:"status=failed"`               // CSV row filter, repeatable
+	Columns    string   `form:"columns" json:"columns" example:"id,status"`                 // Comma separated CSV columns
+	Limit      int      `form:"limit" json:"limit" binding:"omitempty,min=0" example:"100"` // Maximum CSV rows
+	Offset     int      `form:"offset" json:"offset" binding:"omitempty,min=0" example:"0"` // CSV rows to skip
+	JSONPath   string   `form:"json_path" json:"json_path" example:"$.items[*].name"`       // JSON sub-tree
+}
+
+// parseOptions builds the file parser options; any query parameter implies a query
+func (r GetArtifactReq) parseOptions() fileparser.ParseOptions {
+	opts := fileparser.ParseOptions{Structured: r.Structured}
+	if len(r.Filter) == 0 && r.Columns == "" && r.Limit == 0 && r.Offset == 0 && r.JSONPath == "" {
+		return opts
+	}
+
+	q := &fileparser.Query{
+		Filters:  r.Filter,
+		Limit:    r.Limit,
+		Offset:   r.Offset,
+		JSONPath: r.JSONPath,
+	}
+	for _, col := range strings.Split(r.Columns, ",") {
+		if col = strings.TrimSpace(col); col != "" {
+			q.Columns = append(q.Columns, col)
+		}
+	}
+	opts.Query = q
+	return opts
 }
 
 type GetArtifactResp struct {
@@ -185,6 +252,8 @@ type GetArtifactResp struct {
 //
 //	@Summary		Get artifact
 //	@Description	Get artifact information by path and filename. Optionally include a presigned URL for downloading and parsed file content.
+//	@Description	For JSON and CSV files, structured=true returns the schema (CSV columns with inferred types and row count, JSON shape) instead of the full content.
+//	@Description	filter, columns, limit and offset select CSV rows and columns; json_path selects a JSON sub-tree. Any of them implies structured content.
 //	@Tags			artifact
 //	@Accept			json
 //	@Produce		json
@@ -193,20 +262,27 @@ type GetArtifactResp struct {
 //	@Param			with_public_url	query	boolean	false	"Whether to return public URL, default is true"				example(true)
 //	@Param			with_content	query	boolean	false	"Whether to return parsed file content, default is true"	example(true)
 //	@Param			expire			query	int		false	"Expire time in seconds for presigned URL (default: 3600)"	example(3600)
+//	@Param			structured		query	boolean	false	"Return structured content for JSON and CSV files"			example(true)
+//	@Param			filter			query	[]string	false	"CSV row filter column<op>value, op is = != > >= < <= ~"	collectionFormat(multi)	example(status=failed)
+//	@Param			columns			query	string	false	"Comma separated CSV columns to return"					example(id,status)
+//	@Param			limit			query	int		false	"Maximum CSV rows to return"								example(100)
+//	@Param			offset			query	int		false	"CSV matching rows to skip"								example(0)
+//	@Param			json_path		query	string	false	"JSONPath of the JSON sub-tree to return"					example($.items[*].name)
 //	@Security		BearerAuth
 //	@Success		200	{object}	serializer.Response{data=handler.GetArtifactResp}
+//	@Failure		400	{object}	serializer.Problem	"Invalid query or query on a file type without structured content"
 //	@Router			/disk/{disk_id}/artifact [get]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Get artifact information\nartifact_info = client.disks.get_artifact(\n    disk_id='disk-uuid',\n    file_path='/documents/report.pdf',\n    with_public_url=True,\n    with_content=True,\n    expire=3600\n)\nprint(f\"Artifact: {artifact_info.artifact.filename}\")\nif artifact_info.public_url:\n    print(f\"Download URL: {artifact_info.public_url}\")\nif artifact_info.content:\n    print(f\"Content: {artifact_info.content.text[:100]}...\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Get artifact information\nconst artifactInfo = await client.disks.getArtifact('disk-uuid', {\n  filePath: '/documents/report.pdf',\n  withPublicUrl: true,\n  withContent: true,\n  expire: 3600\n});\nconsole.log(`Artifact: ${artifactInfo.artifact.filename}`);\nif (artifactInfo.publicUrl) {\n  console.log(`Download URL: ${artifactInfo.publicUrl}`);\n}\nif (artifactInfo.content) {\n  console.log(`Content: ${artifactInfo.content.text.substring(0, 100)}...`);\n}\n","label":"JavaScript"}]
 func (h *ArtifactHandler) GetArtifact(c *gin.Context) {
 	req := GetArtifactReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
@@ -215,13 +291,13 @@ func (h *ArtifactHandler) GetArtifact(c *gin.Context) {
 
 	// Validate the path parameter
 	if err := path.ValidatePath(filePath); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+		serializer.ParamErr(c, "invalid path", err)
 		return
 	}
 
 	artifact, err := h.svc.GetByPath(c.Request.Context(), diskID, filePath, filename)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		artifactErr(c, err)
 		return
 	}
 
@@ -231,15 +307,25 @@ func (h *ArtifactHandler) GetArtifact(c *gin.Context) {
 	if req.WithPublicURL {
 		url, err := h.svc.GetPresignedURL(c.Request.Context(), artifact, time.Duration(req.Expire)*time.Second)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+			serializer.DBErr(c, "", err)
 			return
 		}
 		resp.PublicURL = &url
 	}
 
 	// Parse file content if requested
-	if req.WithContent {
-		content, err := h.svc.GetFileContent(c.Request.Context(), artifact)
+	opts := req.parseOptions()
+	if req.WithContent || opts.Query != nil {
+		content, err := h.svc.GetFileContent(c.Request.Context(), artifact, opts)
+		// An explicit query must either be answered or rejected
+		if err != nil && opts.Query != nil {
+			if errors.Is(err, fileparser.ErrInvalidQuery) || errors.Is(err, fileparser.ErrQueryNotSupported) || errors.Is(err, fileparser.ErrUnsupportedFileType) {
+				serializer.ParamErr(c, "", err)
+				return
+			}
+			serializer.DBErr(c, "", err)
+			return
+		}
 		// Only set content if parsing succeeded
 		// Unsupported file types (images, binaries, etc.) will not have content
 		if err == nil && content != nil {
@@ -276,13 +362,13 @@ type UpdateArtifactResp struct {
 func (h *ArtifactHandler) UpdateArtifact(c *gin.Context) {
 	req := UpdateArtifactReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
@@ -291,14 +377,14 @@ func (h *ArtifactHandler) UpdateArtifact(c *gin.Context) {
 
 	// Validate the path parameter
 	if err := path.ValidatePath(filePath); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+		serializer.ParamErr(c, "invalid path", err)
 		return
 	}
 
 	// Parse user meta from JSON string
 	var userMeta map[string]interface{}
 	if err := sonic.Unmarshal([]byte(req.Meta), &userMeta); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid meta JSON format", err))
+		serializer.ParamErr(c, "invalid meta JSON format", err)
 		return
 	}
 
@@ -306,7 +392,7 @@ func (h *ArtifactHandler) UpdateArtifact(c *gin.Context) {
 	reservedKeys := model.GetReservedKeys()
 	for _, reservedKey := range reservedKeys {
 		if _, exists := userMeta[reservedKey]; exists {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("", fmt.Errorf("reserved key '%s' is not allowed in user meta", reservedKey)))
+			serializer.ParamErr(c, "", fmt.Errorf("reserved key '%s' is not allowed in user meta", reservedKey))
 			return
 		}
 	}
@@ -314,7 +400,7 @@ func (h *ArtifactHandler) UpdateArtifact(c *gin.Context) {
 	// Update artifact meta
 	artifactRecord, err := h.svc.UpdateArtifactMetaByPath(c.Request.Context(), diskID, filePath, filename, userMeta)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		artifactErr(c, err)
 		return
 	}
 
@@ -324,35 +410,44 @@ func (h *ArtifactHandler) UpdateArtifact(c *gin.Context) {
 }
 
 type ListArtifactsReq struct {
-	Path string `form:"path" json:"path"` // Optional path filter
-}
-
-type ListArtifactsResp struct {
-	Artifacts   []*model.Artifact `json:"artifacts"`
-	Directories []string          `json:"directories"`
+	Path      string `form:"path" json:"path"` // Optional path filter
+	Recursive bool   `form:"recursive,default=false" json:"recursive" example:"false"`
+	Depth     int    `form:"depth,default=0" json:"depth" binding:"min=0,max=64" example:"0"`
+	Limit     int    `form:"limit,default=100" json:"limit" binding:"required,min=1,max=1000" example:"100"`
+	Cursor    string `form:"cursor" json:"cursor"`
 }
 
 // ListArtifacts godoc
 //
 //	@Summary		List artifacts
-//	@Description	List artifacts in a specific path or all artifacts in a disk
+//	@Description	List artifacts in a specific path, optionally recursing into subdirectories. Results are ordered by path and filename and paginated with a cursor. Recursive listings also return a directory tree with per-directory file counts and total sizes. Directories and the tree are only returned on the first page, the one requested without a cursor.
 //	@Tags			artifact
 //	@Accept			json
 //	@Produce		json
-//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
-//	@Param			path	query	string	false	"Path filter (optional, defaults to root '/')"
+//	@Param			disk_id		path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			path		query	string	false	"Path filter (optional, defaults to root '/')"
+//	@Param			recursive	query	boolean	false	"Include artifacts in all subdirectories (default false)"	example(false)
+//	@Param			depth		query	integer	false	"Maximum directory depth below path for recursive listing, 0 means unlimited (default 0)"	example(0)
+//	@Param			limit		query	integer	false	"Limit of artifacts to return, default 100. Max 1000."
+//	@Param			cursor		query	string	false	"Cursor for pagination. Use the cursor from the previous response to get the next page."
 //	@Security		BearerAuth
-//	@Success		200	{object}	serializer.Response{data=handler.ListArtifactsResp}
+//	@Success		200	{object}	serializer.Response{data=service.ListArtifactsOutput}
 //	@Router			/disk/{disk_id}/artifact/ls [get]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# List artifacts in a path\nresult = client.disks.list_artifacts(\n    disk_id='disk-uuid',\n    path='/documents/'\n)\nprint(f\"Found {len(result.artifacts)} artifacts\")\nfor artifact in result.artifacts:\n    print(f\"  - {artifact.path}{artifact.filename}\")\nprint(f\"Subdirectories: {', '.join(result.directories)}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// List artifacts in a path\nconst result = await client.disks.listArtifacts('disk-uuid', {\n  path: '/documents/'\n});\nconsole.log(`Found ${result.artifacts.length} artifacts`);\nfor (const artifact of result.artifacts) {\n  console.log(`  - ${artifact.path}${artifact.filename}`);\n}\nconsole.log(`Subdirectories: ${result.directories.join(', ')}`);\n","label":"JavaScript"}]
 func (h *ArtifactHandler) ListArtifacts(c *gin.Context) {
 	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
-	pathQuery := c.Query("path")
+	req := ListArtifactsReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	pathQuery := req.Path
 
 	// Set default path to root directory if not provided
 	if pathQuery == "" {
@@ -360,37 +455,432 @@ func (h *ArtifactHandler) ListArtifacts(c *gin.Context) {
 	} else {
 		// Validate that path does not contain filename
 		if path, _ := path.SplitFilePath(pathQuery); path != pathQuery {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("both ends of the path must be '/'", errors.New("both ends of the path must be '/'")))
+			serializer.ParamErr(c, "both ends of the path must be '/'", errors.New("both ends of the path must be '/'"))
 			return
 		}
 	}
 
 	// Validate the path parameter
 	if err := path.ValidatePath(pathQuery); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid path", err))
+		serializer.ParamErr(c, "invalid path", err)
 		return
 	}
 
-	artifacts, err := h.svc.ListByPath(c.Request.Context(), diskID, pathQuery)
+	out, err := h.svc.List(c.Request.Context(), service.ListArtifactsInput{
+		DiskID:    diskID,
+		Path:      pathQuery,
+		Recursive: req.Recursive,
+		Depth:     req.Depth,
+		Limit:     req.Limit,
+		Cursor:    req.Cursor,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		serializer.DBErr(c, "", err)
 		return
 	}
 
-	// Get all paths to extract directory names
-	allPaths, err := h.svc.GetAllPaths(c.Request.Context(), diskID)
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+type DeleteDirectoryReq struct {
+	Path string `form:"path" json:"path" binding:"required" example:"/documents/"` // Directory path, both ends must be '/'
+}
+
+type DeleteDirectoryResp struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// DeleteDirectory godoc
+//
+//	@Summary		Delete directory
+//	@Description	Recursively delete all artifacts under a directory path. Deleting '/' removes every artifact in the disk but keeps the disk itself.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string	true	"Disk ID"							Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			path	query	string	true	"Directory path, both ends must be '/'"	example(/documents/)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=handler.DeleteDirectoryResp}
+//	@Router			/disk/{disk_id}/artifact/dir [delete]
+func (h *ArtifactHandler) DeleteDirectory(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	req := DeleteDirectoryReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
-	// Extract direct subdirectories
-	directories := path.GetDirectoriesFromPaths(pathQuery, allPaths)
+	// Validate that path does not contain filename
+	if dirPath, _ := path.SplitFilePath(req.Path); dirPath != req.Path {
+		serializer.ParamErr(c, "both ends of the path must be '/'", errors.New("both ends of the path must be '/'"))
+		return
+	}
 
-	c.JSON(http.StatusOK, serializer.Response{
-		Data: ListArtifactsResp{
-			Artifacts:   artifacts,
-			Directories: directories,
-		},
+	// Validate the path parameter
+	if err := path.ValidatePath(req.Path); err != nil {
+		serializer.ParamErr(c, "invalid path", err)
+		return
+	}
+
+	deleted, err := h.svc.DeleteDirectory(c.Request.Context(), project.ID, diskID, req.Path)
+	if err != nil {
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: DeleteDirectoryResp{Deleted: deleted}})
+}
+
+type BatchArtifactOperationReq struct {
+	Op           string                 `json:"op" binding:"required,oneof=upsert delete move" example:"upsert"`
+	FilePath     string                 `json:"file_path" binding:"required" example:"/documents/notes.md"`    // File path including filename
+	DestFilePath string                 `json:"dest_file_path" example:"/archive/notes.md"`                    // Destination for move; a trailing '/' keeps the filename
+	Content      string                 `json:"content" example:"# Notes"`                                     // File content for upsert
+	Encoding     string                 `json:"encoding" binding:"omitempty,oneof=utf8 base64" example:"utf8"` // Content encoding, defaults to utf8
+	MimeType     string                 `json:"mime_type" example:"text/markdown"`                             // Optional, detected from filename when empty
+	Meta         map[string]interface{} `json:"meta" swaggertype:"object"`                                     // Custom metadata for upsert
+}
+
+type BatchArtifactsReq struct {
+	Operations []BatchArtifactOperationReq `json:"operations" binding:"required,min=1,max=1000,dive"`
+}
+
+type BatchArtifactsResp struct {
+	Results []service.BatchArtifactResult `json:"results"`
+}
+
+// BatchArtifacts godoc
+//
+//	@Summary		Batch artifact operations
+//	@Description	Apply a list of upsert, delete and move operations to a disk in order within a single transaction. Either all operations succeed or none are applied.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string						true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request	body	handler.BatchArtifactsReq	true	"Batch operations request"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=handler.BatchArtifactsResp}
+//	@Router			/disk/{disk_id}/artifact/batch [post]
+func (h *ArtifactHandler) BatchArtifacts(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	req := BatchArtifactsReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	ops := make([]service.ArtifactBatchOperation, 0, len(req.Operations))
+	for i, item := range req.Operations {
+		op, err := toArtifactBatchOperation(item)
+		if err != nil {
+			serializer.ParamErr(c, fmt.Sprintf("invalid operation %d", i), err)
+			return
+		}
+		ops = append(ops, op)
+	}
+
+	results, err := h.svc.Batch(c.Request.Context(), service.BatchArtifactsInput{
+		ProjectID:  project.ID,
+		DiskID:     diskID,
+		Operations: ops,
+	})
+	if err != nil {
+		artifactWriteErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: BatchArtifactsResp{Results: results}})
+}
+
+// toArtifactBatchOperation validates a batch operation request and converts it to a service operation
+func toArtifactBatchOperation(item BatchArtifactOperationReq) (service.ArtifactBatchOperation, error) {
+	filePath, filename := path.SplitFilePath(item.FilePath)
+	if filename == "" {
+		return service.ArtifactBatchOperation{}, errors.New("file_path must include a filename")
+	}
+	if err := path.ValidatePath(filePath); err != nil {
+		return service.ArtifactBatchOperation{}, err
+	}
+
+	op := service.ArtifactBatchOperation{
+		Op:       item.Op,
+		Path:     filePath,
+		Filename: filename,
+	}
+
+	switch item.Op {
+	case "upsert":
+		content := []byte(item.Content)
+		if item.Encoding == "base64" {
+			decoded, err := base64.StdEncoding.DecodeString(item.Content)
+			if err != nil {
+				return service.ArtifactBatchOperation{}, fmt.Errorf("invalid base64 content: %w", err)
+			}
+			content = decoded
+		}
+
+		// Validate that user meta doesn't contain system reserved keys
+		for _, reservedKey := range model.GetReservedKeys() {
+			if _, exists := item.Meta[reservedKey]; exists {
+				return service.ArtifactBatchOperation{}, fmt.Errorf("reserved key '%s' is not allowed in user meta", reservedKey)
+			}
+		}
+
+		op.Content = content
+		op.MIME = item.MimeType
+		op.UserMeta = item.Meta
+	case "move":
+		if item.DestFilePath == "" {
+			return service.ArtifactBatchOperation{}, errors.New("dest_file_path is required for move")
+		}
+		destPath, destFilename := path.SplitFilePath(item.DestFilePath)
+		if destFilename == "" {
+			destFilename = filename
+		}
+		if err := path.ValidatePath(destPath); err != nil {
+			return service.ArtifactBatchOperation{}, err
+		}
+		op.DestPath = destPath
+		op.DestFilename = destFilename
+	}
+
+	return op, nil
+}
+
+type ImportArchiveReq struct {
+	Path string `form:"path" json:"path"` // Target directory, defaults to "/"
+}
+
+// ImportArchive godoc
+//
+//	@Summary		Import archive
+//	@Description	Upload a zip or tar.gz archive and unpack its files into artifacts under the target directory. Existing artifacts at the same paths are replaced. The import is applied atomically.
+//	@Tags			artifact
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			disk_id	path		string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			path	formData	string	false	"Target directory, both ends must be '/' (optional, defaults to '/')"
+//	@Param			file	formData	file	true	"Archive file (.zip, .tar.gz or .tgz)"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=service.ImportArchiveOutput}
+//	@Router			/disk/{disk_id}/import [post]
+func (h *ArtifactHandler) ImportArchive(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	req := ImportArchiveReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	targetPath := req.Path
+	if targetPath == "" {
+		targetPath = "/"
+	} else if dirPath, _ := path.SplitFilePath(targetPath); dirPath != targetPath {
+		serializer.ParamErr(c, "both ends of the path must be '/'", errors.New("both ends of the path must be '/'"))
+		return
+	}
+	if err := path.ValidatePath(targetPath); err != nil {
+		serializer.ParamErr(c, "invalid path", err)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		serializer.ParamErr(c, "file is required", err)
+		return
+	}
+
+	format, err := archive.DetectFormat(fileHeader.Filename)
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+	defer file.Close()
+
+	out, err := h.svc.ImportArchive(c.Request.Context(), service.ImportArchiveInput{
+		ProjectID: project.ID,
+		DiskID:    diskID,
+		Path:      targetPath,
+		Format:    format,
+		Reader:    file,
+		Size:      fileHeader.Size,
+	})
+	if err != nil {
+		if isArchiveInputErr(err) {
+			serializer.ParamErr(c, "invalid archive", err)
+			return
+		}
+		artifactWriteErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+// isArchiveInputErr reports whether err is caused by the uploaded archive rather than the server
+func isArchiveInputErr(err error) bool {
+	return errors.Is(err, archive.ErrUnsafePath) ||
+		errors.Is(err, archive.ErrTooManyEntries) ||
+		errors.Is(err, archive.ErrTooLarge) ||
+		errors.Is(err, archive.ErrUnsupportedFormat) ||
+		errors.Is(err, zip.ErrFormat) ||
+		errors.Is(err, gzip.ErrHeader) ||
+		errors.Is(err, tar.ErrHeader) ||
+		errors.Is(err, path.ErrPathTraversal) ||
+		errors.Is(err, path.ErrInvalidPath)
+}
+
+type ExportArchiveReq struct {
+	Path string `form:"path" json:"path"` // Directory to export, defaults to "/"
+}
+
+// ExportArchive godoc
+//
+//	@Summary		Export archive
+//	@Description	Download all artifacts under a directory as a zip archive. File contents are streamed from storage, and entry names are relative to the exported directory.
+//	@Tags			artifact
+//	@Produce		application/zip
+//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			path	query	string	false	"Directory to export, both ends must be '/' (optional, defaults to '/')"
+//	@Security		BearerAuth
+//	@Success		200	{file}	file
+//	@Router			/disk/{disk_id}/export [get]
+func (h *ArtifactHandler) ExportArchive(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	req := ExportArchiveReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	exportPath := req.Path
+	if exportPath == "" {
+		exportPath = "/"
+	} else if dirPath, _ := path.SplitFilePath(exportPath); dirPath != exportPath {
+		serializer.ParamErr(c, "both ends of the path must be '/'", errors.New("both ends of the path must be '/'"))
+		return
+	}
+	if err := path.ValidatePath(exportPath); err != nil {
+		serializer.ParamErr(c, "invalid path", err)
+		return
+	}
+
+	name := strings.Trim(exportPath, "/")
+	if name == "" {
+		name = diskID.String()
+	} else {
+		name = name[strings.LastIndex(name, "/")+1:]
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, name))
+	c.Status(http.StatusOK)
+
+	// The response has already started, so a failure can only abort the stream
+	if err := h.svc.ExportZip(c.Request.Context(), diskID, exportPath, c.Writer); err != nil {
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
+
+type SearchArtifactsReq struct {
+	Query string `form:"query" json:"query" binding:"required" example:"how to configure the database"`
+	Path  string `form:"path" json:"path" example:"/documents/"` // Optional directory filter
+	Limit int    `form:"limit,default=10" json:"limit" binding:"required,min=1,max=100" example:"10"`
+}
+
+// SearchArtifacts godoc
+//
+//	@Summary		Search artifacts
+//	@Description	Semantic search over the parsable artifacts (text, code, markdown, CSV, JSON) of a disk. Returns the best matching chunks ranked by similarity, with their file path and line range. Artifacts are indexed asynchronously after upload, so very recent uploads may not be searchable yet.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			query	query	string	true	"Search query"	example(how to configure the database)
+//	@Param			path	query	string	false	"Only search artifacts under this directory (optional)"
+//	@Param			limit	query	integer	false	"Maximum number of chunks to return, default 10. Max 100."
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.SearchArtifactsOutput}
+//	@Router			/disk/{disk_id}/artifact/search [get]
+func (h *ArtifactHandler) SearchArtifacts(c *gin.Context) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	req := SearchArtifactsReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	if req.Path != "" {
+		if dirPath, _ := path.SplitFilePath(req.Path); dirPath != req.Path {
+			serializer.ParamErr(c, "both ends of the path must be '/'", errors.New("both ends of the path must be '/'"))
+			return
+		}
+		if err := path.ValidatePath(req.Path); err != nil {
+			serializer.ParamErr(c, "invalid path", err)
+			return
+		}
+	}
+
+	out, err := h.searchSvc.Search(c.Request.Context(), service.SearchArtifactsInput{
+		DiskID: diskID,
+		Query:  req.Query,
+		Path:   req.Path,
+		Limit:  req.Limit,
 	})
+	if err != nil {
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
 }
@@ -4,18 +4,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
 	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/archive"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/fileparser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -52,14 +57,73 @@ func (m *MockArtifactService) UpdateArtifact(ctx context.Context, diskID uuid.UU
 	return args.Get(0).(*model.Artifact), args.Error(1)
 }
 
-func (m *MockArtifactService) ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error) {
-	args := m.Called(ctx, diskID, path)
-	return args.Get(0).([]*model.Artifact), args.Error(1)
+func (m *MockArtifactService) List(ctx context.Context, in service.ListArtifactsInput) (*service.ListArtifactsOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ListArtifactsOutput), args.Error(1)
 }
 
-func (m *MockArtifactService) GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error) {
-	args := m.Called(ctx, diskID)
-	return args.Get(0).([]string), args.Error(1)
+func (m *MockArtifactService) DeleteDirectory(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string) (int64, error) {
+	args := m.Called(ctx, projectID, diskID, path)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockArtifactService) Batch(ctx context.Context, in service.BatchArtifactsInput) ([]service.BatchArtifactResult, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.BatchArtifactResult), args.Error(1)
+}
+
+func (m *MockArtifactService) ImportArchive(ctx context.Context, in service.ImportArchiveInput) (*service.ImportArchiveOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ImportArchiveOutput), args.Error(1)
+}
+
+func (m *MockArtifactService) ExportZip(ctx context.Context, diskID uuid.UUID, path string, w io.Writer) error {
+	args := m.Called(ctx, diskID, path, w)
+	return args.Error(0)
+}
+
+func (m *MockArtifactService) OpenContent(ctx context.Context, artifact *model.Artifact) (io.ReadCloser, error) {
+	args := m.Called(ctx, artifact)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+// MockArtifactSearchService is a mock implementation of ArtifactSearchService
+type MockArtifactSearchService struct {
+	mock.Mock
+}
+
+func (m *MockArtifactSearchService) EnqueueIndex(ctx context.Context, projectID uuid.UUID, artifacts []*model.Artifact) {
+	m.Called(ctx, projectID, artifacts)
+}
+
+func (m *MockArtifactSearchService) HandleIndexMessage(ctx context.Context, body []byte) error {
+	args := m.Called(ctx, body)
+	return args.Error(0)
+}
+
+func (m *MockArtifactSearchService) IndexArtifact(ctx context.Context, artifactID uuid.UUID) error {
+	args := m.Called(ctx, artifactID)
+	return args.Error(0)
+}
+
+func (m *MockArtifactSearchService) Search(ctx context.Context, in service.SearchArtifactsInput) (*service.SearchArtifactsOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.SearchArtifactsOutput), args.Error(1)
 }
 
 func (m *MockArtifactService) GetByDiskID(ctx context.Context, diskID uuid.UUID) ([]*model.Artifact, error) {
@@ -87,8 +151,8 @@ func (m *MockArtifactService) UpdateArtifactMetaByPath(ctx context.Context, disk
 	return args.Get(0).(*model.Artifact), args.Error(1)
 }
 
-func (m *MockArtifactService) GetFileContent(ctx context.Context, artifact *model.Artifact) (*fileparser.FileContent, error) {
-	args := m.Called(ctx, artifact)
+func (m *MockArtifactService) GetFileContent(ctx context.Context, artifact *model.Artifact, opts fileparser.ParseOptions) (*fileparser.FileContent, error) {
+	args := m.Called(ctx, artifact, opts)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -154,7 +218,7 @@ func TestArtifactHandler_UpsertArtifact(t *testing.T) {
 			projectID := uuid.New()
 			tt.mockSetup(mockService, tt.diskID, projectID)
 
-			handler := NewArtifactHandler(mockService)
+			handler := NewArtifactHandler(mockService, nil)
 
 			// Create multipart form data
 			body := &bytes.Buffer{}
@@ -238,7 +302,7 @@ func TestArtifactHandler_DeleteArtifact(t *testing.T) {
 			projectID := uuid.New()
 			tt.mockSetup(mockService, tt.diskID, tt.filePath, projectID)
 
-			handler := NewArtifactHandler(mockService)
+			handler := NewArtifactHandler(mockService, nil)
 
 			// Create request with query parameters
 			req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/disk/%s/artifact?file_path=%s", tt.diskID, tt.filePath), nil)
@@ -363,7 +427,7 @@ func TestArtifactHandler_UpdateArtifact(t *testing.T) {
 			mockService := new(MockArtifactService)
 			tt.mockSetup(mockService, tt.diskID)
 
-			handler := NewArtifactHandler(mockService)
+			handler := NewArtifactHandler(mockService, nil)
 
 			// Create JSON request body
 			requestBody := map[string]string{
@@ -453,7 +517,7 @@ func TestArtifactHandler_GetArtifact(t *testing.T) {
 				}
 				m.On("GetByPath", mock.Anything, diskID, "/test/", "data.csv").Return(expectedFile, nil)
 				m.On("GetPresignedURL", mock.Anything, expectedFile, mock.AnythingOfType("time.Duration")).Return("https://example.com/presigned-url", nil)
-				m.On("GetFileContent", mock.Anything, expectedFile).Return(expectedContent, nil)
+				m.On("GetFileContent", mock.Anything, expectedFile, fileparser.ParseOptions{}).Return(expectedContent, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -509,7 +573,7 @@ func TestArtifactHandler_GetArtifact(t *testing.T) {
 			mockService := new(MockArtifactService)
 			tt.mockSetup(mockService, tt.diskID, tt.filePath)
 
-			handler := NewArtifactHandler(mockService)
+			handler := NewArtifactHandler(mockService, nil)
 
 			// Create request with query parameters
 			url := fmt.Sprintf("/disk/%s/artifact?file_path=%s", tt.diskID, tt.filePath)
@@ -562,3 +626,510 @@ func TestArtifactHandler_GetArtifact(t *testing.T) {
 		})
 	}
 }
+
+func TestArtifactHandler_GetArtifact_Query(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedOpts   fileparser.ParseOptions
+		serviceErr     error
+		expectedStatus int
+	}{
+		{
+			name:           "structured mode",
+			query:          "&structured=true",
+			expectedOpts:   fileparser.ParseOptions{Structured: true},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "csv filters and columns",
+			query: "&filter=status%3Dfailed&filter=amount%3E10&columns=id,%20status&limit=5&offset=10",
+			expectedOpts: fileparser.ParseOptions{Query: &fileparser.Query{
+				Filters: []string{"status=failed", "amount>10"},
+				Columns: []string{"id", "status"},
+				Limit:   5,
+				Offset:  10,
+			}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "json path",
+			query:          "&json_path=$.items[*].name",
+			expectedOpts:   fileparser.ParseOptions{Query: &fileparser.Query{JSONPath: "$.items[*].name"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid query",
+			query:          "&filter=missing%3D1",
+			expectedOpts:   fileparser.ParseOptions{Query: &fileparser.Query{Filters: []string{"missing=1"}}},
+			serviceErr:     fmt.Errorf("failed to parse file content: %w", fileparser.ErrInvalidQuery),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "query on a file type without structured content",
+			query:          "&json_path=$",
+			expectedOpts:   fileparser.ParseOptions{Query: &fileparser.Query{JSONPath: "$"}},
+			serviceErr:     fileparser.ErrQueryNotSupported,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "storage error with a query",
+			query:          "&json_path=$",
+			expectedOpts:   fileparser.ParseOptions{Query: &fileparser.Query{JSONPath: "$"}},
+			serviceErr:     errors.New("failed to download file content"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "negative limit",
+			query:          "&limit=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockArtifactService{}
+			diskID := uuid.New()
+			artifact := &model.Artifact{ID: uuid.New(), DiskID: diskID, Path: "/data/", Filename: "orders.csv"}
+
+			if tt.expectedStatus != http.StatusBadRequest || tt.serviceErr != nil {
+				mockService.On("GetByPath", mock.Anything, diskID, "/data/", "orders.csv").Return(artifact, nil)
+				if tt.serviceErr != nil {
+					mockService.On("GetFileContent", mock.Anything, artifact, tt.expectedOpts).Return(nil, tt.serviceErr)
+				} else {
+					mockService.On("GetFileContent", mock.Anything, artifact, tt.expectedOpts).Return(&fileparser.FileContent{Type: "csv"}, nil)
+				}
+			}
+
+			handler := NewArtifactHandler(mockService, nil)
+			url := fmt.Sprintf("/disk/%s/artifact?file_path=/data/orders.csv&with_public_url=false%s", diskID, tt.query)
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = []gin.Param{
+				{Key: "disk_id", Value: diskID.String()},
+			}
+
+			handler.GetArtifact(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_DeleteDirectory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		diskID         string
+		path           string
+		mockSetup      func(*MockArtifactService, string, uuid.UUID)
+		expectedStatus int
+	}{
+		{
+			name:   "successful directory deletion",
+			diskID: uuid.New().String(),
+			path:   "/test/",
+			mockSetup: func(m *MockArtifactService, diskIDStr string, projectID uuid.UUID) {
+				m.On("DeleteDirectory", mock.Anything, projectID, uuid.MustParse(diskIDStr), "/test/").Return(int64(3), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "path without trailing slash",
+			diskID:         uuid.New().String(),
+			path:           "/test",
+			mockSetup:      func(m *MockArtifactService, diskIDStr string, projectID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing path",
+			diskID:         uuid.New().String(),
+			path:           "",
+			mockSetup:      func(m *MockArtifactService, diskIDStr string, projectID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "service error",
+			diskID: uuid.New().String(),
+			path:   "/",
+			mockSetup: func(m *MockArtifactService, diskIDStr string, projectID uuid.UUID) {
+				m.On("DeleteDirectory", mock.Anything, projectID, uuid.MustParse(diskIDStr), "/").Return(int64(0), errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockArtifactService)
+			projectID := uuid.New()
+			tt.mockSetup(mockService, tt.diskID, projectID)
+
+			handler := NewArtifactHandler(mockService, nil)
+
+			req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/disk/%s/artifact/dir?path=%s", tt.diskID, tt.path), nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = []gin.Param{
+				{Key: "disk_id", Value: tt.diskID},
+			}
+			c.Set("project", &model.Project{ID: projectID})
+
+			handler.DeleteDirectory(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_BatchArtifacts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func(*MockArtifactService, uuid.UUID, uuid.UUID)
+		expectedStatus int
+	}{
+		{
+			name: "successful batch",
+			body: `{"operations":[
+				{"op":"upsert","file_path":"/docs/a.md","content":"aGVsbG8=","encoding":"base64","meta":{"k":"v"}},
+				{"op":"move","file_path":"/docs/b.md","dest_file_path":"/archive/"},
+				{"op":"delete","file_path":"/docs/c.md"}
+			]}`,
+			mockSetup: func(m *MockArtifactService, projectID, diskID uuid.UUID) {
+				m.On("Batch", mock.Anything, mock.MatchedBy(func(in service.BatchArtifactsInput) bool {
+					if in.ProjectID != projectID || in.DiskID != diskID || len(in.Operations) != 3 {
+						return false
+					}
+					upsert, move, del := in.Operations[0], in.Operations[1], in.Operations[2]
+					return upsert.Op == "upsert" && upsert.Path == "/docs/" && upsert.Filename == "a.md" &&
+						string(upsert.Content) == "hello" && upsert.UserMeta["k"] == "v" &&
+						move.Op == "move" && move.DestPath == "/archive/" && move.DestFilename == "b.md" &&
+						del.Op == "delete" && del.Path == "/docs/" && del.Filename == "c.md"
+				})).Return([]service.BatchArtifactResult{{Op: "upsert"}, {Op: "move"}, {Op: "delete"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "empty operations",
+			body:           `{"operations":[]}`,
+			mockSetup:      func(m *MockArtifactService, projectID, diskID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown operation",
+			body:           `{"operations":[{"op":"copy","file_path":"/a.md"}]}`,
+			mockSetup:      func(m *MockArtifactService, projectID, diskID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "move without destination",
+			body:           `{"operations":[{"op":"move","file_path":"/a.md"}]}`,
+			mockSetup:      func(m *MockArtifactService, projectID, diskID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "reserved meta key",
+			body:           `{"operations":[{"op":"upsert","file_path":"/a.md","content":"x","meta":{"__artifact_info__":{}}}]}`,
+			mockSetup:      func(m *MockArtifactService, projectID, diskID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "service error",
+			body: `{"operations":[{"op":"delete","file_path":"/a.md"}]}`,
+			mockSetup: func(m *MockArtifactService, projectID, diskID uuid.UUID) {
+				m.On("Batch", mock.Anything, mock.Anything).Return(nil, errors.New("record not found"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockArtifactService)
+			projectID := uuid.New()
+			diskID := uuid.New()
+			tt.mockSetup(mockService, projectID, diskID)
+
+			handler := NewArtifactHandler(mockService, nil)
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/disk/%s/artifact/batch", diskID), strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = []gin.Param{
+				{Key: "disk_id", Value: diskID.String()},
+			}
+			c.Set("project", &model.Project{ID: projectID})
+
+			handler.BatchArtifacts(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_ImportArchive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		fileName       string
+		path           string
+		mockSetup      func(*MockArtifactService, uuid.UUID, uuid.UUID)
+		expectedStatus int
+	}{
+		{
+			name:     "successful zip import",
+			fileName: "repo.zip",
+			path:     "/workspace/",
+			mockSetup: func(m *MockArtifactService, projectID, diskID uuid.UUID) {
+				m.On("ImportArchive", mock.Anything, mock.MatchedBy(func(in service.ImportArchiveInput) bool {
+					return in.ProjectID == projectID && in.DiskID == diskID && in.Path == "/workspace/" &&
+						in.Format == archive.FormatZip && in.Reader != nil && in.Size > 0
+				})).Return(&service.ImportArchiveOutput{Imported: 1}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:     "default path for tar.gz",
+			fileName: "repo.tar.gz",
+			mockSetup: func(m *MockArtifactService, projectID, diskID uuid.UUID) {
+				m.On("ImportArchive", mock.Anything, mock.MatchedBy(func(in service.ImportArchiveInput) bool {
+					return in.Path == "/" && in.Format == archive.FormatTarGz
+				})).Return(&service.ImportArchiveOutput{Imported: 1}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "unsupported format",
+			fileName:       "repo.rar",
+			mockSetup:      func(m *MockArtifactService, projectID, diskID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "path without trailing slash",
+			fileName:       "repo.zip",
+			path:           "/workspace",
+			mockSetup:      func(m *MockArtifactService, projectID, diskID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "unsafe archive entry",
+			fileName: "repo.zip",
+			mockSetup: func(m *MockArtifactService, projectID, diskID uuid.UUID) {
+				m.On("ImportArchive", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: ../evil.sh", archive.ErrUnsafePath))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "service error",
+			fileName: "repo.zip",
+			mockSetup: func(m *MockArtifactService, projectID, diskID uuid.UUID) {
+				m.On("ImportArchive", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockArtifactService)
+			projectID := uuid.New()
+			diskID := uuid.New()
+			tt.mockSetup(mockService, projectID, diskID)
+
+			handler := NewArtifactHandler(mockService, nil)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			fileWriter, err := writer.CreateFormFile("file", tt.fileName)
+			assert.NoError(t, err)
+			_, err = fileWriter.Write([]byte("archive bytes"))
+			assert.NoError(t, err)
+			if tt.path != "" {
+				writer.WriteField("path", tt.path)
+			}
+			writer.Close()
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/disk/%s/import", diskID), body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = []gin.Param{
+				{Key: "disk_id", Value: diskID.String()},
+			}
+			c.Set("project", &model.Project{ID: projectID})
+
+			handler.ImportArchive(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_ExportArchive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name                string
+		path                string
+		mockSetup           func(*MockArtifactService, uuid.UUID)
+		expectedStatus      int
+		expectedDisposition string
+	}{
+		{
+			name: "export subtree",
+			path: "/docs/reports/",
+			mockSetup: func(m *MockArtifactService, diskID uuid.UUID) {
+				m.On("ExportZip", mock.Anything, diskID, "/docs/reports/", mock.Anything).Run(func(args mock.Arguments) {
+					args.Get(3).(io.Writer).Write([]byte("PK"))
+				}).Return(nil)
+			},
+			expectedStatus:      http.StatusOK,
+			expectedDisposition: `attachment; filename="reports.zip"`,
+		},
+		{
+			name:           "invalid path",
+			path:           "/docs/report.pdf",
+			mockSetup:      func(m *MockArtifactService, diskID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockArtifactService)
+			diskID := uuid.New()
+			tt.mockSetup(mockService, diskID)
+
+			handler := NewArtifactHandler(mockService, nil)
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/disk/%s/export?path=%s", diskID, tt.path), nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = []gin.Param{
+				{Key: "disk_id", Value: diskID.String()},
+			}
+
+			handler.ExportArchive(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedDisposition, w.Header().Get("Content-Disposition"))
+				assert.Equal(t, "PK", w.Body.String())
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactHandler_SearchArtifacts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(*MockArtifactSearchService, uuid.UUID)
+		expectedStatus int
+	}{
+		{
+			name:  "successful search with defaults",
+			query: "query=database+config",
+			mockSetup: func(m *MockArtifactSearchService, diskID uuid.UUID) {
+				m.On("Search", mock.Anything, service.SearchArtifactsInput{
+					DiskID: diskID,
+					Query:  "database config",
+					Limit:  10,
+				}).Return(&service.SearchArtifactsOutput{Results: []repo.ArtifactChunkHit{
+					{Path: "/docs/", Filename: "db.md", StartLine: 1, EndLine: 12, Score: 0.82},
+				}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "search under a directory",
+			query: "query=config&path=/docs/&limit=3",
+			mockSetup: func(m *MockArtifactSearchService, diskID uuid.UUID) {
+				m.On("Search", mock.Anything, service.SearchArtifactsInput{
+					DiskID: diskID,
+					Query:  "config",
+					Path:   "/docs/",
+					Limit:  3,
+				}).Return(&service.SearchArtifactsOutput{Results: []repo.ArtifactChunkHit{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing query",
+			query:          "",
+			mockSetup:      func(m *MockArtifactSearchService, diskID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "limit too large",
+			query:          "query=x&limit=1000",
+			mockSetup:      func(m *MockArtifactSearchService, diskID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "path with filename",
+			query:          "query=x&path=/docs/a.md",
+			mockSetup:      func(m *MockArtifactSearchService, diskID uuid.UUID) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "service error",
+			query: "query=x",
+			mockSetup: func(m *MockArtifactSearchService, diskID uuid.UUID) {
+				m.On("Search", mock.Anything, mock.Anything).Return(nil, errors.New("embedding provider unavailable"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSearch := new(MockArtifactSearchService)
+			diskID := uuid.New()
+			tt.mockSetup(mockSearch, diskID)
+
+			handler := NewArtifactHandler(new(MockArtifactService), mockSearch)
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/disk/%s/artifact/search?%s", diskID, tt.query), nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+			c.Params = []gin.Param{
+				{Key: "disk_id", Value: diskID.String()},
+			}
+
+			handler.SearchArtifacts(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockSearch.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/path"
+)
+
+type ArtifactUploadHandler struct {
+	svc service.ArtifactUploadService
+}
+
+func NewArtifactUploadHandler(s service.ArtifactUploadService) *ArtifactUploadHandler {
+	return &ArtifactUploadHandler{svc: s}
+}
+
+type CreateUploadReq struct {
+	FilePath string                 `json:"file_path" binding:"required" example:"/datasets/train.parquet"`                                                          // File path including filename
+	Size     *int64                 `json:"size" binding:"required,min=0" example:"1073741824"`                                                                      // File size in bytes
+	SHA256   string                 `json:"sha256" binding:"required,len=64,hexadecimal" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"` // Hex SHA256 of the file content
+	MimeType string                 `json:"mime_type" example:"application/vnd.apache.parquet"`                                                                      // Optional, derived from the filename when empty
+	Meta     map[string]interface{} `json:"meta" swaggertype:"object"`                                                                                               // Custom metadata for the artifact
+}
+
+// CreateUpload godoc
+//
+//	@Summary		Create direct upload
+//	@Description	Reserve an upload slot for a file that is sent straight to S3. Files up to 64MB get a single presigned PUT URL, larger files get one presigned URL per part. Send the file to the returned URLs, then commit the upload to create the artifact.
+//	@Tags			artifact
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string						true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			request	body	handler.CreateUploadReq	true	"Upload request"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=service.CreateUploadOutput}
+//	@Failure		413	{object}	serializer.Problem	"Disk quota exceeded"
+//	@Failure		501	{object}	serializer.Problem	"Direct uploads are disabled because encryption is enabled"
+//	@Router			/disk/{disk_id}/artifact/upload [post]
+func (h *ArtifactUploadHandler) CreateUpload(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	req := CreateUploadReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	filePath, filename := path.SplitFilePath(req.FilePath)
+	if filename == "" {
+		serializer.ParamErr(c, "", errors.New("file_path must include a filename"))
+		return
+	}
+	if err := path.ValidatePath(filePath); err != nil {
+		serializer.ParamErr(c, "invalid path", err)
+		return
+	}
+
+	// Validate that user meta doesn't contain system reserved keys
+	for _, reservedKey := range model.GetReservedKeys() {
+		if _, exists := req.Meta[reservedKey]; exists {
+			serializer.ParamErr(c, "", fmt.Errorf("reserved key '%s' is not allowed in user meta", reservedKey))
+			return
+		}
+	}
+
+	out, err := h.svc.Create(c.Request.Context(), service.CreateUploadInput{
+		ProjectID: project.ID,
+		DiskID:    diskID,
+		Path:      filePath,
+		Filename:  filename,
+		MIME:      req.MimeType,
+		Size:      *req.Size,
+		SHA256:    req.SHA256,
+		UserMeta:  req.Meta,
+	})
+	if err != nil {
+		uploadErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+// CommitUpload godoc
+//
+//	@Summary		Commit direct upload
+//	@Description	Verify the size and SHA256 of a file uploaded through an upload slot and create the artifact. A file that does not match what was declared is discarded and the upload aborted. Committing before all parts are uploaded fails with 409 and can be retried.
+//	@Tags			artifact
+//	@Produce		json
+//	@Param			disk_id		path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			upload_id	path	string	true	"Upload ID"	Format(uuid)
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=model.Artifact}
+//	@Failure		409	{object}	serializer.Problem	"Upload is unfinished, expired, committed or aborted"
+//	@Failure		413	{object}	serializer.Problem	"Disk quota exceeded"
+//	@Failure		422	{object}	serializer.Problem	"Uploaded file does not match the declared size or sha256"
+//	@Router			/disk/{disk_id}/artifact/upload/{upload_id}/commit [post]
+func (h *ArtifactUploadHandler) CommitUpload(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, uploadID, err := parseUploadIDs(c)
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	artifact, err := h.svc.Commit(c.Request.Context(), project.ID, diskID, uploadID)
+	if err != nil {
+		uploadErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: artifact})
+}
+
+// AbortUpload godoc
+//
+//	@Summary		Abort direct upload
+//	@Description	Discard a pending upload and any data uploaded for it
+//	@Tags			artifact
+//	@Produce		json
+//	@Param			disk_id		path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			upload_id	path	string	true	"Upload ID"	Format(uuid)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{}
+//	@Router			/disk/{disk_id}/artifact/upload/{upload_id} [delete]
+func (h *ArtifactUploadHandler) AbortUpload(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, uploadID, err := parseUploadIDs(c)
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	if err := h.svc.Abort(c.Request.Context(), project.ID, diskID, uploadID); err != nil {
+		uploadErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{})
+}
+
+func parseUploadIDs(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	uploadID, err := uuid.Parse(c.Param("upload_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return diskID, uploadID, nil
+}
+
+// uploadErr answers a failed direct upload request
+func uploadErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidUpload):
+		serializer.ParamErr(c, "", err)
+	case errors.Is(err, service.ErrUploadNotFound):
+		serializer.Err(c, apierr.UploadNotFound, "upload not found", err)
+	case errors.Is(err, service.ErrUploadUnavailable):
+		serializer.Err(c, apierr.UploadUnavailable, err.Error(), err)
+	case errors.Is(err, service.ErrUploadIncomplete):
+		serializer.Err(c, apierr.UploadIncomplete, err.Error(), err)
+	case errors.Is(err, service.ErrUploadMismatch):
+		serializer.Err(c, apierr.UploadMismatch, "uploaded file does not match the declared size or sha256", err)
+	case errors.Is(err, blob.ErrDirectUploadUnsupported):
+		serializer.Err(c, apierr.DirectUploadUnsupported, "direct uploads are not supported while encryption is enabled, upload the file through the artifact API instead", err)
+	default:
+		artifactWriteErr(c, err)
+	}
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockArtifactUploadService is a mock implementation of ArtifactUploadService
+type MockArtifactUploadService struct {
+	mock.Mock
+}
+
+func (m *MockArtifactUploadService) Create(ctx context.Context, in service.CreateUploadInput) (*service.CreateUploadOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CreateUploadOutput), args.Error(1)
+}
+
+func (m *MockArtifactUploadService) Commit(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, uploadID uuid.UUID) (*model.Artifact, error) {
+	args := m.Called(ctx, projectID, diskID, uploadID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Artifact), args.Error(1)
+}
+
+func (m *MockArtifactUploadService) Abort(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, uploadID uuid.UUID) error {
+	args := m.Called(ctx, projectID, diskID, uploadID)
+	return args.Error(0)
+}
+
+func (m *MockArtifactUploadService) Sweep(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+const testUploadSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func setupUploadRouter(svc service.ArtifactUploadService, projectID uuid.UUID) *gin.Engine {
+	h := NewArtifactUploadHandler(svc)
+	router := setupDiskRouter()
+	router.Use(func(c *gin.Context) { c.Set("project", &model.Project{ID: projectID}) })
+	router.POST("/disk/:disk_id/artifact/upload", h.CreateUpload)
+	router.POST("/disk/:disk_id/artifact/upload/:upload_id/commit", h.CommitUpload)
+	router.DELETE("/disk/:disk_id/artifact/upload/:upload_id", h.AbortUpload)
+	return router
+}
+
+func TestArtifactUploadHandler_CreateUpload(t *testing.T) {
+	projectID, diskID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		setup          func(*MockArtifactUploadService)
+		expectedStatus int
+	}{
+		{
+			name: "valid request",
+			body: fmt.Sprintf(`{"file_path":"/datasets/train.parquet","size":0,"sha256":"%s","meta":{"split":"train"}}`, testUploadSHA256),
+			setup: func(svc *MockArtifactUploadService) {
+				svc.On("Create", mock.Anything, service.CreateUploadInput{
+					ProjectID: projectID,
+					DiskID:    diskID,
+					Path:      "/datasets/",
+					Filename:  "train.parquet",
+					Size:      0,
+					SHA256:    testUploadSHA256,
+					UserMeta:  map[string]interface{}{"split": "train"},
+				}).Return(&service.CreateUploadOutput{URL: "https://s3/put"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing size",
+			body:           fmt.Sprintf(`{"file_path":"/a.bin","sha256":"%s"}`, testUploadSHA256),
+			setup:          func(svc *MockArtifactUploadService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid sha256",
+			body:           `{"file_path":"/a.bin","size":1,"sha256":"xyz"}`,
+			setup:          func(svc *MockArtifactUploadService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "directory instead of file",
+			body:           fmt.Sprintf(`{"file_path":"/data/","size":1,"sha256":"%s"}`, testUploadSHA256),
+			setup:          func(svc *MockArtifactUploadService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "reserved meta key",
+			body:           fmt.Sprintf(`{"file_path":"/a.bin","size":1,"sha256":"%s","meta":{"%s":1}}`, testUploadSHA256, model.ArtifactInfoKey),
+			setup:          func(svc *MockArtifactUploadService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "over quota",
+			body: fmt.Sprintf(`{"file_path":"/a.bin","size":1,"sha256":"%s"}`, testUploadSHA256),
+			setup: func(svc *MockArtifactUploadService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, service.ErrDiskQuotaExceeded)
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "over the project storage limit",
+			body: fmt.Sprintf(`{"file_path":"/a.bin","size":1,"sha256":"%s"}`, testUploadSHA256),
+			setup: func(svc *MockArtifactUploadService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, &service.UsageLimitError{Limit: service.UsageLimitStorageBytes, Used: 10, Adding: 1, Hard: 10})
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "encryption enabled",
+			body: fmt.Sprintf(`{"file_path":"/a.bin","size":1,"sha256":"%s"}`, testUploadSHA256),
+			setup: func(svc *MockArtifactUploadService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("presign upload: %w", blob.ErrDirectUploadUnsupported))
+			},
+			expectedStatus: http.StatusNotImplemented,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockArtifactUploadService{}
+			tt.setup(svc)
+			router := setupUploadRouter(svc, projectID)
+
+			req := httptest.NewRequest("POST", "/disk/"+diskID.String()+"/artifact/upload", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactUploadHandler_CommitUpload(t *testing.T) {
+	projectID, diskID, uploadID := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "committed", expectedStatus: http.StatusCreated},
+		{name: "unknown upload", err: service.ErrUploadNotFound, expectedStatus: http.StatusNotFound},
+		{name: "parts missing", err: service.ErrUploadIncomplete, expectedStatus: http.StatusConflict},
+		{name: "already committed", err: service.ErrUploadUnavailable, expectedStatus: http.StatusConflict},
+		{name: "checksum mismatch", err: service.ErrUploadMismatch, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "over quota", err: service.ErrDiskQuotaExceeded, expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockArtifactUploadService{}
+			if tt.err != nil {
+				svc.On("Commit", mock.Anything, projectID, diskID, uploadID).Return(nil, tt.err)
+			} else {
+				svc.On("Commit", mock.Anything, projectID, diskID, uploadID).Return(&model.Artifact{DiskID: diskID}, nil)
+			}
+			router := setupUploadRouter(svc, projectID)
+
+			req := httptest.NewRequest("POST", "/disk/"+diskID.String()+"/artifact/upload/"+uploadID.String()+"/commit", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactUploadHandler_AbortUpload(t *testing.T) {
+	projectID, diskID, uploadID := uuid.New(), uuid.New(), uuid.New()
+
+	svc := &MockArtifactUploadService{}
+	svc.On("Abort", mock.Anything, projectID, diskID, uploadID).Return(nil)
+	router := setupUploadRouter(svc, projectID)
+
+	req := httptest.NewRequest("DELETE", "/disk/"+diskID.String()+"/artifact/upload/"+uploadID.String(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("DELETE", "/disk/"+diskID.String()+"/artifact/upload/not-a-uuid", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	svc.AssertExpectations(t)
+}
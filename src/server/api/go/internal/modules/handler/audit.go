@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+)
+
+type AuditHandler struct {
+	svc service.AuditService
+}
+
+func NewAuditHandler(s service.AuditService) *AuditHandler {
+	return &AuditHandler{svc: s}
+}
+
+type ListAuditEventsReq struct {
+	ResourceType string `form:"resource_type" json:"resource_type" example:"session"`
+	ResourceID   string `form:"resource_id" json:"resource_id" format:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	APIKeyID     string `form:"api_key_id" json:"api_key_id" format:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Method       string `form:"method" json:"method" binding:"omitempty,oneof=POST PUT PATCH DELETE post put patch delete" example:"DELETE"`
+	From         string `form:"from" json:"from" example:"2025-01-01"` // RFC 3339 time or date
+	To           string `form:"to" json:"to" example:"2025-02-01"`     // RFC 3339 time or date, exclusive
+	Limit        int    `form:"limit,default=20" json:"limit" binding:"required,min=1,max=200" example:"20"`
+	Cursor       string `form:"cursor" json:"cursor" example:"cHJvdGVjdGVkIHZlcnNpb24gdG8gYmUgZXhjbHVkZWQgaW4gcGFyc2luZyB0aGUgY3Vyc29y"`
+	TimeDesc     bool   `form:"time_desc,default=false" json:"time_desc" example:"false"`
+}
+
+// ListAuditEvents godoc
+//
+//	@Summary		List audit events
+//	@Description	List the audit events of the project: one per mutating request, with who made it, the route, the resource IDs, summaries of the resource before and after, the trace ID and the client IP
+//	@Tags			project
+//	@Accept			json
+//	@Produce		json
+//	@Param			resource_type	query	string	false	"Route group of the request, e.g. session, space, disk"						example(session)
+//	@Param			resource_id		query	string	false	"Only events naming this resource ID, in the path or as the resource created"	format(uuid)
+//	@Param			api_key_id		query	string	false	"Only events of requests made with this API key"								format(uuid)
+//	@Param			method			query	string	false	"Only events of this HTTP method"												example(DELETE)
+//	@Param			from			query	string	false	"Start of the range, RFC 3339 time or date"									example(2025-01-01)
+//	@Param			to				query	string	false	"End of the range, exclusive"													example(2025-02-01)
+//	@Param			limit			query	integer	false	"Limit of events to return, default 20. Max 200."
+//	@Param			cursor			query	string	false	"Cursor for pagination. Use the cursor from the previous response to get the next page."
+//	@Param			time_desc		query	string	false	"Order by created_at descending if true, ascending if false (default false)"	example(false)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ListAuditEventsOutput}
+//	@Router			/audit [get]
+func (h *AuditHandler) ListAuditEvents(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	req := ListAuditEventsReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	filter := repo.AuditFilter{
+		ResourceType: req.ResourceType,
+		Method:       strings.ToUpper(req.Method),
+	}
+	if req.ResourceID != "" {
+		id, err := uuid.Parse(req.ResourceID)
+		if err != nil {
+			serializer.ParamErr(c, "invalid resource_id", err)
+			return
+		}
+		filter.ResourceID = &id
+	}
+	if req.APIKeyID != "" {
+		id, err := uuid.Parse(req.APIKeyID)
+		if err != nil {
+			serializer.ParamErr(c, "invalid api_key_id", err)
+			return
+		}
+		filter.APIKeyID = &id
+	}
+	var err error
+	if req.From != "" {
+		if filter.From, err = parseUsageTime(req.From); err != nil {
+			serializer.ParamErr(c, "invalid from", err)
+			return
+		}
+	}
+	if req.To != "" {
+		if filter.To, err = parseUsageTime(req.To); err != nil {
+			serializer.ParamErr(c, "invalid to", err)
+			return
+		}
+	}
+
+	out, err := h.svc.List(c.Request.Context(), service.ListAuditEventsInput{
+		ProjectID: project.ID,
+		Filter:    filter,
+		Limit:     req.Limit,
+		Cursor:    req.Cursor,
+		TimeDesc:  req.TimeDesc,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAuditQuery) {
+			serializer.ParamErr(c, "", err)
+			return
+		}
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
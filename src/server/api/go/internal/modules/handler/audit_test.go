@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuditService is a mock implementation of AuditService
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(ctx context.Context, e *model.AuditEvent) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+func (m *MockAuditService) List(ctx context.Context, in service.ListAuditEventsInput) (*service.ListAuditEventsOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ListAuditEventsOutput), args.Error(1)
+}
+
+func (m *MockAuditService) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	args := m.Called(ctx, retention)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestAuditHandler_ListAuditEvents(t *testing.T) {
+	projectID := uuid.New()
+	resourceID := uuid.New()
+	keyID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		setup          func(*MockAuditService)
+		expectedStatus int
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			setup: func(svc *MockAuditService) {
+				svc.On("List", mock.Anything, service.ListAuditEventsInput{
+					ProjectID: projectID,
+					Limit:     20,
+				}).Return(&service.ListAuditEventsOutput{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "filters",
+			query: "?resource_type=session&resource_id=" + resourceID.String() + "&api_key_id=" + keyID.String() +
+				"&method=delete&from=2025-01-01&to=2025-02-01T00:00:00Z&limit=50&cursor=abc&time_desc=true",
+			setup: func(svc *MockAuditService) {
+				svc.On("List", mock.Anything, service.ListAuditEventsInput{
+					ProjectID: projectID,
+					Filter: repo.AuditFilter{
+						ResourceType: "session",
+						ResourceID:   &resourceID,
+						APIKeyID:     &keyID,
+						Method:       http.MethodDelete,
+						From:         time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+						To:           time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+					},
+					Limit:    50,
+					Cursor:   "abc",
+					TimeDesc: true,
+				}).Return(&service.ListAuditEventsOutput{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid resource_id",
+			query:          "?resource_id=not-a-uuid",
+			setup:          func(svc *MockAuditService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "read method",
+			query:          "?method=GET",
+			setup:          func(svc *MockAuditService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "limit too large",
+			query:          "?limit=500",
+			setup:          func(svc *MockAuditService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "bad cursor",
+			query: "?cursor=%%%",
+			setup: func(svc *MockAuditService) {
+				svc.On("List", mock.Anything, mock.Anything).Return(nil, service.ErrInvalidAuditQuery)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockAuditService{}
+			tt.setup(svc)
+			h := NewAuditHandler(svc)
+
+			router := setupDiskRouter()
+			router.Use(func(c *gin.Context) { c.Set("project", &model.Project{ID: projectID}) })
+			router.GET("/audit", h.ListAuditEvents)
+
+			req := httptest.NewRequest(http.MethodGet, "/audit"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+)
+
+// BlobHandler serves the presigned URLs of blob stores that keep objects on the API node, and
+// of encrypted stores, which decrypt objects on the way out. With the S3 driver and encryption
+// off, clients talk to S3 directly and every request here is answered with 404.
+type BlobHandler struct {
+	store blob.SignedURLStore
+}
+
+func NewBlobHandler(store blob.BlobStore) *BlobHandler {
+	signed, _ := store.(blob.SignedURLStore)
+	return &BlobHandler{store: signed}
+}
+
+// GetObject godoc
+//
+//	@Summary		Download object by presigned URL
+//	@Description	Download an object through a presigned URL issued by the local or memory blob driver. Range requests are supported.
+//	@Tags			blob
+//	@Produce		octet-stream
+//	@Param			key			path	string	true	"Object key"
+//	@Param			expires		query	int		true	"Unix time the URL expires at"
+//	@Param			signature	query	string	true	"URL signature"
+//	@Success		200
+//	@Failure		403	{object}	serializer.Problem	"Invalid or expired signature"
+//	@Router			/blob/{key} [get]
+func (h *BlobHandler) GetObject(c *gin.Context) {
+	key, ok := h.verify(c, http.MethodGet)
+	if !ok {
+		return
+	}
+
+	f, _, err := h.store.Open(key)
+	if err != nil {
+		blobErr(c, err)
+		return
+	}
+	defer f.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Type", contentType)
+	http.ServeContent(c.Writer, c.Request, path.Base(key), time.Time{}, f)
+}
+
+// PutObject godoc
+//
+//	@Summary		Upload object by presigned URL
+//	@Description	Upload an object, or one part of a multipart upload, through a presigned URL issued by the local or memory blob driver
+//	@Tags			blob
+//	@Accept			octet-stream
+//	@Produce		json
+//	@Param			key			path	string	true	"Object key"
+//	@Param			expires		query	int		true	"Unix time the URL expires at"
+//	@Param			signature	query	string	true	"URL signature"
+//	@Success		200	{object}	serializer.Response{}
+//	@Failure		403	{object}	serializer.Problem	"Invalid or expired signature"
+//	@Router			/blob/{key} [put]
+func (h *BlobHandler) PutObject(c *gin.Context) {
+	key, ok := h.verify(c, http.MethodPut)
+	if !ok {
+		return
+	}
+	if projectID, ok := stagingProject(key); ok {
+		c.Set("project", &model.Project{ID: projectID})
+		c.Set("audit_actor", model.AuditActorPresignedURL)
+	}
+
+	if err := h.store.Write(key, c.Request.URL.Query(), c.Request.Body); err != nil {
+		blobErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{})
+}
+
+// verify checks the signature of the request URL and returns the object key
+func (h *BlobHandler) verify(c *gin.Context, method string) (string, bool) {
+	if h.store == nil {
+		serializer.Err(c, apierr.NotFound, "not found", nil)
+		return "", false
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if err := h.store.Verify(method, key, c.Request.URL.Query()); err != nil {
+		serializer.Err(c, apierr.InvalidSignature, err.Error(), nil)
+		return "", false
+	}
+	return key, true
+}
+
+// stagingProject returns the project of a staging key, uploads/<project>/<upload>, which are the
+// only keys uploads are presigned for
+func stagingProject(key string) (uuid.UUID, bool) {
+	parts := strings.Split(key, "/")
+	if len(parts) != 3 || parts[0] != "uploads" {
+		return uuid.Nil, false
+	}
+	projectID, err := uuid.Parse(parts[1])
+	return projectID, err == nil
+}
+
+func blobErr(c *gin.Context, err error) {
+	if errors.Is(err, blob.ErrObjectNotFound) {
+		serializer.Err(c, apierr.ObjectNotFound, "object not found", err)
+		return
+	}
+	if errors.Is(err, blob.ErrDirectUploadUnsupported) {
+		serializer.Err(c, apierr.DirectUploadUnsupported, err.Error(), nil)
+		return
+	}
+	serializer.Err(c, apierr.Internal, "blob store error", err)
+}
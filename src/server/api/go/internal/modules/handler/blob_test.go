@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupBlobRouter(store blob.BlobStore) *gin.Engine {
+	h := NewBlobHandler(store)
+	router := setupDiskRouter()
+	router.GET(blob.SignedURLPath+"*key", h.GetObject)
+	router.PUT(blob.SignedURLPath+"*key", h.PutObject)
+	return router
+}
+
+// requestURI strips the scheme and host from a presigned URL
+func requestURI(t *testing.T, raw string) string {
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u.RequestURI()
+}
+
+func TestBlobHandler_PresignedRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := blob.NewMemoryStore(blob.NewURLSigner("http://api.test", []byte("secret")))
+	router := setupBlobRouter(store)
+	key := "disks/p1/report 1.csv"
+
+	putURL, err := store.PresignPut(ctx, key, "text/csv", time.Minute)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", requestURI(t, putURL), strings.NewReader("a,b\n1,2\n")))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	getURL, err := store.PresignGet(ctx, key, time.Minute)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", requestURI(t, getURL), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a,b\n1,2\n", w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+
+	req := httptest.NewRequest("GET", requestURI(t, getURL), nil)
+	req.Header.Set("Range", "bytes=4-6")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	assert.Equal(t, "1,2", string(body))
+
+	// A GET URL cannot be used to upload
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", requestURI(t, getURL), strings.NewReader("x")))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", blob.SignedURLPath+"disks/p1/other.csv?"+url.Values{"expires": {"1"}, "signature": {"00"}}.Encode(), nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	missingURL, _ := store.PresignGet(ctx, "disks/p1/missing.csv", time.Minute)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", requestURI(t, missingURL), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestBlobHandler_S3Driver(t *testing.T) {
+	router := setupBlobRouter(&blob.S3Deps{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", blob.SignedURLPath+"disks/p1/a.txt", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestBlobHandler_PutObjectAuditContext(t *testing.T) {
+	ctx := context.Background()
+	store := blob.NewMemoryStore(blob.NewURLSigner("http://api.test", []byte("secret")))
+	h := NewBlobHandler(store)
+	projectID := uuid.New()
+
+	var project *model.Project
+	var actor string
+	router := gin.New()
+	router.PUT(blob.SignedURLPath+"*key", func(c *gin.Context) {
+		// Reads the context after the handler, like the audit middleware
+		c.Next()
+		if p, ok := c.Get("project"); ok {
+			project = p.(*model.Project)
+		}
+		actor = c.GetString("audit_actor")
+	}, h.PutObject)
+
+	tests := []struct {
+		name    string
+		key     string
+		project *model.Project
+		actor   string
+	}{
+		{name: "staging key", key: "uploads/" + projectID.String() + "/" + uuid.NewString(), project: &model.Project{ID: projectID}, actor: model.AuditActorPresignedURL},
+		{name: "other key", key: "disks/p1/report.csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, actor = nil, ""
+			putURL, err := store.PresignPut(ctx, tt.key, "text/csv", time.Minute)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("PUT", requestURI(t, putURL), strings.NewReader("x")))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.project, project)
+			assert.Equal(t, tt.actor, actor)
+		})
+	}
+}
@@ -10,8 +10,10 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
 	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/path"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type BlockHandler struct {
@@ -26,6 +28,16 @@ func NewBlockHandler(s service.BlockService, coreClient *httpclient.CoreClient)
 	}
 }
 
+// blockErr answers a failed block operation. Blocks, including the parent of a move, are
+// looked up within the space of the route, so a block of another space is not found.
+func blockErr(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		serializer.Err(c, apierr.BlockNotFound, "block not found", err)
+		return
+	}
+	serializer.DBErr(c, "", err)
+}
+
 type CreateBlockReq struct {
 	ParentID *uuid.UUID     `from:"parent_id" json:"parent_id"`
 	Type     string         `from:"type" json:"type" binding:"required" example:"text"`
@@ -50,29 +62,29 @@ func (h *BlockHandler) CreateBlock(c *gin.Context) {
 	// Get project from context
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	spaceID, err := uuid.Parse(c.Param("space_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	req := CreateBlockReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	if !model.IsValidBlockType(req.Type) {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("type", errors.New("invalid block type")))
+		serializer.ParamErr(c, "type", errors.New("invalid block type"))
 		return
 	}
 
 	if _, filename := path.SplitFilePath(req.Title); filename != req.Title {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("title", errors.New("title cannot contain path")))
+		serializer.ParamErr(c, "title", errors.New("title cannot contain path"))
 		return
 	}
 
@@ -87,27 +99,27 @@ func (h *BlockHandler) CreateBlock(c *gin.Context) {
 
 	// 2. Validate basic block constraints
 	if err := tempBlock.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	// 3. If parent_id is provided, validate parent-child relationship
 	if req.ParentID != nil {
-		parent, err := h.svc.GetBlockProperties(c.Request.Context(), *req.ParentID)
+		parent, err := h.svc.GetBlockProperties(c.Request.Context(), spaceID, *req.ParentID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("parent_id", errors.New("parent block not found")))
+			serializer.ParamErr(c, "parent_id", errors.New("parent block not found"))
 			return
 		}
 
 		// Check if parent can have children
 		if !parent.CanHaveChildren() {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("parent_id", errors.New("parent cannot have children")))
+			serializer.ParamErr(c, "parent_id", errors.New("parent cannot have children"))
 			return
 		}
 
 		// Validate parent type compatibility
 		if err := tempBlock.ValidateParentType(parent); err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("parent_id", err))
+			serializer.ParamErr(c, "parent_id", err)
 			return
 		}
 	}
@@ -123,7 +135,7 @@ func (h *BlockHandler) CreateBlock(c *gin.Context) {
 	// Call Core service to insert block
 	result, err := h.coreClient.InsertBlock(c.Request.Context(), project.ID, spaceID, coreReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "failed to insert block", err))
+		serializer.Err(c, apierr.Internal, "failed to insert block", err)
 		return
 	}
 
@@ -146,18 +158,18 @@ func (h *BlockHandler) CreateBlock(c *gin.Context) {
 func (h *BlockHandler) DeleteBlock(c *gin.Context) {
 	spaceID, err := uuid.Parse(c.Param("space_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	blockID, err := uuid.Parse(c.Param("block_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	if err := h.svc.Delete(c.Request.Context(), spaceID, blockID); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -178,15 +190,20 @@ func (h *BlockHandler) DeleteBlock(c *gin.Context) {
 //	@Router			/space/{space_id}/block/{block_id}/properties [get]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Get block properties\nblock = client.blocks.get_properties(\n    space_id='space-uuid',\n    block_id='block-uuid'\n)\nprint(f\"{block.title}: {block.props}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Get block properties\nconst block = await client.blocks.getProperties('space-uuid', 'block-uuid');\nconsole.log(`${block.title}: ${JSON.stringify(block.props)}`);\n","label":"JavaScript"}]
 func (h *BlockHandler) GetBlockProperties(c *gin.Context) {
+	spaceID, err := uuid.Parse(c.Param("space_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
 	blockID, err := uuid.Parse(c.Param("block_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
-	b, err := h.svc.GetBlockProperties(c.Request.Context(), blockID)
+	b, err := h.svc.GetBlockProperties(c.Request.Context(), spaceID, blockID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		blockErr(c, err)
 		return
 	}
 
@@ -213,30 +230,36 @@ type UpdateBlockPropertiesReq struct {
 //	@Router			/space/{space_id}/block/{block_id}/properties [put]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Update block properties\nclient.blocks.update_properties(\n    space_id='space-uuid',\n    block_id='block-uuid',\n    title='Updated Title',\n    props={\"text\": \"Updated content\"}\n)\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Update block properties\nawait client.blocks.updateProperties('space-uuid', 'block-uuid', {\n  title: 'Updated Title',\n  props: { text: 'Updated content' }\n});\n","label":"JavaScript"}]
 func (h *BlockHandler) UpdateBlockProperties(c *gin.Context) {
+	spaceID, err := uuid.Parse(c.Param("space_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
 	blockID, err := uuid.Parse(c.Param("block_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	req := UpdateBlockPropertiesReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	if _, filename := path.SplitFilePath(req.Title); filename != req.Title {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("title", errors.New("title cannot contain path")))
+		serializer.ParamErr(c, "title", errors.New("title cannot contain path"))
 		return
 	}
 
 	b := model.Block{
-		ID:    blockID,
-		Title: req.Title,
-		Props: datatypes.NewJSONType(req.Props),
+		ID:      blockID,
+		SpaceID: spaceID,
+		Title:   req.Title,
+		Props:   datatypes.NewJSONType(req.Props),
 	}
 	if err := h.svc.UpdateBlockProperties(c.Request.Context(), &b); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		blockErr(c, err)
 		return
 	}
 
@@ -265,13 +288,13 @@ type ListBlocksReq struct {
 func (h *BlockHandler) ListBlocks(c *gin.Context) {
 	spaceID, err := uuid.Parse(c.Param("space_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	req := ListBlocksReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
@@ -280,7 +303,7 @@ func (h *BlockHandler) ListBlocks(c *gin.Context) {
 	if req.ParentID != "" {
 		pid, err := uuid.Parse(req.ParentID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("parent_id", err))
+			serializer.ParamErr(c, "parent_id", err)
 			return
 		}
 		parentID = &pid
@@ -289,7 +312,7 @@ func (h *BlockHandler) ListBlocks(c *gin.Context) {
 	// Use unified List method - it handles type and parent_id filtering
 	list, err := h.svc.List(c.Request.Context(), spaceID, req.Type, parentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -316,27 +339,32 @@ type MoveBlockReq struct {
 //	@Router			/space/{space_id}/block/{block_id}/move [put]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Move block to a different parent\nclient.blocks.move(\n    space_id='space-uuid',\n    block_id='block-uuid',\n    parent_id='new-parent-uuid'\n)\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Move block to a different parent\nawait client.blocks.move('space-uuid', 'block-uuid', {\n  parentId: 'new-parent-uuid'\n});\n","label":"JavaScript"}]
 func (h *BlockHandler) MoveBlock(c *gin.Context) {
+	spaceID, err := uuid.Parse(c.Param("space_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
 	blockID, err := uuid.Parse(c.Param("block_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	req := MoveBlockReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	// Validate: parent_id cannot be the block itself
 	if req.ParentID != nil && *req.ParentID == blockID {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("parent_id", errors.New("parent_id cannot be self")))
+		serializer.ParamErr(c, "parent_id", errors.New("parent_id cannot be self"))
 		return
 	}
 
 	// Use unified Move method - it handles special logic for folder path
-	if err := h.svc.Move(c.Request.Context(), blockID, req.ParentID, req.Sort); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+	if err := h.svc.Move(c.Request.Context(), spaceID, blockID, req.ParentID, req.Sort); err != nil {
+		blockErr(c, err)
 		return
 	}
 
@@ -362,20 +390,25 @@ type UpdateBlockSortReq struct {
 //	@Router			/space/{space_id}/block/{block_id}/sort [put]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Update block sort order\nclient.blocks.update_sort(\n    space_id='space-uuid',\n    block_id='block-uuid',\n    sort=5\n)\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Update block sort order\nawait client.blocks.updateSort('space-uuid', 'block-uuid', {\n  sort: 5\n});\n","label":"JavaScript"}]
 func (h *BlockHandler) UpdateBlockSort(c *gin.Context) {
+	spaceID, err := uuid.Parse(c.Param("space_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
 	blockID, err := uuid.Parse(c.Param("block_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	req := UpdateBlockSortReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
-	if err := h.svc.UpdateSort(c.Request.Context(), blockID, req.Sort); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+	if err := h.svc.UpdateSort(c.Request.Context(), spaceID, blockID, req.Sort); err != nil {
+		blockErr(c, err)
 		return
 	}
 
@@ -15,6 +15,7 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // MockBlockService is a mock implementation of BlockService
@@ -34,8 +35,8 @@ func (m *MockBlockService) Delete(ctx context.Context, spaceID uuid.UUID, blockI
 	return args.Error(0)
 }
 
-func (m *MockBlockService) GetBlockProperties(ctx context.Context, blockID uuid.UUID) (*model.Block, error) {
-	args := m.Called(ctx, blockID)
+func (m *MockBlockService) GetBlockProperties(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID) (*model.Block, error) {
+	args := m.Called(ctx, spaceID, blockID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -55,13 +56,13 @@ func (m *MockBlockService) List(ctx context.Context, spaceID uuid.UUID, blockTyp
 	return args.Get(0).([]model.Block), args.Error(1)
 }
 
-func (m *MockBlockService) Move(ctx context.Context, blockID uuid.UUID, newParentID *uuid.UUID, targetSort *int64) error {
-	args := m.Called(ctx, blockID, newParentID, targetSort)
+func (m *MockBlockService) Move(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID, newParentID *uuid.UUID, targetSort *int64) error {
+	args := m.Called(ctx, spaceID, blockID, newParentID, targetSort)
 	return args.Error(0)
 }
 
-func (m *MockBlockService) UpdateSort(ctx context.Context, blockID uuid.UUID, sort int64) error {
-	args := m.Called(ctx, blockID, sort)
+func (m *MockBlockService) UpdateSort(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID, sort int64) error {
+	args := m.Called(ctx, spaceID, blockID, sort)
 	return args.Error(0)
 }
 
@@ -670,6 +671,17 @@ func TestBlockHandler_UpdateBlockProperties(t *testing.T) {
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name:         "block of another space",
+			blockIDParam: blockID.String(),
+			requestBody: UpdateBlockPropertiesReq{
+				Title: "Updated Title",
+			},
+			setup: func(svc *MockBlockService) {
+				svc.On("UpdateBlockProperties", mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+	"github.com/memodb-io/Acontext/internal/pkg/configs"
+	"gorm.io/gorm"
+)
+
+// ConfigHandler serves the schema of configs, their merge patches and the configs that
+// apply to spaces and sessions
+type ConfigHandler struct {
+	svc service.ConfigService
+}
+
+func NewConfigHandler(s service.ConfigService) *ConfigHandler {
+	return &ConfigHandler{svc: s}
+}
+
+// invalidConfigs answers 422 with the problems of refused configs; it reports whether err was one
+func invalidConfigs(c *gin.Context, err error) bool {
+	var verr *configs.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	serializer.ErrWith(c, apierr.InvalidConfigs, verr.Error(), nil, map[string]any{"problems": verr.Problems})
+	return true
+}
+
+func configErr(c *gin.Context, notFound apierr.Code, err error) {
+	if invalidConfigs(c, err) {
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		serializer.Err(c, notFound, "", err)
+		return
+	}
+	serializer.DBErr(c, "", err)
+}
+
+// bindMergePatch reads a JSON merge patch, which must be an object
+func bindMergePatch(c *gin.Context) (map[string]any, bool) {
+	var patch map[string]any
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		serializer.ParamErr(c, "", err)
+		return nil, false
+	}
+	if patch == nil {
+		serializer.ParamErr(c, "merge patch must be a JSON object", nil)
+		return nil, false
+	}
+	return patch, true
+}
+
+// GetConfigSchema godoc
+//
+//	@Summary		Get config schema
+//	@Description	List the registered keys of project, space and session configs, with the scopes they can be set at and their JSON Schema, and how unknown keys are treated
+//	@Tags			config
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ConfigSchema}
+//	@Router			/config/schema [get]
+func (h *ConfigHandler) GetConfigSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, serializer.Response{Data: h.svc.Schema()})
+}
+
+// PatchSpaceConfigs godoc
+//
+//	@Summary		Patch space configs
+//	@Description	Apply a JSON merge patch (RFC 7396) to the configs of a space: keys set to null are removed, objects are merged and other values replace the stored ones. Unknown keys are reported in warnings, or refused when the server rejects them.
+//	@Tags			space
+//	@Accept			json
+//	@Accept			application/merge-patch+json
+//	@Produce		json
+//	@Param			space_id	path	string	true	"Space ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload		body	object	true	"Merge patch of the configs"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ConfigsUpdate}
+//	@Failure		422	{object}	serializer.Problem{problems=[]configs.Problem}
+//	@Router			/space/{space_id}/configs [patch]
+func (h *ConfigHandler) PatchSpaceConfigs(c *gin.Context) {
+	h.patch(c, configs.ScopeSpace, "space_id", apierr.SpaceNotFound)
+}
+
+// PatchSessionConfigs godoc
+//
+//	@Summary		Patch session configs
+//	@Description	Apply a JSON merge patch (RFC 7396) to the configs of a session: keys set to null are removed, objects are merged and other values replace the stored ones. Unknown keys are reported in warnings, or refused when the server rejects them.
+//	@Tags			session
+//	@Accept			json
+//	@Accept			application/merge-patch+json
+//	@Produce		json
+//	@Param			session_id	path	string	true	"Session ID"	format(uuid)
+//	@Param			payload		body	object	true	"Merge patch of the configs"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ConfigsUpdate}
+//	@Failure		422	{object}	serializer.Problem{problems=[]configs.Problem}
+//	@Router			/session/{session_id}/configs [patch]
+func (h *ConfigHandler) PatchSessionConfigs(c *gin.Context) {
+	h.patch(c, configs.ScopeSession, "session_id", apierr.SessionNotFound)
+}
+
+// PatchProjectConfigs godoc
+//
+//	@Summary		Patch project configs
+//	@Description	Apply a JSON merge patch (RFC 7396) to the configs of a project, e.g. {"usage_limits": {"storage_bytes": {"hard": 1073741824}}} changes one limit and keeps the others
+//	@Tags			admin
+//	@Accept			json
+//	@Accept			application/merge-patch+json
+//	@Produce		json
+//	@Param			project_id	path	string	true	"Project ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload		body	object	true	"Merge patch of the configs"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ConfigsUpdate}
+//	@Failure		404	{object}	serializer.Problem	"The project does not exist or is being deleted"
+//	@Failure		422	{object}	serializer.Problem{problems=[]configs.Problem}
+//	@Router			/admin/project/{project_id}/configs [patch]
+func (h *ConfigHandler) PatchProjectConfigs(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+	patch, ok := bindMergePatch(c)
+	if !ok {
+		return
+	}
+
+	out, err := h.svc.PatchConfigs(c.Request.Context(), service.ConfigTarget{Scope: configs.ScopeProject, ProjectID: projectID, ID: projectID}, patch)
+	if err != nil {
+		configErr(c, apierr.ProjectNotFound, err)
+		return
+	}
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+func (h *ConfigHandler) patch(c *gin.Context, scope configs.Scope, param string, notFound apierr.Code) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+	patch, ok := bindMergePatch(c)
+	if !ok {
+		return
+	}
+
+	out, err := h.svc.PatchConfigs(c.Request.Context(), service.ConfigTarget{Scope: scope, ProjectID: project.ID, ID: id}, patch)
+	if err != nil {
+		configErr(c, notFound, err)
+		return
+	}
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+// GetSpaceEffectiveConfigs godoc
+//
+//	@Summary		Get effective space configs
+//	@Description	Resolve the configs that apply to a space: those of its project, overridden by its own. Objects are merged key by key; sources names the scope each key comes from.
+//	@Tags			space
+//	@Produce		json
+//	@Param			space_id	path	string	true	"Space ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=configs.Effective}
+//	@Router			/space/{space_id}/effective_configs [get]
+func (h *ConfigHandler) GetSpaceEffectiveConfigs(c *gin.Context) {
+	h.effective(c, configs.ScopeSpace, "space_id", apierr.SpaceNotFound)
+}
+
+// GetSessionEffectiveConfigs godoc
+//
+//	@Summary		Get effective session configs
+//	@Description	Resolve the configs that apply to a session: those of its project, overridden by those of the space it is connected to, overridden by its own. Objects are merged key by key; sources names the scope each key comes from.
+//	@Tags			session
+//	@Produce		json
+//	@Param			session_id	path	string	true	"Session ID"	format(uuid)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=configs.Effective}
+//	@Router			/session/{session_id}/effective_configs [get]
+func (h *ConfigHandler) GetSessionEffectiveConfigs(c *gin.Context) {
+	h.effective(c, configs.ScopeSession, "session_id", apierr.SessionNotFound)
+}
+
+func (h *ConfigHandler) effective(c *gin.Context, scope configs.Scope, param string, notFound apierr.Code) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	out, err := h.svc.EffectiveConfigs(c.Request.Context(), service.ConfigTarget{Scope: scope, ProjectID: project.ID, ID: id})
+	if err != nil {
+		configErr(c, notFound, err)
+		return
+	}
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// MockConfigService is a mock implementation of ConfigService
+type MockConfigService struct {
+	mock.Mock
+}
+
+func (m *MockConfigService) Schema() *service.ConfigSchema {
+	args := m.Called()
+	return args.Get(0).(*service.ConfigSchema)
+}
+
+func (m *MockConfigService) PatchConfigs(ctx context.Context, t service.ConfigTarget, patch map[string]any) (*service.ConfigsUpdate, error) {
+	args := m.Called(ctx, t, patch)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ConfigsUpdate), args.Error(1)
+}
+
+func (m *MockConfigService) EffectiveConfigs(ctx context.Context, t service.ConfigTarget) (*configs.Effective, error) {
+	args := m.Called(ctx, t)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*configs.Effective), args.Error(1)
+}
+
+func TestConfigHandler(t *testing.T) {
+	project := &model.Project{ID: uuid.New()}
+	spaceID, sessionID := uuid.New(), uuid.New()
+	spaceTarget := service.ConfigTarget{Scope: configs.ScopeSpace, ProjectID: project.ID, ID: spaceID}
+	sessionTarget := service.ConfigTarget{Scope: configs.ScopeSession, ProjectID: project.ID, ID: sessionID}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setup          func(*MockConfigService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "schema",
+			method: http.MethodGet,
+			path:   "/config/schema",
+			setup: func(svc *MockConfigService) {
+				svc.On("Schema").Return(&service.ConfigSchema{UnknownKeys: configs.UnknownKeysWarn})
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "patch space configs",
+			method: http.MethodPatch,
+			path:   "/space/" + spaceID.String() + "/configs",
+			body:   `{"mode":"fast","name":null}`,
+			setup: func(svc *MockConfigService) {
+				svc.On("PatchConfigs", mock.Anything, spaceTarget, map[string]any{"mode": "fast", "name": nil}).
+					Return(&service.ConfigsUpdate{Configs: map[string]any{"mode": "fast"}, Warnings: []configs.Problem{{Key: "mode"}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"warnings":[{"key":"mode"`,
+		},
+		{
+			name:           "patch that is not an object",
+			method:         http.MethodPatch,
+			path:           "/space/" + spaceID.String() + "/configs",
+			body:           `null`,
+			setup:          func(svc *MockConfigService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "patch that is an array",
+			method:         http.MethodPatch,
+			path:           "/space/" + spaceID.String() + "/configs",
+			body:           `[{"op":"add"}]`,
+			setup:          func(svc *MockConfigService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "patch with a project key",
+			method: http.MethodPatch,
+			path:   "/session/" + sessionID.String() + "/configs",
+			body:   `{"usage_limits":{}}`,
+			setup: func(svc *MockConfigService) {
+				svc.On("PatchConfigs", mock.Anything, sessionTarget, mock.Anything).Return(nil, &configs.ValidationError{
+					Problems: []configs.Problem{{Key: "usage_limits", Message: "cannot be set on a session, only on a project"}},
+				})
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"problems":[{"key":"usage_limits"`,
+		},
+		{
+			name:   "patch configs of an unknown project",
+			method: http.MethodPatch,
+			path:   "/admin/project/" + project.ID.String() + "/configs",
+			body:   `{"rate_limits":{"concurrency":2}}`,
+			setup: func(svc *MockConfigService) {
+				target := service.ConfigTarget{Scope: configs.ScopeProject, ProjectID: project.ID, ID: project.ID}
+				svc.On("PatchConfigs", mock.Anything, target, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "effective session configs",
+			method: http.MethodGet,
+			path:   "/session/" + sessionID.String() + "/effective_configs",
+			setup: func(svc *MockConfigService) {
+				svc.On("EffectiveConfigs", mock.Anything, sessionTarget).Return(&configs.Effective{
+					Configs: map[string]any{"mode": "fast"},
+					Sources: map[string]configs.Scope{"mode": configs.ScopeSpace},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"sources":{"mode":"space"}`,
+		},
+		{
+			name:   "effective configs of an unknown space",
+			method: http.MethodGet,
+			path:   "/space/" + spaceID.String() + "/effective_configs",
+			setup: func(svc *MockConfigService) {
+				svc.On("EffectiveConfigs", mock.Anything, spaceTarget).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid session id",
+			method:         http.MethodGet,
+			path:           "/session/not-a-uuid/effective_configs",
+			setup:          func(svc *MockConfigService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockConfigService{}
+			tt.setup(svc)
+			h := NewConfigHandler(svc)
+
+			router := setupDiskRouter()
+			router.PATCH("/admin/project/:project_id/configs", h.PatchProjectConfigs)
+			scoped := router.Group("", func(c *gin.Context) {
+				c.Set("project", project)
+				c.Next()
+			})
+			scoped.GET("/config/schema", h.GetConfigSchema)
+			scoped.PATCH("/space/:space_id/configs", h.PatchSpaceConfigs)
+			scoped.GET("/space/:space_id/effective_configs", h.GetSpaceEffectiveConfigs)
+			scoped.PATCH("/session/:session_id/configs", h.PatchSessionConfigs)
+			scoped.GET("/session/:session_id/effective_configs", h.GetSessionEffectiveConfigs)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			require.True(t, json.Valid(w.Body.Bytes()))
+			if tt.expectedBody != "" {
+				assert.Contains(t, w.Body.String(), tt.expectedBody)
+			}
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,6 +10,8 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
 	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+	"gorm.io/gorm"
 )
 
 type DiskHandler struct {
@@ -19,33 +22,227 @@ func NewDiskHandler(s service.DiskService) *DiskHandler {
 	return &DiskHandler{svc: s}
 }
 
+type DiskReq struct {
+	Name        *string                `json:"name" example:"user-42"` // Unique within the project, optional
+	Description string                 `json:"description" example:"Files produced for user 42"`
+	Meta        map[string]interface{} `json:"meta"`
+	MaxBytes    *int64                 `json:"max_bytes" binding:"omitempty,min=0" example:"1073741824"` // Total artifact size quota, unlimited when omitted
+	MaxFiles    *int64                 `json:"max_files" binding:"omitempty,min=0" example:"10000"`      // Artifact count quota, unlimited when omitted
+}
+
+func (r DiskReq) fields() service.DiskFields {
+	return service.DiskFields{
+		Name:        r.Name,
+		Description: r.Description,
+		Meta:        r.Meta,
+		MaxBytes:    r.MaxBytes,
+		MaxFiles:    r.MaxFiles,
+	}
+}
+
+// diskErr answers a failed disk operation
+func diskErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		serializer.Err(c, apierr.DiskNotFound, "disk not found", err)
+	case errors.Is(err, service.ErrDiskNameExists):
+		serializer.Err(c, apierr.DiskNameConflict, "disk name already exists", err)
+	case errors.Is(err, service.ErrInvalidDisk):
+		serializer.ParamErr(c, "", err)
+	default:
+		serializer.DBErr(c, "", err)
+	}
+}
+
 // CreateDisk godoc
 //
 //	@Summary		Create disk
-//	@Description	Create a disk group under a project
+//	@Description	Create a disk group under a project. The body is optional; name, description, meta and quotas can be set later.
 //	@Tags			disk
 //	@Accept			json
 //	@Produce		json
+//	@Param			payload	body	handler.DiskReq	false	"CreateDisk payload"
 //	@Security		BearerAuth
 //	@Success		201	{object}	serializer.Response{data=model.Disk}
+//	@Failure		409	{object}	serializer.Problem	"Disk name already exists"
 //	@Router			/disk [post]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Create a disk\ndisk = client.disks.create()\nprint(f\"Created disk: {disk.id}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Create a disk\nconst disk = await client.disks.create();\nconsole.log(`Created disk: ${disk.id}`);\n","label":"JavaScript"}]
 func (h *DiskHandler) CreateDisk(c *gin.Context) {
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	// The body is optional
+	req := DiskReq{}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
-	disk, err := h.svc.Create(c.Request.Context(), project.ID)
+	disk, err := h.svc.Create(c.Request.Context(), service.CreateDiskInput{
+		ProjectID:  project.ID,
+		DiskFields: req.fields(),
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		diskErr(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, serializer.Response{Data: disk})
 }
 
+// GetDisk godoc
+//
+//	@Summary		Get disk
+//	@Description	Get a disk by its UUID
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.Disk}
+//	@Router			/disk/{disk_id} [get]
+func (h *DiskHandler) GetDisk(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	disk, err := h.svc.Get(c.Request.Context(), project.ID, diskID)
+	if err != nil {
+		diskErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: disk})
+}
+
+type GetDiskByNameReq struct {
+	Name string `form:"name" json:"name" binding:"required" example:"user-42"`
+}
+
+// GetDiskByName godoc
+//
+//	@Summary		Get disk by name
+//	@Description	Look up a disk of the project by its name
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			name	query	string	true	"Disk name"	example(user-42)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.Disk}
+//	@Failure		404	{object}	serializer.Problem	"No disk has the name"
+//	@Router			/disk/by_name [get]
+func (h *DiskHandler) GetDiskByName(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	req := GetDiskByNameReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	disk, err := h.svc.GetByName(c.Request.Context(), project.ID, req.Name)
+	if err != nil {
+		diskErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: disk})
+}
+
+// UpdateDisk godoc
+//
+//	@Summary		Update disk
+//	@Description	Replace the name, description, meta and quotas of a disk. Omitted fields are cleared, and omitted quotas become unlimited.
+//	@Description	A quota lower than the current usage is accepted and blocks further uploads.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string			true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload	body	handler.DiskReq	true	"UpdateDisk payload"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.Disk}
+//	@Failure		409	{object}	serializer.Problem	"Disk name already exists"
+//	@Router			/disk/{disk_id} [put]
+func (h *DiskHandler) UpdateDisk(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	req := DiskReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	disk, err := h.svc.Update(c.Request.Context(), service.UpdateDiskInput{
+		ProjectID:  project.ID,
+		DiskID:     diskID,
+		DiskFields: req.fields(),
+	})
+	if err != nil {
+		diskErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: disk})
+}
+
+// GetDiskUsage godoc
+//
+//	@Summary		Get disk usage
+//	@Description	Get the number of artifacts and their total size in a disk, together with the disk quotas
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.DiskUsage}
+//	@Router			/disk/{disk_id}/usage [get]
+func (h *DiskHandler) GetDiskUsage(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	usage, err := h.svc.GetUsage(c.Request.Context(), project.ID, diskID)
+	if err != nil {
+		diskErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: usage})
+}
+
 type ListDisksReq struct {
 	Limit    int    `form:"limit,default=20" json:"limit" binding:"required,min=1,max=200" example:"20"`
 	Cursor   string `form:"cursor" json:"cursor" example:"cHJvdGVjdGVkIHZlcnNpb24gdG8gYmUgZXhjbHVkZWQgaW4gcGFyc2luZyB0aGUgY3Vyc29y"`
@@ -69,13 +266,13 @@ type ListDisksReq struct {
 func (h *DiskHandler) ListDisks(c *gin.Context) {
 	req := ListDisksReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
@@ -86,7 +283,7 @@ func (h *DiskHandler) ListDisks(c *gin.Context) {
 		TimeDesc:  req.TimeDesc,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -96,7 +293,7 @@ func (h *DiskHandler) ListDisks(c *gin.Context) {
 // DeleteDisk godoc
 //
 //	@Summary		Delete disk
-//	@Description	Delete a disk by its UUID
+//	@Description	Move a disk to the trash, which frees its name. It can be restored with POST /disk/{disk_id}/restore for trash.retentionDays days, after which it is purged with its artifacts.
 //	@Tags			disk
 //	@Accept			json
 //	@Produce		json
@@ -108,18 +305,18 @@ func (h *DiskHandler) ListDisks(c *gin.Context) {
 func (h *DiskHandler) DeleteDisk(c *gin.Context) {
 	diskID, err := uuid.Parse(c.Param("disk_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	if err := h.svc.Delete(c.Request.Context(), project.ID, diskID); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		serializer.DBErr(c, "", err)
 		return
 	}
 
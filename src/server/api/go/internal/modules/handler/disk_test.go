@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // MockDiskService is a mock implementation of DiskService
@@ -22,14 +24,46 @@ type MockDiskService struct {
 	mock.Mock
 }
 
-func (m *MockDiskService) Create(ctx context.Context, projectID uuid.UUID) (*model.Disk, error) {
-	args := m.Called(ctx, projectID)
+func (m *MockDiskService) Create(ctx context.Context, in service.CreateDiskInput) (*model.Disk, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockDiskService) Update(ctx context.Context, in service.UpdateDiskInput) (*model.Disk, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockDiskService) Get(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockDiskService) GetByName(ctx context.Context, projectID uuid.UUID, name string) (*model.Disk, error) {
+	args := m.Called(ctx, projectID, name)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.Disk), args.Error(1)
 }
 
+func (m *MockDiskService) GetUsage(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.DiskUsage, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DiskUsage), args.Error(1)
+}
+
 func (m *MockDiskService) Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) error {
 	args := m.Called(ctx, projectID, diskID)
 	return args.Error(0)
@@ -74,14 +108,14 @@ func TestDiskHandler_CreateDisk(t *testing.T) {
 		{
 			name: "successful disk creation",
 			setup: func(svc *MockDiskService) {
-				svc.On("Create", mock.Anything, projectID).Return(disk, nil)
+				svc.On("Create", mock.Anything, service.CreateDiskInput{ProjectID: projectID}).Return(disk, nil)
 			},
 			expectedStatus: http.StatusCreated,
 		},
 		{
 			name: "service error",
 			setup: func(svc *MockDiskService) {
-				svc.On("Create", mock.Anything, projectID).Return(nil, errors.New("service error"))
+				svc.On("Create", mock.Anything, service.CreateDiskInput{ProjectID: projectID}).Return(nil, errors.New("service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
@@ -273,3 +307,168 @@ func TestDiskHandler_DeleteDisk(t *testing.T) {
 		})
 	}
 }
+
+func TestDiskHandler_CreateDiskWithFields(t *testing.T) {
+	projectID := uuid.New()
+	name := "user-42"
+	maxBytes := int64(1024)
+
+	tests := []struct {
+		name           string
+		body           string
+		setup          func(*MockDiskService)
+		expectedStatus int
+	}{
+		{
+			name: "name, description and quota",
+			body: `{"name":"user-42","description":"d","max_bytes":1024}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("Create", mock.Anything, service.CreateDiskInput{
+					ProjectID:  projectID,
+					DiskFields: service.DiskFields{Name: &name, Description: "d", MaxBytes: &maxBytes},
+				}).Return(&model.Disk{ProjectID: projectID, Name: &name}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "negative quota",
+			body:           `{"max_files":-1}`,
+			setup:          func(svc *MockDiskService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "name already taken",
+			body: `{"name":"user-42"}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, service.ErrDiskNameExists)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockDiskService{}
+			tt.setup(mockService)
+			handler := NewDiskHandler(mockService)
+
+			router := setupDiskRouter()
+			router.POST("/disk", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				handler.CreateDisk(c)
+			})
+
+			req := httptest.NewRequest("POST", "/disk", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiskHandler_LookupAndUsage(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	maxFiles := int64(10)
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		body           string
+		setup          func(*MockDiskService)
+		expectedStatus int
+	}{
+		{
+			name:   "get by name",
+			method: "GET",
+			url:    "/disk/by_name?name=user-42",
+			setup: func(svc *MockDiskService) {
+				svc.On("GetByName", mock.Anything, projectID, "user-42").Return(&model.Disk{ID: diskID}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "unknown name",
+			method: "GET",
+			url:    "/disk/by_name?name=nobody",
+			setup: func(svc *MockDiskService) {
+				svc.On("GetByName", mock.Anything, projectID, "nobody").Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "get by name without a name",
+			method:         "GET",
+			url:            "/disk/by_name",
+			setup:          func(svc *MockDiskService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "get by id",
+			method: "GET",
+			url:    "/disk/" + diskID.String(),
+			setup: func(svc *MockDiskService) {
+				svc.On("Get", mock.Anything, projectID, diskID).Return(&model.Disk{ID: diskID}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "update",
+			method: "PUT",
+			url:    "/disk/" + diskID.String(),
+			body:   `{"max_files":10}`,
+			setup: func(svc *MockDiskService) {
+				svc.On("Update", mock.Anything, service.UpdateDiskInput{
+					ProjectID:  projectID,
+					DiskID:     diskID,
+					DiskFields: service.DiskFields{MaxFiles: &maxFiles},
+				}).Return(&model.Disk{ID: diskID, MaxFiles: &maxFiles}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "usage",
+			method: "GET",
+			url:    "/disk/" + diskID.String() + "/usage",
+			setup: func(svc *MockDiskService) {
+				svc.On("GetUsage", mock.Anything, projectID, diskID).Return(&model.DiskUsage{Files: 2, Bytes: 30, MaxFiles: &maxFiles}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &MockDiskService{}
+			tt.setup(mockService)
+			handler := NewDiskHandler(mockService)
+
+			router := setupDiskRouter()
+			router.Use(func(c *gin.Context) { c.Set("project", &model.Project{ID: projectID}) })
+			router.GET("/disk/by_name", handler.GetDiskByName)
+			router.GET("/disk/:disk_id", handler.GetDisk)
+			router.PUT("/disk/:disk_id", handler.UpdateDisk)
+			router.GET("/disk/:disk_id/usage", handler.GetDiskUsage)
+
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.name == "usage" {
+				var response map[string]interface{}
+				assert.NoError(t, sonic.Unmarshal(w.Body.Bytes(), &response))
+				data := response["data"].(map[string]interface{})
+				assert.Equal(t, float64(2), data["files"])
+				assert.Equal(t, float64(30), data["bytes"])
+				assert.Equal(t, float64(10), data["max_files"])
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,446 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+)
+
+// ProjectHandler serves the admin API, which is authenticated by the root token
+type ProjectHandler struct {
+	svc service.ProjectService
+}
+
+func NewProjectHandler(s service.ProjectService) *ProjectHandler {
+	return &ProjectHandler{svc: s}
+}
+
+type CreateKeyReq struct {
+	Name      string   `json:"name" binding:"required" example:"ci"`
+	Scopes    []string `json:"scopes" example:"read_only,disks"`             // read_only, sessions and disks; none gives full access
+	ExpiresIn int64    `json:"expires_in" binding:"min=0" example:"2592000"` // Seconds until the key expires, 0 never expires
+}
+
+func (r CreateKeyReq) input(projectID uuid.UUID) service.CreateKeyInput {
+	scopes := make([]model.APIKeyScope, len(r.Scopes))
+	for i, scope := range r.Scopes {
+		scopes[i] = model.APIKeyScope(scope)
+	}
+	return service.CreateKeyInput{
+		ProjectID: projectID,
+		Name:      r.Name,
+		Scopes:    scopes,
+		ExpiresIn: time.Duration(r.ExpiresIn) * time.Second,
+	}
+}
+
+type CreateProjectReq struct {
+	Configs map[string]interface{} `json:"configs"`
+	// Key is the first API key of the project, a key named "default" with full access when omitted
+	Key *CreateKeyReq `json:"key"`
+}
+
+type ListProjectsReq struct {
+	Limit    int    `form:"limit,default=20" json:"limit" binding:"required,min=1,max=200" example:"20"`
+	Cursor   string `form:"cursor" json:"cursor" example:"cHJvdGVjdGVkIHZlcnNpb24gdG8gYmUgZXhjbHVkZWQgaW4gcGFyc2luZyB0aGUgY3Vyc29y"`
+	TimeDesc bool   `form:"time_desc,default=false" json:"time_desc" example:"false"`
+}
+
+type UpdateProjectConfigsReq struct {
+	Configs map[string]interface{} `form:"configs" json:"configs" binding:"required"`
+}
+
+type RotateKeyReq struct {
+	// Seconds the rotated key keeps working, defaults to 86400. 0 ends it at once.
+	OverlapSec *int64 `json:"overlap_sec" binding:"omitempty,min=0" example:"86400"`
+}
+
+// projectKeyErr answers the errors of the project service
+func projectKeyErr(c *gin.Context, err error) {
+	if invalidConfigs(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidAPIKey):
+		serializer.ParamErr(c, "", err)
+	case errors.Is(err, service.ErrProjectNotFound):
+		serializer.Err(c, apierr.ProjectNotFound, "project not found", err)
+	case errors.Is(err, service.ErrAPIKeyNotFound):
+		serializer.Err(c, apierr.APIKeyNotFound, "api key not found", err)
+	case errors.Is(err, service.ErrAPIKeyUnavailable):
+		serializer.Err(c, apierr.APIKeyUnavailable, err.Error(), err)
+	case errors.Is(err, service.ErrDefaultProject), errors.Is(err, service.ErrDefaultProjectSecret):
+		serializer.Err(c, apierr.DefaultProject, err.Error(), err)
+	case errors.Is(err, service.ErrProjectDeleting):
+		serializer.Err(c, apierr.ProjectDeleting, err.Error(), err)
+	default:
+		serializer.DBErr(c, "", err)
+	}
+}
+
+// CreateProject godoc
+//
+//	@Summary		Create project
+//	@Description	Create a project and its first API key. The key is only returned once.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	handler.CreateProjectReq	false	"CreateProject payload"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=service.CreateProjectOutput}
+//	@Failure		422	{object}	serializer.Problem{problems=[]configs.Problem}
+//	@Router			/admin/project [post]
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	req := CreateProjectReq{}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			serializer.ParamErr(c, "", err)
+			return
+		}
+	}
+	if req.Key == nil {
+		req.Key = &CreateKeyReq{Name: "default"}
+	}
+
+	out, err := h.svc.CreateProject(c.Request.Context(), service.CreateProjectInput{
+		Configs: req.Configs,
+		Key:     req.Key.input(uuid.Nil),
+	})
+	if err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+// ListProjects godoc
+//
+//	@Summary		List projects
+//	@Description	List all projects with their current storage and usage against their limits, including projects being deleted
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit		query	integer	false	"Limit of projects to return, default 20. Max 200."
+//	@Param			cursor		query	string	false	"Cursor for pagination. Use the cursor from the previous response to get the next page."
+//	@Param			time_desc	query	boolean	false	"Order by created_at descending if true, ascending if false (default false)"	example(false)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ListProjectsOutput}
+//	@Router			/admin/project [get]
+func (h *ProjectHandler) ListProjects(c *gin.Context) {
+	req := ListProjectsReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	out, err := h.svc.ListProjects(c.Request.Context(), service.ListProjectsInput{
+		Limit:    req.Limit,
+		Cursor:   req.Cursor,
+		TimeDesc: req.TimeDesc,
+	})
+	if err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+// GetProject godoc
+//
+//	@Summary		Get project
+//	@Description	Get a project with its current storage and usage against its limits
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			project_id	path	string	true	"Project ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ProjectSummary}
+//	@Router			/admin/project/{project_id} [get]
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	out, err := h.svc.GetProject(c.Request.Context(), projectID)
+	if err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+// UpdateConfigs godoc
+//
+//	@Summary		Update project configs
+//	@Description	Replace the configs of a project, such as its usage_limits and rate_limits
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			project_id	path	string							true	"Project ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload		body	handler.UpdateProjectConfigsReq	true	"UpdateConfigs payload"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.Project}
+//	@Failure		409	{object}	serializer.Problem	"The project is being deleted"
+//	@Failure		422	{object}	serializer.Problem{problems=[]configs.Problem}
+//	@Router			/admin/project/{project_id}/configs [put]
+func (h *ProjectHandler) UpdateConfigs(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	req := UpdateProjectConfigsReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	project, err := h.svc.UpdateConfigs(c.Request.Context(), projectID, req.Configs)
+	if err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: project})
+}
+
+// DeleteProject godoc
+//
+//	@Summary		Delete project
+//	@Description	Delete a project. It stops authenticating at once; the project, everything it owns and its stored objects are removed in the background.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			project_id	path	string	true	"Project ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		202	{object}	serializer.Response{data=model.Project}
+//	@Failure		409	{object}	serializer.Problem	"The default project cannot be deleted"
+//	@Router			/admin/project/{project_id} [delete]
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	project, err := h.svc.DeleteProject(c.Request.Context(), projectID)
+	if err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, serializer.Response{Data: project})
+}
+
+// ListKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	List the API keys of a project, including expired and revoked ones
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			project_id	path	string	true	"Project ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=[]model.ProjectAPIKey}
+//	@Router			/admin/project/{project_id}/key [get]
+func (h *ProjectHandler) ListKeys(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	keys, err := h.svc.ListKeys(c.Request.Context(), projectID)
+	if err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: keys})
+}
+
+// CreateKey godoc
+//
+//	@Summary		Create API key
+//	@Description	Create an API key for a project. The key is only returned once.
+//	@Description	read_only keys can only make GET requests; sessions and disks keys can only reach the session and disk routes.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			project_id	path	string				true	"Project ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload		body	handler.CreateKeyReq	true	"CreateKey payload"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=service.CreateKeyOutput}
+//	@Router			/admin/project/{project_id}/key [post]
+func (h *ProjectHandler) CreateKey(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	req := CreateKeyReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	out, err := h.svc.CreateKey(c.Request.Context(), req.input(projectID))
+	if err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+// RotateKey godoc
+//
+//	@Summary		Rotate API key
+//	@Description	Replace an API key with a new one of the same name and scopes. The previous key keeps working for the overlap, so clients can switch over without downtime. The new key is only returned once.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			project_id	path	string				true	"Project ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			key_id		path	string				true	"API key ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload		body	handler.RotateKeyReq	false	"RotateKey payload"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=service.RotateKeyOutput}
+//	@Failure		409	{object}	serializer.Problem	"The key has expired, was revoked or was already rotated"
+//	@Router			/admin/project/{project_id}/key/{key_id}/rotate [post]
+func (h *ProjectHandler) RotateKey(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+	keyID, err := uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	req := RotateKeyReq{}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			serializer.ParamErr(c, "", err)
+			return
+		}
+	}
+	overlap := service.DefaultKeyRotationOverlap
+	if req.OverlapSec != nil {
+		overlap = time.Duration(*req.OverlapSec) * time.Second
+	}
+
+	out, err := h.svc.RotateKey(c.Request.Context(), service.RotateKeyInput{
+		ProjectID: projectID,
+		KeyID:     keyID,
+		Overlap:   overlap,
+	})
+	if err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+// RevokeKey godoc
+//
+//	@Summary		Revoke API key
+//	@Description	Stop an API key from working immediately
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			project_id	path	string	true	"Project ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			key_id		path	string	true	"API key ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{}
+//	@Router			/admin/project/{project_id}/key/{key_id} [delete]
+func (h *ProjectHandler) RevokeKey(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+	keyID, err := uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	if err := h.svc.RevokeKey(c.Request.Context(), projectID, keyID); err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{})
+}
+
+// RotateSecret godoc
+//
+//	@Summary		Rotate project secret
+//	@Description	Replace the built-in secret of a project, which has full access, with a new one. The previous secret stops working immediately. The new secret is only returned once.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			project_id	path	string	true	"Project ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=service.RotateSecretOutput}
+//	@Failure		409	{object}	serializer.Problem	"The secret of the default project is the root token"
+//	@Router			/admin/project/{project_id}/secret/rotate [post]
+func (h *ProjectHandler) RotateSecret(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	out, err := h.svc.RotateSecret(c.Request.Context(), projectID)
+	if err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+// RevokeSecret godoc
+//
+//	@Summary		Revoke project secret
+//	@Description	Stop the built-in secret of a project from working immediately. The project is then only reached through its API keys, until the secret is rotated.
+//	@Tags			admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			project_id	path	string	true	"Project ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{}
+//	@Failure		409	{object}	serializer.Problem	"The secret of the default project is the root token"
+//	@Router			/admin/project/{project_id}/secret [delete]
+func (h *ProjectHandler) RevokeSecret(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	if err := h.svc.RevokeSecret(c.Request.Context(), projectID); err != nil {
+		projectKeyErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{})
+}
@@ -0,0 +1,367 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockProjectService is a mock implementation of ProjectService
+type MockProjectService struct {
+	mock.Mock
+}
+
+func (m *MockProjectService) CreateProject(ctx context.Context, in service.CreateProjectInput) (*service.CreateProjectOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CreateProjectOutput), args.Error(1)
+}
+
+func (m *MockProjectService) ListKeys(ctx context.Context, projectID uuid.UUID) ([]*model.ProjectAPIKey, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.ProjectAPIKey), args.Error(1)
+}
+
+func (m *MockProjectService) CreateKey(ctx context.Context, in service.CreateKeyInput) (*service.CreateKeyOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CreateKeyOutput), args.Error(1)
+}
+
+func (m *MockProjectService) RotateKey(ctx context.Context, in service.RotateKeyInput) (*service.RotateKeyOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.RotateKeyOutput), args.Error(1)
+}
+
+func (m *MockProjectService) RevokeKey(ctx context.Context, projectID uuid.UUID, keyID uuid.UUID) error {
+	args := m.Called(ctx, projectID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockProjectService) RotateSecret(ctx context.Context, projectID uuid.UUID) (*service.RotateSecretOutput, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.RotateSecretOutput), args.Error(1)
+}
+
+func (m *MockProjectService) RevokeSecret(ctx context.Context, projectID uuid.UUID) error {
+	args := m.Called(ctx, projectID)
+	return args.Error(0)
+}
+
+func (m *MockProjectService) ListProjects(ctx context.Context, in service.ListProjectsInput) (*service.ListProjectsOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ListProjectsOutput), args.Error(1)
+}
+
+func (m *MockProjectService) GetProject(ctx context.Context, projectID uuid.UUID) (*service.ProjectSummary, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ProjectSummary), args.Error(1)
+}
+
+func (m *MockProjectService) UpdateConfigs(ctx context.Context, projectID uuid.UUID, configs map[string]interface{}) (*model.Project, error) {
+	args := m.Called(ctx, projectID, configs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Project), args.Error(1)
+}
+
+func (m *MockProjectService) DeleteProject(ctx context.Context, projectID uuid.UUID) (*model.Project, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Project), args.Error(1)
+}
+
+func (m *MockProjectService) PurgeDeleted(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func TestProjectHandler(t *testing.T) {
+	projectID, keyID := uuid.New(), uuid.New()
+	projectPath := "/admin/project/" + projectID.String()
+	keyPath := projectPath + "/key"
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setup          func(*MockProjectService)
+		expectedStatus int
+	}{
+		{
+			name:   "create project with a default key",
+			method: http.MethodPost,
+			path:   "/admin/project",
+			setup: func(svc *MockProjectService) {
+				svc.On("CreateProject", mock.Anything, service.CreateProjectInput{
+					Key: service.CreateKeyInput{Name: "default", Scopes: []model.APIKeyScope{}},
+				}).Return(&service.CreateProjectOutput{}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "create scoped key",
+			method: http.MethodPost,
+			path:   keyPath,
+			body:   `{"name":"ci","scopes":["read_only","disks"],"expires_in":60}`,
+			setup: func(svc *MockProjectService) {
+				svc.On("CreateKey", mock.Anything, service.CreateKeyInput{
+					ProjectID: projectID,
+					Name:      "ci",
+					Scopes:    []model.APIKeyScope{model.APIKeyScopeReadOnly, model.APIKeyScopeDisks},
+					ExpiresIn: time.Minute,
+				}).Return(&service.CreateKeyOutput{}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "create key without name",
+			method:         http.MethodPost,
+			path:           keyPath,
+			body:           `{"scopes":["disks"]}`,
+			setup:          func(svc *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "create key with unknown scope",
+			method: http.MethodPost,
+			path:   keyPath,
+			body:   `{"name":"ci","scopes":["admin"]}`,
+			setup: func(svc *MockProjectService) {
+				svc.On("CreateKey", mock.Anything, mock.Anything).Return(nil, service.ErrInvalidAPIKey)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "create key for unknown project",
+			method: http.MethodPost,
+			path:   keyPath,
+			body:   `{"name":"ci"}`,
+			setup: func(svc *MockProjectService) {
+				svc.On("CreateKey", mock.Anything, mock.Anything).Return(nil, service.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "list keys",
+			method: http.MethodGet,
+			path:   keyPath,
+			setup: func(svc *MockProjectService) {
+				svc.On("ListKeys", mock.Anything, projectID).Return([]*model.ProjectAPIKey{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "rotate with the default overlap",
+			method: http.MethodPost,
+			path:   keyPath + "/" + keyID.String() + "/rotate",
+			setup: func(svc *MockProjectService) {
+				svc.On("RotateKey", mock.Anything, service.RotateKeyInput{ProjectID: projectID, KeyID: keyID, Overlap: service.DefaultKeyRotationOverlap}).
+					Return(&service.RotateKeyOutput{}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "rotate without overlap",
+			method: http.MethodPost,
+			path:   keyPath + "/" + keyID.String() + "/rotate",
+			body:   `{"overlap_sec":0}`,
+			setup: func(svc *MockProjectService) {
+				svc.On("RotateKey", mock.Anything, service.RotateKeyInput{ProjectID: projectID, KeyID: keyID}).
+					Return(&service.RotateKeyOutput{}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "rotate revoked key",
+			method: http.MethodPost,
+			path:   keyPath + "/" + keyID.String() + "/rotate",
+			setup: func(svc *MockProjectService) {
+				svc.On("RotateKey", mock.Anything, mock.Anything).Return(nil, service.ErrAPIKeyUnavailable)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "revoke unknown key",
+			method: http.MethodDelete,
+			path:   keyPath + "/" + keyID.String(),
+			setup: func(svc *MockProjectService) {
+				svc.On("RevokeKey", mock.Anything, projectID, keyID).Return(service.ErrAPIKeyNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "rotate project secret",
+			method: http.MethodPost,
+			path:   projectPath + "/secret/rotate",
+			setup: func(svc *MockProjectService) {
+				svc.On("RotateSecret", mock.Anything, projectID).Return(&service.RotateSecretOutput{}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "revoke secret of the default project",
+			method: http.MethodDelete,
+			path:   projectPath + "/secret",
+			setup: func(svc *MockProjectService) {
+				svc.On("RevokeSecret", mock.Anything, projectID).Return(service.ErrDefaultProjectSecret)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "list projects",
+			method: http.MethodGet,
+			path:   "/admin/project?limit=5&time_desc=true",
+			setup: func(svc *MockProjectService) {
+				svc.On("ListProjects", mock.Anything, service.ListProjectsInput{Limit: 5, TimeDesc: true}).
+					Return(&service.ListProjectsOutput{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "list projects over the limit",
+			method:         http.MethodGet,
+			path:           "/admin/project?limit=500",
+			setup:          func(svc *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "get unknown project",
+			method: http.MethodGet,
+			path:   projectPath,
+			setup: func(svc *MockProjectService) {
+				svc.On("GetProject", mock.Anything, projectID).Return(nil, service.ErrProjectNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "update configs",
+			method: http.MethodPut,
+			path:   projectPath + "/configs",
+			body:   `{"configs":{"usage_limits":{"storage_bytes":{"hard":1024}}}}`,
+			setup: func(svc *MockProjectService) {
+				svc.On("UpdateConfigs", mock.Anything, projectID, map[string]interface{}{
+					"usage_limits": map[string]interface{}{"storage_bytes": map[string]interface{}{"hard": float64(1024)}},
+				}).Return(&model.Project{ID: projectID}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "update configs with an invalid limit",
+			method: http.MethodPut,
+			path:   projectPath + "/configs",
+			body:   `{"configs":{"usage_limits":{"storage_bytes":{"hard":-1}}}}`,
+			setup: func(svc *MockProjectService) {
+				svc.On("UpdateConfigs", mock.Anything, projectID, mock.Anything).Return(nil, &configs.ValidationError{
+					Problems: []configs.Problem{{Key: "usage_limits", Message: "at /storage_bytes/hard: minimum: got -1, want 0"}},
+				})
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "update configs without configs",
+			method:         http.MethodPut,
+			path:           projectPath + "/configs",
+			body:           `{}`,
+			setup:          func(svc *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "update configs of a project being deleted",
+			method: http.MethodPut,
+			path:   projectPath + "/configs",
+			body:   `{"configs":{}}`,
+			setup: func(svc *MockProjectService) {
+				svc.On("UpdateConfigs", mock.Anything, projectID, map[string]interface{}{}).Return(nil, service.ErrProjectDeleting)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "delete project",
+			method: http.MethodDelete,
+			path:   projectPath,
+			setup: func(svc *MockProjectService) {
+				svc.On("DeleteProject", mock.Anything, projectID).Return(&model.Project{ID: projectID}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:   "delete default project",
+			method: http.MethodDelete,
+			path:   projectPath,
+			setup: func(svc *MockProjectService) {
+				svc.On("DeleteProject", mock.Anything, projectID).Return(nil, service.ErrDefaultProject)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "invalid key id",
+			method:         http.MethodDelete,
+			path:           keyPath + "/not-a-uuid",
+			setup:          func(svc *MockProjectService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockProjectService{}
+			tt.setup(svc)
+			h := NewProjectHandler(svc)
+
+			router := setupDiskRouter()
+			router.GET("/admin/project", h.ListProjects)
+			router.POST("/admin/project", h.CreateProject)
+			router.GET("/admin/project/:project_id", h.GetProject)
+			router.PUT("/admin/project/:project_id/configs", h.UpdateConfigs)
+			router.DELETE("/admin/project/:project_id", h.DeleteProject)
+			router.GET("/admin/project/:project_id/key", h.ListKeys)
+			router.POST("/admin/project/:project_id/key", h.CreateKey)
+			router.POST("/admin/project/:project_id/key/:key_id/rotate", h.RotateKey)
+			router.DELETE("/admin/project/:project_id/key/:key_id", h.RevokeKey)
+			router.POST("/admin/project/:project_id/secret/rotate", h.RotateSecret)
+			router.DELETE("/admin/project/:project_id/secret", h.RevokeSecret)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -15,11 +15,13 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
 	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
 	"github.com/memodb-io/Acontext/internal/pkg/converter"
 	"github.com/memodb-io/Acontext/internal/pkg/editor"
 	"github.com/memodb-io/Acontext/internal/pkg/normalizer"
 	"github.com/memodb-io/Acontext/internal/pkg/tokenizer"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type SessionHandler struct {
@@ -34,6 +36,18 @@ func NewSessionHandler(s service.SessionService, coreClient *httpclient.CoreClie
 	}
 }
 
+// sessionErr answers a failed session operation
+func sessionErr(c *gin.Context, err error) {
+	if invalidConfigs(c, err) {
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		serializer.Err(c, apierr.SessionNotFound, "session not found", err)
+		return
+	}
+	serializer.DBErr(c, "", err)
+}
+
 type CreateSessionReq struct {
 	SpaceID             string                 `form:"space_id" json:"space_id" format:"uuid" example:"123e4567-e89b-12d3-a456-42661417"`
 	DisableTaskTracking *bool                  `form:"disable_task_tracking" json:"disable_task_tracking" example:"false"`
@@ -67,13 +81,13 @@ type GetSessionsReq struct {
 func (h *SessionHandler) GetSessions(c *gin.Context) {
 	req := GetSessionsReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
@@ -82,7 +96,7 @@ func (h *SessionHandler) GetSessions(c *gin.Context) {
 	if req.SpaceID != "" {
 		parsed, err := uuid.Parse(req.SpaceID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid space_id", err))
+			serializer.ParamErr(c, "invalid space_id", err)
 			return
 		}
 		spaceID = &parsed
@@ -97,7 +111,7 @@ func (h *SessionHandler) GetSessions(c *gin.Context) {
 		TimeDesc:     req.TimeDesc,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -114,18 +128,19 @@ func (h *SessionHandler) GetSessions(c *gin.Context) {
 //	@Param			payload	body	handler.CreateSessionReq	true	"CreateSession payload"
 //	@Security		BearerAuth
 //	@Success		201	{object}	serializer.Response{data=model.Session}
+//	@Failure		422	{object}	serializer.Problem{problems=[]configs.Problem}
 //	@Router			/session [post]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Create a session\nsession = client.sessions.create(\n    space_id='space-uuid',\n    configs={\"mode\": \"chat\"}\n)\nprint(f\"Created session: {session.id}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Create a session\nconst session = await client.sessions.create({\n  spaceId: 'space-uuid',\n  configs: { mode: 'chat' }\n});\nconsole.log(`Created session: ${session.id}`);\n","label":"JavaScript"}]
 func (h *SessionHandler) CreateSession(c *gin.Context) {
 	req := CreateSessionReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
@@ -137,7 +152,7 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 	if len(req.SpaceID) != 0 {
 		spaceID, err := uuid.Parse(req.SpaceID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+			serializer.ParamErr(c, "", err)
 			return
 		}
 		session.SpaceID = &spaceID
@@ -146,7 +161,14 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 		session.DisableTaskTracking = *req.DisableTaskTracking
 	}
 	if err := h.svc.Create(c.Request.Context(), &session); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		if invalidConfigs(c, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			serializer.Err(c, apierr.SpaceNotFound, "space not found", err)
+			return
+		}
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -156,7 +178,7 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 // DeleteSession godoc
 //
 //	@Summary		Delete session
-//	@Description	Delete a session by id
+//	@Description	Move a session to the trash. It can be restored with POST /session/{session_id}/restore for trash.retentionDays days, after which it is purged with its messages.
 //	@Tags			session
 //	@Accept			json
 //	@Produce		json
@@ -168,18 +190,18 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 func (h *SessionHandler) DeleteSession(c *gin.Context) {
 	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	if err := h.svc.Delete(c.Request.Context(), project.ID, sessionID); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		sessionErr(c, err)
 		return
 	}
 
@@ -201,25 +223,34 @@ type UpdateSessionConfigsReq struct {
 //	@Param			payload		body	handler.UpdateSessionConfigsReq	true	"UpdateSessionConfigs payload"
 //	@Security		BearerAuth
 //	@Success		200	{object}	serializer.Response{}
+//	@Failure		422	{object}	serializer.Problem{problems=[]configs.Problem}
 //	@Router			/session/{session_id}/configs [put]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Update session configs\nclient.sessions.update_configs(\n    session_id='session-uuid',\n    configs={\"mode\": \"updated-mode\"}\n)\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Update session configs\nawait client.sessions.updateConfigs('session-uuid', {\n  configs: { mode: 'updated-mode' }\n});\n","label":"JavaScript"}]
 func (h *SessionHandler) UpdateConfigs(c *gin.Context) {
 	req := UpdateSessionConfigsReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
+
 	if err := h.svc.UpdateByID(c.Request.Context(), &model.Session{
-		ID:      sessionID,
-		Configs: datatypes.JSONMap(req.Configs),
+		ID:        sessionID,
+		ProjectID: project.ID,
+		Configs:   datatypes.JSONMap(req.Configs),
 	}); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		sessionErr(c, err)
 		return
 	}
 
@@ -241,12 +272,19 @@ func (h *SessionHandler) UpdateConfigs(c *gin.Context) {
 func (h *SessionHandler) GetConfigs(c *gin.Context) {
 	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
-	session, err := h.svc.GetByID(c.Request.Context(), &model.Session{ID: sessionID})
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	session, err := h.svc.GetByID(c.Request.Context(), &model.Session{ID: sessionID, ProjectID: project.ID})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		sessionErr(c, err)
 		return
 	}
 
@@ -273,26 +311,38 @@ type ConnectToSpaceReq struct {
 func (h *SessionHandler) ConnectToSpace(c *gin.Context) {
 	req := ConnectToSpaceReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 	spaceID, err := uuid.Parse(req.SpaceID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
+	// Both the session and the space must be in the project
 	if err := h.svc.UpdateByID(c.Request.Context(), &model.Session{
-		ID:      sessionID,
-		SpaceID: &spaceID,
+		ID:        sessionID,
+		ProjectID: project.ID,
+		SpaceID:   &spaceID,
 	}); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			serializer.Err(c, apierr.SpaceNotFound, "session or space not found", err)
+			return
+		}
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -331,13 +381,13 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 	if strings.HasPrefix(ct, "multipart/form-data") {
 		if p := c.PostForm("payload"); p != "" {
 			if err := sonic.Unmarshal([]byte(p), &req); err != nil {
-				c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid payload json", err))
+				serializer.ParamErr(c, "invalid payload json", err)
 				return
 			}
 		}
 	} else {
 		if err := c.ShouldBind(&req); err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+			serializer.ParamErr(c, "", err)
 			return
 		}
 	}
@@ -350,7 +400,7 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 
 	format, err := converter.ValidateFormat(formatStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid format", err))
+		serializer.ParamErr(c, "invalid format", err)
 		return
 	}
 
@@ -363,7 +413,7 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 
 	blobJSON, err := sonic.Marshal(req.Blob)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid blob", err))
+		serializer.ParamErr(c, "invalid blob", err)
 		return
 	}
 
@@ -373,7 +423,7 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 		norm := &normalizer.AcontextNormalizer{}
 		normalizedRole, normalizedParts, normalizedMeta, err = norm.NormalizeFromAcontextMessage(blobJSON)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("failed to normalize Acontext message", err))
+			serializer.ParamErr(c, "failed to normalize Acontext message", err)
 			return
 		}
 
@@ -389,7 +439,7 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 		norm := &normalizer.OpenAINormalizer{}
 		normalizedRole, normalizedParts, normalizedMeta, err = norm.NormalizeFromOpenAIMessage(blobJSON)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("failed to normalize OpenAI message", err))
+			serializer.ParamErr(c, "failed to normalize OpenAI message", err)
 			return
 		}
 
@@ -405,7 +455,7 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 		norm := &normalizer.AnthropicNormalizer{}
 		normalizedRole, normalizedParts, normalizedMeta, err = norm.NormalizeFromAnthropicMessage(blobJSON)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("failed to normalize Anthropic message", err))
+			serializer.ParamErr(c, "failed to normalize Anthropic message", err)
 			return
 		}
 
@@ -417,13 +467,13 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 		}
 
 	default:
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("unsupported format", fmt.Errorf("format %s is not supported", format)))
+		serializer.ParamErr(c, "unsupported format", fmt.Errorf("format %s is not supported", format))
 		return
 	}
 
 	// Validate that we have at least one part
 	if len(normalizedParts) == 0 {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("message must contain at least one part")))
+		serializer.ParamErr(c, "", errors.New("message must contain at least one part"))
 		return
 	}
 
@@ -433,7 +483,7 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 		for _, fileField := range fileFields {
 			fh, err := c.FormFile(fileField)
 			if err != nil {
-				c.JSON(http.StatusBadRequest, serializer.ParamErr(fmt.Sprintf("missing file %s", fileField), err))
+				serializer.ParamErr(c, fmt.Sprintf("missing file %s", fileField), err)
 				return
 			}
 			fileMap[fileField] = fh
@@ -442,13 +492,13 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
@@ -461,7 +511,10 @@ func (h *SessionHandler) StoreMessage(c *gin.Context) {
 		Files:       fileMap,
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.DBErr("", err))
+		if usageLimitErr(c, err) {
+			return
+		}
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -498,13 +551,13 @@ type GetMessagesReq struct {
 func (h *SessionHandler) GetMessages(c *gin.Context) {
 	req := GetMessagesReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
@@ -518,7 +571,7 @@ func (h *SessionHandler) GetMessages(c *gin.Context) {
 	var editStrategies []editor.StrategyConfig
 	if req.EditStrategies != "" {
 		if err := sonic.Unmarshal([]byte(req.EditStrategies), &editStrategies); err != nil {
-			c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid edit_strategies JSON", err))
+			serializer.ParamErr(c, "invalid edit_strategies JSON", err)
 			return
 		}
 	}
@@ -533,7 +586,11 @@ func (h *SessionHandler) GetMessages(c *gin.Context) {
 		EditStrategies:     editStrategies,
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.DBErr("", err))
+		if errors.Is(err, editor.ErrInvalidStrategy) {
+			serializer.Err(c, apierr.InvalidEditStrategy, err.Error(), err)
+			return
+		}
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -545,7 +602,7 @@ func (h *SessionHandler) GetMessages(c *gin.Context) {
 
 	format, err := converter.ValidateFormat(formatStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("invalid format", err))
+		serializer.ParamErr(c, "invalid format", err)
 		return
 	}
 
@@ -557,7 +614,7 @@ func (h *SessionHandler) GetMessages(c *gin.Context) {
 		out.HasMore,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("failed to convert messages", err))
+		serializer.DBErr(c, "failed to convert messages", err)
 		return
 	}
 
@@ -579,19 +636,19 @@ func (h *SessionHandler) GetMessages(c *gin.Context) {
 func (h *SessionHandler) SessionFlush(c *gin.Context) {
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	result, err := h.coreClient.SessionFlush(c.Request.Context(), project.ID, sessionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "failed to flush session", err))
+		serializer.Err(c, apierr.Internal, "failed to flush session", err)
 		return
 	}
 
@@ -613,19 +670,19 @@ func (h *SessionHandler) SessionFlush(c *gin.Context) {
 func (h *SessionHandler) GetLearningStatus(c *gin.Context) {
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	result, err := h.coreClient.GetLearningStatus(c.Request.Context(), project.ID, sessionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "failed to get learning status", err))
+		serializer.Err(c, apierr.Internal, "failed to get learning status", err)
 		return
 	}
 
@@ -651,21 +708,21 @@ type TokenCountsResp struct {
 func (h *SessionHandler) GetTokenCounts(c *gin.Context) {
 	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	// Get all messages for the session
 	messages, err := h.svc.GetAllMessages(c.Request.Context(), sessionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("failed to get messages", err))
+		serializer.DBErr(c, "failed to get messages", err)
 		return
 	}
 
 	// Count tokens for all text and tool-call parts
 	totalTokens, err := tokenizer.CountMessagePartsTokens(c.Request.Context(), messages)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "failed to count tokens", err))
+		serializer.Err(c, apierr.Internal, "failed to count tokens", err)
 		return
 	}
 
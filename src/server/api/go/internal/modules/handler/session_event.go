@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+)
+
+// sessionEventRetryMs is how long clients wait before reconnecting a dropped stream
+const sessionEventRetryMs = 3000
+
+type SessionEventHandler struct {
+	svc       service.SessionEventService
+	keepalive time.Duration
+
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewSessionEventHandler(s service.SessionEventService, cfg *config.Config) *SessionEventHandler {
+	return &SessionEventHandler{
+		svc:       s,
+		keepalive: time.Duration(cfg.Events.KeepaliveSec) * time.Second,
+		closing:   make(chan struct{}),
+	}
+}
+
+// Close ends the open streams, whose clients reconnect with Last-Event-ID. It is called when the
+// server shuts down, which would otherwise wait for the streams to end.
+func (h *SessionEventHandler) Close() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+// StreamSessionEvents godoc
+//
+//	@Summary		Stream session events
+//	@Description	Stream the activity of a session as Server-Sent Events: stored messages (message.created), message processing status (message.status), tasks (task.created, task.updated) and learning progress (learning.progress). The id of every event is its sequence number; reconnect with the Last-Event-ID header, or the last_event_id query parameter, to resume after it. Without one the stream starts with the next event.
+//	@Tags			session
+//	@Produce		text/event-stream
+//	@Param			session_id		path	string	true	"Session ID"	format(uuid)
+//	@Param			Last-Event-ID	header	integer	false	"Sequence number of the last event received"
+//	@Param			last_event_id	query	integer	false	"Sequence number of the last event received, for clients that cannot set headers"
+//	@Security		BearerAuth
+//	@Success		200	{object}	model.SessionEvent
+//	@Router			/session/{session_id}/events [get]
+func (h *SessionEventHandler) StreamSessionEvents(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	var lastSeq *int64
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	if lastEventID != "" {
+		seq, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || seq < 0 {
+			serializer.ParamErr(c, "invalid Last-Event-ID", err)
+			return
+		}
+		lastSeq = &seq
+	}
+
+	events, err := h.svc.Watch(c.Request.Context(), sessionID, lastSeq)
+	if err != nil {
+		serializer.Err(c, apierr.Internal, "failed to watch session events", err)
+		return
+	}
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Keep proxies from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sessionEventRetryMs)
+	w.Flush()
+
+	keepalive := time.NewTicker(h.keepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-h.closing:
+			return
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeSessionEvent(w, e); err != nil {
+				return
+			}
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		}
+		w.Flush()
+	}
+}
+
+func writeSessionEvent(w gin.ResponseWriter, e *model.SessionEvent) error {
+	data, err := sonic.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", *e.Seq, e.Type, data)
+	return err
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/datatypes"
+)
+
+// MockSessionEventService is a mock implementation of SessionEventService
+type MockSessionEventService struct {
+	mock.Mock
+}
+
+func (m *MockSessionEventService) Relay(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSessionEventService) Watch(ctx context.Context, sessionID uuid.UUID, lastSeq *int64) (<-chan *model.SessionEvent, error) {
+	args := m.Called(ctx, sessionID, lastSeq)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(<-chan *model.SessionEvent), args.Error(1)
+}
+
+func (m *MockSessionEventService) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	args := m.Called(ctx, retention)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func sessionEvents(events ...*model.SessionEvent) <-chan *model.SessionEvent {
+	ch := make(chan *model.SessionEvent, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	return ch
+}
+
+func seqPtr(seq int64) *int64 { return &seq }
+
+func TestSessionEventHandler_StreamSessionEvents(t *testing.T) {
+	sessionID := uuid.New()
+	taskID := uuid.New()
+	createdAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	event := &model.SessionEvent{
+		Seq:       seqPtr(13),
+		SessionID: sessionID,
+		Type:      model.SessionEventTaskUpdated,
+		Data:      datatypes.JSONMap{"id": taskID.String(), "status": "success"},
+		CreatedAt: createdAt,
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		lastEventID    string
+		setup          func(*MockSessionEventService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "starts with the next event",
+			setup: func(svc *MockSessionEventService) {
+				svc.On("Watch", mock.Anything, sessionID, (*int64)(nil)).Return(sessionEvents(event), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: "retry: 3000\n\n" +
+				"id: 13\nevent: task.updated\ndata: {\"seq\":13,\"session_id\":\"" + sessionID.String() + "\",\"type\":\"task.updated\"," +
+				"\"data\":{\"id\":\"" + taskID.String() + "\",\"status\":\"success\"},\"created_at\":\"2025-03-01T00:00:00Z\"}\n\n",
+		},
+		{
+			name:        "resumes after Last-Event-ID",
+			lastEventID: "12",
+			query:       "?last_event_id=3",
+			setup: func(svc *MockSessionEventService) {
+				svc.On("Watch", mock.Anything, sessionID, seqPtr(12)).Return(sessionEvents(), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "retry: 3000\n\n",
+		},
+		{
+			name:  "resumes after last_event_id",
+			query: "?last_event_id=3",
+			setup: func(svc *MockSessionEventService) {
+				svc.On("Watch", mock.Anything, sessionID, seqPtr(3)).Return(sessionEvents(), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "retry: 3000\n\n",
+		},
+		{
+			name:           "invalid Last-Event-ID",
+			lastEventID:    "abc",
+			setup:          func(svc *MockSessionEventService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "watch fails",
+			setup: func(svc *MockSessionEventService) {
+				svc.On("Watch", mock.Anything, sessionID, (*int64)(nil)).Return(nil, errors.New("redis down"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockSessionEventService{}
+			tt.setup(svc)
+			h := NewSessionEventHandler(svc, &config.Config{Events: config.SessionEventsCfg{KeepaliveSec: 15}})
+
+			router := setupDiskRouter()
+			router.GET("/session/:session_id/events", h.StreamSessionEvents)
+
+			req := httptest.NewRequest(http.MethodGet, "/session/"+sessionID.String()+"/events"+tt.query, nil)
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSessionEventHandler_Close(t *testing.T) {
+	sessionID := uuid.New()
+	svc := &MockSessionEventService{}
+	// A stream with no events ends only when the handler is closed
+	svc.On("Watch", mock.Anything, sessionID, (*int64)(nil)).Return((<-chan *model.SessionEvent)(make(chan *model.SessionEvent)), nil)
+	h := NewSessionEventHandler(svc, &config.Config{Events: config.SessionEventsCfg{KeepaliveSec: 15}})
+
+	router := setupDiskRouter()
+	router.GET("/session/:session_id/events", h.StreamSessionEvents)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/session/"+sessionID.String()+"/events", nil))
+	}()
+
+	h.Close()
+	h.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end on Close")
+	}
+}
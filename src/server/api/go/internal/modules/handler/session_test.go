@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -15,12 +16,15 @@ import (
 	"github.com/memodb-io/Acontext/internal/infra/httpclient"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/editor"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
 	"github.com/memodb-io/Acontext/internal/pkg/tokenizer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // MockSessionService is a mock implementation of SessionService
@@ -370,6 +374,7 @@ func TestSessionHandler_DeleteSession(t *testing.T) {
 }
 
 func TestSessionHandler_UpdateConfigs(t *testing.T) {
+	projectID := uuid.New()
 	sessionID := uuid.New()
 
 	tests := []struct {
@@ -390,7 +395,7 @@ func TestSessionHandler_UpdateConfigs(t *testing.T) {
 			},
 			setup: func(svc *MockSessionService) {
 				svc.On("UpdateByID", mock.Anything, mock.MatchedBy(func(s *model.Session) bool {
-					return s.ID == sessionID
+					return s.ID == sessionID && s.ProjectID == projectID
 				})).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -424,6 +429,10 @@ func TestSessionHandler_UpdateConfigs(t *testing.T) {
 
 			handler := NewSessionHandler(mockService, getMockSessionCoreClient())
 			router := setupSessionRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				c.Next()
+			})
 			router.PUT("/session/:session_id/configs", handler.UpdateConfigs)
 
 			body, _ := sonic.Marshal(tt.requestBody)
@@ -440,6 +449,7 @@ func TestSessionHandler_UpdateConfigs(t *testing.T) {
 }
 
 func TestSessionHandler_GetConfigs(t *testing.T) {
+	projectID := uuid.New()
 	sessionID := uuid.New()
 
 	tests := []struct {
@@ -457,7 +467,7 @@ func TestSessionHandler_GetConfigs(t *testing.T) {
 					Configs: datatypes.JSONMap{"temperature": 0.7},
 				}
 				svc.On("GetByID", mock.Anything, mock.MatchedBy(func(s *model.Session) bool {
-					return s.ID == sessionID
+					return s.ID == sessionID && s.ProjectID == projectID
 				})).Return(expectedSession, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -476,6 +486,14 @@ func TestSessionHandler_GetConfigs(t *testing.T) {
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name:           "session of another project",
+			sessionIDParam: sessionID.String(),
+			setup: func(svc *MockSessionService) {
+				svc.On("GetByID", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
 	}
 
 	for _, tt := range tests {
@@ -485,6 +503,10 @@ func TestSessionHandler_GetConfigs(t *testing.T) {
 
 			handler := NewSessionHandler(mockService, getMockSessionCoreClient())
 			router := setupSessionRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				c.Next()
+			})
 			router.GET("/session/:session_id/configs", handler.GetConfigs)
 
 			req := httptest.NewRequest("GET", "/session/"+tt.sessionIDParam+"/configs", nil)
@@ -499,6 +521,7 @@ func TestSessionHandler_GetConfigs(t *testing.T) {
 }
 
 func TestSessionHandler_ConnectToSpace(t *testing.T) {
+	projectID := uuid.New()
 	sessionID := uuid.New()
 	spaceID := uuid.New()
 
@@ -560,6 +583,10 @@ func TestSessionHandler_ConnectToSpace(t *testing.T) {
 
 			handler := NewSessionHandler(mockService, getMockSessionCoreClient())
 			router := setupSessionRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				c.Next()
+			})
 			router.POST("/session/:session_id/connect_to_space", handler.ConnectToSpace)
 
 			body, _ := sonic.Marshal(tt.requestBody)
@@ -1842,7 +1869,7 @@ func TestSessionHandler_StoreMessage(t *testing.T) {
 			setup: func(svc *MockSessionService) {
 				svc.On("StoreMessage", mock.Anything, mock.Anything).Return(nil, errors.New("store failed"))
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusInternalServerError,
 		},
 	}
 
@@ -1938,6 +1965,25 @@ func TestSessionHandler_GetMessages(t *testing.T) {
 			setup: func(svc *MockSessionService) {
 				svc.On("GetMessages", mock.Anything, mock.Anything).Return(nil, errors.New("retrieval failed"))
 			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "invalid edit strategy",
+			sessionIDParam: sessionID.String(),
+			queryParams:    "?limit=20",
+			setup: func(svc *MockSessionService) {
+				svc.On("GetMessages", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("failed to apply edit strategies: %w", fmt.Errorf("%w: unknown strategy type: shrink", editor.ErrInvalidStrategy)))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "bad cursor",
+			sessionIDParam: sessionID.String(),
+			queryParams:    "?limit=20&cursor=bad",
+			setup: func(svc *MockSessionService) {
+				svc.On("GetMessages", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: bad cursor", paging.ErrInvalidCursor))
+			},
 			expectedStatus: http.StatusBadRequest,
 		},
 
@@ -0,0 +1,496 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/path"
+	"gorm.io/gorm"
+)
+
+type ShareHandler struct {
+	svc         service.DiskShareService
+	artifactSvc service.ArtifactService
+}
+
+func NewShareHandler(s service.DiskShareService, artifactSvc service.ArtifactService) *ShareHandler {
+	return &ShareHandler{svc: s, artifactSvc: artifactSvc}
+}
+
+type CreateShareReq struct {
+	Path       string `form:"path" json:"path" binding:"required" example:"/reports/"`                               // Shared directory, both ends must be '/'
+	Permission string `form:"permission" json:"permission" binding:"omitempty,oneof=read read_write" example:"read"` // Defaults to read
+	ExpiresIn  int64  `form:"expires_in" json:"expires_in" binding:"omitempty,min=0" example:"86400"`                // Seconds until the link expires, 0 never expires
+}
+
+// CreateShare godoc
+//
+//	@Summary		Create share link
+//	@Description	Create a link that gives access to a directory of the disk without the project key. The token is only returned once.
+//	@Description	read links can list and download files below the directory; read_write links can also upload and delete them.
+//	@Tags			share
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string					true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload	body	handler.CreateShareReq	true	"CreateShare payload"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=service.CreateShareOutput}
+//	@Router			/disk/{disk_id}/share [post]
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	req := CreateShareReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	if dirPath, _ := path.SplitFilePath(req.Path); dirPath != req.Path {
+		serializer.ParamErr(c, "both ends of the path must be '/'", errors.New("both ends of the path must be '/'"))
+		return
+	}
+	if err := path.ValidatePath(req.Path); err != nil {
+		serializer.ParamErr(c, "invalid path", err)
+		return
+	}
+
+	permission := model.SharePermission(req.Permission)
+	if permission == "" {
+		permission = model.SharePermissionRead
+	}
+
+	out, err := h.svc.Create(c.Request.Context(), service.CreateShareInput{
+		ProjectID:  project.ID,
+		DiskID:     diskID,
+		Path:       req.Path,
+		Permission: permission,
+		ExpiresIn:  time.Duration(req.ExpiresIn) * time.Second,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrDiskNotFound) {
+			serializer.Err(c, apierr.DiskNotFound, "disk not found", err)
+			return
+		}
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+// ListShares godoc
+//
+//	@Summary		List share links
+//	@Description	List the share links of a disk, including expired and revoked ones
+//	@Tags			share
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=[]model.DiskShare}
+//	@Router			/disk/{disk_id}/share [get]
+func (h *ShareHandler) ListShares(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	shares, err := h.svc.List(c.Request.Context(), project.ID, diskID)
+	if err != nil {
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: shares})
+}
+
+// RevokeShare godoc
+//
+//	@Summary		Revoke share link
+//	@Description	Revoke a share link. Requests with its token are rejected from then on.
+//	@Tags			share
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id		path	string	true	"Disk ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			share_id	path	string	true	"Share ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{}
+//	@Router			/disk/{disk_id}/share/{share_id} [delete]
+func (h *ShareHandler) RevokeShare(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	diskID, err := uuid.Parse(c.Param("disk_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+	shareID, err := uuid.Parse(c.Param("share_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	if err := h.svc.Revoke(c.Request.Context(), project.ID, diskID, shareID); err != nil {
+		if errors.Is(err, service.ErrShareNotFound) {
+			serializer.Err(c, apierr.ShareNotFound, "share link not found", err)
+			return
+		}
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{})
+}
+
+// ResolveShare is the middleware of the public share routes. It looks up the share of the
+// :token path parameter and sets it in the context, answering 404 for unknown, expired
+// and revoked links alike.
+func (h *ShareHandler) ResolveShare() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		share, err := h.svc.Resolve(c.Request.Context(), c.Param("token"))
+		if err != nil {
+			if errors.Is(err, service.ErrShareNotFound) || errors.Is(err, service.ErrShareUnavailable) {
+				serializer.Err(c, apierr.ShareNotFound, "share link not found", nil)
+				return
+			}
+			serializer.DBErr(c, "", err)
+			return
+		}
+
+		c.Set("share", share)
+		// The share acts for its project, so its requests are audited and rate limited like the
+		// project's own
+		c.Set("project", share.Project)
+		c.Set("audit_actor", model.AuditActorShare)
+		c.Next()
+	}
+}
+
+// sharedPath validates a directory or file path requested through a share link.
+// It answers the request and returns false when the path is invalid or outside the share.
+func sharedPath(c *gin.Context, share *model.DiskShare, dirPath string) bool {
+	if err := path.ValidatePath(dirPath); err != nil {
+		serializer.ParamErr(c, "invalid path", err)
+		return false
+	}
+	if !share.Covers(dirPath) {
+		serializer.Err(c, apierr.OutsideShare, "path is outside the shared directory", nil)
+		return false
+	}
+	return true
+}
+
+// sharedDirectory resolves an optional directory query, defaulting to the shared directory
+func sharedDirectory(c *gin.Context, share *model.DiskShare, dirPath string) (string, bool) {
+	if dirPath == "" {
+		return share.Path, true
+	}
+	if p, _ := path.SplitFilePath(dirPath); p != dirPath {
+		serializer.ParamErr(c, "both ends of the path must be '/'", errors.New("both ends of the path must be '/'"))
+		return "", false
+	}
+	return dirPath, sharedPath(c, share, dirPath)
+}
+
+// writableShare answers 403 and returns false for read-only share links
+func writableShare(c *gin.Context, share *model.DiskShare) bool {
+	if !share.Writable() {
+		serializer.Err(c, apierr.ShareReadOnly, "share link is read-only", nil)
+		return false
+	}
+	return true
+}
+
+// GetShare godoc
+//
+//	@Summary		Get shared directory
+//	@Description	Get the directory, permission and expiry of a share link. No authentication is required.
+//	@Tags			share
+//	@Produce		json
+//	@Param			token	path	string	true	"Share token"
+//	@Success		200	{object}	serializer.Response{data=model.DiskShare}
+//	@Failure		404	{object}	serializer.Problem	"Unknown, expired or revoked share link"
+//	@Router			/share/{token} [get]
+func (h *ShareHandler) GetShare(c *gin.Context) {
+	c.JSON(http.StatusOK, serializer.Response{Data: c.MustGet("share").(*model.DiskShare)})
+}
+
+type ListSharedArtifactsReq struct {
+	Path      string `form:"path" json:"path"` // Defaults to the shared directory
+	Recursive bool   `form:"recursive,default=false" json:"recursive" example:"false"`
+	Depth     int    `form:"depth,default=0" json:"depth" binding:"min=0,max=64" example:"0"`
+	Limit     int    `form:"limit,default=100" json:"limit" binding:"required,min=1,max=1000" example:"100"`
+	Cursor    string `form:"cursor" json:"cursor"`
+}
+
+// ListSharedArtifacts godoc
+//
+//	@Summary		List shared artifacts
+//	@Description	List artifacts below the shared directory, with the same paging and recursion as the disk listing. No authentication is required.
+//	@Tags			share
+//	@Produce		json
+//	@Param			token		path	string	true	"Share token"
+//	@Param			path		query	string	false	"Directory to list, defaults to the shared directory"
+//	@Param			recursive	query	boolean	false	"Include artifacts in all subdirectories (default false)"	example(false)
+//	@Param			depth		query	integer	false	"Maximum directory depth below path for recursive listing, 0 means unlimited (default 0)"	example(0)
+//	@Param			limit		query	integer	false	"Limit of artifacts to return, default 100. Max 1000."
+//	@Param			cursor		query	string	false	"Cursor for pagination"
+//	@Success		200	{object}	serializer.Response{data=service.ListArtifactsOutput}
+//	@Failure		403	{object}	serializer.Problem	"Path is outside the shared directory"
+//	@Router			/share/{token}/ls [get]
+func (h *ShareHandler) ListSharedArtifacts(c *gin.Context) {
+	share := c.MustGet("share").(*model.DiskShare)
+
+	req := ListSharedArtifactsReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	dirPath, ok := sharedDirectory(c, share, req.Path)
+	if !ok {
+		return
+	}
+
+	out, err := h.artifactSvc.List(c.Request.Context(), service.ListArtifactsInput{
+		DiskID:    share.DiskID,
+		Path:      dirPath,
+		Recursive: req.Recursive,
+		Depth:     req.Depth,
+		Limit:     req.Limit,
+		Cursor:    req.Cursor,
+	})
+	if err != nil {
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+type SharedArtifactReq struct {
+	FilePath string `form:"file_path" json:"file_path" binding:"required"` // File path including filename
+}
+
+// DownloadSharedArtifact godoc
+//
+//	@Summary		Download shared artifact
+//	@Description	Download a file below the shared directory. The content is streamed through the API, so no storage URL is exposed. No authentication is required.
+//	@Tags			share
+//	@Produce		octet-stream
+//	@Param			token		path	string	true	"Share token"
+//	@Param			file_path	query	string	true	"File path including filename"	example(/reports/summary.pdf)
+//	@Success		200	{file}	file
+//	@Failure		403	{object}	serializer.Problem	"Path is outside the shared directory"
+//	@Failure		404	{object}	serializer.Problem	"Artifact not found"
+//	@Router			/share/{token}/artifact [get]
+func (h *ShareHandler) DownloadSharedArtifact(c *gin.Context) {
+	share := c.MustGet("share").(*model.DiskShare)
+
+	req := SharedArtifactReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	filePath, filename := path.SplitFilePath(req.FilePath)
+	if !sharedPath(c, share, filePath) {
+		return
+	}
+
+	artifact, err := h.artifactSvc.GetByPath(c.Request.Context(), share.DiskID, filePath, filename)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			serializer.Err(c, apierr.ArtifactNotFound, "artifact not found", err)
+			return
+		}
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	body, err := h.artifactSvc.OpenContent(c.Request.Context(), artifact)
+	if err != nil {
+		serializer.DBErr(c, "", err)
+		return
+	}
+	defer body.Close()
+
+	asset := artifact.AssetMeta.Data()
+	contentType := asset.MIME
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, strings.ReplaceAll(artifact.Filename, `"`, "")))
+	c.DataFromReader(http.StatusOK, asset.SizeB, contentType, body, nil)
+}
+
+type ExportSharedArchiveReq struct {
+	Path string `form:"path" json:"path"` // Defaults to the shared directory
+}
+
+// ExportSharedArchive godoc
+//
+//	@Summary		Export shared archive
+//	@Description	Download the artifacts below a shared directory as a zip archive. No authentication is required.
+//	@Tags			share
+//	@Produce		application/zip
+//	@Param			token	path	string	true	"Share token"
+//	@Param			path	query	string	false	"Directory to export, defaults to the shared directory"
+//	@Success		200	{file}	file
+//	@Failure		403	{object}	serializer.Problem	"Path is outside the shared directory"
+//	@Router			/share/{token}/export [get]
+func (h *ShareHandler) ExportSharedArchive(c *gin.Context) {
+	share := c.MustGet("share").(*model.DiskShare)
+
+	req := ExportSharedArchiveReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	dirPath, ok := sharedDirectory(c, share, req.Path)
+	if !ok {
+		return
+	}
+
+	name := strings.Trim(dirPath, "/")
+	if name == "" {
+		name = share.DiskID.String()
+	} else {
+		name = name[strings.LastIndex(name, "/")+1:]
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, name))
+	c.Status(http.StatusOK)
+
+	// The response has already started, so a failure can only abort the stream
+	if err := h.artifactSvc.ExportZip(c.Request.Context(), share.DiskID, dirPath, c.Writer); err != nil {
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
+
+type UploadSharedArtifactReq struct {
+	FilePath string `form:"file_path" json:"file_path"` // Directory to upload to, defaults to the shared directory
+}
+
+// UploadSharedArtifact godoc
+//
+//	@Summary		Upload shared artifact
+//	@Description	Upload a file below the directory of a read_write share link, replacing a file with the same path. No authentication is required.
+//	@Tags			share
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			token		path		string	true	"Share token"
+//	@Param			file_path	formData	string	false	"Directory to upload to, defaults to the shared directory"
+//	@Param			file		formData	file	true	"File to upload"
+//	@Success		201	{object}	serializer.Response{data=model.Artifact}
+//	@Failure		403	{object}	serializer.Problem	"Read-only share link or path outside the shared directory"
+//	@Router			/share/{token}/artifact [post]
+func (h *ShareHandler) UploadSharedArtifact(c *gin.Context) {
+	share := c.MustGet("share").(*model.DiskShare)
+	if !writableShare(c, share) {
+		return
+	}
+
+	req := UploadSharedArtifactReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		serializer.ParamErr(c, "file is required", err)
+		return
+	}
+
+	filePath := share.Path
+	if req.FilePath != "" {
+		filePath, _ = path.SplitFilePath(req.FilePath)
+	}
+	if !sharedPath(c, share, filePath) {
+		return
+	}
+
+	artifact, err := h.artifactSvc.Create(c.Request.Context(), service.CreateArtifactInput{
+		ProjectID:  share.ProjectID,
+		DiskID:     share.DiskID,
+		Path:       filePath,
+		Filename:   file.Filename,
+		FileHeader: file,
+	})
+	if err != nil {
+		artifactWriteErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: artifact})
+}
+
+// DeleteSharedArtifact godoc
+//
+//	@Summary		Delete shared artifact
+//	@Description	Delete a file below the directory of a read_write share link. No authentication is required.
+//	@Tags			share
+//	@Produce		json
+//	@Param			token		path	string	true	"Share token"
+//	@Param			file_path	query	string	true	"File path including filename"	example(/reports/summary.pdf)
+//	@Success		200	{object}	serializer.Response{}
+//	@Failure		403	{object}	serializer.Problem	"Read-only share link or path outside the shared directory"
+//	@Router			/share/{token}/artifact [delete]
+func (h *ShareHandler) DeleteSharedArtifact(c *gin.Context) {
+	share := c.MustGet("share").(*model.DiskShare)
+	if !writableShare(c, share) {
+		return
+	}
+
+	req := SharedArtifactReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	filePath, filename := path.SplitFilePath(req.FilePath)
+	if !sharedPath(c, share, filePath) {
+		return
+	}
+
+	if err := h.artifactSvc.DeleteByPath(c.Request.Context(), share.ProjectID, share.DiskID, filePath, filename); err != nil {
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{})
+}
@@ -0,0 +1,349 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// MockDiskShareService is a mock implementation of DiskShareService
+type MockDiskShareService struct {
+	mock.Mock
+}
+
+func (m *MockDiskShareService) Create(ctx context.Context, in service.CreateShareInput) (*service.CreateShareOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CreateShareOutput), args.Error(1)
+}
+
+func (m *MockDiskShareService) List(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.DiskShare), args.Error(1)
+}
+
+func (m *MockDiskShareService) Revoke(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID) error {
+	args := m.Called(ctx, projectID, diskID, shareID)
+	return args.Error(0)
+}
+
+func (m *MockDiskShareService) Resolve(ctx context.Context, token string) (*model.DiskShare, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DiskShare), args.Error(1)
+}
+
+// setupShareRouter registers the public share routes the same way as the main router
+func setupShareRouter(h *ShareHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	share := r.Group("/share/:token")
+	share.Use(h.ResolveShare())
+	share.GET("", h.GetShare)
+	share.GET("/ls", h.ListSharedArtifacts)
+	share.GET("/artifact", h.DownloadSharedArtifact)
+	share.POST("/artifact", h.UploadSharedArtifact)
+	share.DELETE("/artifact", h.DeleteSharedArtifact)
+	share.GET("/export", h.ExportSharedArchive)
+	return r
+}
+
+func TestShareHandler_CreateShare(t *testing.T) {
+	projectID, diskID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		setup          func(*MockDiskShareService)
+		expectedStatus int
+	}{
+		{
+			name: "default read permission",
+			body: `{"path":"/reports/","expires_in":60}`,
+			setup: func(svc *MockDiskShareService) {
+				svc.On("Create", mock.Anything, service.CreateShareInput{
+					ProjectID:  projectID,
+					DiskID:     diskID,
+					Path:       "/reports/",
+					Permission: model.SharePermissionRead,
+					ExpiresIn:  time.Minute,
+				}).Return(&service.CreateShareOutput{Share: &model.DiskShare{}, Token: "sh-token"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "path is a file",
+			body:           `{"path":"/reports/a.txt"}`,
+			setup:          func(svc *MockDiskShareService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown permission",
+			body:           `{"path":"/","permission":"admin"}`,
+			setup:          func(svc *MockDiskShareService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "disk not found",
+			body: `{"path":"/","permission":"read_write"}`,
+			setup: func(svc *MockDiskShareService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, service.ErrDiskNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockDiskShareService{}
+			tt.setup(svc)
+			h := NewShareHandler(svc, &MockArtifactService{})
+
+			router := setupDiskRouter()
+			router.POST("/disk/:disk_id/share", func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				h.CreateShare(c)
+			})
+
+			req := httptest.NewRequest("POST", "/disk/"+diskID.String()+"/share", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var response map[string]interface{}
+				assert.NoError(t, sonic.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "sh-token", response["data"].(map[string]interface{})["token"])
+			}
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestShareHandler_RevokeShare(t *testing.T) {
+	projectID, diskID, shareID := uuid.New(), uuid.New(), uuid.New()
+
+	for _, tc := range []struct {
+		err    error
+		status int
+	}{
+		{nil, http.StatusOK},
+		{service.ErrShareNotFound, http.StatusNotFound},
+	} {
+		svc := &MockDiskShareService{}
+		svc.On("Revoke", mock.Anything, projectID, diskID, shareID).Return(tc.err)
+		h := NewShareHandler(svc, &MockArtifactService{})
+
+		router := setupDiskRouter()
+		router.DELETE("/disk/:disk_id/share/:share_id", func(c *gin.Context) {
+			c.Set("project", &model.Project{ID: projectID})
+			h.RevokeShare(c)
+		})
+
+		req := httptest.NewRequest("DELETE", "/disk/"+diskID.String()+"/share/"+shareID.String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.status, w.Code)
+		svc.AssertExpectations(t)
+	}
+}
+
+func TestShareHandler_PublicRoutes(t *testing.T) {
+	projectID, diskID := uuid.New(), uuid.New()
+	readShare := &model.DiskShare{ProjectID: projectID, DiskID: diskID, Path: "/reports/", Permission: model.SharePermissionRead}
+	writeShare := &model.DiskShare{ProjectID: projectID, DiskID: diskID, Path: "/reports/", Permission: model.SharePermissionReadWrite}
+	artifact := &model.Artifact{
+		DiskID:    diskID,
+		Path:      "/reports/",
+		Filename:  "q1.txt",
+		AssetMeta: datatypes.NewJSONType(model.Asset{S3Key: "key", MIME: "text/plain", SizeB: 5}),
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		share          *model.DiskShare
+		resolveErr     error
+		setup          func(*MockArtifactService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "unknown or expired token",
+			method:         "GET",
+			url:            "/share/sh-x/ls",
+			resolveErr:     service.ErrShareUnavailable,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "list defaults to the shared directory",
+			method: "GET",
+			url:    "/share/sh-x/ls",
+			share:  readShare,
+			setup: func(svc *MockArtifactService) {
+				svc.On("List", mock.Anything, service.ListArtifactsInput{DiskID: diskID, Path: "/reports/", Limit: 100}).
+					Return(&service.ListArtifactsOutput{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "list outside the shared directory",
+			method:         "GET",
+			url:            "/share/sh-x/ls?path=/secrets/",
+			share:          readShare,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "download streams the file",
+			method: "GET",
+			url:    "/share/sh-x/artifact?file_path=/reports/q1.txt",
+			share:  readShare,
+			setup: func(svc *MockArtifactService) {
+				svc.On("GetByPath", mock.Anything, diskID, "/reports/", "q1.txt").Return(artifact, nil)
+				svc.On("OpenContent", mock.Anything, artifact).Return(io.NopCloser(strings.NewReader("hello")), nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "hello",
+		},
+		{
+			name:   "download of a missing file",
+			method: "GET",
+			url:    "/share/sh-x/artifact?file_path=/reports/missing.txt",
+			share:  readShare,
+			setup: func(svc *MockArtifactService) {
+				svc.On("GetByPath", mock.Anything, diskID, "/reports/", "missing.txt").Return((*model.Artifact)(nil), gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "download outside the shared directory",
+			method:         "GET",
+			url:            "/share/sh-x/artifact?file_path=/other/q1.txt",
+			share:          readShare,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "delete through a read-only share",
+			method:         "DELETE",
+			url:            "/share/sh-x/artifact?file_path=/reports/q1.txt",
+			share:          readShare,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "delete through a read_write share",
+			method: "DELETE",
+			url:    "/share/sh-x/artifact?file_path=/reports/q1.txt",
+			share:  writeShare,
+			setup: func(svc *MockArtifactService) {
+				svc.On("DeleteByPath", mock.Anything, projectID, diskID, "/reports/", "q1.txt").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "upload through a read-only share",
+			method:         "POST",
+			url:            "/share/sh-x/artifact",
+			share:          readShare,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shareSvc := &MockDiskShareService{}
+			if tt.resolveErr != nil {
+				shareSvc.On("Resolve", mock.Anything, "sh-x").Return(nil, tt.resolveErr)
+			} else {
+				shareSvc.On("Resolve", mock.Anything, "sh-x").Return(tt.share, nil)
+			}
+			artifactSvc := &MockArtifactService{}
+			if tt.setup != nil {
+				tt.setup(artifactSvc)
+			}
+			router := setupShareRouter(NewShareHandler(shareSvc, artifactSvc))
+
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+				assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+			}
+			shareSvc.AssertExpectations(t)
+			artifactSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestShareHandler_UploadSharedArtifact(t *testing.T) {
+	projectID, diskID := uuid.New(), uuid.New()
+	share := &model.DiskShare{ProjectID: projectID, DiskID: diskID, Path: "/inbox/", Permission: model.SharePermissionReadWrite}
+
+	for _, tc := range []struct {
+		filePath string
+		status   int
+	}{
+		{"", http.StatusCreated},
+		{"/inbox/drafts/", http.StatusCreated},
+		{"/outbox/", http.StatusForbidden},
+	} {
+		shareSvc := &MockDiskShareService{}
+		shareSvc.On("Resolve", mock.Anything, "sh-x").Return(share, nil)
+		artifactSvc := &MockArtifactService{}
+		if tc.status == http.StatusCreated {
+			wantPath := tc.filePath
+			if wantPath == "" {
+				wantPath = share.Path
+			}
+			artifactSvc.On("Create", mock.Anything, mock.MatchedBy(func(in service.CreateArtifactInput) bool {
+				return in.ProjectID == projectID && in.DiskID == diskID && in.Path == wantPath && in.Filename == "notes.txt"
+			})).Return(&model.Artifact{}, nil)
+		}
+		router := setupShareRouter(NewShareHandler(shareSvc, artifactSvc))
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		if tc.filePath != "" {
+			_ = writer.WriteField("file_path", tc.filePath)
+		}
+		part, _ := writer.CreateFormFile("file", "notes.txt")
+		_, _ = part.Write([]byte("hello"))
+		_ = writer.Close()
+
+		req := httptest.NewRequest("POST", "/share/sh-x/artifact", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.status, w.Code, "file_path %q", tc.filePath)
+		artifactSvc.AssertExpectations(t)
+	}
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
 	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type SpaceHandler struct {
@@ -25,6 +27,18 @@ func NewSpaceHandler(s service.SpaceService, coreClient *httpclient.CoreClient)
 	}
 }
 
+// spaceErr answers a failed space operation
+func spaceErr(c *gin.Context, err error) {
+	if invalidConfigs(c, err) {
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		serializer.Err(c, apierr.SpaceNotFound, "space not found", err)
+		return
+	}
+	serializer.DBErr(c, "", err)
+}
+
 type CreateSpaceReq struct {
 	Configs map[string]interface{} `form:"configs" json:"configs"`
 }
@@ -52,13 +66,13 @@ type GetSpacesReq struct {
 func (h *SpaceHandler) GetSpaces(c *gin.Context) {
 	req := GetSpacesReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
@@ -69,7 +83,7 @@ func (h *SpaceHandler) GetSpaces(c *gin.Context) {
 		TimeDesc:  req.TimeDesc,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -86,18 +100,19 @@ func (h *SpaceHandler) GetSpaces(c *gin.Context) {
 //	@Param			payload	body	handler.CreateSpaceReq	true	"CreateSpace payload"
 //	@Security		BearerAuth
 //	@Success		201	{object}	serializer.Response{data=model.Space}
+//	@Failure		422	{object}	serializer.Problem{problems=[]configs.Problem}
 //	@Router			/space [post]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Create a space\nspace = client.spaces.create(configs={\"name\": \"My Space\"})\nprint(f\"Created space: {space.id}\")\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Create a space\nconst space = await client.spaces.create({ configs: { name: 'My Space' } });\nconsole.log(`Created space: ${space.id}`);\n","label":"JavaScript"}]
 func (h *SpaceHandler) CreateSpace(c *gin.Context) {
 	req := CreateSpaceReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
@@ -106,7 +121,10 @@ func (h *SpaceHandler) CreateSpace(c *gin.Context) {
 		Configs:   datatypes.JSONMap(req.Configs),
 	}
 	if err := h.svc.Create(c.Request.Context(), &space); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		if invalidConfigs(c, err) {
+			return
+		}
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -116,7 +134,7 @@ func (h *SpaceHandler) CreateSpace(c *gin.Context) {
 // DeleteSpace godoc
 //
 //	@Summary		Delete space
-//	@Description	Delete a space by its ID
+//	@Description	Move a space to the trash. It can be restored with POST /space/{space_id}/restore for trash.retentionDays days; its sessions stay connected to it meanwhile.
 //	@Tags			space
 //	@Accept			json
 //	@Produce		json
@@ -128,18 +146,18 @@ func (h *SpaceHandler) CreateSpace(c *gin.Context) {
 func (h *SpaceHandler) DeleteSpace(c *gin.Context) {
 	spaceID, err := uuid.Parse(c.Param("space_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	if err := h.svc.Delete(c.Request.Context(), project.ID, spaceID); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		spaceErr(c, err)
 		return
 	}
 
@@ -161,25 +179,34 @@ type UpdateSpaceConfigsReq struct {
 //	@Param			payload		body	handler.UpdateSpaceConfigsReq	true	"UpdateConfigs payload"
 //	@Security		BearerAuth
 //	@Success		200	{object}	serializer.Response
+//	@Failure		422	{object}	serializer.Problem{problems=[]configs.Problem}
 //	@Router			/space/{space_id}/configs [put]
 //	@x-code-samples	[{"lang":"python","source":"from acontext import AcontextClient\n\nclient = AcontextClient(api_key='sk_project_token')\n\n# Update space configs\nclient.spaces.update_configs(\n    space_id='space-uuid',\n    configs={\"name\": \"Updated Name\", \"description\": \"New description\"}\n)\n","label":"Python"},{"lang":"javascript","source":"import { AcontextClient } from '@acontext/acontext';\n\nconst client = new AcontextClient({ apiKey: 'sk_project_token' });\n\n// Update space configs\nawait client.spaces.updateConfigs('space-uuid', {\n  configs: { name: 'Updated Name', description: 'New description' }\n});\n","label":"JavaScript"}]
 func (h *SpaceHandler) UpdateConfigs(c *gin.Context) {
 	req := UpdateSpaceConfigsReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	spaceID, err := uuid.Parse(c.Param("space_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
 	if err := h.svc.UpdateByID(c.Request.Context(), &model.Space{
-		ID:      spaceID,
-		Configs: datatypes.JSONMap(req.Configs),
+		ID:        spaceID,
+		ProjectID: project.ID,
+		Configs:   datatypes.JSONMap(req.Configs),
 	}); err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		spaceErr(c, err)
 		return
 	}
 
@@ -201,12 +228,19 @@ func (h *SpaceHandler) UpdateConfigs(c *gin.Context) {
 func (h *SpaceHandler) GetConfigs(c *gin.Context) {
 	spaceID, err := uuid.Parse(c.Param("space_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
-	space, err := h.svc.GetByID(c.Request.Context(), &model.Space{ID: spaceID})
+
+	space, err := h.svc.GetByID(c.Request.Context(), &model.Space{ID: spaceID, ProjectID: project.ID})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		spaceErr(c, err)
 		return
 	}
 
@@ -241,7 +275,7 @@ type GetExperienceSearchReq struct {
 func (h *SpaceHandler) GetExperienceSearch(c *gin.Context) {
 	spaceID, err := uuid.Parse(c.Param("space_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
@@ -251,13 +285,13 @@ func (h *SpaceHandler) GetExperienceSearch(c *gin.Context) {
 		MaxIterations: 16,
 	}
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
@@ -270,7 +304,7 @@ func (h *SpaceHandler) GetExperienceSearch(c *gin.Context) {
 		MaxIterations:     req.MaxIterations,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "Failed to call core service", err))
+		serializer.Err(c, apierr.Internal, "Failed to call core service", err)
 		return
 	}
 
@@ -301,30 +335,13 @@ type ListExperienceConfirmationsReq struct {
 func (h *SpaceHandler) ListExperienceConfirmations(c *gin.Context) {
 	spaceID, err := uuid.Parse(c.Param("space_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	req := ListExperienceConfirmationsReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
-		return
-	}
-
-	project, ok := c.MustGet("project").(*model.Project)
-	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
-		return
-	}
-
-	// Verify the space belongs to the project
-	space, err := h.svc.GetByID(c.Request.Context(), &model.Space{ID: spaceID})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
-		return
-	}
-	if space.ProjectID != project.ID {
-		c.JSON(http.StatusForbidden, serializer.ParamErr("", errors.New("space does not belong to project")))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
@@ -335,7 +352,7 @@ func (h *SpaceHandler) ListExperienceConfirmations(c *gin.Context) {
 		TimeDesc: req.TimeDesc,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -363,42 +380,31 @@ type ConfirmExperienceReq struct {
 func (h *SpaceHandler) ConfirmExperience(c *gin.Context) {
 	spaceID, err := uuid.Parse(c.Param("space_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	experienceID, err := uuid.Parse(c.Param("experience_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	req := ConfirmExperienceReq{}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
-		return
-	}
-
-	// Verify the space belongs to the project
-	space, err := h.svc.GetByID(c.Request.Context(), &model.Space{ID: spaceID})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
-		return
-	}
-	if space.ProjectID != project.ID {
-		c.JSON(http.StatusForbidden, serializer.ParamErr("", errors.New("space does not belong to project")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
-	confirmation, err := h.svc.ConfirmExperience(c.Request.Context(), spaceID, experienceID, *req.Save)
+	confirmation, err := h.svc.ConfirmExperience(c.Request.Context(), project.ID, spaceID, experienceID, *req.Save)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.DBErr("", err))
+		serializer.DBErr(c, "", err)
 		return
 	}
 
@@ -65,8 +65,8 @@ func (m *MockSpaceService) ListExperienceConfirmations(ctx context.Context, in s
 	return args.Get(0).(*service.ListExperienceConfirmationsOutput), args.Error(1)
 }
 
-func (m *MockSpaceService) ConfirmExperience(ctx context.Context, spaceID uuid.UUID, experienceID uuid.UUID, save bool) (*model.ExperienceConfirmation, error) {
-	args := m.Called(ctx, spaceID, experienceID, save)
+func (m *MockSpaceService) ConfirmExperience(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID, experienceID uuid.UUID, save bool) (*model.ExperienceConfirmation, error) {
+	args := m.Called(ctx, projectID, spaceID, experienceID, save)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -297,6 +297,7 @@ func TestSpaceHandler_DeleteSpace(t *testing.T) {
 }
 
 func TestSpaceHandler_UpdateConfigs(t *testing.T) {
+	projectID := uuid.New()
 	spaceID := uuid.New()
 
 	tests := []struct {
@@ -317,7 +318,7 @@ func TestSpaceHandler_UpdateConfigs(t *testing.T) {
 			},
 			setup: func(svc *MockSpaceService) {
 				svc.On("UpdateByID", mock.Anything, mock.MatchedBy(func(s *model.Space) bool {
-					return s.ID == spaceID
+					return s.ID == spaceID && s.ProjectID == projectID
 				})).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -347,6 +348,10 @@ func TestSpaceHandler_UpdateConfigs(t *testing.T) {
 
 			handler := NewSpaceHandler(mockService, getMockCoreClient())
 			router := setupSpaceRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				c.Next()
+			})
 			router.PUT("/space/:space_id/configs", handler.UpdateConfigs)
 
 			body, _ := sonic.Marshal(tt.requestBody)
@@ -363,6 +368,7 @@ func TestSpaceHandler_UpdateConfigs(t *testing.T) {
 }
 
 func TestSpaceHandler_GetConfigs(t *testing.T) {
+	projectID := uuid.New()
 	spaceID := uuid.New()
 
 	tests := []struct {
@@ -380,7 +386,7 @@ func TestSpaceHandler_GetConfigs(t *testing.T) {
 					Configs: datatypes.JSONMap{"theme": "dark"},
 				}
 				svc.On("GetByID", mock.Anything, mock.MatchedBy(func(s *model.Space) bool {
-					return s.ID == spaceID
+					return s.ID == spaceID && s.ProjectID == projectID
 				})).Return(expectedSpace, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -408,6 +414,10 @@ func TestSpaceHandler_GetConfigs(t *testing.T) {
 
 			handler := NewSpaceHandler(mockService, getMockCoreClient())
 			router := setupSpaceRouter()
+			router.Use(func(c *gin.Context) {
+				c.Set("project", &model.Project{ID: projectID})
+				c.Next()
+			})
 			router.GET("/space/:space_id/configs", handler.GetConfigs)
 
 			req := httptest.NewRequest("GET", "/space/"+tt.spaceIDParam+"/configs", nil)
@@ -492,14 +502,6 @@ func TestSpaceHandler_ListExperienceConfirmations(t *testing.T) {
 			spaceIDParam: spaceID.String(),
 			queryParams:  "?limit=20&time_desc=false",
 			setup: func(svc *MockSpaceService) {
-				expectedSpace := &model.Space{
-					ID:        spaceID,
-					ProjectID: projectID,
-				}
-				svc.On("GetByID", mock.Anything, mock.MatchedBy(func(s *model.Space) bool {
-					return s.ID == spaceID
-				})).Return(expectedSpace, nil)
-
 				expectedOutput := &service.ListExperienceConfirmationsOutput{
 					Items: []model.ExperienceConfirmation{
 						{
@@ -524,14 +526,6 @@ func TestSpaceHandler_ListExperienceConfirmations(t *testing.T) {
 			spaceIDParam: spaceID.String(),
 			queryParams:  "?limit=20",
 			setup: func(svc *MockSpaceService) {
-				expectedSpace := &model.Space{
-					ID:        spaceID,
-					ProjectID: projectID,
-				}
-				svc.On("GetByID", mock.Anything, mock.MatchedBy(func(s *model.Space) bool {
-					return s.ID == spaceID
-				})).Return(expectedSpace, nil)
-
 				svc.On("ListExperienceConfirmations", mock.Anything, mock.Anything).Return(&service.ListExperienceConfirmationsOutput{
 					Items:   []model.ExperienceConfirmation{},
 					HasMore: false,
@@ -546,45 +540,11 @@ func TestSpaceHandler_ListExperienceConfirmations(t *testing.T) {
 			setup:          func(svc *MockSpaceService) {},
 			expectedStatus: http.StatusBadRequest,
 		},
-		{
-			name:         "space not found",
-			spaceIDParam: spaceID.String(),
-			queryParams:  "?limit=20",
-			setup: func(svc *MockSpaceService) {
-				svc.On("GetByID", mock.Anything, mock.MatchedBy(func(s *model.Space) bool {
-					return s.ID == spaceID
-				})).Return(nil, errors.New("space not found"))
-			},
-			expectedStatus: http.StatusInternalServerError,
-		},
-		{
-			name:         "space does not belong to project",
-			spaceIDParam: spaceID.String(),
-			queryParams:  "?limit=20",
-			setup: func(svc *MockSpaceService) {
-				expectedSpace := &model.Space{
-					ID:        spaceID,
-					ProjectID: uuid.New(), // Different project ID
-				}
-				svc.On("GetByID", mock.Anything, mock.MatchedBy(func(s *model.Space) bool {
-					return s.ID == spaceID
-				})).Return(expectedSpace, nil)
-			},
-			expectedStatus: http.StatusForbidden,
-		},
 		{
 			name:         "service layer error",
 			spaceIDParam: spaceID.String(),
 			queryParams:  "?limit=20",
 			setup: func(svc *MockSpaceService) {
-				expectedSpace := &model.Space{
-					ID:        spaceID,
-					ProjectID: projectID,
-				}
-				svc.On("GetByID", mock.Anything, mock.MatchedBy(func(s *model.Space) bool {
-					return s.ID == spaceID
-				})).Return(expectedSpace, nil)
-
 				svc.On("ListExperienceConfirmations", mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -594,14 +554,6 @@ func TestSpaceHandler_ListExperienceConfirmations(t *testing.T) {
 			spaceIDParam: spaceID.String(),
 			queryParams:  "?limit=10&cursor=test_cursor&time_desc=true",
 			setup: func(svc *MockSpaceService) {
-				expectedSpace := &model.Space{
-					ID:        spaceID,
-					ProjectID: projectID,
-				}
-				svc.On("GetByID", mock.Anything, mock.MatchedBy(func(s *model.Space) bool {
-					return s.ID == spaceID
-				})).Return(expectedSpace, nil)
-
 				expectedOutput := &service.ListExperienceConfirmationsOutput{
 					Items: []model.ExperienceConfirmation{
 						{
@@ -41,13 +41,13 @@ type GetTasksReq struct {
 func (h *TaskHandler) GetTasks(c *gin.Context) {
 	req := GetTasksReq{}
 	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
 	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
@@ -58,7 +58,7 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 		TimeDesc:  req.TimeDesc,
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, serializer.DBErr("", err))
+		serializer.DBErr(c, "", err)
 		return
 	}
 
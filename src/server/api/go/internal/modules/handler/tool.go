@@ -8,6 +8,7 @@ import (
 	"github.com/memodb-io/Acontext/internal/infra/httpclient"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
 )
 
 type ToolHandler struct {
@@ -45,13 +46,13 @@ func (h *ToolHandler) RenameToolName(c *gin.Context) {
 	// Get project from context
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	req := RenameToolNameReq{}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", err))
+		serializer.ParamErr(c, "", err)
 		return
 	}
 
@@ -67,7 +68,7 @@ func (h *ToolHandler) RenameToolName(c *gin.Context) {
 	// Call Core service to rename tools
 	result, err := h.coreClient.ToolRename(c.Request.Context(), project.ID, renameItems)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "failed to rename tools", err))
+		serializer.Err(c, apierr.Internal, "failed to rename tools", err)
 		return
 	}
 
@@ -89,14 +90,14 @@ func (h *ToolHandler) GetToolName(c *gin.Context) {
 	// Get project from context
 	project, ok := c.MustGet("project").(*model.Project)
 	if !ok {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("", errors.New("project not found")))
+		serializer.ParamErr(c, "", errors.New("project not found"))
 		return
 	}
 
 	// Call Core service to get tool names
 	result, err := h.coreClient.GetToolNames(c.Request.Context(), project.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, serializer.Err(http.StatusInternalServerError, "failed to get tool names", err))
+		serializer.Err(c, apierr.Internal, "failed to get tool names", err)
 		return
 	}
 
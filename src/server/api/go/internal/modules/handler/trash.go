@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+	"gorm.io/gorm"
+)
+
+type TrashHandler struct {
+	svc service.TrashService
+}
+
+func NewTrashHandler(s service.TrashService) *TrashHandler {
+	return &TrashHandler{svc: s}
+}
+
+type ListTrashReq struct {
+	Limit  int    `form:"limit,default=20" json:"limit" binding:"required,min=1,max=200" example:"20"`
+	Cursor string `form:"cursor" json:"cursor" example:"cHJvdGVjdGVkIHZlcnNpb24gdG8gYmUgZXhjbHVkZWQgaW4gcGFyc2luZyB0aGUgY3Vyc29y"`
+}
+
+// listTrash binds a trash listing request of the project
+func listTrash(c *gin.Context) (service.ListTrashInput, bool) {
+	req := ListTrashReq{}
+	if err := c.ShouldBind(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return service.ListTrashInput{}, false
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return service.ListTrashInput{}, false
+	}
+
+	return service.ListTrashInput{ProjectID: project.ID, Limit: req.Limit, Cursor: req.Cursor}, true
+}
+
+// restoreTarget parses the resource named by param of a restore request of the project
+func restoreTarget(c *gin.Context, param string) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(param))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return project.ID, id, true
+}
+
+// restoreErr answers a failed restore, where a missing resource is one that is not in the trash
+func restoreErr(c *gin.Context, notFound apierr.Code, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		serializer.Err(c, notFound, "not in the trash, or kept there longer than the retention window", err)
+	case errors.Is(err, service.ErrDiskNameExists):
+		serializer.Err(c, apierr.DiskNameConflict, "another disk has taken the name of the disk", err)
+	default:
+		serializer.DBErr(c, "", err)
+	}
+}
+
+// ListTrashedSessions godoc
+//
+//	@Summary		List deleted sessions
+//	@Description	List the sessions of the project in the trash, the most recently deleted first. Sessions stay in the trash, and can be restored, for trash.retentionDays days.
+//	@Tags			session
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query	integer	false	"Limit of sessions to return, default 20. Max 200."
+//	@Param			cursor	query	string	false	"Cursor for pagination. Use the cursor from the previous response to get the next page."
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ListSessionsOutput}
+//	@Router			/session/trash [get]
+func (h *TrashHandler) ListTrashedSessions(c *gin.Context) {
+	in, ok := listTrash(c)
+	if !ok {
+		return
+	}
+
+	out, err := h.svc.ListSessions(c.Request.Context(), in)
+	if err != nil {
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+// RestoreSession godoc
+//
+//	@Summary		Restore session
+//	@Description	Take a deleted session out of the trash, with its messages and tasks
+//	@Tags			session
+//	@Accept			json
+//	@Produce		json
+//	@Param			session_id	path	string	true	"Session ID"	format(uuid)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.Session}
+//	@Failure		404	{object}	serializer.Problem	"The session is not in the trash"
+//	@Router			/session/{session_id}/restore [post]
+func (h *TrashHandler) RestoreSession(c *gin.Context) {
+	projectID, sessionID, ok := restoreTarget(c, "session_id")
+	if !ok {
+		return
+	}
+
+	session, err := h.svc.RestoreSession(c.Request.Context(), projectID, sessionID)
+	if err != nil {
+		restoreErr(c, apierr.SessionNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: session})
+}
+
+// ListTrashedSpaces godoc
+//
+//	@Summary		List deleted spaces
+//	@Description	List the spaces of the project in the trash, the most recently deleted first. Spaces stay in the trash, and can be restored, for trash.retentionDays days.
+//	@Tags			space
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query	integer	false	"Limit of spaces to return, default 20. Max 200."
+//	@Param			cursor	query	string	false	"Cursor for pagination. Use the cursor from the previous response to get the next page."
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ListSpacesOutput}
+//	@Router			/space/trash [get]
+func (h *TrashHandler) ListTrashedSpaces(c *gin.Context) {
+	in, ok := listTrash(c)
+	if !ok {
+		return
+	}
+
+	out, err := h.svc.ListSpaces(c.Request.Context(), in)
+	if err != nil {
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+// RestoreSpace godoc
+//
+//	@Summary		Restore space
+//	@Description	Take a deleted space out of the trash, with its blocks and experience confirmations
+//	@Tags			space
+//	@Accept			json
+//	@Produce		json
+//	@Param			space_id	path	string	true	"Space ID"	format(uuid)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.Space}
+//	@Failure		404	{object}	serializer.Problem	"The space is not in the trash"
+//	@Router			/space/{space_id}/restore [post]
+func (h *TrashHandler) RestoreSpace(c *gin.Context) {
+	projectID, spaceID, ok := restoreTarget(c, "space_id")
+	if !ok {
+		return
+	}
+
+	space, err := h.svc.RestoreSpace(c.Request.Context(), projectID, spaceID)
+	if err != nil {
+		restoreErr(c, apierr.SpaceNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: space})
+}
+
+// ListTrashedDisks godoc
+//
+//	@Summary		List deleted disks
+//	@Description	List the disks of the project in the trash, the most recently deleted first. Disks stay in the trash, and can be restored, for trash.retentionDays days.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query	integer	false	"Limit of disks to return, default 20. Max 200."
+//	@Param			cursor	query	string	false	"Cursor for pagination. Use the cursor from the previous response to get the next page."
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ListDisksOutput}
+//	@Router			/disk/trash [get]
+func (h *TrashHandler) ListTrashedDisks(c *gin.Context) {
+	in, ok := listTrash(c)
+	if !ok {
+		return
+	}
+
+	out, err := h.svc.ListDisks(c.Request.Context(), in)
+	if err != nil {
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+// RestoreDisk godoc
+//
+//	@Summary		Restore disk
+//	@Description	Take a deleted disk out of the trash, with its artifacts. A disk whose name was taken by another disk meanwhile cannot be restored until that disk is renamed or deleted.
+//	@Tags			disk
+//	@Accept			json
+//	@Produce		json
+//	@Param			disk_id	path	string	true	"Disk ID"	Format(uuid)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.Disk}
+//	@Failure		404	{object}	serializer.Problem	"The disk is not in the trash"
+//	@Failure		409	{object}	serializer.Problem	"Another disk has taken the name of the disk"
+//	@Router			/disk/{disk_id}/restore [post]
+func (h *TrashHandler) RestoreDisk(c *gin.Context) {
+	projectID, diskID, ok := restoreTarget(c, "disk_id")
+	if !ok {
+		return
+	}
+
+	disk, err := h.svc.RestoreDisk(c.Request.Context(), projectID, diskID)
+	if err != nil {
+		restoreErr(c, apierr.DiskNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: disk})
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// MockTrashService is a mock implementation of TrashService
+type MockTrashService struct {
+	mock.Mock
+}
+
+func (m *MockTrashService) ListSessions(ctx context.Context, in service.ListTrashInput) (*service.ListSessionsOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ListSessionsOutput), args.Error(1)
+}
+
+func (m *MockTrashService) ListSpaces(ctx context.Context, in service.ListTrashInput) (*service.ListSpacesOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ListSpacesOutput), args.Error(1)
+}
+
+func (m *MockTrashService) ListDisks(ctx context.Context, in service.ListTrashInput) (*service.ListDisksOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ListDisksOutput), args.Error(1)
+}
+
+func (m *MockTrashService) RestoreSession(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) (*model.Session, error) {
+	args := m.Called(ctx, projectID, sessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Session), args.Error(1)
+}
+
+func (m *MockTrashService) RestoreSpace(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) (*model.Space, error) {
+	args := m.Called(ctx, projectID, spaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Space), args.Error(1)
+}
+
+func (m *MockTrashService) RestoreDisk(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockTrashService) Purge(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func TestTrashHandler_ListTrashedSessions(t *testing.T) {
+	projectID := uuid.New()
+
+	tests := []struct {
+		name           string
+		query          string
+		setup          func(*MockTrashService)
+		expectedStatus int
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			setup: func(svc *MockTrashService) {
+				svc.On("ListSessions", mock.Anything, service.ListTrashInput{
+					ProjectID: projectID,
+					Limit:     20,
+				}).Return(&service.ListSessionsOutput{Items: []model.Session{{ID: uuid.New(), ProjectID: projectID}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "next page",
+			query: "?limit=5&cursor=abc",
+			setup: func(svc *MockTrashService) {
+				svc.On("ListSessions", mock.Anything, service.ListTrashInput{
+					ProjectID: projectID,
+					Limit:     5,
+					Cursor:    "abc",
+				}).Return(&service.ListSessionsOutput{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "limit too large",
+			query:          "?limit=500",
+			setup:          func(svc *MockTrashService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "bad cursor",
+			query: "?cursor=%25%25",
+			setup: func(svc *MockTrashService) {
+				svc.On("ListSessions", mock.Anything, mock.Anything).Return(nil, paging.ErrInvalidCursor)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockTrashService{}
+			tt.setup(svc)
+			h := NewTrashHandler(svc)
+
+			router := setupDiskRouter()
+			router.Use(func(c *gin.Context) { c.Set("project", &model.Project{ID: projectID}) })
+			router.GET("/session/trash", h.ListTrashedSessions)
+
+			req := httptest.NewRequest(http.MethodGet, "/session/trash"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTrashHandler_Restore(t *testing.T) {
+	projectID := uuid.New()
+	id := uuid.New()
+
+	tests := []struct {
+		name           string
+		route          string
+		path           string
+		handler        func(*TrashHandler) gin.HandlerFunc
+		setup          func(*MockTrashService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:    "session",
+			route:   "/session/:session_id/restore",
+			path:    "/session/" + id.String() + "/restore",
+			handler: func(h *TrashHandler) gin.HandlerFunc { return h.RestoreSession },
+			setup: func(svc *MockTrashService) {
+				svc.On("RestoreSession", mock.Anything, projectID, id).Return(&model.Session{ID: id, ProjectID: projectID}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "session not in the trash",
+			route:   "/session/:session_id/restore",
+			path:    "/session/" + id.String() + "/restore",
+			handler: func(h *TrashHandler) gin.HandlerFunc { return h.RestoreSession },
+			setup: func(svc *MockTrashService) {
+				svc.On("RestoreSession", mock.Anything, projectID, id).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "session_not_found",
+		},
+		{
+			name:           "invalid session id",
+			route:          "/session/:session_id/restore",
+			path:           "/session/not-a-uuid/restore",
+			handler:        func(h *TrashHandler) gin.HandlerFunc { return h.RestoreSession },
+			setup:          func(svc *MockTrashService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+		},
+		{
+			name:    "space not in the trash",
+			route:   "/space/:space_id/restore",
+			path:    "/space/" + id.String() + "/restore",
+			handler: func(h *TrashHandler) gin.HandlerFunc { return h.RestoreSpace },
+			setup: func(svc *MockTrashService) {
+				svc.On("RestoreSpace", mock.Anything, projectID, id).Return(nil, gorm.ErrRecordNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "space_not_found",
+		},
+		{
+			name:    "disk",
+			route:   "/disk/:disk_id/restore",
+			path:    "/disk/" + id.String() + "/restore",
+			handler: func(h *TrashHandler) gin.HandlerFunc { return h.RestoreDisk },
+			setup: func(svc *MockTrashService) {
+				svc.On("RestoreDisk", mock.Anything, projectID, id).Return(&model.Disk{ID: id, ProjectID: projectID}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "disk name taken meanwhile",
+			route:   "/disk/:disk_id/restore",
+			path:    "/disk/" + id.String() + "/restore",
+			handler: func(h *TrashHandler) gin.HandlerFunc { return h.RestoreDisk },
+			setup: func(svc *MockTrashService) {
+				svc.On("RestoreDisk", mock.Anything, projectID, id).Return(nil, service.ErrDiskNameExists)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "disk_name_conflict",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockTrashService{}
+			tt.setup(svc)
+			h := NewTrashHandler(svc)
+
+			router := setupDiskRouter()
+			router.Use(func(c *gin.Context) { c.Set("project", &model.Project{ID: projectID}) })
+			router.POST(tt.route, tt.handler(h))
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var body map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.expectedCode, body["code"])
+			}
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+)
+
+type UsageHandler struct {
+	svc service.UsageService
+	now func() time.Time
+}
+
+func NewUsageHandler(s service.UsageService) *UsageHandler {
+	return &UsageHandler{svc: s, now: time.Now}
+}
+
+type GetUsageReq struct {
+	From   string `form:"from" json:"from" example:"2025-01-01"`                                                   // RFC 3339 time or date, defaults to the start of the current month (UTC)
+	To     string `form:"to" json:"to" example:"2025-02-01"`                                                       // RFC 3339 time or date, exclusive, defaults to now
+	Bucket string `form:"bucket,default=day" json:"bucket" binding:"omitempty,oneof=day week month" example:"day"` // Bucket size: day, week or month
+}
+
+// parseUsageTime accepts an RFC 3339 time or a bare date, which is taken as midnight UTC
+func parseUsageTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// GetUsage godoc
+//
+//	@Summary		Get project usage
+//	@Description	Get the usage counters of the project per time bucket, the storage its assets take up and its usage against its soft and hard limits
+//	@Tags			project
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query	string	false	"Start of the range, RFC 3339 time or date. Defaults to the start of the current month (UTC)"	example(2025-01-01)
+//	@Param			to		query	string	false	"End of the range, exclusive. Defaults to now"													example(2025-02-01)
+//	@Param			bucket	query	string	false	"Bucket size: day, week or month. Defaults to day"												example(day)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ProjectUsage}
+//	@Router			/project/usage [get]
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	req := GetUsageReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	now := h.now().UTC()
+	in := service.GetUsageInput{
+		ProjectID: project.ID,
+		From:      time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+		To:        now,
+		Bucket:    req.Bucket,
+	}
+	var err error
+	if req.From != "" {
+		if in.From, err = parseUsageTime(req.From); err != nil {
+			serializer.ParamErr(c, "invalid from", err)
+			return
+		}
+	}
+	if req.To != "" {
+		if in.To, err = parseUsageTime(req.To); err != nil {
+			serializer.ParamErr(c, "invalid to", err)
+			return
+		}
+	}
+
+	usage, err := h.svc.Get(c.Request.Context(), in)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidUsageQuery) {
+			serializer.ParamErr(c, "", err)
+			return
+		}
+		serializer.DBErr(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: usage})
+}
+
+// usageLimitErr answers a write rejected by a usage hard limit and reports whether err was one.
+// The storage limit only clears once data is deleted, so it is forbidden; monthly limits are
+// rate limits that clear when the next month starts.
+func usageLimitErr(c *gin.Context, err error) bool {
+	var limitErr *service.UsageLimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	ext := map[string]any{"limit": limitErr.Limit, "used": limitErr.Used, "adding": limitErr.Adding, "hard": limitErr.Hard}
+	if errors.Is(err, service.ErrStorageLimitExceeded) {
+		serializer.ErrWith(c, apierr.StorageLimitExceeded, limitErr.Error(), err, ext)
+		return true
+	}
+	if limitErr.ResetAt != nil {
+		retryAfter := int64(time.Until(*limitErr.ResetAt).Seconds()) + 1
+		c.Header("Retry-After", strconv.FormatInt(max(retryAfter, 1), 10))
+		ext["reset_at"] = limitErr.ResetAt
+	}
+	serializer.ErrWith(c, apierr.QuotaExceeded, limitErr.Error(), err, ext)
+	return true
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUsageService is a mock implementation of UsageService
+type MockUsageService struct {
+	mock.Mock
+}
+
+func (m *MockUsageService) Check(ctx context.Context, projectID uuid.UUID, demand service.UsageDemand) error {
+	args := m.Called(ctx, projectID, demand)
+	return args.Error(0)
+}
+
+func (m *MockUsageService) Record(ctx context.Context, projectID uuid.UUID, counters map[string]int64) {
+	m.Called(ctx, projectID, counters)
+}
+
+func (m *MockUsageService) Get(ctx context.Context, in service.GetUsageInput) (*service.ProjectUsage, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ProjectUsage), args.Error(1)
+}
+
+func (m *MockUsageService) Summary(ctx context.Context, projectID uuid.UUID) (*service.UsageSummary, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.UsageSummary), args.Error(1)
+}
+
+func TestUsageHandler_GetUsage(t *testing.T) {
+	projectID := uuid.New()
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		setup          func(*MockUsageService)
+		expectedStatus int
+	}{
+		{
+			name:  "defaults to the current month by day",
+			query: "",
+			setup: func(svc *MockUsageService) {
+				svc.On("Get", mock.Anything, service.GetUsageInput{
+					ProjectID: projectID,
+					From:      time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+					To:        now,
+					Bucket:    "day",
+				}).Return(&service.ProjectUsage{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "dates and times",
+			query: "?from=2025-01-01&to=2025-02-01T00:00:00Z&bucket=month",
+			setup: func(svc *MockUsageService) {
+				svc.On("Get", mock.Anything, service.GetUsageInput{
+					ProjectID: projectID,
+					From:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:        time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+					Bucket:    "month",
+				}).Return(&service.ProjectUsage{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown bucket",
+			query:          "?bucket=hour",
+			setup:          func(svc *MockUsageService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid from",
+			query:          "?from=yesterday",
+			setup:          func(svc *MockUsageService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "empty range",
+			query: "?from=2025-03-01&to=2025-02-01",
+			setup: func(svc *MockUsageService) {
+				svc.On("Get", mock.Anything, mock.Anything).Return(nil, service.ErrInvalidUsageQuery)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockUsageService{}
+			tt.setup(svc)
+			h := NewUsageHandler(svc)
+			h.now = func() time.Time { return now }
+
+			router := setupDiskRouter()
+			router.Use(func(c *gin.Context) { c.Set("project", &model.Project{ID: projectID}) })
+			router.GET("/project/usage", h.GetUsage)
+
+			req := httptest.NewRequest(http.MethodGet, "/project/usage"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUsageLimitErr(t *testing.T) {
+	t.Run("monthly limits are rate limits", func(t *testing.T) {
+		resetAt := time.Now().Add(time.Hour)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		handled := usageLimitErr(c, &service.UsageLimitError{Limit: service.UsageLimitMonthlyTokens, Used: 100, Adding: 5, Hard: 100, ResetAt: &resetAt})
+
+		assert.True(t, handled)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+		require.NoError(t, err)
+		assert.InDelta(t, 3600, retryAfter, 5)
+		assert.Contains(t, w.Body.String(), "monthly_tokens")
+	})
+
+	t.Run("other errors are left alone", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+
+		assert.False(t, usageLimitErr(c, service.ErrDiskQuotaExceeded))
+	})
+}
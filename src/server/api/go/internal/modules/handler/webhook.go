@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+)
+
+type WebhookHandler struct {
+	svc service.WebhookService
+}
+
+func NewWebhookHandler(s service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{svc: s}
+}
+
+type CreateWebhookReq struct {
+	URL         string   `json:"url" binding:"required" example:"https://example.com/acontext/webhook"`
+	Description string   `json:"description" example:"Slack notifier"`
+	EventTypes  []string `json:"event_types" binding:"required" example:"task.created,task.updated"` // message.stored, task.created, task.updated, experience.pending, sop.saved, artifact.changed
+	Enabled     *bool    `json:"enabled" example:"true"`                                             // Defaults to true
+}
+
+type UpdateWebhookReq struct {
+	URL         *string  `json:"url" example:"https://example.com/acontext/webhook"`
+	Description *string  `json:"description" example:"Slack notifier"`
+	EventTypes  []string `json:"event_types" example:"task.created,task.updated"`
+	Enabled     *bool    `json:"enabled" example:"false"`
+}
+
+type ListWebhookDeliveriesReq struct {
+	Status   string `form:"status" json:"status" binding:"omitempty,oneof=pending succeeded failed" example:"failed"`
+	Limit    int    `form:"limit,default=20" json:"limit" binding:"required,min=1,max=200" example:"20"`
+	Cursor   string `form:"cursor" json:"cursor" example:"cHJvdGVjdGVkIHZlcnNpb24gdG8gYmUgZXhjbHVkZWQgaW4gcGFyc2luZyB0aGUgY3Vyc29y"`
+	TimeDesc bool   `form:"time_desc,default=false" json:"time_desc" example:"false"`
+}
+
+func webhookEventTypes(types []string) []model.WebhookEventType {
+	if types == nil {
+		return nil
+	}
+	out := make([]model.WebhookEventType, len(types))
+	for i, t := range types {
+		out[i] = model.WebhookEventType(t)
+	}
+	return out
+}
+
+// webhookErr answers the errors of the webhook service
+func webhookErr(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidWebhook):
+		serializer.ParamErr(c, "", err)
+	case errors.Is(err, service.ErrWebhookNotFound):
+		serializer.Err(c, apierr.WebhookNotFound, "webhook not found", err)
+	case errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		serializer.Err(c, apierr.WebhookDeliveryNotFound, "webhook delivery not found", err)
+	case errors.Is(err, service.ErrWebhookDisabled):
+		serializer.Err(c, apierr.WebhookDisabled, err.Error(), err)
+	default:
+		serializer.DBErr(c, "", err)
+	}
+}
+
+// webhookParams parses the project and the :webhook_id param of a request
+func webhookParams(c *gin.Context) (*model.Project, uuid.UUID, bool) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return nil, uuid.Nil, false
+	}
+	webhookID, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return nil, uuid.Nil, false
+	}
+	return project, webhookID, true
+}
+
+// CreateWebhook godoc
+//
+//	@Summary		Create webhook
+//	@Description	Subscribe a URL to events of the project. Every delivery is a POST of the event as JSON, signed in the Acontext-Signature header as "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">" with the secret of the webhook.
+//	@Description	Deliveries that are not answered with a 2xx are retried with backoff. The secret is only returned once.
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	handler.CreateWebhookReq	true	"CreateWebhook payload"
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=service.CreateWebhookOutput}
+//	@Router			/webhook [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	req := CreateWebhookReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	out, err := h.svc.Create(c.Request.Context(), service.CreateWebhookInput{
+		ProjectID:   project.ID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  webhookEventTypes(req.EventTypes),
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	})
+	if err != nil {
+		webhookErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: out})
+}
+
+// ListWebhooks godoc
+//
+//	@Summary		List webhooks
+//	@Description	List the webhooks of the project
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=[]model.WebhookEndpoint}
+//	@Router			/webhook [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	project, ok := c.MustGet("project").(*model.Project)
+	if !ok {
+		serializer.ParamErr(c, "", errors.New("project not found"))
+		return
+	}
+
+	webhooks, err := h.svc.List(c.Request.Context(), project.ID)
+	if err != nil {
+		webhookErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: webhooks})
+}
+
+// GetWebhook godoc
+//
+//	@Summary		Get webhook
+//	@Description	Get a webhook by its ID
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook_id	path	string	true	"Webhook ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.WebhookEndpoint}
+//	@Router			/webhook/{webhook_id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	project, webhookID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	w, err := h.svc.Get(c.Request.Context(), project.ID, webhookID)
+	if err != nil {
+		webhookErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: w})
+}
+
+// UpdateWebhook godoc
+//
+//	@Summary		Update webhook
+//	@Description	Change the URL, description, event types or enabled flag of a webhook. Omitted fields are kept.
+//	@Description	Pending deliveries of a disabled webhook are marked failed; they can be replayed once it is enabled again.
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook_id	path	string						true	"Webhook ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			payload		body	handler.UpdateWebhookReq	true	"UpdateWebhook payload"
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=model.WebhookEndpoint}
+//	@Router			/webhook/{webhook_id} [patch]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	project, webhookID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	req := UpdateWebhookReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	w, err := h.svc.Update(c.Request.Context(), service.UpdateWebhookInput{
+		ProjectID:   project.ID,
+		ID:          webhookID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  webhookEventTypes(req.EventTypes),
+		Enabled:     req.Enabled,
+	})
+	if err != nil {
+		webhookErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: w})
+}
+
+// DeleteWebhook godoc
+//
+//	@Summary		Delete webhook
+//	@Description	Delete a webhook with its delivery log. Pending deliveries are dropped.
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook_id	path	string	true	"Webhook ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{}
+//	@Router			/webhook/{webhook_id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	project, webhookID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), project.ID, webhookID); err != nil {
+		webhookErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{})
+}
+
+// ListWebhookDeliveries godoc
+//
+//	@Summary		List webhook deliveries
+//	@Description	List the deliveries of a webhook with the outcome of their last attempt
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook_id	path	string	true	"Webhook ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			status		query	string	false	"Only deliveries with this status"	Enums(pending, succeeded, failed)
+//	@Param			limit		query	integer	false	"Limit of deliveries to return, default 20. Max 200."
+//	@Param			cursor		query	string	false	"Cursor for pagination. Use the cursor from the previous response to get the next page."
+//	@Param			time_desc	query	string	false	"Order by created_at descending if true, ascending if false (default false)"	example(false)
+//	@Security		BearerAuth
+//	@Success		200	{object}	serializer.Response{data=service.ListWebhookDeliveriesOutput}
+//	@Router			/webhook/{webhook_id}/delivery [get]
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	project, webhookID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+
+	req := ListWebhookDeliveriesReq{}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	out, err := h.svc.ListDeliveries(c.Request.Context(), service.ListWebhookDeliveriesInput{
+		ProjectID: project.ID,
+		WebhookID: webhookID,
+		Status:    req.Status,
+		Limit:     req.Limit,
+		Cursor:    req.Cursor,
+		TimeDesc:  req.TimeDesc,
+	})
+	if err != nil {
+		webhookErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Response{Data: out})
+}
+
+// ReplayWebhookDelivery godoc
+//
+//	@Summary		Replay webhook delivery
+//	@Description	Send the event of a delivery to the webhook again, as a new delivery with the same event ID
+//	@Tags			webhook
+//	@Accept			json
+//	@Produce		json
+//	@Param			webhook_id	path	string	true	"Webhook ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Param			delivery_id	path	string	true	"Delivery ID"	Format(uuid)	Example(123e4567-e89b-12d3-a456-426614174000)
+//	@Security		BearerAuth
+//	@Success		201	{object}	serializer.Response{data=model.WebhookDelivery}
+//	@Failure		409	{object}	serializer.Problem	"The webhook is disabled"
+//	@Router			/webhook/{webhook_id}/delivery/{delivery_id}/replay [post]
+func (h *WebhookHandler) ReplayWebhookDelivery(c *gin.Context) {
+	project, webhookID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		serializer.ParamErr(c, "", err)
+		return
+	}
+
+	d, err := h.svc.ReplayDelivery(c.Request.Context(), project.ID, webhookID, deliveryID)
+	if err != nil {
+		webhookErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializer.Response{Data: d})
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookService is a mock implementation of WebhookService
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) Create(ctx context.Context, in service.CreateWebhookInput) (*service.CreateWebhookOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.CreateWebhookOutput), args.Error(1)
+}
+
+func (m *MockWebhookService) List(ctx context.Context, projectID uuid.UUID) ([]*model.WebhookEndpoint, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookService) Get(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (*model.WebhookEndpoint, error) {
+	args := m.Called(ctx, projectID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookService) Update(ctx context.Context, in service.UpdateWebhookInput) (*model.WebhookEndpoint, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockWebhookService) Delete(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	args := m.Called(ctx, projectID, id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, in service.ListWebhookDeliveriesInput) (*service.ListWebhookDeliveriesOutput, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ListWebhookDeliveriesOutput), args.Error(1)
+}
+
+func (m *MockWebhookService) ReplayDelivery(ctx context.Context, projectID uuid.UUID, webhookID uuid.UUID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	args := m.Called(ctx, projectID, webhookID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookService) Dispatch(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWebhookService) Deliver(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+
+func setupWebhookRouter(projectID uuid.UUID, h *WebhookHandler) *gin.Engine {
+	router := setupDiskRouter()
+	router.Use(func(c *gin.Context) { c.Set("project", &model.Project{ID: projectID}) })
+	router.POST("/webhook", h.CreateWebhook)
+	router.GET("/webhook/:webhook_id", h.GetWebhook)
+	router.PATCH("/webhook/:webhook_id", h.UpdateWebhook)
+	router.GET("/webhook/:webhook_id/delivery", h.ListWebhookDeliveries)
+	router.POST("/webhook/:webhook_id/delivery/:delivery_id/replay", h.ReplayWebhookDelivery)
+	return router
+}
+
+func TestWebhookHandler_CreateWebhook(t *testing.T) {
+	projectID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		setup          func(*MockWebhookService)
+		expectedStatus int
+	}{
+		{
+			name: "enabled by default",
+			body: `{"url":"https://example.com/hook","event_types":["task.created"]}`,
+			setup: func(svc *MockWebhookService) {
+				svc.On("Create", mock.Anything, service.CreateWebhookInput{
+					ProjectID:  projectID,
+					URL:        "https://example.com/hook",
+					EventTypes: []model.WebhookEventType{model.WebhookEventTaskCreated},
+					Enabled:    true,
+				}).Return(&service.CreateWebhookOutput{Webhook: &model.WebhookEndpoint{}, Secret: "whsec_x"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "created disabled",
+			body: `{"url":"https://example.com/hook","event_types":["sop.saved"],"enabled":false,"description":"slack"}`,
+			setup: func(svc *MockWebhookService) {
+				svc.On("Create", mock.Anything, service.CreateWebhookInput{
+					ProjectID:   projectID,
+					URL:         "https://example.com/hook",
+					Description: "slack",
+					EventTypes:  []model.WebhookEventType{model.WebhookEventSOPSaved},
+				}).Return(&service.CreateWebhookOutput{Webhook: &model.WebhookEndpoint{}, Secret: "whsec_x"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing url",
+			body:           `{"event_types":["task.created"]}`,
+			setup:          func(svc *MockWebhookService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid event type",
+			body: `{"url":"https://example.com/hook","event_types":["nope"]}`,
+			setup: func(svc *MockWebhookService) {
+				svc.On("Create", mock.Anything, mock.Anything).Return(nil, service.ErrInvalidWebhook)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockWebhookService{}
+			tt.setup(svc)
+			router := setupWebhookRouter(projectID, NewWebhookHandler(svc))
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWebhookHandler_Errors(t *testing.T) {
+	projectID, webhookID, deliveryID := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setup          func(*MockWebhookService)
+		expectedStatus int
+	}{
+		{
+			name:           "invalid webhook id",
+			method:         http.MethodGet,
+			path:           "/webhook/not-a-uuid",
+			setup:          func(svc *MockWebhookService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "webhook of another project",
+			method: http.MethodGet,
+			path:   "/webhook/" + webhookID.String(),
+			setup: func(svc *MockWebhookService) {
+				svc.On("Get", mock.Anything, projectID, webhookID).Return(nil, service.ErrWebhookNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "partial update",
+			method: http.MethodPatch,
+			path:   "/webhook/" + webhookID.String(),
+			body:   `{"enabled":false}`,
+			setup: func(svc *MockWebhookService) {
+				enabled := false
+				svc.On("Update", mock.Anything, service.UpdateWebhookInput{
+					ProjectID: projectID,
+					ID:        webhookID,
+					Enabled:   &enabled,
+				}).Return(&model.WebhookEndpoint{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown delivery status",
+			method:         http.MethodGet,
+			path:           "/webhook/" + webhookID.String() + "/delivery?status=lost",
+			setup:          func(svc *MockWebhookService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "failed deliveries",
+			method: http.MethodGet,
+			path:   "/webhook/" + webhookID.String() + "/delivery?status=failed&time_desc=true",
+			setup: func(svc *MockWebhookService) {
+				svc.On("ListDeliveries", mock.Anything, service.ListWebhookDeliveriesInput{
+					ProjectID: projectID,
+					WebhookID: webhookID,
+					Status:    model.WebhookDeliveryFailed,
+					Limit:     20,
+					TimeDesc:  true,
+				}).Return(&service.ListWebhookDeliveriesOutput{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "replay",
+			method: http.MethodPost,
+			path:   "/webhook/" + webhookID.String() + "/delivery/" + deliveryID.String() + "/replay",
+			setup: func(svc *MockWebhookService) {
+				svc.On("ReplayDelivery", mock.Anything, projectID, webhookID, deliveryID).Return(&model.WebhookDelivery{}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "replay to a disabled webhook",
+			method: http.MethodPost,
+			path:   "/webhook/" + webhookID.String() + "/delivery/" + deliveryID.String() + "/replay",
+			setup: func(svc *MockWebhookService) {
+				svc.On("ReplayDelivery", mock.Anything, projectID, webhookID, deliveryID).Return(nil, service.ErrWebhookDisabled)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "replay of an unknown delivery",
+			method: http.MethodPost,
+			path:   "/webhook/" + webhookID.String() + "/delivery/" + deliveryID.String() + "/replay",
+			setup: func(svc *MockWebhookService) {
+				svc.On("ReplayDelivery", mock.Anything, projectID, webhookID, deliveryID).Return(nil, service.ErrWebhookDeliveryNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &MockWebhookService{}
+			tt.setup(svc)
+			router := setupWebhookRouter(projectID, NewWebhookHandler(svc))
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			svc.AssertExpectations(t)
+		})
+	}
+}
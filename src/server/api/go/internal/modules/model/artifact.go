@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // Reserved metadata keys that are not allowed in user metadata
@@ -21,17 +22,50 @@ func GetReservedKeys() []string {
 
 type Disk struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	ProjectID uuid.UUID `gorm:"type:uuid;not null;index" json:"project_id"`
+	ProjectID uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_disk_project_live_name,where:deleted_at IS NULL" json:"project_id"`
+
+	// Name is unique among the disks of a project that are not in the trash, when set
+	Name        *string           `gorm:"type:text;uniqueIndex:idx_disk_project_live_name,where:deleted_at IS NULL" json:"name"`
+	Description string            `gorm:"type:text;not null;default:''" json:"description"`
+	Meta        datatypes.JSONMap `gorm:"type:jsonb" swaggertype:"object" json:"meta"`
+
+	// Quotas on the artifacts of the disk, nil means unlimited
+	MaxBytes *int64 `json:"max_bytes"`
+	MaxFiles *int64 `json:"max_files"`
 
 	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
+	// DeletedAt is set when the disk is moved to the trash. Its artifacts, and the references to
+	// their assets, are kept until the trash purge removes it.
+	DeletedAt gorm.DeletedAt `gorm:"index" swaggertype:"string" json:"deleted_at"`
+
 	// Disk <-> Project
 	Project *Project `gorm:"foreignKey:ProjectID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
 }
 
 func (Disk) TableName() string { return "disks" }
 
+// DiskUsage is the space taken by the artifacts of a disk, with the disk quotas
+type DiskUsage struct {
+	Files    int64  `json:"files"`
+	Bytes    int64  `json:"bytes"`
+	MaxFiles *int64 `json:"max_files"`
+	MaxBytes *int64 `json:"max_bytes"`
+}
+
+// Allows reports whether adding files and bytes keeps the disk within its quotas.
+// Negative values free space, as when a file is replaced by a smaller one.
+func (u *DiskUsage) Allows(files int64, bytes int64) bool {
+	if u.MaxFiles != nil && files > 0 && u.Files+files > *u.MaxFiles {
+		return false
+	}
+	if u.MaxBytes != nil && bytes > 0 && u.Bytes+bytes > *u.MaxBytes {
+		return false
+	}
+	return true
+}
+
 type Artifact struct {
 	ID        uuid.UUID                 `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"-"`
 	DiskID    uuid.UUID                 `gorm:"type:uuid;not null;index;uniqueIndex:idx_disk_path_filename" json:"disk_id"`
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ArtifactChunk is an embedded slice of a parsable artifact used for semantic search.
+// Chunks are replaced whenever the artifact content changes and are removed with the artifact.
+type ArtifactChunk struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	DiskID     uuid.UUID `gorm:"type:uuid;not null;index:idx_artifact_chunks_disk_model,priority:1" json:"disk_id"`
+	ArtifactID uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	ChunkIndex int       `gorm:"type:integer;not null" json:"chunk_index"`
+	StartLine  int       `gorm:"type:integer;not null" json:"start_line"`
+	EndLine    int       `gorm:"type:integer;not null" json:"end_line"`
+	Content    string    `gorm:"type:text;not null" json:"content"`
+
+	// Embedding model that produced the vector; only vectors of the same model are compared
+	EmbeddingModel string `gorm:"type:text;not null;index:idx_artifact_chunks_disk_model,priority:2" json:"embedding_model"`
+	Embedding      Vector `gorm:"type:vector;not null" swaggertype:"array,number" json:"-"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// ArtifactChunk <-> Artifact
+	Artifact *Artifact `gorm:"foreignKey:ArtifactID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (ArtifactChunk) TableName() string { return "artifact_chunks" }
+
+// Vector maps a float32 slice to a pgvector column using its text representation
+type Vector []float32
+
+func (v Vector) Value() (driver.Value, error) {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(f), 'f', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String(), nil
+}
+
+func (v *Vector) Scan(src any) error {
+	var s string
+	switch t := src.(type) {
+	case string:
+		s = t
+	case []byte:
+		s = string(t)
+	case nil:
+		*v = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Vector", src)
+	}
+
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return fmt.Errorf("invalid vector literal: %q", s)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+	if s == "" {
+		*v = Vector{}
+		return nil
+	}
+
+	parts := strings.Split(s, ",")
+	out := make(Vector, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return fmt.Errorf("invalid vector element %q: %w", p, err)
+		}
+		out[i] = float32(f)
+	}
+	*v = out
+	return nil
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVector_ValueAndScan(t *testing.T) {
+	v := Vector{0.5, -1, 0.125}
+
+	val, err := v.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "[0.5,-1,0.125]", val)
+
+	var got Vector
+	assert.NoError(t, got.Scan(val))
+	assert.Equal(t, v, got)
+
+	assert.NoError(t, got.Scan([]byte("[1, 2]")))
+	assert.Equal(t, Vector{1, 2}, got)
+
+	assert.NoError(t, got.Scan("[]"))
+	assert.Equal(t, Vector{}, got)
+
+	assert.Error(t, got.Scan("1,2"))
+	assert.Error(t, got.Scan("[a]"))
+	assert.Error(t, got.Scan(42))
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// UploadStatus is the lifecycle state of a direct upload
+type UploadStatus string
+
+const (
+	UploadStatusPending    UploadStatus = "pending"
+	UploadStatusCommitting UploadStatus = "committing"
+	UploadStatusCommitted  UploadStatus = "committed"
+	UploadStatusAborted    UploadStatus = "aborted"
+	// UploadStatusExpired marks an upload that was never committed and was swept after it expired
+	UploadStatusExpired UploadStatus = "expired"
+)
+
+// ArtifactUpload is an upload slot for a file that the client sends straight to S3.
+// The file lands on a staging key and only becomes an artifact once the upload is committed
+// and its size and SHA256 match what the client declared.
+type ArtifactUpload struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProjectID uuid.UUID `gorm:"type:uuid;not null;index" json:"project_id"`
+	DiskID    uuid.UUID `gorm:"type:uuid;not null;index" json:"disk_id"`
+
+	// Target location of the artifact in the disk
+	Path     string `gorm:"type:text;not null" json:"path"`
+	Filename string `gorm:"type:text;not null" json:"filename"`
+
+	// Declared by the client and verified on commit
+	MIME   string `gorm:"type:text;not null" json:"mime"`
+	SizeB  int64  `gorm:"not null" json:"size_b"`
+	SHA256 string `gorm:"type:char(64);not null" json:"sha256"`
+
+	UserMeta datatypes.JSONMap `gorm:"type:jsonb" swaggertype:"object" json:"meta,omitempty"`
+
+	StagingKey string `gorm:"type:text;not null" json:"-"`
+	// MultipartUploadID is the S3 upload ID, nil for single PUT uploads or once the parts are assembled
+	MultipartUploadID *string `gorm:"type:text" json:"-"`
+	PartSize          int64   `gorm:"not null;default:0" json:"part_size,omitempty"`
+	PartCount         int32   `gorm:"not null;default:0" json:"part_count,omitempty"`
+
+	Status    UploadStatus `gorm:"type:text;not null;index" json:"status"`
+	ExpiresAt time.Time    `gorm:"not null;index" json:"expires_at"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// ArtifactUpload <-> Disk
+	Disk *Disk `gorm:"foreignKey:DiskID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (ArtifactUpload) TableName() string { return "artifact_uploads" }
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Who made an audited request
+const (
+	AuditActorAPIKey        = "api_key"
+	AuditActorProjectSecret = "project_secret"
+	AuditActorRoot          = "root"
+	AuditActorShare         = "share"
+	AuditActorPresignedURL  = "presigned_url"
+)
+
+// AuditEvent records one mutating API request. Events are only ever appended, and removed once
+// they are older than the retention period. They keep no foreign key to the project, so they
+// outlive what they describe.
+type AuditEvent struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProjectID uuid.UUID  `gorm:"type:uuid;not null;index:idx_audit_event_project_id_created_at,priority:1" json:"project_id"`
+	Actor     string     `gorm:"type:varchar(32);not null" json:"actor"`
+	APIKeyID  *uuid.UUID `gorm:"type:uuid;index" json:"api_key_id,omitempty"`
+
+	Method string `gorm:"type:varchar(16);not null" json:"method"`
+	Route  string `gorm:"type:text;not null" json:"route"`
+	Status int    `gorm:"not null" json:"status"`
+
+	// ResourceType is the route group the request went to, e.g. "session"
+	ResourceType string `gorm:"type:varchar(64);not null;index" json:"resource_type"`
+	// ResourceIDs holds the IDs in the route params, and the ID of the resource the request
+	// created or changed under "id"
+	ResourceIDs datatypes.JSONMap `gorm:"type:jsonb" swaggertype:"object" json:"resource_ids"`
+	Before      datatypes.JSONMap `gorm:"type:jsonb" swaggertype:"object" json:"before,omitempty"`
+	After       datatypes.JSONMap `gorm:"type:jsonb" swaggertype:"object" json:"after,omitempty"`
+
+	TraceID  string `gorm:"type:varchar(32)" json:"trace_id,omitempty"`
+	ClientIP string `gorm:"type:varchar(64)" json:"client_ip"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP;index:idx_audit_event_project_id_created_at,priority:2" json:"created_at"`
+}
+
+func (AuditEvent) TableName() string { return "audit_events" }
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SharePermission controls what a share link holder can do below the shared path
+type SharePermission string
+
+const (
+	SharePermissionRead      SharePermission = "read"
+	SharePermissionReadWrite SharePermission = "read_write"
+)
+
+// DiskShare is a link that grants unauthenticated access to a directory of a disk.
+// Only an HMAC of the token is stored; the token itself is returned once at creation.
+type DiskShare struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProjectID uuid.UUID `gorm:"type:uuid;not null;index" json:"project_id"`
+	DiskID    uuid.UUID `gorm:"type:uuid;not null;index" json:"disk_id"`
+
+	// Path is the shared directory prefix, both ends are '/'
+	Path       string          `gorm:"type:text;not null" json:"path"`
+	Permission SharePermission `gorm:"type:text;not null" json:"permission"`
+	TokenHMAC  string          `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	AccessCount    int64      `gorm:"not null;default:0" json:"access_count"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// DiskShare <-> Disk
+	Disk *Disk `gorm:"foreignKey:DiskID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+
+	// DiskShare <-> Project
+	Project *Project `gorm:"foreignKey:ProjectID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (DiskShare) TableName() string { return "disk_shares" }
+
+// Active reports whether the share can still be used at the given time
+func (s *DiskShare) Active(now time.Time) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+	return s.ExpiresAt == nil || now.Before(*s.ExpiresAt)
+}
+
+// Covers reports whether a disk path lies within the shared directory
+func (s *DiskShare) Covers(path string) bool {
+	return len(path) >= len(s.Path) && path[:len(s.Path)] == s.Path
+}
+
+// Writable reports whether the share allows uploads and deletes
+func (s *DiskShare) Writable() bool {
+	return s.Permission == SharePermissionReadWrite
+}
@@ -6,6 +6,17 @@ import (
 	"github.com/google/uuid"
 )
 
+// Tags of the usage counters the API records. The core records its own tags, such as
+// "task.created", in the same table.
+const (
+	MetricTagMessageStored  = "message.stored"
+	MetricTagTokenIngested  = "message.tokens"
+	MetricTagBytesIngested  = "storage.bytes_ingested"
+	MetricTagArtifactWrite  = "artifact.write"
+	MetricTagArtifactDelete = "artifact.delete"
+)
+
+// Metric is the running total of one tag for one project and UTC day
 type Metric struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	ProjectID uuid.UUID `gorm:"type:uuid;not null;index:idx_metric_project_id_tag_created_at,priority:1" json:"project_id"`
@@ -7,12 +7,19 @@ import (
 	"gorm.io/datatypes"
 )
 
+// DefaultProjectConfigKey marks the project created at startup for the root token
+const DefaultProjectConfigKey = "__default_init_project__"
+
 type Project struct {
 	ID               uuid.UUID         `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	SecretKeyHMAC    string            `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
 	SecretKeyHashPHC string            `gorm:"type:varchar(255);not null" json:"-"`
 	Configs          datatypes.JSONMap `gorm:"type:jsonb" swaggertype:"object" json:"configs"`
 
+	// DeleteRequestedAt is set when the project is deleted. It stops authenticating at once and is
+	// removed, with its stored objects, by the cleanup worker.
+	DeleteRequestedAt *time.Time `gorm:"index" json:"delete_requested_at,omitempty"`
+
 	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
@@ -33,3 +40,9 @@ type Project struct {
 }
 
 func (Project) TableName() string { return "projects" }
+
+// IsDefault reports whether p is the project created at startup for the root token
+func (p *Project) IsDefault() bool {
+	isDefault, _ := p.Configs[DefaultProjectConfigKey].(bool)
+	return isDefault
+}
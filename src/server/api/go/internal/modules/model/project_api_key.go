@@ -0,0 +1,102 @@
+package model
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// APIKeyScope restricts what a project API key can do. A key without scopes has full access.
+// Resource scopes limit a key to some routes and combine with each other; read_only applies
+// on top of them.
+type APIKeyScope string
+
+const (
+	APIKeyScopeReadOnly APIKeyScope = "read_only"
+	APIKeyScopeSessions APIKeyScope = "sessions"
+	APIKeyScopeDisks    APIKeyScope = "disks"
+)
+
+// apiKeyOpenRoutes can be reached with any key, so clients can check their key works
+var apiKeyOpenRoutes = map[string]bool{"/ping": true}
+
+// apiKeyScopeRoutes are the route prefixes, below /api/v1, each resource scope grants
+var apiKeyScopeRoutes = map[APIKeyScope][]string{
+	APIKeyScopeSessions: {"/session"},
+	APIKeyScopeDisks:    {"/disk"},
+}
+
+// IsValidAPIKeyScope reports whether scope is a known API key scope
+func IsValidAPIKeyScope(scope APIKeyScope) bool {
+	if scope == APIKeyScopeReadOnly {
+		return true
+	}
+	_, ok := apiKeyScopeRoutes[scope]
+	return ok
+}
+
+// ProjectAPIKey is one of the named keys a project is accessed with.
+// Only an HMAC and an Argon2 hash of the secret are stored; the key itself is returned once at creation.
+type ProjectAPIKey struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProjectID uuid.UUID `gorm:"type:uuid;not null;index" json:"project_id"`
+	Name      string    `gorm:"type:text;not null" json:"name"`
+
+	// Hint is the end of the secret, so keys can be told apart without revealing them
+	Hint             string `gorm:"type:text;not null" json:"hint"`
+	SecretKeyHMAC    string `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	SecretKeyHashPHC string `gorm:"type:varchar(255);not null" json:"-"`
+
+	Scopes datatypes.JSONSlice[APIKeyScope] `gorm:"type:jsonb;not null;default:'[]'" swaggertype:"array,string" json:"scopes"`
+
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	// RotatedToID is the key that replaced this one when it was rotated
+	RotatedToID *uuid.UUID `gorm:"type:uuid" json:"rotated_to_id,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// ProjectAPIKey <-> Project
+	Project *Project `gorm:"foreignKey:ProjectID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (ProjectAPIKey) TableName() string { return "project_api_keys" }
+
+// Active reports whether the key can still be used at the given time
+func (k *ProjectAPIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Allows reports whether the scopes of the key permit method on route, the path of a
+// route below /api/v1 such as "/session/:session_id/messages"
+func (k *ProjectAPIKey) Allows(method, route string) bool {
+	if apiKeyOpenRoutes[route] {
+		return true
+	}
+	restricted, granted := false, false
+	for _, scope := range k.Scopes {
+		switch scope {
+		case APIKeyScopeReadOnly:
+			if method != http.MethodGet && method != http.MethodHead {
+				return false
+			}
+		default:
+			restricted = true
+			for _, prefix := range apiKeyScopeRoutes[scope] {
+				if route == prefix || strings.HasPrefix(route, prefix+"/") {
+					granted = true
+				}
+			}
+		}
+	}
+	return !restricted || granted
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectAPIKey_Allows(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []APIKeyScope
+		method  string
+		route   string
+		allowed bool
+	}{
+		{"no scopes", nil, http.MethodDelete, "/space/:space_id", true},
+		{"read only get", []APIKeyScope{APIKeyScopeReadOnly}, http.MethodGet, "/session", true},
+		{"read only post", []APIKeyScope{APIKeyScopeReadOnly}, http.MethodPost, "/session", false},
+		{"sessions", []APIKeyScope{APIKeyScopeSessions}, http.MethodPost, "/session/:session_id/messages", true},
+		{"sessions outside", []APIKeyScope{APIKeyScopeSessions}, http.MethodGet, "/disk", false},
+		{"sessions prefix only", []APIKeyScope{APIKeyScopeSessions}, http.MethodGet, "/sessionx", false},
+		{"disks", []APIKeyScope{APIKeyScopeDisks}, http.MethodPut, "/disk/:disk_id/artifact", true},
+		{"sessions and disks", []APIKeyScope{APIKeyScopeSessions, APIKeyScopeDisks}, http.MethodGet, "/disk", true},
+		{"read only disks write", []APIKeyScope{APIKeyScopeDisks, APIKeyScopeReadOnly}, http.MethodPost, "/disk", false},
+		{"read only disks read", []APIKeyScope{APIKeyScopeDisks, APIKeyScopeReadOnly}, http.MethodGet, "/disk/:disk_id", true},
+		{"ping is open", []APIKeyScope{APIKeyScopeDisks}, http.MethodGet, "/ping", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := &ProjectAPIKey{Scopes: tt.scopes}
+			assert.Equal(t, tt.allowed, key.Allows(tt.method, tt.route))
+		})
+	}
+}
+
+func TestProjectAPIKey_Active(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	assert.True(t, (&ProjectAPIKey{}).Active(now))
+	assert.True(t, (&ProjectAPIKey{ExpiresAt: &future}).Active(now))
+	assert.False(t, (&ProjectAPIKey{ExpiresAt: &past}).Active(now))
+	assert.False(t, (&ProjectAPIKey{RevokedAt: &past}).Active(now))
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProjectDataKey is the key the stored objects of a project are encrypted with, wrapped by a
+// master key of the KMS. Only the KMS can unwrap it, so the database alone does not expose it.
+type ProjectDataKey struct {
+	ProjectID uuid.UUID `gorm:"type:uuid;primaryKey" json:"project_id"`
+
+	// KMSKeyID names the master key WrappedKey was wrapped with
+	KMSKeyID   string `gorm:"type:text;not null" json:"kms_key_id"`
+	WrappedKey []byte `gorm:"type:bytea;not null" json:"-"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	// ProjectDataKey <-> Project
+	Project *Project `gorm:"foreignKey:ProjectID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (ProjectDataKey) TableName() string { return "project_data_keys" }
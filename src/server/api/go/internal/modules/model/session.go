@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type Session struct {
@@ -17,6 +18,10 @@ type Session struct {
 	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
+	// DeletedAt is set when the session is moved to the trash. Queries skip it from then on, and
+	// the trash purge removes it with its messages once the retention window has passed.
+	DeletedAt gorm.DeletedAt `gorm:"index" swaggertype:"string" json:"deleted_at"`
+
 	// Session <-> Project
 	Project *Project `gorm:"foreignKey:ProjectID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
 
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// SessionEventType names a change in a session that is streamed to its watchers
+type SessionEventType string
+
+const (
+	SessionEventMessageCreated SessionEventType = "message.created"
+	// SessionEventMessageStatus is sent when the task processing status of a message changes
+	SessionEventMessageStatus SessionEventType = "message.status"
+	SessionEventTaskCreated   SessionEventType = "task.created"
+	SessionEventTaskUpdated   SessionEventType = "task.updated"
+	// SessionEventLearningProgress is sent when the learning status of the session changes
+	SessionEventLearningProgress SessionEventType = "learning.progress"
+)
+
+// SessionEvent is a change in a session, written by database triggers in the transaction that
+// made it. The relay numbers the events as it publishes them, so Seq orders them as watchers
+// see them and can be used to resume a stream. Events are removed once older than the retention.
+type SessionEvent struct {
+	ID int64 `gorm:"primaryKey;autoIncrement" json:"-"`
+	// Seq is null until the event is published
+	Seq       *int64            `gorm:"uniqueIndex;index:idx_session_event_session_seq,priority:2;index:idx_session_event_unpublished,where:seq IS NULL" json:"seq"`
+	SessionID uuid.UUID         `gorm:"type:uuid;not null;index:idx_session_event_session_seq,priority:1" json:"session_id"`
+	Type      SessionEventType  `gorm:"type:text;not null" json:"type"`
+	Data      datatypes.JSONMap `gorm:"type:jsonb;not null" swaggertype:"object" json:"data"`
+	CreatedAt time.Time         `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP;index" json:"created_at"`
+
+	// SessionEvent <-> Session
+	Session *Session `gorm:"foreignKey:SessionID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (SessionEvent) TableName() string { return "session_events" }
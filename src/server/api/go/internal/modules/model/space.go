@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type Space struct {
@@ -15,6 +16,10 @@ type Space struct {
 	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 
+	// DeletedAt is set when the space is moved to the trash. Sessions stay connected to it until
+	// the trash purge removes it.
+	DeletedAt gorm.DeletedAt `gorm:"index" swaggertype:"string" json:"deleted_at"`
+
 	// Space <-> Project
 	Project *Project `gorm:"foreignKey:ProjectID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
 
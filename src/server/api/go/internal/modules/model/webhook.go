@@ -0,0 +1,136 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// WebhookEventType names something that happened in a project that webhooks can subscribe to
+type WebhookEventType string
+
+const (
+	WebhookEventMessageStored     WebhookEventType = "message.stored"
+	WebhookEventTaskCreated       WebhookEventType = "task.created"
+	WebhookEventTaskUpdated       WebhookEventType = "task.updated"
+	WebhookEventExperiencePending WebhookEventType = "experience.pending"
+	WebhookEventSOPSaved          WebhookEventType = "sop.saved"
+	WebhookEventArtifactChanged   WebhookEventType = "artifact.changed"
+)
+
+// WebhookEventTypes are the event types webhooks can subscribe to
+var WebhookEventTypes = []WebhookEventType{
+	WebhookEventMessageStored,
+	WebhookEventTaskCreated,
+	WebhookEventTaskUpdated,
+	WebhookEventExperiencePending,
+	WebhookEventSOPSaved,
+	WebhookEventArtifactChanged,
+}
+
+// IsValidWebhookEventType reports whether t is a known webhook event type
+func IsValidWebhookEventType(t WebhookEventType) bool {
+	for _, known := range WebhookEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookEndpoint is a URL of a project that is sent the events it subscribes to
+type WebhookEndpoint struct {
+	ID          uuid.UUID                             `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ProjectID   uuid.UUID                             `gorm:"type:uuid;not null;index" json:"project_id"`
+	URL         string                                `gorm:"type:text;not null" json:"url"`
+	Description string                                `gorm:"type:text;not null;default:''" json:"description"`
+	EventTypes  datatypes.JSONSlice[WebhookEventType] `gorm:"type:jsonb;not null;default:'[]'" swaggertype:"array,string" json:"event_types"`
+	Enabled     bool                                  `gorm:"not null" json:"enabled"`
+
+	// Secret signs the deliveries. It is only returned at creation.
+	Secret string `gorm:"type:text;not null" json:"-"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// WebhookEndpoint <-> Project
+	Project *Project `gorm:"foreignKey:ProjectID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (WebhookEndpoint) TableName() string { return "webhook_endpoints" }
+
+// Subscribes reports whether the endpoint is sent events of type t
+func (w *WebhookEndpoint) Subscribes(t WebhookEventType) bool {
+	if !w.Enabled {
+		return false
+	}
+	for _, subscribed := range w.EventTypes {
+		if subscribed == t {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookOutbox holds the events waiting to be fanned out to the endpoints of their project.
+// Rows are written by database triggers in the transaction that made the change, whichever
+// service made it, so an event is recorded if and only if the change was committed.
+type WebhookOutbox struct {
+	ID        int64             `gorm:"primaryKey;autoIncrement"`
+	EventID   uuid.UUID         `gorm:"type:uuid;not null;default:gen_random_uuid()"`
+	ProjectID uuid.UUID         `gorm:"type:uuid;not null"`
+	EventType WebhookEventType  `gorm:"type:text;not null"`
+	Data      datatypes.JSONMap `gorm:"type:jsonb;not null"`
+	CreatedAt time.Time         `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP"`
+}
+
+func (WebhookOutbox) TableName() string { return "webhook_outbox" }
+
+// WebhookEvent is the body of a delivery
+type WebhookEvent struct {
+	ID        uuid.UUID        `json:"id"`
+	Type      WebhookEventType `json:"type"`
+	ProjectID uuid.UUID        `json:"project_id"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      map[string]any   `json:"data"`
+}
+
+// WebhookDelivery is the sending of one event to one endpoint, and the log of its attempts.
+// A replay is a new delivery of the same event, which keeps its event ID.
+type WebhookDelivery struct {
+	ID        uuid.UUID        `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	WebhookID uuid.UUID        `gorm:"type:uuid;not null;index:idx_webhook_delivery_webhook_created,priority:1" json:"webhook_id"`
+	EventID   uuid.UUID        `gorm:"type:uuid;not null;index" json:"event_id"`
+	EventType WebhookEventType `gorm:"type:text;not null" json:"event_type"`
+	// Payload is the WebhookEvent sent by every attempt, and by replays
+	Payload datatypes.JSON `gorm:"type:jsonb;not null" swaggertype:"object" json:"payload"`
+
+	Status        string    `gorm:"type:text;not null;check:status IN ('pending','succeeded','failed');index:idx_webhook_delivery_due,priority:1" json:"status"`
+	Attempts      int       `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_webhook_delivery_due,priority:2" json:"next_attempt_at"`
+
+	// The outcome of the last attempt
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	ResponseStatus *int       `json:"response_status,omitempty"`
+	ResponseBody   string     `gorm:"type:text;not null;default:''" json:"response_body"`
+	Error          string     `gorm:"type:text;not null;default:''" json:"error"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+
+	// ReplayOfID is the delivery this one replays
+	ReplayOfID *uuid.UUID `gorm:"type:uuid" json:"replay_of_id,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;not null;default:CURRENT_TIMESTAMP;index:idx_webhook_delivery_webhook_created,priority:2" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+
+	// WebhookDelivery <-> WebhookEndpoint
+	Webhook *WebhookEndpoint `gorm:"foreignKey:WebhookID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;" json:"-"`
+}
+
+func (WebhookDelivery) TableName() string { return "webhook_deliveries" }
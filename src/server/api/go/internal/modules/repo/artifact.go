@@ -2,10 +2,14 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	pathutil "github.com/memodb-io/Acontext/internal/pkg/utils/path"
 	"gorm.io/gorm"
 )
 
@@ -14,9 +18,45 @@ type ArtifactRepo interface {
 	DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error
 	Update(ctx context.Context, a *model.Artifact) error
 	GetByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, error)
-	ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error)
-	GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Artifact, error)
+	ListByPathWithCursor(ctx context.Context, diskID uuid.UUID, path string, recursive bool, depth int, afterPath string, afterFilename string, limit int) ([]*model.Artifact, error)
+	GetDirectoryStats(ctx context.Context, diskID uuid.UUID, path string) ([]pathutil.DirectoryStat, error)
 	ExistsByPathAndFilename(ctx context.Context, diskID uuid.UUID, path string, filename string, excludeID *uuid.UUID) (bool, error)
+	DeleteByPrefix(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string) (int64, error)
+	ApplyBatch(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, ops []ArtifactBatchOp) ([]*model.Artifact, error)
+	GetDiskUsage(ctx context.Context, diskID uuid.UUID) (*model.DiskUsage, error)
+}
+
+type ArtifactBatchOpType string
+
+const (
+	ArtifactBatchOpUpsert ArtifactBatchOpType = "upsert"
+	ArtifactBatchOpDelete ArtifactBatchOpType = "delete"
+	ArtifactBatchOpMove   ArtifactBatchOpType = "move"
+)
+
+// ArtifactBatchOp is a single operation applied by ApplyBatch.
+// Upsert uses Artifact as the new record, delete uses Path and Filename,
+// and move renames Path/Filename to DestPath/DestFilename.
+type ArtifactBatchOp struct {
+	Type         ArtifactBatchOpType
+	Path         string
+	Filename     string
+	DestPath     string
+	DestFilename string
+	Artifact     *model.Artifact
+}
+
+// ErrArtifactExists is returned when a move or a create targets a path that is already taken
+var ErrArtifactExists = errors.New("artifact already exists")
+
+// translateArtifactErr maps unique violations of the artifact path index to ErrArtifactExists
+func translateArtifactErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_disk_path_filename" {
+		return ErrArtifactExists
+	}
+	return err
 }
 
 type artifactRepo struct {
@@ -35,7 +75,7 @@ func (r *artifactRepo) Create(ctx context.Context, projectID uuid.UUID, a *model
 	// Use transaction to ensure atomicity: create artifact and increment reference
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(a).Error; err != nil {
-			return err
+			return translateArtifactErr(err)
 		}
 
 		if err := r.assetReferenceRepo.IncrementAssetRef(ctx, projectID, asset); err != nil {
@@ -86,33 +126,69 @@ func (r *artifactRepo) GetByPath(ctx context.Context, diskID uuid.UUID, path str
 	return &artifact, nil
 }
 
-func (r *artifactRepo) ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error) {
-	var artifacts []*model.Artifact
-	query := r.db.WithContext(ctx).Where("disk_id = ?", diskID)
+func (r *artifactRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Artifact, error) {
+	var artifact model.Artifact
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&artifact).Error; err != nil {
+		return nil, err
+	}
+	return &artifact, nil
+}
+
+// ListByPathWithCursor lists artifacts ordered by (path, filename), starting after the given position.
+// When recursive is true, artifacts in all subdirectories of path are included, limited to
+// depth directory levels below path (0 means unlimited).
+func (r *artifactRepo) ListByPathWithCursor(ctx context.Context, diskID uuid.UUID, path string, recursive bool, depth int, afterPath string, afterFilename string, limit int) ([]*model.Artifact, error) {
+	q := r.db.WithContext(ctx).Where("disk_id = ?", diskID)
 
-	// If path is specified, filter by path
-	if path != "" {
-		query = query.Where("path = ?", path)
+	if !recursive {
+		q = q.Where("path = ?", path)
+	} else {
+		if path != "/" {
+			q = q.Where("path LIKE ? ESCAPE '\\'", escapeLike(path)+"%")
+		}
+		if depth > 0 {
+			// A path at directory depth d has d+1 slashes, so the files directly under path have
+			// Depth(path)+1 and those depth levels below it have Depth(path)+depth+1
+			q = q.Where("(length(path) - length(replace(path, '/', ''))) <= ?", pathutil.Depth(path)+depth+1)
+		}
 	}
 
-	err := query.Find(&artifacts).Error
-	if err != nil {
-		return nil, err
+	// Apply cursor-based pagination filter if cursor is provided
+	if afterPath != "" {
+		q = q.Where("(path > ?) OR (path = ? AND filename > ?)", afterPath, afterPath, afterFilename)
 	}
-	return artifacts, nil
+
+	var artifacts []*model.Artifact
+	return artifacts, q.Order("path ASC, filename ASC").Limit(limit).Find(&artifacts).Error
 }
 
-func (r *artifactRepo) GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error) {
-	var paths []string
-	err := r.db.WithContext(ctx).
+// GetDirectoryStats aggregates file count and total size per distinct path under the given path.
+// Aggregation happens in the database so that artifact rows are never loaded into memory.
+func (r *artifactRepo) GetDirectoryStats(ctx context.Context, diskID uuid.UUID, path string) ([]pathutil.DirectoryStat, error) {
+	q := r.db.WithContext(ctx).
 		Model(&model.Artifact{}).
-		Where("disk_id = ?", diskID).
-		Distinct("path").
-		Pluck("path", &paths).Error
-	if err != nil {
+		Select("path, COUNT(*) AS file_count, COALESCE(SUM((asset_meta->>'size_b')::bigint), 0) AS total_size").
+		Where("disk_id = ?", diskID)
+
+	if path != "" && path != "/" {
+		q = q.Where("path LIKE ? ESCAPE '\\'", escapeLike(path)+"%")
+	}
+
+	var stats []pathutil.DirectoryStat
+	return stats, q.Group("path").Order("path ASC").Scan(&stats).Error
+}
+
+// GetDiskUsage returns the quotas of the disk the artifacts belong to, with its current usage.
+// The usage is only aggregated for a disk with a quota; an unlimited disk reports zero usage.
+func (r *artifactRepo) GetDiskUsage(ctx context.Context, diskID uuid.UUID) (*model.DiskUsage, error) {
+	var disk model.Disk
+	if err := r.db.WithContext(ctx).Select("max_files", "max_bytes").Where("id = ?", diskID).Take(&disk).Error; err != nil {
 		return nil, err
 	}
-	return paths, nil
+	if disk.MaxFiles == nil && disk.MaxBytes == nil {
+		return &model.DiskUsage{}, nil
+	}
+	return queryDiskUsage(r.db.WithContext(ctx), diskID)
 }
 
 func (r *artifactRepo) ExistsByPathAndFilename(ctx context.Context, diskID uuid.UUID, path string, filename string, excludeID *uuid.UUID) (bool, error) {
@@ -133,3 +209,160 @@ func (r *artifactRepo) ExistsByPathAndFilename(ctx context.Context, diskID uuid.
 
 	return count > 0, nil
 }
+
+// DeleteByPrefix deletes all artifacts under the given directory path (recursively)
+// and returns the number of deleted artifacts. The deleted assets are counted by SHA256 in the
+// same statement and their references released within the transaction, so the size of the
+// directory is never held in memory. Objects no longer referenced are deleted after commit.
+func (r *artifactRepo) DeleteByPrefix(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string) (int64, error) {
+	var deleted int64
+	var released []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		where, args := "disk_id = ?", []interface{}{diskID}
+		if path != "/" {
+			where += " AND path LIKE ? ESCAPE '\\'"
+			args = append(args, escapeLike(path)+"%")
+		}
+
+		var counts []struct {
+			SHA256 string
+			N      int
+		}
+		if err := tx.Raw(`WITH deleted AS (
+			DELETE FROM artifacts WHERE `+where+` RETURNING asset_meta->>'sha256' AS sha256
+		) SELECT COALESCE(sha256, '') AS sha256, count(*) AS n FROM deleted GROUP BY sha256`, args...).
+			Scan(&counts).Error; err != nil {
+			return fmt.Errorf("delete artifacts: %w", err)
+		}
+
+		grouped := make(map[string]int, len(counts))
+		for _, c := range counts {
+			deleted += int64(c.N)
+			if c.SHA256 != "" {
+				grouped[c.SHA256] += c.N
+			}
+		}
+
+		var err error
+		if released, err = r.assetReferenceRepo.WithTx(tx).ReleaseAssetCounts(ctx, projectID, grouped); err != nil {
+			return fmt.Errorf("decrement asset references: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// The delete is committed; an object that fails to delete is only an orphan now
+	_ = r.assetReferenceRepo.DeleteReleasedAssets(ctx, released)
+	return deleted, nil
+}
+
+// ApplyBatch applies upsert, delete and move operations in order within a single transaction.
+// If any operation fails, no artifact or reference count changes are committed. Asset reference
+// changes are aggregated and applied once at the end: increments first, so an upsert that
+// replaces a file with identical content never drops the asset. The objects of the assets no
+// longer referenced are only deleted once the transaction has committed.
+func (r *artifactRepo) ApplyBatch(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, ops []ArtifactBatchOp) ([]*model.Artifact, error) {
+	results := make([]*model.Artifact, len(ops))
+	var released []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		increments := make([]model.Asset, 0)
+		decrements := make([]model.Asset, 0)
+
+		for i, op := range ops {
+			switch op.Type {
+			case ArtifactBatchOpUpsert:
+				if op.Artifact == nil {
+					return fmt.Errorf("operation %d: artifact is required", i)
+				}
+				a := op.Artifact
+				a.DiskID = diskID
+
+				var existing model.Artifact
+				err := tx.Where("disk_id = ? AND path = ? AND filename = ?", diskID, a.Path, a.Filename).Take(&existing).Error
+				if err == nil {
+					if err := tx.Delete(&existing).Error; err != nil {
+						return fmt.Errorf("operation %d: delete existing artifact: %w", i, err)
+					}
+					decrements = append(decrements, existing.AssetMeta.Data())
+				} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("operation %d: %w", i, err)
+				}
+
+				if err := tx.Create(a).Error; err != nil {
+					return fmt.Errorf("operation %d: create artifact: %w", i, err)
+				}
+				increments = append(increments, a.AssetMeta.Data())
+				results[i] = a
+
+			case ArtifactBatchOpDelete:
+				var existing model.Artifact
+				if err := tx.Where("disk_id = ? AND path = ? AND filename = ?", diskID, op.Path, op.Filename).Take(&existing).Error; err != nil {
+					return fmt.Errorf("operation %d: %w", i, err)
+				}
+				if err := tx.Delete(&existing).Error; err != nil {
+					return fmt.Errorf("operation %d: delete artifact: %w", i, err)
+				}
+				decrements = append(decrements, existing.AssetMeta.Data())
+
+			case ArtifactBatchOpMove:
+				var existing model.Artifact
+				if err := tx.Where("disk_id = ? AND path = ? AND filename = ?", diskID, op.Path, op.Filename).Take(&existing).Error; err != nil {
+					return fmt.Errorf("operation %d: %w", i, err)
+				}
+
+				var count int64
+				if err := tx.Model(&model.Artifact{}).
+					Where("disk_id = ? AND path = ? AND filename = ?", diskID, op.DestPath, op.DestFilename).
+					Count(&count).Error; err != nil {
+					return fmt.Errorf("operation %d: %w", i, err)
+				}
+				if count > 0 {
+					return fmt.Errorf("operation %d: %w: %s%s", i, ErrArtifactExists, op.DestPath, op.DestFilename)
+				}
+
+				existing.Path = op.DestPath
+				existing.Filename = op.DestFilename
+				if info, ok := existing.Meta[model.ArtifactInfoKey].(map[string]interface{}); ok {
+					info["path"] = op.DestPath
+					info["filename"] = op.DestFilename
+				}
+				if err := tx.Model(&existing).Updates(map[string]interface{}{
+					"path":     existing.Path,
+					"filename": existing.Filename,
+					"meta":     existing.Meta,
+				}).Error; err != nil {
+					return fmt.Errorf("operation %d: move artifact: %w", i, err)
+				}
+				results[i] = &existing
+
+			default:
+				return fmt.Errorf("operation %d: unknown operation type %q", i, op.Type)
+			}
+		}
+
+		refs := r.assetReferenceRepo.WithTx(tx)
+		if err := refs.BatchIncrementAssetRefs(ctx, projectID, increments); err != nil {
+			return fmt.Errorf("increment asset references: %w", err)
+		}
+		var err error
+		if released, err = refs.ReleaseAssetRefs(ctx, projectID, decrements); err != nil {
+			return fmt.Errorf("decrement asset references: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The batch is committed; an object that fails to delete is only an orphan now
+	_ = r.assetReferenceRepo.DeleteReleasedAssets(ctx, released)
+	return results, nil
+}
+
+// escapeLike escapes LIKE wildcard characters so the value is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
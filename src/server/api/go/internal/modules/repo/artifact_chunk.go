@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+)
+
+type ArtifactChunkRepo interface {
+	ReplaceForArtifact(ctx context.Context, artifactID uuid.UUID, chunks []*model.ArtifactChunk) error
+	Search(ctx context.Context, diskID uuid.UUID, path string, embeddingModel string, query model.Vector, limit int) ([]ArtifactChunkHit, error)
+}
+
+// ArtifactChunkHit is a chunk matched by a semantic search, ranked by score
+type ArtifactChunkHit struct {
+	Path       string  `json:"path"`
+	Filename   string  `json:"filename"`
+	ChunkIndex int     `json:"chunk_index"`
+	StartLine  int     `json:"start_line"`
+	EndLine    int     `json:"end_line"`
+	Content    string  `json:"content"`
+	Score      float64 `json:"score"` // Cosine similarity, higher is better
+}
+
+type artifactChunkRepo struct {
+	db *gorm.DB
+}
+
+func NewArtifactChunkRepo(db *gorm.DB) ArtifactChunkRepo {
+	return &artifactChunkRepo{db: db}
+}
+
+// ReplaceForArtifact atomically swaps the chunks of an artifact.
+// If the artifact no longer exists (deleted or replaced before indexing ran), nothing is written.
+func (r *artifactChunkRepo) ReplaceForArtifact(ctx context.Context, artifactID uuid.UUID, chunks []*model.ArtifactChunk) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var artifact model.Artifact
+		if err := tx.Select("id").Where("id = ?", artifactID).Take(&artifact).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if err := tx.Where("artifact_id = ?", artifactID).Delete(&model.ArtifactChunk{}).Error; err != nil {
+			return err
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+
+		return tx.CreateInBatches(chunks, 100).Error
+	})
+}
+
+// Search returns the chunks closest to the query vector by cosine distance.
+// When path is set, only artifacts under that directory are searched.
+func (r *artifactChunkRepo) Search(ctx context.Context, diskID uuid.UUID, path string, embeddingModel string, query model.Vector, limit int) ([]ArtifactChunkHit, error) {
+	q := r.db.WithContext(ctx).
+		Table("artifact_chunks AS c").
+		Select("a.path, a.filename, c.chunk_index, c.start_line, c.end_line, c.content, 1 - (c.embedding <=> ?::vector) AS score", query).
+		Joins("JOIN artifacts AS a ON a.id = c.artifact_id").
+		Where("c.disk_id = ? AND c.embedding_model = ?", diskID, embeddingModel)
+
+	if path != "" && path != "/" {
+		q = q.Where("a.path LIKE ? ESCAPE '\\'", escapeLike(path)+"%")
+	}
+
+	var hits []ArtifactChunkHit
+	return hits, q.Order("score DESC").Limit(limit).Scan(&hits).Error
+}
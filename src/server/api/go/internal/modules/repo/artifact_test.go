@@ -0,0 +1,226 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// setupArtifactTestDB creates a test database connection for artifact tests
+func setupArtifactTestDB(t *testing.T) *gorm.DB {
+	// Skip if no test database is configured
+	dsn := "host=localhost user=acontext password=helloworld dbname=acontext port=15432 sslmode=disable"
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Skip("Test database not available, skipping integration tests")
+		return nil
+	}
+
+	// Auto migrate all required tables
+	err = db.AutoMigrate(
+		&model.Project{},
+		&model.Disk{},
+		&model.Artifact{},
+		&model.AssetReference{},
+	)
+	require.NoError(t, err)
+
+	return db
+}
+
+// cleanupArtifactTestDB cleans up test data
+func cleanupArtifactTestDB(t *testing.T, db *gorm.DB, projectID uuid.UUID) {
+	// Clean up in reverse order of foreign key dependencies
+	db.Exec("DELETE FROM artifacts WHERE disk_id IN (SELECT id FROM disks WHERE project_id = ?)", projectID)
+	db.Exec("DELETE FROM asset_references WHERE project_id = ?", projectID)
+	db.Exec("DELETE FROM disks WHERE project_id = ?", projectID)
+	db.Exec("DELETE FROM projects WHERE id = ?", projectID)
+}
+
+// TestArtifactRepo_ListByPathWithCursor_Depth runs the depth filter of recursive listings
+// against the database
+func TestArtifactRepo_ListByPathWithCursor_Depth(t *testing.T) {
+	db := setupArtifactTestDB(t)
+	if db == nil {
+		return // Test was skipped
+	}
+
+	repo := NewArtifactRepo(db, nil)
+	ctx := context.Background()
+
+	project := &model.Project{
+		ID:               uuid.New(),
+		SecretKeyHMAC:    "test_hmac_artifact",
+		SecretKeyHashPHC: "test_hash_artifact",
+	}
+	require.NoError(t, db.Create(project).Error)
+	defer cleanupArtifactTestDB(t, db, project.ID)
+
+	disk := &model.Disk{ID: uuid.New(), ProjectID: project.ID}
+	require.NoError(t, db.Create(disk).Error)
+
+	for _, path := range []string{"/", "/a/", "/a/b/", "/a/b/c/"} {
+		require.NoError(t, db.Create(&model.Artifact{
+			DiskID:    disk.ID,
+			Path:      path,
+			Filename:  "f.txt",
+			AssetMeta: datatypes.NewJSONType(model.Asset{SizeB: 1}),
+		}).Error)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		depth    int
+		expected []string
+	}{
+		{name: "root depth 1", path: "/", depth: 1, expected: []string{"/", "/a/"}},
+		{name: "root depth 2", path: "/", depth: 2, expected: []string{"/", "/a/", "/a/b/"}},
+		{name: "subdirectory depth 1", path: "/a/", depth: 1, expected: []string{"/a/", "/a/b/"}},
+		{name: "subdirectory depth 2", path: "/a/", depth: 2, expected: []string{"/a/", "/a/b/", "/a/b/c/"}},
+		{name: "unlimited", path: "/a/", depth: 0, expected: []string{"/a/", "/a/b/", "/a/b/c/"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			artifacts, err := repo.ListByPathWithCursor(ctx, disk.ID, tt.path, true, tt.depth, "", "", 100)
+			require.NoError(t, err)
+
+			paths := make([]string, 0, len(artifacts))
+			for _, a := range artifacts {
+				paths = append(paths, a.Path)
+			}
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+}
+
+// TestArtifactRepo_GetDiskUsage tests that usage is only aggregated for disks with a quota
+func TestArtifactRepo_GetDiskUsage(t *testing.T) {
+	db := setupArtifactTestDB(t)
+	if db == nil {
+		return // Test was skipped
+	}
+
+	repo := NewArtifactRepo(db, nil)
+	ctx := context.Background()
+
+	project := &model.Project{
+		ID:               uuid.New(),
+		SecretKeyHMAC:    "test_hmac_artifact_usage",
+		SecretKeyHashPHC: "test_hash_artifact_usage",
+	}
+	require.NoError(t, db.Create(project).Error)
+	defer cleanupArtifactTestDB(t, db, project.ID)
+
+	maxFiles := int64(10)
+	unlimited := &model.Disk{ID: uuid.New(), ProjectID: project.ID}
+	limited := &model.Disk{ID: uuid.New(), ProjectID: project.ID, MaxFiles: &maxFiles}
+	require.NoError(t, db.Create(unlimited).Error)
+	require.NoError(t, db.Create(limited).Error)
+
+	for _, disk := range []*model.Disk{unlimited, limited} {
+		require.NoError(t, db.Create(&model.Artifact{
+			DiskID:    disk.ID,
+			Path:      "/",
+			Filename:  "f.txt",
+			AssetMeta: datatypes.NewJSONType(model.Asset{SizeB: 5}),
+		}).Error)
+	}
+
+	t.Run("unlimited disk reports zero usage", func(t *testing.T) {
+		usage, err := repo.GetDiskUsage(ctx, unlimited.ID)
+		require.NoError(t, err)
+		assert.Equal(t, &model.DiskUsage{}, usage)
+	})
+
+	t.Run("limited disk reports its usage", func(t *testing.T) {
+		usage, err := repo.GetDiskUsage(ctx, limited.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), usage.Files)
+		assert.Equal(t, int64(5), usage.Bytes)
+		assert.Equal(t, &maxFiles, usage.MaxFiles)
+	})
+
+	t.Run("missing disk", func(t *testing.T) {
+		_, err := repo.GetDiskUsage(ctx, uuid.New())
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+// TestArtifactRepo_DeleteByPrefix tests that a recursive delete releases the references of the
+// deleted artifacts and only deletes the objects no artifact outside the directory still uses
+func TestArtifactRepo_DeleteByPrefix(t *testing.T) {
+	db := setupArtifactTestDB(t)
+	if db == nil {
+		return // Test was skipped
+	}
+
+	store := blob.NewMemoryStore(blob.NewURLSigner("http://api.test", []byte("secret")))
+	repo := NewArtifactRepo(db, NewAssetReferenceRepo(db, store))
+	ctx := context.Background()
+
+	project := &model.Project{
+		ID:               uuid.New(),
+		SecretKeyHMAC:    "test_hmac_artifact_delete",
+		SecretKeyHashPHC: "test_hash_artifact_delete",
+	}
+	require.NoError(t, db.Create(project).Error)
+	defer cleanupArtifactTestDB(t, db, project.ID)
+
+	disk := &model.Disk{ID: uuid.New(), ProjectID: project.ID}
+	require.NoError(t, db.Create(disk).Error)
+
+	asset := func(c string) model.Asset {
+		key := "disks/" + project.ID.String() + "/" + c
+		_, err := store.PutObject(ctx, key, "text/plain", strings.NewReader(c), nil)
+		require.NoError(t, err)
+		return model.Asset{S3Key: key, SHA256: strings.Repeat(c, 64), SizeB: 1}
+	}
+	shared, onlyInside := asset("a"), asset("b")
+	for _, a := range []struct {
+		path, filename string
+		asset          model.Asset
+	}{
+		{"/dir/", "1.txt", shared},
+		{"/dir/sub/", "2.txt", onlyInside},
+		{"/dir/sub/", "3.txt", onlyInside},
+		{"/dir_other/", "4.txt", shared},
+	} {
+		require.NoError(t, repo.Create(ctx, project.ID, &model.Artifact{
+			DiskID:    disk.ID,
+			Path:      a.path,
+			Filename:  a.filename,
+			AssetMeta: datatypes.NewJSONType(a.asset),
+		}))
+	}
+
+	deleted, err := repo.DeleteByPrefix(ctx, project.ID, disk.ID, "/dir/")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	var remaining []model.Artifact
+	require.NoError(t, db.Where("disk_id = ?", disk.ID).Find(&remaining).Error)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "/dir_other/", remaining[0].Path, "the underscore must not match as a wildcard")
+
+	var refs []model.AssetReference
+	require.NoError(t, db.Where("project_id = ?", project.ID).Find(&refs).Error)
+	require.Len(t, refs, 1)
+	assert.Equal(t, shared.SHA256, refs[0].SHA256)
+	assert.Equal(t, 1, refs[0].RefCount)
+
+	_, err = store.DownloadFile(ctx, shared.S3Key)
+	assert.NoError(t, err)
+	_, err = store.DownloadFile(ctx, onlyInside.S3Key)
+	assert.Error(t, err, "the object of a released asset is deleted")
+}
@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+)
+
+type ArtifactUploadRepo interface {
+	Create(ctx context.Context, u *model.ArtifactUpload) error
+	Get(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, uploadID uuid.UUID) (*model.ArtifactUpload, error)
+	Transition(ctx context.Context, uploadID uuid.UUID, from model.UploadStatus, to model.UploadStatus) error
+	ClearMultipart(ctx context.Context, uploadID uuid.UUID) error
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]model.ArtifactUpload, error)
+}
+
+type artifactUploadRepo struct{ db *gorm.DB }
+
+func NewArtifactUploadRepo(db *gorm.DB) ArtifactUploadRepo {
+	return &artifactUploadRepo{db: db}
+}
+
+// Create stores an upload after checking that the disk belongs to the upload's project.
+// It returns gorm.ErrRecordNotFound when the disk does not exist in the project.
+func (r *artifactUploadRepo) Create(ctx context.Context, u *model.ArtifactUpload) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var disk model.Disk
+		if err := tx.Where("id = ? AND project_id = ?", u.DiskID, u.ProjectID).First(&disk).Error; err != nil {
+			return err
+		}
+		return tx.Create(u).Error
+	})
+}
+
+func (r *artifactUploadRepo) Get(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, uploadID uuid.UUID) (*model.ArtifactUpload, error) {
+	var upload model.ArtifactUpload
+	if err := r.db.WithContext(ctx).
+		Where("id = ? AND project_id = ? AND disk_id = ?", uploadID, projectID, diskID).
+		First(&upload).Error; err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Transition moves an upload from one status to another in a single conditional update,
+// so concurrent commits and aborts cannot both claim the same upload.
+// It returns gorm.ErrRecordNotFound when the upload is not in the from status.
+func (r *artifactUploadRepo) Transition(ctx context.Context, uploadID uuid.UUID, from model.UploadStatus, to model.UploadStatus) error {
+	res := r.db.WithContext(ctx).Model(&model.ArtifactUpload{}).
+		Where("id = ? AND status = ?", uploadID, from).
+		Update("status", to)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ClearMultipart records that the parts of a multipart upload were assembled into one object
+func (r *artifactUploadRepo) ClearMultipart(ctx context.Context, uploadID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.ArtifactUpload{}).
+		Where("id = ?", uploadID).
+		Update("multipart_upload_id", nil).Error
+}
+
+// ListExpired lists up to limit uploads that are still pending or committing although they
+// expired before before, the longest expired first
+func (r *artifactUploadRepo) ListExpired(ctx context.Context, before time.Time, limit int) ([]model.ArtifactUpload, error) {
+	var uploads []model.ArtifactUpload
+	err := r.db.WithContext(ctx).
+		Where("expires_at < ? AND status IN ?", before, []model.UploadStatus{model.UploadStatusPending, model.UploadStatusCommitting}).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&uploads).Error
+	return uploads, err
+}
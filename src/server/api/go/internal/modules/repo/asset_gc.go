@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AssetUse is how many artifacts or messages of a project use the asset with a given SHA256
+type AssetUse struct {
+	SHA256    string
+	Uses      int
+	AssetMeta datatypes.JSONType[model.Asset]
+}
+
+// AssetGCRepo reads the true uses of assets and repairs asset_references for the asset garbage collector.
+// Repairs only apply to rows that did not change since they were read, so they never override
+// references taken concurrently.
+type AssetGCRepo interface {
+	// ListProjectIDs leaves out the projects being deleted, so their objects are collected as orphans
+	ListProjectIDs(ctx context.Context) ([]uuid.UUID, error)
+	// ArtifactAssetUses counts the artifacts of a project by asset
+	ArtifactAssetUses(ctx context.Context, projectID uuid.UUID) ([]AssetUse, error)
+	// MessagePartsUses counts the messages of a project by the asset holding their parts JSON
+	MessagePartsUses(ctx context.Context, projectID uuid.UUID) ([]AssetUse, error)
+	ListReferences(ctx context.Context, projectID uuid.UUID) ([]model.AssetReference, error)
+	// CreateReference adds a missing reference; it reports false when one was created meanwhile
+	CreateReference(ctx context.Context, projectID uuid.UUID, asset model.Asset, refCount int) (bool, error)
+	// SetRefCount corrects the count of ref; it reports false when ref changed since it was read
+	SetRefCount(ctx context.Context, ref *model.AssetReference, refCount int) (bool, error)
+	// DeleteReference removes ref; it reports false when ref changed since it was read
+	DeleteReference(ctx context.Context, ref *model.AssetReference) (bool, error)
+	// LiveKeys returns the object keys a project still needs: referenced assets, the assets of
+	// artifacts and messages, and the staging objects of unfinished direct uploads that had not
+	// expired yet at uploadsExpiredBefore. Staging objects of abandoned uploads count as orphans.
+	LiveKeys(ctx context.Context, projectID uuid.UUID, uploadsExpiredBefore time.Time) (map[string]struct{}, error)
+}
+
+type assetGCRepo struct {
+	db *gorm.DB
+}
+
+func NewAssetGCRepo(db *gorm.DB) AssetGCRepo {
+	return &assetGCRepo{db: db}
+}
+
+func (r *assetGCRepo) ListProjectIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&model.Project{}).
+		Where("delete_requested_at IS NULL").
+		Order("id").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *assetGCRepo) ArtifactAssetUses(ctx context.Context, projectID uuid.UUID) ([]AssetUse, error) {
+	var uses []AssetUse
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT a.asset_meta->>'sha256' AS sha256, COUNT(*) AS uses, (ARRAY_AGG(a.asset_meta))[1] AS asset_meta
+		FROM artifacts a JOIN disks d ON d.id = a.disk_id
+		WHERE d.project_id = ? AND COALESCE(a.asset_meta->>'sha256', '') <> ''
+		GROUP BY 1`, projectID).Scan(&uses).Error
+	return uses, err
+}
+
+func (r *assetGCRepo) MessagePartsUses(ctx context.Context, projectID uuid.UUID) ([]AssetUse, error) {
+	var uses []AssetUse
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT m.parts_asset_meta->>'sha256' AS sha256, COUNT(*) AS uses, (ARRAY_AGG(m.parts_asset_meta))[1] AS asset_meta
+		FROM messages m JOIN sessions s ON s.id = m.session_id
+		WHERE s.project_id = ? AND COALESCE(m.parts_asset_meta->>'sha256', '') <> ''
+		GROUP BY 1`, projectID).Scan(&uses).Error
+	return uses, err
+}
+
+func (r *assetGCRepo) ListReferences(ctx context.Context, projectID uuid.UUID) ([]model.AssetReference, error) {
+	var refs []model.AssetReference
+	err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("sha256").Find(&refs).Error
+	return refs, err
+}
+
+func (r *assetGCRepo) CreateReference(ctx context.Context, projectID uuid.UUID, asset model.Asset, refCount int) (bool, error) {
+	now := time.Now()
+	res := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Omit(clause.Associations).
+		Create(&model.AssetReference{
+			ProjectID:        projectID,
+			SHA256:           asset.SHA256,
+			S3Key:            asset.S3Key,
+			RefCount:         refCount,
+			AssetMeta:        datatypes.NewJSONType(asset),
+			LastReferencedAt: now,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *assetGCRepo) SetRefCount(ctx context.Context, ref *model.AssetReference, refCount int) (bool, error) {
+	res := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).Model(&model.AssetReference{}).
+		Where("id = ? AND ref_count = ? AND last_referenced_at = ?", ref.ID, ref.RefCount, ref.LastReferencedAt).
+		UpdateColumns(map[string]any{"ref_count": refCount, "updated_at": time.Now()})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *assetGCRepo) DeleteReference(ctx context.Context, ref *model.AssetReference) (bool, error) {
+	res := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).
+		Where("id = ? AND ref_count = ? AND last_referenced_at = ?", ref.ID, ref.RefCount, ref.LastReferencedAt).
+		Delete(&model.AssetReference{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *assetGCRepo) LiveKeys(ctx context.Context, projectID uuid.UUID, uploadsExpiredBefore time.Time) (map[string]struct{}, error) {
+	var keys []string
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT key FROM (
+			SELECT s3_key AS key FROM asset_references WHERE project_id = @project
+			UNION SELECT a.asset_meta->>'s3_key' FROM artifacts a JOIN disks d ON d.id = a.disk_id WHERE d.project_id = @project
+			UNION SELECT m.parts_asset_meta->>'s3_key' FROM messages m JOIN sessions s ON s.id = m.session_id WHERE s.project_id = @project
+			UNION SELECT staging_key FROM artifact_uploads WHERE project_id = @project AND status IN @unfinished AND expires_at > @expired
+		) live WHERE key IS NOT NULL AND key <> ''`,
+		map[string]any{
+			"project":    projectID,
+			"unfinished": []model.UploadStatus{model.UploadStatusPending, model.UploadStatusCommitting},
+			"expired":    uploadsExpiredBefore,
+		}).Scan(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		live[key] = struct{}{}
+	}
+	return live, nil
+}
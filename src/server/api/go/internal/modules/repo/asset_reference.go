@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -18,17 +19,34 @@ type AssetReferenceRepo interface {
 	DecrementAssetRef(ctx context.Context, projectID uuid.UUID, asset model.Asset) error
 	BatchIncrementAssetRefs(ctx context.Context, projectID uuid.UUID, assets []model.Asset) error
 	BatchDecrementAssetRefs(ctx context.Context, projectID uuid.UUID, assets []model.Asset) error
+	// ReleaseAssetRefs decrements reference counts like BatchDecrementAssetRefs, but keeps the
+	// objects of the assets that are no longer referenced and returns their S3 keys, so a caller
+	// within a transaction can delete them with DeleteReleasedAssets once it has committed
+	ReleaseAssetRefs(ctx context.Context, projectID uuid.UUID, assets []model.Asset) ([]string, error)
+	// ReleaseAssetCounts is ReleaseAssetRefs for references already counted by SHA256
+	ReleaseAssetCounts(ctx context.Context, projectID uuid.UUID, counts map[string]int) ([]string, error)
+	DeleteReleasedAssets(ctx context.Context, keys []string) error
+	// WithTx returns the repo working within tx
+	WithTx(tx *gorm.DB) AssetReferenceRepo
+	FindAsset(ctx context.Context, projectID uuid.UUID, sha256 string) (*model.Asset, error)
+	// DeleteProjectAssets deletes up to limit assets of a project, whatever their reference
+	// count, and returns how many it deleted. It is used to clean up deleted projects.
+	DeleteProjectAssets(ctx context.Context, projectID uuid.UUID, limit int) (int, error)
 }
 
 type assetReferenceRepo struct {
 	db *gorm.DB
-	s3 *blob.S3Deps
+	s3 blob.BlobStore
 }
 
-func NewAssetReferenceRepo(db *gorm.DB, s3 *blob.S3Deps) AssetReferenceRepo {
+func NewAssetReferenceRepo(db *gorm.DB, s3 blob.BlobStore) AssetReferenceRepo {
 	return &assetReferenceRepo{db: db, s3: s3}
 }
 
+func (r *assetReferenceRepo) WithTx(tx *gorm.DB) AssetReferenceRepo {
+	return &assetReferenceRepo{db: tx, s3: r.s3}
+}
+
 // IncrementAssetRef finds or creates an asset reference and increments its RefCount.
 // It upserts by (project_id, sha256) and updates canonical fields.
 // Uses SkipHooks to prevent recursive hook triggers when called from other hooks.
@@ -161,14 +179,24 @@ func (r *assetReferenceRepo) BatchIncrementAssetRefs(ctx context.Context, projec
 }
 
 // BatchDecrementAssetRefs decrements reference counts for a slice of assets.
-// When count reaches zero or below, the asset reference row is deleted.
-// Uses SkipHooks to prevent recursive hook triggers when called from other hooks.
+// When count reaches zero or below, the asset reference row and its object are deleted.
 func (r *assetReferenceRepo) BatchDecrementAssetRefs(ctx context.Context, projectID uuid.UUID, assets []model.Asset) error {
+	keys, err := r.ReleaseAssetRefs(ctx, projectID, assets)
+	if err != nil {
+		return err
+	}
+	return r.DeleteReleasedAssets(ctx, keys)
+}
+
+// ReleaseAssetRefs decrements reference counts for a slice of assets and deletes the asset
+// reference rows whose count reaches zero or below, returning the S3 keys of their objects.
+// Uses SkipHooks to prevent recursive hook triggers when called from other hooks.
+func (r *assetReferenceRepo) ReleaseAssetRefs(ctx context.Context, projectID uuid.UUID, assets []model.Asset) ([]string, error) {
 	if projectID == uuid.Nil {
-		return fmt.Errorf("BatchDecrementAssetRefs: project_id is required")
+		return nil, fmt.Errorf("ReleaseAssetRefs: project_id is required")
 	}
 	if len(assets) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// group by sha256
@@ -179,13 +207,23 @@ func (r *assetReferenceRepo) BatchDecrementAssetRefs(ctx context.Context, projec
 		}
 		grouped[a.SHA256]++
 	}
+	return r.ReleaseAssetCounts(ctx, projectID, grouped)
+}
+
+// ReleaseAssetCounts decrements the reference count of each SHA256 by its count and deletes
+// the asset reference rows whose count reaches zero or below, returning the S3 keys of their objects.
+func (r *assetReferenceRepo) ReleaseAssetCounts(ctx context.Context, projectID uuid.UUID, grouped map[string]int) ([]string, error) {
+	if projectID == uuid.Nil {
+		return nil, fmt.Errorf("ReleaseAssetCounts: project_id is required")
+	}
 	if len(grouped) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// For each sha, decrement or delete
 	// Use SkipHooks to prevent recursive hook triggers when called from other hooks
 	sessionTx := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true})
+	var released []string
 	for sha, dec := range grouped {
 		var ref model.AssetReference
 		err := sessionTx.Where("project_id = ? AND sha256 = ?", projectID, sha).First(&ref).Error
@@ -193,22 +231,93 @@ func (r *assetReferenceRepo) BatchDecrementAssetRefs(ctx context.Context, projec
 			if err == gorm.ErrRecordNotFound {
 				continue
 			}
-			return err
+			return nil, err
 		}
 		if ref.RefCount <= dec {
-			if err := r.s3.DeleteObject(ctx, ref.S3Key); err != nil {
-				return err
-			}
 			if err := sessionTx.Delete(&ref).Error; err != nil {
-				return err
+				return nil, err
 			}
+			released = append(released, ref.S3Key)
 			continue
 		}
 		if err := sessionTx.Model(&model.AssetReference{}).
 			Where("project_id = ? AND sha256 = ?", projectID, sha).
 			UpdateColumn("ref_count", gorm.Expr("ref_count - ?", dec)).Error; err != nil {
-			return err
+			return nil, err
+		}
+	}
+	return released, nil
+}
+
+// DeleteReleasedAssets deletes the objects of the assets released by ReleaseAssetRefs.
+// An object it fails to delete is left to the orphaned asset collector.
+func (r *assetReferenceRepo) DeleteReleasedAssets(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.s3.DeleteObjects(ctx, keys)
+}
+
+// FindAsset returns the referenced asset with the given SHA256 in a project, stored at its
+// canonical S3 key, or nil when the project holds no such content.
+// The lookup goes through idx_project_sha256, so its cost does not grow with the project.
+func (r *assetReferenceRepo) FindAsset(ctx context.Context, projectID uuid.UUID, sha256 string) (*model.Asset, error) {
+	var ref model.AssetReference
+	err := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).
+		Where("project_id = ? AND sha256 = ? AND ref_count > 0", projectID, sha256).
+		First(&ref).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
 		}
+		return nil, err
+	}
+
+	asset := ref.AssetMeta.Data()
+	asset.S3Key = ref.S3Key
+	return &asset, nil
+}
+
+// DeleteProjectAssets deletes the stored objects before their references, so an object whose
+// deletion failed is still found by the next call.
+func (r *assetReferenceRepo) DeleteProjectAssets(ctx context.Context, projectID uuid.UUID, limit int) (int, error) {
+	var refs []model.AssetReference
+	err := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).
+		Select("id", "s3_key").
+		Where("project_id = ?", projectID).
+		Order("id").
+		Limit(limit).
+		Find(&refs).Error
+	if err != nil || len(refs) == 0 {
+		return 0, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(refs))
+	keys := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, ref.ID)
+		if ref.S3Key != "" {
+			keys = append(keys, ref.S3Key)
+		}
+	}
+	if len(keys) > 0 {
+		if err := r.s3.DeleteObjects(ctx, keys); err != nil {
+			return 0, err
+		}
+	}
+	res := r.db.WithContext(ctx).Session(&gorm.Session{SkipHooks: true}).
+		Where("id IN ?", ids).
+		Delete(&model.AssetReference{})
+	return int(res.RowsAffected), res.Error
+}
+
+// AssetLookup scopes FindAsset to a project for deduplicated uploads.
+// It returns nil, which disables deduplication, when r is nil.
+func AssetLookup(r AssetReferenceRepo, projectID uuid.UUID) blob.AssetLookup {
+	if r == nil {
+		return nil
+	}
+	return func(ctx context.Context, sumHex string) (*model.Asset, error) {
+		return r.FindAsset(ctx, projectID, sumHex)
 	}
-	return nil
 }
@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+)
+
+// AuditFilter narrows a listing of audit events; zero fields match everything
+type AuditFilter struct {
+	ResourceType string
+	// ResourceID matches events with the ID anywhere in their resource IDs
+	ResourceID *uuid.UUID
+	APIKeyID   *uuid.UUID
+	Method     string
+	From       time.Time
+	To         time.Time
+}
+
+// AuditRepo appends audit events and reads them back. There is deliberately no way to change an
+// event; they are only removed in bulk once they are past retention.
+type AuditRepo interface {
+	Create(ctx context.Context, e *model.AuditEvent) error
+	ListWithCursor(ctx context.Context, projectID uuid.UUID, filter AuditFilter, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.AuditEvent, error)
+	// DeleteBefore removes up to limit events created before t and returns how many it removed
+	DeleteBefore(ctx context.Context, t time.Time, limit int) (int64, error)
+}
+
+type auditRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditRepo(db *gorm.DB) AuditRepo {
+	return &auditRepo{db: db}
+}
+
+func (r *auditRepo) Create(ctx context.Context, e *model.AuditEvent) error {
+	return r.db.WithContext(ctx).Create(e).Error
+}
+
+func (r *auditRepo) ListWithCursor(ctx context.Context, projectID uuid.UUID, filter AuditFilter, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.AuditEvent, error) {
+	q := r.db.WithContext(ctx).Where("project_id = ?", projectID)
+
+	if filter.ResourceType != "" {
+		q = q.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != nil {
+		q = q.Where("EXISTS (SELECT 1 FROM jsonb_each_text(resource_ids) AS r WHERE r.value = ?)", filter.ResourceID.String())
+	}
+	if filter.APIKeyID != nil {
+		q = q.Where("api_key_id = ?", *filter.APIKeyID)
+	}
+	if filter.Method != "" {
+		q = q.Where("method = ?", filter.Method)
+	}
+	if !filter.From.IsZero() {
+		q = q.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		q = q.Where("created_at < ?", filter.To)
+	}
+
+	// Apply cursor-based pagination filter if cursor is provided
+	if !afterCreatedAt.IsZero() && afterID != uuid.Nil {
+		// Determine comparison operator based on sort direction
+		comparisonOp := ">"
+		if timeDesc {
+			comparisonOp = "<"
+		}
+		q = q.Where(
+			"(created_at "+comparisonOp+" ?) OR (created_at = ? AND id "+comparisonOp+" ?)",
+			afterCreatedAt, afterCreatedAt, afterID,
+		)
+	}
+
+	// Apply ordering based on sort direction
+	orderBy := "created_at ASC, id ASC"
+	if timeDesc {
+		orderBy = "created_at DESC, id DESC"
+	}
+
+	var events []*model.AuditEvent
+	return events, q.Order(orderBy).Limit(limit).Find(&events).Error
+}
+
+func (r *auditRepo) DeleteBefore(ctx context.Context, t time.Time, limit int) (int64, error) {
+	res := r.db.WithContext(ctx).Exec(
+		"DELETE FROM audit_events WHERE id IN (SELECT id FROM audit_events WHERE created_at < ? LIMIT ?)",
+		t, limit,
+	)
+	return res.RowsAffected, res.Error
+}
@@ -14,13 +14,14 @@ import (
 type BlockRepo interface {
 	Create(ctx context.Context, b *model.Block) error
 	Delete(ctx context.Context, spaceID uuid.UUID, id uuid.UUID) error
-	Get(ctx context.Context, id uuid.UUID) (*model.Block, error)
+	Get(ctx context.Context, spaceID uuid.UUID, id uuid.UUID) (*model.Block, error)
+	// Update returns gorm.ErrRecordNotFound when the block is not in the space of b
 	Update(ctx context.Context, b *model.Block) error
 	ListBySpace(ctx context.Context, spaceID uuid.UUID, blockType string, parentID *uuid.UUID) ([]model.Block, error)
 	NextSort(ctx context.Context, spaceID uuid.UUID, parentID *uuid.UUID) (int64, error)
-	MoveToParentAppend(ctx context.Context, id uuid.UUID, newParentID *uuid.UUID) error
-	ReorderWithinGroup(ctx context.Context, id uuid.UUID, newSort int64) error
-	MoveToParentAtSort(ctx context.Context, id uuid.UUID, newParentID *uuid.UUID, targetSort int64) error
+	MoveToParentAppend(ctx context.Context, spaceID uuid.UUID, id uuid.UUID, newParentID *uuid.UUID) error
+	ReorderWithinGroup(ctx context.Context, spaceID uuid.UUID, id uuid.UUID, newSort int64) error
+	MoveToParentAtSort(ctx context.Context, spaceID uuid.UUID, id uuid.UUID, newParentID *uuid.UUID, targetSort int64) error
 }
 
 type blockRepo struct{ db *gorm.DB }
@@ -35,11 +36,11 @@ func (r *blockRepo) Delete(ctx context.Context, spaceID uuid.UUID, id uuid.UUID)
 	return r.db.WithContext(ctx).Where(&model.Block{ID: id, SpaceID: spaceID}).Delete(&model.Block{}).Error
 }
 
-func (r *blockRepo) Get(ctx context.Context, id uuid.UUID) (*model.Block, error) {
+func (r *blockRepo) Get(ctx context.Context, spaceID uuid.UUID, id uuid.UUID) (*model.Block, error) {
 	var b model.Block
 	err := r.db.WithContext(ctx).
 		Preload("ToolSOPs.ToolReference").
-		Where(&model.Block{ID: id}).
+		Where("id = ? AND space_id = ?", id, spaceID).
 		First(&b).Error
 
 	if err != nil {
@@ -53,7 +54,7 @@ func (r *blockRepo) Get(ctx context.Context, id uuid.UUID) (*model.Block, error)
 }
 
 func (r *blockRepo) Update(ctx context.Context, b *model.Block) error {
-	return r.db.WithContext(ctx).Where(&model.Block{ID: b.ID}).Updates(b).Error
+	return affectedOne(r.db.WithContext(ctx).Where("id = ? AND space_id = ?", b.ID, b.SpaceID).Updates(b))
 }
 
 func (r *blockRepo) ListBySpace(ctx context.Context, spaceID uuid.UUID, blockType string, parentID *uuid.UUID) ([]model.Block, error) {
@@ -99,10 +100,10 @@ func (r *blockRepo) NextSort(ctx context.Context, spaceID uuid.UUID, parentID *u
 }
 
 // MoveToParentAppend moves the block to new parent and sets sort to tail in a single transaction.
-func (r *blockRepo) MoveToParentAppend(ctx context.Context, id uuid.UUID, newParentID *uuid.UUID) error {
+func (r *blockRepo) MoveToParentAppend(ctx context.Context, spaceID uuid.UUID, id uuid.UUID, newParentID *uuid.UUID) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var b model.Block
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&model.Block{ID: id}).First(&b).Error; err != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND space_id = ?", id, spaceID).First(&b).Error; err != nil {
 			return err
 		}
 
@@ -122,10 +123,10 @@ func (r *blockRepo) MoveToParentAppend(ctx context.Context, id uuid.UUID, newPar
 }
 
 // ReorderWithinGroup safely reorders an item to newSort within its current (space_id, parent_id) group.
-func (r *blockRepo) ReorderWithinGroup(ctx context.Context, id uuid.UUID, newSort int64) error {
+func (r *blockRepo) ReorderWithinGroup(ctx context.Context, spaceID uuid.UUID, id uuid.UUID, newSort int64) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var b model.Block
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&model.Block{ID: id}).First(&b).Error; err != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND space_id = ?", id, spaceID).First(&b).Error; err != nil {
 			return err
 		}
 		return r.reorderInTransaction(tx, &b, newSort)
@@ -133,11 +134,11 @@ func (r *blockRepo) ReorderWithinGroup(ctx context.Context, id uuid.UUID, newSor
 }
 
 // MoveToParentAtSort moves a block to a specific position in the target parent group.
-func (r *blockRepo) MoveToParentAtSort(ctx context.Context, id uuid.UUID, newParentID *uuid.UUID, targetSort int64) error {
+func (r *blockRepo) MoveToParentAtSort(ctx context.Context, spaceID uuid.UUID, id uuid.UUID, newParentID *uuid.UUID, targetSort int64) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// Lock and load current block
 		var b model.Block
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&model.Block{ID: id}).First(&b).Error; err != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND space_id = ?", id, spaceID).First(&b).Error; err != nil {
 			return err
 		}
 
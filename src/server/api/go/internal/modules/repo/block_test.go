@@ -231,7 +231,7 @@ func TestBlockRepo_GetSOPBlockWithToolSOPs(t *testing.T) {
 	require.NoError(t, db.Create(toolSOP2).Error)
 
 	// Test: Get the SOP block
-	result, err := repo.Get(ctx, sopBlock.ID)
+	result, err := repo.Get(ctx, space.ID, sopBlock.ID)
 	require.NoError(t, err)
 	require.NotNil(t, result)
 
@@ -398,7 +398,7 @@ func TestBlockRepo_GetNonSOPBlock(t *testing.T) {
 	require.NoError(t, db.Create(pageBlock).Error)
 
 	// Test: Get the non-SOP block
-	result, err := repo.Get(ctx, pageBlock.ID)
+	result, err := repo.Get(ctx, space.ID, pageBlock.ID)
 	require.NoError(t, err)
 	require.NotNil(t, result)
 
@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/pkg/configs"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConfigRepo reads and writes the configs of projects, spaces and sessions. A target is named
+// by its scope, its project and its ID; for a project both IDs are the project ID.
+type ConfigRepo interface {
+	// UpdateConfigs locks the configs of a target, replaces them with what apply returns and
+	// returns them. It returns gorm.ErrRecordNotFound when the target is not in the project,
+	// or is a project being deleted.
+	UpdateConfigs(ctx context.Context, scope configs.Scope, projectID uuid.UUID, id uuid.UUID, apply func(datatypes.JSONMap) (datatypes.JSONMap, error)) (datatypes.JSONMap, error)
+	// Layers returns the configs that apply to a target from its project down to itself:
+	// a project, a project and space, or a project, the space the session is connected to
+	// and the session.
+	Layers(ctx context.Context, scope configs.Scope, projectID uuid.UUID, id uuid.UUID) ([]configs.Layer, error)
+}
+
+type configRepo struct{ db *gorm.DB }
+
+func NewConfigRepo(db *gorm.DB) ConfigRepo {
+	return &configRepo{db: db}
+}
+
+// target selects the row of a target, which also keeps a project from reaching the
+// spaces and sessions of another one
+func target(db *gorm.DB, scope configs.Scope, projectID uuid.UUID, id uuid.UUID) (*gorm.DB, error) {
+	switch scope {
+	case configs.ScopeProject:
+		return db.Model(&model.Project{}).Where("id = ? AND delete_requested_at IS NULL", projectID), nil
+	case configs.ScopeSpace:
+		return db.Model(&model.Space{}).Where("id = ? AND project_id = ?", id, projectID), nil
+	case configs.ScopeSession:
+		return db.Model(&model.Session{}).Where("id = ? AND project_id = ?", id, projectID), nil
+	default:
+		return nil, fmt.Errorf("unknown config scope %q", scope)
+	}
+}
+
+func (r *configRepo) UpdateConfigs(ctx context.Context, scope configs.Scope, projectID uuid.UUID, id uuid.UUID, apply func(datatypes.JSONMap) (datatypes.JSONMap, error)) (datatypes.JSONMap, error) {
+	var next datatypes.JSONMap
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q, err := target(tx, scope, projectID, id)
+		if err != nil {
+			return err
+		}
+		var row struct{ Configs datatypes.JSONMap }
+		if err := q.Clauses(clause.Locking{Strength: "UPDATE"}).Select("configs").Take(&row).Error; err != nil {
+			return err
+		}
+		if next, err = apply(row.Configs); err != nil {
+			return err
+		}
+
+		q, _ = target(tx, scope, projectID, id)
+		return affectedOne(q.Update("configs", next))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+func (r *configRepo) Layers(ctx context.Context, scope configs.Scope, projectID uuid.UUID, id uuid.UUID) ([]configs.Layer, error) {
+	db := r.db.WithContext(ctx)
+
+	var project struct{ Configs datatypes.JSONMap }
+	if err := db.Model(&model.Project{}).Select("configs").Where("id = ?", projectID).Take(&project).Error; err != nil {
+		return nil, err
+	}
+	layers := []configs.Layer{{Scope: configs.ScopeProject, Configs: project.Configs}}
+
+	spaceID := id
+	var session struct {
+		SpaceID *uuid.UUID
+		Configs datatypes.JSONMap
+	}
+	switch scope {
+	case configs.ScopeProject:
+		return layers, nil
+	case configs.ScopeSession:
+		if err := db.Model(&model.Session{}).Select("space_id", "configs").Where("id = ? AND project_id = ?", id, projectID).Take(&session).Error; err != nil {
+			return nil, err
+		}
+		if session.SpaceID == nil {
+			return append(layers, configs.Layer{Scope: configs.ScopeSession, Configs: session.Configs}), nil
+		}
+		spaceID = *session.SpaceID
+	case configs.ScopeSpace:
+	default:
+		return nil, fmt.Errorf("unknown config scope %q", scope)
+	}
+
+	// A session stays connected to a space in the trash, and keeps inheriting its configs
+	spaces := db
+	if scope == configs.ScopeSession {
+		spaces = db.Unscoped()
+	}
+	var space struct{ Configs datatypes.JSONMap }
+	if err := spaces.Model(&model.Space{}).Select("configs").Where("id = ? AND project_id = ?", spaceID, projectID).Take(&space).Error; err != nil {
+		return nil, err
+	}
+	layers = append(layers, configs.Layer{Scope: configs.ScopeSpace, Configs: space.Configs})
+	if scope == configs.ScopeSession {
+		layers = append(layers, configs.Layer{Scope: configs.ScopeSession, Configs: session.Configs})
+	}
+	return layers, nil
+}
@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DataKeyRepo interface {
+	blob.DataKeyStore
+}
+
+type dataKeyRepo struct {
+	db *gorm.DB
+}
+
+func NewDataKeyRepo(db *gorm.DB) DataKeyRepo {
+	return &dataKeyRepo{db: db}
+}
+
+func (r *dataKeyRepo) GetDataKey(ctx context.Context, projectID uuid.UUID) (*model.ProjectDataKey, error) {
+	var key model.ProjectDataKey
+	err := r.db.WithContext(ctx).Where("project_id = ?", projectID).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// CreateDataKey keeps the key of whichever request created one first, so concurrent
+// first writes of a project agree on its data key
+func (r *dataKeyRepo) CreateDataKey(ctx context.Context, key *model.ProjectDataKey) (*model.ProjectDataKey, error) {
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(key).Error; err != nil {
+		return nil, err
+	}
+	stored, err := r.GetDataKey(ctx, key.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, errors.New("data key vanished after it was created")
+	}
+	return stored, nil
+}
@@ -2,75 +2,105 @@ package repo
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"gorm.io/gorm"
 )
 
 type DiskRepo interface {
 	Create(ctx context.Context, d *model.Disk) error
+	Update(ctx context.Context, d *model.Disk) error
+	Get(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error)
+	GetByName(ctx context.Context, projectID uuid.UUID, name string) (*model.Disk, error)
+	GetUsage(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.DiskUsage, error)
+	// Delete moves the disk to the trash, see TrashRepo
 	Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) error
 	ListWithCursor(ctx context.Context, projectID uuid.UUID, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.Disk, error)
 }
 
-type diskRepo struct {
-	db                 *gorm.DB
-	assetReferenceRepo AssetReferenceRepo
-}
+// ErrDiskNameExists is returned when another disk of the project already has the name
+var ErrDiskNameExists = errors.New("disk name already exists")
+
+type diskRepo struct{ db *gorm.DB }
 
-func NewDiskRepo(db *gorm.DB, assetReferenceRepo AssetReferenceRepo) DiskRepo {
-	return &diskRepo{db: db, assetReferenceRepo: assetReferenceRepo}
+func NewDiskRepo(db *gorm.DB) DiskRepo {
+	return &diskRepo{db: db}
 }
 
 func (r *diskRepo) Create(ctx context.Context, d *model.Disk) error {
-	return r.db.WithContext(ctx).Create(d).Error
+	return translateDiskErr(r.db.WithContext(ctx).Create(d).Error)
 }
 
-func (r *diskRepo) Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) error {
-	// Use transaction to ensure atomicity
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Verify disk exists and belongs to project
-		var disk model.Disk
-		if err := tx.Where("id = ? AND project_id = ?", diskID, projectID).First(&disk).Error; err != nil {
-			return err
-		}
+// Update saves the editable fields of a disk: name, description, meta and quotas
+func (r *diskRepo) Update(ctx context.Context, d *model.Disk) error {
+	res := r.db.WithContext(ctx).Model(&model.Disk{}).
+		Where("id = ? AND project_id = ?", d.ID, d.ProjectID).
+		Select("name", "description", "meta", "max_bytes", "max_files").
+		Updates(d)
+	if res.Error != nil {
+		return translateDiskErr(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
 
-		// Query all artifacts before deletion to collect asset meta for reference decrement
-		// Artifacts will be automatically deleted by CASCADE when disk is deleted
-		var artifacts []model.Artifact
-		if err := tx.Where("disk_id = ?", diskID).Find(&artifacts).Error; err != nil {
-			return fmt.Errorf("query artifacts: %w", err)
-		}
+func (r *diskRepo) Get(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error) {
+	var disk model.Disk
+	if err := r.db.WithContext(ctx).Where("id = ? AND project_id = ?", diskID, projectID).First(&disk).Error; err != nil {
+		return nil, err
+	}
+	return &disk, nil
+}
 
-		// Collect asset meta from all artifacts for batch decrement
-		assets := make([]model.Asset, 0, len(artifacts))
-		for _, artifact := range artifacts {
-			asset := artifact.AssetMeta.Data()
-			if asset.SHA256 != "" {
-				assets = append(assets, asset)
-			}
-		}
+func (r *diskRepo) GetByName(ctx context.Context, projectID uuid.UUID, name string) (*model.Disk, error) {
+	var disk model.Disk
+	if err := r.db.WithContext(ctx).Where("project_id = ? AND name = ?", projectID, name).First(&disk).Error; err != nil {
+		return nil, err
+	}
+	return &disk, nil
+}
 
-		// Delete the disk (artifacts will be deleted automatically by CASCADE)
-		if err := tx.Delete(&disk).Error; err != nil {
-			return fmt.Errorf("delete disk: %w", err)
-		}
+func (r *diskRepo) GetUsage(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.DiskUsage, error) {
+	return queryDiskUsage(r.db.WithContext(ctx).Where("d.project_id = ?", projectID), diskID)
+}
 
-		// Batch decrement asset references
-		// Note: BatchDecrementAssetRefs uses its own DB connection and may involve S3 operations
-		// The database operations within BatchDecrementAssetRefs will not be part of this transaction,
-		// but the disk and artifacts deletion will be atomic
-		if len(assets) > 0 {
-			if err := r.assetReferenceRepo.BatchDecrementAssetRefs(ctx, projectID, assets); err != nil {
-				return fmt.Errorf("decrement asset references: %w", err)
-			}
-		}
+// queryDiskUsage sums the file count and asset sizes of a disk's artifacts in the database.
+// It returns gorm.ErrRecordNotFound when db matches no disk.
+func queryDiskUsage(db *gorm.DB, diskID uuid.UUID) (*model.DiskUsage, error) {
+	var rows []model.DiskUsage
+	err := db.Table("disks AS d").
+		Select("d.max_files, d.max_bytes, COUNT(a.id) AS files, COALESCE(SUM((a.asset_meta->>'size_b')::bigint), 0) AS bytes").
+		Joins("LEFT JOIN artifacts AS a ON a.disk_id = d.id").
+		Where("d.id = ?", diskID).
+		Group("d.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &rows[0], nil
+}
 
-		return nil
-	})
+// translateDiskErr maps unique violations of the disk name index to ErrDiskNameExists
+func translateDiskErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_disk_project_live_name" {
+		return ErrDiskNameExists
+	}
+	return err
+}
+
+// Delete moves a disk to the trash. Its artifacts are removed when the trash is purged.
+func (r *diskRepo) Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) error {
+	return affectedOne(r.db.WithContext(ctx).Where("id = ? AND project_id = ?", diskID, projectID).Delete(&model.Disk{}))
 }
 
 func (r *diskRepo) ListWithCursor(ctx context.Context, projectID uuid.UUID, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.Disk, error) {
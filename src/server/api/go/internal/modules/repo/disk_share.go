@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+)
+
+type DiskShareRepo interface {
+	Create(ctx context.Context, s *model.DiskShare) error
+	ListByDisk(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error)
+	Revoke(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID, at time.Time) error
+	GetByTokenHMAC(ctx context.Context, tokenHMAC string) (*model.DiskShare, error)
+	RecordAccess(ctx context.Context, shareID uuid.UUID, at time.Time) error
+}
+
+type diskShareRepo struct{ db *gorm.DB }
+
+func NewDiskShareRepo(db *gorm.DB) DiskShareRepo {
+	return &diskShareRepo{db: db}
+}
+
+// Create stores a share after checking that the disk belongs to the share's project.
+// It returns gorm.ErrRecordNotFound when the disk does not exist in the project.
+func (r *diskShareRepo) Create(ctx context.Context, s *model.DiskShare) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var disk model.Disk
+		if err := tx.Where("id = ? AND project_id = ?", s.DiskID, s.ProjectID).First(&disk).Error; err != nil {
+			return err
+		}
+		return tx.Create(s).Error
+	})
+}
+
+func (r *diskShareRepo) ListByDisk(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error) {
+	var shares []*model.DiskShare
+	err := r.db.WithContext(ctx).
+		Where("project_id = ? AND disk_id = ?", projectID, diskID).
+		Order("created_at DESC, id DESC").
+		Find(&shares).Error
+	return shares, err
+}
+
+// Revoke marks a share as revoked. Revoking an already revoked share keeps the original time.
+func (r *diskShareRepo) Revoke(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID, at time.Time) error {
+	var share model.DiskShare
+	if err := r.db.WithContext(ctx).
+		Where("id = ? AND project_id = ? AND disk_id = ?", shareID, projectID, diskID).
+		First(&share).Error; err != nil {
+		return err
+	}
+	if share.RevokedAt != nil {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&share).Update("revoked_at", at).Error
+}
+
+// GetByTokenHMAC finds a share by its token. Shares of disks in the trash are not found.
+func (r *diskShareRepo) GetByTokenHMAC(ctx context.Context, tokenHMAC string) (*model.DiskShare, error) {
+	var share model.DiskShare
+	db := r.db.WithContext(ctx)
+	live := db.Table("disks").Select("1").Where("disks.id = disk_shares.disk_id AND disks.deleted_at IS NULL")
+	err := db.Preload("Project", "delete_requested_at IS NULL").
+		Where(&model.DiskShare{TokenHMAC: tokenHMAC}).Where("EXISTS (?)", live).
+		First(&share).Error
+	if err != nil {
+		return nil, err
+	}
+	// The links of a deleted project stop working with its keys
+	if share.Project == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &share, nil
+}
+
+// RecordAccess increments the access counter atomically in the database
+func (r *diskShareRepo) RecordAccess(ctx context.Context, shareID uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.DiskShare{}).
+		Where("id = ?", shareID).
+		UpdateColumns(map[string]interface{}{
+			"access_count":     gorm.Expr("access_count + 1"),
+			"last_accessed_at": at,
+		}).Error
+}
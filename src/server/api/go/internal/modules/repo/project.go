@@ -0,0 +1,206 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProjectRepo interface {
+	// CreateWithKey creates a project together with its first API key
+	CreateWithKey(ctx context.Context, p *model.Project, key *model.ProjectAPIKey) error
+	// CreateKey returns gorm.ErrRecordNotFound when the project does not exist
+	CreateKey(ctx context.Context, key *model.ProjectAPIKey) error
+	ListKeys(ctx context.Context, projectID uuid.UUID) ([]*model.ProjectAPIKey, error)
+	GetKey(ctx context.Context, projectID uuid.UUID, keyID uuid.UUID) (*model.ProjectAPIKey, error)
+	// RotateKey stores next and lets previous expire at expiresAt, unless it expires earlier anyway.
+	// It returns gorm.ErrRecordNotFound when previous is no longer active at now.
+	RotateKey(ctx context.Context, previous *model.ProjectAPIKey, next *model.ProjectAPIKey, expiresAt time.Time, now time.Time) error
+	RevokeKey(ctx context.Context, projectID uuid.UUID, keyID uuid.UUID, at time.Time) error
+	Get(ctx context.Context, projectID uuid.UUID) (*model.Project, error)
+	ListWithCursor(ctx context.Context, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.Project, error)
+	// UpdateConfigs replaces the configs of a project. It returns gorm.ErrRecordNotFound when the
+	// project does not exist or is being deleted.
+	UpdateConfigs(ctx context.Context, p *model.Project) error
+	// UpdateSecret replaces the built-in secret of a project. It returns gorm.ErrRecordNotFound
+	// when the project does not exist or is being deleted.
+	UpdateSecret(ctx context.Context, p *model.Project) error
+	// MarkDeleted requests the deletion of a project. A project already being deleted keeps the
+	// time of the first request.
+	MarkDeleted(ctx context.Context, projectID uuid.UUID, at time.Time) error
+	// ListDeleted returns up to limit projects being deleted, the oldest requests first
+	ListDeleted(ctx context.Context, limit int) ([]*model.Project, error)
+	// Delete removes a project and, through cascades, everything it owns
+	Delete(ctx context.Context, projectID uuid.UUID) error
+}
+
+type projectRepo struct{ db *gorm.DB }
+
+func NewProjectRepo(db *gorm.DB) ProjectRepo {
+	return &projectRepo{db: db}
+}
+
+func (r *projectRepo) CreateWithKey(ctx context.Context, p *model.Project, key *model.ProjectAPIKey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(p).Error; err != nil {
+			return err
+		}
+		key.ProjectID = p.ID
+		return tx.Create(key).Error
+	})
+}
+
+func (r *projectRepo) CreateKey(ctx context.Context, key *model.ProjectAPIKey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var project model.Project
+		if err := tx.Select("id").Where("id = ? AND delete_requested_at IS NULL", key.ProjectID).First(&project).Error; err != nil {
+			return err
+		}
+		return tx.Create(key).Error
+	})
+}
+
+func (r *projectRepo) ListKeys(ctx context.Context, projectID uuid.UUID) ([]*model.ProjectAPIKey, error) {
+	var keys []*model.ProjectAPIKey
+	err := r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order("created_at DESC, id DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *projectRepo) GetKey(ctx context.Context, projectID uuid.UUID, keyID uuid.UUID) (*model.ProjectAPIKey, error) {
+	var key model.ProjectAPIKey
+	if err := r.db.WithContext(ctx).Where("id = ? AND project_id = ?", keyID, projectID).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RotateKey locks the previous key so concurrent rotations cannot both replace it
+func (r *projectRepo) RotateKey(ctx context.Context, previous *model.ProjectAPIKey, next *model.ProjectAPIKey, expiresAt time.Time, now time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked model.ProjectAPIKey
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND project_id = ?", previous.ID, previous.ProjectID).
+			First(&locked).Error; err != nil {
+			return err
+		}
+		if !locked.Active(now) || locked.RotatedToID != nil {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		if locked.ExpiresAt != nil && locked.ExpiresAt.Before(expiresAt) {
+			expiresAt = *locked.ExpiresAt
+		}
+		if err := tx.Model(&locked).Updates(map[string]interface{}{
+			"expires_at":    expiresAt,
+			"rotated_to_id": next.ID,
+		}).Error; err != nil {
+			return err
+		}
+		*previous = locked
+		return nil
+	})
+}
+
+// RevokeKey marks a key as revoked. Revoking an already revoked key keeps the original time.
+func (r *projectRepo) RevokeKey(ctx context.Context, projectID uuid.UUID, keyID uuid.UUID, at time.Time) error {
+	key, err := r.GetKey(ctx, projectID, keyID)
+	if err != nil {
+		return err
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(key).Update("revoked_at", at).Error
+}
+
+func (r *projectRepo) Get(ctx context.Context, projectID uuid.UUID) (*model.Project, error) {
+	var project model.Project
+	if err := r.db.WithContext(ctx).Where("id = ?", projectID).First(&project).Error; err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *projectRepo) ListWithCursor(ctx context.Context, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.Project, error) {
+	q := r.db.WithContext(ctx)
+
+	// Apply cursor-based pagination filter if cursor is provided
+	if !afterCreatedAt.IsZero() && afterID != uuid.Nil {
+		comparisonOp := ">"
+		if timeDesc {
+			comparisonOp = "<"
+		}
+		q = q.Where(
+			"(created_at "+comparisonOp+" ?) OR (created_at = ? AND id "+comparisonOp+" ?)",
+			afterCreatedAt, afterCreatedAt, afterID,
+		)
+	}
+
+	orderBy := "created_at ASC, id ASC"
+	if timeDesc {
+		orderBy = "created_at DESC, id DESC"
+	}
+
+	var projects []*model.Project
+	return projects, q.Order(orderBy).Limit(limit).Find(&projects).Error
+}
+
+func (r *projectRepo) UpdateConfigs(ctx context.Context, p *model.Project) error {
+	res := r.db.WithContext(ctx).Model(p).
+		Clauses(clause.Returning{}).
+		Where("delete_requested_at IS NULL").
+		Update("configs", p.Configs)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *projectRepo) UpdateSecret(ctx context.Context, p *model.Project) error {
+	res := r.db.WithContext(ctx).Model(p).
+		Clauses(clause.Returning{}).
+		Where("delete_requested_at IS NULL").
+		Updates(map[string]interface{}{
+			"secret_key_hmac":     p.SecretKeyHMAC,
+			"secret_key_hash_phc": p.SecretKeyHashPHC,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *projectRepo) MarkDeleted(ctx context.Context, projectID uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.Project{}).
+		Where("id = ? AND delete_requested_at IS NULL", projectID).
+		Update("delete_requested_at", at).Error
+}
+
+func (r *projectRepo) ListDeleted(ctx context.Context, limit int) ([]*model.Project, error) {
+	var projects []*model.Project
+	err := r.db.WithContext(ctx).
+		Where("delete_requested_at IS NOT NULL").
+		Order("delete_requested_at ASC, id ASC").
+		Limit(limit).
+		Find(&projects).Error
+	return projects, err
+}
+
+func (r *projectRepo) Delete(ctx context.Context, projectID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", projectID).Delete(&model.Project{}).Error
+}
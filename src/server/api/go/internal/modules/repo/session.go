@@ -2,21 +2,22 @@ package repo
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/memodb-io/Acontext/internal/infra/blob"
 	"github.com/memodb-io/Acontext/internal/modules/model"
-	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type SessionRepo interface {
+	// Create returns gorm.ErrRecordNotFound when the space of s is not in its project
 	Create(ctx context.Context, s *model.Session) error
+	// Delete moves the session to the trash, see TrashRepo
 	Delete(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) error
+	// Update returns gorm.ErrRecordNotFound when the session, or the space it is connected to,
+	// is not in the project of s
 	Update(ctx context.Context, s *model.Session) error
-	Get(ctx context.Context, s *model.Session) (*model.Session, error)
+	Get(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) (*model.Session, error)
 	GetDisableTaskTracking(ctx context.Context, sessionID uuid.UUID) (bool, error)
 	ListWithCursor(ctx context.Context, projectID uuid.UUID, spaceID *uuid.UUID, notConnected bool, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]model.Session, error)
 	CreateMessageWithAssets(ctx context.Context, msg *model.Message) error
@@ -24,92 +25,50 @@ type SessionRepo interface {
 	ListAllMessagesBySession(ctx context.Context, sessionID uuid.UUID) ([]model.Message, error)
 }
 
-type sessionRepo struct {
-	db                 *gorm.DB
-	assetReferenceRepo AssetReferenceRepo
-	s3                 *blob.S3Deps
-	log                *zap.Logger
-}
+type sessionRepo struct{ db *gorm.DB }
 
-func NewSessionRepo(db *gorm.DB, assetReferenceRepo AssetReferenceRepo, s3 *blob.S3Deps, log *zap.Logger) SessionRepo {
-	return &sessionRepo{
-		db:                 db,
-		assetReferenceRepo: assetReferenceRepo,
-		s3:                 s3,
-		log:                log,
-	}
+func NewSessionRepo(db *gorm.DB) SessionRepo {
+	return &sessionRepo{db: db}
 }
 
 func (r *sessionRepo) Create(ctx context.Context, s *model.Session) error {
-	return r.db.WithContext(ctx).Create(s).Error
-}
-
-func (r *sessionRepo) Delete(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) error {
-	// Use transaction to ensure atomicity: query messages, delete session, and decrement asset references
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Verify session exists and belongs to project
-		var session model.Session
-		if err := tx.Where("id = ? AND project_id = ?", sessionID, projectID).First(&session).Error; err != nil {
+		if err := checkSpace(tx, s.ProjectID, s.SpaceID); err != nil {
 			return err
 		}
+		return tx.Create(s).Error
+	})
+}
 
-		// Query all messages in transaction before deletion
-		var messages []model.Message
-		if err := tx.Where("session_id = ?", sessionID).Find(&messages).Error; err != nil {
-			return fmt.Errorf("query messages: %w", err)
-		}
-
-		// Collect all assets from messages
-		assets := make([]model.Asset, 0)
-		for _, msg := range messages {
-			// Extract PartsAssetMeta (the asset that stores the parts JSON)
-			partsAssetMeta := msg.PartsAssetMeta.Data()
-			if partsAssetMeta.SHA256 != "" {
-				assets = append(assets, partsAssetMeta)
-			}
-
-			// Download and parse parts to extract assets from individual parts
-			if r.s3 != nil && partsAssetMeta.S3Key != "" {
-				parts := []model.Part{}
-				if err := r.s3.DownloadJSON(ctx, partsAssetMeta.S3Key, &parts); err != nil {
-					// Log error but continue with other messages
-					r.log.Warn("failed to download parts", zap.Error(err), zap.String("s3_key", partsAssetMeta.S3Key))
-					continue
-				}
-
-				// Extract assets from parts
-				for _, part := range parts {
-					if part.Asset != nil && part.Asset.SHA256 != "" {
-						assets = append(assets, *part.Asset)
-					}
-				}
-			}
-		}
-
-		// Delete the session (messages will be automatically deleted by CASCADE)
-		if err := tx.Delete(&session).Error; err != nil {
-			return fmt.Errorf("delete session: %w", err)
-		}
-
-		// Note: BatchDecrementAssetRefs uses its own DB connection and may involve S3 operations
-		// The database operations within BatchDecrementAssetRefs will not be part of this transaction,
-		// but the session and messages deletion will be atomic
-		if len(assets) > 0 {
-			if err := r.assetReferenceRepo.BatchDecrementAssetRefs(ctx, projectID, assets); err != nil {
-				return fmt.Errorf("decrement asset references: %w", err)
-			}
-		}
-
+// checkSpace verifies that the space a session is connected to belongs to the project
+func checkSpace(tx *gorm.DB, projectID uuid.UUID, spaceID *uuid.UUID) error {
+	if spaceID == nil {
 		return nil
-	})
+	}
+	var space model.Space
+	return tx.Select("id").Where("id = ? AND project_id = ?", *spaceID, projectID).First(&space).Error
+}
+
+// Delete moves a session to the trash. Its messages are removed when the trash is purged.
+func (r *sessionRepo) Delete(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) error {
+	return affectedOne(r.db.WithContext(ctx).Where("id = ? AND project_id = ?", sessionID, projectID).Delete(&model.Session{}))
 }
 
 func (r *sessionRepo) Update(ctx context.Context, s *model.Session) error {
-	return r.db.WithContext(ctx).Where(&model.Session{ID: s.ID}).Updates(s).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkSpace(tx, s.ProjectID, s.SpaceID); err != nil {
+			return err
+		}
+		return affectedOne(tx.Where("id = ? AND project_id = ?", s.ID, s.ProjectID).Updates(s))
+	})
 }
 
-func (r *sessionRepo) Get(ctx context.Context, s *model.Session) (*model.Session, error) {
-	return s, r.db.WithContext(ctx).Where(&model.Session{ID: s.ID}).First(s).Error
+func (r *sessionRepo) Get(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) (*model.Session, error) {
+	var session model.Session
+	if err := r.db.WithContext(ctx).Where("id = ? AND project_id = ?", sessionID, projectID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
 }
 
 func (r *sessionRepo) GetDisableTaskTracking(ctx context.Context, sessionID uuid.UUID) (bool, error) {
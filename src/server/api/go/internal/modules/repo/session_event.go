@@ -0,0 +1,180 @@
+package repo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+)
+
+// sessionEventTriggers write the changes to messages and tasks of a session to session_events in
+// the transaction that made them. The core service writes most of these, so the events are
+// captured in the database rather than in either service. Learning progress counts the tasks
+// the same way as the learning status of the core service.
+const sessionEventTriggers = `
+CREATE SEQUENCE IF NOT EXISTS session_event_seq;
+
+CREATE OR REPLACE FUNCTION session_event_message() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		INSERT INTO session_events (session_id, type, data) VALUES (
+			NEW.session_id,
+			'message.created',
+			jsonb_build_object(
+				'id', NEW.id,
+				'parent_id', NEW.parent_id,
+				'role', NEW.role,
+				'session_task_process_status', NEW.session_task_process_status,
+				'created_at', NEW.created_at
+			)
+		);
+	ELSIF OLD.session_task_process_status IS DISTINCT FROM NEW.session_task_process_status OR OLD.task_id IS DISTINCT FROM NEW.task_id THEN
+		INSERT INTO session_events (session_id, type, data) VALUES (
+			NEW.session_id,
+			'message.status',
+			jsonb_build_object(
+				'id', NEW.id,
+				'task_id', NEW.task_id,
+				'session_task_process_status', NEW.session_task_process_status,
+				'updated_at', NEW.updated_at
+			)
+		);
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION session_event_task() RETURNS trigger AS $$
+DECLARE
+	was_counted boolean := false;
+	is_counted boolean := NOT NEW.is_planning AND NEW.status = 'success';
+BEGIN
+	IF TG_OP = 'UPDATE' THEN
+		was_counted := NOT OLD.is_planning AND OLD.status = 'success';
+	END IF;
+
+	IF TG_OP = 'INSERT' OR OLD.status IS DISTINCT FROM NEW.status OR OLD.data IS DISTINCT FROM NEW.data
+		OR OLD.is_planning IS DISTINCT FROM NEW.is_planning OR OLD.space_digested IS DISTINCT FROM NEW.space_digested THEN
+		INSERT INTO session_events (session_id, type, data) VALUES (
+			NEW.session_id,
+			CASE TG_OP WHEN 'INSERT' THEN 'task.created' ELSE 'task.updated' END,
+			jsonb_build_object(
+				'id', NEW.id,
+				'order', NEW."order",
+				'status', NEW.status,
+				'data', NEW.data,
+				'is_planning', NEW.is_planning,
+				'space_digested', NEW.space_digested,
+				'updated_at', NEW.updated_at
+			)
+		);
+	END IF;
+
+	IF (was_counted OR is_counted) AND (was_counted IS DISTINCT FROM is_counted OR OLD.space_digested IS DISTINCT FROM NEW.space_digested)
+		AND EXISTS (SELECT 1 FROM sessions WHERE id = NEW.session_id AND space_id IS NOT NULL) THEN
+		INSERT INTO session_events (session_id, type, data)
+		SELECT
+			NEW.session_id,
+			'learning.progress',
+			jsonb_build_object(
+				'space_digested_count', count(*) FILTER (WHERE space_digested),
+				'not_space_digested_count', count(*) FILTER (WHERE NOT space_digested)
+			)
+		FROM tasks
+		WHERE session_id = NEW.session_id AND NOT is_planning AND status = 'success';
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS session_event_message ON messages;
+CREATE TRIGGER session_event_message AFTER INSERT OR UPDATE ON messages
+	FOR EACH ROW EXECUTE FUNCTION session_event_message();
+
+DROP TRIGGER IF EXISTS session_event_task ON tasks;
+CREATE TRIGGER session_event_task AFTER INSERT OR UPDATE ON tasks
+	FOR EACH ROW EXECUTE FUNCTION session_event_task();
+`
+
+// sessionEventRelayLock is the advisory lock held while events are numbered
+const sessionEventRelayLock = 0x5e55e7
+
+// InstallSessionEventTriggers creates or replaces the triggers that fill session_events. It runs
+// with the auto migration, once the tables exist.
+func InstallSessionEventTriggers(db *gorm.DB) error {
+	return db.Exec(sessionEventTriggers).Error
+}
+
+type SessionEventRepo interface {
+	// Publish numbers up to limit unpublished events in the order they were written and returns
+	// them by sequence number. One caller numbers events at a time, so sequence numbers are
+	// committed in order; the others get no events.
+	Publish(ctx context.Context, limit int) ([]*model.SessionEvent, error)
+	// ListAfter returns up to limit published events of a session numbered after seq, in order
+	ListAfter(ctx context.Context, sessionID uuid.UUID, seq int64, limit int) ([]*model.SessionEvent, error)
+	// LastSeq returns the number of the last published event of a session, 0 when there is none
+	LastSeq(ctx context.Context, sessionID uuid.UUID) (int64, error)
+	// DeleteBefore removes up to limit events created before t and returns how many it removed
+	DeleteBefore(ctx context.Context, t time.Time, limit int) (int64, error)
+}
+
+type sessionEventRepo struct {
+	db *gorm.DB
+}
+
+func NewSessionEventRepo(db *gorm.DB) SessionEventRepo {
+	return &sessionEventRepo{db: db}
+}
+
+func (r *sessionEventRepo) Publish(ctx context.Context, limit int) ([]*model.SessionEvent, error) {
+	var events []*model.SessionEvent
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", sessionEventRelayLock).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+		// The numbers are drawn while the ordered subquery is read, so they follow the write order
+		return tx.Raw(`
+			WITH next AS (
+				SELECT id, nextval('session_event_seq') AS seq
+				FROM (SELECT id FROM session_events WHERE seq IS NULL ORDER BY id LIMIT ?) unpublished
+			)
+			UPDATE session_events e SET seq = next.seq FROM next WHERE e.id = next.id
+			RETURNING e.*`, limit).Scan(&events).Error
+	})
+	sort.Slice(events, func(i, j int) bool { return *events[i].Seq < *events[j].Seq })
+	return events, err
+}
+
+func (r *sessionEventRepo) ListAfter(ctx context.Context, sessionID uuid.UUID, seq int64, limit int) ([]*model.SessionEvent, error) {
+	var events []*model.SessionEvent
+	err := r.db.WithContext(ctx).
+		Where("session_id = ? AND seq > ?", sessionID, seq).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *sessionEventRepo) LastSeq(ctx context.Context, sessionID uuid.UUID) (int64, error) {
+	var seq int64
+	err := r.db.WithContext(ctx).Model(&model.SessionEvent{}).
+		Where("session_id = ? AND seq IS NOT NULL", sessionID).
+		Select("COALESCE(MAX(seq), 0)").
+		Scan(&seq).Error
+	return seq, err
+}
+
+func (r *sessionEventRepo) DeleteBefore(ctx context.Context, t time.Time, limit int) (int64, error) {
+	res := r.db.WithContext(ctx).Exec(
+		"DELETE FROM session_events WHERE id IN (SELECT id FROM session_events WHERE created_at < ? LIMIT ?)",
+		t, limit,
+	)
+	return res.RowsAffected, res.Error
+}
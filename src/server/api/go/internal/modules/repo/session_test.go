@@ -8,7 +8,6 @@ import (
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -47,8 +46,7 @@ func TestSessionRepo_GetDisableTaskTracking(t *testing.T) {
 		return // Test was skipped
 	}
 
-	logger, _ := zap.NewDevelopment()
-	repo := NewSessionRepo(db, nil, nil, logger)
+	repo := NewSessionRepo(db)
 	ctx := context.Background()
 
 	// Create a project
@@ -11,9 +11,11 @@ import (
 
 type SpaceRepo interface {
 	Create(ctx context.Context, s *model.Space) error
-	Delete(ctx context.Context, s *model.Space) error
+	// Delete moves the space to the trash, see TrashRepo
+	Delete(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) error
+	// Update returns gorm.ErrRecordNotFound when the space is not in the project of s
 	Update(ctx context.Context, s *model.Space) error
-	Get(ctx context.Context, s *model.Space) (*model.Space, error)
+	Get(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) (*model.Space, error)
 	ListWithCursor(ctx context.Context, projectID uuid.UUID, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]model.Space, error)
 	ListExperienceConfirmationsWithCursor(ctx context.Context, spaceID uuid.UUID, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]model.ExperienceConfirmation, error)
 	GetExperienceConfirmation(ctx context.Context, spaceID uuid.UUID, experienceID uuid.UUID) (*model.ExperienceConfirmation, error)
@@ -30,16 +32,32 @@ func (r *spaceRepo) Create(ctx context.Context, s *model.Space) error {
 	return r.db.WithContext(ctx).Create(s).Error
 }
 
-func (r *spaceRepo) Delete(ctx context.Context, s *model.Space) error {
-	return r.db.WithContext(ctx).Delete(s).Error
+// Delete moves a space to the trash. Its sessions stay connected to it until it is purged.
+func (r *spaceRepo) Delete(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) error {
+	return affectedOne(r.db.WithContext(ctx).Where("id = ? AND project_id = ?", spaceID, projectID).Delete(&model.Space{}))
 }
 
 func (r *spaceRepo) Update(ctx context.Context, s *model.Space) error {
-	return r.db.WithContext(ctx).Where(&model.Space{ID: s.ID}).Updates(s).Error
+	return affectedOne(r.db.WithContext(ctx).Where("id = ? AND project_id = ?", s.ID, s.ProjectID).Updates(s))
 }
 
-func (r *spaceRepo) Get(ctx context.Context, s *model.Space) (*model.Space, error) {
-	return s, r.db.WithContext(ctx).Where(&model.Space{ID: s.ID}).First(s).Error
+func (r *spaceRepo) Get(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) (*model.Space, error) {
+	var space model.Space
+	if err := r.db.WithContext(ctx).Where("id = ? AND project_id = ?", spaceID, projectID).First(&space).Error; err != nil {
+		return nil, err
+	}
+	return &space, nil
+}
+
+// affectedOne returns gorm.ErrRecordNotFound when a scoped write matched no row
+func affectedOne(res *gorm.DB) error {
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
 func (r *spaceRepo) ListWithCursor(ctx context.Context, projectID uuid.UUID, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]model.Space, error) {
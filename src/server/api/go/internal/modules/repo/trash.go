@@ -0,0 +1,296 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TrashKind is the kind of a resource in the trash
+type TrashKind string
+
+const (
+	TrashSession TrashKind = "session"
+	TrashSpace   TrashKind = "space"
+	TrashDisk    TrashKind = "disk"
+)
+
+// TrashEntry is a session, space or disk in the trash
+type TrashEntry struct {
+	Kind      TrashKind
+	ProjectID uuid.UUID
+	ID        uuid.UUID
+	DeletedAt time.Time
+}
+
+// TrashRepo reads and empties the trash. Deleting a session, space or disk only sets its
+// deleted_at, so it can be restored; its rows, and the references to its assets, are removed
+// by Purge afterwards.
+type TrashRepo interface {
+	// ListSessions lists the sessions of a project deleted since the given time, the most
+	// recently deleted first
+	ListSessions(ctx context.Context, projectID uuid.UUID, since time.Time, afterDeletedAt time.Time, afterID uuid.UUID, limit int) ([]model.Session, error)
+	ListSpaces(ctx context.Context, projectID uuid.UUID, since time.Time, afterDeletedAt time.Time, afterID uuid.UUID, limit int) ([]model.Space, error)
+	ListDisks(ctx context.Context, projectID uuid.UUID, since time.Time, afterDeletedAt time.Time, afterID uuid.UUID, limit int) ([]*model.Disk, error)
+	// RestoreSession takes a session deleted since the given time out of the trash. It returns
+	// gorm.ErrRecordNotFound when the trash of the project holds no such session.
+	RestoreSession(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID, since time.Time) (*model.Session, error)
+	RestoreSpace(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID, since time.Time) (*model.Space, error)
+	// RestoreDisk returns ErrDiskNameExists when another disk of the project took the name of
+	// the disk while it was in the trash
+	RestoreDisk(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, since time.Time) (*model.Disk, error)
+	// ListExpired returns up to limit resources of any project deleted before the given time,
+	// the oldest first
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]TrashEntry, error)
+	// Purge removes a resource in the trash with everything it owns, and releases the assets
+	// its messages or artifacts referenced. It returns gorm.ErrRecordNotFound when the resource
+	// is no longer in the trash.
+	Purge(ctx context.Context, e TrashEntry) error
+}
+
+type trashRepo struct {
+	db                 *gorm.DB
+	assetReferenceRepo AssetReferenceRepo
+	s3                 blob.BlobStore
+}
+
+func NewTrashRepo(db *gorm.DB, assetReferenceRepo AssetReferenceRepo, s3 blob.BlobStore) TrashRepo {
+	return &trashRepo{
+		db:                 db,
+		assetReferenceRepo: assetReferenceRepo,
+		s3:                 s3,
+	}
+}
+
+// listTrash lists the rows of a project deleted since the given time, the most recently deleted first
+func listTrash[T any](db *gorm.DB, projectID uuid.UUID, since time.Time, afterDeletedAt time.Time, afterID uuid.UUID, limit int) ([]T, error) {
+	q := db.Unscoped().Where("project_id = ? AND deleted_at >= ?", projectID, since)
+
+	// Apply cursor-based pagination filter if cursor is provided
+	if !afterDeletedAt.IsZero() && afterID != uuid.Nil {
+		q = q.Where(
+			"(deleted_at < ?) OR (deleted_at = ? AND id < ?)",
+			afterDeletedAt, afterDeletedAt, afterID,
+		)
+	}
+
+	var rows []T
+	return rows, q.Order("deleted_at DESC, id DESC").Limit(limit).Find(&rows).Error
+}
+
+// restore clears the deleted_at of a row of a project deleted since the given time and reads it back
+func restore[T any](db *gorm.DB, projectID uuid.UUID, id uuid.UUID, since time.Time) (*T, error) {
+	row := new(T)
+	res := db.Unscoped().Model(row).
+		Where("id = ? AND project_id = ? AND deleted_at >= ?", id, projectID, since).
+		Update("deleted_at", nil)
+	if err := affectedOne(res); err != nil {
+		return nil, err
+	}
+	return row, db.Where("id = ?", id).First(row).Error
+}
+
+func (r *trashRepo) ListSessions(ctx context.Context, projectID uuid.UUID, since time.Time, afterDeletedAt time.Time, afterID uuid.UUID, limit int) ([]model.Session, error) {
+	return listTrash[model.Session](r.db.WithContext(ctx), projectID, since, afterDeletedAt, afterID, limit)
+}
+
+func (r *trashRepo) ListSpaces(ctx context.Context, projectID uuid.UUID, since time.Time, afterDeletedAt time.Time, afterID uuid.UUID, limit int) ([]model.Space, error) {
+	return listTrash[model.Space](r.db.WithContext(ctx), projectID, since, afterDeletedAt, afterID, limit)
+}
+
+func (r *trashRepo) ListDisks(ctx context.Context, projectID uuid.UUID, since time.Time, afterDeletedAt time.Time, afterID uuid.UUID, limit int) ([]*model.Disk, error) {
+	return listTrash[*model.Disk](r.db.WithContext(ctx), projectID, since, afterDeletedAt, afterID, limit)
+}
+
+func (r *trashRepo) RestoreSession(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID, since time.Time) (*model.Session, error) {
+	return restore[model.Session](r.db.WithContext(ctx), projectID, sessionID, since)
+}
+
+func (r *trashRepo) RestoreSpace(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID, since time.Time) (*model.Space, error) {
+	return restore[model.Space](r.db.WithContext(ctx), projectID, spaceID, since)
+}
+
+func (r *trashRepo) RestoreDisk(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, since time.Time) (*model.Disk, error) {
+	disk, err := restore[model.Disk](r.db.WithContext(ctx), projectID, diskID, since)
+	return disk, translateDiskErr(err)
+}
+
+func (r *trashRepo) ListExpired(ctx context.Context, before time.Time, limit int) ([]TrashEntry, error) {
+	var entries []TrashEntry
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT * FROM (
+			SELECT 'session' AS kind, project_id, id, deleted_at FROM sessions WHERE deleted_at < @before
+			UNION ALL SELECT 'space', project_id, id, deleted_at FROM spaces WHERE deleted_at < @before
+			UNION ALL SELECT 'disk', project_id, id, deleted_at FROM disks WHERE deleted_at < @before
+		) AS t
+		ORDER BY deleted_at ASC, id ASC
+		LIMIT @limit`,
+		map[string]interface{}{"before": before, "limit": limit},
+	).Scan(&entries).Error
+	return entries, err
+}
+
+func (r *trashRepo) Purge(ctx context.Context, e TrashEntry) error {
+	switch e.Kind {
+	case TrashSession:
+		return r.purgeSession(ctx, e.ProjectID, e.ID)
+	case TrashSpace:
+		return r.purgeSpace(ctx, e.ProjectID, e.ID)
+	case TrashDisk:
+		return r.purgeDisk(ctx, e.ProjectID, e.ID)
+	default:
+		return fmt.Errorf("unknown trash kind %q", e.Kind)
+	}
+}
+
+// trashed locks a row of a project that is in the trash, so it cannot be restored while it is purged
+func trashed(tx *gorm.DB, projectID uuid.UUID, id uuid.UUID, dest interface{}) error {
+	return tx.Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND project_id = ? AND deleted_at IS NOT NULL", id, projectID).
+		First(dest).Error
+}
+
+// purgeSession reads the parts of the messages before locking the session, so the lock is not
+// held while they download. A message whose parts cannot be read fails the purge, to be retried
+// later, since its assets would otherwise never be released.
+func (r *trashRepo) purgeSession(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) error {
+	var messages []model.Message
+	if err := r.db.WithContext(ctx).Select("id", "parts_asset_meta").
+		Where("session_id = ?", sessionID).Find(&messages).Error; err != nil {
+		return fmt.Errorf("query messages: %w", err)
+	}
+	partAssets, err := r.partAssets(ctx, messages)
+	if err != nil {
+		return err
+	}
+
+	var released []string
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var session model.Session
+		if err := trashed(tx, projectID, sessionID, &session); err != nil {
+			return err
+		}
+
+		// Read the messages again under the lock; parts are never rewritten, so only messages
+		// added since they were downloaded are missing
+		var messages []model.Message
+		if err := tx.Select("id", "parts_asset_meta").Where("session_id = ?", sessionID).Find(&messages).Error; err != nil {
+			return fmt.Errorf("query messages: %w", err)
+		}
+		assets := make([]model.Asset, 0, len(messages))
+		for _, msg := range messages {
+			parts, ok := partAssets[msg.ID]
+			if !ok {
+				return fmt.Errorf("message %s was added while the session was purged", msg.ID)
+			}
+			assets = append(assets, msg.PartsAssetMeta.Data())
+			assets = append(assets, parts...)
+		}
+
+		// Delete the session (messages will be automatically deleted by CASCADE)
+		if err := tx.Unscoped().Delete(&session).Error; err != nil {
+			return fmt.Errorf("delete session: %w", err)
+		}
+
+		var err error
+		if released, err = r.assetReferenceRepo.WithTx(tx).ReleaseAssetRefs(ctx, projectID, assets); err != nil {
+			return fmt.Errorf("decrement asset references: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The purge is committed; an object that fails to delete is only an orphan now
+	_ = r.assetReferenceRepo.DeleteReleasedAssets(ctx, released)
+	return nil
+}
+
+// partAssets downloads the parts of each message and returns the assets they reference
+func (r *trashRepo) partAssets(ctx context.Context, messages []model.Message) (map[uuid.UUID][]model.Asset, error) {
+	assets := make(map[uuid.UUID][]model.Asset, len(messages))
+	for _, msg := range messages {
+		var found []model.Asset
+		if key := msg.PartsAssetMeta.Data().S3Key; key != "" {
+			parts := []model.Part{}
+			if err := r.s3.DownloadJSON(ctx, key, &parts); err != nil {
+				return nil, fmt.Errorf("download parts of message %s: %w", msg.ID, err)
+			}
+			for _, part := range parts {
+				if part.Asset != nil {
+					found = append(found, *part.Asset)
+				}
+			}
+		}
+		assets[msg.ID] = found
+	}
+	return assets, nil
+}
+
+func (r *trashRepo) purgeSpace(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var space model.Space
+		if err := trashed(tx, projectID, spaceID, &space); err != nil {
+			return err
+		}
+
+		// Sessions are disconnected and experience confirmations deleted by the foreign keys
+		if err := tx.Unscoped().Delete(&space).Error; err != nil {
+			return fmt.Errorf("delete space: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *trashRepo) purgeDisk(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) error {
+	var released []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var disk model.Disk
+		if err := trashed(tx, projectID, diskID, &disk); err != nil {
+			return err
+		}
+
+		// Count the assets of the artifacts by SHA256 before the disk is deleted, as the
+		// artifacts go with it by CASCADE
+		var counts []struct {
+			SHA256 string
+			N      int
+		}
+		if err := tx.Model(&model.Artifact{}).
+			Select("asset_meta->>'sha256' AS sha256, count(*) AS n").
+			Where("disk_id = ? AND asset_meta->>'sha256' <> ''", diskID).
+			Group("asset_meta->>'sha256'").
+			Scan(&counts).Error; err != nil {
+			return fmt.Errorf("count artifact assets: %w", err)
+		}
+		grouped := make(map[string]int, len(counts))
+		for _, c := range counts {
+			grouped[c.SHA256] = c.N
+		}
+
+		if err := tx.Unscoped().Delete(&disk).Error; err != nil {
+			return fmt.Errorf("delete disk: %w", err)
+		}
+
+		var err error
+		if released, err = r.assetReferenceRepo.WithTx(tx).ReleaseAssetCounts(ctx, projectID, grouped); err != nil {
+			return fmt.Errorf("decrement asset references: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The purge is committed; an object that fails to delete is only an orphan now
+	_ = r.assetReferenceRepo.DeleteReleasedAssets(ctx, released)
+	return nil
+}
@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// setupTrashTestDB creates a test database connection for trash tests
+func setupTrashTestDB(t *testing.T) *gorm.DB {
+	// Skip if no test database is configured
+	dsn := "host=localhost user=acontext password=helloworld dbname=acontext port=15432 sslmode=disable"
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Skip("Test database not available, skipping integration tests")
+		return nil
+	}
+
+	// Auto migrate all required tables
+	err = db.AutoMigrate(
+		&model.Project{},
+		&model.Session{},
+		&model.Message{},
+		&model.AssetReference{},
+	)
+	require.NoError(t, err)
+
+	return db
+}
+
+// TestTrashRepo_PurgeSession tests that a session is only purged once the parts of all its
+// messages could be read, and that the assets of its messages are released with it
+func TestTrashRepo_PurgeSession(t *testing.T) {
+	db := setupTrashTestDB(t)
+	if db == nil {
+		return // Test was skipped
+	}
+
+	store := blob.NewMemoryStore(blob.NewURLSigner("http://api.test", []byte("secret")))
+	assetRefs := NewAssetReferenceRepo(db, store)
+	repo := NewTrashRepo(db, assetRefs, store)
+	ctx := context.Background()
+
+	project := &model.Project{
+		ID:               uuid.New(),
+		SecretKeyHMAC:    "test_hmac_trash",
+		SecretKeyHashPHC: "test_hash_trash",
+	}
+	require.NoError(t, db.Create(project).Error)
+	defer func() {
+		db.Exec("DELETE FROM sessions WHERE project_id = ?", project.ID)
+		db.Exec("DELETE FROM asset_references WHERE project_id = ?", project.ID)
+		db.Exec("DELETE FROM projects WHERE id = ?", project.ID)
+	}()
+
+	session := &model.Session{ID: uuid.New(), ProjectID: project.ID}
+	require.NoError(t, db.Create(session).Error)
+
+	file := model.Asset{S3Key: "assets/" + project.ID.String() + "/file", SHA256: strings.Repeat("f", 64)}
+	_, err := store.PutObject(ctx, file.S3Key, "text/plain", strings.NewReader("file"), nil)
+	require.NoError(t, err)
+	require.NoError(t, assetRefs.IncrementAssetRef(ctx, project.ID, file))
+
+	partsKey := "parts/" + project.ID.String() + "/message.json"
+	parts := model.Asset{S3Key: partsKey, SHA256: strings.Repeat("p", 64)}
+	require.NoError(t, assetRefs.IncrementAssetRef(ctx, project.ID, parts))
+	require.NoError(t, db.Create(&model.Message{
+		SessionID:      session.ID,
+		Role:           "user",
+		PartsAssetMeta: datatypes.NewJSONType(parts),
+	}).Error)
+	require.NoError(t, db.Delete(session).Error)
+
+	entry := TrashEntry{Kind: TrashSession, ProjectID: project.ID, ID: session.ID, DeletedAt: time.Now()}
+
+	t.Run("unreadable parts fail the purge", func(t *testing.T) {
+		assert.Error(t, repo.Purge(ctx, entry))
+
+		var count int64
+		require.NoError(t, db.Unscoped().Model(&model.Session{}).Where("id = ?", session.ID).Count(&count).Error)
+		assert.Equal(t, int64(1), count, "the session stays in the trash to be retried")
+		require.NoError(t, db.Model(&model.AssetReference{}).Where("project_id = ?", project.ID).Count(&count).Error)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("purge releases the assets of the messages", func(t *testing.T) {
+		_, err := store.PutObject(ctx, partsKey, "application/json",
+			strings.NewReader(`[{"type":"file","asset":{"s3_key":"`+file.S3Key+`","sha256":"`+file.SHA256+`"}}]`), nil)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.Purge(ctx, entry))
+
+		var count int64
+		require.NoError(t, db.Unscoped().Model(&model.Session{}).Where("id = ?", session.ID).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+		require.NoError(t, db.Model(&model.AssetReference{}).Where("project_id = ?", project.ID).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+		_, err = store.DownloadFile(ctx, file.S3Key)
+		assert.Error(t, err, "the object of a released asset is deleted")
+	})
+}
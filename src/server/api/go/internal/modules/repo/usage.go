@@ -0,0 +1,131 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// UsageBucket is the total of one metric tag within one time bucket
+type UsageBucket struct {
+	Bucket time.Time
+	Tag    string
+	Total  int64
+}
+
+// StorageUsage is what the assets referenced by a project currently take up
+type StorageUsage struct {
+	Bytes  int64 `json:"bytes"`
+	Assets int64 `json:"assets"`
+}
+
+// UsageRepo records usage counters as daily metric rows and reads them back.
+// Each row holds the running total of one tag for one project and UTC day, the same
+// rollup the core uses for the metrics it records.
+type UsageRepo interface {
+	// Increment adds n to the counter of tag for the current UTC day
+	Increment(ctx context.Context, projectID uuid.UUID, tag string, n int64) error
+	// Totals sums the counters of tags recorded since from
+	Totals(ctx context.Context, projectID uuid.UUID, tags []string, from time.Time) (map[string]int64, error)
+	// Buckets sums the counters of every tag recorded in [from, to) per bucket, which is a
+	// Postgres date_trunc unit such as "day" or "month"
+	Buckets(ctx context.Context, projectID uuid.UUID, bucket string, from time.Time, to time.Time) ([]UsageBucket, error)
+	// Storage sums the size of the assets the project references
+	Storage(ctx context.Context, projectID uuid.UUID) (*StorageUsage, error)
+	GetProjectConfigs(ctx context.Context, projectID uuid.UUID) (datatypes.JSONMap, error)
+}
+
+type usageRepo struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+func NewUsageRepo(db *gorm.DB) UsageRepo {
+	return &usageRepo{db: db, now: time.Now}
+}
+
+func (r *usageRepo) Increment(ctx context.Context, projectID uuid.UUID, tag string, n int64) error {
+	day := r.now().UTC().Truncate(24 * time.Hour)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialize the first write of the day so concurrent requests share one row
+		lockKey := projectID.String() + ":" + tag + ":" + day.Format(time.DateOnly)
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", lockKey).Error; err != nil {
+			return err
+		}
+
+		var metric model.Metric
+		err := tx.Where("project_id = ? AND tag = ? AND created_at >= ? AND created_at < ?", projectID, tag, day, day.Add(24*time.Hour)).
+			Order("created_at DESC").
+			First(&metric).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			metric = model.Metric{ProjectID: projectID, Tag: tag, CreatedAt: r.now()}
+			if err := tx.Create(&metric).Error; err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Metric{}).Where("id = ?", metric.ID).
+			Update("increment", gorm.Expr("increment + ?", n)).Error
+	})
+}
+
+func (r *usageRepo) Totals(ctx context.Context, projectID uuid.UUID, tags []string, from time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Tag   string
+		Total int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.Metric{}).
+		Select("tag, COALESCE(SUM(increment), 0) AS total").
+		Where("project_id = ? AND tag IN ? AND created_at >= ?", projectID, tags, from).
+		Group("tag").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int64, len(tags))
+	for _, row := range rows {
+		totals[row.Tag] = row.Total
+	}
+	return totals, nil
+}
+
+func (r *usageRepo) Buckets(ctx context.Context, projectID uuid.UUID, bucket string, from time.Time, to time.Time) ([]UsageBucket, error) {
+	var buckets []UsageBucket
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT date_trunc(?, created_at AT TIME ZONE 'UTC') AS bucket, tag, SUM(increment) AS total
+		FROM metrics
+		WHERE project_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, bucket, projectID, from, to).
+		Scan(&buckets).Error
+	return buckets, err
+}
+
+func (r *usageRepo) Storage(ctx context.Context, projectID uuid.UUID) (*StorageUsage, error) {
+	var usage StorageUsage
+	err := r.db.WithContext(ctx).Model(&model.AssetReference{}).
+		Select("COALESCE(SUM((asset_meta->>'size_b')::bigint), 0) AS bytes, COUNT(*) AS assets").
+		Where("project_id = ? AND ref_count > 0", projectID).
+		Scan(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (r *usageRepo) GetProjectConfigs(ctx context.Context, projectID uuid.UUID) (datatypes.JSONMap, error) {
+	var project model.Project
+	err := r.db.WithContext(ctx).Select("configs").Where("id = ?", projectID).First(&project).Error
+	if err != nil {
+		return nil, err
+	}
+	return project.Configs, nil
+}
@@ -0,0 +1,371 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// webhookOutboxTriggers write the events of tasks, experiences, SOPs, messages and artifacts to
+// webhook_outbox in the transaction that changed them. The core service writes most of these
+// tables, so the events are captured in the database rather than in either service. Nothing is
+// written for projects without an enabled endpoint subscribed to the event.
+const webhookOutboxTriggers = `
+CREATE OR REPLACE FUNCTION webhook_enqueue(p_project_id uuid, p_event_type text, p_data jsonb) RETURNS void AS $$
+BEGIN
+	IF p_project_id IS NULL THEN
+		RETURN;
+	END IF;
+	IF EXISTS (
+		SELECT 1 FROM webhook_endpoints
+		WHERE project_id = p_project_id AND enabled AND event_types @> jsonb_build_array(p_event_type)
+	) THEN
+		INSERT INTO webhook_outbox (project_id, event_type, data) VALUES (p_project_id, p_event_type, p_data);
+	END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION webhook_message_stored() RETURNS trigger AS $$
+BEGIN
+	PERFORM webhook_enqueue(
+		(SELECT project_id FROM sessions WHERE id = NEW.session_id),
+		'message.stored',
+		jsonb_build_object(
+			'id', NEW.id,
+			'session_id', NEW.session_id,
+			'parent_id', NEW.parent_id,
+			'role', NEW.role,
+			'created_at', NEW.created_at
+		)
+	);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION webhook_task_changed() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE' AND OLD.status IS NOT DISTINCT FROM NEW.status AND OLD.data IS NOT DISTINCT FROM NEW.data THEN
+		RETURN NULL;
+	END IF;
+	PERFORM webhook_enqueue(
+		NEW.project_id,
+		CASE TG_OP WHEN 'INSERT' THEN 'task.created' ELSE 'task.updated' END,
+		jsonb_build_object(
+			'id', NEW.id,
+			'session_id', NEW.session_id,
+			'order', NEW."order",
+			'status', NEW.status,
+			'data', NEW.data,
+			'is_planning', NEW.is_planning,
+			'updated_at', NEW.updated_at
+		)
+	);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION webhook_experience_pending() RETURNS trigger AS $$
+BEGIN
+	PERFORM webhook_enqueue(
+		(SELECT project_id FROM spaces WHERE id = NEW.space_id),
+		'experience.pending',
+		jsonb_build_object(
+			'id', NEW.id,
+			'space_id', NEW.space_id,
+			'task_id', NEW.task_id,
+			'experience_data', NEW.experience_data,
+			'created_at', NEW.created_at
+		)
+	);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION webhook_sop_saved() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE' AND OLD.title IS NOT DISTINCT FROM NEW.title AND OLD.props IS NOT DISTINCT FROM NEW.props THEN
+		RETURN NULL;
+	END IF;
+	PERFORM webhook_enqueue(
+		(SELECT project_id FROM spaces WHERE id = NEW.space_id),
+		'sop.saved',
+		jsonb_build_object(
+			'id', NEW.id,
+			'space_id', NEW.space_id,
+			'parent_id', NEW.parent_id,
+			'title', NEW.title,
+			'props', NEW.props,
+			'updated_at', NEW.updated_at
+		)
+	);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION webhook_artifact_changed() RETURNS trigger AS $$
+DECLARE
+	a artifacts;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		a := OLD;
+	ELSE
+		a := NEW;
+	END IF;
+	IF TG_OP = 'UPDATE' AND OLD.path IS NOT DISTINCT FROM NEW.path AND OLD.filename IS NOT DISTINCT FROM NEW.filename
+		AND OLD.meta IS NOT DISTINCT FROM NEW.meta AND OLD.asset_meta IS NOT DISTINCT FROM NEW.asset_meta THEN
+		RETURN NULL;
+	END IF;
+	-- When a disk is deleted its artifacts go with it; the disk is already gone, so nothing is sent
+	PERFORM webhook_enqueue(
+		(SELECT project_id FROM disks WHERE id = a.disk_id),
+		'artifact.changed',
+		jsonb_build_object(
+			'action', CASE TG_OP WHEN 'INSERT' THEN 'created' WHEN 'UPDATE' THEN 'updated' ELSE 'deleted' END,
+			'disk_id', a.disk_id,
+			'path', a.path,
+			'filename', a.filename,
+			'meta', a.meta,
+			'mime', a.asset_meta->>'mime',
+			'size_b', (a.asset_meta->>'size_b')::bigint,
+			'updated_at', a.updated_at
+		)
+	);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS webhook_message_stored ON messages;
+CREATE TRIGGER webhook_message_stored AFTER INSERT ON messages
+	FOR EACH ROW EXECUTE FUNCTION webhook_message_stored();
+
+DROP TRIGGER IF EXISTS webhook_task_changed ON tasks;
+CREATE TRIGGER webhook_task_changed AFTER INSERT OR UPDATE ON tasks
+	FOR EACH ROW EXECUTE FUNCTION webhook_task_changed();
+
+DROP TRIGGER IF EXISTS webhook_experience_pending ON experience_confirmations;
+CREATE TRIGGER webhook_experience_pending AFTER INSERT ON experience_confirmations
+	FOR EACH ROW EXECUTE FUNCTION webhook_experience_pending();
+
+DROP TRIGGER IF EXISTS webhook_sop_saved ON blocks;
+CREATE TRIGGER webhook_sop_saved AFTER INSERT OR UPDATE ON blocks
+	FOR EACH ROW WHEN (NEW.type = 'sop') EXECUTE FUNCTION webhook_sop_saved();
+
+DROP TRIGGER IF EXISTS webhook_artifact_changed ON artifacts;
+CREATE TRIGGER webhook_artifact_changed AFTER INSERT OR UPDATE OR DELETE ON artifacts
+	FOR EACH ROW EXECUTE FUNCTION webhook_artifact_changed();
+`
+
+// InstallWebhookOutboxTriggers creates or replaces the triggers that fill webhook_outbox. It runs
+// with the auto migration, once the tables exist.
+func InstallWebhookOutboxTriggers(db *gorm.DB) error {
+	return db.Exec(webhookOutboxTriggers).Error
+}
+
+type WebhookRepo interface {
+	Create(ctx context.Context, w *model.WebhookEndpoint) error
+	List(ctx context.Context, projectID uuid.UUID) ([]*model.WebhookEndpoint, error)
+	Get(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (*model.WebhookEndpoint, error)
+	// Update saves the URL, description, event types and enabled flag of w
+	Update(ctx context.Context, w *model.WebhookEndpoint) error
+	// Delete removes an endpoint and its deliveries. It returns gorm.ErrRecordNotFound when
+	// the project has no such endpoint.
+	Delete(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error
+
+	CreateDelivery(ctx context.Context, d *model.WebhookDelivery) error
+	GetDelivery(ctx context.Context, webhookID uuid.UUID, id uuid.UUID) (*model.WebhookDelivery, error)
+	ListDeliveriesWithCursor(ctx context.Context, webhookID uuid.UUID, status string, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.WebhookDelivery, error)
+
+	// DispatchOutbox takes up to limit events off the outbox and creates a delivery for every
+	// endpoint subscribed to each, with build. Concurrent callers take different events.
+	// It returns how many events it took.
+	DispatchOutbox(ctx context.Context, limit int, build func(e *model.WebhookOutbox, w *model.WebhookEndpoint) (*model.WebhookDelivery, error)) (int, error)
+	// ClaimDueDeliveries takes up to limit pending deliveries due at now, with their endpoint.
+	// Their attempt is counted and they are not due again until now+lease, so concurrent
+	// callers take different deliveries and one whose sender died is retried.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error)
+	// FinishAttempt saves the status and outcome of the last attempt of d
+	FinishAttempt(ctx context.Context, d *model.WebhookDelivery) error
+}
+
+type webhookRepo struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepo(db *gorm.DB) WebhookRepo {
+	return &webhookRepo{db: db}
+}
+
+func (r *webhookRepo) Create(ctx context.Context, w *model.WebhookEndpoint) error {
+	return r.db.WithContext(ctx).Create(w).Error
+}
+
+func (r *webhookRepo) List(ctx context.Context, projectID uuid.UUID) ([]*model.WebhookEndpoint, error) {
+	var webhooks []*model.WebhookEndpoint
+	err := r.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order("created_at ASC, id ASC").
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepo) Get(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (*model.WebhookEndpoint, error) {
+	var w model.WebhookEndpoint
+	if err := r.db.WithContext(ctx).Where("id = ? AND project_id = ?", id, projectID).First(&w).Error; err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *webhookRepo) Update(ctx context.Context, w *model.WebhookEndpoint) error {
+	return r.db.WithContext(ctx).Model(w).
+		Where("project_id = ?", w.ProjectID).
+		Select("url", "description", "event_types", "enabled").
+		Updates(w).Error
+}
+
+func (r *webhookRepo) Delete(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	res := r.db.WithContext(ctx).Where("id = ? AND project_id = ?", id, projectID).Delete(&model.WebhookEndpoint{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepo) CreateDelivery(ctx context.Context, d *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(d).Error
+}
+
+func (r *webhookRepo) GetDelivery(ctx context.Context, webhookID uuid.UUID, id uuid.UUID) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	if err := r.db.WithContext(ctx).Where("id = ? AND webhook_id = ?", id, webhookID).First(&d).Error; err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *webhookRepo) ListDeliveriesWithCursor(ctx context.Context, webhookID uuid.UUID, status string, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.WebhookDelivery, error) {
+	q := r.db.WithContext(ctx).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+
+	// Apply cursor-based pagination filter if cursor is provided
+	if !afterCreatedAt.IsZero() && afterID != uuid.Nil {
+		// Determine comparison operator based on sort direction
+		comparisonOp := ">"
+		if timeDesc {
+			comparisonOp = "<"
+		}
+		q = q.Where(
+			"(created_at "+comparisonOp+" ?) OR (created_at = ? AND id "+comparisonOp+" ?)",
+			afterCreatedAt, afterCreatedAt, afterID,
+		)
+	}
+
+	// Apply ordering based on sort direction
+	orderBy := "created_at ASC, id ASC"
+	if timeDesc {
+		orderBy = "created_at DESC, id DESC"
+	}
+
+	var deliveries []*model.WebhookDelivery
+	return deliveries, q.Order(orderBy).Limit(limit).Find(&deliveries).Error
+}
+
+func (r *webhookRepo) DispatchOutbox(ctx context.Context, limit int, build func(e *model.WebhookOutbox, w *model.WebhookEndpoint) (*model.WebhookDelivery, error)) (int, error) {
+	var taken int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var events []*model.WebhookOutbox
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Order("id ASC").
+			Limit(limit).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		projectIDs := make([]uuid.UUID, 0, len(events))
+		seen := make(map[uuid.UUID]bool, len(events))
+		for _, e := range events {
+			if !seen[e.ProjectID] {
+				seen[e.ProjectID] = true
+				projectIDs = append(projectIDs, e.ProjectID)
+			}
+		}
+		var endpoints []*model.WebhookEndpoint
+		if err := tx.Where("project_id IN ? AND enabled", projectIDs).Find(&endpoints).Error; err != nil {
+			return err
+		}
+
+		// Endpoints disabled or unsubscribed since the event was written are skipped
+		var deliveries []*model.WebhookDelivery
+		ids := make([]int64, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+			for _, w := range endpoints {
+				if w.ProjectID != e.ProjectID || !w.Subscribes(e.EventType) {
+					continue
+				}
+				d, err := build(e, w)
+				if err != nil {
+					return err
+				}
+				deliveries = append(deliveries, d)
+			}
+		}
+		if len(deliveries) > 0 {
+			if err := tx.Create(&deliveries).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&model.WebhookOutbox{}).Error; err != nil {
+			return err
+		}
+		taken = len(events)
+		return nil
+	})
+	return taken, err
+}
+
+func (r *webhookRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		if err := tx.Model(&model.WebhookDelivery{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Model(&model.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": now.Add(lease),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Preload("Webhook").Where("id IN ?", ids).Find(&deliveries).Error
+	})
+	return deliveries, err
+}
+
+func (r *webhookRepo) FinishAttempt(ctx context.Context, d *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(d).
+		Select("status", "next_attempt_at", "last_attempt_at", "response_status", "response_body", "error", "delivered_at").
+		Updates(d).Error
+}
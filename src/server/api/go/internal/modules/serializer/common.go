@@ -1,11 +1,20 @@
 package serializer
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
 )
 
 var logger *zap.Logger
@@ -15,6 +24,12 @@ func SetLogger(log *zap.Logger) {
 	logger = log
 }
 
+// ProblemContentType is the media type of error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix makes the type URI of a problem from its code
+const problemTypePrefix = "urn:acontext:error:"
+
 // Response
 type Response struct {
 	Code  int         `json:"code"`
@@ -23,53 +38,134 @@ type Response struct {
 	Error string      `json:"error,omitempty"`
 }
 
-// TraceErrorResponse
-type TrackedErrorResponse struct {
-	Response
-	TraceID string `json:"trace_id"`
+// Problem is the body of error responses, an RFC 7807 problem detail. Code is the stable
+// machine-readable code of the error; Detail is free text for humans.
+type Problem struct {
+	Type     string `json:"type" example:"urn:acontext:error:session_not_found"`
+	Title    string `json:"title" example:"Session not found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail,omitempty" example:"session not found"`
+	Instance string `json:"instance,omitempty" example:"/api/v1/session/123e4567-e89b-12d3-a456-426614174000"`
+	Code     string `json:"code" example:"session_not_found"`
+	TraceID  string `json:"trace_id" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	// Error is the underlying error, only outside release mode
+	Error string `json:"error,omitempty"`
+	// Extensions are members specific to the code, e.g. the problems of invalid configs
+	Extensions map[string]any `json:"-"`
+}
+
+// MarshalJSON inlines the extension members next to the standard ones
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	b, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return b, err
+	}
+	out := make(map[string]any, len(p.Extensions)+8)
+	for k, v := range p.Extensions {
+		out[k] = v
+	}
+	var std map[string]any
+	if err := json.Unmarshal(b, &std); err != nil {
+		return nil, err
+	}
+	for k, v := range std {
+		out[k] = v
+	}
+	return json.Marshal(out)
+}
+
+// Err answers the request with a problem of code and aborts it. msg is the detail, the title
+// of the code when empty.
+func Err(c *gin.Context, code apierr.Code, msg string, err error) {
+	ErrWith(c, code, msg, err, nil)
 }
 
-// Err
-func Err(errCode int, msg string, err error) Response {
-	res := Response{
-		Code: errCode,
-		Msg:  msg,
+// ErrWith is Err with extension members added to the problem
+func ErrWith(c *gin.Context, code apierr.Code, msg string, err error, ext map[string]any) {
+	if msg == "" {
+		msg = code.Title
+	}
+	p := Problem{
+		Type:       problemTypePrefix + code.ID,
+		Title:      code.Title,
+		Status:     code.Status,
+		Detail:     msg,
+		Code:       code.ID,
+		TraceID:    traceID(c),
+		Extensions: ext,
+	}
+	if c.Request != nil {
+		p.Instance = c.Request.URL.Path
 	}
 	// Log error if logger is available
 	if err != nil && logger != nil {
 		logger.Error("API error",
-			zap.Int("code", errCode),
+			zap.Int("status", code.Status),
+			zap.String("code", code.ID),
 			zap.String("msg", msg),
+			zap.String("trace_id", p.TraceID),
 			zap.Error(err),
 		)
 	}
 	// development mode, show error detail
 	if err != nil && gin.Mode() != gin.ReleaseMode {
-		res.Error = fmt.Sprintf("%+v", err)
+		p.Error = fmt.Sprintf("%+v", err)
 	}
-	return res
+
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(code.Status, p)
 }
 
-// DBErr
-func DBErr(msg string, err error) Response {
-	if msg == "" {
-		msg = "database error"
+// DBErr answers a failed read or write. Missing records answer not_found, unique violations
+// conflict and cursors that cannot be decoded invalid_request; anything else is an internal error.
+func DBErr(c *gin.Context, msg string, err error) {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		Err(c, apierr.NotFound, msg, err)
+	case errors.Is(err, gorm.ErrDuplicatedKey), errors.As(err, &pgErr) && pgErr.Code == "23505":
+		Err(c, apierr.Conflict, msg, err)
+	case errors.Is(err, paging.ErrInvalidCursor):
+		Err(c, apierr.InvalidRequest, msg, err)
+	default:
+		if msg == "" {
+			msg = "database error"
+		}
+		Err(c, apierr.Internal, msg, err)
 	}
-	return Err(http.StatusInternalServerError, msg, err)
 }
 
 // ParamErr
-func ParamErr(msg string, err error) Response {
+func ParamErr(c *gin.Context, msg string, err error) {
 	if msg == "" {
 		msg = "parameter error"
 	}
-	return Err(http.StatusBadRequest, msg, err)
+	Err(c, apierr.InvalidRequest, msg, err)
 }
 
 // AuthErr
-func AuthErr(msg string) Response {
+func AuthErr(c *gin.Context, msg string) {
 	if msg == "" {
 		msg = "authentication error"
 	}
-	return Err(http.StatusUnauthorized, msg, nil)
+	Err(c, apierr.Unauthorized, msg, nil)
+}
+
+// traceID returns the ID of the trace of the request. Without telemetry there is none, so a
+// random ID is made up and sent in X-Trace-Id; it is logged with the error either way.
+func traceID(c *gin.Context) string {
+	if c.Request != nil {
+		if sc := trace.SpanFromContext(c.Request.Context()).SpanContext(); sc.IsValid() {
+			return sc.TraceID().String()
+		}
+	}
+	if id := c.Writer.Header().Get("X-Trace-Id"); id != "" {
+		return id
+	}
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	id := hex.EncodeToString(b[:])
+	c.Header("X-Trace-Id", id)
+	return id
 }
@@ -1,18 +1,42 @@
 package serializer
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
 )
 
+// serve answers a request with respond and decodes the problem it wrote
+func serve(t *testing.T, respond func(c *gin.Context)) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/session/1", nil)
+	respond(c)
+
+	assert.True(t, c.IsAborted())
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w, body
+}
+
 func TestErr(t *testing.T) {
 	tests := []struct {
 		name    string
-		errCode int
+		code    apierr.Code
 		msg     string
 		err     error
 		ginMode string
@@ -20,7 +44,7 @@ func TestErr(t *testing.T) {
 	}{
 		{
 			name:    "basic error response",
-			errCode: http.StatusBadRequest,
+			code:    apierr.InvalidRequest,
 			msg:     "test error",
 			err:     nil,
 			ginMode: gin.ReleaseMode,
@@ -28,7 +52,7 @@ func TestErr(t *testing.T) {
 		},
 		{
 			name:    "response with error details (debug mode)",
-			errCode: http.StatusInternalServerError,
+			code:    apierr.Internal,
 			msg:     "server error",
 			err:     errors.New("detailed error information"),
 			ginMode: gin.DebugMode,
@@ -36,7 +60,7 @@ func TestErr(t *testing.T) {
 		},
 		{
 			name:    "with error but release mode (no error details)",
-			errCode: http.StatusInternalServerError,
+			code:    apierr.Internal,
 			msg:     "server error",
 			err:     errors.New("detailed error information"),
 			ginMode: gin.ReleaseMode,
@@ -44,9 +68,9 @@ func TestErr(t *testing.T) {
 		},
 		{
 			name:    "test mode shows error details",
-			errCode: http.StatusBadRequest,
-			msg:     "parameter error",
-			err:     errors.New("parameter validation failed"),
+			code:    apierr.SessionNotFound,
+			msg:     "session not found",
+			err:     errors.New("record not found"),
 			ginMode: gin.TestMode,
 			wantErr: true,
 		},
@@ -57,19 +81,22 @@ func TestErr(t *testing.T) {
 			// Set Gin mode
 			gin.SetMode(tt.ginMode)
 
-			response := Err(tt.errCode, tt.msg, tt.err)
+			w, body := serve(t, func(c *gin.Context) { Err(c, tt.code, tt.msg, tt.err) })
 
-			assert.Equal(t, tt.errCode, response.Code)
-			assert.Equal(t, tt.msg, response.Msg)
-			assert.Nil(t, response.Data)
+			assert.Equal(t, tt.code.Status, w.Code)
+			assert.Equal(t, "urn:acontext:error:"+tt.code.ID, body["type"])
+			assert.Equal(t, tt.code.Title, body["title"])
+			assert.Equal(t, float64(tt.code.Status), body["status"])
+			assert.Equal(t, tt.code.ID, body["code"])
+			assert.Equal(t, tt.msg, body["detail"])
+			assert.Equal(t, "/api/v1/session/1", body["instance"])
+			assert.Len(t, body["trace_id"], 32)
+			assert.Equal(t, body["trace_id"], w.Header().Get("X-Trace-Id"))
 
 			if tt.wantErr {
-				assert.NotEmpty(t, response.Error)
-				if tt.err != nil {
-					assert.Contains(t, response.Error, tt.err.Error())
-				}
+				assert.Contains(t, body["error"], tt.err.Error())
 			} else {
-				assert.Empty(t, response.Error)
+				assert.NotContains(t, body, "error")
 			}
 		})
 	}
@@ -78,30 +105,67 @@ func TestErr(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 }
 
+func TestErr_DefaultDetail(t *testing.T) {
+	_, body := serve(t, func(c *gin.Context) { Err(c, apierr.DiskNotFound, "", nil) })
+	assert.Equal(t, "Disk not found", body["detail"])
+}
+
+func TestErrWith(t *testing.T) {
+	w, body := serve(t, func(c *gin.Context) {
+		ErrWith(c, apierr.InvalidConfigs, "invalid configs", nil, map[string]any{
+			"problems": []string{"mode"},
+			"code":     "overridden",
+		})
+	})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, []any{"mode"}, body["problems"])
+	// Extensions cannot replace the standard members
+	assert.Equal(t, "invalid_configs", body["code"])
+}
+
 func TestDBErr(t *testing.T) {
 	tests := []struct {
-		name    string
-		msg     string
-		err     error
-		wantMsg string
+		name     string
+		msg      string
+		err      error
+		wantCode apierr.Code
+		wantMsg  string
 	}{
 		{
-			name:    "custom database error message",
-			msg:     "user creation failed",
-			err:     errors.New("duplicate key value"),
-			wantMsg: "user creation failed",
+			name:     "custom database error message",
+			msg:      "user creation failed",
+			err:      errors.New("connection reset"),
+			wantCode: apierr.Internal,
+			wantMsg:  "user creation failed",
 		},
 		{
-			name:    "default database error message",
-			msg:     "",
-			err:     errors.New("connection timeout"),
-			wantMsg: "database error",
+			name:     "default database error message",
+			msg:      "",
+			err:      errors.New("connection timeout"),
+			wantCode: apierr.Internal,
+			wantMsg:  "database error",
 		},
 		{
-			name:    "database error without error object",
-			msg:     "data query failed",
-			err:     nil,
-			wantMsg: "data query failed",
+			name:     "missing record",
+			msg:      "",
+			err:      fmt.Errorf("get session: %w", gorm.ErrRecordNotFound),
+			wantCode: apierr.NotFound,
+			wantMsg:  "Not found",
+		},
+		{
+			name:     "unique violation",
+			msg:      "name taken",
+			err:      fmt.Errorf("create: %w", &pgconn.PgError{Code: "23505"}),
+			wantCode: apierr.Conflict,
+			wantMsg:  "name taken",
+		},
+		{
+			name:     "bad cursor",
+			msg:      "",
+			err:      fmt.Errorf("list: %w", paging.ErrInvalidCursor),
+			wantCode: apierr.InvalidRequest,
+			wantMsg:  "Invalid request",
 		},
 	}
 
@@ -110,16 +174,12 @@ func TestDBErr(t *testing.T) {
 			// Set to debug mode to show error details
 			gin.SetMode(gin.DebugMode)
 
-			response := DBErr(tt.msg, tt.err)
-
-			assert.Equal(t, http.StatusInternalServerError, response.Code)
-			assert.Equal(t, tt.wantMsg, response.Msg)
-			assert.Nil(t, response.Data)
+			w, body := serve(t, func(c *gin.Context) { DBErr(c, tt.msg, tt.err) })
 
-			if tt.err != nil {
-				assert.NotEmpty(t, response.Error)
-				assert.Contains(t, response.Error, tt.err.Error())
-			}
+			assert.Equal(t, tt.wantCode.Status, w.Code)
+			assert.Equal(t, tt.wantCode.ID, body["code"])
+			assert.Equal(t, tt.wantMsg, body["detail"])
+			assert.Contains(t, body["error"], tt.err.Error())
 		})
 	}
 
@@ -159,15 +219,14 @@ func TestParamErr(t *testing.T) {
 			// Set to debug mode to show error details
 			gin.SetMode(gin.DebugMode)
 
-			response := ParamErr(tt.msg, tt.err)
+			w, body := serve(t, func(c *gin.Context) { ParamErr(c, tt.msg, tt.err) })
 
-			assert.Equal(t, http.StatusBadRequest, response.Code)
-			assert.Equal(t, tt.wantMsg, response.Msg)
-			assert.Nil(t, response.Data)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, "invalid_request", body["code"])
+			assert.Equal(t, tt.wantMsg, body["detail"])
 
 			if tt.err != nil {
-				assert.NotEmpty(t, response.Error)
-				assert.Contains(t, response.Error, tt.err.Error())
+				assert.Contains(t, body["error"], tt.err.Error())
 			}
 		})
 	}
@@ -192,21 +251,16 @@ func TestAuthErr(t *testing.T) {
 			msg:     "",
 			wantMsg: "authentication error",
 		},
-		{
-			name:    "insufficient permission error",
-			msg:     "insufficient permission",
-			wantMsg: "insufficient permission",
-		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			response := AuthErr(tt.msg)
+			w, body := serve(t, func(c *gin.Context) { AuthErr(c, tt.msg) })
 
-			assert.Equal(t, http.StatusUnauthorized, response.Code)
-			assert.Equal(t, tt.wantMsg, response.Msg)
-			assert.Nil(t, response.Data)
-			assert.Empty(t, response.Error) // AuthErr does not pass error object
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, "unauthorized", body["code"])
+			assert.Equal(t, tt.wantMsg, body["detail"])
+			assert.NotContains(t, body, "error") // AuthErr does not pass error object
 		})
 	}
 }
@@ -227,51 +281,11 @@ func TestResponse_Structure(t *testing.T) {
 	})
 }
 
-func TestTrackedErrorResponse_Structure(t *testing.T) {
-	t.Run("verify TrackedErrorResponse structure", func(t *testing.T) {
-		response := TrackedErrorResponse{
-			Response: Response{
-				Code:  http.StatusInternalServerError,
-				Msg:   "server error",
-				Error: "detailed error information",
-			},
-			TraceID: "trace-123456",
-		}
+func TestTraceID_KeepsHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Header("X-Trace-Id", "4bf92f3577b34da6a3ce929d0e0e4736")
 
-		assert.Equal(t, http.StatusInternalServerError, response.Code)
-		assert.Equal(t, "server error", response.Msg)
-		assert.Equal(t, "detailed error information", response.Error)
-		assert.Equal(t, "trace-123456", response.TraceID)
-	})
-}
-
-func TestErrorResponseInDifferentModes(t *testing.T) {
-	testErr := errors.New("test error")
-
-	modes := []struct {
-		mode       string
-		shouldShow bool
-	}{
-		{gin.ReleaseMode, false},
-		{gin.DebugMode, true},
-		{gin.TestMode, true},
-	}
-
-	for _, mode := range modes {
-		t.Run("mode_"+mode.mode, func(t *testing.T) {
-			gin.SetMode(mode.mode)
-
-			response := Err(http.StatusBadRequest, "test message", testErr)
-
-			if mode.shouldShow {
-				assert.NotEmpty(t, response.Error)
-				assert.Contains(t, response.Error, "test error")
-			} else {
-				assert.Empty(t, response.Error)
-			}
-		})
-	}
-
-	// Reset to test mode
-	gin.SetMode(gin.TestMode)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID(c))
 }
@@ -1,18 +1,29 @@
 package service
 
 import (
+	"archive/zip"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/infra/blob"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/archive"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/fileparser"
+	pathutil "github.com/memodb-io/Acontext/internal/pkg/utils/path"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type ArtifactService interface {
@@ -20,19 +31,88 @@ type ArtifactService interface {
 	DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error
 	GetByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, error)
 	GetPresignedURL(ctx context.Context, artifact *model.Artifact, expire time.Duration) (string, error)
-	GetFileContent(ctx context.Context, artifact *model.Artifact) (*fileparser.FileContent, error)
+	GetFileContent(ctx context.Context, artifact *model.Artifact, opts fileparser.ParseOptions) (*fileparser.FileContent, error)
 	UpdateArtifactMetaByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, userMeta map[string]interface{}) (*model.Artifact, error)
-	ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error)
-	GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error)
+	List(ctx context.Context, in ListArtifactsInput) (*ListArtifactsOutput, error)
+	DeleteDirectory(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string) (int64, error)
+	Batch(ctx context.Context, in BatchArtifactsInput) ([]BatchArtifactResult, error)
+	ImportArchive(ctx context.Context, in ImportArchiveInput) (*ImportArchiveOutput, error)
+	ExportZip(ctx context.Context, diskID uuid.UUID, path string, w io.Writer) error
+	OpenContent(ctx context.Context, artifact *model.Artifact) (io.ReadCloser, error)
 }
 
 type artifactService struct {
-	r  repo.ArtifactRepo
-	s3 *blob.S3Deps
+	r         repo.ArtifactRepo
+	assetRefs repo.AssetReferenceRepo
+	s3        blob.BlobStore
+	indexer   ArtifactIndexer
+	usage     UsageService
 }
 
-func NewArtifactService(r repo.ArtifactRepo, s3 *blob.S3Deps) ArtifactService {
-	return &artifactService{r: r, s3: s3}
+// NewArtifactService creates the artifact service. indexer may be nil, in which case
+// uploaded artifacts are not scheduled for semantic indexing. assetRefs may be nil, in which
+// case uploads are not deduplicated against content already stored in the project. usage may
+// be nil, in which case writes are neither accounted for nor limited.
+func NewArtifactService(r repo.ArtifactRepo, assetRefs repo.AssetReferenceRepo, s3 blob.BlobStore, indexer ArtifactIndexer, usage UsageService) ArtifactService {
+	return &artifactService{r: r, assetRefs: assetRefs, s3: s3, indexer: indexer, usage: usage}
+}
+
+// assetLookup finds content already stored in the project so uploads can reuse it
+func (s *artifactService) assetLookup(projectID uuid.UUID) blob.AssetLookup {
+	return repo.AssetLookup(s.assetRefs, projectID)
+}
+
+// assetBatch deduplicates the uploads of a batch or import. Their asset references are only
+// recorded once the whole batch is applied, so files uploaded earlier in it are remembered here.
+type assetBatch struct {
+	lookup   blob.AssetLookup
+	uploaded map[string]*model.Asset
+}
+
+func (s *artifactService) newAssetBatch(projectID uuid.UUID) *assetBatch {
+	return &assetBatch{lookup: s.assetLookup(projectID), uploaded: make(map[string]*model.Asset)}
+}
+
+func (b *assetBatch) find(ctx context.Context, sumHex string) (*model.Asset, error) {
+	if asset, ok := b.uploaded[sumHex]; ok {
+		return asset, nil
+	}
+	if b.lookup == nil {
+		return nil, nil
+	}
+	return b.lookup(ctx, sumHex)
+}
+
+func (b *assetBatch) add(asset *model.Asset) {
+	b.uploaded[asset.SHA256] = asset
+}
+
+// enqueueIndex schedules artifacts for semantic indexing when an indexer is configured
+func (s *artifactService) enqueueIndex(ctx context.Context, projectID uuid.UUID, artifacts []*model.Artifact) {
+	if s.indexer == nil || len(artifacts) == 0 {
+		return
+	}
+	s.indexer.EnqueueIndex(ctx, projectID, artifacts)
+}
+
+// checkUsage returns a *UsageLimitError when storing bytes more would take the project over its storage limit
+func (s *artifactService) checkUsage(ctx context.Context, projectID uuid.UUID, bytes int64) error {
+	if s.usage == nil {
+		return nil
+	}
+	return s.usage.Check(ctx, projectID, UsageDemand{Bytes: bytes})
+}
+
+// recordUsage counts artifact writes and deletes and the bytes written
+func (s *artifactService) recordUsage(ctx context.Context, projectID uuid.UUID, writes int64, deletes int64, bytes int64) {
+	if s.usage == nil {
+		return
+	}
+	s.usage.Record(ctx, projectID, map[string]int64{
+		model.MetricTagArtifactWrite:  writes,
+		model.MetricTagArtifactDelete: deletes,
+		model.MetricTagBytesIngested:  bytes,
+	})
 }
 
 type CreateArtifactInput struct {
@@ -45,55 +125,192 @@ type CreateArtifactInput struct {
 }
 
 func (s *artifactService) Create(ctx context.Context, in CreateArtifactInput) (*model.Artifact, error) {
+	if err := s.checkDiskQuota(ctx, in.DiskID, []pendingFile{{path: in.Path, filename: in.Filename, size: in.FileHeader.Size}}); err != nil {
+		return nil, err
+	}
+	if err := s.checkUsage(ctx, in.ProjectID, in.FileHeader.Size); err != nil {
+		return nil, err
+	}
+
+	if err := s.clearPath(ctx, in.ProjectID, in.DiskID, in.Path, in.Filename); err != nil {
+		return nil, err
+	}
+
+	asset, err := s.s3.UploadFormFile(ctx, "disks/"+in.ProjectID.String(), in.FileHeader, s.assetLookup(in.ProjectID))
+	if err != nil {
+		return nil, fmt.Errorf("upload file to S3: %w", err)
+	}
+
+	return s.createArtifact(ctx, in.ProjectID, in.DiskID, in.Path, in.Filename, in.FileHeader.Filename, asset, in.UserMeta)
+}
+
+// clearPath deletes the artifact at path/filename, if any, before a new file is stored there.
+// It must run before the new asset is stored: releasing the old artifact can delete an object
+// that deduplication would otherwise hand out again for identical content.
+func (s *artifactService) clearPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error {
 	// Check if artifact with same path and filename already exists in the same disk
-	exists, err := s.r.ExistsByPathAndFilename(ctx, in.DiskID, in.Path, in.Filename, nil)
+	exists, err := s.r.ExistsByPathAndFilename(ctx, diskID, path, filename, nil)
 	if err != nil {
-		return nil, fmt.Errorf("check artifact existence: %w", err)
+		return fmt.Errorf("check artifact existence: %w", err)
 	}
 	if exists {
-		if err := s.r.DeleteByPath(ctx, in.ProjectID, in.DiskID, in.Path, in.Filename); err != nil {
-			return nil, fmt.Errorf("upsert existing artifact: %w", err)
+		if err := s.r.DeleteByPath(ctx, projectID, diskID, path, filename); err != nil {
+			return fmt.Errorf("upsert existing artifact: %w", err)
 		}
 	}
+	return nil
+}
+
+// createArtifact records a stored asset as the artifact at path/filename and schedules it for indexing
+func (s *artifactService) createArtifact(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string, sourceFilename string, asset *model.Asset, userMeta map[string]interface{}) (*model.Artifact, error) {
+	artifact := &model.Artifact{
+		DiskID:    diskID,
+		Path:      path,
+		Filename:  filename,
+		Meta:      buildArtifactMeta(path, sourceFilename, asset, userMeta),
+		AssetMeta: datatypes.NewJSONType(*asset),
+	}
+
+	if err := s.r.Create(ctx, projectID, artifact); err != nil {
+		return nil, fmt.Errorf("create artifact record: %w", err)
+	}
 
-	asset, err := s.s3.UploadFormFile(ctx, "disks/"+in.ProjectID.String(), in.FileHeader)
+	s.enqueueIndex(ctx, projectID, []*model.Artifact{artifact})
+	s.recordUsage(ctx, projectID, 1, 0, asset.SizeB)
+
+	return artifact, nil
+}
+
+// replaceArtifact records a stored asset as the artifact at path/filename, replacing the one
+// already there within the same transaction, and schedules it for indexing. The reference to
+// the new asset is taken before the old one is released, so identical content is never lost.
+func (s *artifactService) replaceArtifact(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string, sourceFilename string, asset *model.Asset, userMeta map[string]interface{}) (*model.Artifact, error) {
+	artifacts, err := s.r.ApplyBatch(ctx, projectID, diskID, []repo.ArtifactBatchOp{{
+		Type:     repo.ArtifactBatchOpUpsert,
+		Path:     path,
+		Filename: filename,
+		Artifact: &model.Artifact{
+			DiskID:    diskID,
+			Path:      path,
+			Filename:  filename,
+			Meta:      buildArtifactMeta(path, sourceFilename, asset, userMeta),
+			AssetMeta: datatypes.NewJSONType(*asset),
+		},
+	}})
 	if err != nil {
-		return nil, fmt.Errorf("upload file to S3: %w", err)
+		return nil, fmt.Errorf("store artifact record: %w", err)
 	}
 
-	// Build artifact metadata
+	s.enqueueIndex(ctx, projectID, artifacts)
+	s.recordUsage(ctx, projectID, 1, 0, asset.SizeB)
+
+	return artifacts[0], nil
+}
+
+// pendingFile is a file about to be written to a disk, replacing any file at the same path
+type pendingFile struct {
+	path     string
+	filename string
+	size     int64
+}
+
+// diskQuota tracks what a write adds to a disk and rejects it once it would go over the
+// disk's byte or file quota. Files that replace existing ones only count the size difference.
+type diskQuota struct {
+	s      *artifactService
+	diskID uuid.UUID
+	usage  *model.DiskUsage
+	files  int64
+	bytes  int64
+}
+
+// newDiskQuota starts tracking a write to the disk. The disk's usage is only summed up when it
+// has a quota to check it against.
+func (s *artifactService) newDiskQuota(ctx context.Context, diskID uuid.UUID) (*diskQuota, error) {
+	usage, err := s.r.GetDiskUsage(ctx, diskID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDiskNotFound
+		}
+		return nil, fmt.Errorf("get disk usage: %w", err)
+	}
+	return &diskQuota{s: s, diskID: diskID, usage: usage}, nil
+}
+
+// add counts a file and returns ErrDiskQuotaExceeded when the total no longer fits
+func (q *diskQuota) add(ctx context.Context, f pendingFile) error {
+	if q.usage.MaxBytes == nil && q.usage.MaxFiles == nil {
+		return nil
+	}
+
+	files, bytes := int64(1), f.size
+	existing, err := q.s.r.GetByPath(ctx, q.diskID, f.path, f.filename)
+	if err == nil {
+		files--
+		bytes -= existing.AssetMeta.Data().SizeB
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("check existing artifact: %w", err)
+	}
+
+	if !q.usage.Allows(q.files+files, q.bytes+bytes) {
+		return fmt.Errorf("%w: disk holds %d files and %d bytes, writing %s%s would add %d files and %d bytes",
+			ErrDiskQuotaExceeded, q.usage.Files, q.usage.Bytes, f.path, f.filename, q.files+files, q.bytes+bytes)
+	}
+	q.files += files
+	q.bytes += bytes
+	return nil
+}
+
+// checkDiskQuota returns ErrDiskQuotaExceeded when writing all files would take the disk over its quota
+func (s *artifactService) checkDiskQuota(ctx context.Context, diskID uuid.UUID, files []pendingFile) error {
+	q, err := s.newDiskQuota(ctx, diskID)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := q.add(ctx, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildArtifactMeta combines the system artifact info with user-defined metadata
+func buildArtifactMeta(path string, filename string, asset *model.Asset, userMeta map[string]interface{}) map[string]interface{} {
 	meta := map[string]interface{}{
 		model.ArtifactInfoKey: map[string]interface{}{
-			"path":     in.Path,
-			"filename": in.FileHeader.Filename,
+			"path":     path,
+			"filename": filename,
 			"mime":     asset.MIME,
 			"size":     asset.SizeB,
 		},
 	}
-	for k, v := range in.UserMeta {
+	for k, v := range userMeta {
 		meta[k] = v
 	}
+	return meta
+}
 
-	artifact := &model.Artifact{
-		DiskID:    in.DiskID,
-		Path:      in.Path,
-		Filename:  in.Filename,
-		Meta:      meta,
-		AssetMeta: datatypes.NewJSONType(*asset),
+// detectMIME returns the given MIME type, falling back to the filename extension and then content sniffing
+func detectMIME(filename string, mimeType string, content []byte) string {
+	if mimeType != "" {
+		return mimeType
 	}
-
-	if err := s.r.Create(ctx, in.ProjectID, artifact); err != nil {
-		return nil, fmt.Errorf("create artifact record: %w", err)
+	if byExt := mime.TypeByExtension(filepath.Ext(filename)); byExt != "" {
+		return byExt
 	}
-
-	return artifact, nil
+	return http.DetectContentType(content)
 }
 
 func (s *artifactService) DeleteByPath(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string, filename string) error {
 	if path == "" || filename == "" {
 		return errors.New("path and filename are required")
 	}
-	return s.r.DeleteByPath(ctx, projectID, diskID, path, filename)
+	if err := s.r.DeleteByPath(ctx, projectID, diskID, path, filename); err != nil {
+		return err
+	}
+	s.recordUsage(ctx, projectID, 0, 1, 0)
+	return nil
 }
 
 func (s *artifactService) GetByPath(ctx context.Context, diskID uuid.UUID, path string, filename string) (*model.Artifact, error) {
@@ -116,7 +333,21 @@ func (s *artifactService) GetPresignedURL(ctx context.Context, artifact *model.A
 	return s.s3.PresignGet(ctx, assetData.S3Key, expire)
 }
 
-func (s *artifactService) GetFileContent(ctx context.Context, artifact *model.Artifact) (*fileparser.FileContent, error) {
+// OpenContent streams the stored file of an artifact. The caller must close the reader.
+func (s *artifactService) OpenContent(ctx context.Context, artifact *model.Artifact) (io.ReadCloser, error) {
+	if artifact == nil {
+		return nil, errors.New("artifact is nil")
+	}
+
+	assetData := artifact.AssetMeta.Data()
+	if assetData.S3Key == "" {
+		return nil, errors.New("artifact has no S3 key")
+	}
+
+	return s.s3.OpenObject(ctx, assetData.S3Key)
+}
+
+func (s *artifactService) GetFileContent(ctx context.Context, artifact *model.Artifact, opts fileparser.ParseOptions) (*fileparser.FileContent, error) {
 	if artifact == nil {
 		return nil, errors.New("artifact is nil")
 	}
@@ -129,7 +360,7 @@ func (s *artifactService) GetFileContent(ctx context.Context, artifact *model.Ar
 	// Check if file type is parsable before downloading
 	parser := fileparser.NewFileParser()
 	if !parser.CanParseFile(artifact.Filename, assetData.MIME) {
-		return nil, fmt.Errorf("unsupported file type: %s (mime: %s)", artifact.Filename, assetData.MIME)
+		return nil, fmt.Errorf("%w: %s (mime: %s)", fileparser.ErrUnsupportedFileType, artifact.Filename, assetData.MIME)
 	}
 
 	// Download file content from S3
@@ -139,7 +370,7 @@ func (s *artifactService) GetFileContent(ctx context.Context, artifact *model.Ar
 	}
 
 	// Parse file content
-	fileContent, err := parser.ParseFile(artifact.Filename, assetData.MIME, content)
+	fileContent, err := parser.ParseFileWithOptions(artifact.Filename, assetData.MIME, content, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse file content: %w", err)
 	}
@@ -185,10 +416,349 @@ func (s *artifactService) UpdateArtifactMetaByPath(ctx context.Context, diskID u
 	return artifact, nil
 }
 
-func (s *artifactService) ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error) {
-	return s.r.ListByPath(ctx, diskID, path)
+type ListArtifactsInput struct {
+	DiskID    uuid.UUID `json:"disk_id"`
+	Path      string    `json:"path"`
+	Recursive bool      `json:"recursive"`
+	Depth     int       `json:"depth"`
+	Limit     int       `json:"limit"`
+	Cursor    string    `json:"cursor"`
+}
+
+// ListArtifactsOutput is a page of artifacts. Directories and Tree are only set on the first
+// page, the one without a cursor.
+type ListArtifactsOutput struct {
+	Artifacts   []*model.Artifact       `json:"artifacts"`
+	Directories []string                `json:"directories"`
+	Tree        *pathutil.DirectoryNode `json:"tree,omitempty"`
+	NextCursor  string                  `json:"next_cursor,omitempty"`
+	HasMore     bool                    `json:"has_more"`
+}
+
+func (s *artifactService) List(ctx context.Context, in ListArtifactsInput) (*ListArtifactsOutput, error) {
+	// Parse cursor (path, filename); an empty cursor indicates starting from the beginning
+	var afterPath, afterFilename string
+	var err error
+	if in.Cursor != "" {
+		afterPath, afterFilename, err = paging.DecodePathCursor(in.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Query limit+1 is used to determine has_more
+	artifacts, err := s.r.ListByPathWithCursor(ctx, in.DiskID, in.Path, in.Recursive, in.Depth, afterPath, afterFilename, in.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ListArtifactsOutput{
+		Artifacts: artifacts,
+		HasMore:   false,
+	}
+	if len(artifacts) > in.Limit {
+		out.HasMore = true
+		out.Artifacts = artifacts[:in.Limit]
+		last := out.Artifacts[len(out.Artifacts)-1]
+		out.NextCursor = paging.EncodePathCursor(last.Path, last.Filename)
+	}
+
+	// Directories and the tree describe the whole subtree, not the page, so they are only
+	// aggregated for the first page instead of once per page of a cursor walk
+	if in.Cursor != "" {
+		return out, nil
+	}
+
+	// Directory statistics are aggregated per path in the database
+	stats, err := s.r.GetDirectoryStats(ctx, in.DiskID, in.Path)
+	if err != nil {
+		return nil, fmt.Errorf("get directory stats: %w", err)
+	}
+
+	statPaths := make([]string, 0, len(stats))
+	for _, st := range stats {
+		statPaths = append(statPaths, st.Path)
+	}
+	out.Directories = pathutil.GetDirectoriesFromPaths(in.Path, statPaths)
+	sort.Strings(out.Directories)
+
+	if in.Recursive {
+		out.Tree = pathutil.BuildDirectoryTree(in.Path, stats, in.Depth)
+	}
+
+	return out, nil
+}
+
+func (s *artifactService) DeleteDirectory(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string) (int64, error) {
+	if path == "" {
+		return 0, errors.New("path is required")
+	}
+	return s.r.DeleteByPrefix(ctx, projectID, diskID, path)
+}
+
+type ArtifactBatchOperation struct {
+	Op           string
+	Path         string
+	Filename     string
+	DestPath     string
+	DestFilename string
+	Content      []byte
+	MIME         string
+	UserMeta     map[string]interface{}
 }
 
-func (s *artifactService) GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error) {
-	return s.r.GetAllPaths(ctx, diskID)
+type BatchArtifactsInput struct {
+	ProjectID  uuid.UUID
+	DiskID     uuid.UUID
+	Operations []ArtifactBatchOperation
+}
+
+type BatchArtifactResult struct {
+	Op       string          `json:"op"`
+	Path     string          `json:"path"`
+	Filename string          `json:"filename"`
+	Artifact *model.Artifact `json:"artifact,omitempty"`
+}
+
+// Batch applies a list of upsert/delete/move operations to a disk in a single transaction.
+// Upsert contents are uploaded to S3 before the transaction starts; objects left behind by a
+// failed batch are not referenced by any artifact.
+func (s *artifactService) Batch(ctx context.Context, in BatchArtifactsInput) ([]BatchArtifactResult, error) {
+	if len(in.Operations) == 0 {
+		return nil, errors.New("operations are required")
+	}
+
+	// Validate every operation before uploading anything
+	for i, op := range in.Operations {
+		if op.Path == "" || op.Filename == "" {
+			return nil, fmt.Errorf("operation %d: path and filename are required", i)
+		}
+		switch repo.ArtifactBatchOpType(op.Op) {
+		case repo.ArtifactBatchOpUpsert, repo.ArtifactBatchOpDelete:
+		case repo.ArtifactBatchOpMove:
+			if op.DestPath == "" || op.DestFilename == "" {
+				return nil, fmt.Errorf("operation %d: destination path and filename are required", i)
+			}
+		default:
+			return nil, fmt.Errorf("operation %d: unknown operation %q", i, op.Op)
+		}
+	}
+
+	var uploads []pendingFile
+	var uploadBytes, deletes int64
+	for _, op := range in.Operations {
+		switch repo.ArtifactBatchOpType(op.Op) {
+		case repo.ArtifactBatchOpUpsert:
+			uploads = append(uploads, pendingFile{path: op.Path, filename: op.Filename, size: int64(len(op.Content))})
+			uploadBytes += int64(len(op.Content))
+		case repo.ArtifactBatchOpDelete:
+			deletes++
+		}
+	}
+	if len(uploads) > 0 {
+		if err := s.checkDiskQuota(ctx, in.DiskID, uploads); err != nil {
+			return nil, err
+		}
+		if err := s.checkUsage(ctx, in.ProjectID, uploadBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	batch := s.newAssetBatch(in.ProjectID)
+	ops := make([]repo.ArtifactBatchOp, 0, len(in.Operations))
+	for i, op := range in.Operations {
+		switch repo.ArtifactBatchOpType(op.Op) {
+		case repo.ArtifactBatchOpUpsert:
+			asset, err := s.s3.UploadBytes(ctx, "disks/"+in.ProjectID.String(), op.Filename, detectMIME(op.Filename, op.MIME, op.Content), op.Content, batch.find)
+			if err != nil {
+				return nil, fmt.Errorf("operation %d: upload file to S3: %w", i, err)
+			}
+			batch.add(asset)
+			ops = append(ops, repo.ArtifactBatchOp{
+				Type:     repo.ArtifactBatchOpUpsert,
+				Path:     op.Path,
+				Filename: op.Filename,
+				Artifact: &model.Artifact{
+					DiskID:    in.DiskID,
+					Path:      op.Path,
+					Filename:  op.Filename,
+					Meta:      buildArtifactMeta(op.Path, op.Filename, asset, op.UserMeta),
+					AssetMeta: datatypes.NewJSONType(*asset),
+				},
+			})
+		case repo.ArtifactBatchOpDelete:
+			ops = append(ops, repo.ArtifactBatchOp{
+				Type:     repo.ArtifactBatchOpDelete,
+				Path:     op.Path,
+				Filename: op.Filename,
+			})
+		case repo.ArtifactBatchOpMove:
+			ops = append(ops, repo.ArtifactBatchOp{
+				Type:         repo.ArtifactBatchOpMove,
+				Path:         op.Path,
+				Filename:     op.Filename,
+				DestPath:     op.DestPath,
+				DestFilename: op.DestFilename,
+			})
+		}
+	}
+
+	artifacts, err := s.r.ApplyBatch(ctx, in.ProjectID, in.DiskID, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only upserted artifacts carry new content; moved artifacts keep their chunks
+	upserted := make([]*model.Artifact, 0, len(ops))
+	for i, op := range ops {
+		if op.Type == repo.ArtifactBatchOpUpsert {
+			upserted = append(upserted, artifacts[i])
+		}
+	}
+	s.enqueueIndex(ctx, in.ProjectID, upserted)
+	// Moves only rename artifacts, so they count as a write without new bytes
+	s.recordUsage(ctx, in.ProjectID, int64(len(ops))-deletes, deletes, uploadBytes)
+
+	results := make([]BatchArtifactResult, len(ops))
+	for i, op := range ops {
+		results[i] = BatchArtifactResult{
+			Op:       string(op.Type),
+			Path:     op.Path,
+			Filename: op.Filename,
+			Artifact: artifacts[i],
+		}
+	}
+	return results, nil
+}
+
+type ImportArchiveInput struct {
+	ProjectID uuid.UUID
+	DiskID    uuid.UUID
+	Path      string
+	Format    archive.Format
+	Reader    io.ReaderAt
+	Size      int64
+}
+
+type ImportArchiveOutput struct {
+	Imported  int               `json:"imported"`
+	Artifacts []*model.Artifact `json:"artifacts"`
+}
+
+// ImportArchive unpacks a zip or tar.gz archive into artifacts under the target path.
+// Each file is uploaded with deduplication as it is read; the artifact records are then
+// upserted in a single transaction so a failed import leaves the disk unchanged.
+func (s *artifactService) ImportArchive(ctx context.Context, in ImportArchiveInput) (*ImportArchiveOutput, error) {
+	var quota *diskQuota
+	var bytes int64
+	batch := s.newAssetBatch(in.ProjectID)
+	ops := make([]repo.ArtifactBatchOp, 0)
+	err := archive.Extract(in.Format, in.Reader, in.Size, in.Path, archive.DefaultLimits, func(e archive.Entry) error {
+		// Checked before each upload so an import that does not fit stops early
+		if quota == nil {
+			var err error
+			if quota, err = s.newDiskQuota(ctx, in.DiskID); err != nil {
+				return err
+			}
+		}
+		if err := quota.add(ctx, pendingFile{path: e.Path, filename: e.Filename, size: int64(len(e.Content))}); err != nil {
+			return err
+		}
+		asset, err := s.s3.UploadBytes(ctx, "disks/"+in.ProjectID.String(), e.Filename, detectMIME(e.Filename, "", e.Content), e.Content, batch.find)
+		if err != nil {
+			return fmt.Errorf("upload %s%s to S3: %w", e.Path, e.Filename, err)
+		}
+		batch.add(asset)
+		bytes += int64(len(e.Content))
+		ops = append(ops, repo.ArtifactBatchOp{
+			Type:     repo.ArtifactBatchOpUpsert,
+			Path:     e.Path,
+			Filename: e.Filename,
+			Artifact: &model.Artifact{
+				DiskID:    in.DiskID,
+				Path:      e.Path,
+				Filename:  e.Filename,
+				Meta:      buildArtifactMeta(e.Path, e.Filename, asset, nil),
+				AssetMeta: datatypes.NewJSONType(*asset),
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ops) == 0 {
+		return &ImportArchiveOutput{Artifacts: []*model.Artifact{}}, nil
+	}
+	// The unpacked size is only known now; the uploaded objects are left to the asset
+	// garbage collector when the import does not fit
+	if err := s.checkUsage(ctx, in.ProjectID, bytes); err != nil {
+		return nil, err
+	}
+
+	artifacts, err := s.r.ApplyBatch(ctx, in.ProjectID, in.DiskID, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	s.enqueueIndex(ctx, in.ProjectID, artifacts)
+	s.recordUsage(ctx, in.ProjectID, int64(len(artifacts)), 0, bytes)
+
+	return &ImportArchiveOutput{Imported: len(artifacts), Artifacts: artifacts}, nil
+}
+
+// exportPageSize is the number of artifact rows fetched per query while exporting
+const exportPageSize = 500
+
+// ExportZip writes a zip archive of all artifacts under path to w.
+// File contents are streamed from S3 one at a time and never buffered in full.
+func (s *artifactService) ExportZip(ctx context.Context, diskID uuid.UUID, path string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	var afterPath, afterFilename string
+	for {
+		artifacts, err := s.r.ListByPathWithCursor(ctx, diskID, path, true, 0, afterPath, afterFilename, exportPageSize)
+		if err != nil {
+			return fmt.Errorf("list artifacts: %w", err)
+		}
+
+		for _, a := range artifacts {
+			if err := s.writeZipEntry(ctx, zw, path, a); err != nil {
+				return err
+			}
+		}
+
+		if len(artifacts) < exportPageSize {
+			break
+		}
+		last := artifacts[len(artifacts)-1]
+		afterPath, afterFilename = last.Path, last.Filename
+	}
+
+	return zw.Close()
+}
+
+func (s *artifactService) writeZipEntry(ctx context.Context, zw *zip.Writer, basePath string, a *model.Artifact) error {
+	asset := a.AssetMeta.Data()
+
+	body, err := s.s3.OpenObject(ctx, asset.S3Key)
+	if err != nil {
+		return fmt.Errorf("open %s%s: %w", a.Path, a.Filename, err)
+	}
+	defer body.Close()
+
+	fw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     strings.TrimPrefix(a.Path, basePath) + a.Filename,
+		Method:   zip.Deflate,
+		Modified: a.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("create zip entry: %w", err)
+	}
+
+	if _, err := io.Copy(fw, body); err != nil {
+		return fmt.Errorf("write %s%s: %w", a.Path, a.Filename, err)
+	}
+
+	return nil
 }
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/infra/embedding"
+	mq "github.com/memodb-io/Acontext/internal/infra/queue"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/chunker"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/fileparser"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ArtifactIndexer schedules artifacts for chunking and embedding
+type ArtifactIndexer interface {
+	EnqueueIndex(ctx context.Context, projectID uuid.UUID, artifacts []*model.Artifact)
+}
+
+type ArtifactSearchService interface {
+	ArtifactIndexer
+	HandleIndexMessage(ctx context.Context, body []byte) error
+	IndexArtifact(ctx context.Context, artifactID uuid.UUID) error
+	Search(ctx context.Context, in SearchArtifactsInput) (*SearchArtifactsOutput, error)
+}
+
+type artifactSearchService struct {
+	artifactRepo repo.ArtifactRepo
+	chunkRepo    repo.ArtifactChunkRepo
+	s3           blob.BlobStore
+	embedder     embedding.Provider
+	publisher    *mq.Publisher
+	cfg          *config.Config
+	log          *zap.Logger
+}
+
+func NewArtifactSearchService(artifactRepo repo.ArtifactRepo, chunkRepo repo.ArtifactChunkRepo, s3 blob.BlobStore, embedder embedding.Provider, publisher *mq.Publisher, cfg *config.Config, log *zap.Logger) ArtifactSearchService {
+	return &artifactSearchService{
+		artifactRepo: artifactRepo,
+		chunkRepo:    chunkRepo,
+		s3:           s3,
+		embedder:     embedder,
+		publisher:    publisher,
+		cfg:          cfg,
+		log:          log,
+	}
+}
+
+type ArtifactIndexMQPublishJSON struct {
+	ProjectID  uuid.UUID `json:"project_id"`
+	DiskID     uuid.UUID `json:"disk_id"`
+	ArtifactID uuid.UUID `json:"artifact_id"`
+}
+
+// EnqueueIndex publishes an index message for every parsable artifact.
+// Publishing failures are logged and never fail the upload itself.
+func (s *artifactSearchService) EnqueueIndex(ctx context.Context, projectID uuid.UUID, artifacts []*model.Artifact) {
+	if s.publisher == nil {
+		return
+	}
+
+	parser := fileparser.NewFileParser()
+	for _, a := range artifacts {
+		if a == nil || !parser.CanParseFile(a.Filename, a.AssetMeta.Data().MIME) {
+			continue
+		}
+		if err := s.publisher.PublishJSON(ctx, s.cfg.RabbitMQ.ExchangeName.ArtifactIndex, s.cfg.RabbitMQ.RoutingKey.ArtifactIndexUpsert, ArtifactIndexMQPublishJSON{
+			ProjectID:  projectID,
+			DiskID:     a.DiskID,
+			ArtifactID: a.ID,
+		}); err != nil {
+			s.log.Error("publish artifact index", zap.Error(err), zap.String("artifact_id", a.ID.String()))
+		}
+	}
+}
+
+// HandleIndexMessage is the queue consumer entry point for index messages
+func (s *artifactSearchService) HandleIndexMessage(ctx context.Context, body []byte) error {
+	var msg ArtifactIndexMQPublishJSON
+	if err := sonic.Unmarshal(body, &msg); err != nil {
+		// A malformed message will never succeed, so drop it instead of requeueing
+		s.log.Error("invalid artifact index message", zap.Error(err))
+		return nil
+	}
+	return s.IndexArtifact(ctx, msg.ArtifactID)
+}
+
+// IndexArtifact parses, chunks and embeds an artifact, replacing its previous chunks.
+// Only transient failures (storage, database, embedding provider) are returned so that the
+// message is retried; artifacts that are gone or cannot be parsed are skipped.
+func (s *artifactSearchService) IndexArtifact(ctx context.Context, artifactID uuid.UUID) error {
+	artifact, err := s.artifactRepo.GetByID(ctx, artifactID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("get artifact: %w", err)
+	}
+
+	asset := artifact.AssetMeta.Data()
+	parser := fileparser.NewFileParser()
+	if !parser.CanParseFile(artifact.Filename, asset.MIME) {
+		return nil
+	}
+
+	content, err := s.s3.DownloadFile(ctx, asset.S3Key)
+	if err != nil {
+		return fmt.Errorf("download artifact content: %w", err)
+	}
+
+	parsed, err := parser.ParseFile(artifact.Filename, asset.MIME, content)
+	if err != nil {
+		s.log.Warn("skip indexing unparsable artifact", zap.Error(err), zap.String("artifact_id", artifactID.String()))
+		return nil
+	}
+
+	chunks, err := s.embedChunks(ctx, artifact, parsed.Raw)
+	if err != nil {
+		return err
+	}
+
+	return s.chunkRepo.ReplaceForArtifact(ctx, artifact.ID, chunks)
+}
+
+// embedChunks splits text into line-aligned chunks and embeds them with the configured provider
+func (s *artifactSearchService) embedChunks(ctx context.Context, artifact *model.Artifact, text string) ([]*model.ArtifactChunk, error) {
+	pieces := chunker.Split(text, chunker.DefaultOptions)
+	if len(pieces) == 0 {
+		return nil, nil
+	}
+
+	texts := make([]string, len(pieces))
+	for i, p := range pieces {
+		texts[i] = p.Content
+	}
+	vectors, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("embed chunks: %w", err)
+	}
+	if len(vectors) != len(pieces) {
+		return nil, fmt.Errorf("embed chunks: expected %d vectors, got %d", len(pieces), len(vectors))
+	}
+
+	chunks := make([]*model.ArtifactChunk, len(pieces))
+	for i, p := range pieces {
+		chunks[i] = &model.ArtifactChunk{
+			DiskID:         artifact.DiskID,
+			ArtifactID:     artifact.ID,
+			ChunkIndex:     p.Index,
+			StartLine:      p.StartLine,
+			EndLine:        p.EndLine,
+			Content:        p.Content,
+			EmbeddingModel: s.embedder.Model(),
+			Embedding:      vectors[i],
+		}
+	}
+	return chunks, nil
+}
+
+type SearchArtifactsInput struct {
+	DiskID uuid.UUID
+	Query  string
+	Path   string
+	Limit  int
+}
+
+type SearchArtifactsOutput struct {
+	Results []repo.ArtifactChunkHit `json:"results"`
+}
+
+func (s *artifactSearchService) Search(ctx context.Context, in SearchArtifactsInput) (*SearchArtifactsOutput, error) {
+	if in.Query == "" {
+		return nil, errors.New("query is required")
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{in.Query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embed query: expected 1 vector, got %d", len(vectors))
+	}
+
+	// A query without any embeddable content has no meaningful nearest neighbours
+	if isZeroVector(vectors[0]) {
+		return &SearchArtifactsOutput{Results: []repo.ArtifactChunkHit{}}, nil
+	}
+
+	hits, err := s.chunkRepo.Search(ctx, in.DiskID, in.Path, s.embedder.Model(), vectors[0], in.Limit)
+	if err != nil {
+		return nil, err
+	}
+	if hits == nil {
+		hits = []repo.ArtifactChunkHit{}
+	}
+	return &SearchArtifactsOutput{Results: hits}, nil
+}
+
+func isZeroVector(v []float32) bool {
+	for _, f := range v {
+		if f != 0 {
+			return false
+		}
+	}
+	return true
+}
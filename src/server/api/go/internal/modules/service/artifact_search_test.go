@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/embedding"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// MockArtifactChunkRepo is a mock implementation of ArtifactChunkRepo
+type MockArtifactChunkRepo struct {
+	mock.Mock
+}
+
+func (m *MockArtifactChunkRepo) ReplaceForArtifact(ctx context.Context, artifactID uuid.UUID, chunks []*model.ArtifactChunk) error {
+	args := m.Called(ctx, artifactID, chunks)
+	return args.Error(0)
+}
+
+func (m *MockArtifactChunkRepo) Search(ctx context.Context, diskID uuid.UUID, path string, embeddingModel string, query model.Vector, limit int) ([]repo.ArtifactChunkHit, error) {
+	args := m.Called(ctx, diskID, path, embeddingModel, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repo.ArtifactChunkHit), args.Error(1)
+}
+
+// MockArtifactIndexer records artifacts scheduled for indexing
+type MockArtifactIndexer struct {
+	mock.Mock
+}
+
+func (m *MockArtifactIndexer) EnqueueIndex(ctx context.Context, projectID uuid.UUID, artifacts []*model.Artifact) {
+	m.Called(ctx, projectID, artifacts)
+}
+
+func newTestArtifactSearchService(artifactRepo *MockArtifactRepo, chunkRepo *MockArtifactChunkRepo) *artifactSearchService {
+	return NewArtifactSearchService(artifactRepo, chunkRepo, nil, embedding.NewLocal("", 32), nil, nil, zap.NewNop()).(*artifactSearchService)
+}
+
+func TestArtifactSearchService_Search(t *testing.T) {
+	diskID := uuid.New()
+
+	tests := []struct {
+		name        string
+		in          SearchArtifactsInput
+		setup       func(*MockArtifactChunkRepo)
+		expectError bool
+		expectLen   int
+	}{
+		{
+			name: "ranked hits from the repo",
+			in:   SearchArtifactsInput{DiskID: diskID, Query: "database config", Path: "/docs/", Limit: 5},
+			setup: func(r *MockArtifactChunkRepo) {
+				r.On("Search", mock.Anything, diskID, "/docs/", "local-hash", mock.MatchedBy(func(v model.Vector) bool {
+					return len(v) == 32
+				}), 5).Return([]repo.ArtifactChunkHit{
+					{Path: "/docs/", Filename: "db.md", StartLine: 1, EndLine: 10, Score: 0.9},
+					{Path: "/docs/", Filename: "setup.md", StartLine: 4, EndLine: 8, Score: 0.5},
+				}, nil)
+			},
+			expectLen: 2,
+		},
+		{
+			name: "no hits returns an empty list",
+			in:   SearchArtifactsInput{DiskID: diskID, Query: "nothing", Limit: 5},
+			setup: func(r *MockArtifactChunkRepo) {
+				r.On("Search", mock.Anything, diskID, "", "local-hash", mock.Anything, 5).Return(nil, nil)
+			},
+			expectLen: 0,
+		},
+		{
+			name:      "query without embeddable content skips the repo",
+			in:        SearchArtifactsInput{DiskID: diskID, Query: "?!", Limit: 5},
+			setup:     func(r *MockArtifactChunkRepo) {},
+			expectLen: 0,
+		},
+		{
+			name:        "empty query",
+			in:          SearchArtifactsInput{DiskID: diskID, Limit: 5},
+			setup:       func(r *MockArtifactChunkRepo) {},
+			expectError: true,
+		},
+		{
+			name: "repo error",
+			in:   SearchArtifactsInput{DiskID: diskID, Query: "database", Limit: 5},
+			setup: func(r *MockArtifactChunkRepo) {
+				r.On("Search", mock.Anything, diskID, "", "local-hash", mock.Anything, 5).Return(nil, errors.New("db error"))
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunkRepo := &MockArtifactChunkRepo{}
+			tt.setup(chunkRepo)
+
+			out, err := newTestArtifactSearchService(&MockArtifactRepo{}, chunkRepo).Search(context.Background(), tt.in)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, out)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, out.Results)
+				assert.Len(t, out.Results, tt.expectLen)
+			}
+			chunkRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactSearchService_IndexArtifact_Skips(t *testing.T) {
+	artifactID := uuid.New()
+
+	t.Run("artifact no longer exists", func(t *testing.T) {
+		artifactRepo := &MockArtifactRepo{}
+		artifactRepo.On("GetByID", mock.Anything, artifactID).Return(nil, gorm.ErrRecordNotFound)
+
+		err := newTestArtifactSearchService(artifactRepo, &MockArtifactChunkRepo{}).IndexArtifact(context.Background(), artifactID)
+
+		assert.NoError(t, err)
+		artifactRepo.AssertExpectations(t)
+	})
+
+	t.Run("unparsable file type", func(t *testing.T) {
+		artifactRepo := &MockArtifactRepo{}
+		artifactRepo.On("GetByID", mock.Anything, artifactID).Return(&model.Artifact{
+			ID:        artifactID,
+			Filename:  "photo.png",
+			AssetMeta: datatypes.NewJSONType(model.Asset{S3Key: "key", MIME: "image/png"}),
+		}, nil)
+
+		err := newTestArtifactSearchService(artifactRepo, &MockArtifactChunkRepo{}).IndexArtifact(context.Background(), artifactID)
+
+		assert.NoError(t, err)
+		artifactRepo.AssertExpectations(t)
+	})
+
+	t.Run("database error is retried", func(t *testing.T) {
+		artifactRepo := &MockArtifactRepo{}
+		artifactRepo.On("GetByID", mock.Anything, artifactID).Return(nil, errors.New("connection refused"))
+
+		err := newTestArtifactSearchService(artifactRepo, &MockArtifactChunkRepo{}).IndexArtifact(context.Background(), artifactID)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestArtifactSearchService_HandleIndexMessage_Malformed(t *testing.T) {
+	svc := newTestArtifactSearchService(&MockArtifactRepo{}, &MockArtifactChunkRepo{})
+	assert.NoError(t, svc.HandleIndexMessage(context.Background(), []byte("not json")))
+}
+
+func TestArtifactSearchService_EmbedChunks(t *testing.T) {
+	svc := newTestArtifactSearchService(&MockArtifactRepo{}, &MockArtifactChunkRepo{})
+	artifact := &model.Artifact{ID: uuid.New(), DiskID: uuid.New()}
+
+	lines := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		lines = append(lines, "line of text that is reasonably long to fill the chunk quickly")
+	}
+
+	chunks, err := svc.embedChunks(context.Background(), artifact, strings.Join(lines, "\n"))
+	assert.NoError(t, err)
+	assert.Greater(t, len(chunks), 1)
+
+	assert.Equal(t, 1, chunks[0].StartLine)
+	assert.Equal(t, 200, chunks[len(chunks)-1].EndLine)
+	for i, c := range chunks {
+		assert.Equal(t, i, c.ChunkIndex)
+		assert.Equal(t, artifact.ID, c.ArtifactID)
+		assert.Equal(t, artifact.DiskID, c.DiskID)
+		assert.Equal(t, "local-hash", c.EmbeddingModel)
+		assert.Len(t, c.Embedding, 32)
+		assert.LessOrEqual(t, c.StartLine, c.EndLine)
+	}
+
+	empty, err := svc.embedChunks(context.Background(), artifact, "   ")
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestArtifactService_EnqueueIndexOnlyForUpserts(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	mockRepo := &MockArtifactRepo{}
+	mockRepo.On("ApplyBatch", mock.Anything, projectID, diskID, mock.Anything).Return([]*model.Artifact{createTestArtifact()}, nil)
+	indexer := &MockArtifactIndexer{}
+
+	_, err := NewArtifactService(mockRepo, nil, nil, indexer, nil).Batch(context.Background(), BatchArtifactsInput{
+		ProjectID:  projectID,
+		DiskID:     diskID,
+		Operations: []ArtifactBatchOperation{{Op: "move", Path: "/a/", Filename: "x.txt", DestPath: "/b/", DestFilename: "x.txt"}},
+	})
+
+	assert.NoError(t, err)
+	indexer.AssertNotCalled(t, "EnqueueIndex", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
@@ -1,18 +1,26 @@
 package service
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"mime/multipart"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/archive"
 	"github.com/memodb-io/Acontext/internal/pkg/utils/fileparser"
+	pathutil "github.com/memodb-io/Acontext/internal/pkg/utils/path"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // MockArtifactRepo is a mock implementation of ArtifactRepo
@@ -43,20 +51,49 @@ func (m *MockArtifactRepo) GetByPath(ctx context.Context, diskID uuid.UUID, path
 	return args.Get(0).(*model.Artifact), args.Error(1)
 }
 
-func (m *MockArtifactRepo) ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error) {
+func (m *MockArtifactRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Artifact, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Artifact), args.Error(1)
+}
+
+func (m *MockArtifactRepo) ListByPathWithCursor(ctx context.Context, diskID uuid.UUID, path string, recursive bool, depth int, afterPath string, afterFilename string, limit int) ([]*model.Artifact, error) {
+	args := m.Called(ctx, diskID, path, recursive, depth, afterPath, afterFilename, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Artifact), args.Error(1)
+}
+
+func (m *MockArtifactRepo) GetDirectoryStats(ctx context.Context, diskID uuid.UUID, path string) ([]pathutil.DirectoryStat, error) {
 	args := m.Called(ctx, diskID, path)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
+	return args.Get(0).([]pathutil.DirectoryStat), args.Error(1)
+}
+
+func (m *MockArtifactRepo) DeleteByPrefix(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string) (int64, error) {
+	args := m.Called(ctx, projectID, diskID, path)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockArtifactRepo) ApplyBatch(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, ops []repo.ArtifactBatchOp) ([]*model.Artifact, error) {
+	args := m.Called(ctx, projectID, diskID, ops)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).([]*model.Artifact), args.Error(1)
 }
 
-func (m *MockArtifactRepo) GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error) {
+func (m *MockArtifactRepo) GetDiskUsage(ctx context.Context, diskID uuid.UUID) (*model.DiskUsage, error) {
 	args := m.Called(ctx, diskID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]string), args.Error(1)
+	return args.Get(0).(*model.DiskUsage), args.Error(1)
 }
 
 func (m *MockArtifactRepo) ExistsByPathAndFilename(ctx context.Context, diskID uuid.UUID, path string, filename string, excludeID *uuid.UUID) (bool, error) {
@@ -228,12 +265,28 @@ func (s *testArtifactService) GetPresignedURL(ctx context.Context, artifact *mod
 	return s.s3.PresignGet(ctx, assetData.S3Key, expire)
 }
 
-func (s *testArtifactService) ListByPath(ctx context.Context, diskID uuid.UUID, path string) ([]*model.Artifact, error) {
-	return s.r.ListByPath(ctx, diskID, path)
+func (s *testArtifactService) List(ctx context.Context, in ListArtifactsInput) (*ListArtifactsOutput, error) {
+	return NewArtifactService(s.r, nil, nil, nil, nil).List(ctx, in)
+}
+
+func (s *testArtifactService) DeleteDirectory(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, path string) (int64, error) {
+	return NewArtifactService(s.r, nil, nil, nil, nil).DeleteDirectory(ctx, projectID, diskID, path)
+}
+
+func (s *testArtifactService) Batch(ctx context.Context, in BatchArtifactsInput) ([]BatchArtifactResult, error) {
+	return NewArtifactService(s.r, nil, nil, nil, nil).Batch(ctx, in)
+}
+
+func (s *testArtifactService) ImportArchive(ctx context.Context, in ImportArchiveInput) (*ImportArchiveOutput, error) {
+	return NewArtifactService(s.r, nil, nil, nil, nil).ImportArchive(ctx, in)
 }
 
-func (s *testArtifactService) GetAllPaths(ctx context.Context, diskID uuid.UUID) ([]string, error) {
-	return s.r.GetAllPaths(ctx, diskID)
+func (s *testArtifactService) ExportZip(ctx context.Context, diskID uuid.UUID, path string, w io.Writer) error {
+	return NewArtifactService(s.r, nil, nil, nil, nil).ExportZip(ctx, diskID, path, w)
+}
+
+func (s *testArtifactService) OpenContent(ctx context.Context, artifact *model.Artifact) (io.ReadCloser, error) {
+	return NewArtifactService(s.r, nil, nil, nil, nil).OpenContent(ctx, artifact)
 }
 
 func (s *testArtifactService) UpdateArtifactMetaByPath(ctx context.Context, diskID uuid.UUID, path string, filename string, userMeta map[string]interface{}) (*model.Artifact, error) {
@@ -274,7 +327,7 @@ func (s *testArtifactService) UpdateArtifactMetaByPath(ctx context.Context, disk
 	return artifact, nil
 }
 
-func (s *testArtifactService) GetFileContent(ctx context.Context, artifact *model.Artifact) (*fileparser.FileContent, error) {
+func (s *testArtifactService) GetFileContent(ctx context.Context, artifact *model.Artifact, opts fileparser.ParseOptions) (*fileparser.FileContent, error) {
 	// This is a test implementation that doesn't actually download from S3
 	// In real tests, you would mock the S3 download and file parsing
 	if artifact == nil {
@@ -503,3 +556,445 @@ func TestArtifactService_UpdateArtifactMetaByPath(t *testing.T) {
 		})
 	}
 }
+
+// Test cases for List method
+func TestArtifactService_List(t *testing.T) {
+	diskID := uuid.New()
+
+	newArtifact := func(path, filename string) *model.Artifact {
+		a := createTestArtifact()
+		a.DiskID = diskID
+		a.Path = path
+		a.Filename = filename
+		return a
+	}
+
+	stats := []pathutil.DirectoryStat{
+		{Path: "/docs/", FileCount: 2, TotalSize: 20},
+		{Path: "/docs/work/", FileCount: 1, TotalSize: 100},
+	}
+
+	tests := []struct {
+		name        string
+		in          ListArtifactsInput
+		setup       func(*MockArtifactRepo)
+		expectError bool
+		check       func(*testing.T, *ListArtifactsOutput)
+	}{
+		{
+			name: "first page with more results",
+			in:   ListArtifactsInput{DiskID: diskID, Path: "/docs/", Limit: 2},
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("ListByPathWithCursor", mock.Anything, diskID, "/docs/", false, 0, "", "", 3).Return([]*model.Artifact{
+					newArtifact("/docs/", "a.txt"),
+					newArtifact("/docs/", "b.txt"),
+					newArtifact("/docs/", "c.txt"),
+				}, nil)
+				repo.On("GetDirectoryStats", mock.Anything, diskID, "/docs/").Return(stats, nil)
+			},
+			check: func(t *testing.T, out *ListArtifactsOutput) {
+				assert.Len(t, out.Artifacts, 2)
+				assert.True(t, out.HasMore)
+				assert.NotEmpty(t, out.NextCursor)
+				assert.Equal(t, []string{"work"}, out.Directories)
+				assert.Nil(t, out.Tree)
+			},
+		},
+		{
+			name: "recursive first page builds tree",
+			in:   ListArtifactsInput{DiskID: diskID, Path: "/docs/", Recursive: true, Depth: 2, Limit: 10},
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("ListByPathWithCursor", mock.Anything, diskID, "/docs/", true, 2, "", "", 11).Return([]*model.Artifact{
+					newArtifact("/docs/", "a.txt"),
+					newArtifact("/docs/", "b.txt"),
+					newArtifact("/docs/work/", "c.txt"),
+				}, nil)
+				repo.On("GetDirectoryStats", mock.Anything, diskID, "/docs/").Return(stats, nil)
+			},
+			check: func(t *testing.T, out *ListArtifactsOutput) {
+				assert.Len(t, out.Artifacts, 3)
+				if assert.NotNil(t, out.Tree) {
+					assert.Equal(t, int64(3), out.Tree.FileCount)
+					assert.Equal(t, int64(120), out.Tree.TotalSize)
+					assert.Len(t, out.Tree.Children, 1)
+				}
+			},
+		},
+		{
+			name: "recursive listing continues from cursor without re-aggregating the tree",
+			in:   ListArtifactsInput{DiskID: diskID, Path: "/docs/", Recursive: true, Depth: 2, Limit: 10, Cursor: paging.EncodePathCursor("/docs/", "b.txt")},
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("ListByPathWithCursor", mock.Anything, diskID, "/docs/", true, 2, "/docs/", "b.txt", 11).Return([]*model.Artifact{
+					newArtifact("/docs/work/", "c.txt"),
+				}, nil)
+			},
+			check: func(t *testing.T, out *ListArtifactsOutput) {
+				assert.Len(t, out.Artifacts, 1)
+				assert.False(t, out.HasMore)
+				assert.Empty(t, out.NextCursor)
+				assert.Nil(t, out.Tree)
+				assert.Nil(t, out.Directories)
+			},
+		},
+		{
+			name:        "invalid cursor",
+			in:          ListArtifactsInput{DiskID: diskID, Path: "/", Limit: 10, Cursor: "!!!"},
+			setup:       func(repo *MockArtifactRepo) {},
+			expectError: true,
+		},
+		{
+			name: "stats error",
+			in:   ListArtifactsInput{DiskID: diskID, Path: "/", Limit: 10},
+			setup: func(repo *MockArtifactRepo) {
+				repo.On("ListByPathWithCursor", mock.Anything, diskID, "/", false, 0, "", "", 11).Return([]*model.Artifact{}, nil)
+				repo.On("GetDirectoryStats", mock.Anything, diskID, "/").Return(nil, errors.New("db error"))
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockArtifactRepo{}
+			tt.setup(mockRepo)
+
+			service := NewArtifactService(mockRepo, nil, nil, nil, nil)
+
+			out, err := service.List(context.Background(), tt.in)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, out)
+			} else {
+				assert.NoError(t, err)
+				tt.check(t, out)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// Test cases for Batch method
+func TestArtifactService_Batch(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+
+	tests := []struct {
+		name        string
+		ops         []ArtifactBatchOperation
+		setup       func(*MockArtifactRepo)
+		expectError bool
+	}{
+		{
+			name: "delete and move are passed to the repo in order",
+			ops: []ArtifactBatchOperation{
+				{Op: "delete", Path: "/a/", Filename: "x.txt"},
+				{Op: "move", Path: "/a/", Filename: "y.txt", DestPath: "/b/", DestFilename: "y.txt"},
+			},
+			setup: func(repoMock *MockArtifactRepo) {
+				repoMock.On("ApplyBatch", mock.Anything, projectID, diskID, []repo.ArtifactBatchOp{
+					{Type: repo.ArtifactBatchOpDelete, Path: "/a/", Filename: "x.txt"},
+					{Type: repo.ArtifactBatchOpMove, Path: "/a/", Filename: "y.txt", DestPath: "/b/", DestFilename: "y.txt"},
+				}).Return([]*model.Artifact{nil, createTestArtifact()}, nil)
+			},
+		},
+		{
+			name:        "unknown operation is rejected before touching the repo",
+			ops:         []ArtifactBatchOperation{{Op: "copy", Path: "/a/", Filename: "x.txt"}},
+			setup:       func(repoMock *MockArtifactRepo) {},
+			expectError: true,
+		},
+		{
+			name:        "move without destination",
+			ops:         []ArtifactBatchOperation{{Op: "move", Path: "/a/", Filename: "x.txt"}},
+			setup:       func(repoMock *MockArtifactRepo) {},
+			expectError: true,
+		},
+		{
+			name:        "empty operations",
+			ops:         nil,
+			setup:       func(repoMock *MockArtifactRepo) {},
+			expectError: true,
+		},
+		{
+			name: "repo error rolls back the batch",
+			ops:  []ArtifactBatchOperation{{Op: "delete", Path: "/a/", Filename: "x.txt"}},
+			setup: func(repoMock *MockArtifactRepo) {
+				repoMock.On("ApplyBatch", mock.Anything, projectID, diskID, mock.Anything).Return(nil, errors.New("record not found"))
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockArtifactRepo{}
+			tt.setup(mockRepo)
+
+			service := NewArtifactService(mockRepo, nil, nil, nil, nil)
+
+			results, err := service.Batch(context.Background(), BatchArtifactsInput{
+				ProjectID:  projectID,
+				DiskID:     diskID,
+				Operations: tt.ops,
+			})
+
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, results)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, results, len(tt.ops))
+				assert.Equal(t, "delete", results[0].Op)
+				assert.Nil(t, results[0].Artifact)
+				assert.NotNil(t, results[1].Artifact)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// Test cases for ImportArchive and ExportZip methods
+func TestArtifactService_ImportArchive(t *testing.T) {
+	diskID := uuid.New()
+
+	newZip := func(names ...string) []byte {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, name := range names {
+			_, err := zw.Create(name)
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, zw.Close())
+		return buf.Bytes()
+	}
+
+	t.Run("zip-slip entry is rejected before any upload", func(t *testing.T) {
+		mockRepo := &MockArtifactRepo{}
+		data := newZip("../evil.sh")
+
+		out, err := NewArtifactService(mockRepo, nil, nil, nil, nil).ImportArchive(context.Background(), ImportArchiveInput{
+			ProjectID: uuid.New(),
+			DiskID:    diskID,
+			Path:      "/",
+			Format:    archive.FormatZip,
+			Reader:    bytes.NewReader(data),
+			Size:      int64(len(data)),
+		})
+
+		assert.ErrorIs(t, err, archive.ErrUnsafePath)
+		assert.Nil(t, out)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("archive without files imports nothing", func(t *testing.T) {
+		mockRepo := &MockArtifactRepo{}
+		data := newZip("empty/")
+
+		out, err := NewArtifactService(mockRepo, nil, nil, nil, nil).ImportArchive(context.Background(), ImportArchiveInput{
+			ProjectID: uuid.New(),
+			DiskID:    diskID,
+			Path:      "/",
+			Format:    archive.FormatZip,
+			Reader:    bytes.NewReader(data),
+			Size:      int64(len(data)),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, out.Imported)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestArtifactService_ExportZip(t *testing.T) {
+	diskID := uuid.New()
+
+	t.Run("empty subtree produces an empty zip", func(t *testing.T) {
+		mockRepo := &MockArtifactRepo{}
+		mockRepo.On("ListByPathWithCursor", mock.Anything, diskID, "/docs/", true, 0, "", "", exportPageSize).Return([]*model.Artifact{}, nil)
+
+		var buf bytes.Buffer
+		err := NewArtifactService(mockRepo, nil, nil, nil, nil).ExportZip(context.Background(), diskID, "/docs/", &buf)
+
+		assert.NoError(t, err)
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		assert.NoError(t, err)
+		assert.Empty(t, zr.File)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("list error", func(t *testing.T) {
+		mockRepo := &MockArtifactRepo{}
+		mockRepo.On("ListByPathWithCursor", mock.Anything, diskID, "/", true, 0, "", "", exportPageSize).Return(nil, errors.New("db error"))
+
+		err := NewArtifactService(mockRepo, nil, nil, nil, nil).ExportZip(context.Background(), diskID, "/", io.Discard)
+
+		assert.Error(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// Test cases for disk quota enforcement
+func TestArtifactService_DiskQuota(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	limit := func(n int64) *int64 { return &n }
+
+	t.Run("upload over the byte quota is rejected before uploading", func(t *testing.T) {
+		mockRepo := &MockArtifactRepo{}
+		mockRepo.On("GetDiskUsage", mock.Anything, diskID).Return(&model.DiskUsage{Files: 3, Bytes: 1000, MaxBytes: limit(1500)}, nil)
+		mockRepo.On("GetByPath", mock.Anything, diskID, "/a/", "new.txt").Return(nil, gorm.ErrRecordNotFound)
+
+		// A nil S3 client makes any upload attempt panic
+		_, err := NewArtifactService(mockRepo, nil, nil, nil, nil).Create(context.Background(), CreateArtifactInput{
+			ProjectID:  projectID,
+			DiskID:     diskID,
+			Path:       "/a/",
+			Filename:   "new.txt",
+			FileHeader: &multipart.FileHeader{Filename: "new.txt", Size: 600},
+		})
+
+		assert.ErrorIs(t, err, ErrDiskQuotaExceeded)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown disk", func(t *testing.T) {
+		mockRepo := &MockArtifactRepo{}
+		mockRepo.On("GetDiskUsage", mock.Anything, diskID).Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := NewArtifactService(mockRepo, nil, nil, nil, nil).Create(context.Background(), CreateArtifactInput{
+			ProjectID:  projectID,
+			DiskID:     diskID,
+			Path:       "/",
+			Filename:   "new.txt",
+			FileHeader: &multipart.FileHeader{Filename: "new.txt", Size: 1},
+		})
+
+		assert.ErrorIs(t, err, ErrDiskNotFound)
+	})
+
+	t.Run("batch upserts count together against the file quota", func(t *testing.T) {
+		mockRepo := &MockArtifactRepo{}
+		mockRepo.On("GetDiskUsage", mock.Anything, diskID).Return(&model.DiskUsage{Files: 9, MaxFiles: limit(10)}, nil)
+		mockRepo.On("GetByPath", mock.Anything, diskID, "/", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := NewArtifactService(mockRepo, nil, nil, nil, nil).Batch(context.Background(), BatchArtifactsInput{
+			ProjectID: projectID,
+			DiskID:    diskID,
+			Operations: []ArtifactBatchOperation{
+				{Op: "upsert", Path: "/", Filename: "a.txt", Content: []byte("a")},
+				{Op: "upsert", Path: "/", Filename: "b.txt", Content: []byte("b")},
+			},
+		})
+
+		assert.ErrorIs(t, err, ErrDiskQuotaExceeded)
+		mockRepo.AssertNotCalled(t, "ApplyBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	tests := []struct {
+		name    string
+		usage   *model.DiskUsage
+		files   []pendingFile
+		setup   func(*MockArtifactRepo)
+		wantErr bool
+	}{
+		{
+			name:  "no quota skips the existing file lookups",
+			usage: &model.DiskUsage{Files: 100, Bytes: 1 << 30},
+			files: []pendingFile{{path: "/", filename: "a.txt", size: 1 << 30}},
+			setup: func(r *MockArtifactRepo) {},
+		},
+		{
+			name:  "replacing a file only counts the size difference",
+			usage: &model.DiskUsage{Files: 10, Bytes: 1000, MaxFiles: limit(10), MaxBytes: limit(1000)},
+			files: []pendingFile{{path: "/", filename: "a.txt", size: 300}},
+			setup: func(r *MockArtifactRepo) {
+				existing := createTestArtifact()
+				existing.AssetMeta = datatypes.NewJSONType(model.Asset{SizeB: 400})
+				r.On("GetByPath", mock.Anything, diskID, "/", "a.txt").Return(existing, nil)
+			},
+		},
+		{
+			name:  "shrinking a disk over its quota is allowed",
+			usage: &model.DiskUsage{Files: 20, Bytes: 5000, MaxFiles: limit(10), MaxBytes: limit(1000)},
+			files: []pendingFile{{path: "/", filename: "a.txt", size: 100}},
+			setup: func(r *MockArtifactRepo) {
+				existing := createTestArtifact()
+				existing.AssetMeta = datatypes.NewJSONType(model.Asset{SizeB: 400})
+				r.On("GetByPath", mock.Anything, diskID, "/", "a.txt").Return(existing, nil)
+			},
+		},
+		{
+			name:  "a new file over the file quota",
+			usage: &model.DiskUsage{Files: 10, MaxFiles: limit(10)},
+			files: []pendingFile{{path: "/", filename: "b.txt", size: 1}},
+			setup: func(r *MockArtifactRepo) {
+				r.On("GetByPath", mock.Anything, diskID, "/", "b.txt").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErr: true,
+		},
+		{
+			name:  "exactly filling the byte quota",
+			usage: &model.DiskUsage{Bytes: 900, MaxBytes: limit(1000)},
+			files: []pendingFile{{path: "/", filename: "b.txt", size: 100}},
+			setup: func(r *MockArtifactRepo) {
+				r.On("GetByPath", mock.Anything, diskID, "/", "b.txt").Return(nil, gorm.ErrRecordNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockArtifactRepo{}
+			mockRepo.On("GetDiskUsage", mock.Anything, diskID).Return(tt.usage, nil)
+			tt.setup(mockRepo)
+
+			s := NewArtifactService(mockRepo, nil, nil, nil, nil).(*artifactService)
+			err := s.checkDiskQuota(context.Background(), diskID, tt.files)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrDiskQuotaExceeded)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactService_AssetBatch(t *testing.T) {
+	projectID := uuid.New()
+	stored := &model.Asset{S3Key: "disks/stored.txt", SHA256: "stored"}
+
+	refs := &MockAssetReferenceRepo{}
+	refs.On("FindAsset", mock.Anything, projectID, "stored").Return(stored, nil)
+	refs.On("FindAsset", mock.Anything, projectID, "new").Return(nil, nil)
+
+	batch := NewArtifactService(&MockArtifactRepo{}, refs, nil, nil, nil).(*artifactService).newAssetBatch(projectID)
+	ctx := context.Background()
+
+	// Content already referenced in the project is found through asset_references
+	asset, err := batch.find(ctx, "stored")
+	assert.NoError(t, err)
+	assert.Equal(t, stored, asset)
+
+	asset, err = batch.find(ctx, "new")
+	assert.NoError(t, err)
+	assert.Nil(t, asset)
+
+	// Content uploaded earlier in the batch is reused before its reference is recorded
+	uploaded := &model.Asset{S3Key: "disks/new.txt", SHA256: "new"}
+	batch.add(uploaded)
+	asset, err = batch.find(ctx, "new")
+	assert.NoError(t, err)
+	assert.Equal(t, uploaded, asset)
+	refs.AssertNumberOfCalls(t, "FindAsset", 2)
+
+	// Without asset references only the batch itself is deduplicated
+	batch = NewArtifactService(&MockArtifactRepo{}, nil, nil, nil, nil).(*artifactService).newAssetBatch(projectID)
+	asset, err = batch.find(ctx, "stored")
+	assert.NoError(t, err)
+	assert.Nil(t, asset)
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"gorm.io/gorm"
+)
+
+const (
+	// uploadPartSize is the smallest part size handed out for multipart uploads.
+	// Files up to this size are uploaded with a single PUT.
+	uploadPartSize = 64 << 20
+	// maxUploadParts is the largest number of parts S3 accepts in a multipart upload
+	maxUploadParts = 10000
+	// maxUploadSize is the largest object S3 can store
+	maxUploadSize = 5 << 40
+	// uploadSweepGrace is how long after it expired an upload is left alone by Sweep, so a
+	// commit claimed just before the upload expired can finish
+	uploadSweepGrace = time.Hour
+	// uploadSweepBatch is how many expired uploads one Sweep call cleans up at most
+	uploadSweepBatch = 100
+)
+
+var (
+	ErrInvalidUpload     = errors.New("invalid upload")
+	ErrUploadNotFound    = errors.New("upload not found")
+	ErrUploadUnavailable = errors.New("upload has expired or was already committed or aborted")
+	ErrUploadIncomplete  = errors.New("upload has not finished")
+	ErrUploadMismatch    = errors.New("uploaded file does not match the declared size or sha256")
+)
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type ArtifactUploadService interface {
+	Create(ctx context.Context, in CreateUploadInput) (*CreateUploadOutput, error)
+	Commit(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, uploadID uuid.UUID) (*model.Artifact, error)
+	Abort(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, uploadID uuid.UUID) error
+	// Sweep cleans up the uploads left pending after they expired, and those left committing by
+	// a crash: their staging objects and multipart parts are deleted and they are marked expired.
+	// It returns how many uploads it swept.
+	Sweep(ctx context.Context) (int, error)
+}
+
+type artifactUploadService struct {
+	r         repo.ArtifactUploadRepo
+	artifacts *artifactService
+	store     blob.BlobStore
+	expire    time.Duration
+	now       func() time.Time
+}
+
+// NewArtifactUploadService creates the direct upload service. Upload URLs and upload slots
+// expire after expire; indexer and usage may be nil, as for NewArtifactService.
+func NewArtifactUploadService(r repo.ArtifactUploadRepo, artifactRepo repo.ArtifactRepo, assetRefs repo.AssetReferenceRepo, s3 blob.BlobStore, indexer ArtifactIndexer, usage UsageService, expire time.Duration) ArtifactUploadService {
+	return &artifactUploadService{
+		r:         r,
+		artifacts: &artifactService{r: artifactRepo, assetRefs: assetRefs, s3: s3, indexer: indexer, usage: usage},
+		store:     s3,
+		expire:    expire,
+		now:       time.Now,
+	}
+}
+
+type CreateUploadInput struct {
+	ProjectID uuid.UUID
+	DiskID    uuid.UUID
+	Path      string
+	Filename  string
+	MIME      string // Optional, derived from the filename extension when empty
+	Size      int64
+	SHA256    string
+	UserMeta  map[string]interface{}
+}
+
+// UploadPart is a presigned URL for one part of a multipart upload
+type UploadPart struct {
+	PartNumber int32  `json:"part_number"`
+	URL        string `json:"url"`
+}
+
+type CreateUploadOutput struct {
+	Upload *model.ArtifactUpload `json:"upload"`
+	// URL is the presigned PUT URL for single part uploads
+	URL string `json:"url,omitempty"`
+	// Parts are the presigned PUT URLs of each part, in order, for multipart uploads.
+	// Every part except the last must be exactly part_size bytes.
+	Parts []UploadPart `json:"parts,omitempty"`
+	// Headers must be sent with every PUT request
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// uploadPlan returns the part size and part count for a file, or zeros when a single PUT suffices
+func uploadPlan(size int64) (int64, int32) {
+	if size <= uploadPartSize {
+		return 0, 0
+	}
+	partSize := int64(uploadPartSize)
+	if minPartSize := (size + maxUploadParts - 1) / maxUploadParts; minPartSize > partSize {
+		// Round up to whole MiB to keep part sizes readable
+		partSize = (minPartSize + 1<<20 - 1) &^ (1<<20 - 1)
+	}
+	return partSize, int32((size + partSize - 1) / partSize)
+}
+
+func (s *artifactUploadService) Create(ctx context.Context, in CreateUploadInput) (*CreateUploadOutput, error) {
+	if in.Size < 0 || in.Size > maxUploadSize {
+		return nil, fmt.Errorf("%w: size must be between 0 and %d bytes", ErrInvalidUpload, int64(maxUploadSize))
+	}
+	sum := strings.ToLower(in.SHA256)
+	if !sha256Pattern.MatchString(sum) {
+		return nil, fmt.Errorf("%w: sha256 must be 64 hex characters", ErrInvalidUpload)
+	}
+	if in.Path == "" || in.Filename == "" {
+		return nil, fmt.Errorf("%w: path and filename are required", ErrInvalidUpload)
+	}
+	mimeType := in.MIME
+	if mimeType == "" {
+		// The content is not available yet, so only the extension can tell the type
+		if mimeType = mime.TypeByExtension(filepath.Ext(in.Filename)); mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+	}
+
+	if err := s.artifacts.checkDiskQuota(ctx, in.DiskID, []pendingFile{{path: in.Path, filename: in.Filename, size: in.Size}}); err != nil {
+		return nil, err
+	}
+	if err := s.artifacts.checkUsage(ctx, in.ProjectID, in.Size); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	partSize, partCount := uploadPlan(in.Size)
+	upload := &model.ArtifactUpload{
+		ID:         id,
+		ProjectID:  in.ProjectID,
+		DiskID:     in.DiskID,
+		Path:       in.Path,
+		Filename:   in.Filename,
+		MIME:       mimeType,
+		SizeB:      in.Size,
+		SHA256:     sum,
+		UserMeta:   in.UserMeta,
+		StagingKey: fmt.Sprintf("uploads/%s/%s", in.ProjectID, id),
+		PartSize:   partSize,
+		PartCount:  partCount,
+		Status:     model.UploadStatusPending,
+		ExpiresAt:  s.now().Add(s.expire),
+	}
+	out := &CreateUploadOutput{Upload: upload}
+
+	if partCount == 0 {
+		url, err := s.store.PresignPut(ctx, upload.StagingKey, mimeType, s.expire)
+		if err != nil {
+			return nil, fmt.Errorf("presign upload: %w", err)
+		}
+		out.URL = url
+		out.Headers = map[string]string{"Content-Type": mimeType}
+	} else {
+		mpuID, err := s.store.CreateMultipartUpload(ctx, upload.StagingKey, mimeType)
+		if err != nil {
+			return nil, err
+		}
+		upload.MultipartUploadID = &mpuID
+		out.Parts = make([]UploadPart, 0, partCount)
+		for n := int32(1); n <= partCount; n++ {
+			url, err := s.store.PresignUploadPart(ctx, upload.StagingKey, mpuID, n, s.expire)
+			if err != nil {
+				_ = s.store.AbortMultipartUpload(ctx, upload.StagingKey, mpuID)
+				return nil, fmt.Errorf("presign upload part %d: %w", n, err)
+			}
+			out.Parts = append(out.Parts, UploadPart{PartNumber: n, URL: url})
+		}
+	}
+
+	if err := s.r.Create(ctx, upload); err != nil {
+		if upload.MultipartUploadID != nil {
+			_ = s.store.AbortMultipartUpload(ctx, upload.StagingKey, *upload.MultipartUploadID)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDiskNotFound
+		}
+		return nil, fmt.Errorf("create upload: %w", err)
+	}
+
+	return out, nil
+}
+
+// claim loads a pending upload and moves it to status to.
+// Expired uploads can still be aborted but no longer committed.
+func (s *artifactUploadService) claim(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, uploadID uuid.UUID, to model.UploadStatus) (*model.ArtifactUpload, error) {
+	upload, err := s.r.Get(ctx, projectID, diskID, uploadID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, fmt.Errorf("get upload: %w", err)
+	}
+	if upload.Status != model.UploadStatusPending || (to == model.UploadStatusCommitting && !s.now().Before(upload.ExpiresAt)) {
+		return nil, ErrUploadUnavailable
+	}
+	if err := s.r.Transition(ctx, upload.ID, model.UploadStatusPending, to); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadUnavailable
+		}
+		return nil, fmt.Errorf("claim upload: %w", err)
+	}
+	upload.Status = to
+	return upload, nil
+}
+
+// errUploadPromoted wraps failures after the staged file was moved to its final key. The
+// staging object is gone then, so the commit cannot be retried; the moved object is left to
+// the asset garbage collector.
+var errUploadPromoted = errors.New("uploaded file was stored but not recorded")
+
+// Commit verifies the uploaded file against the declared size and SHA256 and turns it into an
+// artifact. A mismatching file is discarded and the upload aborted, as is an upload whose file
+// was stored but could not be recorded; other failures leave the upload pending so the commit
+// can be retried.
+func (s *artifactUploadService) Commit(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, uploadID uuid.UUID) (*model.Artifact, error) {
+	upload, err := s.claim(ctx, projectID, diskID, uploadID, model.UploadStatusCommitting)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact, err := s.commit(ctx, upload)
+	if err != nil {
+		next := model.UploadStatusPending
+		switch {
+		case errors.Is(err, ErrUploadMismatch):
+			next = model.UploadStatusAborted
+			_ = s.store.DeleteObject(ctx, upload.StagingKey)
+		case errors.Is(err, errUploadPromoted):
+			next = model.UploadStatusAborted
+		}
+		if terr := s.r.Transition(ctx, upload.ID, model.UploadStatusCommitting, next); terr != nil {
+			return nil, errors.Join(err, fmt.Errorf("release upload: %w", terr))
+		}
+		return nil, err
+	}
+
+	if err := s.r.Transition(ctx, upload.ID, model.UploadStatusCommitting, model.UploadStatusCommitted); err != nil {
+		return nil, fmt.Errorf("mark upload committed: %w", err)
+	}
+	return artifact, nil
+}
+
+func (s *artifactUploadService) commit(ctx context.Context, upload *model.ArtifactUpload) (*model.Artifact, error) {
+	if upload.MultipartUploadID != nil {
+		err := s.store.CompleteMultipartUpload(ctx, upload.StagingKey, *upload.MultipartUploadID, upload.PartCount)
+		if err != nil {
+			if errors.Is(err, blob.ErrIncompleteUpload) || errors.Is(err, blob.ErrObjectNotFound) {
+				return nil, fmt.Errorf("%w: %v", ErrUploadIncomplete, err)
+			}
+			return nil, err
+		}
+		if err := s.r.ClearMultipart(ctx, upload.ID); err != nil {
+			return nil, fmt.Errorf("update upload: %w", err)
+		}
+		upload.MultipartUploadID = nil
+	}
+
+	sum, size, err := s.store.HashObject(ctx, upload.StagingKey)
+	if err != nil {
+		if errors.Is(err, blob.ErrObjectNotFound) {
+			return nil, fmt.Errorf("%w: no file was uploaded", ErrUploadIncomplete)
+		}
+		return nil, err
+	}
+	if size != upload.SizeB || sum != upload.SHA256 {
+		return nil, fmt.Errorf("%w: got %d bytes with sha256 %s, declared %d bytes with sha256 %s",
+			ErrUploadMismatch, size, sum, upload.SizeB, upload.SHA256)
+	}
+
+	if err := s.artifacts.checkDiskQuota(ctx, upload.DiskID, []pendingFile{{path: upload.Path, filename: upload.Filename, size: size}}); err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(upload.Filename))
+	asset, err := s.store.PromoteObject(ctx, upload.StagingKey, "disks/"+upload.ProjectID.String(), sum, upload.MIME, ext, size,
+		map[string]string{"sha256": sum, "name": upload.Filename}, s.artifacts.assetLookup(upload.ProjectID))
+	if err != nil {
+		return nil, fmt.Errorf("store uploaded file: %w", err)
+	}
+
+	// The artifact already at the path, if any, is only replaced once the file is in place
+	artifact, err := s.artifacts.replaceArtifact(ctx, upload.ProjectID, upload.DiskID, upload.Path, upload.Filename, upload.Filename, asset, upload.UserMeta)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUploadPromoted, err)
+	}
+	return artifact, nil
+}
+
+// Abort discards a pending upload and whatever was uploaded for it
+func (s *artifactUploadService) Abort(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, uploadID uuid.UUID) error {
+	upload, err := s.claim(ctx, projectID, diskID, uploadID, model.UploadStatusAborted)
+	if err != nil {
+		return err
+	}
+	if upload.MultipartUploadID != nil {
+		return s.store.AbortMultipartUpload(ctx, upload.StagingKey, *upload.MultipartUploadID)
+	}
+	return s.store.DeleteObject(ctx, upload.StagingKey)
+}
+
+// Sweep discards the staging data of uploads that expired more than uploadSweepGrace ago
+// without being committed or aborted, and marks them expired. Uploads it fails to clean up
+// stay as they are, so the next call retries them.
+func (s *artifactUploadService) Sweep(ctx context.Context) (int, error) {
+	uploads, err := s.r.ListExpired(ctx, s.now().Add(-uploadSweepGrace), uploadSweepBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list expired uploads: %w", err)
+	}
+
+	swept := 0
+	var errs []error
+	for _, upload := range uploads {
+		if err := s.discard(ctx, &upload); err != nil {
+			errs = append(errs, fmt.Errorf("upload %s: %w", upload.ID, err))
+			continue
+		}
+		if err := s.r.Transition(ctx, upload.ID, upload.Status, model.UploadStatusExpired); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Aborted meanwhile
+				continue
+			}
+			errs = append(errs, fmt.Errorf("upload %s: mark expired: %w", upload.ID, err))
+			continue
+		}
+		swept++
+	}
+	return swept, errors.Join(errs...)
+}
+
+// discard deletes the parts and the staging object of an upload. A multipart upload that was
+// assembled before a crash has left its object on the staging key, so both are always removed.
+func (s *artifactUploadService) discard(ctx context.Context, upload *model.ArtifactUpload) error {
+	if upload.MultipartUploadID != nil {
+		if err := s.store.AbortMultipartUpload(ctx, upload.StagingKey, *upload.MultipartUploadID); err != nil {
+			return err
+		}
+	}
+	return s.store.DeleteObject(ctx, upload.StagingKey)
+}
@@ -0,0 +1,549 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// MockArtifactUploadRepo is a mock implementation of ArtifactUploadRepo
+type MockArtifactUploadRepo struct {
+	mock.Mock
+}
+
+func (m *MockArtifactUploadRepo) Create(ctx context.Context, u *model.ArtifactUpload) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *MockArtifactUploadRepo) Get(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, uploadID uuid.UUID) (*model.ArtifactUpload, error) {
+	args := m.Called(ctx, projectID, diskID, uploadID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ArtifactUpload), args.Error(1)
+}
+
+func (m *MockArtifactUploadRepo) Transition(ctx context.Context, uploadID uuid.UUID, from model.UploadStatus, to model.UploadStatus) error {
+	args := m.Called(ctx, uploadID, from, to)
+	return args.Error(0)
+}
+
+func (m *MockArtifactUploadRepo) ClearMultipart(ctx context.Context, uploadID uuid.UUID) error {
+	args := m.Called(ctx, uploadID)
+	return args.Error(0)
+}
+
+func (m *MockArtifactUploadRepo) ListExpired(ctx context.Context, before time.Time, limit int) ([]model.ArtifactUpload, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ArtifactUpload), args.Error(1)
+}
+
+// MockUploadStore mocks the parts of blob.BlobStore used by direct uploads
+type MockUploadStore struct {
+	mock.Mock
+	blob.BlobStore
+}
+
+func (m *MockUploadStore) PresignPut(ctx context.Context, key, contentType string, expire time.Duration) (string, error) {
+	args := m.Called(ctx, key, contentType, expire)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUploadStore) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	args := m.Called(ctx, key, contentType)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUploadStore) PresignUploadPart(ctx context.Context, key, uploadID string, partNumber int32, expire time.Duration) (string, error) {
+	args := m.Called(ctx, key, uploadID, partNumber, expire)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUploadStore) CompleteMultipartUpload(ctx context.Context, key, uploadID string, partCount int32) error {
+	args := m.Called(ctx, key, uploadID, partCount)
+	return args.Error(0)
+}
+
+func (m *MockUploadStore) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	args := m.Called(ctx, key, uploadID)
+	return args.Error(0)
+}
+
+func (m *MockUploadStore) HashObject(ctx context.Context, key string) (string, int64, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUploadStore) PromoteObject(ctx context.Context, stagingKey, keyPrefix, sumHex, contentType, ext string, size int64, metadata map[string]string, lookup blob.AssetLookup) (*model.Asset, error) {
+	args := m.Called(ctx, stagingKey, keyPrefix, sumHex, contentType, ext, size, metadata)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Asset), args.Error(1)
+}
+
+func (m *MockUploadStore) DeleteObject(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+const testUploadSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func newTestUploadService(r *MockArtifactUploadRepo, artifactRepo *MockArtifactRepo, store *MockUploadStore, now time.Time) *artifactUploadService {
+	return &artifactUploadService{
+		r:         r,
+		artifacts: &artifactService{r: artifactRepo},
+		store:     store,
+		expire:    15 * time.Minute,
+		now:       func() time.Time { return now },
+	}
+}
+
+func TestUploadPlan(t *testing.T) {
+	tests := []struct {
+		size      int64
+		partSize  int64
+		partCount int32
+	}{
+		{size: 0},
+		{size: uploadPartSize},
+		{size: uploadPartSize + 1, partSize: uploadPartSize, partCount: 2},
+		{size: 10 << 30, partSize: uploadPartSize, partCount: 160},
+		// Over 10000 parts of the default size, parts grow to keep within the S3 limit
+		{size: 1 << 40, partSize: 105 << 20, partCount: 9987},
+	}
+	for _, tt := range tests {
+		partSize, partCount := uploadPlan(tt.size)
+		assert.Equal(t, tt.partSize, partSize, "size %d", tt.size)
+		assert.Equal(t, tt.partCount, partCount, "size %d", tt.size)
+		assert.LessOrEqual(t, partCount, int32(maxUploadParts))
+	}
+}
+
+func TestArtifactUploadService_Create(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	projectID, diskID := uuid.New(), uuid.New()
+
+	t.Run("small file gets a single presigned PUT", func(t *testing.T) {
+		r, artifactRepo, store := &MockArtifactUploadRepo{}, &MockArtifactRepo{}, &MockUploadStore{}
+		artifactRepo.On("GetDiskUsage", mock.Anything, diskID).Return(&model.DiskUsage{}, nil)
+		store.On("PresignPut", mock.Anything, mock.AnythingOfType("string"), "text/csv; charset=utf-8", 15*time.Minute).Return("https://s3/put", nil)
+		r.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		out, err := newTestUploadService(r, artifactRepo, store, now).Create(context.Background(), CreateUploadInput{
+			ProjectID: projectID,
+			DiskID:    diskID,
+			Path:      "/data/",
+			Filename:  "small.csv",
+			Size:      1024,
+			SHA256:    testUploadSHA256,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "https://s3/put", out.URL)
+		assert.Empty(t, out.Parts)
+		assert.Equal(t, "text/csv; charset=utf-8", out.Headers["Content-Type"])
+		assert.Equal(t, model.UploadStatusPending, out.Upload.Status)
+		assert.Equal(t, now.Add(15*time.Minute), out.Upload.ExpiresAt)
+		assert.Contains(t, out.Upload.StagingKey, "uploads/"+projectID.String()+"/")
+		assert.Nil(t, out.Upload.MultipartUploadID)
+		store.AssertExpectations(t)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("large file gets one presigned URL per part", func(t *testing.T) {
+		r, artifactRepo, store := &MockArtifactUploadRepo{}, &MockArtifactRepo{}, &MockUploadStore{}
+		artifactRepo.On("GetDiskUsage", mock.Anything, diskID).Return(&model.DiskUsage{}, nil)
+		store.On("CreateMultipartUpload", mock.Anything, mock.AnythingOfType("string"), "application/octet-stream").Return("mpu-1", nil)
+		store.On("PresignUploadPart", mock.Anything, mock.AnythingOfType("string"), "mpu-1", mock.AnythingOfType("int32"), 15*time.Minute).Return("https://s3/part", nil)
+		r.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+		out, err := newTestUploadService(r, artifactRepo, store, now).Create(context.Background(), CreateUploadInput{
+			ProjectID: projectID,
+			DiskID:    diskID,
+			Path:      "/data/",
+			Filename:  "big.bin",
+			Size:      3*uploadPartSize - 1,
+			SHA256:    testUploadSHA256,
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, out.URL)
+		assert.Len(t, out.Parts, 3)
+		assert.Equal(t, int32(3), out.Parts[2].PartNumber)
+		assert.Equal(t, int64(uploadPartSize), out.Upload.PartSize)
+		assert.Equal(t, "mpu-1", *out.Upload.MultipartUploadID)
+		store.AssertNumberOfCalls(t, "PresignUploadPart", 3)
+	})
+
+	t.Run("unknown disk aborts the multipart upload", func(t *testing.T) {
+		r, artifactRepo, store := &MockArtifactUploadRepo{}, &MockArtifactRepo{}, &MockUploadStore{}
+		artifactRepo.On("GetDiskUsage", mock.Anything, diskID).Return(&model.DiskUsage{}, nil)
+		store.On("CreateMultipartUpload", mock.Anything, mock.Anything, mock.Anything).Return("mpu-1", nil)
+		store.On("PresignUploadPart", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("https://s3/part", nil)
+		store.On("AbortMultipartUpload", mock.Anything, mock.Anything, "mpu-1").Return(nil)
+		r.On("Create", mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+
+		_, err := newTestUploadService(r, artifactRepo, store, now).Create(context.Background(), CreateUploadInput{
+			ProjectID: projectID,
+			DiskID:    diskID,
+			Path:      "/",
+			Filename:  "big.bin",
+			Size:      2 * uploadPartSize,
+			SHA256:    testUploadSHA256,
+		})
+
+		assert.ErrorIs(t, err, ErrDiskNotFound)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("declared size over the disk quota", func(t *testing.T) {
+		r, artifactRepo, store := &MockArtifactUploadRepo{}, &MockArtifactRepo{}, &MockUploadStore{}
+		maxBytes := int64(100)
+		artifactRepo.On("GetDiskUsage", mock.Anything, diskID).Return(&model.DiskUsage{MaxBytes: &maxBytes}, nil)
+		artifactRepo.On("GetByPath", mock.Anything, diskID, "/", "a.bin").Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := newTestUploadService(r, artifactRepo, store, now).Create(context.Background(), CreateUploadInput{
+			ProjectID: projectID,
+			DiskID:    diskID,
+			Path:      "/",
+			Filename:  "a.bin",
+			Size:      101,
+			SHA256:    testUploadSHA256,
+		})
+
+		assert.ErrorIs(t, err, ErrDiskQuotaExceeded)
+		store.AssertNotCalled(t, "PresignPut", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	for name, in := range map[string]CreateUploadInput{
+		"invalid sha256": {Path: "/", Filename: "a.bin", SHA256: "abc"},
+		"negative size":  {Path: "/", Filename: "a.bin", SHA256: testUploadSHA256, Size: -1},
+		"too large":      {Path: "/", Filename: "a.bin", SHA256: testUploadSHA256, Size: maxUploadSize + 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := newTestUploadService(&MockArtifactUploadRepo{}, &MockArtifactRepo{}, &MockUploadStore{}, now).Create(context.Background(), in)
+			assert.ErrorIs(t, err, ErrInvalidUpload)
+		})
+	}
+}
+
+func TestArtifactUploadService_Commit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	projectID, diskID, uploadID := uuid.New(), uuid.New(), uuid.New()
+	stagingKey := "uploads/" + projectID.String() + "/" + uploadID.String()
+	mpuID := "mpu-1"
+
+	newUpload := func() *model.ArtifactUpload {
+		return &model.ArtifactUpload{
+			ID:                uploadID,
+			ProjectID:         projectID,
+			DiskID:            diskID,
+			Path:              "/data/",
+			Filename:          "big.bin",
+			MIME:              "application/octet-stream",
+			SizeB:             2 * uploadPartSize,
+			SHA256:            testUploadSHA256,
+			StagingKey:        stagingKey,
+			MultipartUploadID: &mpuID,
+			PartSize:          uploadPartSize,
+			PartCount:         2,
+			Status:            model.UploadStatusPending,
+			ExpiresAt:         now.Add(time.Minute),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		upload     func() *model.ArtifactUpload
+		setup      func(*MockArtifactUploadRepo, *MockArtifactRepo, *MockUploadStore)
+		wantErrIs  error
+		wantStatus model.UploadStatus // Status the upload is left in after claiming it
+	}{
+		{
+			name:   "verified upload becomes an artifact",
+			upload: newUpload,
+			setup: func(r *MockArtifactUploadRepo, a *MockArtifactRepo, s *MockUploadStore) {
+				s.On("CompleteMultipartUpload", mock.Anything, stagingKey, mpuID, int32(2)).Return(nil)
+				r.On("ClearMultipart", mock.Anything, uploadID).Return(nil)
+				s.On("HashObject", mock.Anything, stagingKey).Return(testUploadSHA256, int64(2*uploadPartSize), nil)
+				a.On("GetDiskUsage", mock.Anything, diskID).Return(&model.DiskUsage{}, nil)
+				s.On("PromoteObject", mock.Anything, stagingKey, "disks/"+projectID.String(), testUploadSHA256, "application/octet-stream", ".bin", int64(2*uploadPartSize), mock.Anything).
+					Return(&model.Asset{S3Key: "disks/key.bin", SHA256: testUploadSHA256, SizeB: 2 * uploadPartSize}, nil)
+				// The existing artifact is replaced in the same transaction as the new one is created
+				a.On("ApplyBatch", mock.Anything, projectID, diskID, mock.MatchedBy(func(ops []repo.ArtifactBatchOp) bool {
+					return len(ops) == 1 && ops[0].Type == repo.ArtifactBatchOpUpsert &&
+						ops[0].Artifact.Path == "/data/" && ops[0].Artifact.Filename == "big.bin" &&
+						ops[0].Artifact.AssetMeta.Data().S3Key == "disks/key.bin"
+				})).Return([]*model.Artifact{{Path: "/data/", Filename: "big.bin"}}, nil)
+			},
+			wantStatus: model.UploadStatusCommitted,
+		},
+		{
+			name:   "artifact that cannot be recorded after promotion aborts the upload",
+			upload: newUpload,
+			setup: func(r *MockArtifactUploadRepo, a *MockArtifactRepo, s *MockUploadStore) {
+				s.On("CompleteMultipartUpload", mock.Anything, stagingKey, mpuID, int32(2)).Return(nil)
+				r.On("ClearMultipart", mock.Anything, uploadID).Return(nil)
+				s.On("HashObject", mock.Anything, stagingKey).Return(testUploadSHA256, int64(2*uploadPartSize), nil)
+				a.On("GetDiskUsage", mock.Anything, diskID).Return(&model.DiskUsage{}, nil)
+				s.On("PromoteObject", mock.Anything, stagingKey, "disks/"+projectID.String(), testUploadSHA256, "application/octet-stream", ".bin", int64(2*uploadPartSize), mock.Anything).
+					Return(&model.Asset{S3Key: "disks/key.bin", SHA256: testUploadSHA256, SizeB: 2 * uploadPartSize}, nil)
+				a.On("ApplyBatch", mock.Anything, projectID, diskID, mock.Anything).Return(nil, errors.New("db down"))
+			},
+			wantErrIs:  errUploadPromoted,
+			wantStatus: model.UploadStatusAborted,
+		},
+		{
+			name:   "missing parts leave the upload pending",
+			upload: newUpload,
+			setup: func(r *MockArtifactUploadRepo, a *MockArtifactRepo, s *MockUploadStore) {
+				s.On("CompleteMultipartUpload", mock.Anything, stagingKey, mpuID, int32(2)).Return(blob.ErrIncompleteUpload)
+			},
+			wantErrIs:  ErrUploadIncomplete,
+			wantStatus: model.UploadStatusPending,
+		},
+		{
+			name: "single PUT that never happened",
+			upload: func() *model.ArtifactUpload {
+				u := newUpload()
+				u.MultipartUploadID, u.PartCount, u.PartSize = nil, 0, 0
+				return u
+			},
+			setup: func(r *MockArtifactUploadRepo, a *MockArtifactRepo, s *MockUploadStore) {
+				s.On("HashObject", mock.Anything, stagingKey).Return("", int64(0), blob.ErrObjectNotFound)
+			},
+			wantErrIs:  ErrUploadIncomplete,
+			wantStatus: model.UploadStatusPending,
+		},
+		{
+			name:   "checksum mismatch discards the file",
+			upload: newUpload,
+			setup: func(r *MockArtifactUploadRepo, a *MockArtifactRepo, s *MockUploadStore) {
+				s.On("CompleteMultipartUpload", mock.Anything, stagingKey, mpuID, int32(2)).Return(nil)
+				r.On("ClearMultipart", mock.Anything, uploadID).Return(nil)
+				s.On("HashObject", mock.Anything, stagingKey).Return("0000000000000000000000000000000000000000000000000000000000000000", int64(2*uploadPartSize), nil)
+				s.On("DeleteObject", mock.Anything, stagingKey).Return(nil)
+			},
+			wantErrIs:  ErrUploadMismatch,
+			wantStatus: model.UploadStatusAborted,
+		},
+		{
+			name:   "size mismatch discards the file",
+			upload: newUpload,
+			setup: func(r *MockArtifactUploadRepo, a *MockArtifactRepo, s *MockUploadStore) {
+				s.On("CompleteMultipartUpload", mock.Anything, stagingKey, mpuID, int32(2)).Return(nil)
+				r.On("ClearMultipart", mock.Anything, uploadID).Return(nil)
+				s.On("HashObject", mock.Anything, stagingKey).Return(testUploadSHA256, int64(10), nil)
+				s.On("DeleteObject", mock.Anything, stagingKey).Return(nil)
+			},
+			wantErrIs:  ErrUploadMismatch,
+			wantStatus: model.UploadStatusAborted,
+		},
+		{
+			name: "expired upload",
+			upload: func() *model.ArtifactUpload {
+				u := newUpload()
+				u.ExpiresAt = now
+				return u
+			},
+			setup:     func(r *MockArtifactUploadRepo, a *MockArtifactRepo, s *MockUploadStore) {},
+			wantErrIs: ErrUploadUnavailable,
+		},
+		{
+			name: "already committed",
+			upload: func() *model.ArtifactUpload {
+				u := newUpload()
+				u.Status = model.UploadStatusCommitted
+				return u
+			},
+			setup:     func(r *MockArtifactUploadRepo, a *MockArtifactRepo, s *MockUploadStore) {},
+			wantErrIs: ErrUploadUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, artifactRepo, store := &MockArtifactUploadRepo{}, &MockArtifactRepo{}, &MockUploadStore{}
+			r.On("Get", mock.Anything, projectID, diskID, uploadID).Return(tt.upload(), nil)
+			if tt.wantStatus != "" {
+				r.On("Transition", mock.Anything, uploadID, model.UploadStatusPending, model.UploadStatusCommitting).Return(nil)
+				r.On("Transition", mock.Anything, uploadID, model.UploadStatusCommitting, tt.wantStatus).Return(nil)
+			}
+			tt.setup(r, artifactRepo, store)
+
+			artifact, err := newTestUploadService(r, artifactRepo, store, now).Commit(context.Background(), projectID, diskID, uploadID)
+			if tt.wantErrIs != nil {
+				assert.True(t, errors.Is(err, tt.wantErrIs), "error = %v", err)
+				artifactRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "big.bin", artifact.Filename)
+			}
+			if tt.wantStatus == "" {
+				r.AssertNotCalled(t, "Transition", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+			r.AssertExpectations(t)
+			artifactRepo.AssertExpectations(t)
+			store.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArtifactUploadService_CommitRace(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	projectID, diskID, uploadID := uuid.New(), uuid.New(), uuid.New()
+
+	r, store := &MockArtifactUploadRepo{}, &MockUploadStore{}
+	r.On("Get", mock.Anything, projectID, diskID, uploadID).Return(&model.ArtifactUpload{
+		ID:        uploadID,
+		Status:    model.UploadStatusPending,
+		ExpiresAt: now.Add(time.Minute),
+	}, nil)
+	// Another request claimed the upload between the read and the update
+	r.On("Transition", mock.Anything, uploadID, model.UploadStatusPending, model.UploadStatusCommitting).Return(gorm.ErrRecordNotFound)
+
+	_, err := newTestUploadService(r, &MockArtifactRepo{}, store, now).Commit(context.Background(), projectID, diskID, uploadID)
+	assert.ErrorIs(t, err, ErrUploadUnavailable)
+	store.AssertNotCalled(t, "HashObject", mock.Anything, mock.Anything)
+}
+
+func TestArtifactUploadService_Abort(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	projectID, diskID, uploadID := uuid.New(), uuid.New(), uuid.New()
+	mpuID := "mpu-1"
+
+	t.Run("expired multipart upload can still be aborted", func(t *testing.T) {
+		r, store := &MockArtifactUploadRepo{}, &MockUploadStore{}
+		r.On("Get", mock.Anything, projectID, diskID, uploadID).Return(&model.ArtifactUpload{
+			ID:                uploadID,
+			StagingKey:        "uploads/key",
+			MultipartUploadID: &mpuID,
+			Status:            model.UploadStatusPending,
+			ExpiresAt:         now.Add(-time.Minute),
+		}, nil)
+		r.On("Transition", mock.Anything, uploadID, model.UploadStatusPending, model.UploadStatusAborted).Return(nil)
+		store.On("AbortMultipartUpload", mock.Anything, "uploads/key", mpuID).Return(nil)
+
+		err := newTestUploadService(r, &MockArtifactRepo{}, store, now).Abort(context.Background(), projectID, diskID, uploadID)
+		assert.NoError(t, err)
+		r.AssertExpectations(t)
+		store.AssertExpectations(t)
+	})
+
+	t.Run("unknown upload", func(t *testing.T) {
+		r := &MockArtifactUploadRepo{}
+		r.On("Get", mock.Anything, projectID, diskID, uploadID).Return(nil, gorm.ErrRecordNotFound)
+
+		err := newTestUploadService(r, &MockArtifactRepo{}, &MockUploadStore{}, now).Abort(context.Background(), projectID, diskID, uploadID)
+		assert.ErrorIs(t, err, ErrUploadNotFound)
+	})
+}
+
+func TestArtifactUploadService_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	projectID, diskID := uuid.New(), uuid.New()
+	content := "id,name\n1,alice\n"
+	sum := sha256.Sum256([]byte(content))
+	store := blob.NewMemoryStore(blob.NewURLSigner("http://api.test", []byte("secret")))
+
+	r, artifactRepo := &MockArtifactUploadRepo{}, &MockArtifactRepo{}
+	var upload *model.ArtifactUpload
+	r.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		upload = args.Get(1).(*model.ArtifactUpload)
+	}).Return(nil)
+	r.On("Transition", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	artifactRepo.On("GetDiskUsage", mock.Anything, diskID).Return(&model.DiskUsage{}, nil)
+	created := &model.Artifact{}
+	artifactRepo.On("ApplyBatch", mock.Anything, projectID, diskID, mock.Anything).Run(func(args mock.Arguments) {
+		*created = *args.Get(3).([]repo.ArtifactBatchOp)[0].Artifact
+	}).Return([]*model.Artifact{created}, nil)
+
+	svc := newTestUploadService(r, artifactRepo, nil, now)
+	svc.store = store
+
+	out, err := svc.Create(ctx, CreateUploadInput{
+		ProjectID: projectID,
+		DiskID:    diskID,
+		Path:      "/",
+		Filename:  "users.csv",
+		Size:      int64(len(content)),
+		SHA256:    hex.EncodeToString(sum[:]),
+	})
+	require.NoError(t, err)
+
+	// The client sends the file to the presigned URL, which the API serves for the memory driver
+	u, err := url.Parse(out.URL)
+	require.NoError(t, err)
+	key := strings.TrimPrefix(u.Path, blob.SignedURLPath)
+	require.NoError(t, store.Verify(http.MethodPut, key, u.Query()))
+	require.NoError(t, store.Write(key, u.Query(), strings.NewReader(content)))
+
+	r.On("Get", mock.Anything, projectID, diskID, out.Upload.ID).Return(upload, nil)
+	artifact, err := svc.Commit(ctx, projectID, diskID, out.Upload.ID)
+	require.NoError(t, err)
+
+	asset := artifact.AssetMeta.Data()
+	assert.Equal(t, blob.DriverMemory, asset.Bucket)
+	data, err := store.DownloadFile(ctx, asset.S3Key)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	_, err = store.DownloadFile(ctx, key)
+	assert.ErrorIs(t, err, blob.ErrObjectNotFound, "staging object is removed")
+}
+
+func TestArtifactUploadService_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	mpuID := "mpu-1"
+
+	pending := model.ArtifactUpload{ID: uuid.New(), StagingKey: "uploads/p/pending", Status: model.UploadStatusPending}
+	stuck := model.ArtifactUpload{ID: uuid.New(), StagingKey: "uploads/p/stuck", Status: model.UploadStatusCommitting, MultipartUploadID: &mpuID}
+	failing := model.ArtifactUpload{ID: uuid.New(), StagingKey: "uploads/p/failing", Status: model.UploadStatusPending}
+	aborted := model.ArtifactUpload{ID: uuid.New(), StagingKey: "uploads/p/aborted", Status: model.UploadStatusPending}
+
+	r := &MockArtifactUploadRepo{}
+	store := &MockUploadStore{}
+	r.On("ListExpired", ctx, now.Add(-uploadSweepGrace), uploadSweepBatch).Return([]model.ArtifactUpload{pending, stuck, failing, aborted}, nil)
+
+	store.On("DeleteObject", ctx, pending.StagingKey).Return(nil)
+	r.On("Transition", ctx, pending.ID, model.UploadStatusPending, model.UploadStatusExpired).Return(nil)
+
+	// A crash while committing leaves the parts, and maybe the assembled object, behind
+	store.On("AbortMultipartUpload", ctx, stuck.StagingKey, mpuID).Return(nil)
+	store.On("DeleteObject", ctx, stuck.StagingKey).Return(nil)
+	r.On("Transition", ctx, stuck.ID, model.UploadStatusCommitting, model.UploadStatusExpired).Return(nil)
+
+	// An upload whose staging object cannot be deleted stays for the next sweep
+	store.On("DeleteObject", ctx, failing.StagingKey).Return(errors.New("s3 down"))
+
+	store.On("DeleteObject", ctx, aborted.StagingKey).Return(nil)
+	r.On("Transition", ctx, aborted.ID, model.UploadStatusPending, model.UploadStatusExpired).Return(gorm.ErrRecordNotFound)
+
+	swept, err := newTestUploadService(r, &MockArtifactRepo{}, store, now).Sweep(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 2, swept)
+	r.AssertExpectations(t)
+	store.AssertExpectations(t)
+}
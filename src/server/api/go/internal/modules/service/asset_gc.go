@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"go.uber.org/zap"
+)
+
+// assetPrefixes are the top-level key prefixes the API stores assets under,
+// each followed by the project ID: disks/{project}/..., assets/{project}/... and so on
+var assetPrefixes = []string{"disks/", "assets/", "parts/", "uploads/"}
+
+// assetGCDeleteBatch is how many orphaned objects are deleted per request
+const assetGCDeleteBatch = 1000
+
+type AssetGCService interface {
+	// Reconcile recomputes the reference count of every asset from the artifacts, messages and
+	// parts JSON that use it, repairs asset_references and deletes stored objects nothing uses
+	Reconcile(ctx context.Context, opts AssetGCOptions) (*AssetGCReport, error)
+}
+
+type AssetGCOptions struct {
+	// DryRun reports what would change without changing anything
+	DryRun bool
+	// GracePeriod protects references and objects touched more recently, as they may belong
+	// to writes that are still in flight
+	GracePeriod time.Duration
+}
+
+type AssetGCReport struct {
+	DryRun            bool  `json:"dry_run"`
+	Projects          int   `json:"projects"`
+	FailedProjects    int   `json:"failed_projects"`
+	ReferencesChecked int   `json:"references_checked"`
+	RefCountsFixed    int   `json:"ref_counts_fixed"`
+	ReferencesCreated int   `json:"references_created"`
+	ReferencesDeleted int   `json:"references_deleted"`
+	ObjectsScanned    int   `json:"objects_scanned"`
+	OrphanObjects     int   `json:"orphan_objects"`
+	OrphanBytes       int64 `json:"orphan_bytes"`
+	// Skipped counts drifted references and orphaned objects left alone because of the grace period
+	Skipped int `json:"skipped"`
+}
+
+type assetGCService struct {
+	r     repo.AssetGCRepo
+	store blob.BlobStore
+	log   *zap.Logger
+	now   func() time.Time
+}
+
+func NewAssetGCService(r repo.AssetGCRepo, store blob.BlobStore, log *zap.Logger) AssetGCService {
+	return &assetGCService{r: r, store: store, log: log, now: time.Now}
+}
+
+// assetGCRun is the state of one reconciliation
+type assetGCRun struct {
+	opts   AssetGCOptions
+	report *AssetGCReport
+	cutoff time.Time
+	// projects maps every known project to whether its references were reconciled
+	projects map[uuid.UUID]bool
+	// extraKeys are keys in use that LiveKeys does not return: assets whose reference is
+	// missing in a dry run, or that are stored away from the key of their reference
+	extraKeys map[uuid.UUID][]string
+	// doomed are the keys of deleted references; their objects are already counted
+	doomed map[string]struct{}
+}
+
+// assetUse is the true reference count of one asset
+type assetUse struct {
+	asset model.Asset
+	count int
+}
+
+func (s *assetGCService) Reconcile(ctx context.Context, opts AssetGCOptions) (*AssetGCReport, error) {
+	run := &assetGCRun{
+		opts:      opts,
+		report:    &AssetGCReport{DryRun: opts.DryRun},
+		cutoff:    s.now().Add(-opts.GracePeriod),
+		projects:  map[uuid.UUID]bool{},
+		extraKeys: map[uuid.UUID][]string{},
+		doomed:    map[string]struct{}{},
+	}
+
+	projectIDs, err := s.r.ListProjectIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	for _, projectID := range projectIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		run.report.Projects++
+		if err := s.reconcileProject(ctx, run, projectID); err != nil {
+			// Without the true counts of a project its objects cannot be judged either
+			s.log.Warn("asset gc: skip project", zap.String("project_id", projectID.String()), zap.Error(err))
+			run.report.FailedProjects++
+			run.projects[projectID] = false
+			continue
+		}
+		run.projects[projectID] = true
+	}
+
+	for _, prefix := range assetPrefixes {
+		if err := s.collectOrphans(ctx, run, prefix); err != nil {
+			return nil, fmt.Errorf("scan %s: %w", prefix, err)
+		}
+	}
+
+	return run.report, nil
+}
+
+// trueUses counts the artifacts, messages and message parts of a project that use each asset
+func (s *assetGCService) trueUses(ctx context.Context, projectID uuid.UUID) (map[string]*assetUse, error) {
+	uses := map[string]*assetUse{}
+	add := func(asset model.Asset, n int) {
+		if u, ok := uses[asset.SHA256]; ok {
+			u.count += n
+			return
+		}
+		uses[asset.SHA256] = &assetUse{asset: asset, count: n}
+	}
+
+	artifactUses, err := s.r.ArtifactAssetUses(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("count artifact assets: %w", err)
+	}
+	for _, u := range artifactUses {
+		add(u.AssetMeta.Data(), u.Uses)
+	}
+
+	partsUses, err := s.r.MessagePartsUses(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("count message parts: %w", err)
+	}
+	for _, u := range partsUses {
+		partsAsset := u.AssetMeta.Data()
+		add(partsAsset, u.Uses)
+
+		// Every message storing these parts holds a reference to each asset in them
+		parts := []model.Part{}
+		if err := s.store.DownloadJSON(ctx, partsAsset.S3Key, &parts); err != nil {
+			return nil, fmt.Errorf("download parts %s: %w", partsAsset.S3Key, err)
+		}
+		for _, part := range parts {
+			if part.Asset != nil && part.Asset.SHA256 != "" {
+				add(*part.Asset, u.Uses)
+			}
+		}
+	}
+	return uses, nil
+}
+
+func (s *assetGCService) reconcileProject(ctx context.Context, run *assetGCRun, projectID uuid.UUID) error {
+	uses, err := s.trueUses(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	refs, err := s.r.ListReferences(ctx, projectID)
+	if err != nil {
+		return fmt.Errorf("list asset references: %w", err)
+	}
+
+	for i := range refs {
+		ref := &refs[i]
+		run.report.ReferencesChecked++
+
+		want := 0
+		if u, ok := uses[ref.SHA256]; ok {
+			want = u.count
+			if u.asset.S3Key != ref.S3Key {
+				run.extraKeys[projectID] = append(run.extraKeys[projectID], u.asset.S3Key)
+			}
+			delete(uses, ref.SHA256)
+		}
+		if ref.RefCount == want {
+			continue
+		}
+
+		log := s.log.With(
+			zap.String("project_id", projectID.String()),
+			zap.String("sha256", ref.SHA256),
+			zap.String("s3_key", ref.S3Key),
+			zap.Int("ref_count", ref.RefCount),
+			zap.Int("true_count", want),
+			zap.Bool("dry_run", run.opts.DryRun),
+		)
+		if ref.LastReferencedAt.After(run.cutoff) {
+			log.Info("asset gc: skip recently referenced asset")
+			run.report.Skipped++
+			continue
+		}
+
+		if want == 0 {
+			log.Info("asset gc: delete unreferenced asset")
+			if !run.opts.DryRun {
+				deleted, err := s.r.DeleteReference(ctx, ref)
+				if err != nil {
+					return fmt.Errorf("delete asset reference: %w", err)
+				}
+				if !deleted {
+					run.report.Skipped++
+					continue
+				}
+				if err := s.store.DeleteObject(ctx, ref.S3Key); err != nil {
+					return fmt.Errorf("delete object: %w", err)
+				}
+			}
+			run.doomed[ref.S3Key] = struct{}{}
+			run.report.ReferencesDeleted++
+			run.report.OrphanObjects++
+			run.report.OrphanBytes += ref.AssetMeta.Data().SizeB
+			continue
+		}
+
+		log.Info("asset gc: fix reference count")
+		if !run.opts.DryRun {
+			fixed, err := s.r.SetRefCount(ctx, ref, want)
+			if err != nil {
+				return fmt.Errorf("fix reference count: %w", err)
+			}
+			if !fixed {
+				run.report.Skipped++
+				continue
+			}
+		}
+		run.report.RefCountsFixed++
+	}
+
+	// Assets in use without any reference row
+	for sha, u := range uses {
+		s.log.Info("asset gc: restore missing reference",
+			zap.String("project_id", projectID.String()),
+			zap.String("sha256", sha),
+			zap.String("s3_key", u.asset.S3Key),
+			zap.Int("true_count", u.count),
+			zap.Bool("dry_run", run.opts.DryRun),
+		)
+		run.extraKeys[projectID] = append(run.extraKeys[projectID], u.asset.S3Key)
+		if !run.opts.DryRun {
+			if _, err := s.r.CreateReference(ctx, projectID, u.asset, u.count); err != nil {
+				return fmt.Errorf("create asset reference: %w", err)
+			}
+		}
+		run.report.ReferencesCreated++
+	}
+	return nil
+}
+
+// collectOrphans deletes the objects under prefix that no project uses. Objects of projects that
+// no longer exist are orphans too; objects of projects that failed to reconcile are left alone.
+func (s *assetGCService) collectOrphans(ctx context.Context, run *assetGCRun, prefix string) error {
+	var (
+		current uuid.UUID
+		live    map[string]struct{}
+		pending []string
+	)
+	flush := func() error {
+		if len(pending) == 0 || run.opts.DryRun {
+			pending = pending[:0]
+			return nil
+		}
+		if err := s.store.DeleteObjects(ctx, pending); err != nil {
+			return fmt.Errorf("delete orphaned objects: %w", err)
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	err := s.store.ListObjects(ctx, prefix, func(obj blob.ObjectInfo) error {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(obj.Key, prefix), "/")
+		projectID, err := uuid.Parse(segment)
+		if err != nil {
+			// Not laid out by the API, so not ours to judge
+			return nil
+		}
+
+		reconciled, known := run.projects[projectID]
+		if known && !reconciled {
+			return nil
+		}
+		// Objects are listed in key order, so the keys of a project arrive together
+		if projectID != current || live == nil {
+			current = projectID
+			live = map[string]struct{}{}
+			if known {
+				if live, err = s.r.LiveKeys(ctx, projectID, run.cutoff); err != nil {
+					return fmt.Errorf("load live keys of project %s: %w", projectID, err)
+				}
+				for _, key := range run.extraKeys[projectID] {
+					live[key] = struct{}{}
+				}
+			}
+		}
+
+		run.report.ObjectsScanned++
+		if _, ok := run.doomed[obj.Key]; ok {
+			return nil
+		}
+		if _, ok := live[obj.Key]; ok {
+			return nil
+		}
+		if obj.LastModified.After(run.cutoff) {
+			run.report.Skipped++
+			return nil
+		}
+
+		s.log.Info("asset gc: delete orphaned object",
+			zap.String("s3_key", obj.Key),
+			zap.Int64("size_b", obj.Size),
+			zap.Bool("known_project", known),
+			zap.Bool("dry_run", run.opts.DryRun),
+		)
+		run.report.OrphanObjects++
+		run.report.OrphanBytes += obj.Size
+		pending = append(pending, obj.Key)
+		if len(pending) >= assetGCDeleteBatch {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+// MockAssetGCRepo is a mock implementation of AssetGCRepo
+type MockAssetGCRepo struct {
+	mock.Mock
+}
+
+func (m *MockAssetGCRepo) ListProjectIDs(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockAssetGCRepo) ArtifactAssetUses(ctx context.Context, projectID uuid.UUID) ([]repo.AssetUse, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]repo.AssetUse), args.Error(1)
+}
+
+func (m *MockAssetGCRepo) MessagePartsUses(ctx context.Context, projectID uuid.UUID) ([]repo.AssetUse, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]repo.AssetUse), args.Error(1)
+}
+
+func (m *MockAssetGCRepo) ListReferences(ctx context.Context, projectID uuid.UUID) ([]model.AssetReference, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]model.AssetReference), args.Error(1)
+}
+
+func (m *MockAssetGCRepo) CreateReference(ctx context.Context, projectID uuid.UUID, asset model.Asset, refCount int) (bool, error) {
+	args := m.Called(ctx, projectID, asset, refCount)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAssetGCRepo) SetRefCount(ctx context.Context, ref *model.AssetReference, refCount int) (bool, error) {
+	args := m.Called(ctx, ref.SHA256, refCount)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAssetGCRepo) DeleteReference(ctx context.Context, ref *model.AssetReference) (bool, error) {
+	args := m.Called(ctx, ref.SHA256)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAssetGCRepo) LiveKeys(ctx context.Context, projectID uuid.UUID, uploadsExpiredBefore time.Time) (map[string]struct{}, error) {
+	args := m.Called(ctx, projectID, uploadsExpiredBefore)
+	return args.Get(0).(map[string]struct{}), args.Error(1)
+}
+
+func testAssetUse(asset *model.Asset, uses int) repo.AssetUse {
+	return repo.AssetUse{SHA256: asset.SHA256, Uses: uses, AssetMeta: datatypes.NewJSONType(*asset)}
+}
+
+func testAssetRef(asset *model.Asset, refCount int, lastReferenced time.Time) model.AssetReference {
+	return model.AssetReference{
+		ID:               uuid.New(),
+		SHA256:           asset.SHA256,
+		S3Key:            asset.S3Key,
+		RefCount:         refCount,
+		AssetMeta:        datatypes.NewJSONType(*asset),
+		LastReferencedAt: lastReferenced,
+	}
+}
+
+// assetGCFixture is a project whose asset references drifted, with orphaned objects next to it
+type assetGCFixture struct {
+	store     *blob.SignedStore
+	projectID uuid.UUID
+	// shared is used by two artifacts but counted once
+	shared *model.Asset
+	// image is used by the parts of two messages but counted three times
+	image *model.Asset
+	// parts is the parts JSON of those messages, correctly counted
+	parts *model.Asset
+	// unreferenced has a reference but no use
+	unreferenced *model.Asset
+	// unrecorded is used by an artifact but has no reference
+	unrecorded *model.Asset
+	// orphan has neither a reference nor a use
+	orphan string
+	// gone belongs to a project that was deleted
+	gone string
+	// foreign is not laid out by the API
+	foreign string
+}
+
+func newAssetGCFixture(t *testing.T) *assetGCFixture {
+	ctx := context.Background()
+	f := &assetGCFixture{
+		store:     blob.NewMemoryStore(blob.NewURLSigner("http://api.test", []byte("secret"))),
+		projectID: uuid.New(),
+	}
+	upload := func(prefix, name, content string) *model.Asset {
+		asset, err := f.store.UploadBytes(ctx, prefix+f.projectID.String(), name, "text/plain", []byte(content), nil)
+		require.NoError(t, err)
+		return asset
+	}
+
+	f.shared = upload("disks/", "shared.txt", "shared")
+	f.image = upload("assets/", "image.png", "image")
+	f.unreferenced = upload("disks/", "old.txt", "old")
+	f.unrecorded = upload("disks/", "new.txt", "new")
+	f.orphan = upload("disks/", "orphan.txt", "orphan").S3Key
+	f.gone = "assets/" + uuid.NewString() + "/2025/01/01/gone.png"
+	f.foreign = "uploads/manual/backup.tar"
+	for _, key := range []string{f.gone, f.foreign} {
+		require.NoError(t, f.store.Write(key, nil, strings.NewReader(key)))
+	}
+
+	parts, err := f.store.UploadJSON(ctx, "parts/"+f.projectID.String(), []model.Part{
+		{Type: "text", Text: "look"},
+		{Type: "image", Asset: f.image},
+	}, nil)
+	require.NoError(t, err)
+	f.parts = parts
+	return f
+}
+
+func (f *assetGCFixture) keys(t *testing.T) []string {
+	var keys []string
+	for _, prefix := range assetPrefixes {
+		require.NoError(t, f.store.ListObjects(context.Background(), prefix, func(obj blob.ObjectInfo) error {
+			keys = append(keys, obj.Key)
+			return nil
+		}))
+	}
+	return keys
+}
+
+// setup mocks the reads of the fixture project; references were last taken at lastReferenced
+func (f *assetGCFixture) setup(r *MockAssetGCRepo, lastReferenced time.Time) {
+	r.On("ListProjectIDs", mock.Anything).Return([]uuid.UUID{f.projectID}, nil)
+	r.On("ArtifactAssetUses", mock.Anything, f.projectID).Return([]repo.AssetUse{
+		testAssetUse(f.shared, 2),
+		testAssetUse(f.unrecorded, 1),
+	}, nil)
+	r.On("MessagePartsUses", mock.Anything, f.projectID).Return([]repo.AssetUse{testAssetUse(f.parts, 2)}, nil)
+	r.On("ListReferences", mock.Anything, f.projectID).Return([]model.AssetReference{
+		testAssetRef(f.shared, 1, lastReferenced),
+		testAssetRef(f.image, 3, lastReferenced),
+		testAssetRef(f.parts, 2, lastReferenced),
+		testAssetRef(f.unreferenced, 1, lastReferenced),
+	}, nil)
+	r.On("LiveKeys", mock.Anything, f.projectID, mock.Anything).Return(map[string]struct{}{
+		f.shared.S3Key:       {},
+		f.image.S3Key:        {},
+		f.parts.S3Key:        {},
+		f.unreferenced.S3Key: {},
+		f.unrecorded.S3Key:   {},
+	}, nil)
+}
+
+func TestAssetGCService_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	longAgo := time.Now().Add(-72 * time.Hour)
+
+	t.Run("repairs references and deletes orphans", func(t *testing.T) {
+		f := newAssetGCFixture(t)
+		r := &MockAssetGCRepo{}
+		f.setup(r, longAgo)
+		r.On("SetRefCount", mock.Anything, f.shared.SHA256, 2).Return(true, nil)
+		r.On("SetRefCount", mock.Anything, f.image.SHA256, 2).Return(true, nil)
+		r.On("DeleteReference", mock.Anything, f.unreferenced.SHA256).Return(true, nil)
+		r.On("CreateReference", mock.Anything, f.projectID, *f.unrecorded, 1).Return(true, nil)
+
+		svc := NewAssetGCService(r, f.store, zap.NewNop()).(*assetGCService)
+		svc.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+
+		report, err := svc.Reconcile(ctx, AssetGCOptions{GracePeriod: 24 * time.Hour})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Projects)
+		assert.Equal(t, 4, report.ReferencesChecked)
+		assert.Equal(t, 2, report.RefCountsFixed)
+		assert.Equal(t, 1, report.ReferencesCreated)
+		assert.Equal(t, 1, report.ReferencesDeleted)
+		assert.Equal(t, 3, report.OrphanObjects, "unreferenced, orphan and gone")
+		r.AssertExpectations(t)
+
+		assert.ElementsMatch(t, []string{f.shared.S3Key, f.unrecorded.S3Key, f.image.S3Key, f.parts.S3Key, f.foreign}, f.keys(t))
+	})
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		f := newAssetGCFixture(t)
+		before := f.keys(t)
+		r := &MockAssetGCRepo{}
+		f.setup(r, longAgo)
+
+		svc := NewAssetGCService(r, f.store, zap.NewNop()).(*assetGCService)
+		svc.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+
+		report, err := svc.Reconcile(ctx, AssetGCOptions{DryRun: true, GracePeriod: 24 * time.Hour})
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 2, report.RefCountsFixed)
+		assert.Equal(t, 1, report.ReferencesCreated)
+		assert.Equal(t, 1, report.ReferencesDeleted)
+		assert.Equal(t, 3, report.OrphanObjects)
+		assert.Equal(t, before, f.keys(t))
+		r.AssertNotCalled(t, "SetRefCount", mock.Anything, mock.Anything, mock.Anything)
+		r.AssertNotCalled(t, "DeleteReference", mock.Anything, mock.Anything)
+		r.AssertNotCalled(t, "CreateReference", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("grace period protects recent references and objects", func(t *testing.T) {
+		f := newAssetGCFixture(t)
+		before := f.keys(t)
+		r := &MockAssetGCRepo{}
+		f.setup(r, time.Now())
+		r.On("CreateReference", mock.Anything, f.projectID, *f.unrecorded, 1).Return(true, nil)
+
+		report, err := NewAssetGCService(r, f.store, zap.NewNop()).Reconcile(ctx, AssetGCOptions{GracePeriod: time.Hour})
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.RefCountsFixed)
+		assert.Equal(t, 0, report.ReferencesDeleted)
+		assert.Equal(t, 0, report.OrphanObjects)
+		assert.Equal(t, 3+2, report.Skipped, "three drifted references and two orphaned objects")
+		assert.Equal(t, before, f.keys(t))
+	})
+
+	t.Run("references changed meanwhile are left alone", func(t *testing.T) {
+		f := newAssetGCFixture(t)
+		r := &MockAssetGCRepo{}
+		f.setup(r, longAgo)
+		r.On("SetRefCount", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+		r.On("DeleteReference", mock.Anything, f.unreferenced.SHA256).Return(false, nil)
+		r.On("CreateReference", mock.Anything, f.projectID, *f.unrecorded, 1).Return(false, nil)
+
+		svc := NewAssetGCService(r, f.store, zap.NewNop()).(*assetGCService)
+		svc.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+
+		report, err := svc.Reconcile(ctx, AssetGCOptions{GracePeriod: 24 * time.Hour})
+		require.NoError(t, err)
+		assert.Equal(t, 0, report.RefCountsFixed)
+		assert.Equal(t, 0, report.ReferencesDeleted)
+		assert.Equal(t, 3, report.Skipped)
+		assert.Contains(t, f.keys(t), f.unreferenced.S3Key)
+	})
+
+	t.Run("project with unreadable parts is skipped", func(t *testing.T) {
+		f := newAssetGCFixture(t)
+		require.NoError(t, f.store.DeleteObject(ctx, f.parts.S3Key))
+		before := f.keys(t)
+
+		r := &MockAssetGCRepo{}
+		r.On("ListProjectIDs", mock.Anything).Return([]uuid.UUID{f.projectID}, nil)
+		r.On("ArtifactAssetUses", mock.Anything, f.projectID).Return([]repo.AssetUse{}, nil)
+		r.On("MessagePartsUses", mock.Anything, f.projectID).Return([]repo.AssetUse{testAssetUse(f.parts, 1)}, nil)
+
+		svc := NewAssetGCService(r, f.store, zap.NewNop()).(*assetGCService)
+		svc.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+
+		report, err := svc.Reconcile(ctx, AssetGCOptions{GracePeriod: 24 * time.Hour})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.FailedProjects)
+		assert.Equal(t, 1, report.OrphanObjects, "only the object of the deleted project")
+		assert.NotContains(t, f.keys(t), f.gone)
+		assert.Len(t, f.keys(t), len(before)-1)
+		r.AssertNotCalled(t, "LiveKeys", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("listing failure", func(t *testing.T) {
+		r := &MockAssetGCRepo{}
+		r.On("ListProjectIDs", mock.Anything).Return([]uuid.UUID(nil), errors.New("connection refused"))
+
+		_, err := NewAssetGCService(r, nil, zap.NewNop()).Reconcile(ctx, AssetGCOptions{})
+		assert.Error(t, err)
+	})
+}
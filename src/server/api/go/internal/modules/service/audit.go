@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+)
+
+// auditPruneBatch is how many expired events one statement of Prune removes
+const auditPruneBatch = 1000
+
+var ErrInvalidAuditQuery = errors.New("invalid audit query")
+
+type AuditService interface {
+	Record(ctx context.Context, e *model.AuditEvent) error
+	List(ctx context.Context, in ListAuditEventsInput) (*ListAuditEventsOutput, error)
+	// Prune removes the events older than retention and returns how many it removed
+	Prune(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type auditService struct {
+	r   repo.AuditRepo
+	now func() time.Time
+}
+
+func NewAuditService(r repo.AuditRepo) AuditService {
+	return &auditService{r: r, now: time.Now}
+}
+
+func (s *auditService) Record(ctx context.Context, e *model.AuditEvent) error {
+	return s.r.Create(ctx, e)
+}
+
+type ListAuditEventsInput struct {
+	ProjectID uuid.UUID
+	Filter    repo.AuditFilter
+	Limit     int
+	Cursor    string
+	TimeDesc  bool
+}
+
+type ListAuditEventsOutput struct {
+	Items      []*model.AuditEvent `json:"items"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	HasMore    bool                `json:"has_more"`
+}
+
+func (s *auditService) List(ctx context.Context, in ListAuditEventsInput) (*ListAuditEventsOutput, error) {
+	if !in.Filter.From.IsZero() && !in.Filter.To.IsZero() && !in.Filter.From.Before(in.Filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidAuditQuery)
+	}
+
+	// Parse cursor (createdAt, id); an empty cursor indicates starting from the first event
+	var afterT time.Time
+	var afterID uuid.UUID
+	if in.Cursor != "" {
+		var err error
+		afterT, afterID, err = paging.DecodeCursor(in.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAuditQuery, err)
+		}
+	}
+
+	// Query limit+1 is used to determine has_more
+	events, err := s.r.ListWithCursor(ctx, in.ProjectID, in.Filter, afterT, afterID, in.Limit+1, in.TimeDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ListAuditEventsOutput{
+		Items:   events,
+		HasMore: false,
+	}
+	if len(events) > in.Limit {
+		out.HasMore = true
+		out.Items = events[:in.Limit]
+		last := out.Items[len(out.Items)-1]
+		out.NextCursor = paging.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return out, nil
+}
+
+func (s *auditService) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := s.now().Add(-retention)
+	var total int64
+	// Remove in batches so a large backlog does not hold one long transaction
+	for {
+		n, err := s.r.DeleteBefore(ctx, cutoff, auditPruneBatch)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < auditPruneBatch {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepo) Create(ctx context.Context, e *model.AuditEvent) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepo) ListWithCursor(ctx context.Context, projectID uuid.UUID, filter repo.AuditFilter, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.AuditEvent, error) {
+	args := m.Called(ctx, projectID, filter, afterCreatedAt, afterID, limit, timeDesc)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.AuditEvent), args.Error(1)
+}
+
+func (m *MockAuditRepo) DeleteBefore(ctx context.Context, t time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, t, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestAuditService_List(t *testing.T) {
+	projectID := uuid.New()
+	created := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	events := []*model.AuditEvent{
+		{ID: uuid.New(), ProjectID: projectID, CreatedAt: created},
+		{ID: uuid.New(), ProjectID: projectID, CreatedAt: created.Add(time.Second)},
+		{ID: uuid.New(), ProjectID: projectID, CreatedAt: created.Add(2 * time.Second)},
+	}
+
+	t.Run("first page", func(t *testing.T) {
+		r := &MockAuditRepo{}
+		filter := repo.AuditFilter{ResourceType: "session"}
+		r.On("ListWithCursor", mock.Anything, projectID, filter, time.Time{}, uuid.Nil, 3, false).Return(events, nil)
+		s := NewAuditService(r)
+
+		out, err := s.List(context.Background(), ListAuditEventsInput{ProjectID: projectID, Filter: filter, Limit: 2})
+
+		require.NoError(t, err)
+		assert.Len(t, out.Items, 2)
+		assert.True(t, out.HasMore)
+		assert.Equal(t, paging.EncodeCursor(events[1].CreatedAt, events[1].ID), out.NextCursor)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		r := &MockAuditRepo{}
+		cursor := paging.EncodeCursor(events[1].CreatedAt, events[1].ID)
+		r.On("ListWithCursor", mock.Anything, projectID, repo.AuditFilter{}, events[1].CreatedAt, events[1].ID, 3, false).Return(events[2:], nil)
+		s := NewAuditService(r)
+
+		out, err := s.List(context.Background(), ListAuditEventsInput{ProjectID: projectID, Limit: 2, Cursor: cursor})
+
+		require.NoError(t, err)
+		assert.Len(t, out.Items, 1)
+		assert.False(t, out.HasMore)
+		assert.Empty(t, out.NextCursor)
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		s := NewAuditService(&MockAuditRepo{})
+
+		_, err := s.List(context.Background(), ListAuditEventsInput{ProjectID: projectID, Limit: 2, Cursor: "%%%"})
+		assert.ErrorIs(t, err, ErrInvalidAuditQuery)
+
+		_, err = s.List(context.Background(), ListAuditEventsInput{
+			ProjectID: projectID,
+			Limit:     2,
+			Filter:    repo.AuditFilter{From: created, To: created},
+		})
+		assert.ErrorIs(t, err, ErrInvalidAuditQuery)
+	})
+}
+
+func TestAuditService_Prune(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-30 * 24 * time.Hour)
+
+	r := &MockAuditRepo{}
+	r.On("DeleteBefore", mock.Anything, cutoff, auditPruneBatch).Return(int64(auditPruneBatch), nil).Twice()
+	r.On("DeleteBefore", mock.Anything, cutoff, auditPruneBatch).Return(int64(7), nil).Once()
+	s := &auditService{r: r, now: func() time.Time { return now }}
+
+	removed, err := s.Prune(context.Background(), 30*24*time.Hour)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2*auditPruneBatch+7), removed)
+	r.AssertExpectations(t)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/audit"
 )
 
 type BlockService interface {
@@ -16,18 +17,18 @@ type BlockService interface {
 	// Delete - unified method
 	Delete(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID) error
 
-	// Properties - unified methods
-	GetBlockProperties(ctx context.Context, blockID uuid.UUID) (*model.Block, error)
+	// Properties - unified methods, scoped to the space of the block
+	GetBlockProperties(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID) (*model.Block, error)
 	UpdateBlockProperties(ctx context.Context, b *model.Block) error
 
 	// List - unified method with optional filters
 	List(ctx context.Context, spaceID uuid.UUID, blockType string, parentID *uuid.UUID) ([]model.Block, error)
 
-	// Move - unified method, handles special logic for folder path
-	Move(ctx context.Context, blockID uuid.UUID, newParentID *uuid.UUID, targetSort *int64) error
+	// Move - unified method, handles special logic for folder path. The new parent must be in the same space.
+	Move(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID, newParentID *uuid.UUID, targetSort *int64) error
 
 	// Sort - unified method
-	UpdateSort(ctx context.Context, blockID uuid.UUID, sort int64) error
+	UpdateSort(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID, sort int64) error
 }
 
 type blockService struct{ r repo.BlockRepo }
@@ -43,7 +44,7 @@ func (s *blockService) validateAndPrepareCreate(ctx context.Context, b *model.Bl
 	var parent *model.Block
 	if b.ParentID != nil {
 		var err error
-		parent, err = s.r.Get(ctx, *b.ParentID)
+		parent, err = s.r.Get(ctx, b.SpaceID, *b.ParentID)
 		if err != nil {
 			return nil, err
 		}
@@ -96,11 +97,15 @@ func (s *blockService) Create(ctx context.Context, b *model.Block) error {
 		return err
 	}
 
-	return s.r.Create(ctx, b)
+	if err := s.r.Create(ctx, b); err != nil {
+		return err
+	}
+	audit.After(ctx, b)
+	return nil
 }
 
 // isDescendant checks if candidateID is a descendant of ancestorID in the tree
-func (s *blockService) isDescendant(ctx context.Context, ancestorID uuid.UUID, candidateID uuid.UUID) (bool, error) {
+func (s *blockService) isDescendant(ctx context.Context, spaceID uuid.UUID, ancestorID uuid.UUID, candidateID uuid.UUID) (bool, error) {
 	// Start from candidateID and traverse up the parent chain
 	currentID := candidateID
 
@@ -109,7 +114,7 @@ func (s *blockService) isDescendant(ctx context.Context, ancestorID uuid.UUID, c
 	depth := 0
 
 	for depth < maxDepth {
-		block, err := s.r.Get(ctx, currentID)
+		block, err := s.r.Get(ctx, spaceID, currentID)
 		if err != nil {
 			return false, err
 		}
@@ -134,12 +139,12 @@ func (s *blockService) isDescendant(ctx context.Context, ancestorID uuid.UUID, c
 }
 
 // validateAndPrepareMove validates a block move and prepares the new parent
-func (s *blockService) validateAndPrepareMove(ctx context.Context, blockID uuid.UUID, newParentID *uuid.UUID) (*model.Block, *model.Block, error) {
+func (s *blockService) validateAndPrepareMove(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID, newParentID *uuid.UUID) (*model.Block, *model.Block, error) {
 	if len(blockID) == 0 {
 		return nil, nil, errors.New("block id is empty")
 	}
 
-	block, err := s.r.Get(ctx, blockID)
+	block, err := s.r.Get(ctx, spaceID, blockID)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -151,7 +156,7 @@ func (s *blockService) validateAndPrepareMove(ctx context.Context, blockID uuid.
 		}
 
 		// Check for circular reference: newParentID cannot be a descendant of blockID
-		isDesc, err := s.isDescendant(ctx, blockID, *newParentID)
+		isDesc, err := s.isDescendant(ctx, spaceID, blockID, *newParentID)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -159,7 +164,7 @@ func (s *blockService) validateAndPrepareMove(ctx context.Context, blockID uuid.
 			return nil, nil, errors.New("new parent cannot be a descendant of the block (would create circular reference)")
 		}
 
-		parent, err = s.r.Get(ctx, *newParentID)
+		parent, err = s.r.Get(ctx, spaceID, *newParentID)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -184,11 +189,11 @@ func (s *blockService) Delete(ctx context.Context, spaceID uuid.UUID, blockID uu
 }
 
 // GetBlockProperties - unified get properties method
-func (s *blockService) GetBlockProperties(ctx context.Context, blockID uuid.UUID) (*model.Block, error) {
+func (s *blockService) GetBlockProperties(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID) (*model.Block, error) {
 	if len(blockID) == 0 {
 		return nil, errors.New("block id is empty")
 	}
-	return s.r.Get(ctx, blockID)
+	return s.r.Get(ctx, spaceID, blockID)
 }
 
 // UpdateBlockProperties - unified update properties method
@@ -196,7 +201,11 @@ func (s *blockService) UpdateBlockProperties(ctx context.Context, b *model.Block
 	if len(b.ID) == 0 {
 		return errors.New("block id is empty")
 	}
-	return s.r.Update(ctx, b)
+	if err := s.r.Update(ctx, b); err != nil {
+		return err
+	}
+	audit.After(ctx, b)
+	return nil
 }
 
 // List - unified list method with optional type and parent_id filters
@@ -208,8 +217,8 @@ func (s *blockService) List(ctx context.Context, spaceID uuid.UUID, blockType st
 }
 
 // Move - unified move method for all block types
-func (s *blockService) Move(ctx context.Context, blockID uuid.UUID, newParentID *uuid.UUID, targetSort *int64) error {
-	block, parent, err := s.validateAndPrepareMove(ctx, blockID, newParentID)
+func (s *blockService) Move(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID, newParentID *uuid.UUID, targetSort *int64) error {
+	block, parent, err := s.validateAndPrepareMove(ctx, spaceID, blockID, newParentID)
 	if err != nil {
 		return err
 	}
@@ -232,15 +241,25 @@ func (s *blockService) Move(ctx context.Context, blockID uuid.UUID, newParentID
 	}
 
 	if targetSort == nil {
-		return s.r.MoveToParentAppend(ctx, blockID, newParentID)
+		err = s.r.MoveToParentAppend(ctx, spaceID, blockID, newParentID)
+	} else {
+		err = s.r.MoveToParentAtSort(ctx, spaceID, blockID, newParentID, *targetSort)
 	}
-	return s.r.MoveToParentAtSort(ctx, blockID, newParentID, *targetSort)
+	if err != nil {
+		return err
+	}
+	audit.After(ctx, map[string]any{"id": blockID, "parent_id": newParentID, "sort": targetSort})
+	return nil
 }
 
 // UpdateSort - unified sort method for all block types
-func (s *blockService) UpdateSort(ctx context.Context, blockID uuid.UUID, sort int64) error {
+func (s *blockService) UpdateSort(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID, sort int64) error {
 	if len(blockID) == 0 {
 		return errors.New("block id is empty")
 	}
-	return s.r.ReorderWithinGroup(ctx, blockID, sort)
+	if err := s.r.ReorderWithinGroup(ctx, spaceID, blockID, sort); err != nil {
+		return err
+	}
+	audit.After(ctx, map[string]any{"id": blockID, "sort": sort})
+	return nil
 }
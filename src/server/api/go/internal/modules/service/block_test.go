@@ -21,8 +21,8 @@ func (m *MockBlockRepo) Create(ctx context.Context, b *model.Block) error {
 	return args.Error(0)
 }
 
-func (m *MockBlockRepo) Get(ctx context.Context, id uuid.UUID) (*model.Block, error) {
-	args := m.Called(ctx, id)
+func (m *MockBlockRepo) Get(ctx context.Context, spaceID uuid.UUID, id uuid.UUID) (*model.Block, error) {
+	args := m.Called(ctx, spaceID, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -44,18 +44,18 @@ func (m *MockBlockRepo) NextSort(ctx context.Context, spaceID uuid.UUID, parentI
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockBlockRepo) MoveToParentAppend(ctx context.Context, blockID uuid.UUID, newParentID *uuid.UUID) error {
-	args := m.Called(ctx, blockID, newParentID)
+func (m *MockBlockRepo) MoveToParentAppend(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID, newParentID *uuid.UUID) error {
+	args := m.Called(ctx, spaceID, blockID, newParentID)
 	return args.Error(0)
 }
 
-func (m *MockBlockRepo) MoveToParentAtSort(ctx context.Context, blockID uuid.UUID, newParentID *uuid.UUID, sort int64) error {
-	args := m.Called(ctx, blockID, newParentID, sort)
+func (m *MockBlockRepo) MoveToParentAtSort(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID, newParentID *uuid.UUID, sort int64) error {
+	args := m.Called(ctx, spaceID, blockID, newParentID, sort)
 	return args.Error(0)
 }
 
-func (m *MockBlockRepo) ReorderWithinGroup(ctx context.Context, blockID uuid.UUID, sort int64) error {
-	args := m.Called(ctx, blockID, sort)
+func (m *MockBlockRepo) ReorderWithinGroup(ctx context.Context, spaceID uuid.UUID, blockID uuid.UUID, sort int64) error {
+	args := m.Called(ctx, spaceID, blockID, sort)
 	return args.Error(0)
 }
 
@@ -107,7 +107,7 @@ func TestBlockService_Create_Page(t *testing.T) {
 					ID:   parentID,
 					Type: model.BlockTypeFolder,
 				}
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 				repo.On("NextSort", ctx, spaceID, &parentID).Return(int64(2), nil)
 				repo.On("Create", ctx, mock.MatchedBy(func(b *model.Block) bool {
 					return b.Type == model.BlockTypePage && b.Sort == 2
@@ -128,7 +128,7 @@ func TestBlockService_Create_Page(t *testing.T) {
 					ID:   parentID,
 					Type: model.BlockTypePage, // pages cannot have page children
 				}
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 			},
 			wantErr: true,
 			errMsg:  "cannot be a child of",
@@ -146,7 +146,7 @@ func TestBlockService_Create_Page(t *testing.T) {
 					ID:   parentID,
 					Type: model.BlockTypeText, // text cannot have children
 				}
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 			},
 			wantErr: true,
 			errMsg:  "parent cannot have children",
@@ -262,7 +262,7 @@ func TestBlockService_Create_Text(t *testing.T) {
 					ID:   parentID,
 					Type: model.BlockTypePage,
 				}
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 				repo.On("NextSort", ctx, spaceID, &parentID).Return(int64(1), nil)
 				repo.On("Create", ctx, mock.MatchedBy(func(b *model.Block) bool {
 					return b.Type == "text" && b.Sort == 1
@@ -294,7 +294,7 @@ func TestBlockService_Create_Text(t *testing.T) {
 					ID:   parentID,
 					Type: "image", // Assume image type cannot have children
 				}
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 			},
 			wantErr: true,
 			errMsg:  "parent cannot have children",
@@ -312,7 +312,7 @@ func TestBlockService_Create_Text(t *testing.T) {
 					ID:   parentID,
 					Type: model.BlockTypeFolder,
 				}
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 			},
 			wantErr: true,
 			errMsg:  "cannot be a child of",
@@ -330,7 +330,7 @@ func TestBlockService_Create_Text(t *testing.T) {
 					ID:   parentID,
 					Type: model.BlockTypeText, // text cannot have children
 				}
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 			},
 			wantErr: true,
 			errMsg:  "parent cannot have children",
@@ -348,7 +348,7 @@ func TestBlockService_Create_Text(t *testing.T) {
 					ID:   parentID,
 					Type: model.BlockTypeText, // text cannot have children
 				}
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 			},
 			wantErr: true,
 			errMsg:  "parent cannot have children",
@@ -420,7 +420,7 @@ func TestBlockService_Create_Folder(t *testing.T) {
 					Type: model.BlockTypeFolder,
 				}
 				parentBlock.SetFolderPath("RootFolder")
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 				repo.On("NextSort", ctx, spaceID, &parentID).Return(int64(2), nil)
 				repo.On("Create", ctx, mock.MatchedBy(func(b *model.Block) bool {
 					return b.Type == model.BlockTypeFolder && b.Sort == 2 && b.GetFolderPath() == "RootFolder/Subfolder"
@@ -443,7 +443,7 @@ func TestBlockService_Create_Folder(t *testing.T) {
 					Type: model.BlockTypeFolder,
 				}
 				parentBlock.SetFolderPath("Folder1/Folder2/Folder3")
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 				repo.On("NextSort", ctx, spaceID, &parentID).Return(int64(1), nil)
 				repo.On("Create", ctx, mock.MatchedBy(func(b *model.Block) bool {
 					return b.Type == model.BlockTypeFolder && b.GetFolderPath() == "Folder1/Folder2/Folder3/DeepFolder"
@@ -465,7 +465,7 @@ func TestBlockService_Create_Folder(t *testing.T) {
 					ID:   parentID,
 					Type: model.BlockTypePage, // pages cannot be folder parents
 				}
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 			},
 			wantErr: true,
 			errMsg:  "cannot be a child of",
@@ -483,7 +483,7 @@ func TestBlockService_Create_Folder(t *testing.T) {
 					ID:   parentID,
 					Type: model.BlockTypeText, // text cannot have children
 				}
-				repo.On("Get", ctx, parentID).Return(parentBlock, nil)
+				repo.On("Get", ctx, spaceID, parentID).Return(parentBlock, nil)
 			},
 			wantErr: true,
 			errMsg:  "parent cannot have children",
@@ -518,6 +518,7 @@ func TestBlockService_Create_Folder(t *testing.T) {
 
 func TestBlockService_Move_Folder(t *testing.T) {
 	ctx := context.Background()
+	spaceID := uuid.New()
 	folderID := uuid.New()
 	newParentID := uuid.New()
 
@@ -543,11 +544,11 @@ func TestBlockService_Move_Folder(t *testing.T) {
 					Title: "MovedFolder",
 				}
 				folder.SetFolderPath("OldParent/MovedFolder")
-				repo.On("Get", ctx, folderID).Return(folder, nil)
+				repo.On("Get", ctx, spaceID, folderID).Return(folder, nil)
 				repo.On("Update", ctx, mock.MatchedBy(func(b *model.Block) bool {
 					return b.GetFolderPath() == "MovedFolder"
 				})).Return(nil)
-				repo.On("MoveToParentAppend", ctx, folderID, (*uuid.UUID)(nil)).Return(nil)
+				repo.On("MoveToParentAppend", ctx, spaceID, folderID, (*uuid.UUID)(nil)).Return(nil)
 			},
 			wantErr:      false,
 			expectedPath: "MovedFolder",
@@ -568,12 +569,12 @@ func TestBlockService_Move_Folder(t *testing.T) {
 					Type: model.BlockTypeFolder,
 				}
 				newParent.SetFolderPath("NewParent")
-				repo.On("Get", ctx, folderID).Return(folder, nil)
-				repo.On("Get", ctx, newParentID).Return(newParent, nil)
+				repo.On("Get", ctx, spaceID, folderID).Return(folder, nil)
+				repo.On("Get", ctx, spaceID, newParentID).Return(newParent, nil)
 				repo.On("Update", ctx, mock.MatchedBy(func(b *model.Block) bool {
 					return b.GetFolderPath() == "NewParent/MovedFolder"
 				})).Return(nil)
-				repo.On("MoveToParentAppend", ctx, folderID, &newParentID).Return(nil)
+				repo.On("MoveToParentAppend", ctx, spaceID, folderID, &newParentID).Return(nil)
 			},
 			wantErr:      false,
 			expectedPath: "NewParent/MovedFolder",
@@ -593,8 +594,8 @@ func TestBlockService_Move_Folder(t *testing.T) {
 					ID:   newParentID,
 					Type: model.BlockTypePage, // pages cannot be folder parents
 				}
-				repo.On("Get", ctx, folderID).Return(folder, nil)
-				repo.On("Get", ctx, newParentID).Return(invalidParent, nil)
+				repo.On("Get", ctx, spaceID, folderID).Return(folder, nil)
+				repo.On("Get", ctx, spaceID, newParentID).Return(invalidParent, nil)
 			},
 			wantErr: true,
 			errMsg:  "cannot be a child of",
@@ -607,7 +608,7 @@ func TestBlockService_Move_Folder(t *testing.T) {
 			tt.setup(repo)
 
 			service := NewBlockService(repo)
-			err := service.Move(ctx, tt.folderID, tt.newParentID, tt.targetSort)
+			err := service.Move(ctx, spaceID, tt.folderID, tt.newParentID, tt.targetSort)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -739,7 +740,7 @@ func TestBlockService_ComprehensiveNesting(t *testing.T) {
 			ID:   pageID,
 			Type: model.BlockTypePage,
 		}
-		repo.On("Get", ctx, pageID).Return(pageBlock, nil)
+		repo.On("Get", ctx, spaceID, pageID).Return(pageBlock, nil)
 
 		service := NewBlockService(repo)
 		err := service.Create(ctx, folderUnderPage)
@@ -801,7 +802,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:   "FolderA",
 					SpaceID: spaceID,
 				}
-				repo.On("Get", ctx, folderAID).Return(folderA, nil)
+				repo.On("Get", ctx, spaceID, folderAID).Return(folderA, nil)
 
 				// FolderB is a direct child of FolderA
 				folderB := &model.Block{
@@ -810,7 +811,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:    "FolderB",
 					ParentID: &folderAID, // FolderB is child of FolderA
 				}
-				repo.On("Get", ctx, folderBID).Return(folderB, nil)
+				repo.On("Get", ctx, spaceID, folderBID).Return(folderB, nil)
 			},
 			wantErr: true,
 			errMsg:  "new parent cannot be a descendant of the block",
@@ -828,7 +829,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:   "FolderA",
 					SpaceID: spaceID,
 				}
-				repo.On("Get", ctx, folderAID).Return(folderA, nil)
+				repo.On("Get", ctx, spaceID, folderAID).Return(folderA, nil)
 
 				// FolderC is an indirect descendant (grandchild)
 				folderC := &model.Block{
@@ -837,7 +838,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:    "FolderC",
 					ParentID: &folderBID, // FolderC is child of FolderB
 				}
-				repo.On("Get", ctx, folderCID).Return(folderC, nil)
+				repo.On("Get", ctx, spaceID, folderCID).Return(folderC, nil)
 
 				// FolderB is the intermediate node
 				folderB := &model.Block{
@@ -846,7 +847,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:    "FolderB",
 					ParentID: &folderAID, // FolderB is child of FolderA
 				}
-				repo.On("Get", ctx, folderBID).Return(folderB, nil)
+				repo.On("Get", ctx, spaceID, folderBID).Return(folderB, nil)
 			},
 			wantErr: true,
 			errMsg:  "new parent cannot be a descendant of the block",
@@ -864,7 +865,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:   "FolderB",
 					SpaceID: spaceID,
 				}
-				repo.On("Get", ctx, folderBID).Return(folderB, nil)
+				repo.On("Get", ctx, spaceID, folderBID).Return(folderB, nil)
 
 				// Unrelated folder is not a descendant
 				unrelated := &model.Block{
@@ -874,11 +875,11 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					SpaceID: spaceID,
 					// No parent, or parent is different
 				}
-				repo.On("Get", ctx, unrelatedID).Return(unrelated, nil)
+				repo.On("Get", ctx, spaceID, unrelatedID).Return(unrelated, nil)
 				repo.On("Update", ctx, mock.MatchedBy(func(b *model.Block) bool {
 					return b.ID == folderBID
 				})).Return(nil)
-				repo.On("MoveToParentAppend", ctx, folderBID, &unrelatedID).Return(nil)
+				repo.On("MoveToParentAppend", ctx, spaceID, folderBID, &unrelatedID).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -895,11 +896,11 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:   "FolderB",
 					SpaceID: spaceID,
 				}
-				repo.On("Get", ctx, folderBID).Return(folderB, nil)
+				repo.On("Get", ctx, spaceID, folderBID).Return(folderB, nil)
 				repo.On("Update", ctx, mock.MatchedBy(func(b *model.Block) bool {
 					return b.ID == folderBID
 				})).Return(nil)
-				repo.On("MoveToParentAppend", ctx, folderBID, (*uuid.UUID)(nil)).Return(nil)
+				repo.On("MoveToParentAppend", ctx, spaceID, folderBID, (*uuid.UUID)(nil)).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -915,7 +916,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:   "FolderA",
 					SpaceID: spaceID,
 				}
-				repo.On("Get", ctx, folderAID).Return(folderA, nil)
+				repo.On("Get", ctx, spaceID, folderAID).Return(folderA, nil)
 			},
 			wantErr: true,
 			errMsg:  "new parent cannot be the same as the block",
@@ -933,7 +934,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:   "FolderA",
 					SpaceID: spaceID,
 				}
-				repo.On("Get", ctx, folderAID).Return(folderA, nil)
+				repo.On("Get", ctx, spaceID, folderAID).Return(folderA, nil)
 
 				// FolderC is deep in the chain
 				folderC := &model.Block{
@@ -942,7 +943,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:    "FolderC",
 					ParentID: &folderBID,
 				}
-				repo.On("Get", ctx, folderCID).Return(folderC, nil)
+				repo.On("Get", ctx, spaceID, folderCID).Return(folderC, nil)
 
 				// FolderB is the intermediate
 				folderB := &model.Block{
@@ -951,7 +952,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:    "FolderB",
 					ParentID: &folderAID,
 				}
-				repo.On("Get", ctx, folderBID).Return(folderB, nil)
+				repo.On("Get", ctx, spaceID, folderBID).Return(folderB, nil)
 			},
 			wantErr: true,
 			errMsg:  "new parent cannot be a descendant of the block",
@@ -969,7 +970,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:   "FolderB",
 					SpaceID: spaceID,
 				}
-				repo.On("Get", ctx, folderBID).Return(folderB, nil)
+				repo.On("Get", ctx, spaceID, folderBID).Return(folderB, nil)
 
 				// FolderC is a sibling (same parent FolderA, but not a descendant of FolderB)
 				folderC := &model.Block{
@@ -978,17 +979,17 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 					Title:    "FolderC",
 					ParentID: &unrelatedID, // Different parent, so not a descendant
 				}
-				repo.On("Get", ctx, folderCID).Return(folderC, nil)
+				repo.On("Get", ctx, spaceID, folderCID).Return(folderC, nil)
 				// isDescendant will traverse up the parent chain, need to mock unrelatedID
 				unrelated := &model.Block{
 					ID:       unrelatedID,
 					ParentID: nil, // Root level
 				}
-				repo.On("Get", ctx, unrelatedID).Return(unrelated, nil)
+				repo.On("Get", ctx, spaceID, unrelatedID).Return(unrelated, nil)
 				repo.On("Update", ctx, mock.MatchedBy(func(b *model.Block) bool {
 					return b.ID == folderBID
 				})).Return(nil)
-				repo.On("MoveToParentAppend", ctx, folderBID, &folderCID).Return(nil)
+				repo.On("MoveToParentAppend", ctx, spaceID, folderBID, &folderCID).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -1000,7 +1001,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 			tt.setup(repo)
 
 			service := NewBlockService(repo)
-			err := service.Move(ctx, tt.blockID, tt.newParentID, nil)
+			err := service.Move(ctx, spaceID, tt.blockID, tt.newParentID, nil)
 
 			if tt.wantErr {
 				assert.Error(t, err, "Expected error for: %s", tt.description)
@@ -1018,6 +1019,7 @@ func TestBlockService_Move_CircularReference(t *testing.T) {
 
 func TestBlockService_isDescendant(t *testing.T) {
 	ctx := context.Background()
+	spaceID := uuid.New()
 
 	// Create a test tree: Root -> A -> B -> C
 	blockAID := uuid.New()
@@ -1044,13 +1046,13 @@ func TestBlockService_isDescendant(t *testing.T) {
 					ID:       blockBID,
 					ParentID: &blockAID,
 				}
-				repo.On("Get", ctx, blockBID).Return(blockB, nil)
+				repo.On("Get", ctx, spaceID, blockBID).Return(blockB, nil)
 				// isDescendant will traverse up to parent, need to mock blockA
 				blockA := &model.Block{
 					ID:       blockAID,
 					ParentID: nil,
 				}
-				repo.On("Get", ctx, blockAID).Return(blockA, nil)
+				repo.On("Get", ctx, spaceID, blockAID).Return(blockA, nil)
 			},
 			expected: true,
 			wantErr:  false,
@@ -1065,18 +1067,18 @@ func TestBlockService_isDescendant(t *testing.T) {
 					ID:       blockCID,
 					ParentID: &blockBID,
 				}
-				repo.On("Get", ctx, blockCID).Return(blockC, nil)
+				repo.On("Get", ctx, spaceID, blockCID).Return(blockC, nil)
 				blockB := &model.Block{
 					ID:       blockBID,
 					ParentID: &blockAID,
 				}
-				repo.On("Get", ctx, blockBID).Return(blockB, nil)
+				repo.On("Get", ctx, spaceID, blockBID).Return(blockB, nil)
 				// isDescendant will traverse up to blockA
 				blockA := &model.Block{
 					ID:       blockAID,
 					ParentID: nil,
 				}
-				repo.On("Get", ctx, blockAID).Return(blockA, nil)
+				repo.On("Get", ctx, spaceID, blockAID).Return(blockA, nil)
 			},
 			expected: true,
 			wantErr:  false,
@@ -1091,7 +1093,7 @@ func TestBlockService_isDescendant(t *testing.T) {
 					ID:       unrelatedID,
 					ParentID: nil, // Root level
 				}
-				repo.On("Get", ctx, unrelatedID).Return(unrelated, nil)
+				repo.On("Get", ctx, spaceID, unrelatedID).Return(unrelated, nil)
 			},
 			expected: false,
 			wantErr:  false,
@@ -1106,7 +1108,7 @@ func TestBlockService_isDescendant(t *testing.T) {
 					ID:       blockAID,
 					ParentID: nil,
 				}
-				repo.On("Get", ctx, blockAID).Return(blockA, nil)
+				repo.On("Get", ctx, spaceID, blockAID).Return(blockA, nil)
 			},
 			expected: true, // Returns true because block.ID == ancestorID
 			wantErr:  false,
@@ -1121,17 +1123,17 @@ func TestBlockService_isDescendant(t *testing.T) {
 					ID:       blockCID,
 					ParentID: &blockBID,
 				}
-				repo.On("Get", ctx, blockCID).Return(blockC, nil)
+				repo.On("Get", ctx, spaceID, blockCID).Return(blockC, nil)
 				blockB := &model.Block{
 					ID:       blockBID,
 					ParentID: &unrelatedID, // Different parent, not A
 				}
-				repo.On("Get", ctx, blockBID).Return(blockB, nil)
+				repo.On("Get", ctx, spaceID, blockBID).Return(blockB, nil)
 				unrelated := &model.Block{
 					ID:       unrelatedID,
 					ParentID: nil,
 				}
-				repo.On("Get", ctx, unrelatedID).Return(unrelated, nil)
+				repo.On("Get", ctx, spaceID, unrelatedID).Return(unrelated, nil)
 			},
 			expected: false,
 			wantErr:  false,
@@ -1144,7 +1146,7 @@ func TestBlockService_isDescendant(t *testing.T) {
 			tt.setup(repo)
 
 			service := NewBlockService(repo)
-			result, err := service.(*blockService).isDescendant(ctx, tt.ancestorID, tt.candidateID)
+			result, err := service.(*blockService).isDescendant(ctx, spaceID, tt.ancestorID, tt.candidateID)
 
 			if tt.wantErr {
 				assert.Error(t, err)
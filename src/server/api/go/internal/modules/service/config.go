@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/audit"
+	"github.com/memodb-io/Acontext/internal/pkg/configs"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ConfigTarget names the project, space or session whose configs are read or written. For a
+// project ID is the project ID.
+type ConfigTarget struct {
+	Scope     configs.Scope
+	ProjectID uuid.UUID
+	ID        uuid.UUID
+}
+
+// ConfigsUpdate is the configs a target was left with, and the unknown keys they hold
+type ConfigsUpdate struct {
+	Scope    configs.Scope     `json:"scope"`
+	ID       uuid.UUID         `json:"id"`
+	Configs  map[string]any    `json:"configs"`
+	Warnings []configs.Problem `json:"warnings,omitempty"`
+}
+
+// ConfigSchema describes the config keys that are checked when configs are written
+type ConfigSchema struct {
+	Keys []*configs.Key `json:"keys"`
+	// UnknownKeys is what happens to keys that are not registered: "warn" or "reject"
+	UnknownKeys string `json:"unknown_keys" example:"warn"`
+	// CustomPrefix starts the keys of free-form client data, which are never checked
+	CustomPrefix string `json:"custom_prefix" example:"x_"`
+}
+
+type ConfigService interface {
+	// Schema returns the registered config keys
+	Schema() *ConfigSchema
+	// PatchConfigs applies a JSON merge patch to the configs of a target. Only the keys the
+	// patch sets are validated, so configs stored before a key was registered do not block it.
+	PatchConfigs(ctx context.Context, t ConfigTarget, patch map[string]any) (*ConfigsUpdate, error)
+	// EffectiveConfigs resolves the configs that apply to a target from its project, and for a
+	// session from the space it is connected to
+	EffectiveConfigs(ctx context.Context, t ConfigTarget) (*configs.Effective, error)
+}
+
+type configService struct {
+	r           repo.ConfigRepo
+	unknownKeys string
+	log         *zap.Logger
+}
+
+func NewConfigService(r repo.ConfigRepo, cfg *config.Config, log *zap.Logger) ConfigService {
+	return &configService{
+		r:           r,
+		unknownKeys: cfg.Configs.UnknownKeys,
+		log:         log,
+	}
+}
+
+func (s *configService) Schema() *ConfigSchema {
+	unknownKeys := configs.UnknownKeysWarn
+	if s.unknownKeys == configs.UnknownKeysReject {
+		unknownKeys = configs.UnknownKeysReject
+	}
+	return &ConfigSchema{Keys: configs.Keys(), UnknownKeys: unknownKeys, CustomPrefix: configs.CustomPrefix}
+}
+
+func (s *configService) PatchConfigs(ctx context.Context, t ConfigTarget, patch map[string]any) (*ConfigsUpdate, error) {
+	var warnings []configs.Problem
+	next, err := s.r.UpdateConfigs(ctx, t.Scope, t.ProjectID, t.ID, func(current datatypes.JSONMap) (datatypes.JSONMap, error) {
+		merged := configs.MergePatch(current, patch)
+
+		// Removed keys need no checks; reserved keys are passed on so they are refused
+		changed := make(map[string]any, len(patch))
+		for k := range patch {
+			if v, ok := merged[k]; ok || configs.IsReserved(k) {
+				changed[k] = v
+			}
+		}
+		var err error
+		if warnings, err = configs.ValidateInput(t.Scope, changed, s.unknownKeys); err != nil {
+			return nil, err
+		}
+		return datatypes.JSONMap(merged), nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, configs.ErrInvalid) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("patch %s configs: %w", t.Scope, err)
+	}
+
+	out := &ConfigsUpdate{Scope: t.Scope, ID: t.ID, Configs: next, Warnings: warnings}
+	if len(warnings) > 0 {
+		s.log.Warn("configs hold unknown keys", zap.String("scope", string(t.Scope)), zap.String("id", t.ID.String()), zap.Any("warnings", warnings))
+	}
+	audit.After(ctx, out)
+	return out, nil
+}
+
+func (s *configService) EffectiveConfigs(ctx context.Context, t ConfigTarget) (*configs.Effective, error) {
+	layers, err := s.r.Layers(ctx, t.Scope, t.ProjectID, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	return configs.Resolve(layers...), nil
+}
+
+// validateConfigs checks configs sent by a client, which replace those of a target, and logs
+// the unknown keys they hold
+func validateConfigs(log *zap.Logger, scope configs.Scope, m map[string]any, unknownKeys string) error {
+	warnings, err := configs.ValidateInput(scope, m, unknownKeys)
+	if err != nil {
+		return err
+	}
+	if len(warnings) > 0 {
+		log.Warn("configs hold unknown keys", zap.String("scope", string(scope)), zap.Any("warnings", warnings))
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/pkg/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// MockConfigRepo is a mock implementation of ConfigRepo. UpdateConfigs applies the update to
+// the configs the mock returns, as the repo does to the stored ones.
+type MockConfigRepo struct {
+	mock.Mock
+}
+
+func (m *MockConfigRepo) UpdateConfigs(ctx context.Context, scope configs.Scope, projectID uuid.UUID, id uuid.UUID, apply func(datatypes.JSONMap) (datatypes.JSONMap, error)) (datatypes.JSONMap, error) {
+	args := m.Called(ctx, scope, projectID, id)
+	if err := args.Error(1); err != nil {
+		return nil, err
+	}
+	current, _ := args.Get(0).(datatypes.JSONMap)
+	return apply(current)
+}
+
+func (m *MockConfigRepo) Layers(ctx context.Context, scope configs.Scope, projectID uuid.UUID, id uuid.UUID) ([]configs.Layer, error) {
+	args := m.Called(ctx, scope, projectID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]configs.Layer), args.Error(1)
+}
+
+func newTestConfigService(r *MockConfigRepo, unknownKeys string) ConfigService {
+	return NewConfigService(r, &config.Config{Configs: config.ConfigsCfg{UnknownKeys: unknownKeys}}, zap.NewNop())
+}
+
+func TestConfigService_PatchConfigs(t *testing.T) {
+	ctx := context.Background()
+	projectID, sessionID := uuid.New(), uuid.New()
+	target := ConfigTarget{Scope: configs.ScopeSession, ProjectID: projectID, ID: sessionID}
+
+	t.Run("merges the patch and reports unknown keys", func(t *testing.T) {
+		r := &MockConfigRepo{}
+		r.On("UpdateConfigs", ctx, configs.ScopeSession, projectID, sessionID).
+			Return(datatypes.JSONMap{"mode": "slow", "name": "a", "x_tags": map[string]any{"a": true}}, nil)
+		s := newTestConfigService(r, configs.UnknownKeysWarn)
+
+		out, err := s.PatchConfigs(ctx, target, map[string]any{"mode": "fast", "name": nil, "x_tags": map[string]any{"b": true}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"mode": "fast", "x_tags": map[string]any{"a": true, "b": true}}, out.Configs)
+		require.Len(t, out.Warnings, 1)
+		assert.Equal(t, "mode", out.Warnings[0].Key)
+	})
+
+	t.Run("stored keys the patch does not touch are not checked", func(t *testing.T) {
+		r := &MockConfigRepo{}
+		r.On("UpdateConfigs", ctx, configs.ScopeSession, projectID, sessionID).Return(datatypes.JSONMap{"legacy": 1}, nil)
+		s := newTestConfigService(r, configs.UnknownKeysReject)
+
+		out, err := s.PatchConfigs(ctx, target, map[string]any{"x_owner": "alice"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"legacy": 1, "x_owner": "alice"}, out.Configs)
+	})
+
+	t.Run("refuses unknown keys", func(t *testing.T) {
+		r := &MockConfigRepo{}
+		r.On("UpdateConfigs", ctx, configs.ScopeSession, projectID, sessionID).Return(datatypes.JSONMap{}, nil)
+		s := newTestConfigService(r, configs.UnknownKeysReject)
+
+		_, err := s.PatchConfigs(ctx, target, map[string]any{"mdoe": "fast"})
+		assert.ErrorIs(t, err, configs.ErrInvalid)
+	})
+
+	t.Run("refuses reserved keys", func(t *testing.T) {
+		r := &MockConfigRepo{}
+		r.On("UpdateConfigs", ctx, configs.ScopeProject, projectID, projectID).
+			Return(datatypes.JSONMap{"__default_init_project__": true}, nil)
+		s := newTestConfigService(r, configs.UnknownKeysWarn)
+
+		_, err := s.PatchConfigs(ctx, ConfigTarget{Scope: configs.ScopeProject, ProjectID: projectID, ID: projectID}, map[string]any{"__default_init_project__": nil})
+		assert.ErrorIs(t, err, configs.ErrInvalid)
+	})
+
+	t.Run("validates the merged value", func(t *testing.T) {
+		r := &MockConfigRepo{}
+		r.On("UpdateConfigs", ctx, configs.ScopeProject, projectID, projectID).
+			Return(datatypes.JSONMap{"usage_limits": map[string]any{"storage_bytes": map[string]any{"hard": float64(10)}}}, nil)
+		s := newTestConfigService(r, configs.UnknownKeysWarn)
+		project := ConfigTarget{Scope: configs.ScopeProject, ProjectID: projectID, ID: projectID}
+
+		out, err := s.PatchConfigs(ctx, project, map[string]any{"usage_limits": map[string]any{"storage_bytes": map[string]any{"soft": float64(5)}}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"storage_bytes": map[string]any{"soft": float64(5), "hard": float64(10)}}, out.Configs["usage_limits"])
+
+		_, err = s.PatchConfigs(ctx, project, map[string]any{"usage_limits": map[string]any{"storage_byte": map[string]any{"soft": float64(5)}}})
+		assert.ErrorIs(t, err, configs.ErrInvalid)
+	})
+
+	t.Run("unknown target", func(t *testing.T) {
+		r := &MockConfigRepo{}
+		r.On("UpdateConfigs", ctx, configs.ScopeSession, projectID, sessionID).Return(nil, gorm.ErrRecordNotFound)
+		s := newTestConfigService(r, configs.UnknownKeysWarn)
+
+		_, err := s.PatchConfigs(ctx, target, map[string]any{"x_owner": "alice"})
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestConfigService_EffectiveConfigs(t *testing.T) {
+	ctx := context.Background()
+	projectID, sessionID := uuid.New(), uuid.New()
+
+	r := &MockConfigRepo{}
+	r.On("Layers", ctx, configs.ScopeSession, projectID, sessionID).Return([]configs.Layer{
+		{Scope: configs.ScopeProject, Configs: map[string]any{"__default_init_project__": true, "mode": "slow"}},
+		{Scope: configs.ScopeSpace, Configs: map[string]any{"mode": "fast"}},
+		{Scope: configs.ScopeSession, Configs: map[string]any{"x_owner": "alice"}},
+	}, nil)
+	s := newTestConfigService(r, configs.UnknownKeysWarn)
+
+	eff, err := s.EffectiveConfigs(ctx, ConfigTarget{Scope: configs.ScopeSession, ProjectID: projectID, ID: sessionID})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"mode": "fast", "x_owner": "alice"}, eff.Configs)
+	assert.Equal(t, map[string]configs.Scope{"mode": configs.ScopeSpace, "x_owner": configs.ScopeSession}, eff.Sources)
+}
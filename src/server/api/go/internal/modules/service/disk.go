@@ -4,38 +4,137 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/audit"
 	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"gorm.io/gorm"
 )
 
 type DiskService interface {
-	Create(ctx context.Context, projectID uuid.UUID) (*model.Disk, error)
+	Create(ctx context.Context, in CreateDiskInput) (*model.Disk, error)
+	Update(ctx context.Context, in UpdateDiskInput) (*model.Disk, error)
+	Get(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error)
+	GetByName(ctx context.Context, projectID uuid.UUID, name string) (*model.Disk, error)
+	GetUsage(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.DiskUsage, error)
 	Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) error
 	List(ctx context.Context, in ListDisksInput) (*ListDisksOutput, error)
 }
 
+// maxDiskNameLength limits disk names, which are used as lookup keys
+const maxDiskNameLength = 255
+
+var (
+	ErrDiskNameExists    = repo.ErrDiskNameExists
+	ErrArtifactExists    = repo.ErrArtifactExists
+	ErrInvalidDisk       = errors.New("invalid disk")
+	ErrDiskQuotaExceeded = errors.New("disk quota exceeded")
+)
+
 type diskService struct{ r repo.DiskRepo }
 
 func NewDiskService(r repo.DiskRepo) DiskService {
 	return &diskService{r: r}
 }
 
-func (s *diskService) Create(ctx context.Context, projectID uuid.UUID) (*model.Disk, error) {
+// DiskFields are the user editable fields of a disk
+type DiskFields struct {
+	Name        *string
+	Description string
+	Meta        map[string]interface{}
+	MaxBytes    *int64 // nil means unlimited
+	MaxFiles    *int64 // nil means unlimited
+}
+
+// apply validates the fields and copies them to the disk
+func (f DiskFields) apply(disk *model.Disk) error {
+	if f.Name != nil {
+		name := strings.TrimSpace(*f.Name)
+		if name == "" || len(name) > maxDiskNameLength {
+			return fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidDisk, maxDiskNameLength)
+		}
+		f.Name = &name
+	}
+	if (f.MaxBytes != nil && *f.MaxBytes < 0) || (f.MaxFiles != nil && *f.MaxFiles < 0) {
+		return fmt.Errorf("%w: quotas must not be negative", ErrInvalidDisk)
+	}
+
+	disk.Name = f.Name
+	disk.Description = f.Description
+	disk.Meta = f.Meta
+	disk.MaxBytes = f.MaxBytes
+	disk.MaxFiles = f.MaxFiles
+	return nil
+}
+
+type CreateDiskInput struct {
+	ProjectID uuid.UUID
+	DiskFields
+}
+
+func (s *diskService) Create(ctx context.Context, in CreateDiskInput) (*model.Disk, error) {
 	disk := &model.Disk{
-		ProjectID: projectID,
+		ProjectID: in.ProjectID,
+	}
+	if err := in.DiskFields.apply(disk); err != nil {
+		return nil, err
 	}
 
 	if err := s.r.Create(ctx, disk); err != nil {
+		if errors.Is(err, repo.ErrDiskNameExists) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("create disk record: %w", err)
 	}
 
+	audit.After(ctx, disk)
+	return disk, nil
+}
+
+// UpdateDiskInput replaces every editable field of a disk. Lowering a quota below the
+// current usage is allowed; it only blocks further uploads.
+type UpdateDiskInput struct {
+	ProjectID uuid.UUID
+	DiskID    uuid.UUID
+	DiskFields
+}
+
+func (s *diskService) Update(ctx context.Context, in UpdateDiskInput) (*model.Disk, error) {
+	disk, err := s.r.Get(ctx, in.ProjectID, in.DiskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.DiskFields.apply(disk); err != nil {
+		return nil, err
+	}
+
+	if err := s.r.Update(ctx, disk); err != nil {
+		if errors.Is(err, repo.ErrDiskNameExists) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("update disk record: %w", err)
+	}
+
+	audit.After(ctx, disk)
 	return disk, nil
 }
 
+func (s *diskService) Get(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error) {
+	return s.r.Get(ctx, projectID, diskID)
+}
+
+func (s *diskService) GetByName(ctx context.Context, projectID uuid.UUID, name string) (*model.Disk, error) {
+	return s.r.GetByName(ctx, projectID, strings.TrimSpace(name))
+}
+
+func (s *diskService) GetUsage(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.DiskUsage, error) {
+	return s.r.GetUsage(ctx, projectID, diskID)
+}
+
 func (s *diskService) Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) error {
 	if len(diskID) == 0 {
 		return errors.New("disk id is empty")
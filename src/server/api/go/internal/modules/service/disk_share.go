@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/tokens"
+	"gorm.io/gorm"
+)
+
+// ShareTokenPrefix marks share link tokens so they are not mistaken for project keys
+const ShareTokenPrefix = "sh-"
+
+var (
+	ErrDiskNotFound     = errors.New("disk not found")
+	ErrShareNotFound    = errors.New("share link not found")
+	ErrShareUnavailable = errors.New("share link has expired or was revoked")
+)
+
+type DiskShareService interface {
+	Create(ctx context.Context, in CreateShareInput) (*CreateShareOutput, error)
+	List(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error)
+	Revoke(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID) error
+	Resolve(ctx context.Context, token string) (*model.DiskShare, error)
+}
+
+type diskShareService struct {
+	r      repo.DiskShareRepo
+	pepper string
+	now    func() time.Time
+}
+
+func NewDiskShareService(r repo.DiskShareRepo, cfg *config.Config) DiskShareService {
+	return &diskShareService{r: r, pepper: cfg.Root.SecretPepper, now: time.Now}
+}
+
+type CreateShareInput struct {
+	ProjectID  uuid.UUID
+	DiskID     uuid.UUID
+	Path       string
+	Permission model.SharePermission
+	ExpiresIn  time.Duration // Zero means the link never expires
+}
+
+type CreateShareOutput struct {
+	Share *model.DiskShare `json:"share"`
+	Token string           `json:"token"` // Only returned at creation
+}
+
+func (s *diskShareService) Create(ctx context.Context, in CreateShareInput) (*CreateShareOutput, error) {
+	if !strings.HasPrefix(in.Path, "/") || !strings.HasSuffix(in.Path, "/") {
+		return nil, errors.New("both ends of the path must be '/'")
+	}
+	if in.Permission != model.SharePermissionRead && in.Permission != model.SharePermissionReadWrite {
+		return nil, fmt.Errorf("invalid permission: %s", in.Permission)
+	}
+	if in.ExpiresIn < 0 {
+		return nil, errors.New("expires_in must not be negative")
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate share token: %w", err)
+	}
+	token := ShareTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	share := &model.DiskShare{
+		ProjectID:  in.ProjectID,
+		DiskID:     in.DiskID,
+		Path:       in.Path,
+		Permission: in.Permission,
+		TokenHMAC:  tokens.HMAC256Hex(s.pepper, token),
+	}
+	if in.ExpiresIn > 0 {
+		expiresAt := s.now().Add(in.ExpiresIn)
+		share.ExpiresAt = &expiresAt
+	}
+
+	if err := s.r.Create(ctx, share); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDiskNotFound
+		}
+		return nil, fmt.Errorf("create share: %w", err)
+	}
+
+	return &CreateShareOutput{Share: share, Token: token}, nil
+}
+
+func (s *diskShareService) List(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error) {
+	return s.r.ListByDisk(ctx, projectID, diskID)
+}
+
+func (s *diskShareService) Revoke(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID) error {
+	if err := s.r.Revoke(ctx, projectID, diskID, shareID, s.now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrShareNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Resolve looks up an active share by its token and counts the access
+func (s *diskShareService) Resolve(ctx context.Context, token string) (*model.DiskShare, error) {
+	if !strings.HasPrefix(token, ShareTokenPrefix) {
+		return nil, ErrShareNotFound
+	}
+
+	share, err := s.r.GetByTokenHMAC(ctx, tokens.HMAC256Hex(s.pepper, token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareNotFound
+		}
+		return nil, err
+	}
+
+	now := s.now()
+	if !share.Active(now) {
+		return nil, ErrShareUnavailable
+	}
+
+	if err := s.r.RecordAccess(ctx, share.ID, now); err != nil {
+		return nil, fmt.Errorf("record share access: %w", err)
+	}
+	share.AccessCount++
+	share.LastAccessedAt = &now
+
+	return share, nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockDiskShareRepo is a mock implementation of DiskShareRepo
+type MockDiskShareRepo struct {
+	mock.Mock
+}
+
+func (m *MockDiskShareRepo) Create(ctx context.Context, s *model.DiskShare) error {
+	args := m.Called(ctx, s)
+	return args.Error(0)
+}
+
+func (m *MockDiskShareRepo) ListByDisk(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) ([]*model.DiskShare, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.DiskShare), args.Error(1)
+}
+
+func (m *MockDiskShareRepo) Revoke(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, shareID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, projectID, diskID, shareID, at)
+	return args.Error(0)
+}
+
+func (m *MockDiskShareRepo) GetByTokenHMAC(ctx context.Context, tokenHMAC string) (*model.DiskShare, error) {
+	args := m.Called(ctx, tokenHMAC)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DiskShare), args.Error(1)
+}
+
+func (m *MockDiskShareRepo) RecordAccess(ctx context.Context, shareID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, shareID, at)
+	return args.Error(0)
+}
+
+const testSharePepper = "test-pepper"
+
+func newTestDiskShareService(r *MockDiskShareRepo, now time.Time) *diskShareService {
+	cfg := &config.Config{Root: config.RootCfg{SecretPepper: testSharePepper}}
+	s := NewDiskShareService(r, cfg).(*diskShareService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestDiskShareService_Create(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	projectID, diskID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name        string
+		input       CreateShareInput
+		setup       func(*MockDiskShareRepo)
+		wantExpiry  *time.Time
+		wantErr     bool
+		wantErrIs   error
+		wantRepoHit bool
+	}{
+		{
+			name:        "read share without expiry",
+			input:       CreateShareInput{ProjectID: projectID, DiskID: diskID, Path: "/reports/", Permission: model.SharePermissionRead},
+			setup:       func(r *MockDiskShareRepo) { r.On("Create", mock.Anything, mock.Anything).Return(nil) },
+			wantRepoHit: true,
+		},
+		{
+			name:        "read_write share with expiry",
+			input:       CreateShareInput{ProjectID: projectID, DiskID: diskID, Path: "/", Permission: model.SharePermissionReadWrite, ExpiresIn: time.Hour},
+			setup:       func(r *MockDiskShareRepo) { r.On("Create", mock.Anything, mock.Anything).Return(nil) },
+			wantExpiry:  func() *time.Time { t := now.Add(time.Hour); return &t }(),
+			wantRepoHit: true,
+		},
+		{
+			name:  "disk of another project",
+			input: CreateShareInput{ProjectID: projectID, DiskID: diskID, Path: "/", Permission: model.SharePermissionRead},
+			setup: func(r *MockDiskShareRepo) {
+				r.On("Create", mock.Anything, mock.Anything).Return(gorm.ErrRecordNotFound)
+			},
+			wantErr:     true,
+			wantErrIs:   ErrDiskNotFound,
+			wantRepoHit: true,
+		},
+		{
+			name:    "path is not a directory",
+			input:   CreateShareInput{ProjectID: projectID, DiskID: diskID, Path: "/reports/a.txt", Permission: model.SharePermissionRead},
+			setup:   func(r *MockDiskShareRepo) {},
+			wantErr: true,
+		},
+		{
+			name:    "unknown permission",
+			input:   CreateShareInput{ProjectID: projectID, DiskID: diskID, Path: "/", Permission: "admin"},
+			setup:   func(r *MockDiskShareRepo) {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &MockDiskShareRepo{}
+			tt.setup(r)
+			s := newTestDiskShareService(r, now)
+
+			out, err := s.Create(context.Background(), tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.wantErrIs != nil {
+					assert.True(t, errors.Is(err, tt.wantErrIs))
+				}
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, strings.HasPrefix(out.Token, ShareTokenPrefix))
+				// Only the HMAC of the token is stored
+				assert.Equal(t, tokens.HMAC256Hex(testSharePepper, out.Token), out.Share.TokenHMAC)
+				assert.Equal(t, tt.input.Path, out.Share.Path)
+				assert.Equal(t, tt.input.Permission, out.Share.Permission)
+				assert.Equal(t, tt.wantExpiry, out.Share.ExpiresAt)
+			}
+			if !tt.wantRepoHit {
+				r.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			}
+			r.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiskShareService_Resolve(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	token := ShareTokenPrefix + "secret"
+	lookup := tokens.HMAC256Hex(testSharePepper, token)
+
+	tests := []struct {
+		name      string
+		token     string
+		share     *model.DiskShare
+		repoErr   error
+		wantErrIs error
+	}{
+		{name: "active share", token: token, share: &model.DiskShare{ID: uuid.New(), ExpiresAt: &future}},
+		{name: "share without expiry", token: token, share: &model.DiskShare{ID: uuid.New()}},
+		{name: "expired share", token: token, share: &model.DiskShare{ID: uuid.New(), ExpiresAt: &past}, wantErrIs: ErrShareUnavailable},
+		{name: "revoked share", token: token, share: &model.DiskShare{ID: uuid.New(), RevokedAt: &past}, wantErrIs: ErrShareUnavailable},
+		{name: "unknown token", token: token, repoErr: gorm.ErrRecordNotFound, wantErrIs: ErrShareNotFound},
+		{name: "project key instead of share token", token: "sk-ac-secret", wantErrIs: ErrShareNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &MockDiskShareRepo{}
+			if strings.HasPrefix(tt.token, ShareTokenPrefix) {
+				if tt.repoErr != nil {
+					r.On("GetByTokenHMAC", mock.Anything, lookup).Return(nil, tt.repoErr)
+				} else {
+					r.On("GetByTokenHMAC", mock.Anything, lookup).Return(tt.share, nil)
+				}
+			}
+			if tt.wantErrIs == nil {
+				r.On("RecordAccess", mock.Anything, tt.share.ID, now).Return(nil)
+			}
+			s := newTestDiskShareService(r, now)
+
+			share, err := s.Resolve(context.Background(), tt.token)
+			if tt.wantErrIs != nil {
+				assert.True(t, errors.Is(err, tt.wantErrIs), "error = %v", err)
+				r.AssertNotCalled(t, "RecordAccess", mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), share.AccessCount)
+				assert.Equal(t, &now, share.LastAccessedAt)
+			}
+			r.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiskShareService_Revoke(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	projectID, diskID, shareID := uuid.New(), uuid.New(), uuid.New()
+
+	r := &MockDiskShareRepo{}
+	r.On("Revoke", mock.Anything, projectID, diskID, shareID, now).Return(gorm.ErrRecordNotFound)
+	s := newTestDiskShareService(r, now)
+
+	err := s.Revoke(context.Background(), projectID, diskID, shareID)
+	assert.True(t, errors.Is(err, ErrShareNotFound))
+	r.AssertExpectations(t)
+}
+
+func TestDiskShare_Covers(t *testing.T) {
+	share := &model.DiskShare{Path: "/reports/"}
+	assert.True(t, share.Covers("/reports/"))
+	assert.True(t, share.Covers("/reports/2024/"))
+	assert.False(t, share.Covers("/"))
+	assert.False(t, share.Covers("/reports-old/"))
+	assert.False(t, share.Covers("/other/"))
+}
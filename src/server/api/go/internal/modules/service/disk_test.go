@@ -8,8 +8,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // MockDiskRepo is a mock implementation of DiskRepo
@@ -27,6 +29,35 @@ func (m *MockDiskRepo) Delete(ctx context.Context, projectID uuid.UUID, diskID u
 	return args.Error(0)
 }
 
+func (m *MockDiskRepo) Update(ctx context.Context, d *model.Disk) error {
+	args := m.Called(ctx, d)
+	return args.Error(0)
+}
+
+func (m *MockDiskRepo) Get(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockDiskRepo) GetByName(ctx context.Context, projectID uuid.UUID, name string) (*model.Disk, error) {
+	args := m.Called(ctx, projectID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockDiskRepo) GetUsage(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.DiskUsage, error) {
+	args := m.Called(ctx, projectID, diskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DiskUsage), args.Error(1)
+}
+
 func (m *MockDiskRepo) ListWithCursor(ctx context.Context, projectID uuid.UUID, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.Disk, error) {
 	args := m.Called(ctx, projectID, afterCreatedAt, afterID, limit, timeDesc)
 	if args.Get(0) == nil {
@@ -63,10 +94,10 @@ func newTestDiskService(r *MockDiskRepo, s3 *MockS3Deps) DiskService {
 	return &testDiskService{r: r, s3: s3}
 }
 
-func (s *testDiskService) Create(ctx context.Context, projectID uuid.UUID) (*model.Disk, error) {
+func (s *testDiskService) Create(ctx context.Context, in CreateDiskInput) (*model.Disk, error) {
 	disk := &model.Disk{
 		ID:        uuid.New(),
-		ProjectID: projectID,
+		ProjectID: in.ProjectID,
 	}
 
 	if err := s.r.Create(ctx, disk); err != nil {
@@ -76,6 +107,22 @@ func (s *testDiskService) Create(ctx context.Context, projectID uuid.UUID) (*mod
 	return disk, nil
 }
 
+func (s *testDiskService) Update(ctx context.Context, in UpdateDiskInput) (*model.Disk, error) {
+	return NewDiskService(s.r).Update(ctx, in)
+}
+
+func (s *testDiskService) Get(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error) {
+	return NewDiskService(s.r).Get(ctx, projectID, diskID)
+}
+
+func (s *testDiskService) GetByName(ctx context.Context, projectID uuid.UUID, name string) (*model.Disk, error) {
+	return NewDiskService(s.r).GetByName(ctx, projectID, name)
+}
+
+func (s *testDiskService) GetUsage(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.DiskUsage, error) {
+	return NewDiskService(s.r).GetUsage(ctx, projectID, diskID)
+}
+
 func (s *testDiskService) Delete(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) error {
 	if diskID == uuid.Nil {
 		return errors.New("disk id is empty")
@@ -138,7 +185,7 @@ func TestDiskService_Create(t *testing.T) {
 
 			service := newTestDiskService(mockRepo, &MockS3Deps{})
 
-			disk, err := service.Create(context.Background(), projectID)
+			disk, err := service.Create(context.Background(), CreateDiskInput{ProjectID: projectID})
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -298,3 +345,83 @@ func TestDiskService_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestDiskService_Update(t *testing.T) {
+	projectID := uuid.New()
+	diskID := uuid.New()
+	name := func(s string) *string { return &s }
+	limit := func(n int64) *int64 { return &n }
+
+	tests := []struct {
+		name      string
+		fields    DiskFields
+		setup     func(*MockDiskRepo)
+		wantErrIs error
+	}{
+		{
+			name:   "replaces every field and trims the name",
+			fields: DiskFields{Name: name("  user-42 "), Description: "desc", Meta: map[string]interface{}{"k": "v"}, MaxBytes: limit(100), MaxFiles: limit(5)},
+			setup: func(r *MockDiskRepo) {
+				r.On("Get", mock.Anything, projectID, diskID).Return(&model.Disk{ID: diskID, ProjectID: projectID, Name: name("old")}, nil)
+				r.On("Update", mock.Anything, mock.MatchedBy(func(d *model.Disk) bool {
+					return *d.Name == "user-42" && d.Description == "desc" && *d.MaxBytes == 100 && *d.MaxFiles == 5
+				})).Return(nil)
+			},
+		},
+		{
+			name:   "empty name",
+			fields: DiskFields{Name: name("  ")},
+			setup: func(r *MockDiskRepo) {
+				r.On("Get", mock.Anything, projectID, diskID).Return(&model.Disk{ID: diskID, ProjectID: projectID}, nil)
+			},
+			wantErrIs: ErrInvalidDisk,
+		},
+		{
+			name:   "negative quota",
+			fields: DiskFields{MaxFiles: limit(-1)},
+			setup: func(r *MockDiskRepo) {
+				r.On("Get", mock.Anything, projectID, diskID).Return(&model.Disk{ID: diskID, ProjectID: projectID}, nil)
+			},
+			wantErrIs: ErrInvalidDisk,
+		},
+		{
+			name:   "name taken by another disk",
+			fields: DiskFields{Name: name("taken")},
+			setup: func(r *MockDiskRepo) {
+				r.On("Get", mock.Anything, projectID, diskID).Return(&model.Disk{ID: diskID, ProjectID: projectID}, nil)
+				r.On("Update", mock.Anything, mock.Anything).Return(repo.ErrDiskNameExists)
+			},
+			wantErrIs: ErrDiskNameExists,
+		},
+		{
+			name:   "disk of another project",
+			fields: DiskFields{},
+			setup: func(r *MockDiskRepo) {
+				r.On("Get", mock.Anything, projectID, diskID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockDiskRepo{}
+			tt.setup(mockRepo)
+
+			disk, err := NewDiskService(mockRepo).Update(context.Background(), UpdateDiskInput{
+				ProjectID:  projectID,
+				DiskID:     diskID,
+				DiskFields: tt.fields,
+			})
+
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+				assert.Nil(t, disk)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-42", *disk.Name)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,524 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/audit"
+	"github.com/memodb-io/Acontext/internal/pkg/configs"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/secrets"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/tokens"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// DefaultKeyRotationOverlap is how long a rotated key keeps working when no overlap is given
+const DefaultKeyRotationOverlap = 24 * time.Hour
+
+const (
+	// projectPurgeBatch is how many deleted projects one PurgeDeleted call removes at most
+	projectPurgeBatch = 10
+	// projectAssetDeleteBatch is how many assets of a deleted project are removed at a time
+	projectAssetDeleteBatch = 500
+)
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrAPIKeyNotFound  = errors.New("api key not found")
+	// ErrAPIKeyUnavailable is returned when rotating a key that expired, was revoked or was already rotated
+	ErrAPIKeyUnavailable = errors.New("api key has expired, was revoked or was already rotated")
+	ErrInvalidAPIKey     = errors.New("invalid api key request")
+	// ErrDefaultProject is returned when deleting the project created at startup for the root token
+	ErrDefaultProject = errors.New("the default project cannot be deleted")
+	// ErrDefaultProjectSecret is returned when changing the secret of the default project, which
+	// is the root token and set again at every startup
+	ErrDefaultProjectSecret = errors.New("the secret of the default project is the root token")
+	// ErrProjectDeleting is returned when changing a project that is being deleted
+	ErrProjectDeleting = errors.New("project is being deleted")
+)
+
+type ProjectService interface {
+	// CreateProject creates a project and its first API key
+	CreateProject(ctx context.Context, in CreateProjectInput) (*CreateProjectOutput, error)
+	ListKeys(ctx context.Context, projectID uuid.UUID) ([]*model.ProjectAPIKey, error)
+	CreateKey(ctx context.Context, in CreateKeyInput) (*CreateKeyOutput, error)
+	// RotateKey creates a key with the name and scopes of an existing one, which keeps working
+	// for the overlap so clients can switch over without downtime
+	RotateKey(ctx context.Context, in RotateKeyInput) (*RotateKeyOutput, error)
+	// RevokeKey stops a key from working immediately
+	RevokeKey(ctx context.Context, projectID uuid.UUID, keyID uuid.UUID) error
+	// RotateSecret replaces the built-in secret of a project, which has full access, and returns
+	// the token of the new one once. The previous secret stops working immediately.
+	RotateSecret(ctx context.Context, projectID uuid.UUID) (*RotateSecretOutput, error)
+	// RevokeSecret replaces the built-in secret of a project with one that is never handed out,
+	// so the project is only reached through its API keys
+	RevokeSecret(ctx context.Context, projectID uuid.UUID) error
+	// ListProjects lists the projects with their current usage, including those being deleted
+	ListProjects(ctx context.Context, in ListProjectsInput) (*ListProjectsOutput, error)
+	GetProject(ctx context.Context, projectID uuid.UUID) (*ProjectSummary, error)
+	// UpdateConfigs replaces the configs of a project. The default project stays marked as such.
+	// Invalid configs are refused with an error wrapping configs.ErrInvalid.
+	UpdateConfigs(ctx context.Context, projectID uuid.UUID, configs map[string]interface{}) (*model.Project, error)
+	// DeleteProject stops a project from authenticating and leaves its removal to PurgeDeleted
+	DeleteProject(ctx context.Context, projectID uuid.UUID) (*model.Project, error)
+	// PurgeDeleted removes projects being deleted, with their stored objects, and returns how
+	// many it removed
+	PurgeDeleted(ctx context.Context) (int, error)
+}
+
+type projectService struct {
+	r           repo.ProjectRepo
+	assets      repo.AssetReferenceRepo
+	usage       UsageService
+	prefix      string
+	pepper      string
+	unknownKeys string
+	log         *zap.Logger
+	now         func() time.Time
+}
+
+func NewProjectService(r repo.ProjectRepo, assets repo.AssetReferenceRepo, usage UsageService, cfg *config.Config, log *zap.Logger) ProjectService {
+	return &projectService{
+		r:           r,
+		assets:      assets,
+		usage:       usage,
+		prefix:      cfg.Root.ProjectBearerTokenPrefix,
+		pepper:      cfg.Root.SecretPepper,
+		unknownKeys: cfg.Configs.UnknownKeys,
+		log:         log,
+		now:         time.Now,
+	}
+}
+
+type CreateProjectInput struct {
+	Configs map[string]interface{}
+	// Key is the first API key of the project; its ProjectID is ignored
+	Key CreateKeyInput
+}
+
+type CreateProjectOutput struct {
+	Project *model.Project   `json:"project"`
+	Key     *CreateKeyOutput `json:"key"`
+}
+
+type ListProjectsInput struct {
+	Limit    int
+	Cursor   string
+	TimeDesc bool
+}
+
+// ProjectSummary is a project with its current usage
+type ProjectSummary struct {
+	*model.Project
+	Usage *UsageSummary `json:"usage"`
+}
+
+type ListProjectsOutput struct {
+	Items      []*ProjectSummary `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	HasMore    bool              `json:"has_more"`
+}
+
+type CreateKeyInput struct {
+	ProjectID uuid.UUID
+	Name      string
+	Scopes    []model.APIKeyScope
+	ExpiresIn time.Duration // Zero means the key never expires
+}
+
+type CreateKeyOutput struct {
+	Key   *model.ProjectAPIKey `json:"key"`
+	Token string               `json:"token"` // Only returned at creation
+}
+
+type RotateKeyInput struct {
+	ProjectID uuid.UUID
+	KeyID     uuid.UUID
+	// Overlap is how long the previous key keeps working; zero ends it at once
+	Overlap time.Duration
+}
+
+type RotateKeyOutput struct {
+	CreateKeyOutput
+	Previous *model.ProjectAPIKey `json:"previous"`
+}
+
+type RotateSecretOutput struct {
+	Project *model.Project `json:"project"`
+	Token   string         `json:"token"` // Only returned once
+}
+
+// generateSecret returns a random secret and the bearer token that carries it
+func (s *projectService) generateSecret() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("generate api key: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+	return secret, s.prefix + secret, nil
+}
+
+// newProjectSecret generates a built-in project secret and sets it on project, returning the
+// bearer token that carries it
+func (s *projectService) newProjectSecret(project *model.Project) (string, error) {
+	secret, token, err := s.generateSecret()
+	if err != nil {
+		return "", err
+	}
+	phc, err := secrets.HashSecret(secret, s.pepper)
+	if err != nil {
+		return "", fmt.Errorf("hash project secret: %w", err)
+	}
+	project.SecretKeyHMAC = tokens.HMAC256Hex(s.pepper, secret)
+	project.SecretKeyHashPHC = phc
+	return token, nil
+}
+
+// newKey validates in and builds a key with a fresh secret
+func (s *projectService) newKey(in CreateKeyInput) (*CreateKeyOutput, error) {
+	if in.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if in.ExpiresIn < 0 {
+		return nil, fmt.Errorf("%w: expires_in must not be negative", ErrInvalidAPIKey)
+	}
+	scopes := make([]model.APIKeyScope, 0, len(in.Scopes))
+	seen := make(map[model.APIKeyScope]bool, len(in.Scopes))
+	for _, scope := range in.Scopes {
+		if !model.IsValidAPIKeyScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKey, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	secret, token, err := s.generateSecret()
+	if err != nil {
+		return nil, err
+	}
+	phc, err := secrets.HashSecret(secret, s.pepper)
+	if err != nil {
+		return nil, fmt.Errorf("hash api key: %w", err)
+	}
+	key := &model.ProjectAPIKey{
+		ProjectID:        in.ProjectID,
+		Name:             in.Name,
+		Hint:             secret[len(secret)-4:],
+		SecretKeyHMAC:    tokens.HMAC256Hex(s.pepper, secret),
+		SecretKeyHashPHC: phc,
+		Scopes:           datatypes.NewJSONSlice(scopes),
+	}
+	if in.ExpiresIn > 0 {
+		expiresAt := s.now().Add(in.ExpiresIn)
+		key.ExpiresAt = &expiresAt
+	}
+	return &CreateKeyOutput{Key: key, Token: token}, nil
+}
+
+func (s *projectService) CreateProject(ctx context.Context, in CreateProjectInput) (*CreateProjectOutput, error) {
+	if err := validateConfigs(s.log, configs.ScopeProject, in.Configs, s.unknownKeys); err != nil {
+		return nil, err
+	}
+	key, err := s.newKey(in.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Projects created here are only accessed through API keys. Their built-in secret is
+	// random and only handed out by RotateSecret.
+	project := &model.Project{Configs: datatypes.JSONMap(in.Configs)}
+	if _, err := s.newProjectSecret(project); err != nil {
+		return nil, err
+	}
+
+	if err := s.r.CreateWithKey(ctx, project, key.Key); err != nil {
+		return nil, fmt.Errorf("create project: %w", err)
+	}
+	audit.After(ctx, project)
+	return &CreateProjectOutput{Project: project, Key: key}, nil
+}
+
+func (s *projectService) ListKeys(ctx context.Context, projectID uuid.UUID) ([]*model.ProjectAPIKey, error) {
+	return s.r.ListKeys(ctx, projectID)
+}
+
+func (s *projectService) CreateKey(ctx context.Context, in CreateKeyInput) (*CreateKeyOutput, error) {
+	out, err := s.newKey(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.r.CreateKey(ctx, out.Key); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("create api key: %w", err)
+	}
+	audit.After(ctx, out.Key)
+	return out, nil
+}
+
+func (s *projectService) RotateKey(ctx context.Context, in RotateKeyInput) (*RotateKeyOutput, error) {
+	if in.Overlap < 0 {
+		return nil, fmt.Errorf("%w: overlap must not be negative", ErrInvalidAPIKey)
+	}
+	previous, err := s.r.GetKey(ctx, in.ProjectID, in.KeyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	now := s.now()
+	if !previous.Active(now) || previous.RotatedToID != nil {
+		return nil, ErrAPIKeyUnavailable
+	}
+
+	// The new key inherits what remains of the lifetime of the previous one
+	var expiresIn time.Duration
+	if previous.ExpiresAt != nil {
+		expiresIn = previous.ExpiresAt.Sub(now)
+	}
+	next, err := s.newKey(CreateKeyInput{
+		ProjectID: previous.ProjectID,
+		Name:      previous.Name,
+		Scopes:    previous.Scopes,
+		ExpiresIn: expiresIn,
+	})
+	if err != nil {
+		return nil, err
+	}
+	next.Key.ID = uuid.New()
+
+	// The previous key keeps working for the overlap at most, never longer than it would have
+	expiresAt := now.Add(in.Overlap)
+	if previous.ExpiresAt != nil && previous.ExpiresAt.Before(expiresAt) {
+		expiresAt = *previous.ExpiresAt
+	}
+	if err := s.r.RotateKey(ctx, previous, next.Key, expiresAt, now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyUnavailable
+		}
+		return nil, fmt.Errorf("rotate api key: %w", err)
+	}
+	audit.After(ctx, next.Key)
+	return &RotateKeyOutput{CreateKeyOutput: *next, Previous: previous}, nil
+}
+
+func (s *projectService) RevokeKey(ctx context.Context, projectID uuid.UUID, keyID uuid.UUID) error {
+	if err := s.r.RevokeKey(ctx, projectID, keyID, s.now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	audit.After(ctx, map[string]any{"id": keyID, "revoked": true})
+	return nil
+}
+
+// replaceSecret gives a project a new built-in secret and returns the token that carries it
+func (s *projectService) replaceSecret(ctx context.Context, projectID uuid.UUID) (*model.Project, string, error) {
+	project, err := s.getProject(ctx, projectID)
+	if err != nil {
+		return nil, "", err
+	}
+	if project.IsDefault() {
+		return nil, "", ErrDefaultProjectSecret
+	}
+	if project.DeleteRequestedAt != nil {
+		return nil, "", ErrProjectDeleting
+	}
+	token, err := s.newProjectSecret(project)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.r.UpdateSecret(ctx, project); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrProjectDeleting
+		}
+		return nil, "", fmt.Errorf("update project secret: %w", err)
+	}
+	audit.After(ctx, project)
+	return project, token, nil
+}
+
+func (s *projectService) RotateSecret(ctx context.Context, projectID uuid.UUID) (*RotateSecretOutput, error) {
+	project, token, err := s.replaceSecret(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return &RotateSecretOutput{Project: project, Token: token}, nil
+}
+
+func (s *projectService) RevokeSecret(ctx context.Context, projectID uuid.UUID) error {
+	// The new secret is dropped, so nobody holds it
+	_, _, err := s.replaceSecret(ctx, projectID)
+	return err
+}
+
+func (s *projectService) getProject(ctx context.Context, projectID uuid.UUID) (*model.Project, error) {
+	project, err := s.r.Get(ctx, projectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *projectService) summarize(ctx context.Context, project *model.Project) (*ProjectSummary, error) {
+	usage, err := s.usage.Summary(ctx, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get usage of project %s: %w", project.ID, err)
+	}
+	return &ProjectSummary{Project: project, Usage: usage}, nil
+}
+
+func (s *projectService) ListProjects(ctx context.Context, in ListProjectsInput) (*ListProjectsOutput, error) {
+	// Parse cursor (createdAt, id); an empty cursor indicates starting from the first
+	var afterT time.Time
+	var afterID uuid.UUID
+	var err error
+	if in.Cursor != "" {
+		afterT, afterID, err = paging.DecodeCursor(in.Cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Query limit+1 is used to determine has_more
+	projects, err := s.r.ListWithCursor(ctx, afterT, afterID, in.Limit+1, in.TimeDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ListProjectsOutput{Items: []*ProjectSummary{}}
+	if len(projects) > in.Limit {
+		out.HasMore = true
+		projects = projects[:in.Limit]
+		last := projects[len(projects)-1]
+		out.NextCursor = paging.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	for _, project := range projects {
+		summary, err := s.summarize(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+		out.Items = append(out.Items, summary)
+	}
+	return out, nil
+}
+
+func (s *projectService) GetProject(ctx context.Context, projectID uuid.UUID) (*ProjectSummary, error) {
+	project, err := s.getProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return s.summarize(ctx, project)
+}
+
+func (s *projectService) UpdateConfigs(ctx context.Context, projectID uuid.UUID, in map[string]interface{}) (*model.Project, error) {
+	project, err := s.getProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project.DeleteRequestedAt != nil {
+		return nil, ErrProjectDeleting
+	}
+
+	// Reserved keys are kept as they were rather than refused, so configs that were read
+	// from the project can be sent back
+	next := datatypes.JSONMap{}
+	for k, v := range in {
+		if !configs.IsReserved(k) {
+			next[k] = v
+		}
+	}
+	if err := validateConfigs(s.log, configs.ScopeProject, next, s.unknownKeys); err != nil {
+		return nil, err
+	}
+	// The startup would otherwise no longer find the default project and create another one
+	if project.IsDefault() {
+		next[model.DefaultProjectConfigKey] = true
+	}
+	project.Configs = next
+
+	if err := s.r.UpdateConfigs(ctx, project); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectDeleting
+		}
+		return nil, fmt.Errorf("update project configs: %w", err)
+	}
+	audit.After(ctx, project)
+	return project, nil
+}
+
+func (s *projectService) DeleteProject(ctx context.Context, projectID uuid.UUID) (*model.Project, error) {
+	project, err := s.getProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project.IsDefault() {
+		return nil, ErrDefaultProject
+	}
+	// Deleting a project twice keeps the first request
+	if project.DeleteRequestedAt != nil {
+		return project, nil
+	}
+
+	now := s.now()
+	if err := s.r.MarkDeleted(ctx, projectID, now); err != nil {
+		return nil, fmt.Errorf("delete project: %w", err)
+	}
+	project.DeleteRequestedAt = &now
+	audit.After(ctx, project)
+	return project, nil
+}
+
+func (s *projectService) PurgeDeleted(ctx context.Context) (int, error) {
+	projects, err := s.r.ListDeleted(ctx, projectPurgeBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list deleted projects: %w", err)
+	}
+	purged := 0
+	for _, project := range projects {
+		if err := s.purge(ctx, project.ID); err != nil {
+			// The project stays marked, so the next call retries it
+			s.log.Warn("purge deleted project", zap.String("project_id", project.ID.String()), zap.Error(err))
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge removes the stored assets of a project, then the project and everything it owns. Objects
+// that no reference points to, such as those of unfinished uploads, are left to the asset garbage
+// collector, which treats the objects of projects that no longer exist as orphans.
+func (s *projectService) purge(ctx context.Context, projectID uuid.UUID) error {
+	for {
+		n, err := s.assets.DeleteProjectAssets(ctx, projectID, projectAssetDeleteBatch)
+		if err != nil {
+			return fmt.Errorf("delete assets: %w", err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if err := s.r.Delete(ctx, projectID); err != nil {
+		return fmt.Errorf("delete project: %w", err)
+	}
+	return nil
+}
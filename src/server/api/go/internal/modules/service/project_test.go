@@ -0,0 +1,473 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/configs"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/secrets"
+	"github.com/memodb-io/Acontext/internal/pkg/utils/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// MockProjectRepo is a mock implementation of ProjectRepo
+type MockProjectRepo struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepo) CreateWithKey(ctx context.Context, p *model.Project, key *model.ProjectAPIKey) error {
+	args := m.Called(ctx, p, key)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepo) CreateKey(ctx context.Context, key *model.ProjectAPIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepo) ListKeys(ctx context.Context, projectID uuid.UUID) ([]*model.ProjectAPIKey, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.ProjectAPIKey), args.Error(1)
+}
+
+func (m *MockProjectRepo) GetKey(ctx context.Context, projectID uuid.UUID, keyID uuid.UUID) (*model.ProjectAPIKey, error) {
+	args := m.Called(ctx, projectID, keyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ProjectAPIKey), args.Error(1)
+}
+
+func (m *MockProjectRepo) RotateKey(ctx context.Context, previous *model.ProjectAPIKey, next *model.ProjectAPIKey, expiresAt time.Time, now time.Time) error {
+	args := m.Called(ctx, previous, next, expiresAt, now)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepo) RevokeKey(ctx context.Context, projectID uuid.UUID, keyID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, projectID, keyID, at)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepo) Get(ctx context.Context, projectID uuid.UUID) (*model.Project, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Project), args.Error(1)
+}
+
+func (m *MockProjectRepo) ListWithCursor(ctx context.Context, afterCreatedAt time.Time, afterID uuid.UUID, limit int, timeDesc bool) ([]*model.Project, error) {
+	args := m.Called(ctx, afterCreatedAt, afterID, limit, timeDesc)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Project), args.Error(1)
+}
+
+func (m *MockProjectRepo) UpdateConfigs(ctx context.Context, p *model.Project) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepo) UpdateSecret(ctx context.Context, p *model.Project) error {
+	args := m.Called(ctx, p)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepo) MarkDeleted(ctx context.Context, projectID uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, projectID, at)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepo) ListDeleted(ctx context.Context, limit int) ([]*model.Project, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Project), args.Error(1)
+}
+
+func (m *MockProjectRepo) Delete(ctx context.Context, projectID uuid.UUID) error {
+	args := m.Called(ctx, projectID)
+	return args.Error(0)
+}
+
+const testKeyPrefix = "sk-ac-"
+
+func newTestProjectService(r *MockProjectRepo, now time.Time) *projectService {
+	cfg := &config.Config{Root: config.RootCfg{SecretPepper: testSharePepper, ProjectBearerTokenPrefix: testKeyPrefix}}
+	s := NewProjectService(r, nil, nil, cfg, zap.NewNop()).(*projectService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+// assertTokenMatches checks that token authenticates as key the way the auth middleware does
+func assertTokenMatches(t *testing.T, token string, key *model.ProjectAPIKey) {
+	secret, ok := tokens.ParseToken(token, testKeyPrefix)
+	require.True(t, ok, token)
+	assert.Equal(t, tokens.HMAC256Hex(testSharePepper, secret), key.SecretKeyHMAC)
+	pass, err := secrets.VerifySecret(secret, testSharePepper, key.SecretKeyHashPHC)
+	require.NoError(t, err)
+	assert.True(t, pass)
+	assert.True(t, strings.HasSuffix(secret, key.Hint))
+}
+
+func TestProjectService_CreateKey(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	projectID := uuid.New()
+
+	t.Run("scoped key that expires", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("CreateKey", ctx, mock.Anything).Return(nil)
+		s := newTestProjectService(r, now)
+
+		out, err := s.CreateKey(ctx, CreateKeyInput{
+			ProjectID: projectID,
+			Name:      "ci",
+			Scopes:    []model.APIKeyScope{model.APIKeyScopeDisks, model.APIKeyScopeReadOnly, model.APIKeyScopeDisks},
+			ExpiresIn: time.Hour,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, projectID, out.Key.ProjectID)
+		assert.Equal(t, []model.APIKeyScope{model.APIKeyScopeDisks, model.APIKeyScopeReadOnly}, []model.APIKeyScope(out.Key.Scopes))
+		require.NotNil(t, out.Key.ExpiresAt)
+		assert.Equal(t, now.Add(time.Hour), *out.Key.ExpiresAt)
+		assertTokenMatches(t, out.Token, out.Key)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		s := newTestProjectService(&MockProjectRepo{}, now)
+		for _, in := range []CreateKeyInput{
+			{ProjectID: projectID},
+			{ProjectID: projectID, Name: "ci", Scopes: []model.APIKeyScope{"admin"}},
+			{ProjectID: projectID, Name: "ci", ExpiresIn: -time.Second},
+		} {
+			_, err := s.CreateKey(ctx, in)
+			assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		}
+	})
+
+	t.Run("unknown project", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("CreateKey", ctx, mock.Anything).Return(gorm.ErrRecordNotFound)
+		s := newTestProjectService(r, now)
+
+		_, err := s.CreateKey(ctx, CreateKeyInput{ProjectID: projectID, Name: "ci"})
+		assert.ErrorIs(t, err, ErrProjectNotFound)
+	})
+}
+
+func TestProjectService_CreateProject(t *testing.T) {
+	ctx := context.Background()
+	r := &MockProjectRepo{}
+	r.On("CreateWithKey", ctx, mock.Anything, mock.Anything).Return(nil)
+	s := newTestProjectService(r, time.Now())
+
+	out, err := s.CreateProject(ctx, CreateProjectInput{
+		Configs: map[string]interface{}{"usage_limits": map[string]interface{}{}},
+		Key:     CreateKeyInput{Name: "default"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, out.Project.Configs, "usage_limits")
+	assertTokenMatches(t, out.Key.Token, out.Key.Key)
+	// The built-in project secret is not the one of the key
+	assert.NotEqual(t, out.Key.Key.SecretKeyHMAC, out.Project.SecretKeyHMAC)
+	assert.NotEmpty(t, out.Project.SecretKeyHashPHC)
+}
+
+func TestProjectService_RotateKey(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	projectID, keyID := uuid.New(), uuid.New()
+	expiresAt := now.Add(48 * time.Hour)
+	revokedAt := now.Add(-time.Minute)
+
+	t.Run("new key inherits name, scopes and expiry", func(t *testing.T) {
+		previous := &model.ProjectAPIKey{ID: keyID, ProjectID: projectID, Name: "ci", Scopes: []model.APIKeyScope{model.APIKeyScopeSessions}, ExpiresAt: &expiresAt}
+		r := &MockProjectRepo{}
+		r.On("GetKey", ctx, projectID, keyID).Return(previous, nil)
+		r.On("RotateKey", ctx, previous, mock.Anything, now.Add(time.Hour), now).Return(nil)
+		s := newTestProjectService(r, now)
+
+		out, err := s.RotateKey(ctx, RotateKeyInput{ProjectID: projectID, KeyID: keyID, Overlap: time.Hour})
+		require.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, out.Key.ID)
+		assert.Equal(t, "ci", out.Key.Name)
+		assert.Equal(t, previous.Scopes, out.Key.Scopes)
+		require.NotNil(t, out.Key.ExpiresAt)
+		assert.Equal(t, expiresAt, *out.Key.ExpiresAt)
+		assert.Same(t, previous, out.Previous)
+		assertTokenMatches(t, out.Token, out.Key)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("previous key keeps its earlier expiry", func(t *testing.T) {
+		soon := now.Add(time.Minute)
+		previous := &model.ProjectAPIKey{ID: keyID, ProjectID: projectID, Name: "ci", ExpiresAt: &soon}
+		r := &MockProjectRepo{}
+		r.On("GetKey", ctx, projectID, keyID).Return(previous, nil)
+		r.On("RotateKey", ctx, previous, mock.Anything, soon, now).Return(nil)
+		s := newTestProjectService(r, now)
+
+		_, err := s.RotateKey(ctx, RotateKeyInput{ProjectID: projectID, KeyID: keyID, Overlap: time.Hour})
+		require.NoError(t, err)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("GetKey", ctx, projectID, keyID).Return(&model.ProjectAPIKey{ID: keyID, ProjectID: projectID, Name: "ci", RevokedAt: &revokedAt}, nil)
+		s := newTestProjectService(r, now)
+
+		_, err := s.RotateKey(ctx, RotateKeyInput{ProjectID: projectID, KeyID: keyID})
+		assert.ErrorIs(t, err, ErrAPIKeyUnavailable)
+	})
+
+	t.Run("rotated concurrently", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("GetKey", ctx, projectID, keyID).Return(&model.ProjectAPIKey{ID: keyID, ProjectID: projectID, Name: "ci"}, nil)
+		r.On("RotateKey", ctx, mock.Anything, mock.Anything, now, now).Return(gorm.ErrRecordNotFound)
+		s := newTestProjectService(r, now)
+
+		_, err := s.RotateKey(ctx, RotateKeyInput{ProjectID: projectID, KeyID: keyID})
+		assert.ErrorIs(t, err, ErrAPIKeyUnavailable)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("GetKey", ctx, projectID, keyID).Return(nil, gorm.ErrRecordNotFound)
+		s := newTestProjectService(r, now)
+
+		_, err := s.RotateKey(ctx, RotateKeyInput{ProjectID: projectID, KeyID: keyID})
+		assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+	})
+}
+
+func TestProjectService_RevokeKey(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	projectID, keyID := uuid.New(), uuid.New()
+
+	r := &MockProjectRepo{}
+	r.On("RevokeKey", ctx, projectID, keyID, now).Return(gorm.ErrRecordNotFound)
+	s := newTestProjectService(r, now)
+
+	assert.ErrorIs(t, s.RevokeKey(ctx, projectID, keyID), ErrAPIKeyNotFound)
+}
+
+func TestProjectService_RotateSecret(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	projectID := uuid.New()
+
+	t.Run("replaces the secret", func(t *testing.T) {
+		project := &model.Project{ID: projectID, SecretKeyHMAC: "old", SecretKeyHashPHC: "old"}
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(project, nil)
+		r.On("UpdateSecret", ctx, project).Return(nil)
+		s := newTestProjectService(r, now)
+
+		out, err := s.RotateSecret(ctx, projectID)
+		require.NoError(t, err)
+		assert.NotEqual(t, "old", out.Project.SecretKeyHMAC)
+		assertTokenMatches(t, out.Token, &model.ProjectAPIKey{
+			SecretKeyHMAC:    out.Project.SecretKeyHMAC,
+			SecretKeyHashPHC: out.Project.SecretKeyHashPHC,
+		})
+		r.AssertExpectations(t)
+	})
+
+	t.Run("default project", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(&model.Project{ID: projectID, Configs: map[string]interface{}{model.DefaultProjectConfigKey: true}}, nil)
+		s := newTestProjectService(r, now)
+
+		_, err := s.RotateSecret(ctx, projectID)
+		assert.ErrorIs(t, err, ErrDefaultProjectSecret)
+		assert.ErrorIs(t, s.RevokeSecret(ctx, projectID), ErrDefaultProjectSecret)
+		r.AssertNotCalled(t, "UpdateSecret", mock.Anything, mock.Anything)
+	})
+
+	t.Run("project being deleted", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(&model.Project{ID: projectID, DeleteRequestedAt: &now}, nil)
+		s := newTestProjectService(r, now)
+
+		assert.ErrorIs(t, s.RevokeSecret(ctx, projectID), ErrProjectDeleting)
+	})
+}
+
+func TestProjectService_ListProjects(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	projects := []*model.Project{
+		{ID: uuid.New(), CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: uuid.New(), CreatedAt: now.Add(-time.Hour)},
+		{ID: uuid.New(), CreatedAt: now},
+	}
+
+	r := &MockProjectRepo{}
+	r.On("ListWithCursor", ctx, time.Time{}, uuid.Nil, 3, false).Return(projects, nil)
+	usage := &MockUsageService{}
+	usage.On("Summary", ctx, mock.Anything).Return(&UsageSummary{Storage: repo.StorageUsage{Bytes: 42}}, nil)
+	s := newTestProjectService(r, now)
+	s.usage = usage
+
+	out, err := s.ListProjects(ctx, ListProjectsInput{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, out.Items, 2)
+	assert.True(t, out.HasMore)
+	assert.Equal(t, projects[0].ID, out.Items[0].ID)
+	assert.Equal(t, int64(42), out.Items[1].Usage.Storage.Bytes)
+	usage.AssertNumberOfCalls(t, "Summary", 2)
+
+	afterT, afterID, err := paging.DecodeCursor(out.NextCursor)
+	require.NoError(t, err)
+	assert.True(t, projects[1].CreatedAt.Equal(afterT))
+	assert.Equal(t, projects[1].ID, afterID)
+}
+
+func TestProjectService_UpdateConfigs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	projectID := uuid.New()
+
+	t.Run("default project stays marked", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(&model.Project{
+			ID:      projectID,
+			Configs: datatypes.JSONMap{model.DefaultProjectConfigKey: true, "old": 1},
+		}, nil)
+		r.On("UpdateConfigs", ctx, mock.Anything).Return(nil)
+		s := newTestProjectService(r, now)
+
+		project, err := s.UpdateConfigs(ctx, projectID, map[string]interface{}{"new": 2})
+		require.NoError(t, err)
+		assert.Equal(t, datatypes.JSONMap{model.DefaultProjectConfigKey: true, "new": 2}, project.Configs)
+	})
+
+	t.Run("other projects cannot be marked default", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(&model.Project{ID: projectID}, nil)
+		r.On("UpdateConfigs", ctx, mock.Anything).Return(nil)
+		s := newTestProjectService(r, now)
+
+		project, err := s.UpdateConfigs(ctx, projectID, map[string]interface{}{model.DefaultProjectConfigKey: true})
+		require.NoError(t, err)
+		assert.False(t, project.IsDefault())
+	})
+
+	t.Run("invalid configs", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(&model.Project{ID: projectID}, nil)
+		s := newTestProjectService(r, now)
+
+		_, err := s.UpdateConfigs(ctx, projectID, map[string]interface{}{
+			"rate_limits": map[string]interface{}{"project": map[string]interface{}{"rate": -1.0}},
+		})
+		assert.ErrorIs(t, err, configs.ErrInvalid)
+		r.AssertNotCalled(t, "UpdateConfigs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("project being deleted", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(&model.Project{ID: projectID, DeleteRequestedAt: &now}, nil)
+		s := newTestProjectService(r, now)
+
+		_, err := s.UpdateConfigs(ctx, projectID, map[string]interface{}{})
+		assert.ErrorIs(t, err, ErrProjectDeleting)
+		r.AssertNotCalled(t, "UpdateConfigs", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown project", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(nil, gorm.ErrRecordNotFound)
+		s := newTestProjectService(r, now)
+
+		_, err := s.UpdateConfigs(ctx, projectID, map[string]interface{}{})
+		assert.ErrorIs(t, err, ErrProjectNotFound)
+	})
+}
+
+func TestProjectService_DeleteProject(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	projectID := uuid.New()
+
+	t.Run("marks the project", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(&model.Project{ID: projectID}, nil)
+		r.On("MarkDeleted", ctx, projectID, now).Return(nil)
+		s := newTestProjectService(r, now)
+
+		project, err := s.DeleteProject(ctx, projectID)
+		require.NoError(t, err)
+		require.NotNil(t, project.DeleteRequestedAt)
+		assert.Equal(t, now, *project.DeleteRequestedAt)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("already being deleted", func(t *testing.T) {
+		requested := now.Add(-time.Minute)
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(&model.Project{ID: projectID, DeleteRequestedAt: &requested}, nil)
+		s := newTestProjectService(r, now)
+
+		project, err := s.DeleteProject(ctx, projectID)
+		require.NoError(t, err)
+		assert.Equal(t, requested, *project.DeleteRequestedAt)
+		r.AssertNotCalled(t, "MarkDeleted", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("default project", func(t *testing.T) {
+		r := &MockProjectRepo{}
+		r.On("Get", ctx, projectID).Return(&model.Project{
+			ID:      projectID,
+			Configs: datatypes.JSONMap{model.DefaultProjectConfigKey: true},
+		}, nil)
+		s := newTestProjectService(r, now)
+
+		_, err := s.DeleteProject(ctx, projectID)
+		assert.ErrorIs(t, err, ErrDefaultProject)
+	})
+}
+
+func TestProjectService_PurgeDeleted(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	done, failing := uuid.New(), uuid.New()
+
+	r := &MockProjectRepo{}
+	r.On("ListDeleted", ctx, projectPurgeBatch).Return([]*model.Project{{ID: failing}, {ID: done}}, nil)
+	r.On("Delete", ctx, done).Return(nil)
+	assets := &MockAssetReferenceRepo{}
+	assets.On("DeleteProjectAssets", ctx, failing, projectAssetDeleteBatch).Return(0, errors.New("s3 down"))
+	assets.On("DeleteProjectAssets", ctx, done, projectAssetDeleteBatch).Return(projectAssetDeleteBatch, nil).Twice()
+	assets.On("DeleteProjectAssets", ctx, done, projectAssetDeleteBatch).Return(0, nil).Once()
+	s := newTestProjectService(r, now)
+	s.assets = assets
+
+	purged, err := s.PurgeDeleted(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	r.AssertExpectations(t)
+	r.AssertNotCalled(t, "Delete", ctx, failing)
+	assets.AssertExpectations(t)
+}
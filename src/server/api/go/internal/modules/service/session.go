@@ -16,8 +16,11 @@ import (
 	mq "github.com/memodb-io/Acontext/internal/infra/queue"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/audit"
+	"github.com/memodb-io/Acontext/internal/pkg/configs"
 	"github.com/memodb-io/Acontext/internal/pkg/editor"
 	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"github.com/memodb-io/Acontext/internal/pkg/tokenizer"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"gorm.io/datatypes"
@@ -38,10 +41,11 @@ type sessionService struct {
 	sessionRepo        repo.SessionRepo
 	assetReferenceRepo repo.AssetReferenceRepo
 	log                *zap.Logger
-	s3                 *blob.S3Deps
+	s3                 blob.BlobStore
 	publisher          *mq.Publisher
 	cfg                *config.Config
 	redis              *redis.Client
+	usage              UsageService
 }
 
 const (
@@ -51,7 +55,9 @@ const (
 	defaultPartsCacheTTL = time.Hour
 )
 
-func NewSessionService(sessionRepo repo.SessionRepo, assetReferenceRepo repo.AssetReferenceRepo, log *zap.Logger, s3 *blob.S3Deps, publisher *mq.Publisher, cfg *config.Config, redis *redis.Client) SessionService {
+// NewSessionService creates the session service. usage may be nil, in which case stored
+// messages are neither accounted for nor limited.
+func NewSessionService(sessionRepo repo.SessionRepo, assetReferenceRepo repo.AssetReferenceRepo, log *zap.Logger, s3 blob.BlobStore, publisher *mq.Publisher, cfg *config.Config, redis *redis.Client, usage UsageService) SessionService {
 	return &sessionService{
 		sessionRepo:        sessionRepo,
 		assetReferenceRepo: assetReferenceRepo,
@@ -60,11 +66,19 @@ func NewSessionService(sessionRepo repo.SessionRepo, assetReferenceRepo repo.Ass
 		publisher:          publisher,
 		cfg:                cfg,
 		redis:              redis,
+		usage:              usage,
 	}
 }
 
 func (s *sessionService) Create(ctx context.Context, ss *model.Session) error {
-	return s.sessionRepo.Create(ctx, ss)
+	if err := validateConfigs(s.log, configs.ScopeSession, ss.Configs, s.cfg.Configs.UnknownKeys); err != nil {
+		return err
+	}
+	if err := s.sessionRepo.Create(ctx, ss); err != nil {
+		return err
+	}
+	audit.After(ctx, ss)
+	return nil
 }
 
 func (s *sessionService) Delete(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) error {
@@ -80,14 +94,21 @@ func (s *sessionService) Delete(ctx context.Context, projectID uuid.UUID, sessio
 }
 
 func (s *sessionService) UpdateByID(ctx context.Context, ss *model.Session) error {
-	return s.sessionRepo.Update(ctx, ss)
+	if err := validateConfigs(s.log, configs.ScopeSession, ss.Configs, s.cfg.Configs.UnknownKeys); err != nil {
+		return err
+	}
+	if err := s.sessionRepo.Update(ctx, ss); err != nil {
+		return err
+	}
+	audit.After(ctx, ss)
+	return nil
 }
 
 func (s *sessionService) GetByID(ctx context.Context, ss *model.Session) (*model.Session, error) {
 	if len(ss.ID) == 0 {
 		return nil, errors.New("space id is empty")
 	}
-	return s.sessionRepo.Get(ctx, ss)
+	return s.sessionRepo.Get(ctx, ss.ProjectID, ss.ID)
 }
 
 type ListSessionsInput struct {
@@ -206,7 +227,35 @@ func (p *PartIn) Validate() error {
 	return nil
 }
 
+// messageDemand is what storing a message adds to the usage of its project. Files count at
+// their full size even though deduplication may store them for free.
+func (s *sessionService) messageDemand(ctx context.Context, in StoreMessageInput) UsageDemand {
+	demand := UsageDemand{Messages: 1}
+	parts := make([]model.Part, 0, len(in.Parts))
+	for _, p := range in.Parts {
+		parts = append(parts, model.Part{Type: p.Type, Text: p.Text, Meta: p.Meta})
+		if fh, ok := in.Files[p.FileField]; ok && fh != nil && p.FileField != "" {
+			demand.Bytes += fh.Size
+		}
+	}
+	tokens, err := tokenizer.CountSingleMessageTokens(ctx, model.Message{Parts: parts})
+	if err != nil {
+		// Token counts are usage, not a reason to lose the message
+		s.log.Warn("count message tokens", zap.Error(err))
+	}
+	demand.Tokens = int64(tokens)
+	return demand
+}
+
 func (s *sessionService) StoreMessage(ctx context.Context, in StoreMessageInput) (*model.Message, error) {
+	var demand UsageDemand
+	if s.usage != nil {
+		demand = s.messageDemand(ctx, in)
+		if err := s.usage.Check(ctx, in.ProjectID, demand); err != nil {
+			return nil, err
+		}
+	}
+
 	parts := make([]model.Part, 0, len(in.Parts))
 
 	for idx, p := range in.Parts {
@@ -222,7 +271,7 @@ func (s *sessionService) StoreMessage(ctx context.Context, in StoreMessageInput)
 			}
 
 			// upload asset to S3
-			asset, err := s.s3.UploadFormFile(ctx, "assets/"+in.ProjectID.String(), fh)
+			asset, err := s.s3.UploadFormFile(ctx, "assets/"+in.ProjectID.String(), fh, repo.AssetLookup(s.assetReferenceRepo, in.ProjectID))
 			if err != nil {
 				return nil, fmt.Errorf("upload %s failed: %w", p.FileField, err)
 			}
@@ -243,7 +292,7 @@ func (s *sessionService) StoreMessage(ctx context.Context, in StoreMessageInput)
 	}
 
 	// upload parts to S3 as JSON file
-	asset, err := s.s3.UploadJSON(ctx, "parts/"+in.ProjectID.String(), parts)
+	asset, err := s.s3.UploadJSON(ctx, "parts/"+in.ProjectID.String(), parts, repo.AssetLookup(s.assetReferenceRepo, in.ProjectID))
 	if err != nil {
 		return nil, fmt.Errorf("upload parts to S3 failed: %w", err)
 	}
@@ -254,7 +303,7 @@ func (s *sessionService) StoreMessage(ctx context.Context, in StoreMessageInput)
 
 	// Cache parts data in Redis after successful S3 upload
 	if s.redis != nil {
-		if err := s.cachePartsInRedis(ctx, asset.SHA256, parts); err != nil {
+		if err := s.cachePartsInRedis(ctx, *asset, parts); err != nil {
 			// Log error but don't fail the request if Redis caching fails
 			s.log.Warn("failed to cache parts in Redis", zap.String("sha256", asset.SHA256), zap.Error(err))
 		}
@@ -278,6 +327,14 @@ func (s *sessionService) StoreMessage(ctx context.Context, in StoreMessageInput)
 		return nil, err
 	}
 
+	if s.usage != nil {
+		s.usage.Record(ctx, in.ProjectID, map[string]int64{
+			model.MetricTagMessageStored: demand.Messages,
+			model.MetricTagTokenIngested: demand.Tokens,
+			model.MetricTagBytesIngested: demand.Bytes + asset.SizeB,
+		})
+	}
+
 	// Check if task tracking is disabled for this session
 	disableTaskTracking, err := s.sessionRepo.GetDisableTaskTracking(ctx, in.SessionID)
 	if err != nil {
@@ -294,6 +351,7 @@ func (s *sessionService) StoreMessage(ctx context.Context, in StoreMessageInput)
 		}
 	}
 
+	audit.After(ctx, &msg)
 	return &msg, nil
 }
 
@@ -410,8 +468,9 @@ func (s *sessionService) GetMessages(ctx context.Context, in GetMessagesInput) (
 	return out, nil
 }
 
-// cachePartsInRedis stores message parts in Redis with a fixed TTL
-func (s *sessionService) cachePartsInRedis(ctx context.Context, sha256 string, parts []model.Part) error {
+// cachePartsInRedis stores message parts in Redis with a fixed TTL.
+// When the blob store encrypts objects, the cached copy is encrypted the same way.
+func (s *sessionService) cachePartsInRedis(ctx context.Context, meta model.Asset, parts []model.Part) error {
 	if s.redis == nil {
 		return errors.New("redis client is not available")
 	}
@@ -421,9 +480,14 @@ func (s *sessionService) cachePartsInRedis(ctx context.Context, sha256 string, p
 	if err != nil {
 		return fmt.Errorf("marshal parts to JSON: %w", err)
 	}
+	if sealer, ok := s.s3.(blob.Sealer); ok {
+		if jsonData, err = sealer.Seal(ctx, meta.S3Key, jsonData); err != nil {
+			return fmt.Errorf("seal parts: %w", err)
+		}
+	}
 
 	// Use SHA256 as part of Redis key for content-based caching
-	redisKey := redisKeyPrefixParts + sha256
+	redisKey := redisKeyPrefixParts + meta.SHA256
 
 	// Store in Redis with fixed TTL
 	if err := s.redis.Set(ctx, redisKey, jsonData, defaultPartsCacheTTL).Err(); err != nil {
@@ -435,12 +499,12 @@ func (s *sessionService) cachePartsInRedis(ctx context.Context, sha256 string, p
 
 // getPartsFromRedis retrieves message parts from Redis cache
 // Returns (nil, redis.Nil) on cache miss, which is a normal condition
-func (s *sessionService) getPartsFromRedis(ctx context.Context, sha256 string) ([]model.Part, error) {
+func (s *sessionService) getPartsFromRedis(ctx context.Context, meta model.Asset) ([]model.Part, error) {
 	if s.redis == nil {
 		return nil, errors.New("redis client is not available")
 	}
 
-	redisKey := redisKeyPrefixParts + sha256
+	redisKey := redisKeyPrefixParts + meta.SHA256
 
 	// Get from Redis
 	val, err := s.redis.Get(ctx, redisKey).Result()
@@ -453,9 +517,16 @@ func (s *sessionService) getPartsFromRedis(ctx context.Context, sha256 string) (
 		return nil, fmt.Errorf("get Redis key %s: %w", redisKey, err)
 	}
 
+	jsonData := []byte(val)
+	if sealer, ok := s.s3.(blob.Sealer); ok {
+		if jsonData, err = sealer.Unseal(ctx, meta.S3Key, jsonData); err != nil {
+			return nil, fmt.Errorf("unseal parts: %w", err)
+		}
+	}
+
 	// Deserialize JSON to parts
 	var parts []model.Part
-	if err := sonic.Unmarshal([]byte(val), &parts); err != nil {
+	if err := sonic.Unmarshal(jsonData, &parts); err != nil {
 		return nil, fmt.Errorf("unmarshal parts from JSON: %w", err)
 	}
 
@@ -470,7 +541,7 @@ func (s *sessionService) loadPartsForMessage(ctx context.Context, meta model.Ass
 
 	// Try to get parts from Redis cache first, fallback to S3 if not found
 	if s.redis != nil {
-		if cachedParts, err := s.getPartsFromRedis(ctx, meta.SHA256); err == nil {
+		if cachedParts, err := s.getPartsFromRedis(ctx, meta); err == nil {
 			parts = cachedParts
 			cacheHit = true
 		} else if err != redis.Nil {
@@ -487,7 +558,7 @@ func (s *sessionService) loadPartsForMessage(ctx context.Context, meta model.Ass
 		}
 		// Cache the parts in Redis after successful S3 download
 		if s.redis != nil {
-			if err := s.cachePartsInRedis(ctx, meta.SHA256, parts); err != nil {
+			if err := s.cachePartsInRedis(ctx, meta, parts); err != nil {
 				// Log error but don't fail the request if Redis caching fails
 				s.log.Warn("failed to cache parts in Redis", zap.String("sha256", meta.SHA256), zap.Error(err))
 			}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// sessionEventChannelPrefix prefixes the Redis channel of the events of a session
+	sessionEventChannelPrefix = "session:events:"
+	// sessionEventReadBatch is how many stored events a stream reads at a time
+	sessionEventReadBatch = 200
+	// sessionEventPruneBatch is how many expired events one statement of Prune removes
+	sessionEventPruneBatch = 1000
+)
+
+type SessionEventService interface {
+	// Relay numbers the events written since the last call, publishes them to the watchers of
+	// their session on every replica and returns how many it published
+	Relay(ctx context.Context) (int, error)
+	// Watch returns the events of a session numbered after lastSeq, the stored ones first and
+	// then the live ones, until ctx is done. A nil lastSeq starts with the next event.
+	Watch(ctx context.Context, sessionID uuid.UUID, lastSeq *int64) (<-chan *model.SessionEvent, error)
+	// Prune removes the events older than retention and returns how many it removed
+	Prune(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type sessionEventService struct {
+	r     repo.SessionEventRepo
+	redis *redis.Client
+	cfg   config.SessionEventsCfg
+	log   *zap.Logger
+	now   func() time.Time
+}
+
+func NewSessionEventService(r repo.SessionEventRepo, redis *redis.Client, cfg *config.Config, log *zap.Logger) SessionEventService {
+	return &sessionEventService{r: r, redis: redis, cfg: cfg.Events, log: log, now: time.Now}
+}
+
+func sessionEventChannel(sessionID uuid.UUID) string {
+	return sessionEventChannelPrefix + sessionID.String()
+}
+
+func (s *sessionEventService) Relay(ctx context.Context) (int, error) {
+	events, err := s.r.Publish(ctx, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	// An event that fails to publish is still stored, and open streams read it on their next catch up
+	for _, e := range events {
+		payload, err := sonic.Marshal(e)
+		if err != nil {
+			s.log.Sugar().Warnw("marshal session event", "seq", *e.Seq, "err", err)
+			continue
+		}
+		if err := s.redis.Publish(ctx, sessionEventChannel(e.SessionID), payload).Err(); err != nil {
+			s.log.Sugar().Warnw("publish session event", "seq", *e.Seq, "err", err)
+		}
+	}
+	return len(events), nil
+}
+
+func (s *sessionEventService) Watch(ctx context.Context, sessionID uuid.UUID, lastSeq *int64) (<-chan *model.SessionEvent, error) {
+	// Subscribe before reading the stored events, so nothing published in between is missed
+	sub := s.redis.Subscribe(ctx, sessionEventChannel(sessionID))
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, err
+	}
+
+	var last int64
+	if lastSeq != nil {
+		last = *lastSeq
+	} else {
+		var err error
+		if last, err = s.r.LastSeq(ctx, sessionID); err != nil {
+			_ = sub.Close()
+			return nil, err
+		}
+	}
+
+	out := make(chan *model.SessionEvent)
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		send := func(e *model.SessionEvent) bool {
+			select {
+			case out <- e:
+				last = *e.Seq
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		catchUp := func() bool {
+			for {
+				events, err := s.r.ListAfter(ctx, sessionID, last, sessionEventReadBatch)
+				if err != nil {
+					if ctx.Err() == nil {
+						s.log.Sugar().Warnw("read session events", "session_id", sessionID, "err", err)
+					}
+					return ctx.Err() == nil
+				}
+				for _, e := range events {
+					if !send(e) {
+						return false
+					}
+				}
+				if len(events) < sessionEventReadBatch {
+					return true
+				}
+			}
+		}
+
+		if lastSeq != nil && !catchUp() {
+			return
+		}
+
+		ticker := time.NewTicker(time.Duration(s.cfg.CatchUpSec) * time.Second)
+		defer ticker.Stop()
+		live := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-live:
+				if !ok {
+					return
+				}
+				// The stored events are the source of truth: a live one only says there is more
+				// to read, so none are skipped when Redis drops a message
+				var e model.SessionEvent
+				if err := sonic.UnmarshalString(msg.Payload, &e); err == nil && e.Seq != nil && *e.Seq <= last {
+					continue
+				}
+				if !catchUp() {
+					return
+				}
+			case <-ticker.C:
+				if !catchUp() {
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *sessionEventService) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := s.now().Add(-retention)
+	var total int64
+	// Remove in batches so a large backlog does not hold one long transaction
+	for {
+		n, err := s.r.DeleteBefore(ctx, cutoff, sessionEventPruneBatch)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < sessionEventPruneBatch {
+			return total, nil
+		}
+	}
+}
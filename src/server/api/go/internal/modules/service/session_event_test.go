@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockSessionEventRepo struct {
+	mock.Mock
+}
+
+func (m *MockSessionEventRepo) Publish(ctx context.Context, limit int) ([]*model.SessionEvent, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.SessionEvent), args.Error(1)
+}
+
+func (m *MockSessionEventRepo) ListAfter(ctx context.Context, sessionID uuid.UUID, seq int64, limit int) ([]*model.SessionEvent, error) {
+	args := m.Called(ctx, sessionID, seq, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.SessionEvent), args.Error(1)
+}
+
+func (m *MockSessionEventRepo) LastSeq(ctx context.Context, sessionID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSessionEventRepo) DeleteBefore(ctx context.Context, t time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, t, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// setupTestRedis connects to the Redis of the development environment
+// Note: This requires a running Redis instance for integration tests
+func setupTestRedis(t *testing.T) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:16379", Password: "helloworld"})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skip("Test Redis not available, skipping integration tests")
+	}
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+func sessionEvent(sessionID uuid.UUID, seq int64, typ model.SessionEventType) *model.SessionEvent {
+	return &model.SessionEvent{ID: seq, Seq: &seq, SessionID: sessionID, Type: typ}
+}
+
+func receiveSessionEvent(t *testing.T, events <-chan *model.SessionEvent) *model.SessionEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		require.NotNil(t, e)
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no session event received")
+		return nil
+	}
+}
+
+func TestSessionEventService_Watch(t *testing.T) {
+	rdb := setupTestRedis(t)
+	sessionID, otherID := uuid.New(), uuid.New()
+
+	r := &MockSessionEventRepo{}
+	s := &sessionEventService{
+		r:     r,
+		redis: rdb,
+		cfg:   config.SessionEventsCfg{BatchSize: 100, CatchUpSec: 60},
+		log:   zap.NewNop(),
+		now:   time.Now,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Resuming sends the stored events after the last one received
+	r.On("ListAfter", mock.Anything, sessionID, int64(4), sessionEventReadBatch).
+		Return([]*model.SessionEvent{sessionEvent(sessionID, 5, model.SessionEventMessageCreated)}, nil).Once()
+	last := int64(4)
+	events, err := s.Watch(ctx, sessionID, &last)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *receiveSessionEvent(t, events).Seq)
+
+	// A published event wakes the stream, which reads what it has not sent yet
+	r.On("Publish", mock.Anything, 100).Return([]*model.SessionEvent{
+		sessionEvent(otherID, 6, model.SessionEventTaskCreated),
+		sessionEvent(sessionID, 8, model.SessionEventTaskUpdated),
+	}, nil).Once()
+	r.On("ListAfter", mock.Anything, sessionID, int64(5), sessionEventReadBatch).
+		Return([]*model.SessionEvent{
+			sessionEvent(sessionID, 7, model.SessionEventMessageStatus),
+			sessionEvent(sessionID, 8, model.SessionEventTaskUpdated),
+		}, nil).Once()
+	n, err := s.Relay(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, int64(7), *receiveSessionEvent(t, events).Seq)
+	assert.Equal(t, int64(8), *receiveSessionEvent(t, events).Seq)
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end with its context")
+	}
+}
+
+func TestSessionEventService_Prune(t *testing.T) {
+	now := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-24 * time.Hour)
+
+	t.Run("removes in batches until one is short", func(t *testing.T) {
+		r := &MockSessionEventRepo{}
+		r.On("DeleteBefore", mock.Anything, cutoff, sessionEventPruneBatch).Return(int64(sessionEventPruneBatch), nil).Once()
+		r.On("DeleteBefore", mock.Anything, cutoff, sessionEventPruneBatch).Return(int64(7), nil).Once()
+		s := &sessionEventService{r: r, now: func() time.Time { return now }}
+
+		removed, err := s.Prune(context.Background(), 24*time.Hour)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(sessionEventPruneBatch+7), removed)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("stops on error", func(t *testing.T) {
+		r := &MockSessionEventRepo{}
+		r.On("DeleteBefore", mock.Anything, cutoff, sessionEventPruneBatch).Return(int64(0), errors.New("db down")).Once()
+		s := &sessionEventService{r: r, now: func() time.Time { return now }}
+
+		_, err := s.Prune(context.Background(), 24*time.Hour)
+
+		assert.Error(t, err)
+	})
+}
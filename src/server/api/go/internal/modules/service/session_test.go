@@ -9,9 +9,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/memodb-io/Acontext/internal/config"
 	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // MockSessionRepo is a mock implementation of SessionRepo
@@ -34,8 +36,8 @@ func (m *MockSessionRepo) Update(ctx context.Context, s *model.Session) error {
 	return args.Error(0)
 }
 
-func (m *MockSessionRepo) Get(ctx context.Context, s *model.Session) (*model.Session, error) {
-	args := m.Called(ctx, s)
+func (m *MockSessionRepo) Get(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) (*model.Session, error) {
+	args := m.Called(ctx, projectID, sessionID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -101,6 +103,44 @@ func (m *MockAssetReferenceRepo) BatchDecrementAssetRefs(ctx context.Context, pr
 	return args.Error(0)
 }
 
+func (m *MockAssetReferenceRepo) ReleaseAssetRefs(ctx context.Context, projectID uuid.UUID, assets []model.Asset) ([]string, error) {
+	args := m.Called(ctx, projectID, assets)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAssetReferenceRepo) ReleaseAssetCounts(ctx context.Context, projectID uuid.UUID, counts map[string]int) ([]string, error) {
+	args := m.Called(ctx, projectID, counts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAssetReferenceRepo) DeleteReleasedAssets(ctx context.Context, keys []string) error {
+	args := m.Called(ctx, keys)
+	return args.Error(0)
+}
+
+func (m *MockAssetReferenceRepo) WithTx(tx *gorm.DB) repo.AssetReferenceRepo {
+	return m
+}
+
+func (m *MockAssetReferenceRepo) FindAsset(ctx context.Context, projectID uuid.UUID, sha256 string) (*model.Asset, error) {
+	args := m.Called(ctx, projectID, sha256)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Asset), args.Error(1)
+}
+
+func (m *MockAssetReferenceRepo) DeleteProjectAssets(ctx context.Context, projectID uuid.UUID, limit int) (int, error) {
+	args := m.Called(ctx, projectID, limit)
+	return args.Int(0), args.Error(1)
+}
+
 // MockBlobService is a mock implementation of blob service
 type MockBlobService struct {
 	mock.Mock
@@ -185,7 +225,7 @@ func TestSessionService_Create(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil, nil)
 
 			err := service.Create(ctx, tt.session)
 
@@ -263,7 +303,7 @@ func TestSessionService_Delete(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil, nil)
 
 			err := service.Delete(ctx, tt.projectID, tt.sessionID)
 
@@ -283,6 +323,7 @@ func TestSessionService_Delete(t *testing.T) {
 
 func TestSessionService_GetByID(t *testing.T) {
 	ctx := context.Background()
+	projectID := uuid.New()
 	sessionID := uuid.New()
 
 	tests := []struct {
@@ -295,16 +336,15 @@ func TestSessionService_GetByID(t *testing.T) {
 		{
 			name: "successful session retrieval",
 			session: &model.Session{
-				ID: sessionID,
+				ID:        sessionID,
+				ProjectID: projectID,
 			},
 			setup: func(repo *MockSessionRepo) {
 				expectedSession := &model.Session{
 					ID:        sessionID,
-					ProjectID: uuid.New(),
+					ProjectID: projectID,
 				}
-				repo.On("Get", ctx, mock.MatchedBy(func(s *model.Session) bool {
-					return s.ID == sessionID
-				})).Return(expectedSession, nil)
+				repo.On("Get", ctx, projectID, sessionID).Return(expectedSession, nil)
 			},
 			wantErr: false,
 		},
@@ -315,7 +355,7 @@ func TestSessionService_GetByID(t *testing.T) {
 			},
 			setup: func(repo *MockSessionRepo) {
 				// Empty UUID will call Get, because len(uuid.UUID{}) != 0
-				repo.On("Get", ctx, mock.AnythingOfType("*model.Session")).Return(&model.Session{}, nil)
+				repo.On("Get", ctx, uuid.Nil, uuid.Nil).Return(&model.Session{}, nil)
 			},
 			wantErr: false,
 		},
@@ -325,7 +365,7 @@ func TestSessionService_GetByID(t *testing.T) {
 				ID: sessionID,
 			},
 			setup: func(repo *MockSessionRepo) {
-				repo.On("Get", ctx, mock.AnythingOfType("*model.Session")).Return(nil, errors.New("session not found"))
+				repo.On("Get", ctx, uuid.Nil, sessionID).Return(nil, errors.New("session not found"))
 			},
 			wantErr: true,
 		},
@@ -348,7 +388,7 @@ func TestSessionService_GetByID(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil, nil)
 
 			result, err := service.GetByID(ctx, tt.session)
 
@@ -420,7 +460,7 @@ func TestSessionService_UpdateByID(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil, nil)
 
 			err := service.UpdateByID(ctx, tt.session)
 
@@ -558,7 +598,7 @@ func TestSessionService_List(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil, nil)
 
 			result, err := service.List(ctx, tt.input)
 
@@ -838,7 +878,7 @@ func TestSessionService_GetMessages(t *testing.T) {
 				},
 			}
 			// Note: blob is nil in test, so GetMessages will skip DownloadJSON and PresignGet
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil, nil)
 
 			result, err := service.GetMessages(ctx, tt.input)
 
@@ -998,7 +1038,7 @@ func TestSessionService_GetMessages_SortOrder(t *testing.T) {
 					},
 				},
 			}
-			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil)
+			service := NewSessionService(repo, mockAssetRefRepo, logger, nil, nil, cfg, nil, nil)
 
 			result, err := service.GetMessages(ctx, tt.input)
 
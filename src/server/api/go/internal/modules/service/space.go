@@ -11,6 +11,8 @@ import (
 	mq "github.com/memodb-io/Acontext/internal/infra/queue"
 	"github.com/memodb-io/Acontext/internal/modules/model"
 	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/audit"
+	"github.com/memodb-io/Acontext/internal/pkg/configs"
 	"github.com/memodb-io/Acontext/internal/pkg/paging"
 	"go.uber.org/zap"
 )
@@ -22,7 +24,7 @@ type SpaceService interface {
 	GetByID(ctx context.Context, m *model.Space) (*model.Space, error)
 	List(ctx context.Context, in ListSpacesInput) (*ListSpacesOutput, error)
 	ListExperienceConfirmations(ctx context.Context, in ListExperienceConfirmationsInput) (*ListExperienceConfirmationsOutput, error)
-	ConfirmExperience(ctx context.Context, spaceID uuid.UUID, experienceID uuid.UUID, save bool) (*model.ExperienceConfirmation, error)
+	ConfirmExperience(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID, experienceID uuid.UUID, save bool) (*model.ExperienceConfirmation, error)
 }
 
 type spaceService struct {
@@ -42,28 +44,42 @@ func NewSpaceService(r repo.SpaceRepo, publisher *mq.Publisher, cfg *config.Conf
 }
 
 func (s *spaceService) Create(ctx context.Context, m *model.Space) error {
-	return s.r.Create(ctx, m)
+	if err := validateConfigs(s.log, configs.ScopeSpace, m.Configs, s.cfg.Configs.UnknownKeys); err != nil {
+		return err
+	}
+	if err := s.r.Create(ctx, m); err != nil {
+		return err
+	}
+	audit.After(ctx, m)
+	return nil
 }
 
 func (s *spaceService) Delete(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) error {
 	if len(spaceID) == 0 {
 		return errors.New("space id is empty")
 	}
-	return s.r.Delete(ctx, &model.Space{ID: spaceID, ProjectID: projectID})
+	return s.r.Delete(ctx, projectID, spaceID)
 }
 
 func (s *spaceService) UpdateByID(ctx context.Context, m *model.Space) error {
 	if len(m.ID) == 0 {
 		return errors.New("space id is empty")
 	}
-	return s.r.Update(ctx, m)
+	if err := validateConfigs(s.log, configs.ScopeSpace, m.Configs, s.cfg.Configs.UnknownKeys); err != nil {
+		return err
+	}
+	if err := s.r.Update(ctx, m); err != nil {
+		return err
+	}
+	audit.After(ctx, m)
+	return nil
 }
 
 func (s *spaceService) GetByID(ctx context.Context, m *model.Space) (*model.Space, error) {
 	if len(m.ID) == 0 {
 		return nil, errors.New("space id is empty")
 	}
-	return s.r.Get(ctx, m)
+	return s.r.Get(ctx, m.ProjectID, m.ID)
 }
 
 type ListSpacesInput struct {
@@ -156,7 +172,7 @@ func (s *spaceService) ListExperienceConfirmations(ctx context.Context, in ListE
 	return out, nil
 }
 
-func (s *spaceService) ConfirmExperience(ctx context.Context, spaceID uuid.UUID, experienceID uuid.UUID, save bool) (*model.ExperienceConfirmation, error) {
+func (s *spaceService) ConfirmExperience(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID, experienceID uuid.UUID, save bool) (*model.ExperienceConfirmation, error) {
 	if save {
 		// Get the data from this row first
 		confirmation, err := s.r.GetExperienceConfirmation(ctx, spaceID, experienceID)
@@ -167,12 +183,6 @@ func (s *spaceService) ConfirmExperience(ctx context.Context, spaceID uuid.UUID,
 		// Parse experience_data to check type
 		experienceData := confirmation.ExperienceData
 		if expType, ok := experienceData["type"].(string); ok && expType == "sop" {
-			// Get space to retrieve project_id
-			space, err := s.r.Get(ctx, &model.Space{ID: spaceID})
-			if err != nil {
-				return nil, fmt.Errorf("failed to get space: %w", err)
-			}
-
 			// Extract data field
 			dataField, ok := experienceData["data"]
 			if !ok {
@@ -185,7 +195,7 @@ func (s *spaceService) ConfirmExperience(ctx context.Context, spaceID uuid.UUID,
 				taskID = *confirmation.TaskID
 			}
 			sopComplete := map[string]interface{}{
-				"project_id": space.ProjectID,
+				"project_id": projectID,
 				"space_id":   spaceID,
 				"task_id":    taskID,
 				"sop_data":   dataField,
@@ -208,12 +218,14 @@ func (s *spaceService) ConfirmExperience(ctx context.Context, spaceID uuid.UUID,
 			return nil, err
 		}
 
+		audit.After(ctx, map[string]any{"id": experienceID, "saved": true, "task_id": confirmation.TaskID})
 		return confirmation, nil
 	} else {
 		// Just delete the row
 		if err := s.r.DeleteExperienceConfirmation(ctx, spaceID, experienceID); err != nil {
 			return nil, err
 		}
+		audit.After(ctx, map[string]any{"id": experienceID, "saved": false})
 		return nil, nil
 	}
 }
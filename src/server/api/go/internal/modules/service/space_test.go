@@ -24,8 +24,8 @@ func (m *MockSpaceRepo) Create(ctx context.Context, s *model.Space) error {
 	return args.Error(0)
 }
 
-func (m *MockSpaceRepo) Delete(ctx context.Context, s *model.Space) error {
-	args := m.Called(ctx, s)
+func (m *MockSpaceRepo) Delete(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) error {
+	args := m.Called(ctx, projectID, spaceID)
 	return args.Error(0)
 }
 
@@ -34,8 +34,8 @@ func (m *MockSpaceRepo) Update(ctx context.Context, s *model.Space) error {
 	return args.Error(0)
 }
 
-func (m *MockSpaceRepo) Get(ctx context.Context, s *model.Space) (*model.Space, error) {
-	args := m.Called(ctx, s)
+func (m *MockSpaceRepo) Get(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) (*model.Space, error) {
+	args := m.Called(ctx, projectID, spaceID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -146,9 +146,7 @@ func TestSpaceService_Delete(t *testing.T) {
 			projectID: projectID,
 			spaceID:   spaceID,
 			setup: func(repo *MockSpaceRepo) {
-				repo.On("Delete", ctx, mock.MatchedBy(func(s *model.Space) bool {
-					return s.ID == spaceID && s.ProjectID == projectID
-				})).Return(nil)
+				repo.On("Delete", ctx, projectID, spaceID).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -158,7 +156,7 @@ func TestSpaceService_Delete(t *testing.T) {
 			spaceID:   uuid.UUID{},
 			setup: func(repo *MockSpaceRepo) {
 				// Empty UUID will call Delete, because len(uuid.UUID{}) != 0
-				repo.On("Delete", ctx, mock.AnythingOfType("*model.Space")).Return(nil)
+				repo.On("Delete", ctx, projectID, uuid.Nil).Return(nil)
 			},
 			wantErr: false, // Actually won't error
 		},
@@ -167,7 +165,7 @@ func TestSpaceService_Delete(t *testing.T) {
 			projectID: projectID,
 			spaceID:   spaceID,
 			setup: func(repo *MockSpaceRepo) {
-				repo.On("Delete", ctx, mock.AnythingOfType("*model.Space")).Return(errors.New("deletion failed"))
+				repo.On("Delete", ctx, projectID, spaceID).Return(errors.New("deletion failed"))
 			},
 			wantErr: true,
 		},
@@ -265,6 +263,7 @@ func TestSpaceService_UpdateByID(t *testing.T) {
 
 func TestSpaceService_GetByID(t *testing.T) {
 	ctx := context.Background()
+	projectID := uuid.New()
 	spaceID := uuid.New()
 
 	tests := []struct {
@@ -277,16 +276,15 @@ func TestSpaceService_GetByID(t *testing.T) {
 		{
 			name: "successful space retrieval",
 			space: &model.Space{
-				ID: spaceID,
+				ID:        spaceID,
+				ProjectID: projectID,
 			},
 			setup: func(repo *MockSpaceRepo) {
 				expectedSpace := &model.Space{
 					ID:        spaceID,
-					ProjectID: uuid.New(),
+					ProjectID: projectID,
 				}
-				repo.On("Get", ctx, mock.MatchedBy(func(s *model.Space) bool {
-					return s.ID == spaceID
-				})).Return(expectedSpace, nil)
+				repo.On("Get", ctx, projectID, spaceID).Return(expectedSpace, nil)
 			},
 			wantErr: false,
 		},
@@ -297,7 +295,7 @@ func TestSpaceService_GetByID(t *testing.T) {
 			},
 			setup: func(repo *MockSpaceRepo) {
 				// Empty UUID will call Get, because len(uuid.UUID{}) != 0
-				repo.On("Get", ctx, mock.AnythingOfType("*model.Space")).Return(&model.Space{}, nil)
+				repo.On("Get", ctx, uuid.Nil, uuid.Nil).Return(&model.Space{}, nil)
 			},
 			wantErr: false,
 		},
@@ -307,7 +305,7 @@ func TestSpaceService_GetByID(t *testing.T) {
 				ID: spaceID,
 			},
 			setup: func(repo *MockSpaceRepo) {
-				repo.On("Get", ctx, mock.AnythingOfType("*model.Space")).Return(nil, errors.New("space not found"))
+				repo.On("Get", ctx, uuid.Nil, spaceID).Return(nil, errors.New("space not found"))
 			},
 			wantErr: true,
 		},
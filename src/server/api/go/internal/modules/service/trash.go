@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/audit"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// trashPurgeBatch is how many expired resources one Purge call removes at most
+const trashPurgeBatch = 100
+
+// TrashService lists and restores the deleted sessions, spaces and disks of a project, and purges
+// them for good once they have been in the trash for longer than trash.retentionDays.
+type TrashService interface {
+	ListSessions(ctx context.Context, in ListTrashInput) (*ListSessionsOutput, error)
+	ListSpaces(ctx context.Context, in ListTrashInput) (*ListSpacesOutput, error)
+	ListDisks(ctx context.Context, in ListTrashInput) (*ListDisksOutput, error)
+	// RestoreSession takes a session out of the trash. It returns gorm.ErrRecordNotFound when the
+	// session is not in the trash, or has been there longer than the retention window.
+	RestoreSession(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) (*model.Session, error)
+	RestoreSpace(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) (*model.Space, error)
+	// RestoreDisk returns ErrDiskNameExists when another disk took the name of the disk meanwhile
+	RestoreDisk(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error)
+	// Purge removes the resources that have been in the trash longer than the retention window,
+	// with everything they own, and returns how many it removed
+	Purge(ctx context.Context) (int, error)
+}
+
+type trashService struct {
+	r         repo.TrashRepo
+	retention time.Duration
+	log       *zap.Logger
+	now       func() time.Time
+}
+
+func NewTrashService(r repo.TrashRepo, cfg *config.Config, log *zap.Logger) TrashService {
+	return &trashService{
+		r:         r,
+		retention: time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour,
+		log:       log,
+		now:       time.Now,
+	}
+}
+
+// since is the oldest deletion time of a resource that can still be restored
+func (s *trashService) since() time.Time {
+	return s.now().Add(-s.retention)
+}
+
+type ListTrashInput struct {
+	ProjectID uuid.UUID `json:"project_id"`
+	Limit     int       `json:"limit"`
+	Cursor    string    `json:"cursor"`
+}
+
+// trashPage cuts a listing of up to limit+1 rows, the most recently deleted first, to a page and
+// returns the cursor of the next page, if any
+func trashPage[T any](rows []T, limit int, key func(T) (time.Time, uuid.UUID)) ([]T, string, bool) {
+	if len(rows) <= limit {
+		return rows, "", false
+	}
+	rows = rows[:limit]
+	deletedAt, id := key(rows[len(rows)-1])
+	return rows, paging.EncodeCursor(deletedAt, id), true
+}
+
+// decodeTrashCursor parses a cursor (deletedAt, id); an empty cursor starts from the most
+// recently deleted
+func decodeTrashCursor(cursor string) (time.Time, uuid.UUID, error) {
+	if cursor == "" {
+		return time.Time{}, uuid.Nil, nil
+	}
+	return paging.DecodeCursor(cursor)
+}
+
+func (s *trashService) ListSessions(ctx context.Context, in ListTrashInput) (*ListSessionsOutput, error) {
+	afterT, afterID, err := decodeTrashCursor(in.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Query limit+1 is used to determine has_more
+	sessions, err := s.r.ListSessions(ctx, in.ProjectID, s.since(), afterT, afterID, in.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ListSessionsOutput{}
+	out.Items, out.NextCursor, out.HasMore = trashPage(sessions, in.Limit, func(ss model.Session) (time.Time, uuid.UUID) {
+		return ss.DeletedAt.Time, ss.ID
+	})
+	return out, nil
+}
+
+func (s *trashService) ListSpaces(ctx context.Context, in ListTrashInput) (*ListSpacesOutput, error) {
+	afterT, afterID, err := decodeTrashCursor(in.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	spaces, err := s.r.ListSpaces(ctx, in.ProjectID, s.since(), afterT, afterID, in.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ListSpacesOutput{}
+	out.Items, out.NextCursor, out.HasMore = trashPage(spaces, in.Limit, func(sp model.Space) (time.Time, uuid.UUID) {
+		return sp.DeletedAt.Time, sp.ID
+	})
+	return out, nil
+}
+
+func (s *trashService) ListDisks(ctx context.Context, in ListTrashInput) (*ListDisksOutput, error) {
+	afterT, afterID, err := decodeTrashCursor(in.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	disks, err := s.r.ListDisks(ctx, in.ProjectID, s.since(), afterT, afterID, in.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ListDisksOutput{}
+	out.Items, out.NextCursor, out.HasMore = trashPage(disks, in.Limit, func(d *model.Disk) (time.Time, uuid.UUID) {
+		return d.DeletedAt.Time, d.ID
+	})
+	return out, nil
+}
+
+func (s *trashService) RestoreSession(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID) (*model.Session, error) {
+	session, err := s.r.RestoreSession(ctx, projectID, sessionID, s.since())
+	if err != nil {
+		return nil, err
+	}
+	audit.After(ctx, session)
+	return session, nil
+}
+
+func (s *trashService) RestoreSpace(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID) (*model.Space, error) {
+	space, err := s.r.RestoreSpace(ctx, projectID, spaceID, s.since())
+	if err != nil {
+		return nil, err
+	}
+	audit.After(ctx, space)
+	return space, nil
+}
+
+func (s *trashService) RestoreDisk(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID) (*model.Disk, error) {
+	disk, err := s.r.RestoreDisk(ctx, projectID, diskID, s.since())
+	if err != nil {
+		return nil, err
+	}
+	audit.After(ctx, disk)
+	return disk, nil
+}
+
+func (s *trashService) Purge(ctx context.Context) (int, error) {
+	entries, err := s.r.ListExpired(ctx, s.since(), trashPurgeBatch)
+	if err != nil {
+		return 0, fmt.Errorf("list expired trash: %w", err)
+	}
+	purged := 0
+	for _, e := range entries {
+		if err := s.r.Purge(ctx, e); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Restored or purged by someone else since it was listed
+				continue
+			}
+			// The resource stays in the trash, so the next call retries it
+			s.log.Warn("purge trash",
+				zap.String("kind", string(e.Kind)),
+				zap.String("id", e.ID.String()),
+				zap.String("project_id", e.ProjectID.String()),
+				zap.Error(err))
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MockTrashRepo is a mock implementation of TrashRepo
+type MockTrashRepo struct {
+	mock.Mock
+}
+
+func (m *MockTrashRepo) ListSessions(ctx context.Context, projectID uuid.UUID, since time.Time, afterDeletedAt time.Time, afterID uuid.UUID, limit int) ([]model.Session, error) {
+	args := m.Called(ctx, projectID, since, afterDeletedAt, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Session), args.Error(1)
+}
+
+func (m *MockTrashRepo) ListSpaces(ctx context.Context, projectID uuid.UUID, since time.Time, afterDeletedAt time.Time, afterID uuid.UUID, limit int) ([]model.Space, error) {
+	args := m.Called(ctx, projectID, since, afterDeletedAt, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Space), args.Error(1)
+}
+
+func (m *MockTrashRepo) ListDisks(ctx context.Context, projectID uuid.UUID, since time.Time, afterDeletedAt time.Time, afterID uuid.UUID, limit int) ([]*model.Disk, error) {
+	args := m.Called(ctx, projectID, since, afterDeletedAt, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.Disk), args.Error(1)
+}
+
+func (m *MockTrashRepo) RestoreSession(ctx context.Context, projectID uuid.UUID, sessionID uuid.UUID, since time.Time) (*model.Session, error) {
+	args := m.Called(ctx, projectID, sessionID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Session), args.Error(1)
+}
+
+func (m *MockTrashRepo) RestoreSpace(ctx context.Context, projectID uuid.UUID, spaceID uuid.UUID, since time.Time) (*model.Space, error) {
+	args := m.Called(ctx, projectID, spaceID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Space), args.Error(1)
+}
+
+func (m *MockTrashRepo) RestoreDisk(ctx context.Context, projectID uuid.UUID, diskID uuid.UUID, since time.Time) (*model.Disk, error) {
+	args := m.Called(ctx, projectID, diskID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Disk), args.Error(1)
+}
+
+func (m *MockTrashRepo) ListExpired(ctx context.Context, before time.Time, limit int) ([]repo.TrashEntry, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repo.TrashEntry), args.Error(1)
+}
+
+func (m *MockTrashRepo) Purge(ctx context.Context, e repo.TrashEntry) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+// newTestTrashService keeps deleted resources for a week and tells the time as now
+func newTestTrashService(r repo.TrashRepo, now time.Time) *trashService {
+	s := NewTrashService(r, &config.Config{Trash: config.TrashCfg{RetentionDays: 7}}, zap.NewNop()).(*trashService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestTrashService_ListSessions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	since := now.Add(-7 * 24 * time.Hour)
+	projectID := uuid.New()
+
+	sessions := make([]model.Session, 3)
+	for i := range sessions {
+		sessions[i] = model.Session{
+			ID:        uuid.New(),
+			ProjectID: projectID,
+			DeletedAt: gorm.DeletedAt{Time: now.Add(-time.Duration(i) * time.Hour), Valid: true},
+		}
+	}
+
+	t.Run("first page", func(t *testing.T) {
+		r := &MockTrashRepo{}
+		r.On("ListSessions", ctx, projectID, since, time.Time{}, uuid.Nil, 3).Return(sessions, nil)
+
+		out, err := newTestTrashService(r, now).ListSessions(ctx, ListTrashInput{ProjectID: projectID, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, sessions[:2], out.Items)
+		assert.True(t, out.HasMore)
+
+		// The next page starts after the last session of this one
+		afterT, afterID, err := paging.DecodeCursor(out.NextCursor)
+		require.NoError(t, err)
+		assert.True(t, sessions[1].DeletedAt.Time.Equal(afterT))
+		assert.Equal(t, sessions[1].ID, afterID)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		r := &MockTrashRepo{}
+		r.On("ListSessions", ctx, projectID, since, time.Time{}, uuid.Nil, 4).Return(sessions, nil)
+
+		out, err := newTestTrashService(r, now).ListSessions(ctx, ListTrashInput{ProjectID: projectID, Limit: 3})
+		require.NoError(t, err)
+		assert.Len(t, out.Items, 3)
+		assert.False(t, out.HasMore)
+		assert.Empty(t, out.NextCursor)
+	})
+
+	t.Run("bad cursor", func(t *testing.T) {
+		_, err := newTestTrashService(&MockTrashRepo{}, now).ListSessions(ctx, ListTrashInput{ProjectID: projectID, Limit: 2, Cursor: "%%"})
+		assert.ErrorIs(t, err, paging.ErrInvalidCursor)
+	})
+}
+
+func TestTrashService_RestoreDisk(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	since := now.Add(-7 * 24 * time.Hour)
+	projectID, diskID := uuid.New(), uuid.New()
+
+	t.Run("within the retention window", func(t *testing.T) {
+		r := &MockTrashRepo{}
+		r.On("RestoreDisk", ctx, projectID, diskID, since).Return(&model.Disk{ID: diskID, ProjectID: projectID}, nil)
+
+		disk, err := newTestTrashService(r, now).RestoreDisk(ctx, projectID, diskID)
+		require.NoError(t, err)
+		assert.Equal(t, diskID, disk.ID)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("name taken meanwhile", func(t *testing.T) {
+		r := &MockTrashRepo{}
+		r.On("RestoreDisk", ctx, projectID, diskID, since).Return(nil, repo.ErrDiskNameExists)
+
+		_, err := newTestTrashService(r, now).RestoreDisk(ctx, projectID, diskID)
+		assert.ErrorIs(t, err, ErrDiskNameExists)
+	})
+}
+
+func TestTrashService_Purge(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	projectID := uuid.New()
+
+	done := repo.TrashEntry{Kind: repo.TrashSession, ProjectID: projectID, ID: uuid.New()}
+	failing := repo.TrashEntry{Kind: repo.TrashDisk, ProjectID: projectID, ID: uuid.New()}
+	restored := repo.TrashEntry{Kind: repo.TrashSpace, ProjectID: projectID, ID: uuid.New()}
+
+	r := &MockTrashRepo{}
+	r.On("ListExpired", ctx, now.Add(-7*24*time.Hour), trashPurgeBatch).Return([]repo.TrashEntry{failing, restored, done}, nil)
+	r.On("Purge", ctx, failing).Return(errors.New("s3 down"))
+	r.On("Purge", ctx, restored).Return(gorm.ErrRecordNotFound)
+	r.On("Purge", ctx, done).Return(nil)
+
+	purged, err := newTestTrashService(r, now).Purge(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	r.AssertExpectations(t)
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"go.uber.org/zap"
+)
+
+// usageLimitsConfigKey is the key of the project configs that overrides the default usage limits
+const usageLimitsConfigKey = "usage_limits"
+
+// Names of the usage limits, as used in the project configs and in usage reports
+const (
+	UsageLimitStorageBytes    = "storage_bytes"
+	UsageLimitMonthlyMessages = "monthly_messages"
+	UsageLimitMonthlyTokens   = "monthly_tokens"
+)
+
+// usageBuckets are the bucket sizes usage can be reported in. Counters are rolled up per day,
+// so days are the smallest bucket.
+var usageBuckets = map[string]bool{"day": true, "week": true, "month": true}
+
+var (
+	ErrInvalidUsageQuery = errors.New("invalid usage query")
+	// ErrStorageLimitExceeded is returned when a write would take a project over its storage hard limit
+	ErrStorageLimitExceeded = errors.New("storage limit exceeded")
+	// ErrUsageLimitExceeded is returned when a write would take a project over a monthly hard limit
+	ErrUsageLimitExceeded = errors.New("monthly usage limit exceeded")
+)
+
+// UsageLimitError describes the hard limit a write was rejected by. It wraps
+// ErrStorageLimitExceeded or ErrUsageLimitExceeded.
+type UsageLimitError struct {
+	Limit  string
+	Used   int64
+	Adding int64
+	Hard   int64
+	// ResetAt is when a monthly limit starts counting from zero again
+	ResetAt *time.Time
+}
+
+func (e *UsageLimitError) Error() string {
+	return fmt.Sprintf("%v: %s is at %d of a hard limit of %d and the request adds %d", e.Unwrap(), e.Limit, e.Used, e.Hard, e.Adding)
+}
+
+func (e *UsageLimitError) Unwrap() error {
+	if e.Limit == UsageLimitStorageBytes {
+		return ErrStorageLimitExceeded
+	}
+	return ErrUsageLimitExceeded
+}
+
+// UsageLimit limits one usage counter; 0 means unlimited
+type UsageLimit struct {
+	Soft int64 `json:"soft"`
+	Hard int64 `json:"hard"`
+}
+
+type UsageLimits struct {
+	StorageBytes    UsageLimit `json:"storage_bytes"`
+	MonthlyMessages UsageLimit `json:"monthly_messages"`
+	MonthlyTokens   UsageLimit `json:"monthly_tokens"`
+}
+
+func (l UsageLimits) unlimited() bool {
+	return l == UsageLimits{}
+}
+
+// UsageDemand is what a write is about to add to the usage of a project
+type UsageDemand struct {
+	Messages int64
+	Tokens   int64
+	Bytes    int64
+}
+
+// UsageLimitStatus is the usage of a project against one of its limits
+type UsageLimitStatus struct {
+	Name         string     `json:"name"`
+	Used         int64      `json:"used"`
+	Soft         int64      `json:"soft"`
+	Hard         int64      `json:"hard"`
+	SoftExceeded bool       `json:"soft_exceeded"`
+	HardExceeded bool       `json:"hard_exceeded"`
+	ResetAt      *time.Time `json:"reset_at,omitempty"`
+}
+
+type UsageService interface {
+	// Check returns a *UsageLimitError when demand would take the project over a hard limit.
+	// Crossing a soft limit is logged but allowed.
+	Check(ctx context.Context, projectID uuid.UUID, demand UsageDemand) error
+	// Record adds to the usage counters of a project, by metric tag. The write it accounts
+	// for already happened, so failures are logged rather than returned.
+	Record(ctx context.Context, projectID uuid.UUID, counters map[string]int64)
+	Get(ctx context.Context, in GetUsageInput) (*ProjectUsage, error)
+	// Summary returns the current storage of a project and its usage against each of its limits
+	Summary(ctx context.Context, projectID uuid.UUID) (*UsageSummary, error)
+}
+
+type usageService struct {
+	r        repo.UsageRepo
+	defaults UsageLimits
+	log      *zap.Logger
+	now      func() time.Time
+}
+
+func NewUsageService(r repo.UsageRepo, cfg *config.Config, log *zap.Logger) UsageService {
+	return &usageService{
+		r: r,
+		defaults: UsageLimits{
+			StorageBytes:    UsageLimit{Soft: cfg.Usage.StorageBytes.Soft, Hard: cfg.Usage.StorageBytes.Hard},
+			MonthlyMessages: UsageLimit{Soft: cfg.Usage.MonthlyMessages.Soft, Hard: cfg.Usage.MonthlyMessages.Hard},
+			MonthlyTokens:   UsageLimit{Soft: cfg.Usage.MonthlyTokens.Soft, Hard: cfg.Usage.MonthlyTokens.Hard},
+		},
+		log: log,
+		now: time.Now,
+	}
+}
+
+// limits returns the default limits overridden by the project configs
+func (s *usageService) limits(ctx context.Context, projectID uuid.UUID) (UsageLimits, error) {
+	limits := s.defaults
+	configs, err := s.r.GetProjectConfigs(ctx, projectID)
+	if err != nil {
+		return limits, fmt.Errorf("get project configs: %w", err)
+	}
+	override, ok := configs[usageLimitsConfigKey]
+	if !ok || override == nil {
+		return limits, nil
+	}
+	raw, err := sonic.Marshal(override)
+	if err != nil {
+		return limits, fmt.Errorf("encode %s: %w", usageLimitsConfigKey, err)
+	}
+	if err := sonic.Unmarshal(raw, &limits); err != nil {
+		return limits, fmt.Errorf("decode %s: %w", usageLimitsConfigKey, err)
+	}
+	return limits, nil
+}
+
+// monthStart returns the start of the calendar month (UTC) of t and of the month after it
+func monthStart(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// status measures the usage of a project against each of its limits
+func (s *usageService) status(ctx context.Context, projectID uuid.UUID, limits UsageLimits) (*repo.StorageUsage, []UsageLimitStatus, error) {
+	storage, err := s.r.Storage(ctx, projectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get storage usage: %w", err)
+	}
+	start, next := monthStart(s.now())
+	month, err := s.r.Totals(ctx, projectID, []string{model.MetricTagMessageStored, model.MetricTagTokenIngested}, start)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get monthly usage: %w", err)
+	}
+
+	status := func(name string, used int64, limit UsageLimit, resetAt *time.Time) UsageLimitStatus {
+		return UsageLimitStatus{
+			Name:         name,
+			Used:         used,
+			Soft:         limit.Soft,
+			Hard:         limit.Hard,
+			SoftExceeded: limit.Soft > 0 && used > limit.Soft,
+			HardExceeded: limit.Hard > 0 && used > limit.Hard,
+			ResetAt:      resetAt,
+		}
+	}
+	return storage, []UsageLimitStatus{
+		status(UsageLimitStorageBytes, storage.Bytes, limits.StorageBytes, nil),
+		status(UsageLimitMonthlyMessages, month[model.MetricTagMessageStored], limits.MonthlyMessages, &next),
+		status(UsageLimitMonthlyTokens, month[model.MetricTagTokenIngested], limits.MonthlyTokens, &next),
+	}, nil
+}
+
+func (s *usageService) Check(ctx context.Context, projectID uuid.UUID, demand UsageDemand) error {
+	limits, err := s.limits(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	if limits.unlimited() {
+		return nil
+	}
+	_, statuses, err := s.status(ctx, projectID, limits)
+	if err != nil {
+		return err
+	}
+
+	adding := map[string]int64{
+		UsageLimitStorageBytes:    demand.Bytes,
+		UsageLimitMonthlyMessages: demand.Messages,
+		UsageLimitMonthlyTokens:   demand.Tokens,
+	}
+	for _, st := range statuses {
+		add := adding[st.Name]
+		if add <= 0 {
+			continue
+		}
+		if st.Hard > 0 && st.Used+add > st.Hard {
+			return &UsageLimitError{Limit: st.Name, Used: st.Used, Adding: add, Hard: st.Hard, ResetAt: st.ResetAt}
+		}
+		if st.Soft > 0 && st.Used+add > st.Soft {
+			s.log.Warn("usage soft limit exceeded",
+				zap.String("project_id", projectID.String()),
+				zap.String("limit", st.Name),
+				zap.Int64("used", st.Used),
+				zap.Int64("adding", add),
+				zap.Int64("soft", st.Soft),
+			)
+		}
+	}
+	return nil
+}
+
+func (s *usageService) Record(ctx context.Context, projectID uuid.UUID, counters map[string]int64) {
+	tags := make([]string, 0, len(counters))
+	for tag, n := range counters {
+		if n != 0 {
+			tags = append(tags, tag)
+		}
+	}
+	// A fixed order keeps concurrent writers from waiting on each other's locks in turn
+	sort.Strings(tags)
+	for _, tag := range tags {
+		if err := s.r.Increment(ctx, projectID, tag, counters[tag]); err != nil {
+			s.log.Error("record usage",
+				zap.String("project_id", projectID.String()),
+				zap.String("tag", tag),
+				zap.Int64("increment", counters[tag]),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+type GetUsageInput struct {
+	ProjectID uuid.UUID
+	From      time.Time
+	To        time.Time
+	Bucket    string // day, week or month
+}
+
+// UsageBucket holds the counters recorded within one time bucket, by metric tag
+type UsageBucket struct {
+	Start    time.Time        `json:"start"`
+	Counters map[string]int64 `json:"counters"`
+}
+
+type ProjectUsage struct {
+	From    time.Time     `json:"from"`
+	To      time.Time     `json:"to"`
+	Bucket  string        `json:"bucket"`
+	Buckets []UsageBucket `json:"buckets"`
+	// Totals sums the counters of all buckets, by metric tag
+	Totals  map[string]int64   `json:"totals"`
+	Storage repo.StorageUsage  `json:"storage"`
+	Limits  []UsageLimitStatus `json:"limits"`
+}
+
+func (s *usageService) Get(ctx context.Context, in GetUsageInput) (*ProjectUsage, error) {
+	if !usageBuckets[in.Bucket] {
+		return nil, fmt.Errorf("%w: bucket must be day, week or month", ErrInvalidUsageQuery)
+	}
+	if !in.From.Before(in.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidUsageQuery)
+	}
+
+	rows, err := s.r.Buckets(ctx, in.ProjectID, in.Bucket, in.From, in.To)
+	if err != nil {
+		return nil, fmt.Errorf("get usage buckets: %w", err)
+	}
+	out := &ProjectUsage{
+		From:    in.From,
+		To:      in.To,
+		Bucket:  in.Bucket,
+		Buckets: []UsageBucket{},
+		Totals:  map[string]int64{},
+	}
+	for _, row := range rows {
+		// Rows are ordered by bucket
+		if n := len(out.Buckets); n == 0 || !out.Buckets[n-1].Start.Equal(row.Bucket) {
+			out.Buckets = append(out.Buckets, UsageBucket{Start: row.Bucket, Counters: map[string]int64{}})
+		}
+		out.Buckets[len(out.Buckets)-1].Counters[row.Tag] += row.Total
+		out.Totals[row.Tag] += row.Total
+	}
+
+	limits, err := s.limits(ctx, in.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	storage, statuses, err := s.status(ctx, in.ProjectID, limits)
+	if err != nil {
+		return nil, err
+	}
+	out.Storage = *storage
+	out.Limits = statuses
+	return out, nil
+}
+
+// UsageSummary is the current usage of a project, without the history of Get
+type UsageSummary struct {
+	Storage repo.StorageUsage  `json:"storage"`
+	Limits  []UsageLimitStatus `json:"limits"`
+}
+
+func (s *usageService) Summary(ctx context.Context, projectID uuid.UUID) (*UsageSummary, error) {
+	limits, err := s.limits(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	storage, statuses, err := s.status(ctx, projectID, limits)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageSummary{Storage: *storage, Limits: statuses}, nil
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"mime/multipart"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+type MockUsageRepo struct {
+	mock.Mock
+}
+
+func (m *MockUsageRepo) Increment(ctx context.Context, projectID uuid.UUID, tag string, n int64) error {
+	args := m.Called(ctx, projectID, tag, n)
+	return args.Error(0)
+}
+
+func (m *MockUsageRepo) Totals(ctx context.Context, projectID uuid.UUID, tags []string, from time.Time) (map[string]int64, error) {
+	args := m.Called(ctx, projectID, tags, from)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockUsageRepo) Buckets(ctx context.Context, projectID uuid.UUID, bucket string, from time.Time, to time.Time) ([]repo.UsageBucket, error) {
+	args := m.Called(ctx, projectID, bucket, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repo.UsageBucket), args.Error(1)
+}
+
+func (m *MockUsageRepo) Storage(ctx context.Context, projectID uuid.UUID) (*repo.StorageUsage, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repo.StorageUsage), args.Error(1)
+}
+
+func (m *MockUsageRepo) GetProjectConfigs(ctx context.Context, projectID uuid.UUID) (datatypes.JSONMap, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(datatypes.JSONMap), args.Error(1)
+}
+
+type MockUsageService struct {
+	mock.Mock
+}
+
+func (m *MockUsageService) Check(ctx context.Context, projectID uuid.UUID, demand UsageDemand) error {
+	args := m.Called(ctx, projectID, demand)
+	return args.Error(0)
+}
+
+func (m *MockUsageService) Record(ctx context.Context, projectID uuid.UUID, counters map[string]int64) {
+	m.Called(ctx, projectID, counters)
+}
+
+func (m *MockUsageService) Get(ctx context.Context, in GetUsageInput) (*ProjectUsage, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ProjectUsage), args.Error(1)
+}
+
+func (m *MockUsageService) Summary(ctx context.Context, projectID uuid.UUID) (*UsageSummary, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*UsageSummary), args.Error(1)
+}
+
+func newTestUsageService(r repo.UsageRepo, usage config.UsageCfg, now time.Time) *usageService {
+	s := NewUsageService(r, &config.Config{Usage: usage}, zap.NewNop()).(*usageService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestUsageService_Check(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	monthStart := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	monthTags := []string{model.MetricTagMessageStored, model.MetricTagTokenIngested}
+
+	t.Run("unlimited projects skip measuring usage", func(t *testing.T) {
+		r := &MockUsageRepo{}
+		r.On("GetProjectConfigs", ctx, projectID).Return(datatypes.JSONMap{}, nil)
+
+		err := newTestUsageService(r, config.UsageCfg{}, now).Check(ctx, projectID, UsageDemand{Messages: 1, Bytes: 1 << 30})
+
+		assert.NoError(t, err)
+		r.AssertExpectations(t)
+	})
+
+	t.Run("storage hard limit", func(t *testing.T) {
+		r := &MockUsageRepo{}
+		r.On("GetProjectConfigs", ctx, projectID).Return(datatypes.JSONMap{}, nil)
+		r.On("Storage", ctx, projectID).Return(&repo.StorageUsage{Bytes: 900, Assets: 3}, nil)
+		r.On("Totals", ctx, projectID, monthTags, monthStart).Return(map[string]int64{}, nil)
+
+		s := newTestUsageService(r, config.UsageCfg{StorageBytes: config.UsageLimitCfg{Hard: 1000}}, now)
+
+		assert.NoError(t, s.Check(ctx, projectID, UsageDemand{Bytes: 100}))
+
+		err := s.Check(ctx, projectID, UsageDemand{Bytes: 101})
+		assert.ErrorIs(t, err, ErrStorageLimitExceeded)
+		var limitErr *UsageLimitError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, UsageLimitStorageBytes, limitErr.Limit)
+		assert.Equal(t, int64(900), limitErr.Used)
+		assert.Nil(t, limitErr.ResetAt)
+	})
+
+	t.Run("project configs override the monthly limits", func(t *testing.T) {
+		r := &MockUsageRepo{}
+		r.On("GetProjectConfigs", ctx, projectID).Return(datatypes.JSONMap{
+			"usage_limits": map[string]interface{}{"monthly_messages": map[string]interface{}{"hard": 10}},
+		}, nil)
+		r.On("Storage", ctx, projectID).Return(&repo.StorageUsage{}, nil)
+		r.On("Totals", ctx, projectID, monthTags, monthStart).Return(map[string]int64{model.MetricTagMessageStored: 10}, nil)
+
+		// The default soft limit is kept, the hard limit comes from the project
+		s := newTestUsageService(r, config.UsageCfg{MonthlyMessages: config.UsageLimitCfg{Soft: 5, Hard: 1000}}, now)
+		err := s.Check(ctx, projectID, UsageDemand{Messages: 1})
+
+		assert.ErrorIs(t, err, ErrUsageLimitExceeded)
+		var limitErr *UsageLimitError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, UsageLimitMonthlyMessages, limitErr.Limit)
+		assert.Equal(t, int64(10), limitErr.Hard)
+		require.NotNil(t, limitErr.ResetAt)
+		assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), *limitErr.ResetAt)
+	})
+
+	t.Run("soft limits only warn", func(t *testing.T) {
+		r := &MockUsageRepo{}
+		r.On("GetProjectConfigs", ctx, projectID).Return(datatypes.JSONMap{}, nil)
+		r.On("Storage", ctx, projectID).Return(&repo.StorageUsage{}, nil)
+		r.On("Totals", ctx, projectID, monthTags, monthStart).Return(map[string]int64{model.MetricTagTokenIngested: 5000}, nil)
+
+		s := newTestUsageService(r, config.UsageCfg{MonthlyTokens: config.UsageLimitCfg{Soft: 1000}}, now)
+
+		assert.NoError(t, s.Check(ctx, projectID, UsageDemand{Messages: 1, Tokens: 20}))
+	})
+}
+
+func TestUsageService_Record(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+
+	r := &MockUsageRepo{}
+	r.On("Increment", ctx, projectID, model.MetricTagArtifactWrite, int64(1)).Return(nil).Once()
+	r.On("Increment", ctx, projectID, model.MetricTagBytesIngested, int64(42)).Return(assert.AnError).Once()
+
+	newTestUsageService(r, config.UsageCfg{}, time.Now()).Record(ctx, projectID, map[string]int64{
+		model.MetricTagArtifactWrite:  1,
+		model.MetricTagArtifactDelete: 0,
+		model.MetricTagBytesIngested:  42,
+	})
+
+	r.AssertExpectations(t)
+}
+
+func TestUsageService_Get(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan := from
+	feb := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("groups counters by bucket", func(t *testing.T) {
+		r := &MockUsageRepo{}
+		r.On("Buckets", ctx, projectID, "month", from, now).Return([]repo.UsageBucket{
+			{Bucket: jan, Tag: model.MetricTagMessageStored, Total: 3},
+			{Bucket: jan, Tag: model.MetricTagTokenIngested, Total: 120},
+			{Bucket: feb, Tag: model.MetricTagMessageStored, Total: 2},
+		}, nil)
+		r.On("GetProjectConfigs", ctx, projectID).Return(datatypes.JSONMap{}, nil)
+		r.On("Storage", ctx, projectID).Return(&repo.StorageUsage{Bytes: 2048, Assets: 2}, nil)
+		r.On("Totals", ctx, projectID, mock.Anything, mock.Anything).Return(map[string]int64{model.MetricTagMessageStored: 7}, nil)
+
+		s := newTestUsageService(r, config.UsageCfg{StorageBytes: config.UsageLimitCfg{Soft: 1024}}, now)
+		out, err := s.Get(ctx, GetUsageInput{ProjectID: projectID, From: from, To: now, Bucket: "month"})
+
+		require.NoError(t, err)
+		require.Len(t, out.Buckets, 2)
+		assert.Equal(t, jan, out.Buckets[0].Start)
+		assert.Equal(t, map[string]int64{model.MetricTagMessageStored: 3, model.MetricTagTokenIngested: 120}, out.Buckets[0].Counters)
+		assert.Equal(t, map[string]int64{model.MetricTagMessageStored: 2}, out.Buckets[1].Counters)
+		assert.Equal(t, int64(5), out.Totals[model.MetricTagMessageStored])
+		assert.Equal(t, repo.StorageUsage{Bytes: 2048, Assets: 2}, out.Storage)
+
+		require.Len(t, out.Limits, 3)
+		assert.Equal(t, UsageLimitStorageBytes, out.Limits[0].Name)
+		assert.True(t, out.Limits[0].SoftExceeded)
+		assert.False(t, out.Limits[0].HardExceeded)
+		assert.Equal(t, int64(7), out.Limits[1].Used)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		s := newTestUsageService(&MockUsageRepo{}, config.UsageCfg{}, now)
+
+		_, err := s.Get(ctx, GetUsageInput{ProjectID: projectID, From: from, To: now, Bucket: "hour"})
+		assert.ErrorIs(t, err, ErrInvalidUsageQuery)
+
+		_, err = s.Get(ctx, GetUsageInput{ProjectID: projectID, From: now, To: from, Bucket: "day"})
+		assert.ErrorIs(t, err, ErrInvalidUsageQuery)
+	})
+}
+
+func TestUsageLimits_Enforced(t *testing.T) {
+	ctx := context.Background()
+	projectID := uuid.New()
+	limitErr := &UsageLimitError{Limit: UsageLimitStorageBytes, Used: 10, Adding: 600, Hard: 100}
+
+	t.Run("artifact uploads over the storage limit are rejected before uploading", func(t *testing.T) {
+		diskID := uuid.New()
+		mockRepo := &MockArtifactRepo{}
+		mockRepo.On("GetDiskUsage", mock.Anything, diskID).Return(&model.DiskUsage{}, nil)
+		usage := &MockUsageService{}
+		usage.On("Check", mock.Anything, projectID, UsageDemand{Bytes: 600}).Return(limitErr)
+
+		// A nil S3 client makes any upload attempt panic
+		_, err := NewArtifactService(mockRepo, nil, nil, nil, usage).Create(ctx, CreateArtifactInput{
+			ProjectID:  projectID,
+			DiskID:     diskID,
+			Path:       "/",
+			Filename:   "big.bin",
+			FileHeader: &multipart.FileHeader{Filename: "big.bin", Size: 600},
+		})
+
+		assert.ErrorIs(t, err, ErrStorageLimitExceeded)
+		usage.AssertExpectations(t)
+	})
+
+	t.Run("messages over a monthly limit are rejected before uploading", func(t *testing.T) {
+		usage := &MockUsageService{}
+		usage.On("Check", mock.Anything, projectID, mock.MatchedBy(func(d UsageDemand) bool {
+			return d.Messages == 1 && d.Bytes == 300
+		})).Return(&UsageLimitError{Limit: UsageLimitMonthlyMessages, Used: 5, Adding: 1, Hard: 5})
+
+		s := NewSessionService(&MockSessionRepo{}, &MockAssetReferenceRepo{}, zap.NewNop(), nil, nil, &config.Config{}, nil, usage)
+		_, err := s.StoreMessage(ctx, StoreMessageInput{
+			ProjectID: projectID,
+			SessionID: uuid.New(),
+			Role:      "user",
+			Parts:     []PartIn{{Type: "text", Text: "hello"}, {Type: "image", FileField: "img"}},
+			Files:     map[string]*multipart.FileHeader{"img": {Filename: "a.png", Size: 300}},
+		})
+
+		assert.ErrorIs(t, err, ErrUsageLimitExceeded)
+		usage.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/webhook"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/pkg/audit"
+	"github.com/memodb-io/Acontext/internal/pkg/paging"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// webhookSecretPrefix marks webhook signing secrets, so they are not mistaken for API keys
+const webhookSecretPrefix = "whsec_"
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookDisabled         = errors.New("webhook is disabled")
+	ErrInvalidWebhook          = errors.New("invalid webhook request")
+)
+
+type WebhookService interface {
+	// Create adds an endpoint to a project. Its signing secret is only returned here.
+	Create(ctx context.Context, in CreateWebhookInput) (*CreateWebhookOutput, error)
+	List(ctx context.Context, projectID uuid.UUID) ([]*model.WebhookEndpoint, error)
+	Get(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (*model.WebhookEndpoint, error)
+	Update(ctx context.Context, in UpdateWebhookInput) (*model.WebhookEndpoint, error)
+	// Delete removes an endpoint with its delivery log; pending deliveries are dropped
+	Delete(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error
+
+	ListDeliveries(ctx context.Context, in ListWebhookDeliveriesInput) (*ListWebhookDeliveriesOutput, error)
+	// ReplayDelivery sends the event of a delivery to its endpoint again, as a new delivery
+	ReplayDelivery(ctx context.Context, projectID uuid.UUID, webhookID uuid.UUID, deliveryID uuid.UUID) (*model.WebhookDelivery, error)
+
+	// Dispatch fans the events waiting in the outbox out to deliveries and returns how many events it took
+	Dispatch(ctx context.Context) (int, error)
+	// Deliver makes an attempt of the deliveries that are due and returns how many it attempted
+	Deliver(ctx context.Context) (int, error)
+}
+
+type webhookService struct {
+	r      repo.WebhookRepo
+	client *webhook.Client
+	cfg    config.WebhookCfg
+	log    *zap.Logger
+	now    func() time.Time
+}
+
+func NewWebhookService(r repo.WebhookRepo, cfg *config.Config, log *zap.Logger) WebhookService {
+	return &webhookService{
+		r:      r,
+		client: webhook.NewClient(time.Duration(cfg.Webhook.TimeoutSec)*time.Second, cfg.Webhook.AllowPrivateNetworks),
+		cfg:    cfg.Webhook,
+		log:    log,
+		now:    time.Now,
+	}
+}
+
+type CreateWebhookInput struct {
+	ProjectID   uuid.UUID
+	URL         string
+	Description string
+	EventTypes  []model.WebhookEventType
+	Enabled     bool
+}
+
+type CreateWebhookOutput struct {
+	Webhook *model.WebhookEndpoint `json:"webhook"`
+	Secret  string                 `json:"secret"` // Only returned at creation
+}
+
+// UpdateWebhookInput changes the fields that are not nil
+type UpdateWebhookInput struct {
+	ProjectID   uuid.UUID
+	ID          uuid.UUID
+	URL         *string
+	Description *string
+	EventTypes  []model.WebhookEventType
+	Enabled     *bool
+}
+
+type ListWebhookDeliveriesInput struct {
+	ProjectID uuid.UUID
+	WebhookID uuid.UUID
+	Status    string
+	Limit     int
+	Cursor    string
+	TimeDesc  bool
+}
+
+type ListWebhookDeliveriesOutput struct {
+	Items      []*model.WebhookDelivery `json:"items"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+	HasMore    bool                     `json:"has_more"`
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: invalid url: %v", ErrInvalidWebhook, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: url must not contain credentials", ErrInvalidWebhook)
+	}
+	return nil
+}
+
+// webhookEventTypes validates types and removes duplicates
+func webhookEventTypes(types []model.WebhookEventType) ([]model.WebhookEventType, error) {
+	if len(types) == 0 {
+		return nil, fmt.Errorf("%w: event_types is required", ErrInvalidWebhook)
+	}
+	out := make([]model.WebhookEventType, 0, len(types))
+	seen := make(map[model.WebhookEventType]bool, len(types))
+	for _, t := range types {
+		if !model.IsValidWebhookEventType(t) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func generateWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func (s *webhookService) Create(ctx context.Context, in CreateWebhookInput) (*CreateWebhookOutput, error) {
+	if err := validateWebhookURL(in.URL); err != nil {
+		return nil, err
+	}
+	types, err := webhookEventTypes(in.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &model.WebhookEndpoint{
+		ProjectID:   in.ProjectID,
+		URL:         in.URL,
+		Description: in.Description,
+		EventTypes:  datatypes.NewJSONSlice(types),
+		Enabled:     in.Enabled,
+		Secret:      secret,
+	}
+	if err := s.r.Create(ctx, w); err != nil {
+		return nil, fmt.Errorf("create webhook: %w", err)
+	}
+	audit.After(ctx, w)
+	return &CreateWebhookOutput{Webhook: w, Secret: secret}, nil
+}
+
+func (s *webhookService) List(ctx context.Context, projectID uuid.UUID) ([]*model.WebhookEndpoint, error) {
+	return s.r.List(ctx, projectID)
+}
+
+func (s *webhookService) Get(ctx context.Context, projectID uuid.UUID, id uuid.UUID) (*model.WebhookEndpoint, error) {
+	w, err := s.r.Get(ctx, projectID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *webhookService) Update(ctx context.Context, in UpdateWebhookInput) (*model.WebhookEndpoint, error) {
+	w, err := s.Get(ctx, in.ProjectID, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.URL != nil {
+		if err := validateWebhookURL(*in.URL); err != nil {
+			return nil, err
+		}
+		w.URL = *in.URL
+	}
+	if in.Description != nil {
+		w.Description = *in.Description
+	}
+	if in.EventTypes != nil {
+		types, err := webhookEventTypes(in.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+		w.EventTypes = datatypes.NewJSONSlice(types)
+	}
+	if in.Enabled != nil {
+		w.Enabled = *in.Enabled
+	}
+
+	if err := s.r.Update(ctx, w); err != nil {
+		return nil, fmt.Errorf("update webhook: %w", err)
+	}
+	audit.After(ctx, w)
+	return w, nil
+}
+
+func (s *webhookService) Delete(ctx context.Context, projectID uuid.UUID, id uuid.UUID) error {
+	if err := s.r.Delete(ctx, projectID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, in ListWebhookDeliveriesInput) (*ListWebhookDeliveriesOutput, error) {
+	if _, err := s.Get(ctx, in.ProjectID, in.WebhookID); err != nil {
+		return nil, err
+	}
+
+	// Parse cursor (createdAt, id); an empty cursor indicates starting from the first delivery
+	var afterT time.Time
+	var afterID uuid.UUID
+	if in.Cursor != "" {
+		var err error
+		afterT, afterID, err = paging.DecodeCursor(in.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+		}
+	}
+
+	// Query limit+1 is used to determine has_more
+	deliveries, err := s.r.ListDeliveriesWithCursor(ctx, in.WebhookID, in.Status, afterT, afterID, in.Limit+1, in.TimeDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ListWebhookDeliveriesOutput{
+		Items:   deliveries,
+		HasMore: false,
+	}
+	if len(deliveries) > in.Limit {
+		out.HasMore = true
+		out.Items = deliveries[:in.Limit]
+		last := out.Items[len(out.Items)-1]
+		out.NextCursor = paging.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return out, nil
+}
+
+func (s *webhookService) ReplayDelivery(ctx context.Context, projectID uuid.UUID, webhookID uuid.UUID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	w, err := s.Get(ctx, projectID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !w.Enabled {
+		return nil, ErrWebhookDisabled
+	}
+	original, err := s.r.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	d := &model.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: s.now(),
+		ReplayOfID:    &original.ID,
+	}
+	if err := s.r.CreateDelivery(ctx, d); err != nil {
+		return nil, fmt.Errorf("replay webhook delivery: %w", err)
+	}
+	audit.After(ctx, d)
+	return d, nil
+}
+
+func (s *webhookService) Dispatch(ctx context.Context) (int, error) {
+	now := s.now()
+	return s.r.DispatchOutbox(ctx, s.cfg.BatchSize, func(e *model.WebhookOutbox, w *model.WebhookEndpoint) (*model.WebhookDelivery, error) {
+		payload, err := sonic.Marshal(model.WebhookEvent{
+			ID:        e.EventID,
+			Type:      e.EventType,
+			ProjectID: e.ProjectID,
+			CreatedAt: e.CreatedAt,
+			Data:      e.Data,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("marshal webhook event: %w", err)
+		}
+		return &model.WebhookDelivery{
+			WebhookID:     w.ID,
+			EventID:       e.EventID,
+			EventType:     e.EventType,
+			Payload:       datatypes.JSON(payload),
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		}, nil
+	})
+}
+
+// retryDelay is the wait after the given number of failed attempts
+func (s *webhookService) retryDelay(attempts int) time.Duration {
+	limit := time.Duration(s.cfg.RetryMaxSec) * time.Second
+	delay := time.Duration(s.cfg.RetryBaseSec) * time.Second
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+func (s *webhookService) Deliver(ctx context.Context) (int, error) {
+	// A claimed delivery is retried if it is not finished within its lease
+	lease := time.Duration(s.cfg.TimeoutSec)*time.Second + 30*time.Second
+	deliveries, err := s.r.ClaimDueDeliveries(ctx, s.now(), lease, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range deliveries {
+		wg.Add(1)
+		go func(d *model.WebhookDelivery) {
+			defer wg.Done()
+			s.attempt(ctx, d)
+			if err := s.r.FinishAttempt(context.WithoutCancel(ctx), d); err != nil {
+				s.log.Sugar().Errorw("webhook: save delivery attempt", "delivery_id", d.ID, "err", err)
+			}
+		}(d)
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// attempt sends d to its endpoint and records the outcome on d
+func (s *webhookService) attempt(ctx context.Context, d *model.WebhookDelivery) {
+	now := s.now()
+	d.LastAttemptAt = &now
+	d.ResponseStatus = nil
+	d.ResponseBody = ""
+	d.Error = ""
+
+	if !d.Webhook.Enabled {
+		// Pending deliveries of a disabled endpoint are given up; they can be replayed once it is enabled
+		d.Status = model.WebhookDeliveryFailed
+		d.Error = ErrWebhookDisabled.Error()
+		return
+	}
+
+	resp, err := s.client.Send(ctx, webhook.Request{
+		URL:        d.Webhook.URL,
+		Secret:     d.Webhook.Secret,
+		DeliveryID: d.ID.String(),
+		EventID:    d.EventID.String(),
+		EventType:  string(d.EventType),
+		Body:       d.Payload,
+	})
+	if resp != nil {
+		d.ResponseStatus = &resp.Status
+		d.ResponseBody = resp.Body
+		if resp.OK() {
+			d.Status = model.WebhookDeliverySucceeded
+			d.DeliveredAt = &now
+			return
+		}
+		err = fmt.Errorf("endpoint answered %d", resp.Status)
+	}
+	d.Error = err.Error()
+	if d.Attempts >= s.cfg.MaxAttempts {
+		d.Status = model.WebhookDeliveryFailed
+		return
+	}
+	d.NextAttemptAt = now.Add(s.retryDelay(d.Attempts))
+}
@@ -0,0 +1,126 @@
+// Package apierr is the catalog of the errors the API answers with. Every error response
+// names one of its codes, which clients can branch on: codes are stable, while the detail of
+// a response is free text that may change.
+//
+// The SDK error types are generated from the catalog; run go generate after changing it.
+package apierr
+
+//go:generate go run ../../../cmd/errcodes -py ../../../../../../client/acontext-py/src/acontext/error_codes.py -ts ../../../../../../client/acontext-ts/src/error-codes.ts
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Code is a kind of error. ID is the machine-readable code, Status the HTTP status it is
+// answered with and Title a short summary that does not change from one response to another.
+type Code struct {
+	ID     string `json:"code"`
+	Status int    `json:"status"`
+	Title  string `json:"title"`
+}
+
+func (c Code) String() string {
+	return c.ID
+}
+
+var catalog = map[string]Code{}
+
+func register(id string, status int, title string) Code {
+	if _, ok := catalog[id]; ok {
+		panic(fmt.Sprintf("apierr: code %q is registered twice", id))
+	}
+	c := Code{ID: id, Status: status, Title: title}
+	catalog[id] = c
+	return c
+}
+
+// Lookup returns the code with id
+func Lookup(id string) (Code, bool) {
+	c, ok := catalog[id]
+	return c, ok
+}
+
+// All returns every code, sorted by status and then by ID
+func All() []Code {
+	out := make([]Code, 0, len(catalog))
+	for _, c := range catalog {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Status != out[j].Status {
+			return out[i].Status < out[j].Status
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// General codes, answered when no more specific code applies
+var (
+	InvalidRequest   = register("invalid_request", http.StatusBadRequest, "Invalid request")
+	Unauthorized     = register("unauthorized", http.StatusUnauthorized, "Unauthorized")
+	Forbidden        = register("forbidden", http.StatusForbidden, "Forbidden")
+	NotFound         = register("not_found", http.StatusNotFound, "Not found")
+	Conflict         = register("conflict", http.StatusConflict, "Conflict")
+	PayloadTooLarge  = register("payload_too_large", http.StatusRequestEntityTooLarge, "Payload too large")
+	ValidationFailed = register("validation_failed", http.StatusUnprocessableEntity, "Validation failed")
+	RateLimited      = register("rate_limited", http.StatusTooManyRequests, "Too many requests")
+	Internal         = register("internal_error", http.StatusInternalServerError, "Internal server error")
+	NotImplemented   = register("not_implemented", http.StatusNotImplemented, "Not implemented")
+)
+
+// Resources that do not exist, or belong to another project
+var (
+	ProjectNotFound         = register("project_not_found", http.StatusNotFound, "Project not found")
+	APIKeyNotFound          = register("api_key_not_found", http.StatusNotFound, "API key not found")
+	SpaceNotFound           = register("space_not_found", http.StatusNotFound, "Space not found")
+	SessionNotFound         = register("session_not_found", http.StatusNotFound, "Session not found")
+	BlockNotFound           = register("block_not_found", http.StatusNotFound, "Block not found")
+	DiskNotFound            = register("disk_not_found", http.StatusNotFound, "Disk not found")
+	ArtifactNotFound        = register("artifact_not_found", http.StatusNotFound, "Artifact not found")
+	UploadNotFound          = register("upload_not_found", http.StatusNotFound, "Upload not found")
+	ShareNotFound           = register("share_not_found", http.StatusNotFound, "Share link not found")
+	WebhookNotFound         = register("webhook_not_found", http.StatusNotFound, "Webhook not found")
+	WebhookDeliveryNotFound = register("webhook_delivery_not_found", http.StatusNotFound, "Webhook delivery not found")
+	ObjectNotFound          = register("object_not_found", http.StatusNotFound, "Object not found")
+)
+
+// Requests that the current state of a resource does not allow
+var (
+	ArtifactPathConflict  = register("artifact_path_conflict", http.StatusConflict, "An artifact already exists at this path")
+	DiskNameConflict      = register("disk_name_conflict", http.StatusConflict, "Disk name already exists")
+	APIKeyUnavailable     = register("api_key_unavailable", http.StatusConflict, "API key is no longer usable")
+	DefaultProject        = register("default_project", http.StatusConflict, "The default project cannot be changed this way")
+	ProjectDeleting       = register("project_deleting", http.StatusConflict, "Project is being deleted")
+	UploadUnavailable     = register("upload_unavailable", http.StatusConflict, "Upload is no longer open")
+	UploadIncomplete      = register("upload_incomplete", http.StatusConflict, "Upload has not finished")
+	WebhookDisabled       = register("webhook_disabled", http.StatusConflict, "Webhook is disabled")
+	IdempotencyInProgress = register("idempotency_in_progress", http.StatusConflict, "A request with this Idempotency-Key is in progress")
+)
+
+// Requests refused by access rules and limits
+var (
+	ScopeForbidden       = register("scope_forbidden", http.StatusForbidden, "API key scopes do not allow this request")
+	InvalidSignature     = register("invalid_signature", http.StatusForbidden, "Invalid or expired signature")
+	ShareReadOnly        = register("share_read_only", http.StatusForbidden, "Share link is read-only")
+	OutsideShare         = register("outside_share", http.StatusForbidden, "Path is outside the shared directory")
+	StorageLimitExceeded = register("storage_limit_exceeded", http.StatusForbidden, "Storage limit exceeded")
+	DiskQuotaExceeded    = register("disk_quota_exceeded", http.StatusRequestEntityTooLarge, "Disk quota exceeded")
+	QuotaExceeded        = register("quota_exceeded", http.StatusTooManyRequests, "Monthly usage limit exceeded")
+	ConcurrencyLimited   = register("concurrency_limited", http.StatusTooManyRequests, "Too many concurrent requests")
+)
+
+// Requests that are well-formed but cannot be applied
+var (
+	InvalidConfigs       = register("invalid_configs", http.StatusUnprocessableEntity, "Invalid configs")
+	InvalidEditStrategy  = register("invalid_edit_strategy", http.StatusUnprocessableEntity, "Invalid edit strategy")
+	UploadMismatch       = register("upload_mismatch", http.StatusUnprocessableEntity, "Uploaded file does not match the declared size or sha256")
+	IdempotencyKeyReused = register("idempotency_key_reused", http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+)
+
+// Features the server is not set up for
+var (
+	DirectUploadUnsupported = register("direct_upload_unsupported", http.StatusNotImplemented, "Direct uploads are not supported")
+)
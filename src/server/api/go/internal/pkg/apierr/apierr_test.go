@@ -0,0 +1,36 @@
+package apierr
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog(t *testing.T) {
+	id := regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+	codes := All()
+	assert.Len(t, codes, len(catalog))
+	for i, c := range codes {
+		assert.Regexp(t, id, c.ID)
+		assert.NotEmpty(t, c.Title, c.ID)
+		assert.GreaterOrEqual(t, c.Status, http.StatusBadRequest, c.ID)
+		assert.NotEmpty(t, http.StatusText(c.Status), c.ID)
+		if i > 0 {
+			prev := codes[i-1]
+			assert.True(t, prev.Status < c.Status || prev.Status == c.Status && prev.ID < c.ID, "%s is not sorted", c.ID)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	c, ok := Lookup("session_not_found")
+	assert.True(t, ok)
+	assert.Equal(t, SessionNotFound, c)
+	assert.Equal(t, http.StatusNotFound, c.Status)
+
+	_, ok = Lookup("no_such_code")
+	assert.False(t, ok)
+}
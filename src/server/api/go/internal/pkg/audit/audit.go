@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bytedance/sonic"
+)
+
+const (
+	// maxSummaryString is the longest string kept in a summary; longer ones are cut
+	maxSummaryString = 256
+	// maxSummaryObject is the largest encoded object kept in a summary; larger ones are dropped
+	maxSummaryObject = 2048
+)
+
+// Entry collects what a request changed, for the audit event written once it finishes.
+// The audit middleware puts one in the request context; services report to it with After.
+type Entry struct {
+	mu    sync.Mutex
+	after map[string]any
+}
+
+type entryKey struct{}
+
+// NewContext returns a context that carries e
+func NewContext(ctx context.Context, e *Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, e)
+}
+
+// FromContext returns the entry of the request ctx belongs to, or nil outside an audited request
+func FromContext(ctx context.Context) *Entry {
+	e, _ := ctx.Value(entryKey{}).(*Entry)
+	return e
+}
+
+// After records the state a request left a resource in. It does nothing outside an audited
+// request, so services call it unconditionally.
+func After(ctx context.Context, v any) {
+	e := FromContext(ctx)
+	if e == nil {
+		return
+	}
+	summary := Summary(v)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.after = summary
+}
+
+// AfterSummary returns what was recorded with After, or nil
+func (e *Entry) AfterSummary() map[string]any {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.after
+}
+
+// Summary reduces v to the top-level fields of its JSON form that stay small: scalars,
+// strings cut to 256 characters, and objects of at most 2 KiB. Lists, such as message parts,
+// are left out. Fields hidden from JSON, such as secrets, never make it in.
+func Summary(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	raw, err := sonic.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	if err := sonic.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	summary := make(map[string]any, len(fields))
+	for name, value := range fields {
+		switch value := value.(type) {
+		case []any:
+			continue
+		case string:
+			if len(value) > maxSummaryString {
+				value = value[:maxSummaryString] + "..."
+			}
+			summary[name] = value
+		case map[string]any:
+			if encoded, err := sonic.Marshal(value); err == nil && len(encoded) <= maxSummaryObject {
+				summary[name] = value
+			}
+		default:
+			summary[name] = value
+		}
+	}
+	return summary
+}
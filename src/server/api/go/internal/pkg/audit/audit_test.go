@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type summarized struct {
+	ID      string         `json:"id"`
+	Secret  string         `json:"-"`
+	Count   int            `json:"count"`
+	Note    string         `json:"note"`
+	Parts   []string       `json:"parts"`
+	Configs map[string]any `json:"configs"`
+	Blob    map[string]any `json:"blob"`
+}
+
+func TestSummary(t *testing.T) {
+	summary := Summary(&summarized{
+		ID:      "s1",
+		Secret:  "hunter2",
+		Count:   3,
+		Note:    strings.Repeat("n", 300),
+		Parts:   []string{"a", "b"},
+		Configs: map[string]any{"mode": "chat"},
+		Blob:    map[string]any{"data": strings.Repeat("x", 4096)},
+	})
+
+	assert.Equal(t, map[string]any{
+		"id":      "s1",
+		"count":   float64(3),
+		"note":    strings.Repeat("n", 256) + "...",
+		"configs": map[string]any{"mode": "chat"},
+	}, summary)
+	assert.Nil(t, Summary(nil))
+	assert.Nil(t, Summary("not an object"))
+}
+
+func TestAfter(t *testing.T) {
+	t.Run("outside an audited request", func(t *testing.T) {
+		After(context.Background(), map[string]any{"id": "s1"})
+		assert.Nil(t, FromContext(context.Background()))
+	})
+
+	t.Run("inside an audited request", func(t *testing.T) {
+		e := &Entry{}
+		ctx := NewContext(context.Background(), e)
+
+		After(ctx, map[string]any{"id": "s1", "parts": []any{"a"}})
+
+		assert.Same(t, e, FromContext(ctx))
+		assert.Equal(t, map[string]any{"id": "s1"}, e.AfterSummary())
+	})
+}
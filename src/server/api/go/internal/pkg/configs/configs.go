@@ -0,0 +1,201 @@
+// Package configs is the registry of the config keys of projects, spaces and sessions. It
+// validates configs against the JSON Schema of their keys, applies JSON merge patches
+// (RFC 7396) and resolves the configs that apply to a session from its project and space.
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Scope is a level that configs are set at. Configs set at a scope apply to the scopes below it.
+type Scope string
+
+const (
+	ScopeProject Scope = "project"
+	ScopeSpace   Scope = "space"
+	ScopeSession Scope = "session"
+)
+
+// Policies for keys that are not registered
+const (
+	UnknownKeysWarn   = "warn"
+	UnknownKeysReject = "reject"
+)
+
+// CustomPrefix marks keys of free-form client data, which are never checked
+const CustomPrefix = "x_"
+
+// reservedPrefix marks keys written by the server, which clients cannot set
+const reservedPrefix = "__"
+
+var ErrInvalid = errors.New("invalid configs")
+
+// Problem is what is wrong with one key of a configs object
+type Problem struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every problem of a configs object
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		msgs = append(msgs, p.Key+": "+p.Message)
+	}
+	return "invalid configs: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() error { return ErrInvalid }
+
+// Key is a registered config key
+type Key struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Scopes      []Scope        `json:"scopes"`
+	Schema      map[string]any `json:"schema"`
+
+	schema *jsonschema.Schema
+}
+
+func (k *Key) allowedAt(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+var registry = map[string]*Key{}
+
+// register compiles the schema of a key and adds it to the registry; it panics on an invalid
+// schema, as registering one is a programming error
+func register(k *Key) {
+	c := jsonschema.NewCompiler()
+	url := "acontext:///configs/" + k.Name + ".json"
+	if err := c.AddResource(url, k.Schema); err != nil {
+		panic(fmt.Sprintf("configs: schema of %q: %v", k.Name, err))
+	}
+	k.schema = c.MustCompile(url)
+	registry[k.Name] = k
+}
+
+// Keys returns the registered keys that clients can set, by name
+func Keys() []*Key {
+	keys := make([]*Key, 0, len(registry))
+	for name, k := range registry {
+		if !strings.HasPrefix(name, reservedPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys
+}
+
+// IsReserved reports whether a key is written by the server and cannot be set by clients
+func IsReserved(name string) bool {
+	return strings.HasPrefix(name, reservedPrefix)
+}
+
+// Validate checks configs set at scope. Registered keys must be allowed at the scope and match
+// their schema. Unregistered keys are returned as warnings, or rejected when unknownKeys is
+// UnknownKeysReject; keys starting with CustomPrefix are never checked. Reserved keys are
+// checked like the others, so clients must not send them (see ValidateInput).
+// The error is a *ValidationError listing every problem.
+func Validate(scope Scope, configs map[string]any, unknownKeys string) ([]Problem, error) {
+	var warnings, problems []Problem
+	for _, name := range sortedKeys(configs) {
+		if strings.HasPrefix(name, CustomPrefix) {
+			continue
+		}
+		k, ok := registry[name]
+		if !ok {
+			p := Problem{Key: name, Message: "unknown config key; prefix custom keys with " + CustomPrefix}
+			if unknownKeys == UnknownKeysReject {
+				problems = append(problems, p)
+			} else {
+				warnings = append(warnings, p)
+			}
+			continue
+		}
+		if !k.allowedAt(scope) {
+			problems = append(problems, Problem{Key: name, Message: fmt.Sprintf("cannot be set on a %s, only on a %s", scope, joinScopes(k.Scopes))})
+			continue
+		}
+		if err := k.schema.Validate(configs[name]); err != nil {
+			problems = append(problems, schemaProblems(name, err)...)
+		}
+	}
+	if len(problems) > 0 {
+		return warnings, &ValidationError{Problems: problems}
+	}
+	return warnings, nil
+}
+
+// ValidateInput is Validate for configs sent by a client, which must not hold reserved keys
+func ValidateInput(scope Scope, configs map[string]any, unknownKeys string) ([]Problem, error) {
+	var reserved []Problem
+	for _, name := range sortedKeys(configs) {
+		if IsReserved(name) {
+			reserved = append(reserved, Problem{Key: name, Message: "is reserved for the server"})
+		}
+	}
+	warnings, err := Validate(scope, configs, unknownKeys)
+	if len(reserved) == 0 {
+		return warnings, err
+	}
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		reserved = append(reserved, verr.Problems...)
+	}
+	return warnings, &ValidationError{Problems: reserved}
+}
+
+// schemaProblems turns a schema validation error into one problem per failed check
+func schemaProblems(name string, err error) []Problem {
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return []Problem{{Key: name, Message: err.Error()}}
+	}
+	var problems []Problem
+	for _, u := range verr.BasicOutput().Errors {
+		if u.Error == nil || len(u.Errors) > 0 {
+			continue
+		}
+		msg := u.Error.String()
+		if u.InstanceLocation != "" {
+			msg = "at " + u.InstanceLocation + ": " + msg
+		}
+		problems = append(problems, Problem{Key: name, Message: msg})
+	}
+	if len(problems) == 0 {
+		problems = append(problems, Problem{Key: name, Message: "does not match its schema"})
+	}
+	return problems
+}
+
+func joinScopes(scopes []Scope) string {
+	names := make([]string, len(scopes))
+	for i, s := range scopes {
+		names[i] = string(s)
+	}
+	return strings.Join(names, " or ")
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,135 @@
+package configs
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, raw string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(raw), &m))
+	return m
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		scope        Scope
+		configs      string
+		unknownKeys  string
+		wantWarnings []string
+		wantProblems []string
+	}{
+		{
+			name:    "valid project configs",
+			scope:   ScopeProject,
+			configs: `{"usage_limits": {"storage_bytes": {"soft": 10, "hard": 20}}, "rate_limits": {"project": {"rate": 2.5, "burst": 5}, "groups": {"session": {"rate": 1, "burst": 1}}}, "project_config": {"default_sop_agent_max_iterations": 3, "sop_agent_custom_scoring_rules": [{"description": "touches billing", "level": "critical"}]}}`,
+		},
+		{
+			name:         "typo in a nested field",
+			scope:        ScopeProject,
+			configs:      `{"usage_limits": {"storage_byte": {"hard": 20}}}`,
+			wantProblems: []string{"usage_limits"},
+		},
+		{
+			name:         "wrong type",
+			scope:        ScopeProject,
+			configs:      `{"rate_limits": {"concurrency": "2"}, "project_config": {"default_task_agent_max_iterations": 0}}`,
+			wantProblems: []string{"project_config", "rate_limits"},
+		},
+		{
+			name:         "project key set on a session",
+			scope:        ScopeSession,
+			configs:      `{"usage_limits": {}}`,
+			wantProblems: []string{"usage_limits"},
+		},
+		{
+			name:         "unknown key is a warning",
+			scope:        ScopeSpace,
+			configs:      `{"mode": "fast", "x_owner": "alice"}`,
+			unknownKeys:  UnknownKeysWarn,
+			wantWarnings: []string{"mode"},
+		},
+		{
+			name:         "unknown key is rejected",
+			scope:        ScopeSpace,
+			configs:      `{"mode": "fast", "x_owner": "alice"}`,
+			unknownKeys:  UnknownKeysReject,
+			wantProblems: []string{"mode"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := Validate(tt.scope, decode(t, tt.configs), tt.unknownKeys)
+
+			var warned []string
+			for _, w := range warnings {
+				warned = append(warned, w.Key)
+			}
+			assert.Equal(t, tt.wantWarnings, warned)
+
+			if tt.wantProblems == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrInvalid))
+			var verr *ValidationError
+			require.True(t, errors.As(err, &verr))
+			keys := map[string]bool{}
+			for _, p := range verr.Problems {
+				keys[p.Key] = true
+				assert.NotEmpty(t, p.Message)
+			}
+			for _, k := range tt.wantProblems {
+				assert.True(t, keys[k], "no problem reported for %s: %v", k, verr.Problems)
+			}
+			assert.Len(t, keys, len(tt.wantProblems))
+		})
+	}
+}
+
+func TestValidateInput_Reserved(t *testing.T) {
+	_, err := Validate(ScopeProject, decode(t, `{"__default_init_project__": true}`), UnknownKeysReject)
+	assert.NoError(t, err)
+
+	_, err = ValidateInput(ScopeProject, decode(t, `{"__default_init_project__": true}`), UnknownKeysReject)
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Equal(t, "__default_init_project__", verr.Problems[0].Key)
+}
+
+func TestKeys(t *testing.T) {
+	var names []string
+	for _, k := range Keys() {
+		names = append(names, k.Name)
+	}
+	assert.Equal(t, []string{"project_config", "rate_limits", "usage_limits"}, names)
+}
+
+func TestMergePatch(t *testing.T) {
+	configs := decode(t, `{"a": "b", "c": {"d": "e", "f": "g"}, "h": [1, 2]}`)
+	patch := decode(t, `{"a": "z", "c": {"f": null, "i": 1}, "h": [3], "j": {"k": null}}`)
+
+	got := MergePatch(configs, patch)
+
+	assert.Equal(t, decode(t, `{"a": "z", "c": {"d": "e", "i": 1}, "h": [3], "j": {}}`), got)
+	// the input is left as it was
+	assert.Equal(t, decode(t, `{"a": "b", "c": {"d": "e", "f": "g"}, "h": [1, 2]}`), configs)
+}
+
+func TestResolve(t *testing.T) {
+	eff := Resolve(
+		Layer{Scope: ScopeProject, Configs: decode(t, `{"__default_init_project__": true, "project_config": {"default_sop_agent_max_iterations": 4, "default_task_agent_max_iterations": 6}, "mode": "slow"}`)},
+		Layer{Scope: ScopeSpace, Configs: decode(t, `{"mode": "fast", "x_owner": "alice"}`)},
+		Layer{Scope: ScopeSession, Configs: decode(t, `{"project_config": {"default_sop_agent_max_iterations": 2}, "x_owner": null}`)},
+	)
+
+	assert.Equal(t, decode(t, `{"project_config": {"default_sop_agent_max_iterations": 2, "default_task_agent_max_iterations": 6}, "mode": "fast"}`), eff.Configs)
+	assert.Equal(t, map[string]Scope{"project_config": ScopeSession, "mode": ScopeSpace}, eff.Sources)
+}
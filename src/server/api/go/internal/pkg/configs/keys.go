@@ -0,0 +1,94 @@
+package configs
+
+func init() {
+	usageLimit := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"soft": map[string]any{"type": "integer", "minimum": 0, "description": "Crossing it is logged, 0 means unlimited"},
+			"hard": map[string]any{"type": "integer", "minimum": 0, "description": "Crossing it rejects the write, 0 means unlimited"},
+		},
+	}
+	register(&Key{
+		Name:        "usage_limits",
+		Description: "Usage limits of the project, overriding the defaults of the server",
+		Scopes:      []Scope{ScopeProject},
+		Schema: map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties": map[string]any{
+				"storage_bytes":    usageLimit,
+				"monthly_messages": usageLimit,
+				"monthly_tokens":   usageLimit,
+			},
+		},
+	})
+
+	rateLimitRule := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"rate":  map[string]any{"type": "number", "minimum": 0, "description": "Requests per second, 0 means unlimited"},
+			"burst": map[string]any{"type": "integer", "minimum": 0, "description": "Requests at once, 0 means unlimited"},
+		},
+	}
+	register(&Key{
+		Name:        "rate_limits",
+		Description: "Request limits of the project, overriding the defaults of the server",
+		Scopes:      []Scope{ScopeProject},
+		Schema: map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties": map[string]any{
+				"project":     rateLimitRule,
+				"key":         rateLimitRule,
+				"groups":      map[string]any{"type": "object", "additionalProperties": rateLimitRule},
+				"concurrency": map[string]any{"type": "integer", "minimum": 0},
+			},
+		},
+	})
+
+	counter := map[string]any{"type": "integer", "minimum": 0}
+	iterations := map[string]any{"type": "integer", "minimum": 1}
+	register(&Key{
+		Name:        "project_config",
+		Description: "Settings of the message buffer and the agents of the core service",
+		Scopes:      []Scope{ScopeProject},
+		Schema: map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties": map[string]any{
+				"project_session_message_use_previous_messages_turns": counter,
+				"project_session_message_buffer_max_turns":            iterations,
+				"project_session_message_buffer_max_overflow":         counter,
+				"project_session_message_buffer_ttl_seconds":          counter,
+				"project_enable_user_confirmation_on_new_experiences": map[string]any{"type": "boolean"},
+				"default_task_agent_max_iterations":                   iterations,
+				"default_task_agent_previous_progress_num":            counter,
+				"default_sop_agent_max_iterations":                    iterations,
+				"default_space_construct_agent_max_iterations":        iterations,
+				"default_space_construct_agent_previous_tasks_limit":  counter,
+				"sop_agent_custom_scoring_rules": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type":                 "object",
+						"additionalProperties": false,
+						"required":             []any{"description", "level"},
+						"properties": map[string]any{
+							"description": map[string]any{"type": "string", "minLength": 1},
+							"level":       map[string]any{"enum": []any{"normal", "critical"}},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	register(&Key{
+		// model.DefaultProjectConfigKey
+		Name:        "__default_init_project__",
+		Description: "Marks the project created at startup",
+		Scopes:      []Scope{ScopeProject},
+		Schema:      map[string]any{"type": "boolean"},
+	})
+}
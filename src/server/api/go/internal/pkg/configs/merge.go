@@ -0,0 +1,61 @@
+package configs
+
+// MergePatch applies a JSON merge patch (RFC 7396) to configs and returns the result; configs
+// is not modified. A null in the patch removes the key, an object is merged into the object
+// it replaces and any other value replaces it.
+func MergePatch(configs, patch map[string]any) map[string]any {
+	out := make(map[string]any, len(configs)+len(patch))
+	for k, v := range configs {
+		out[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(out, k)
+			continue
+		}
+		if p, ok := v.(map[string]any); ok {
+			target, _ := out[k].(map[string]any)
+			out[k] = MergePatch(target, p)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// Layer is the configs set at one scope
+type Layer struct {
+	Scope   Scope
+	Configs map[string]any
+}
+
+// Effective is the configs that apply at a scope, with the scope that set each key
+type Effective struct {
+	Configs map[string]any   `json:"configs"`
+	Sources map[string]Scope `json:"sources"`
+}
+
+// Resolve merges layers from the widest scope to the narrowest, e.g. project, space and session.
+// A key set at a narrower scope overrides the wider ones; objects are merged key by key, so a
+// session can change one field of an object set on its project. A key is sourced from the
+// narrowest scope that set it. Reserved keys are left out.
+func Resolve(layers ...Layer) *Effective {
+	eff := &Effective{Configs: map[string]any{}, Sources: map[string]Scope{}}
+	for _, l := range layers {
+		patch := make(map[string]any, len(l.Configs))
+		for k, v := range l.Configs {
+			if !IsReserved(k) {
+				patch[k] = v
+			}
+		}
+		eff.Configs = MergePatch(eff.Configs, patch)
+		for k := range patch {
+			if _, ok := eff.Configs[k]; ok {
+				eff.Sources[k] = l.Scope
+			} else {
+				delete(eff.Sources, k)
+			}
+		}
+	}
+	return eff
+}
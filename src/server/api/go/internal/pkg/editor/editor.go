@@ -1,12 +1,16 @@
 package editor
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 
 	"github.com/memodb-io/Acontext/internal/modules/model"
 )
 
+// ErrInvalidStrategy is wrapped by the errors of strategy configs that cannot be applied
+var ErrInvalidStrategy = errors.New("invalid edit strategy")
+
 // EditStrategy defines the interface for message editing strategies
 type EditStrategy interface {
 	Apply(messages []model.Message) ([]model.Message, error)
@@ -82,7 +86,7 @@ func ApplyStrategies(messages []model.Message, configs []StrategyConfig) ([]mode
 	for _, config := range sortedConfigs {
 		strategy, err := CreateStrategy(config)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create strategy: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrInvalidStrategy, err)
 		}
 
 		result, err = strategy.Apply(result)
@@ -11,12 +11,23 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrInvalidCursor is wrapped by the errors of cursors that cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 func EncodeCursor(t time.Time, id uuid.UUID) string {
 	raw := fmt.Sprintf("%d|%s", t.UTC().UnixNano(), id.String())
 	return base64.RawURLEncoding.EncodeToString([]byte(raw))
 }
 
 func DecodeCursor(s string) (time.Time, uuid.UUID, error) {
+	t, id, err := decodeCursor(s)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return t, id, nil
+}
+
+func decodeCursor(s string) (time.Time, uuid.UUID, error) {
 	if s == "" {
 		return time.Time{}, uuid.Nil, errors.New("empty cursor")
 	}
@@ -38,3 +49,35 @@ func DecodeCursor(s string) (time.Time, uuid.UUID, error) {
 	}
 	return time.Unix(0, ns).UTC(), id, nil
 }
+
+// pathCursorSep separates path and filename in a path cursor.
+// Null bytes are rejected by path validation, so they cannot appear in a stored path.
+const pathCursorSep = "\x00"
+
+// EncodePathCursor encodes a (path, filename) position for listings ordered by path and filename
+func EncodePathCursor(path, filename string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(path + pathCursorSep + filename))
+}
+
+func DecodePathCursor(s string) (string, string, error) {
+	path, filename, err := decodePathCursor(s)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return path, filename, nil
+}
+
+func decodePathCursor(s string) (string, string, error) {
+	if s == "" {
+		return "", "", errors.New("empty cursor")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.SplitN(string(b), pathCursorSep, 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", errors.New("bad cursor")
+	}
+	return parts[0], parts[1], nil
+}
@@ -236,3 +236,47 @@ func TestCursor_URLSafe(t *testing.T) {
 		assert.NotContains(t, cursor, "=") // RawURLEncoding does not include padding characters
 	})
 }
+
+func TestPathCursor_Roundtrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		filename string
+	}{
+		{name: "root file", path: "/", filename: "a.txt"},
+		{name: "nested file", path: "/documents/work/", filename: "report.pdf"},
+		{name: "separator-like characters", path: "/a|b/", filename: "c|d.txt"},
+		{name: "unicode", path: "/文档/", filename: "报告.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := EncodePathCursor(tt.path, tt.filename)
+			assert.NotContains(t, cursor, "/")
+
+			path, filename, err := DecodePathCursor(cursor)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.path, path)
+			assert.Equal(t, tt.filename, filename)
+		})
+	}
+}
+
+func TestDecodePathCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "empty", cursor: ""},
+		{name: "not base64", cursor: "!!!"},
+		{name: "missing separator", cursor: "L2RvY3VtZW50cy8"},
+		{name: "time cursor", cursor: EncodeCursor(time.Now(), uuid.New())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := DecodePathCursor(tt.cursor)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
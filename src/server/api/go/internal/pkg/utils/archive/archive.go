@@ -0,0 +1,199 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	pathutil "github.com/memodb-io/Acontext/internal/pkg/utils/path"
+)
+
+// Format is a supported archive format
+type Format string
+
+const (
+	FormatZip   Format = "zip"
+	FormatTarGz Format = "tar.gz"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported archive format")
+	ErrUnsafePath        = errors.New("archive entry path escapes the target directory")
+	ErrTooManyEntries    = errors.New("archive contains too many files")
+	ErrTooLarge          = errors.New("archive content is too large")
+)
+
+// Limits bounds the amount of data extracted from a single archive
+type Limits struct {
+	MaxEntries   int
+	MaxFileSize  int64
+	MaxTotalSize int64
+}
+
+// DefaultLimits protects the server against archive bombs
+var DefaultLimits = Limits{
+	MaxEntries:   10000,
+	MaxFileSize:  100 << 20,
+	MaxTotalSize: 1 << 30,
+}
+
+// Entry is a regular file extracted from an archive
+type Entry struct {
+	Path     string // Directory path below the target path, both ends are '/'
+	Filename string
+	Content  []byte
+}
+
+// DetectFormat detects the archive format from the filename extension
+func DetectFormat(filename string) (Format, error) {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, filename)
+	}
+}
+
+// EntryLocation maps an archive entry name to a directory path and filename under basePath.
+// Absolute names and names containing '..' segments are rejected to prevent zip-slip.
+func EntryLocation(basePath string, name string) (string, string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") {
+		return "", "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+		}
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == "." || cleaned == "" {
+		return "", "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+
+	dir, filename := path.Split(cleaned)
+	if !strings.HasSuffix(basePath, "/") {
+		basePath += "/"
+	}
+	dirPath := basePath + dir
+	if err := pathutil.ValidatePath(dirPath); err != nil {
+		return "", "", fmt.Errorf("%w: %s", err, name)
+	}
+
+	return dirPath, filename, nil
+}
+
+// Extract reads every regular file from the archive and calls fn with its location under basePath.
+// Directories, symlinks and other special entries are skipped.
+func Extract(format Format, r io.ReaderAt, size int64, basePath string, limits Limits, fn func(Entry) error) error {
+	e := &extractor{basePath: basePath, limits: limits, fn: fn}
+
+	switch format {
+	case FormatZip:
+		return e.zip(r, size)
+	case FormatTarGz:
+		return e.tarGz(io.NewSectionReader(r, 0, size))
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+}
+
+type extractor struct {
+	basePath  string
+	limits    Limits
+	fn        func(Entry) error
+	entries   int
+	totalSize int64
+}
+
+func (e *extractor) zip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("open zip archive: %w", err)
+	}
+
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("open %s: %w", f.Name, err)
+		}
+		err = e.add(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *extractor) tarGz(r io.Reader) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("open gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := e.add(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *extractor) add(name string, r io.Reader) error {
+	dir, filename, err := EntryLocation(e.basePath, name)
+	if err != nil {
+		return err
+	}
+
+	e.entries++
+	if e.limits.MaxEntries > 0 && e.entries > e.limits.MaxEntries {
+		return ErrTooManyEntries
+	}
+
+	// Read one byte past the limit to detect oversized files without trusting headers
+	limit := e.limits.MaxFileSize
+	if limit <= 0 {
+		limit = DefaultLimits.MaxFileSize
+	}
+	content, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	if int64(len(content)) > limit {
+		return fmt.Errorf("%w: %s", ErrTooLarge, name)
+	}
+
+	e.totalSize += int64(len(content))
+	if e.limits.MaxTotalSize > 0 && e.totalSize > e.limits.MaxTotalSize {
+		return ErrTooLarge
+	}
+
+	return e.fn(Entry{Path: dir, Filename: filename, Content: content})
+}
@@ -0,0 +1,175 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildZip(t *testing.T, files map[string]string, dirs ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, d := range dirs {
+		_, err := zw.Create(d)
+		require.NoError(t, err)
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func buildTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "repo/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "repo/link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}))
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func collect(t *testing.T, format Format, data []byte, basePath string, limits Limits) (map[string]string, error) {
+	t.Helper()
+	got := map[string]string{}
+	err := Extract(format, bytes.NewReader(data), int64(len(data)), basePath, limits, func(e Entry) error {
+		got[e.Path+e.Filename] = string(e.Content)
+		return nil
+	})
+	return got, err
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     Format
+		wantErr  bool
+	}{
+		{"repo.zip", FormatZip, false},
+		{"REPO.ZIP", FormatZip, false},
+		{"repo.tar.gz", FormatTarGz, false},
+		{"repo.tgz", FormatTarGz, false},
+		{"repo.tar", "", true},
+		{"repo.rar", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			got, err := DetectFormat(tt.filename)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnsupportedFormat)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEntryLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		entry    string
+		wantDir  string
+		wantFile string
+		wantErr  bool
+	}{
+		{"root file", "/", "a.txt", "/", "a.txt", false},
+		{"nested file", "/ws/", "src/main.go", "/ws/src/", "main.go", false},
+		{"base without trailing slash", "/ws", "a.txt", "/ws/", "a.txt", false},
+		{"leading dot segment", "/", "./src/a.txt", "/src/", "a.txt", false},
+		{"windows separators", "/", "src\\a.txt", "/src/", "a.txt", false},
+		{"parent traversal", "/ws/", "../evil.sh", "", "", true},
+		{"nested traversal", "/ws/", "src/../../evil.sh", "", "", true},
+		{"windows traversal", "/ws/", "..\\evil.sh", "", "", true},
+		{"absolute path", "/ws/", "/etc/passwd", "", "", true},
+		{"dots segment", "/ws/", ".../a.txt", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, file, err := EntryLocation(tt.base, tt.entry)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDir, dir)
+			assert.Equal(t, tt.wantFile, file)
+		})
+	}
+}
+
+func TestExtract_Zip(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"README.md":   "hello",
+		"src/main.go": "package main",
+	}, "src/")
+
+	got, err := collect(t, FormatZip, data, "/ws/", DefaultLimits)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"/ws/README.md":   "hello",
+		"/ws/src/main.go": "package main",
+	}, got)
+}
+
+func TestExtract_ZipSlip(t *testing.T) {
+	data := buildZip(t, map[string]string{"../../evil.sh": "rm -rf /"})
+
+	_, err := collect(t, FormatZip, data, "/ws/", DefaultLimits)
+	assert.ErrorIs(t, err, ErrUnsafePath)
+}
+
+func TestExtract_TarGz(t *testing.T) {
+	data := buildTarGz(t, map[string]string{"repo/a.txt": "a", "repo/b/c.txt": "c"})
+
+	got, err := collect(t, FormatTarGz, data, "/", DefaultLimits)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"/repo/a.txt":   "a",
+		"/repo/b/c.txt": "c",
+	}, got)
+}
+
+func TestExtract_Limits(t *testing.T) {
+	data := buildZip(t, map[string]string{"a.txt": "aaaa", "b.txt": "bbbb"})
+
+	_, err := collect(t, FormatZip, data, "/", Limits{MaxEntries: 1, MaxFileSize: 10, MaxTotalSize: 100})
+	assert.ErrorIs(t, err, ErrTooManyEntries)
+
+	_, err = collect(t, FormatZip, data, "/", Limits{MaxEntries: 10, MaxFileSize: 3, MaxTotalSize: 100})
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = collect(t, FormatZip, data, "/", Limits{MaxEntries: 10, MaxFileSize: 10, MaxTotalSize: 6})
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestExtract_CallbackError(t *testing.T) {
+	data := buildZip(t, map[string]string{"a.txt": "a"})
+	want := errors.New("stop")
+
+	err := Extract(FormatZip, bytes.NewReader(data), int64(len(data)), "/", DefaultLimits, func(Entry) error {
+		return want
+	})
+	assert.ErrorIs(t, err, want)
+}
@@ -0,0 +1,113 @@
+package chunker
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunk is a contiguous range of lines from a document
+type Chunk struct {
+	Index     int    `json:"index"`
+	StartLine int    `json:"start_line"` // 1-based, inclusive
+	EndLine   int    `json:"end_line"`   // 1-based, inclusive
+	Content   string `json:"content"`
+}
+
+type Options struct {
+	MaxChars     int // Maximum characters per chunk
+	OverlapLines int // Number of trailing lines repeated at the start of the next chunk
+}
+
+var DefaultOptions = Options{
+	MaxChars:     1500,
+	OverlapLines: 2,
+}
+
+type line struct {
+	no   int
+	text string
+}
+
+// Split breaks text into line-aligned chunks of at most MaxChars characters.
+// Lines longer than MaxChars are split on their own and keep their line number.
+// Chunks that contain only whitespace are dropped.
+func Split(text string, opts Options) []Chunk {
+	if opts.MaxChars <= 0 {
+		opts.MaxChars = DefaultOptions.MaxChars
+	}
+	if opts.OverlapLines < 0 {
+		opts.OverlapLines = 0
+	}
+
+	chunks := make([]Chunk, 0)
+	emit := func(lines []line) {
+		parts := make([]string, len(lines))
+		for i, l := range lines {
+			parts[i] = l.text
+		}
+		content := strings.Join(parts, "\n")
+		if strings.TrimSpace(content) == "" {
+			return
+		}
+		chunks = append(chunks, Chunk{
+			Index:     len(chunks),
+			StartLine: lines[0].no,
+			EndLine:   lines[len(lines)-1].no,
+			Content:   content,
+		})
+	}
+
+	var current []line
+	size := 0
+	for i, text := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		no := i + 1
+
+		// Oversized lines become standalone chunks
+		if len(text) > opts.MaxChars {
+			if len(current) > 0 {
+				emit(current)
+				current, size = nil, 0
+			}
+			for start := 0; start < len(text); {
+				end := min(start+opts.MaxChars, len(text))
+				// Never cut a multi-byte character in half
+				for end < len(text) && end > start+1 && !utf8.RuneStart(text[end]) {
+					end--
+				}
+				emit([]line{{no: no, text: text[start:end]}})
+				start = end
+			}
+			continue
+		}
+
+		if len(current) > 0 && size+len(text) > opts.MaxChars {
+			emit(current)
+
+			// Carry trailing lines over as context, as long as the next line still fits
+			keep := min(opts.OverlapLines, len(current)-1)
+			overlap := current[len(current)-keep:]
+			for len(overlap) > 0 && linesSize(overlap)+len(text) > opts.MaxChars {
+				overlap = overlap[1:]
+			}
+			current = append([]line(nil), overlap...)
+			size = linesSize(current)
+		}
+
+		current = append(current, line{no: no, text: text})
+		size += len(text) + 1
+	}
+	if len(current) > 0 {
+		emit(current)
+	}
+
+	return chunks
+}
+
+// linesSize returns the joined length of lines including one separator per line
+func linesSize(lines []line) int {
+	size := 0
+	for _, l := range lines {
+		size += len(l.text) + 1
+	}
+	return size
+}
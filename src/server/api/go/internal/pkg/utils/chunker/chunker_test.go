@@ -0,0 +1,77 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit_SmallDocument(t *testing.T) {
+	chunks := Split("line one\nline two\nline three", DefaultOptions)
+
+	assert.Equal(t, []Chunk{
+		{Index: 0, StartLine: 1, EndLine: 3, Content: "line one\nline two\nline three"},
+	}, chunks)
+}
+
+func TestSplit_LineRangesAndOverlap(t *testing.T) {
+	text := "aaaa\nbbbb\ncccc\ndddd\neeee"
+	chunks := Split(text, Options{MaxChars: 10, OverlapLines: 1})
+
+	assert.Equal(t, []Chunk{
+		{Index: 0, StartLine: 1, EndLine: 2, Content: "aaaa\nbbbb"},
+		{Index: 1, StartLine: 2, EndLine: 3, Content: "bbbb\ncccc"},
+		{Index: 2, StartLine: 3, EndLine: 4, Content: "cccc\ndddd"},
+		{Index: 3, StartLine: 4, EndLine: 5, Content: "dddd\neeee"},
+	}, chunks)
+}
+
+func TestSplit_NoOverlap(t *testing.T) {
+	chunks := Split("aaaa\nbbbb\ncccc", Options{MaxChars: 10})
+
+	assert.Len(t, chunks, 2)
+	assert.Equal(t, 1, chunks[0].StartLine)
+	assert.Equal(t, 2, chunks[0].EndLine)
+	assert.Equal(t, 3, chunks[1].StartLine)
+	assert.Equal(t, "cccc", chunks[1].Content)
+}
+
+func TestSplit_LongLine(t *testing.T) {
+	text := "short\n" + strings.Repeat("x", 25) + "\nafter"
+	chunks := Split(text, Options{MaxChars: 10})
+
+	assert.Len(t, chunks, 5)
+	assert.Equal(t, "short", chunks[0].Content)
+	for _, c := range chunks[1:4] {
+		assert.Equal(t, 2, c.StartLine)
+		assert.Equal(t, 2, c.EndLine)
+		assert.LessOrEqual(t, len(c.Content), 10)
+	}
+	assert.Equal(t, "after", chunks[4].Content)
+	assert.Equal(t, 3, chunks[4].StartLine)
+}
+
+func TestSplit_SkipsBlankChunks(t *testing.T) {
+	assert.Empty(t, Split("\n\n   \n", DefaultOptions))
+	assert.Empty(t, Split("", DefaultOptions))
+}
+
+func TestSplit_CRLF(t *testing.T) {
+	chunks := Split("a\r\nb", DefaultOptions)
+	assert.Equal(t, "a\nb", chunks[0].Content)
+	assert.Equal(t, 2, chunks[0].EndLine)
+}
+
+func TestSplit_LongLineKeepsRunesIntact(t *testing.T) {
+	text := strings.Repeat("é", 10)
+	chunks := Split(text, Options{MaxChars: 5})
+
+	joined := ""
+	for _, c := range chunks {
+		assert.True(t, utf8.ValidString(c.Content))
+		joined += c.Content
+	}
+	assert.Equal(t, text, joined)
+}
@@ -0,0 +1,95 @@
+package fileparser
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// maxDocumentPartSize bounds a single decompressed XML part of an office document
+const maxDocumentPartSize = 64 << 20
+
+// documentBuilder joins the text of pages, sheets or slides and records where each one lands
+type documentBuilder struct {
+	buf      strings.Builder
+	line     int // Number of lines written so far
+	sections []Section
+}
+
+// add appends a section. Empty sections are recorded with no lines so that indexes stay aligned with the source.
+func (b *documentBuilder) add(kind string, index int, name string, text string) {
+	text = strings.Trim(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		b.sections = append(b.sections, Section{Kind: kind, Index: index, Name: name})
+		return
+	}
+
+	if b.line > 0 {
+		b.buf.WriteString("\n\n")
+		b.line++
+	}
+	start := b.line + 1
+	b.buf.WriteString(text)
+	b.line += strings.Count(text, "\n") + 1
+
+	b.sections = append(b.sections, Section{Kind: kind, Index: index, Name: name, StartLine: start, EndLine: b.line})
+}
+
+func (b *documentBuilder) content(fileType string) *FileContent {
+	sections := b.sections
+	if sections == nil {
+		sections = []Section{}
+	}
+	return &FileContent{
+		Type:     fileType,
+		Raw:      b.buf.String(),
+		Metadata: &FileMetadata{Sections: sections},
+	}
+}
+
+// hasExt reports whether filename has one of the given lowercase extensions
+func hasExt(filename string, exts ...string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// openPackage opens an OOXML or ODF document, which are both zip containers
+func openPackage(content []byte) (*zip.Reader, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document package: %w", err)
+	}
+	return zr, nil
+}
+
+// readPart reads a file from a document package. A missing part returns nil without error.
+func readPart(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(io.LimitReader(rc, maxDocumentPartSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if len(data) > maxDocumentPartSize {
+			return nil, fmt.Errorf("document part %s is too large", name)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,285 @@
+package fileparser
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func buildPackage(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close package: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// buildPDF writes a minimal PDF with one Helvetica text line per page
+func buildPDF(t *testing.T, pages ...string) []byte {
+	t.Helper()
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+i*2)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	for i, text := range pages {
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+i*2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func assertSections(t *testing.T, fc *FileContent, want []Section) {
+	t.Helper()
+	if fc.Metadata == nil {
+		t.Fatal("expected metadata")
+	}
+	if !reflect.DeepEqual(fc.Metadata.Sections, want) {
+		t.Errorf("sections = %+v, want %+v", fc.Metadata.Sections, want)
+	}
+}
+
+func TestPDFParser(t *testing.T) {
+	content := buildPDF(t, "Quarterly report", "Revenue grew")
+
+	result, err := NewFileParser().ParseFile("report.pdf", "application/pdf", content)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	if result.Type != "pdf" {
+		t.Errorf("type = %v, want pdf", result.Type)
+	}
+	if result.Raw != "Quarterly report\n\nRevenue grew" {
+		t.Errorf("raw = %q", result.Raw)
+	}
+	assertSections(t, result, []Section{
+		{Kind: "page", Index: 1, StartLine: 1, EndLine: 1},
+		{Kind: "page", Index: 2, StartLine: 3, EndLine: 3},
+	})
+
+	if _, err := (&PDFParser{}).Parse([]byte("%PDF-1.4 truncated")); err == nil {
+		t.Error("Parse() should return error for a corrupt PDF")
+	}
+}
+
+func TestDOCXParser(t *testing.T) {
+	document := `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:body>
+<w:p><w:r><w:t>Project </w:t></w:r><w:r><w:t>plan</w:t></w:r></w:p>
+<w:tbl>
+<w:tr><w:tc><w:p><w:r><w:t>Task</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Owner</w:t></w:r></w:p></w:tc></w:tr>
+<w:tr><w:tc><w:p><w:r><w:t>Design</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Ana</w:t></w:r></w:p></w:tc></w:tr>
+</w:tbl>
+<w:p><w:r><w:br w:type="page"/><w:t>Appendix</w:t></w:r></w:p>
+</w:body>
+</w:document>`
+	content := buildPackage(t, map[string]string{"word/document.xml": document})
+
+	result, err := NewFileParser().ParseFile("plan.docx", "application/octet-stream", content)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	if result.Type != "docx" {
+		t.Errorf("type = %v, want docx", result.Type)
+	}
+	if result.Raw != "Project plan\nTask\tOwner\nDesign\tAna\n\nAppendix" {
+		t.Errorf("raw = %q", result.Raw)
+	}
+	assertSections(t, result, []Section{
+		{Kind: "page", Index: 1, StartLine: 1, EndLine: 3},
+		{Kind: "page", Index: 2, StartLine: 5, EndLine: 5},
+	})
+
+	if _, err := (&DOCXParser{}).Parse(buildPackage(t, map[string]string{"other.xml": "<x/>"})); err == nil {
+		t.Error("Parse() should return error when word/document.xml is missing")
+	}
+}
+
+func TestXLSXParser(t *testing.T) {
+	content := buildPackage(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Users" sheetId="1" r:id="rId1"/><sheet name="Empty" sheetId="2" r:id="rId2"/><sheet name="Totals" sheetId="3" r:id="rId3"/></sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Target="worksheets/sheet2.xml"/>
+<Relationship Id="rId3" Target="/xl/worksheets/sheet3.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>name</t></si><si><t>age</t></si><si><r><t>Smith, </t></r><r><t>John</t></r></si>
+</sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>active</t></is></c></row>
+<row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3"><v>25</v></c><c r="C3" t="b"><v>1</v></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`,
+		"xl/worksheets/sheet3.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="B1"><v>42</v></c></row>
+</sheetData></worksheet>`,
+	})
+
+	result, err := NewFileParser().ParseFile("users.xlsx", "", content)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	if result.Type != "xlsx" {
+		t.Errorf("type = %v, want xlsx", result.Type)
+	}
+	want := "name,age,active\n,,\n\"Smith, John\",25,TRUE\n\n,42"
+	if result.Raw != want {
+		t.Errorf("raw = %q, want %q", result.Raw, want)
+	}
+	assertSections(t, result, []Section{
+		{Kind: "sheet", Index: 1, Name: "Users", StartLine: 1, EndLine: 3},
+		{Kind: "sheet", Index: 2, Name: "Empty"},
+		{Kind: "sheet", Index: 3, Name: "Totals", StartLine: 5, EndLine: 5},
+	})
+}
+
+func TestODSParser(t *testing.T) {
+	content := buildPackage(t, map[string]string{
+		"content.xml": `<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet>
+<table:table table:name="Prices">
+<table:table-row><table:table-cell><text:p>item</text:p></table:table-cell><table:table-cell><text:p>price</text:p></table:table-cell><table:table-cell table:number-columns-repeated="1020"/></table:table-row>
+<table:table-row><table:table-cell><text:p>tea</text:p></table:table-cell><table:table-cell table:number-columns-repeated="2"><text:p>3</text:p></table:table-cell></table:table-row>
+<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+</table:table>
+</office:spreadsheet></office:body>
+</office:document-content>`,
+	})
+
+	result, err := NewFileParser().ParseFile("prices.ods", "", content)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	if result.Type != "ods" {
+		t.Errorf("type = %v, want ods", result.Type)
+	}
+	if result.Raw != "item,price,\ntea,3,3" {
+		t.Errorf("raw = %q", result.Raw)
+	}
+	assertSections(t, result, []Section{
+		{Kind: "sheet", Index: 1, Name: "Prices", StartLine: 1, EndLine: 2},
+	})
+}
+
+func TestPPTXParser(t *testing.T) {
+	slide := func(lines ...string) string {
+		var sb strings.Builder
+		sb.WriteString(`<p:sld xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main"><p:cSld><p:spTree><p:sp><p:txBody>`)
+		for _, l := range lines {
+			sb.WriteString("<a:p><a:r><a:t>" + l + "</a:t></a:r></a:p>")
+		}
+		sb.WriteString(`</p:txBody></p:sp></p:spTree></p:cSld></p:sld>`)
+		return sb.String()
+	}
+	content := buildPackage(t, map[string]string{
+		"ppt/slides/slide10.xml":           slide("Questions?"),
+		"ppt/slides/slide2.xml":            slide("Roadmap", "Q1 launch"),
+		"ppt/slides/slide1.xml":            slide("Kickoff"),
+		"ppt/slides/_rels/slide1.xml.rels": "<Relationships/>",
+	})
+
+	result, err := NewFileParser().ParseFile("deck.pptx", "", content)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	if result.Type != "pptx" {
+		t.Errorf("type = %v, want pptx", result.Type)
+	}
+	if result.Raw != "Kickoff\n\nRoadmap\nQ1 launch\n\nQuestions?" {
+		t.Errorf("raw = %q", result.Raw)
+	}
+	assertSections(t, result, []Section{
+		{Kind: "slide", Index: 1, StartLine: 1, EndLine: 1},
+		{Kind: "slide", Index: 2, StartLine: 3, EndLine: 4},
+		{Kind: "slide", Index: 3, StartLine: 6, EndLine: 6},
+	})
+}
+
+func TestHTMLParser(t *testing.T) {
+	content := []byte(`<!DOCTYPE html>
+<html><head><title>Ignored</title><style>body { color: red; }</style></head>
+<body>
+  <h1>Getting   started</h1>
+  <p>Install the <strong>CLI</strong> and read the <a href="https://example.com/docs">docs</a>.</p>
+  <script>alert("x")</script>
+  <ul><li>First</li><li>Second<ul><li>Nested</li></ul></li></ul>
+  <pre><code>go build ./...
+go test ./...</code></pre>
+  <table><tr><th>Name</th><th>Value</th></tr><tr><td>a|b</td><td>1</td></tr></table>
+</body></html>`)
+
+	result, err := NewFileParser().ParseFile("index.html", "text/html", content)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	if result.Type != "html" {
+		t.Errorf("type = %v, want html", result.Type)
+	}
+	want := "# Getting started\n\n" +
+		"Install the **CLI** and read the [docs](https://example.com/docs).\n\n" +
+		"- First\n- Second\n  - Nested\n\n" +
+		"```\ngo build ./...\ngo test ./...\n```\n\n" +
+		"| Name | Value |\n| --- | --- |\n| a\\|b | 1 |"
+	if result.Raw != want {
+		t.Errorf("raw = %q, want %q", result.Raw, want)
+	}
+	if result.Metadata != nil {
+		t.Errorf("metadata = %+v, want nil", result.Metadata)
+	}
+}
+
+func TestDocumentParsersRejectInvalidPackages(t *testing.T) {
+	parser := NewFileParser()
+	for _, filename := range []string{"a.docx", "a.xlsx", "a.ods", "a.pptx"} {
+		if !parser.CanParseFile(filename, "") {
+			t.Errorf("CanParseFile(%q) = false, want true", filename)
+		}
+		if _, err := parser.ParseFile(filename, "", []byte("not a zip")); err == nil {
+			t.Errorf("ParseFile(%q) should return error for invalid content", filename)
+		}
+	}
+}
@@ -13,8 +13,27 @@ import (
 
 // FileContent represents the parsed content of a file
 type FileContent struct {
-	Type string `json:"type"` // "text", "json", "csv", "code"
-	Raw  string `json:"raw"`  // Raw text content
+	Type     string        `json:"type"`               // "text", "json", "csv", "code", "html", "pdf", "docx", "xlsx", "ods", "pptx"
+	Raw      string        `json:"raw"`                // Raw text content, extracted text for documents
+	Metadata *FileMetadata `json:"metadata,omitempty"` // Document sections, or the structure of JSON and CSV data
+}
+
+// FileMetadata describes how extracted document text maps back to the source document,
+// and in structured mode the shape of tabular and JSON data
+type FileMetadata struct {
+	Sections []Section   `json:"sections,omitempty"`
+	Table    *TableInfo  `json:"table,omitempty"`
+	Schema   *JSONSchema `json:"schema,omitempty"`
+}
+
+// Section locates a page, sheet or slide inside FileContent.Raw.
+// Lines are 1-based and inclusive; sections are separated by a blank line.
+type Section struct {
+	Kind      string `json:"kind"`           // "page", "sheet", "slide"
+	Index     int    `json:"index"`          // 1-based position in the document
+	Name      string `json:"name,omitempty"` // Sheet name
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
 }
 
 // Parser interface for different file types
@@ -23,12 +42,18 @@ type Parser interface {
 	Parse(content []byte) (*FileContent, error)
 }
 
+// StructuredParser is implemented by parsers of data files that can describe and query their content
+type StructuredParser interface {
+	Parser
+	ParseStructured(content []byte, opts ParseOptions) (*FileContent, error)
+}
+
 // TextParser handles plain text files
 type TextParser struct{}
 
 func (p *TextParser) CanParse(filename string, mimeType string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
-	textExts := []string{".txt", ".md", ".markdown", ".log", ".yml", ".yaml", ".xml", ".html", ".htm"}
+	textExts := []string{".txt", ".md", ".markdown", ".log", ".yml", ".yaml", ".xml"}
 
 	for _, textExt := range textExts {
 		if ext == textExt {
@@ -159,6 +184,12 @@ func NewFileParser() *FileParser {
 		parsers: []Parser{
 			&JSONParser{},
 			&CSVParser{},
+			&PDFParser{},
+			&DOCXParser{},
+			&XLSXParser{},
+			&ODSParser{},
+			&PPTXParser{},
+			&HTMLParser{},
 			&CodeParser{},
 			&TextParser{}, // Text parser should be last as it's the fallback
 		},
@@ -177,16 +208,31 @@ func (fp *FileParser) CanParseFile(filename string, mimeType string) bool {
 
 // ParseFile attempts to parse file content based on filename and MIME type
 func (fp *FileParser) ParseFile(filename string, mimeType string, content []byte) (*FileContent, error) {
+	return fp.ParseFileWithOptions(filename, mimeType, content, ParseOptions{})
+}
+
+// ParseFileWithOptions parses file content, using structured mode for data files when requested.
+// A query on a file type without structured support returns ErrQueryNotSupported.
+func (fp *FileParser) ParseFileWithOptions(filename string, mimeType string, content []byte, opts ParseOptions) (*FileContent, error) {
 	// Try each parser in order
 	for _, parser := range fp.parsers {
-		if parser.CanParse(filename, mimeType) {
-			return parser.Parse(content)
+		if !parser.CanParse(filename, mimeType) {
+			continue
+		}
+		if opts.Structured || opts.Query != nil {
+			if sp, ok := parser.(StructuredParser); ok {
+				return sp.ParseStructured(content, opts)
+			}
+			if opts.Query != nil {
+				return nil, fmt.Errorf("%w: %s", ErrQueryNotSupported, filename)
+			}
 		}
+		return parser.Parse(content)
 	}
 
 	// If no parser matches (e.g., images, binary files), return nil
 	// Only text-based files should be parsed
-	return nil, fmt.Errorf("unsupported file type: %s (mime: %s)", filename, mimeType)
+	return nil, fmt.Errorf("%w: %s (mime: %s)", ErrUnsupportedFileType, filename, mimeType)
 }
 
 // ParseFileFromReader parses file content from an io.Reader
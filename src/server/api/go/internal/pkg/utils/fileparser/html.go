@@ -0,0 +1,290 @@
+package fileparser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLParser converts HTML pages to markdown
+type HTMLParser struct{}
+
+func (p *HTMLParser) CanParse(filename string, mimeType string) bool {
+	return hasExt(filename, ".html", ".htm", ".xhtml") ||
+		strings.HasPrefix(mimeType, "text/html") ||
+		strings.HasPrefix(mimeType, "application/xhtml+xml")
+}
+
+func (p *HTMLParser) Parse(content []byte) (*FileContent, error) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	root := doc
+	if body := findElement(doc, atom.Body); body != nil {
+		root = body
+	}
+
+	c := &markdownConverter{}
+	c.children(root)
+
+	return &FileContent{
+		Type: "html",
+		Raw:  c.String(),
+	}, nil
+}
+
+var (
+	inlineSpace = regexp.MustCompile(`[ \t\r\n]+`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+)
+
+// markdownConverter renders the common subset of HTML (headings, paragraphs, lists,
+// links, emphasis, code, quotes and tables) as markdown
+type markdownConverter struct {
+	buf       strings.Builder
+	listDepth int
+	pre       int // >0 inside <pre>, where whitespace is kept
+}
+
+func (c *markdownConverter) String() string {
+	lines := strings.Split(c.buf.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	out := blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(out)
+}
+
+// block starts a new markdown block separated by a blank line
+func (c *markdownConverter) block() {
+	c.buf.WriteString("\n\n")
+}
+
+func (c *markdownConverter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child)
+	}
+}
+
+func (c *markdownConverter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if c.pre > 0 {
+			c.buf.WriteString(n.Data)
+			return
+		}
+		text := inlineSpace.ReplaceAllString(n.Data, " ")
+		if cur := c.buf.String(); cur == "" || strings.HasSuffix(cur, "\n") {
+			text = strings.TrimLeft(text, " ")
+		}
+		c.buf.WriteString(text)
+		return
+	case html.ElementNode:
+	default:
+		c.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Head, atom.Svg, atom.Iframe:
+		return
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		c.block()
+		c.buf.WriteString(strings.Repeat("#", level) + " " + strings.TrimSpace(c.inline(n)))
+		c.block()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer, atom.Nav, atom.Aside:
+		c.block()
+		c.children(n)
+		c.block()
+	case atom.Br:
+		c.buf.WriteString("\n")
+	case atom.Hr:
+		c.block()
+		c.buf.WriteString("---")
+		c.block()
+	case atom.Strong, atom.B:
+		c.wrap(n, "**")
+	case atom.Em, atom.I:
+		c.wrap(n, "*")
+	case atom.Code:
+		if c.pre > 0 {
+			c.children(n)
+			return
+		}
+		c.wrap(n, "`")
+	case atom.Pre:
+		c.block()
+		c.buf.WriteString("```\n")
+		c.pre++
+		c.children(n)
+		c.pre--
+		c.buf.WriteString("\n```")
+		c.block()
+	case atom.A:
+		text := strings.TrimSpace(c.inline(n))
+		href := getAttr(n, "href")
+		if href == "" || text == "" {
+			c.buf.WriteString(text)
+			return
+		}
+		c.buf.WriteString("[" + text + "](" + href + ")")
+	case atom.Img:
+		if src := getAttr(n, "src"); src != "" {
+			c.buf.WriteString("![" + getAttr(n, "alt") + "](" + src + ")")
+		}
+	case atom.Ul, atom.Ol:
+		c.list(n)
+	case atom.Blockquote:
+		inner := &markdownConverter{listDepth: c.listDepth}
+		inner.children(n)
+		c.block()
+		for _, line := range strings.Split(inner.String(), "\n") {
+			c.buf.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+		c.block()
+	case atom.Table:
+		c.table(n)
+	default:
+		c.children(n)
+	}
+}
+
+func (c *markdownConverter) wrap(n *html.Node, marker string) {
+	text := c.inline(n)
+	if strings.TrimSpace(text) == "" {
+		c.buf.WriteString(text)
+		return
+	}
+	c.buf.WriteString(marker + strings.TrimSpace(text) + marker)
+}
+
+// inline renders the children of n on their own, for elements that need their text before writing
+func (c *markdownConverter) inline(n *html.Node) string {
+	inner := &markdownConverter{listDepth: c.listDepth, pre: c.pre}
+	inner.children(n)
+	return strings.ReplaceAll(strings.TrimSpace(inner.buf.String()), "\n\n", " ")
+}
+
+func (c *markdownConverter) list(n *html.Node) {
+	ordered := n.DataAtom == atom.Ol
+	indent := strings.Repeat("  ", c.listDepth)
+	if c.listDepth == 0 {
+		c.block()
+	} else {
+		c.buf.WriteString("\n")
+	}
+
+	c.listDepth++
+	i := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", i)
+		}
+		i++
+
+		inner := &markdownConverter{listDepth: c.listDepth}
+		inner.children(li)
+		text := strings.TrimSpace(blankLines.ReplaceAllString(inner.buf.String(), "\n"))
+		text = strings.ReplaceAll(text, "\n\n", "\n")
+		c.buf.WriteString(indent + marker + text + "\n")
+	}
+	c.listDepth--
+
+	if c.listDepth == 0 {
+		c.block()
+	}
+}
+
+func (c *markdownConverter) table(n *html.Node) {
+	var rows [][]string
+	header := false
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Tr:
+				var cells []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+						continue
+					}
+					if cell.DataAtom == atom.Th && len(rows) == 0 {
+						header = true
+					}
+					text := strings.ReplaceAll(c.inline(cell), "\n", " ")
+					cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+				}
+				rows = append(rows, cells)
+			case atom.Table:
+				// Nested tables are rendered as part of the enclosing cell text
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return
+	}
+
+	width := 0
+	for _, r := range rows {
+		if len(r) > width {
+			width = len(r)
+		}
+	}
+	if width == 0 {
+		return
+	}
+
+	// Markdown tables need a header row; use an empty one when the HTML has none
+	if !header {
+		rows = append([][]string{make([]string, width)}, rows...)
+	}
+
+	c.block()
+	for i, r := range rows {
+		cells := make([]string, width)
+		copy(cells, r)
+		c.buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			c.buf.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+		}
+	}
+	c.block()
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,290 @@
+package fileparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// Schemas of deep or wide documents are cut off to keep the description small
+const (
+	maxSchemaDepth      = 6
+	maxSchemaProperties = 200
+)
+
+// numberAPI keeps JSON numbers as json.Number so integers can be told apart from floats,
+// and sorts keys so query results are stable
+var numberAPI = sonic.Config{UseNumber: true, SortMapKeys: true}.Froze()
+
+// JSONSchema is the inferred shape of a JSON value
+type JSONSchema struct {
+	Type       string                 `json:"type"`                 // "object", "array", "string", "integer", "number", "boolean", "null", "mixed"
+	Nullable   bool                   `json:"nullable,omitempty"`   // Some values merged into this schema were null
+	Properties map[string]*JSONSchema `json:"properties,omitempty"` // Object keys
+	Items      *JSONSchema            `json:"items,omitempty"`      // Merged schema of array elements
+	Length     *int                   `json:"length,omitempty"`     // Array length
+	Truncated  bool                   `json:"truncated,omitempty"`  // Nested structure was not described
+}
+
+func (p *JSONParser) ParseStructured(content []byte, opts ParseOptions) (*FileContent, error) {
+	var data interface{}
+	if err := numberAPI.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	if opts.Query == nil {
+		return &FileContent{Type: "json", Metadata: &FileMetadata{Schema: describeJSON(data, 0)}}, nil
+	}
+
+	q := opts.Query
+	if len(q.Filters) > 0 || len(q.Columns) > 0 || q.Limit != 0 || q.Offset != 0 {
+		return nil, fmt.Errorf("%w: filters, columns, limit and offset can only be used with CSV files", ErrInvalidQuery)
+	}
+	if q.JSONPath == "" {
+		return nil, fmt.Errorf("%w: json_path is required for JSON files", ErrInvalidQuery)
+	}
+
+	result, err := evalJSONPath(data, q.JSONPath)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := numberAPI.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return &FileContent{Type: "json", Raw: string(raw), Metadata: &FileMetadata{Schema: describeJSON(result, 0)}}, nil
+}
+
+func describeJSON(v interface{}, depth int) *JSONSchema {
+	switch val := v.(type) {
+	case nil:
+		return &JSONSchema{Type: "null"}
+	case bool:
+		return &JSONSchema{Type: "boolean"}
+	case string:
+		return &JSONSchema{Type: "string"}
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return &JSONSchema{Type: "integer"}
+		}
+		return &JSONSchema{Type: "number"}
+	case float64:
+		return &JSONSchema{Type: "number"}
+	case []interface{}:
+		n := len(val)
+		s := &JSONSchema{Type: "array", Length: &n}
+		if depth >= maxSchemaDepth {
+			s.Truncated = n > 0
+			return s
+		}
+		for _, item := range val {
+			s.Items = mergeSchema(s.Items, describeJSON(item, depth+1))
+		}
+		return s
+	case map[string]interface{}:
+		s := &JSONSchema{Type: "object"}
+		if depth >= maxSchemaDepth {
+			s.Truncated = len(val) > 0
+			return s
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		if len(keys) > maxSchemaProperties {
+			keys = keys[:maxSchemaProperties]
+			s.Truncated = true
+		}
+		s.Properties = make(map[string]*JSONSchema, len(keys))
+		for _, k := range keys {
+			s.Properties[k] = describeJSON(val[k], depth+1)
+		}
+		return s
+	default:
+		return &JSONSchema{Type: "mixed"}
+	}
+}
+
+// mergeSchema combines the schemas of array elements. Keys missing from some objects become nullable.
+func mergeSchema(a *JSONSchema, b *JSONSchema) *JSONSchema {
+	if a == nil {
+		return b
+	}
+	if b.Type == "null" {
+		a.Nullable = true
+		return a
+	}
+	if a.Type == "null" {
+		b.Nullable = true
+		return b
+	}
+	if a.Type != b.Type {
+		if (a.Type == "integer" && b.Type == "number") || (a.Type == "number" && b.Type == "integer") {
+			a.Type = "number"
+			return a
+		}
+		return &JSONSchema{Type: "mixed", Nullable: a.Nullable || b.Nullable}
+	}
+
+	a.Nullable = a.Nullable || b.Nullable
+	a.Truncated = a.Truncated || b.Truncated
+	switch a.Type {
+	case "object":
+		for k, bs := range b.Properties {
+			if as, ok := a.Properties[k]; ok {
+				a.Properties[k] = mergeSchema(as, bs)
+			} else if len(a.Properties) < maxSchemaProperties {
+				bs.Nullable = true
+				a.Properties[k] = bs
+			} else {
+				a.Truncated = true
+			}
+		}
+		for k, as := range a.Properties {
+			if _, ok := b.Properties[k]; !ok {
+				as.Nullable = true
+			}
+		}
+	case "array":
+		// The merged schema describes element arrays of varying length
+		a.Length = nil
+		if b.Items != nil {
+			a.Items = mergeSchema(a.Items, b.Items)
+		}
+	}
+	return a
+}
+
+// jsonPathStep is one segment of a parsed JSONPath: a key, an index, or a wildcard
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses the supported JSONPath subset: $, .key, ['key'], ["key"], [n], [-n], [*] and .*
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("%w: json_path %q: %s", ErrInvalidQuery, path, reason)
+	}
+
+	rest := strings.TrimSpace(path)
+	if !strings.HasPrefix(rest, "$") {
+		return nil, invalid("must start with $")
+	}
+	rest = rest[1:]
+
+	var steps []jsonPathStep
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			if strings.HasPrefix(rest, ".") {
+				return nil, invalid("recursive descent is not supported")
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, invalid("empty key")
+			}
+			rest = rest[end:]
+			if key == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+			} else {
+				steps = append(steps, jsonPathStep{key: key})
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, invalid("unclosed [")
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, invalid(fmt.Sprintf("unsupported selector [%s]", inner))
+				}
+				steps = append(steps, jsonPathStep{index: n, isIndex: true})
+			}
+		default:
+			return nil, invalid(fmt.Sprintf("unexpected %q", rest[0]))
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath returns the value at path. Paths with wildcards return the list of matches;
+// a definite path that does not exist returns nil.
+func evalJSONPath(data interface{}, path string) (interface{}, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := []interface{}{data}
+	wildcard := false
+	for _, step := range steps {
+		var next []interface{}
+		for _, v := range current {
+			switch node := v.(type) {
+			case map[string]interface{}:
+				switch {
+				case step.wildcard:
+					keys := make([]string, 0, len(node))
+					for k := range node {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, node[k])
+					}
+				case !step.isIndex:
+					if child, ok := node[step.key]; ok {
+						next = append(next, child)
+					}
+				}
+			case []interface{}:
+				switch {
+				case step.wildcard:
+					next = append(next, node...)
+				case step.isIndex:
+					i := step.index
+					if i < 0 {
+						i += len(node)
+					}
+					if i >= 0 && i < len(node) {
+						next = append(next, node[i])
+					}
+				}
+			}
+		}
+		wildcard = wildcard || step.wildcard
+		current = next
+	}
+
+	if wildcard {
+		if current == nil {
+			return []interface{}{}, nil
+		}
+		return current, nil
+	}
+	if len(current) == 0 {
+		return nil, nil
+	}
+	return current[0], nil
+}
@@ -0,0 +1,241 @@
+package fileparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DOCXParser extracts paragraphs and tables from Word documents
+type DOCXParser struct{}
+
+func (p *DOCXParser) CanParse(filename string, mimeType string) bool {
+	return hasExt(filename, ".docx") ||
+		strings.HasPrefix(mimeType, "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+}
+
+func (p *DOCXParser) Parse(content []byte) (*FileContent, error) {
+	zr, err := openPackage(content)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readPart(zr, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("failed to parse DOCX: word/document.xml not found")
+	}
+
+	// Word documents have no fixed pagination; explicit page breaks delimit pages
+	pages, err := wordprocessingText(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DOCX: %w", err)
+	}
+
+	var b documentBuilder
+	for i, page := range pages {
+		b.add("page", i+1, "", page)
+	}
+	return b.content("docx"), nil
+}
+
+// wordprocessingText walks a WordprocessingML body. Paragraphs become lines and
+// table rows become tab separated lines. Text is split at explicit page breaks.
+func wordprocessingText(data []byte) ([]string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		pages     []string
+		page      strings.Builder
+		paragraph strings.Builder
+		cells     []string
+		inText    bool
+		tableRows int // Nesting depth of table rows
+	)
+	flushParagraph := func() {
+		text := strings.TrimRight(paragraph.String(), " ")
+		paragraph.Reset()
+		if tableRows > 0 {
+			if len(cells) > 0 && text != "" {
+				cells[len(cells)-1] = strings.TrimSpace(cells[len(cells)-1] + " " + text)
+			}
+			return
+		}
+		page.WriteString(text)
+		page.WriteString("\n")
+	}
+	breakPage := func() {
+		flushParagraph()
+		pages = append(pages, page.String())
+		page.Reset()
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				paragraph.WriteString("\t")
+			case "br", "cr":
+				if attr(t, "type") == "page" {
+					breakPage()
+				} else {
+					paragraph.WriteString("\n")
+				}
+			case "tr":
+				// Nested tables are flattened into the cells of the outermost row
+				tableRows++
+				if tableRows == 1 {
+					cells = cells[:0]
+				}
+			case "tc":
+				if tableRows == 1 {
+					cells = append(cells, "")
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				flushParagraph()
+			case "tr":
+				tableRows--
+				if tableRows == 0 {
+					page.WriteString(strings.Join(cells, "\t"))
+					page.WriteString("\n")
+				}
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		}
+	}
+
+	pages = append(pages, page.String())
+	return pages, nil
+}
+
+// PPTXParser extracts the text of each slide of a PowerPoint presentation
+type PPTXParser struct{}
+
+func (p *PPTXParser) CanParse(filename string, mimeType string) bool {
+	return hasExt(filename, ".pptx") ||
+		strings.HasPrefix(mimeType, "application/vnd.openxmlformats-officedocument.presentationml.presentation")
+}
+
+func (p *PPTXParser) Parse(content []byte) (*FileContent, error) {
+	zr, err := openPackage(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Slides are stored as ppt/slides/slideN.xml; order by N rather than lexically
+	type slidePart struct {
+		num  int
+		name string
+	}
+	var slides []slidePart
+	for _, f := range zr.File {
+		dir, file := path.Split(f.Name)
+		if dir != "ppt/slides/" || !strings.HasPrefix(file, "slide") || !strings.HasSuffix(file, ".xml") {
+			continue
+		}
+		num, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(file, "slide"), ".xml"))
+		if err != nil {
+			continue
+		}
+		slides = append(slides, slidePart{num: num, name: f.Name})
+	}
+	if len(slides) == 0 {
+		return nil, fmt.Errorf("failed to parse PPTX: no slides found")
+	}
+	sort.Slice(slides, func(i, j int) bool { return slides[i].num < slides[j].num })
+
+	var b documentBuilder
+	for i, slide := range slides {
+		data, err := readPart(zr, slide.name)
+		if err != nil {
+			return nil, err
+		}
+		text, err := drawingText(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PPTX slide %d: %w", slide.num, err)
+		}
+		b.add("slide", i+1, "", text)
+	}
+	return b.content("pptx"), nil
+}
+
+// drawingText collects DrawingML text runs, one line per paragraph
+func drawingText(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		lines     []string
+		paragraph strings.Builder
+		inText    bool
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "br":
+				paragraph.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if text := strings.TrimSpace(paragraph.String()); text != "" {
+					lines = append(lines, text)
+				}
+				paragraph.Reset()
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// attr returns the value of the attribute with the given local name
+func attr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package fileparser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDFParser extracts the text layer of PDF files page by page
+type PDFParser struct{}
+
+func (p *PDFParser) CanParse(filename string, mimeType string) bool {
+	return hasExt(filename, ".pdf") || strings.HasPrefix(mimeType, "application/pdf")
+}
+
+func (p *PDFParser) Parse(content []byte) (fc *FileContent, err error) {
+	// The PDF reader panics on some malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			fc = nil
+			err = fmt.Errorf("failed to parse PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PDF: %w", err)
+	}
+
+	var b documentBuilder
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract text from PDF page %d: %w", i, err)
+		}
+		b.add("page", i, "", normalizePDFText(text))
+	}
+
+	return b.content("pdf"), nil
+}
+
+// normalizePDFText trims trailing spaces and collapses runs of blank lines left by text object boundaries
+func normalizePDFText(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
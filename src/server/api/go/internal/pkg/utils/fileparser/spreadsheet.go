@@ -0,0 +1,408 @@
+package fileparser
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Spreadsheets may declare huge repeated ranges; anything beyond these bounds is dropped
+const (
+	maxSheetRows    = 100000
+	maxSheetColumns = 1024
+)
+
+// XLSXParser converts every worksheet of an Excel workbook to CSV
+type XLSXParser struct{}
+
+func (p *XLSXParser) CanParse(filename string, mimeType string) bool {
+	return hasExt(filename, ".xlsx") ||
+		strings.HasPrefix(mimeType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+}
+
+func (p *XLSXParser) Parse(content []byte) (*FileContent, error) {
+	zr, err := openPackage(content)
+	if err != nil {
+		return nil, err
+	}
+
+	workbook, err := readPart(zr, "xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	if workbook == nil {
+		return nil, fmt.Errorf("failed to parse XLSX: xl/workbook.xml not found")
+	}
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(workbook, &wb); err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX workbook: %w", err)
+	}
+
+	targets, err := xlsxRelationships(zr)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := xlsxSharedStrings(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	var b documentBuilder
+	for i, sheet := range wb.Sheets {
+		target, ok := targets[sheet.RID]
+		if !ok {
+			return nil, fmt.Errorf("failed to parse XLSX: sheet %q has no part", sheet.Name)
+		}
+		data, err := readPart(zr, target)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := xlsxRows(data, shared)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XLSX sheet %q: %w", sheet.Name, err)
+		}
+		text, err := toCSV(rows)
+		if err != nil {
+			return nil, err
+		}
+		b.add("sheet", i+1, sheet.Name, text)
+	}
+	return b.content("xlsx"), nil
+}
+
+// xlsxRelationships maps workbook relationship ids to package part names
+func xlsxRelationships(zr *zip.Reader) (map[string]string, error) {
+	data, err := readPart(zr, "xl/_rels/workbook.xml.rels")
+	if err != nil {
+		return nil, err
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if data != nil {
+		if err := xml.Unmarshal(data, &rels); err != nil {
+			return nil, fmt.Errorf("failed to parse XLSX relationships: %w", err)
+		}
+	}
+
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, r := range rels.Relationships {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+	return targets, nil
+}
+
+// xlsxSharedStrings loads the shared string table, joining rich text runs
+func xlsxSharedStrings(zr *zip.Reader) ([]string, error) {
+	data, err := readPart(zr, "xl/sharedStrings.xml")
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var sst struct {
+		Items []struct {
+			T    string `xml:"t"`
+			Runs []struct {
+				T string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := xml.Unmarshal(data, &sst); err != nil {
+		return nil, fmt.Errorf("failed to parse XLSX shared strings: %w", err)
+	}
+
+	out := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		var sb strings.Builder
+		sb.WriteString(si.T)
+		for _, r := range si.Runs {
+			sb.WriteString(r.T)
+		}
+		out[i] = sb.String()
+	}
+	return out, nil
+}
+
+// xlsxRows reads the cell values of a worksheet, placing cells by their reference so gaps are kept
+func xlsxRows(data []byte, shared []string) ([][]string, error) {
+	var ws struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline struct {
+					T    string `xml:"t"`
+					Runs []struct {
+						T string `xml:"t"`
+					} `xml:"r"`
+				} `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(data, &ws); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for i, row := range ws.Rows {
+		rowNum := row.R
+		if rowNum <= 0 {
+			rowNum = i + 1
+		}
+		if rowNum > maxSheetRows {
+			break
+		}
+		for len(rows) < rowNum {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for j, c := range row.Cells {
+			col := columnIndex(c.Ref)
+			if col < 0 {
+				col = j
+			}
+			if col >= maxSheetColumns {
+				continue
+			}
+
+			var value string
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err == nil && idx >= 0 && idx < len(shared) {
+					value = shared[idx]
+				}
+			case "inlineStr":
+				var sb strings.Builder
+				sb.WriteString(c.Inline.T)
+				for _, r := range c.Inline.Runs {
+					sb.WriteString(r.T)
+				}
+				value = sb.String()
+			case "b":
+				if c.Value == "1" {
+					value = "TRUE"
+				} else {
+					value = "FALSE"
+				}
+			default:
+				value = c.Value
+			}
+
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			cells[col] = value
+		}
+		rows[rowNum-1] = cells
+	}
+	return rows, nil
+}
+
+// columnIndex converts the column letters of a cell reference such as "AB12" to a 0-based index
+func columnIndex(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
+
+// ODSParser converts every table of an OpenDocument spreadsheet to CSV
+type ODSParser struct{}
+
+func (p *ODSParser) CanParse(filename string, mimeType string) bool {
+	return hasExt(filename, ".ods") ||
+		strings.HasPrefix(mimeType, "application/vnd.oasis.opendocument.spreadsheet")
+}
+
+func (p *ODSParser) Parse(content []byte) (*FileContent, error) {
+	zr, err := openPackage(content)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readPart(zr, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("failed to parse ODS: content.xml not found")
+	}
+
+	tables, err := odsTables(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ODS: %w", err)
+	}
+
+	var b documentBuilder
+	for i, t := range tables {
+		text, err := toCSV(t.rows)
+		if err != nil {
+			return nil, err
+		}
+		b.add("sheet", i+1, t.name, text)
+	}
+	return b.content("ods"), nil
+}
+
+type odsTable struct {
+	name string
+	rows [][]string
+}
+
+// odsTables streams content.xml, expanding repeated rows and columns.
+// Trailing empty cells and rows, which ODS uses to pad tables to their full size, are trimmed.
+func odsTables(data []byte) ([]odsTable, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		tables    []odsTable
+		current   *odsTable
+		row       []string
+		rowRepeat int
+		cell      strings.Builder
+		cellDepth int // >0 while inside a cell
+		cellParas int
+		colRepeat int
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Local == "table" && cellDepth == 0:
+				tables = append(tables, odsTable{name: attr(t, "name")})
+				current = &tables[len(tables)-1]
+			case t.Name.Local == "table-row" && current != nil && cellDepth == 0:
+				row = nil
+				rowRepeat = repeatCount(attr(t, "number-rows-repeated"), maxSheetRows)
+			case (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell") && current != nil:
+				if cellDepth == 0 {
+					cell.Reset()
+					cellParas = 0
+					colRepeat = repeatCount(attr(t, "number-columns-repeated"), maxSheetColumns)
+				}
+				cellDepth++
+			case cellDepth > 0 && t.Name.Local == "p":
+				if cellParas > 0 {
+					cell.WriteString("\n")
+				}
+				cellParas++
+			case cellDepth > 0 && t.Name.Local == "s":
+				cell.WriteString(strings.Repeat(" ", repeatCount(attr(t, "c"), 64)))
+			case cellDepth > 0 && t.Name.Local == "tab":
+				cell.WriteString("\t")
+			case cellDepth > 0 && t.Name.Local == "line-break":
+				cell.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch {
+			case (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell") && cellDepth > 0:
+				cellDepth--
+				if cellDepth == 0 {
+					value := cell.String()
+					for i := 0; i < colRepeat && len(row) < maxSheetColumns; i++ {
+						row = append(row, value)
+					}
+				}
+			case t.Name.Local == "table-row" && current != nil && cellDepth == 0:
+				row = trimTrailingEmpty(row)
+				for i := 0; i < rowRepeat && len(current.rows) < maxSheetRows; i++ {
+					current.rows = append(current.rows, row)
+				}
+			case t.Name.Local == "table" && cellDepth == 0 && current != nil:
+				for len(current.rows) > 0 && len(current.rows[len(current.rows)-1]) == 0 {
+					current.rows = current.rows[:len(current.rows)-1]
+				}
+				current = nil
+			}
+		case xml.CharData:
+			if cellDepth > 0 {
+				cell.Write(t)
+			}
+		}
+	}
+
+	return tables, nil
+}
+
+func repeatCount(value string, max int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 1
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+func trimTrailingEmpty(row []string) []string {
+	for len(row) > 0 && row[len(row)-1] == "" {
+		row = row[:len(row)-1]
+	}
+	return row
+}
+
+// toCSV renders rows as CSV, padding every row to the widest one so the output is rectangular
+func toCSV(rows [][]string) (string, error) {
+	width := 0
+	for _, r := range rows {
+		if len(r) > width {
+			width = len(r)
+		}
+	}
+	if width == 0 {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, r := range rows {
+		record := make([]string, width)
+		copy(record, r)
+		if err := w.Write(record); err != nil {
+			return "", fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
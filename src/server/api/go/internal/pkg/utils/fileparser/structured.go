@@ -0,0 +1,288 @@
+package fileparser
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	ErrQueryNotSupported   = errors.New("query is not supported for this file type")
+	ErrInvalidQuery        = errors.New("invalid query")
+)
+
+// PreviewRows is the number of CSV rows returned in structured mode when no query is given
+const PreviewRows = 20
+
+// ParseOptions selects structured mode for JSON and CSV files.
+// In structured mode Metadata describes the data and Raw only holds a preview:
+// the header and first PreviewRows rows for CSV, nothing for JSON.
+// With a query Raw holds the selected data instead.
+type ParseOptions struct {
+	Structured bool
+	Query      *Query
+}
+
+// Query selects part of a data file server-side
+type Query struct {
+	Filters  []string // CSV row filters "column<op>value" that must all match; op is one of = != > >= < <= ~ (contains)
+	Columns  []string // CSV columns to return, all when empty
+	Limit    int      // Maximum CSV rows to return, all when 0
+	Offset   int      // CSV matching rows to skip
+	JSONPath string   // JSON sub-tree to return, e.g. $.items[*].name
+}
+
+// TableInfo describes a CSV file
+type TableInfo struct {
+	Columns     []ColumnInfo `json:"columns"`
+	RowCount    int          `json:"row_count"`              // Data rows in the file, header excluded
+	MatchedRows *int         `json:"matched_rows,omitempty"` // Rows matching the query filters, before limit and offset
+}
+
+// ColumnInfo is a CSV column with the type inferred from its values
+type ColumnInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`     // "integer", "number", "boolean", "date", "string", "null" when every value is empty
+	Nullable bool   `json:"nullable"` // Whether some values are empty
+}
+
+func (p *CSVParser) ParseStructured(content []byte, opts ParseOptions) (*FileContent, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	var header []string
+	var rows [][]string
+	if len(records) > 0 {
+		header, rows = records[0], records[1:]
+	}
+
+	table := &TableInfo{RowCount: len(rows)}
+	if opts.Query == nil {
+		table.Columns = inferColumns(header, rows)
+		raw, err := toCSV(append([][]string{header}, rows[:min(len(rows), PreviewRows)]...))
+		if err != nil {
+			return nil, err
+		}
+		return &FileContent{Type: "csv", Raw: raw, Metadata: &FileMetadata{Table: table}}, nil
+	}
+
+	q := opts.Query
+	if q.JSONPath != "" {
+		return nil, fmt.Errorf("%w: json_path cannot be used with CSV files", ErrInvalidQuery)
+	}
+	columns, err := columnIndexes(header, q.Columns)
+	if err != nil {
+		return nil, err
+	}
+	filters, err := parseFilters(header, q.Filters)
+	if err != nil {
+		return nil, err
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return nil, fmt.Errorf("%w: limit and offset must not be negative", ErrInvalidQuery)
+	}
+
+	var matched [][]string
+	for _, row := range rows {
+		if matchFilters(row, filters) {
+			matched = append(matched, row)
+		}
+	}
+	matchedCount := len(matched)
+	table.MatchedRows = &matchedCount
+
+	matched = matched[min(q.Offset, len(matched)):]
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+
+	out := make([][]string, 0, len(matched)+1)
+	out = append(out, project(header, columns))
+	for _, row := range matched {
+		out = append(out, project(row, columns))
+	}
+	table.Columns = inferColumns(out[0], out[1:])
+
+	raw, err := toCSV(out)
+	if err != nil {
+		return nil, err
+	}
+	return &FileContent{Type: "csv", Raw: raw, Metadata: &FileMetadata{Table: table}}, nil
+}
+
+// columnIndexes resolves selected column names, returning nil to keep every column
+func columnIndexes(header []string, names []string) ([]int, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	idx := make([]int, 0, len(names))
+	for _, name := range names {
+		i := indexOf(header, name)
+		if i < 0 {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidQuery, name)
+		}
+		idx = append(idx, i)
+	}
+	return idx, nil
+}
+
+func project(row []string, columns []int) []string {
+	if columns == nil {
+		return row
+	}
+	out := make([]string, len(columns))
+	for i, c := range columns {
+		out[i] = row[c]
+	}
+	return out
+}
+
+func indexOf(values []string, v string) int {
+	for i, s := range values {
+		if strings.TrimSpace(s) == v {
+			return i
+		}
+	}
+	return -1
+}
+
+type rowFilter struct {
+	column int
+	op     string
+	value  string
+}
+
+// Longer operators first so that ">=" is not read as ">"
+var filterOps = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+func parseFilters(header []string, exprs []string) ([]rowFilter, error) {
+	filters := make([]rowFilter, 0, len(exprs))
+	for _, expr := range exprs {
+		pos, op := -1, ""
+		for _, candidate := range filterOps {
+			if i := strings.Index(expr, candidate); i > 0 && (pos < 0 || i < pos) {
+				pos, op = i, candidate
+			}
+		}
+		if pos < 0 {
+			return nil, fmt.Errorf("%w: filter %q must look like column=value", ErrInvalidQuery, expr)
+		}
+
+		name := strings.TrimSpace(expr[:pos])
+		column := indexOf(header, name)
+		if column < 0 {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidQuery, name)
+		}
+		filters = append(filters, rowFilter{column: column, op: op, value: strings.TrimSpace(expr[pos+len(op):])})
+	}
+	return filters, nil
+}
+
+func matchFilters(row []string, filters []rowFilter) bool {
+	for _, f := range filters {
+		if !f.match(row[f.column]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f rowFilter) match(cell string) bool {
+	cell = strings.TrimSpace(cell)
+	switch f.op {
+	case "=":
+		return cell == f.value
+	case "!=":
+		return cell != f.value
+	case "~":
+		return strings.Contains(strings.ToLower(cell), strings.ToLower(f.value))
+	}
+
+	// Ordering compares numerically when both sides are numbers, lexically otherwise
+	cmp := strings.Compare(cell, f.value)
+	a, errA := strconv.ParseFloat(cell, 64)
+	b, errB := strconv.ParseFloat(f.value, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	} else if cell == "" {
+		return false
+	}
+
+	switch f.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+// inferColumns picks the narrowest type that fits every non-empty value of each column
+func inferColumns(header []string, rows [][]string) []ColumnInfo {
+	infos := make([]ColumnInfo, len(header))
+	for i, name := range header {
+		infos[i] = ColumnInfo{Name: strings.TrimSpace(name), Type: "null"}
+	}
+	for _, row := range rows {
+		for i := range infos {
+			if i >= len(row) {
+				continue
+			}
+			v := strings.TrimSpace(row[i])
+			if v == "" {
+				infos[i].Nullable = true
+				continue
+			}
+			infos[i].Type = widenType(infos[i].Type, valueType(v))
+		}
+	}
+	return infos
+}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02", "2006-01-02 15:04:05"}
+
+func valueType(v string) string {
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return "integer"
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return "number"
+	}
+	if _, err := strconv.ParseBool(strings.ToLower(v)); err == nil && len(v) > 1 {
+		return "boolean"
+	}
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, v); err == nil {
+			return "date"
+		}
+	}
+	return "string"
+}
+
+func widenType(current string, next string) string {
+	switch {
+	case current == "null" || current == next:
+		return next
+	case (current == "integer" && next == "number") || (current == "number" && next == "integer"):
+		return "number"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,248 @@
+package fileparser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const ordersCSV = `id,status,amount,paid,created
+1,failed,10.5,true,2024-01-02
+2,ok,20,false,2024-01-03
+3,failed,7,,2024-01-04
+4,Failed again,100,true,2024-01-05
+`
+
+func TestCSVParser_Structured(t *testing.T) {
+	result, err := NewFileParser().ParseFileWithOptions("orders.csv", "text/csv", []byte(ordersCSV), ParseOptions{Structured: true})
+	if err != nil {
+		t.Fatalf("ParseFileWithOptions() error = %v", err)
+	}
+
+	table := result.Metadata.Table
+	if table.RowCount != 4 {
+		t.Errorf("row count = %d, want 4", table.RowCount)
+	}
+	if table.MatchedRows != nil {
+		t.Errorf("matched rows = %d, want nil without a query", *table.MatchedRows)
+	}
+	want := []ColumnInfo{
+		{Name: "id", Type: "integer"},
+		{Name: "status", Type: "string"},
+		{Name: "amount", Type: "number"},
+		{Name: "paid", Type: "boolean", Nullable: true},
+		{Name: "created", Type: "date"},
+	}
+	if !reflect.DeepEqual(table.Columns, want) {
+		t.Errorf("columns = %+v, want %+v", table.Columns, want)
+	}
+}
+
+func TestCSVParser_StructuredPreview(t *testing.T) {
+	content := "n\n"
+	for i := 0; i < PreviewRows+5; i++ {
+		content += "1\n"
+	}
+
+	result, err := (&CSVParser{}).ParseStructured([]byte(content), ParseOptions{Structured: true})
+	if err != nil {
+		t.Fatalf("ParseStructured() error = %v", err)
+	}
+
+	lines := len(strings.Split(result.Raw, "\n"))
+	if lines != PreviewRows+1 {
+		t.Errorf("preview lines = %d, want %d", lines, PreviewRows+1)
+	}
+	if result.Metadata.Table.RowCount != PreviewRows+5 {
+		t.Errorf("row count = %d, want %d", result.Metadata.Table.RowCount, PreviewRows+5)
+	}
+}
+
+func TestCSVParser_Query(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       Query
+		wantRaw     string
+		wantMatched int
+		wantErr     bool
+	}{
+		{
+			name:        "equality filter",
+			query:       Query{Filters: []string{"status=failed"}},
+			wantRaw:     "id,status,amount,paid,created\n1,failed,10.5,true,2024-01-02\n3,failed,7,,2024-01-04",
+			wantMatched: 2,
+		},
+		{
+			name:        "contains filter is case insensitive",
+			query:       Query{Filters: []string{"status~FAIL"}, Columns: []string{"id"}},
+			wantRaw:     "id\n1\n3\n4",
+			wantMatched: 3,
+		},
+		{
+			name:        "numeric comparison and column selection",
+			query:       Query{Filters: []string{"amount>=10", "status!=ok"}, Columns: []string{"amount", "id"}},
+			wantRaw:     "amount,id\n10.5,1\n100,4",
+			wantMatched: 2,
+		},
+		{
+			name:        "limit and offset apply after filtering",
+			query:       Query{Filters: []string{"amount<50"}, Columns: []string{"id"}, Offset: 1, Limit: 1},
+			wantRaw:     "id\n2",
+			wantMatched: 3,
+		},
+		{
+			name:        "no matches keeps the header",
+			query:       Query{Filters: []string{"status=unknown"}, Columns: []string{"id", "status"}},
+			wantRaw:     "id,status",
+			wantMatched: 0,
+		},
+		{name: "unknown filter column", query: Query{Filters: []string{"missing=1"}}, wantErr: true},
+		{name: "filter without operator", query: Query{Filters: []string{"status"}}, wantErr: true},
+		{name: "unknown column", query: Query{Columns: []string{"missing"}}, wantErr: true},
+		{name: "json path on CSV", query: Query{JSONPath: "$.a"}, wantErr: true},
+		{name: "negative limit", query: Query{Limit: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.query
+			result, err := NewFileParser().ParseFileWithOptions("orders.csv", "text/csv", []byte(ordersCSV), ParseOptions{Query: &q})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Fatalf("error = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFileWithOptions() error = %v", err)
+			}
+			if result.Raw != tt.wantRaw {
+				t.Errorf("raw = %q, want %q", result.Raw, tt.wantRaw)
+			}
+			table := result.Metadata.Table
+			if table.MatchedRows == nil || *table.MatchedRows != tt.wantMatched {
+				t.Errorf("matched rows = %v, want %d", table.MatchedRows, tt.wantMatched)
+			}
+			if table.RowCount != 4 {
+				t.Errorf("row count = %d, want 4", table.RowCount)
+			}
+		})
+	}
+}
+
+const usersJSON = `{
+  "version": 2,
+  "users": [
+    {"name": "ann", "age": 31, "tags": ["admin"]},
+    {"name": "bob", "age": 27.5, "email": null},
+    {"name": "cy", "age": 40, "email": "cy@example.com"}
+  ],
+  "meta": {"next page": null}
+}`
+
+func TestJSONParser_Structured(t *testing.T) {
+	result, err := NewFileParser().ParseFileWithOptions("users.json", "application/json", []byte(usersJSON), ParseOptions{Structured: true})
+	if err != nil {
+		t.Fatalf("ParseFileWithOptions() error = %v", err)
+	}
+	if result.Raw != "" {
+		t.Errorf("raw = %q, want empty in structured mode", result.Raw)
+	}
+
+	one, three := 1, 3
+	want := &JSONSchema{
+		Type: "object",
+		Properties: map[string]*JSONSchema{
+			"version": {Type: "integer"},
+			"users": {
+				Type:   "array",
+				Length: &three,
+				Items: &JSONSchema{
+					Type: "object",
+					Properties: map[string]*JSONSchema{
+						"name":  {Type: "string"},
+						"age":   {Type: "number"},
+						"tags":  {Type: "array", Nullable: true, Items: &JSONSchema{Type: "string"}, Length: &one},
+						"email": {Type: "string", Nullable: true},
+					},
+				},
+			},
+			"meta": {
+				Type:       "object",
+				Properties: map[string]*JSONSchema{"next page": {Type: "null"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(result.Metadata.Schema, want) {
+		t.Errorf("schema = %+v, want %+v", result.Metadata.Schema, want)
+	}
+}
+
+func TestJSONParser_Query(t *testing.T) {
+	tests := []struct {
+		path     string
+		wantRaw  string
+		wantType string
+		wantErr  bool
+	}{
+		{path: "$", wantRaw: `{"meta":{"next page":null},"users":[{"age":31,"name":"ann","tags":["admin"]},{"age":27.5,"email":null,"name":"bob"},{"age":40,"email":"cy@example.com","name":"cy"}],"version":2}`, wantType: "object"},
+		{path: "$.users[0].name", wantRaw: `"ann"`, wantType: "string"},
+		{path: "$.users[-1]['email']", wantRaw: `"cy@example.com"`, wantType: "string"},
+		{path: `$.meta["next page"]`, wantRaw: `null`, wantType: "null"},
+		{path: "$.users[*].age", wantRaw: `[31,27.5,40]`, wantType: "array"},
+		{path: "$.users.*.tags[0]", wantRaw: `["admin"]`, wantType: "array"},
+		{path: "$.missing[*]", wantRaw: `[]`, wantType: "array"},
+		{path: "$.missing", wantRaw: `null`, wantType: "null"},
+		{path: "users", wantErr: true},
+		{path: "$..name", wantErr: true},
+		{path: "$.users[", wantErr: true},
+		{path: "$.users[1:2]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := NewFileParser().ParseFileWithOptions("users.json", "", []byte(usersJSON), ParseOptions{Query: &Query{JSONPath: tt.path}})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Fatalf("error = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFileWithOptions() error = %v", err)
+			}
+			if result.Raw != tt.wantRaw {
+				t.Errorf("raw = %s, want %s", result.Raw, tt.wantRaw)
+			}
+			if result.Metadata.Schema.Type != tt.wantType {
+				t.Errorf("schema type = %s, want %s", result.Metadata.Schema.Type, tt.wantType)
+			}
+		})
+	}
+
+	_, err := NewFileParser().ParseFileWithOptions("users.json", "", []byte(usersJSON), ParseOptions{Query: &Query{Filters: []string{"a=b"}}})
+	if !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("error = %v, want ErrInvalidQuery for a CSV filter on JSON", err)
+	}
+}
+
+func TestParseFileWithOptions_Unsupported(t *testing.T) {
+	parser := NewFileParser()
+
+	// Structured mode falls back to plain parsing for other file types
+	result, err := parser.ParseFileWithOptions("notes.txt", "text/plain", []byte("hello"), ParseOptions{Structured: true})
+	if err != nil || result.Raw != "hello" {
+		t.Errorf("ParseFileWithOptions() = %v, %v; want plain text", result, err)
+	}
+
+	_, err = parser.ParseFileWithOptions("notes.txt", "text/plain", []byte("hello"), ParseOptions{Query: &Query{JSONPath: "$"}})
+	if !errors.Is(err, ErrQueryNotSupported) {
+		t.Errorf("error = %v, want ErrQueryNotSupported", err)
+	}
+
+	_, err = parser.ParseFileWithOptions("image.png", "image/png", nil, ParseOptions{})
+	if !errors.Is(err, ErrUnsupportedFileType) {
+		t.Errorf("error = %v, want ErrUnsupportedFileType", err)
+	}
+}
@@ -2,6 +2,7 @@ package path
 
 import (
 	"errors"
+	"sort"
 	"strings"
 )
 
@@ -169,3 +170,114 @@ func SplitFilePath(filePath string) (path, filename string) {
 
 	return path, filename
 }
+
+// DirectoryStat holds aggregated file statistics for a single directory path,
+// counting only the files stored directly under that path
+type DirectoryStat struct {
+	Path      string `json:"path"`
+	FileCount int64  `json:"file_count"`
+	TotalSize int64  `json:"total_size"`
+}
+
+// DirectoryNode is a node in a directory tree. FileCount and TotalSize are
+// aggregated over the whole subtree, including files below the depth limit.
+type DirectoryNode struct {
+	Name      string           `json:"name"`
+	Path      string           `json:"path"`
+	FileCount int64            `json:"file_count"`
+	TotalSize int64            `json:"total_size"`
+	Children  []*DirectoryNode `json:"children,omitempty"`
+}
+
+// Depth returns the number of directory levels in a normalized path
+// Examples:
+//
+//	"/" -> 0
+//	"/documents/" -> 1
+//	"/documents/work/" -> 2
+func Depth(path string) int {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return 0
+	}
+	return strings.Count(trimmed, "/") + 1
+}
+
+// BuildDirectoryTree builds a directory tree rooted at parentPath from per-directory stats.
+// Stats for paths outside parentPath are ignored. A depth of 0 means unlimited;
+// otherwise only depth levels of children are materialized below the root.
+func BuildDirectoryTree(parentPath string, stats []DirectoryStat, depth int) *DirectoryNode {
+	parentPath = normalizeDirPath(parentPath)
+
+	root := &DirectoryNode{Name: "", Path: parentPath}
+	if parentPath != "/" {
+		root.Name = lastSegment(parentPath)
+	}
+
+	nodes := map[string]*DirectoryNode{parentPath: root}
+	rootDepth := Depth(parentPath)
+
+	for _, st := range stats {
+		p := normalizeDirPath(st.Path)
+		if !strings.HasPrefix(p, parentPath) {
+			continue
+		}
+
+		root.FileCount += st.FileCount
+		root.TotalSize += st.TotalSize
+
+		// Walk each directory between parentPath and p, creating nodes on the way
+		rel := strings.Trim(strings.TrimPrefix(p, parentPath), "/")
+		if rel == "" {
+			continue
+		}
+		current := parentPath
+		for _, seg := range strings.Split(rel, "/") {
+			current = current + seg + "/"
+			if depth > 0 && Depth(current)-rootDepth > depth {
+				break
+			}
+			node, ok := nodes[current]
+			if !ok {
+				node = &DirectoryNode{Name: seg, Path: current}
+				nodes[current] = node
+				parent := nodes[current[:len(current)-len(seg)-1]]
+				parent.Children = append(parent.Children, node)
+			}
+			node.FileCount += st.FileCount
+			node.TotalSize += st.TotalSize
+		}
+	}
+
+	sortDirectoryTree(root)
+	return root
+}
+
+func sortDirectoryTree(node *DirectoryNode) {
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	for _, child := range node.Children {
+		sortDirectoryTree(child)
+	}
+}
+
+// normalizeDirPath ensures a directory path starts and ends with '/'
+func normalizeDirPath(p string) string {
+	p = strings.TrimSpace(p)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	if !strings.HasSuffix(p, "/") {
+		p = p + "/"
+	}
+	return p
+}
+
+func lastSegment(dirPath string) string {
+	trimmed := strings.Trim(dirPath, "/")
+	if idx := strings.LastIndex(trimmed, "/"); idx >= 0 {
+		return trimmed[idx+1:]
+	}
+	return trimmed
+}
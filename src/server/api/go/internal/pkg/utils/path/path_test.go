@@ -670,3 +670,72 @@ func TestSplitFilePath(t *testing.T) {
 		})
 	}
 }
+
+func TestDepth(t *testing.T) {
+	assert.Equal(t, 0, Depth("/"))
+	assert.Equal(t, 0, Depth(""))
+	assert.Equal(t, 1, Depth("/documents/"))
+	assert.Equal(t, 2, Depth("/documents/work/"))
+	assert.Equal(t, 2, Depth("documents/work"))
+}
+
+func TestBuildDirectoryTree(t *testing.T) {
+	stats := []DirectoryStat{
+		{Path: "/", FileCount: 1, TotalSize: 10},
+		{Path: "/documents/", FileCount: 2, TotalSize: 200},
+		{Path: "/documents/work/", FileCount: 3, TotalSize: 300},
+		{Path: "/documents/work/2024/", FileCount: 4, TotalSize: 400},
+		{Path: "/images/", FileCount: 5, TotalSize: 500},
+	}
+
+	t.Run("unlimited depth from root", func(t *testing.T) {
+		root := BuildDirectoryTree("/", stats, 0)
+
+		assert.Equal(t, "/", root.Path)
+		assert.Equal(t, int64(15), root.FileCount)
+		assert.Equal(t, int64(1410), root.TotalSize)
+		assert.Len(t, root.Children, 2)
+
+		docs := root.Children[0]
+		assert.Equal(t, "documents", docs.Name)
+		assert.Equal(t, "/documents/", docs.Path)
+		assert.Equal(t, int64(9), docs.FileCount)
+		assert.Equal(t, int64(900), docs.TotalSize)
+
+		work := docs.Children[0]
+		assert.Equal(t, "/documents/work/", work.Path)
+		assert.Equal(t, int64(7), work.FileCount)
+		assert.Len(t, work.Children, 1)
+		assert.Equal(t, "2024", work.Children[0].Name)
+
+		assert.Equal(t, "images", root.Children[1].Name)
+		assert.Empty(t, root.Children[1].Children)
+	})
+
+	t.Run("depth limit keeps subtree totals", func(t *testing.T) {
+		root := BuildDirectoryTree("/", stats, 1)
+
+		assert.Len(t, root.Children, 2)
+		docs := root.Children[0]
+		assert.Empty(t, docs.Children)
+		assert.Equal(t, int64(9), docs.FileCount)
+	})
+
+	t.Run("nested parent ignores unrelated paths", func(t *testing.T) {
+		root := BuildDirectoryTree("/documents", stats, 0)
+
+		assert.Equal(t, "documents", root.Name)
+		assert.Equal(t, "/documents/", root.Path)
+		assert.Equal(t, int64(9), root.FileCount)
+		assert.Len(t, root.Children, 1)
+		assert.Equal(t, "work", root.Children[0].Name)
+	})
+
+	t.Run("no stats", func(t *testing.T) {
+		root := BuildDirectoryTree("/empty/", nil, 0)
+
+		assert.Equal(t, "/empty/", root.Path)
+		assert.Zero(t, root.FileCount)
+		assert.Empty(t, root.Children)
+	})
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,9 +10,11 @@ import (
 
 	_ "github.com/memodb-io/Acontext/docs"
 	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/infra/blob"
 	"github.com/memodb-io/Acontext/internal/middleware"
 	"github.com/memodb-io/Acontext/internal/modules/handler"
 	"github.com/memodb-io/Acontext/internal/modules/serializer"
+	"github.com/memodb-io/Acontext/internal/pkg/apierr"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -23,10 +26,24 @@ type RouterDeps struct {
 	SpaceHandler    *handler.SpaceHandler
 	BlockHandler    *handler.BlockHandler
 	SessionHandler  *handler.SessionHandler
+	EventHandler    *handler.SessionEventHandler
 	DiskHandler     *handler.DiskHandler
 	ArtifactHandler *handler.ArtifactHandler
+	UploadHandler   *handler.ArtifactUploadHandler
+	ShareHandler    *handler.ShareHandler
+	BlobHandler     *handler.BlobHandler
 	TaskHandler     *handler.TaskHandler
 	ToolHandler     *handler.ToolHandler
+	UsageHandler    *handler.UsageHandler
+	AuditHandler    *handler.AuditHandler
+	WebhookHandler  *handler.WebhookHandler
+	ProjectHandler  *handler.ProjectHandler
+	ConfigHandler   *handler.ConfigHandler
+	TrashHandler    *handler.TrashHandler
+	Tenant          *middleware.Tenant
+	RateLimit       *middleware.RateLimit
+	Idempotency     *middleware.Idempotency
+	Audit           *middleware.Audit
 }
 
 func NewRouter(d RouterDeps) *gin.Engine {
@@ -34,7 +51,12 @@ func NewRouter(d RouterDeps) *gin.Engine {
 	serializer.SetLogger(d.Log)
 
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.Use(gin.CustomRecovery(func(c *gin.Context, rec any) {
+		serializer.Err(c, apierr.Internal, "", fmt.Errorf("panic: %v", rec))
+	}))
+	r.NoRoute(func(c *gin.Context) {
+		serializer.Err(c, apierr.NotFound, "no route matches "+c.Request.Method+" "+c.Request.URL.Path, nil)
+	})
 
 	// Add OpenTelemetry middleware if enabled (using configuration system)
 	if d.Config.Telemetry.Enabled && d.Config.Telemetry.OtlpEndpoint != "" {
@@ -54,89 +76,198 @@ func NewRouter(d RouterDeps) *gin.Engine {
 	})
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// public share links, authenticated by the token in the path
+	share := r.Group("/api/v1/share/:token")
+	{
+		share.Use(d.ShareHandler.ResolveShare(), d.RateLimit.Requests(), d.Audit.Handle())
+
+		share.GET("", d.ShareHandler.GetShare)
+		share.GET("/ls", d.ShareHandler.ListSharedArtifacts)
+		share.GET("/artifact", d.ShareHandler.DownloadSharedArtifact)
+		share.POST("/artifact", d.ShareHandler.UploadSharedArtifact)
+		share.DELETE("/artifact", d.ShareHandler.DeleteSharedArtifact)
+		share.GET("/export", d.ShareHandler.ExportSharedArchive)
+	}
+
+	// presigned URLs of the local and memory blob drivers, authenticated by their signature
+	r.GET(blob.SignedURLPath+"*key", d.BlobHandler.GetObject)
+	r.PUT(blob.SignedURLPath+"*key", d.Audit.Handle(), d.BlobHandler.PutObject)
+
+	// admin API, authenticated by the root token
+	admin := r.Group("/api/v1/admin")
+	{
+		admin.Use(middleware.RootAuth(d.Config), d.Audit.Handle())
+
+		admin.GET("/project", d.ProjectHandler.ListProjects)
+		admin.POST("/project", d.ProjectHandler.CreateProject)
+		admin.GET("/project/:project_id", d.ProjectHandler.GetProject)
+		admin.PUT("/project/:project_id/configs", d.ProjectHandler.UpdateConfigs)
+		admin.PATCH("/project/:project_id/configs", d.ConfigHandler.PatchProjectConfigs)
+		admin.DELETE("/project/:project_id", d.ProjectHandler.DeleteProject)
+		admin.GET("/project/:project_id/key", d.ProjectHandler.ListKeys)
+		admin.POST("/project/:project_id/key", d.ProjectHandler.CreateKey)
+		admin.POST("/project/:project_id/key/:key_id/rotate", d.ProjectHandler.RotateKey)
+		admin.DELETE("/project/:project_id/key/:key_id", d.ProjectHandler.RevokeKey)
+		admin.POST("/project/:project_id/secret/rotate", d.ProjectHandler.RotateSecret)
+		admin.DELETE("/project/:project_id/secret", d.ProjectHandler.RevokeSecret)
+	}
+
 	v1 := r.Group("/api/v1")
+	// Replays answered by Idempotency are not audited again
+	v1.Use(middleware.ProjectAuth(d.Config, d.DB), d.RateLimit.Requests(), d.Idempotency.Handle(), d.Audit.Handle())
+	registerProjectRoutes(v1, d)
+
+	return r
+}
+
+// registerProjectRoutes registers the routes of the project API on a group whose requests
+// are authenticated as a project. Every route naming a session, space, disk or block
+// resolves it through the tenant loaders, so resources of other projects are not found.
+// Restores are the exception: the loaders do not find resources in the trash, so the trash
+// service looks them up in the project itself.
+func registerProjectRoutes(v1 *gin.RouterGroup, d RouterDeps) {
+	// ping endpoint
+	v1.GET("/ping", func(c *gin.Context) { c.JSON(http.StatusOK, serializer.Response{Msg: "pong"}) })
+
+	v1.GET("/project/usage", d.UsageHandler.GetUsage)
+	v1.GET("/config/schema", d.ConfigHandler.GetConfigSchema)
+	v1.GET("/audit", d.AuditHandler.ListAuditEvents)
+
+	webhook := v1.Group("/webhook")
 	{
-		v1.Use(middleware.ProjectAuth(d.Config, d.DB))
+		webhook.GET("", d.WebhookHandler.ListWebhooks)
+		webhook.POST("", d.WebhookHandler.CreateWebhook)
+		webhook.GET("/:webhook_id", d.WebhookHandler.GetWebhook)
+		webhook.PATCH("/:webhook_id", d.WebhookHandler.UpdateWebhook)
+		webhook.DELETE("/:webhook_id", d.WebhookHandler.DeleteWebhook)
+		webhook.GET("/:webhook_id/delivery", d.WebhookHandler.ListWebhookDeliveries)
+		webhook.POST("/:webhook_id/delivery/:delivery_id/replay", d.WebhookHandler.ReplayWebhookDelivery)
+	}
 
-		// ping endpoint
-		v1.GET("/ping", func(c *gin.Context) { c.JSON(http.StatusOK, serializer.Response{Msg: "pong"}) })
+	space := v1.Group("/space")
+	{
+		space.GET("/status")
 
-		space := v1.Group("/space")
-		{
-			space.GET("/status")
+		space.GET("", d.SpaceHandler.GetSpaces)
+		space.POST("", d.SpaceHandler.CreateSpace)
+		space.GET("/trash", d.TrashHandler.ListTrashedSpaces)
+		space.POST("/:space_id/restore", d.TrashHandler.RestoreSpace)
 
-			space.GET("", d.SpaceHandler.GetSpaces)
-			space.POST("", d.SpaceHandler.CreateSpace)
-			space.DELETE("/:space_id", d.SpaceHandler.DeleteSpace)
+		scoped := space.Group("/:space_id", d.Tenant.Space())
+		{
+			scoped.DELETE("", d.SpaceHandler.DeleteSpace)
 
-			space.PUT("/:space_id/configs", d.SpaceHandler.UpdateConfigs)
-			space.GET("/:space_id/configs", d.SpaceHandler.GetConfigs)
+			scoped.PUT("/configs", d.SpaceHandler.UpdateConfigs)
+			scoped.PATCH("/configs", d.ConfigHandler.PatchSpaceConfigs)
+			scoped.GET("/configs", d.SpaceHandler.GetConfigs)
+			scoped.GET("/effective_configs", d.ConfigHandler.GetSpaceEffectiveConfigs)
 
-			space.GET("/:space_id/experience_search", d.SpaceHandler.GetExperienceSearch)
+			scoped.GET("/experience_search", d.RateLimit.Concurrency(nil), d.SpaceHandler.GetExperienceSearch)
 
-			space.GET("/:space_id/experience_confirmations", d.SpaceHandler.ListExperienceConfirmations)
-			space.PUT("/:space_id/experience_confirmations/:experience_id", d.SpaceHandler.ConfirmExperience)
+			scoped.GET("/experience_confirmations", d.SpaceHandler.ListExperienceConfirmations)
+			scoped.PUT("/experience_confirmations/:experience_id", d.SpaceHandler.ConfirmExperience)
 
-			block := space.Group("/:space_id/block")
+			block := scoped.Group("/block")
 			{
 				block.GET("", d.BlockHandler.ListBlocks)
 				block.POST("", d.BlockHandler.CreateBlock)
-				block.DELETE("/:block_id", d.BlockHandler.DeleteBlock)
 
-				block.GET("/:block_id/properties", d.BlockHandler.GetBlockProperties)
-				block.PUT("/:block_id/properties", d.BlockHandler.UpdateBlockProperties)
+				scopedBlock := block.Group("/:block_id", d.Tenant.Block())
+				{
+					scopedBlock.DELETE("", d.BlockHandler.DeleteBlock)
+
+					scopedBlock.GET("/properties", d.BlockHandler.GetBlockProperties)
+					scopedBlock.PUT("/properties", d.BlockHandler.UpdateBlockProperties)
 
-				block.PUT("/:block_id/move", d.BlockHandler.MoveBlock)
-				block.PUT("/:block_id/sort", d.BlockHandler.UpdateBlockSort)
+					scopedBlock.PUT("/move", d.BlockHandler.MoveBlock)
+					scopedBlock.PUT("/sort", d.BlockHandler.UpdateBlockSort)
+				}
 			}
 		}
+	}
+
+	session := v1.Group("/session")
+	{
+		session.GET("", d.SessionHandler.GetSessions)
+		session.POST("", d.SessionHandler.CreateSession)
+		session.GET("/trash", d.TrashHandler.ListTrashedSessions)
+		session.POST("/:session_id/restore", d.TrashHandler.RestoreSession)
 
-		session := v1.Group("/session")
+		scoped := session.Group("/:session_id", d.Tenant.Session())
 		{
-			session.GET("", d.SessionHandler.GetSessions)
-			session.POST("", d.SessionHandler.CreateSession)
-			session.DELETE("/:session_id", d.SessionHandler.DeleteSession)
+			scoped.DELETE("", d.SessionHandler.DeleteSession)
 
-			session.PUT("/:session_id/configs", d.SessionHandler.UpdateConfigs)
-			session.GET("/:session_id/configs", d.SessionHandler.GetConfigs)
+			scoped.PUT("/configs", d.SessionHandler.UpdateConfigs)
+			scoped.PATCH("/configs", d.ConfigHandler.PatchSessionConfigs)
+			scoped.GET("/configs", d.SessionHandler.GetConfigs)
+			scoped.GET("/effective_configs", d.ConfigHandler.GetSessionEffectiveConfigs)
 
-			session.POST("/:session_id/connect_to_space", d.SessionHandler.ConnectToSpace)
+			scoped.POST("/connect_to_space", d.SessionHandler.ConnectToSpace)
 
-			session.POST("/:session_id/messages", d.SessionHandler.StoreMessage)
-			session.GET("/:session_id/messages", d.SessionHandler.GetMessages)
+			scoped.POST("/messages", d.SessionHandler.StoreMessage)
+			scoped.GET("/messages", d.RateLimit.Concurrency(editsMessages), d.SessionHandler.GetMessages)
 
-			session.POST("/:session_id/flush", d.SessionHandler.SessionFlush)
-			session.GET("/:session_id/get_learning_status", d.SessionHandler.GetLearningStatus)
+			scoped.POST("/flush", d.SessionHandler.SessionFlush)
+			scoped.GET("/get_learning_status", d.SessionHandler.GetLearningStatus)
 
-			session.GET("/:session_id/token_counts", d.SessionHandler.GetTokenCounts)
+			scoped.GET("/token_counts", d.SessionHandler.GetTokenCounts)
 
-			task := session.Group("/:session_id/task")
+			scoped.GET("/events", d.EventHandler.StreamSessionEvents)
+
+			task := scoped.Group("/task")
 			{
 				task.GET("", d.TaskHandler.GetTasks)
 			}
 		}
+	}
+
+	disk := v1.Group("/disk")
+	{
+		disk.GET("", d.DiskHandler.ListDisks)
+		disk.POST("", d.DiskHandler.CreateDisk)
+		disk.GET("/by_name", d.DiskHandler.GetDiskByName)
+		disk.GET("/trash", d.TrashHandler.ListTrashedDisks)
+		disk.POST("/:disk_id/restore", d.TrashHandler.RestoreDisk)
 
-		disk := v1.Group("/disk")
+		scoped := disk.Group("/:disk_id", d.Tenant.Disk())
 		{
-			disk.GET("", d.DiskHandler.ListDisks)
-			disk.POST("", d.DiskHandler.CreateDisk)
-			disk.DELETE("/:disk_id", d.DiskHandler.DeleteDisk)
+			scoped.GET("", d.DiskHandler.GetDisk)
+			scoped.PUT("", d.DiskHandler.UpdateDisk)
+			scoped.DELETE("", d.DiskHandler.DeleteDisk)
+			scoped.GET("/usage", d.DiskHandler.GetDiskUsage)
+			scoped.POST("/import", d.ArtifactHandler.ImportArchive)
+			scoped.GET("/export", d.ArtifactHandler.ExportArchive)
 
-			artifact := disk.Group("/:disk_id/artifact")
+			scoped.POST("/share", d.ShareHandler.CreateShare)
+			scoped.GET("/share", d.ShareHandler.ListShares)
+			scoped.DELETE("/share/:share_id", d.ShareHandler.RevokeShare)
+
+			artifact := scoped.Group("/artifact")
 			{
 				artifact.POST("", d.ArtifactHandler.UpsertArtifact)
 				artifact.GET("", d.ArtifactHandler.GetArtifact)
 				artifact.PUT("", d.ArtifactHandler.UpdateArtifact)
 				artifact.DELETE("", d.ArtifactHandler.DeleteArtifact)
 				artifact.GET("/ls", d.ArtifactHandler.ListArtifacts)
+				artifact.DELETE("/dir", d.ArtifactHandler.DeleteDirectory)
+				artifact.POST("/batch", d.ArtifactHandler.BatchArtifacts)
+				artifact.GET("/search", d.ArtifactHandler.SearchArtifacts)
+
+				artifact.POST("/upload", d.UploadHandler.CreateUpload)
+				artifact.POST("/upload/:upload_id/commit", d.UploadHandler.CommitUpload)
+				artifact.DELETE("/upload/:upload_id", d.UploadHandler.AbortUpload)
 			}
 		}
+	}
 
-		tool := v1.Group("/tool")
-		{
-			tool.PUT("/name", d.ToolHandler.RenameToolName)
-			tool.GET("/name", d.ToolHandler.GetToolName)
-		}
+	tool := v1.Group("/tool")
+	{
+		tool.PUT("/name", d.ToolHandler.RenameToolName)
+		tool.GET("/name", d.ToolHandler.GetToolName)
 	}
-	return r
+}
+
+// editsMessages picks the message reads that apply edit strategies, which are expensive to serve
+func editsMessages(c *gin.Context) bool {
+	return c.Query("edit_strategies") != ""
 }
@@ -0,0 +1,371 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/memodb-io/Acontext/internal/config"
+	"github.com/memodb-io/Acontext/internal/middleware"
+	"github.com/memodb-io/Acontext/internal/modules/handler"
+	"github.com/memodb-io/Acontext/internal/modules/model"
+	"github.com/memodb-io/Acontext/internal/modules/repo"
+	"github.com/memodb-io/Acontext/internal/modules/service"
+)
+
+// The repo stubs only implement the lookups the tenant loaders use
+
+type stubSessionRepo struct {
+	repo.SessionRepo
+	rows map[uuid.UUID]*model.Session
+}
+
+func (r stubSessionRepo) Get(_ context.Context, projectID uuid.UUID, id uuid.UUID) (*model.Session, error) {
+	if s, ok := r.rows[id]; ok && s.ProjectID == projectID {
+		return s, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type stubSpaceRepo struct {
+	repo.SpaceRepo
+	rows map[uuid.UUID]*model.Space
+}
+
+func (r stubSpaceRepo) Get(_ context.Context, projectID uuid.UUID, id uuid.UUID) (*model.Space, error) {
+	if s, ok := r.rows[id]; ok && s.ProjectID == projectID {
+		return s, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type stubDiskRepo struct {
+	repo.DiskRepo
+	rows map[uuid.UUID]*model.Disk
+}
+
+func (r stubDiskRepo) Get(_ context.Context, projectID uuid.UUID, id uuid.UUID) (*model.Disk, error) {
+	if d, ok := r.rows[id]; ok && d.ProjectID == projectID {
+		return d, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type stubBlockRepo struct {
+	repo.BlockRepo
+	rows map[uuid.UUID]*model.Block
+}
+
+func (r stubBlockRepo) Get(_ context.Context, spaceID uuid.UUID, id uuid.UUID) (*model.Block, error) {
+	if b, ok := r.rows[id]; ok && b.SpaceID == spaceID {
+		return b, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// stubTrashRepo holds resources in the trash, restored only into their own project
+type stubTrashRepo struct {
+	repo.TrashRepo
+	sessions map[uuid.UUID]*model.Session
+	spaces   map[uuid.UUID]*model.Space
+	disks    map[uuid.UUID]*model.Disk
+}
+
+func (r stubTrashRepo) RestoreSession(_ context.Context, projectID uuid.UUID, id uuid.UUID, _ time.Time) (*model.Session, error) {
+	if s, ok := r.sessions[id]; ok && s.ProjectID == projectID {
+		return s, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r stubTrashRepo) RestoreSpace(_ context.Context, projectID uuid.UUID, id uuid.UUID, _ time.Time) (*model.Space, error) {
+	if s, ok := r.spaces[id]; ok && s.ProjectID == projectID {
+		return s, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r stubTrashRepo) RestoreDisk(_ context.Context, projectID uuid.UUID, id uuid.UUID, _ time.Time) (*model.Disk, error) {
+	if d, ok := r.disks[id]; ok && d.ProjectID == projectID {
+		return d, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// tenantFixture is a project with one resource of each kind
+type tenantFixture struct {
+	project *model.Project
+	session *model.Session
+	space   *model.Space
+	disk    *model.Disk
+	block   *model.Block
+}
+
+func newTenantFixture() tenantFixture {
+	project := &model.Project{ID: uuid.New()}
+	space := &model.Space{ID: uuid.New(), ProjectID: project.ID}
+	return tenantFixture{
+		project: project,
+		session: &model.Session{ID: uuid.New(), ProjectID: project.ID},
+		space:   space,
+		disk:    &model.Disk{ID: uuid.New(), ProjectID: project.ID},
+		block:   &model.Block{ID: uuid.New(), SpaceID: space.ID},
+	}
+}
+
+func newTestTenant(fixtures ...tenantFixture) *middleware.Tenant {
+	sessions := stubSessionRepo{rows: map[uuid.UUID]*model.Session{}}
+	spaces := stubSpaceRepo{rows: map[uuid.UUID]*model.Space{}}
+	disks := stubDiskRepo{rows: map[uuid.UUID]*model.Disk{}}
+	blocks := stubBlockRepo{rows: map[uuid.UUID]*model.Block{}}
+	for _, f := range fixtures {
+		sessions.rows[f.session.ID] = f.session
+		spaces.rows[f.space.ID] = f.space
+		disks.rows[f.disk.ID] = f.disk
+		blocks.rows[f.block.ID] = f.block
+	}
+	return middleware.NewTenant(sessions, spaces, disks, blocks)
+}
+
+// newTestTrash serves restores of the resources of the fixtures, as if they were in the trash
+func newTestTrash(fixtures ...tenantFixture) *handler.TrashHandler {
+	trash := stubTrashRepo{
+		sessions: map[uuid.UUID]*model.Session{},
+		spaces:   map[uuid.UUID]*model.Space{},
+		disks:    map[uuid.UUID]*model.Disk{},
+	}
+	for _, f := range fixtures {
+		trash.sessions[f.session.ID] = f.session
+		trash.spaces[f.space.ID] = f.space
+		trash.disks[f.disk.ID] = f.disk
+	}
+	return handler.NewTrashHandler(service.NewTrashService(trash, &config.Config{}, zap.NewNop()))
+}
+
+// newTenantTestRouter registers the project API for project, with handlers that have no
+// services: a request that gets past the tenant loaders panics and answers 500. Restores,
+// which do not go through the tenant loaders, are served by trash.
+func newTenantTestRouter(project *model.Project, tenant *middleware.Tenant, trash *handler.TrashHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+	v1 := r.Group("/api/v1")
+	v1.Use(func(c *gin.Context) {
+		c.Set("project", project)
+		c.Next()
+	})
+	registerProjectRoutes(v1, RouterDeps{
+		SpaceHandler:    &handler.SpaceHandler{},
+		BlockHandler:    &handler.BlockHandler{},
+		SessionHandler:  &handler.SessionHandler{},
+		EventHandler:    &handler.SessionEventHandler{},
+		DiskHandler:     &handler.DiskHandler{},
+		ArtifactHandler: &handler.ArtifactHandler{},
+		UploadHandler:   &handler.ArtifactUploadHandler{},
+		ShareHandler:    &handler.ShareHandler{},
+		TaskHandler:     &handler.TaskHandler{},
+		ToolHandler:     &handler.ToolHandler{},
+		UsageHandler:    &handler.UsageHandler{},
+		AuditHandler:    &handler.AuditHandler{},
+		WebhookHandler:  &handler.WebhookHandler{},
+		ConfigHandler:   &handler.ConfigHandler{},
+		TrashHandler:    trash,
+		Tenant:          tenant,
+		RateLimit:       middleware.NewRateLimit(&config.Config{}, nil, zap.NewNop()),
+		Idempotency:     middleware.NewIdempotency(&config.Config{}, nil, zap.NewNop()),
+	})
+	return r
+}
+
+// scopedParams are the route params that name a resource of a project
+var scopedParams = []string{":session_id", ":space_id", ":disk_id", ":block_id"}
+
+// fillRoute replaces the params of a route path with the IDs in ids, and any other param with a random ID
+func fillRoute(path string, ids map[string]string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		if id, ok := ids[segment]; ok {
+			segments[i] = id
+		} else {
+			segments[i] = uuid.NewString()
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func TestProjectRoutes_CrossProjectAccess(t *testing.T) {
+	own, other := newTenantFixture(), newTenantFixture()
+	r := newTenantTestRouter(own.project, newTestTenant(own, other), newTestTrash(own, other))
+
+	cases := []struct {
+		name           string
+		ids            map[string]string
+		only           string // when set, only routes with this param are checked
+		expectedStatus int
+	}{
+		{
+			name: "resources of another project",
+			ids: map[string]string{
+				":session_id": other.session.ID.String(),
+				":space_id":   other.space.ID.String(),
+				":disk_id":    other.disk.ID.String(),
+				":block_id":   other.block.ID.String(),
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "block of another project in an own space",
+			ids: map[string]string{
+				":space_id": own.space.ID.String(),
+				":block_id": other.block.ID.String(),
+			},
+			only:           ":block_id",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "unknown resources",
+			ids: map[string]string{
+				":session_id": uuid.NewString(),
+				":space_id":   uuid.NewString(),
+				":disk_id":    uuid.NewString(),
+				":block_id":   uuid.NewString(),
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "invalid IDs",
+			ids: map[string]string{
+				":session_id": "not-a-uuid",
+				":space_id":   "not-a-uuid",
+				":disk_id":    "not-a-uuid",
+				":block_id":   "not-a-uuid",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			checked := 0
+			for _, route := range r.Routes() {
+				scoped := false
+				for _, param := range scopedParams {
+					if _, ok := tc.ids[param]; ok && strings.Contains(route.Path, param) {
+						scoped = true
+					}
+				}
+				if !scoped || !strings.Contains(route.Path, tc.only) {
+					continue
+				}
+				checked++
+
+				path := fillRoute(route.Path, tc.ids)
+				req := httptest.NewRequest(route.Method, path, nil)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				assert.Equal(t, tc.expectedStatus, w.Code, "%s %s", route.Method, route.Path)
+			}
+			require.NotZero(t, checked)
+		})
+	}
+}
+
+func TestProjectRoutes_RestoreOwnTrash(t *testing.T) {
+	own, other := newTenantFixture(), newTenantFixture()
+	// Resources in the trash are not found by the tenant loaders
+	r := newTenantTestRouter(own.project, newTestTenant(), newTestTrash(own, other))
+
+	for _, path := range []string{
+		"/api/v1/session/" + own.session.ID.String() + "/restore",
+		"/api/v1/space/" + own.space.ID.String() + "/restore",
+		"/api/v1/disk/" + own.disk.ID.String() + "/restore",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+}
+
+func TestTenant_LoadsOwnResources(t *testing.T) {
+	own, other := newTenantFixture(), newTenantFixture()
+	tenant := newTestTenant(own, other)
+
+	tests := []struct {
+		name     string
+		route    string
+		path     string
+		loaders  []gin.HandlerFunc
+		key      string
+		expected any
+	}{
+		{
+			name:     "session",
+			route:    "/session/:session_id",
+			path:     "/session/" + own.session.ID.String(),
+			loaders:  []gin.HandlerFunc{tenant.Session()},
+			key:      "session",
+			expected: own.session,
+		},
+		{
+			name:     "space",
+			route:    "/space/:space_id",
+			path:     "/space/" + own.space.ID.String(),
+			loaders:  []gin.HandlerFunc{tenant.Space()},
+			key:      "space",
+			expected: own.space,
+		},
+		{
+			name:     "disk",
+			route:    "/disk/:disk_id",
+			path:     "/disk/" + own.disk.ID.String(),
+			loaders:  []gin.HandlerFunc{tenant.Disk()},
+			key:      "disk",
+			expected: own.disk,
+		},
+		{
+			name:     "block",
+			route:    "/space/:space_id/block/:block_id",
+			path:     "/space/" + own.space.ID.String() + "/block/" + own.block.ID.String(),
+			loaders:  []gin.HandlerFunc{tenant.Space(), tenant.Block()},
+			key:      "block",
+			expected: own.block,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set("project", own.project)
+				c.Next()
+			})
+			var loaded any
+			handlers := append(tt.loaders, func(c *gin.Context) {
+				loaded = c.MustGet(tt.key)
+				c.Status(http.StatusNoContent)
+			})
+			r.GET(tt.route, handlers...)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, tt.expected, loaded)
+		})
+	}
+}